@@ -11,14 +11,20 @@ import (
 
 	"trusioo_api_v0.0.1/internal/config"
 	"trusioo_api_v0.0.1/internal/infrastructure/database"
+	"trusioo_api_v0.0.1/internal/infrastructure/geoip"
+	"trusioo_api_v0.0.1/internal/infrastructure/mailer"
+	"trusioo_api_v0.0.1/internal/infrastructure/pwned"
 	"trusioo_api_v0.0.1/internal/infrastructure/redis"
 	"trusioo_api_v0.0.1/internal/infrastructure/router"
+	"trusioo_api_v0.0.1/internal/infrastructure/sms"
 	"trusioo_api_v0.0.1/pkg/cryptoutil"
 
 	"trusioo_api_v0.0.1/internal/modules/auth"
 	"trusioo_api_v0.0.1/internal/modules/auth/admin"
 	"trusioo_api_v0.0.1/internal/modules/auth/user"
 	"trusioo_api_v0.0.1/internal/modules/health"
+	"trusioo_api_v0.0.1/internal/modules/kyc"
+	"trusioo_api_v0.0.1/internal/modules/privacy"
 	"trusioo_api_v0.0.1/internal/modules/user_management"
 	"trusioo_api_v0.0.1/internal/modules/wallet"
 
@@ -137,23 +143,121 @@ func setupServices(routerEngine *router.Router, db *database.Database, redisClie
 	// 初始化JWT管理器（带数据库支持）
 	jwtManager := auth.NewJWTManager(&cfg.JWT, db, logger)
 
-	// 初始化密码加密器
-	passwordEncryptor := cryptoutil.NewPasswordEncryptor(cfg.PasswordEncrypt.Key, cfg.PasswordEncrypt.Method)
+	// 使用非对称算法时初始化签名密钥管理器（支持轮换）
+	if cfg.JWT.Algorithm != auth.AlgorithmHS256 {
+		keyManager, err := auth.NewKeyManager(&cfg.JWT, db, logger)
+		if err != nil {
+			logger.WithError(err).Fatal("Failed to create jwt key manager")
+		}
+
+		initCtx, initCancel := context.WithTimeout(context.Background(), 30*time.Second)
+		if err := keyManager.Init(initCtx); err != nil {
+			initCancel()
+			logger.WithError(err).Fatal("Failed to initialize jwt signing keys")
+		}
+		initCancel()
+
+		keyManager.Start(context.Background())
+		jwtManager.SetKeyManager(keyManager)
+
+		if cfg.JWT.AcceptHS256 {
+			logger.Warn("JWT_ACCEPT_HS256 is enabled: tokens signed with the shared secret are still accepted, disable it once the migration window has passed")
+		}
+	}
+
+	// 初始化邮件发送器（日志驱动会把验证码等邮件正文写入日志，只允许在开发环境使用）
+	if !cfg.IsDevelopment() && (cfg.Mail.Driver == "" || cfg.Mail.Driver == "log") {
+		logger.WithField("env", cfg.App.Env).Fatal("The log mail driver is only allowed in development, configure MAIL_DRIVER=smtp")
+	}
+	mailSender, err := mailer.New(&cfg.Mail, logger)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to initialize mailer")
+	}
+
+	// 初始化短信发送器（日志驱动会把手机验证码写入日志，只允许在开发环境使用）
+	if !cfg.IsDevelopment() && (cfg.SMS.Driver == "" || cfg.SMS.Driver == "log") {
+		logger.WithField("env", cfg.App.Env).Fatal("The log sms driver is only allowed in development, configure SMS_DRIVER=twilio")
+	}
+	smsSender, err := sms.New(&cfg.SMS, logger)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to initialize sms sender")
+	}
 
-	// 初始化认证中间件
+	// 刷新令牌重放时通知用户
+	if cfg.Security.NotifyTokenReuse {
+		jwtManager.SetSecurityNotifier(auth.NewMailSecurityNotifier(mailSender, logger))
+	}
+
+	// 初始化密码加密器
+	if !cryptoutil.IsValidMethod(cfg.PasswordEncrypt.Method) {
+		logger.WithField("method", cfg.PasswordEncrypt.Method).Fatal("Unsupported password encryption method")
+	}
+	passwordEncryptor := cryptoutil.NewPasswordEncryptorWithConfig(&cryptoutil.PasswordConfig{
+		EncryptionKey:     cfg.PasswordEncrypt.Key,
+		Method:            cfg.PasswordEncrypt.Method,
+		BcryptCost:        cfg.PasswordEncrypt.BcryptCost,
+		Argon2Memory:      uint32(cfg.PasswordEncrypt.Argon2Memory),
+		Argon2Iterations:  uint32(cfg.PasswordEncrypt.Argon2Iterations),
+		Argon2Parallelism: uint8(cfg.PasswordEncrypt.Argon2Parallelism),
+	})
+
+	// 初始化访问令牌撤销列表
+	jwtManager.SetRevocationStore(auth.NewTokenRevocationStore(redisClient, cfg.JWT.ExpireDuration, logger))
+
+	// 初始化认证中间件（管理员接口按角色权限控制）
+	permissionStore := auth.NewPermissionStore(db, logger)
 	authMiddle := auth.NewAuthMiddleware(jwtManager, logger)
+	authMiddle.SetPermissionStore(permissionStore)
+
+	// 初始化登录风险评分（GeoIP数据库可选）
+	geoDB, err := geoip.Open(cfg.Risk.GeoIPDatabase, logger)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to load GeoIP database")
+	}
+	riskScorer := auth.NewLoginRiskScorer(db, geoDB, &cfg.Risk, logger)
+
+	// 初始化登录失败锁定
+	loginLockout := auth.NewLoginLockout(redisClient, &cfg.Lockout, logger)
+
+	// 初始化密码策略（离线泄露密码库可选）
+	breachedPasswords, err := pwned.Open(cfg.PasswordPolicy.BreachedDir, logger)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to load breached password list")
+	}
+	passwordPolicy := auth.NewPasswordPolicy(db, breachedPasswords, passwordEncryptor, &cfg.PasswordPolicy, logger)
+
+	// 初始化验证码仓储（验证码和一次性令牌以带密钥哈希保存），并改写升级前的明文和摘要记录
+	verifyRepo := user.NewVerificationRepository(db, cryptoutil.NewSecretHasher(cfg.Security.CodeHashKey), logger)
+	migrateCtx, migrateCancel := context.WithTimeout(context.Background(), 60*time.Second)
+	hashed, err := verifyRepo.HashPlaintextSecrets(migrateCtx)
+	migrateCancel()
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to hash plaintext verification codes")
+	}
+	if hashed > 0 {
+		logger.WithField("count", hashed).Info("Hashed plaintext verification codes and legacy token digests")
+	}
+
+	// 注册JWKS公开密钥端点
+	auth.NewJWKSHandler(jwtManager, logger).RegisterRoutes(routerEngine.Engine)
 
 	// 设置健康检查模块
 	setupHealthModule(routerEngine, db, redisClient, logger)
 
 	// 设置认证模块
-	setupAuthModules(routerEngine, db, jwtManager, authMiddle, passwordEncryptor, logger)
+	userService := setupAuthModules(routerEngine, db, verifyRepo, jwtManager, authMiddle, permissionStore, riskScorer, loginLockout, mailSender, smsSender, passwordEncryptor, passwordPolicy, cfg, logger)
+
+	// 设置账户注销和个人数据导出模块
+	privacyService := setupPrivacyModule(routerEngine, db, userService, verifyRepo, jwtManager, authMiddle, mailSender, &cfg.Privacy, logger)
 
 	// 设置用户管理模块
-	setupUserManagementModule(routerEngine, db, jwtManager, authMiddle, passwordEncryptor, logger)
+	userMgmtService := setupUserManagementModule(routerEngine, db, userService, privacyService, jwtManager, authMiddle, loginLockout, mailSender, passwordEncryptor, passwordPolicy, &cfg.UserManagement, logger)
 
 	// 设置钱包模块
-	setupWalletModule(routerEngine, db, jwtManager, authMiddle, passwordEncryptor, logger)
+	walletService := setupWalletModule(routerEngine, db, jwtManager, authMiddle, passwordEncryptor, userMgmtService, logger)
+
+	// 设置KYC身份认证模块
+	setupKYCModule(routerEngine, db, authMiddle, mailSender, walletService, &cfg.KYC, logger)
 }
 
 // setupHealthModule 设置健康检查模块
@@ -169,26 +273,26 @@ func setupHealthModule(routerEngine *router.Router, db *database.Database, redis
 	logger.Info("Health check module initialized")
 }
 
-// setupAuthModules 设置认证模块
-func setupAuthModules(routerEngine *router.Router, db *database.Database, jwtManager *auth.JWTManager, authMiddle *auth.AuthMiddleware, passwordEncryptor *cryptoutil.PasswordEncryptor, logger *logrus.Logger) {
+// setupAuthModules 设置认证模块，返回用户认证服务供其他模块复用
+func setupAuthModules(routerEngine *router.Router, db *database.Database, verifyRepo *user.VerificationRepository, jwtManager *auth.JWTManager, authMiddle *auth.AuthMiddleware, permissionStore *auth.PermissionStore, riskScorer *auth.LoginRiskScorer, loginLockout *auth.LoginLockout, mailSender mailer.Mailer, smsSender sms.Sender, passwordEncryptor *cryptoutil.PasswordEncryptor, passwordPolicy *auth.PasswordPolicy, cfg *config.Config, logger *logrus.Logger) *user.Service {
 	// 获取API v1路由分组
 	v1Group := routerEngine.GetV1Group()
 	authGroup := v1Group.Group("/auth")
 
 	// 设置管理员认证模块
-	setupAdminAuth(authGroup, db, jwtManager, authMiddle, passwordEncryptor, logger)
+	setupAdminAuth(authGroup, db, verifyRepo, jwtManager, authMiddle, permissionStore, mailSender, passwordEncryptor, passwordPolicy, &cfg.Admin, logger)
 
 	// 设置用户认证模块
-	setupUserAuth(authGroup, db, jwtManager, authMiddle, passwordEncryptor, logger)
+	userService := setupUserAuth(authGroup, db, verifyRepo, jwtManager, authMiddle, riskScorer, loginLockout, mailSender, smsSender, passwordEncryptor, passwordPolicy, &cfg.Security, &cfg.Profile, logger)
 
 	logger.Info("Auth modules initialized")
+	return userService
 }
 
 // setupAdminAuth 设置管理员认证模块
-func setupAdminAuth(authGroup *gin.RouterGroup, db *database.Database, jwtManager *auth.JWTManager, authMiddle *auth.AuthMiddleware, passwordEncryptor *cryptoutil.PasswordEncryptor, logger *logrus.Logger) {
+func setupAdminAuth(authGroup *gin.RouterGroup, db *database.Database, verifyRepo *user.VerificationRepository, jwtManager *auth.JWTManager, authMiddle *auth.AuthMiddleware, permissionStore *auth.PermissionStore, mailSender mailer.Mailer, passwordEncryptor *cryptoutil.PasswordEncryptor, passwordPolicy *auth.PasswordPolicy, adminCfg *config.AdminConfig, logger *logrus.Logger) {
 	adminRepo := admin.NewRepository(db, logger)
-	verifyRepo := user.NewVerificationRepository(db, logger)
-	adminService := admin.NewService(adminRepo, verifyRepo, passwordEncryptor, logger)
+	adminService := admin.NewService(adminRepo, verifyRepo, passwordEncryptor, passwordPolicy, permissionStore, mailSender, adminCfg, logger)
 	adminHandler := admin.NewHandler(adminService, jwtManager, logger)
 	adminRoutes := admin.NewRoutes(adminHandler, authMiddle)
 
@@ -196,43 +300,77 @@ func setupAdminAuth(authGroup *gin.RouterGroup, db *database.Database, jwtManage
 	logger.Info("Admin auth module initialized")
 }
 
-// setupUserAuth 设置用户认证模块
-func setupUserAuth(authGroup *gin.RouterGroup, db *database.Database, jwtManager *auth.JWTManager, authMiddle *auth.AuthMiddleware, passwordEncryptor *cryptoutil.PasswordEncryptor, logger *logrus.Logger) {
+// setupUserAuth 设置用户认证模块，返回用户认证服务
+func setupUserAuth(authGroup *gin.RouterGroup, db *database.Database, verifyRepo *user.VerificationRepository, jwtManager *auth.JWTManager, authMiddle *auth.AuthMiddleware, riskScorer *auth.LoginRiskScorer, loginLockout *auth.LoginLockout, mailSender mailer.Mailer, smsSender sms.Sender, passwordEncryptor *cryptoutil.PasswordEncryptor, passwordPolicy *auth.PasswordPolicy, securityCfg *config.SecurityConfig, profileCfg *config.ProfileConfig, logger *logrus.Logger) *user.Service {
 	userRepo := user.NewRepository(db, logger)
-	verifyRepo := user.NewVerificationRepository(db, logger)
-	userService := user.NewService(userRepo, verifyRepo, passwordEncryptor, logger)
-	userHandler := user.NewHandler(userService, jwtManager, logger)
+	userService := user.NewService(userRepo, verifyRepo, passwordEncryptor, passwordPolicy, mailSender, logger)
+	userService.SetLoginLockout(loginLockout)
+	if securityCfg.NotifyNewSignIn {
+		userService.SetLoginAlertNotifier(auth.NewMailSecurityNotifier(mailSender, logger), securityCfg)
+	}
+	if securityCfg.MagicLinkEnabled {
+		userService.SetMagicLinkConfig(securityCfg)
+	}
+	userService.SetEmailChangeConfig(securityCfg)
+	userService.SetProfileConfig(profileCfg, smsSender)
+	userHandler := user.NewHandler(userService, jwtManager, riskScorer, logger)
 	userRoutes := user.NewRoutes(userHandler, authMiddle)
 
 	userRoutes.RegisterRoutes(authGroup)
 	logger.Info("User auth module initialized")
+	return userService
 }
 
-// setupUserManagementModule 设置用户管理模块
-func setupUserManagementModule(routerEngine *router.Router, db *database.Database, _ *auth.JWTManager, authMiddle *auth.AuthMiddleware, passwordEncryptor *cryptoutil.PasswordEncryptor, logger *logrus.Logger) {
+// setupPrivacyModule 设置账户注销和个人数据导出模块，并启动后台任务（生成导出包、匿名化到期账户）
+func setupPrivacyModule(routerEngine *router.Router, db *database.Database, userService *user.Service, verifyRepo *user.VerificationRepository, jwtManager *auth.JWTManager, authMiddle *auth.AuthMiddleware, mailSender mailer.Mailer, privacyCfg *config.PrivacyConfig, logger *logrus.Logger) *privacy.Service {
+	// 获取API v1路由分组
+	v1Group := routerEngine.GetV1Group()
+
+	privacyRepo := privacy.NewRepository(db, logger)
+	privacyService := privacy.NewService(privacyRepo, user.NewRepository(db, logger), userService, verifyRepo, jwtManager, mailSender, privacyCfg, logger)
+	privacyService.Start(context.Background())
+	privacyHandler := privacy.NewHandler(privacyService, logger)
+	privacyRoutes := privacy.NewRoutes(privacyHandler, authMiddle)
+
+	// 注册路由
+	privacyRoutes.RegisterRoutes(v1Group)
+	logger.Info("Privacy module initialized")
+	return privacyService
+}
+
+// setupUserManagementModule 设置用户管理模块，返回用户管理服务作为用户活动观察者供其他模块使用
+func setupUserManagementModule(routerEngine *router.Router, db *database.Database, userService *user.Service, privacyService *privacy.Service, jwtManager *auth.JWTManager, authMiddle *auth.AuthMiddleware, loginLockout *auth.LoginLockout, mailSender mailer.Mailer, passwordEncryptor *cryptoutil.PasswordEncryptor, passwordPolicy *auth.PasswordPolicy, userMgmtConfig *config.UserManagementConfig, logger *logrus.Logger) *user_management.Service {
 	// 获取API v1路由分组
 	v1Group := routerEngine.GetV1Group()
 
 	// 初始化用户管理模块的依赖
 	userRepo := user.NewRepository(db, logger) // 复用用户仓储
 	userMgmtRepo := user_management.NewRepository(db, logger)
-	userMgmtService := user_management.NewService(userMgmtRepo, userRepo, passwordEncryptor, logger)
+	userMgmtRepo.SetAuditLogKey(userMgmtConfig.AuditLogKey)
+	userMgmtService := user_management.NewService(userMgmtRepo, userRepo, userService, privacyService, passwordEncryptor, passwordPolicy, jwtManager, loginLockout, userMgmtConfig, logger)
+	userMgmtService.SetMailer(mailSender)
+	userMgmtService.Start(context.Background()) // 到期暂停自动解除、执行批量操作任务
+	userService.SetActivityObserver(userMgmtService) // 关注用户登录时通知管理员
+	authMiddle.SetImpersonationAuditor(userMgmtService) // 校验并审计管理员模拟登录令牌
 	userMgmtHandler := user_management.NewHandler(userMgmtService, logger)
 	userMgmtRoutes := user_management.NewRoutes(userMgmtHandler, authMiddle)
 
 	// 注册路由
 	userMgmtRoutes.RegisterRoutes(v1Group)
 	logger.Info("User management module initialized")
+
+	return userMgmtService
 }
 
-// setupWalletModule 设置钱包模块
-func setupWalletModule(routerEngine *router.Router, db *database.Database, _ *auth.JWTManager, authMiddle *auth.AuthMiddleware, passwordEncryptor *cryptoutil.PasswordEncryptor, logger *logrus.Logger) {
+// setupWalletModule 设置钱包模块，返回钱包服务作为KYC等级变更观察者
+func setupWalletModule(routerEngine *router.Router, db *database.Database, _ *auth.JWTManager, authMiddle *auth.AuthMiddleware, passwordEncryptor *cryptoutil.PasswordEncryptor, activityObserver auth.UserActivityObserver, logger *logrus.Logger) wallet.Service {
 	// 获取API v1路由分组
 	v1Group := routerEngine.GetV1Group()
 
 	// 初始化钱包模块组件
 	walletRepo := wallet.NewRepository(db, logger)
 	walletService := wallet.NewService(walletRepo, passwordEncryptor, logger)
+	walletService.SetActivityObserver(activityObserver) // 关注用户添加银行账户、申请提现时通知管理员
 	walletHandler := wallet.NewHandler(walletService, logger)
 	walletRoutes := wallet.NewRoutes(walletHandler, authMiddle)
 
@@ -240,5 +378,23 @@ func setupWalletModule(routerEngine *router.Router, db *database.Database, _ *au
 	walletRoutes.RegisterRoutes(v1Group)
 
 	logger.Info("Wallet module initialized")
+	return walletService
+}
+
+// setupKYCModule 设置KYC身份认证模块（人工审核，钱包提现按用户当前等级检查限额）
+func setupKYCModule(routerEngine *router.Router, db *database.Database, authMiddle *auth.AuthMiddleware, mailSender mailer.Mailer, walletService wallet.Service, kycCfg *config.KYCConfig, logger *logrus.Logger) {
+	// 获取API v1路由分组
+	v1Group := routerEngine.GetV1Group()
+
+	kycRepo := kyc.NewRepository(db, logger)
+	kycService := kyc.NewService(kycRepo, user.NewRepository(db, logger), kyc.NewManualProvider(), mailSender, kycCfg, logger)
+	kycService.SetTierObserver(walletService)
+	walletService.SetKYCLimitsProvider(kycService)
+	kycHandler := kyc.NewHandler(kycService, logger)
+	kycRoutes := kyc.NewRoutes(kycHandler, authMiddle)
+
+	// 注册路由
+	kycRoutes.RegisterRoutes(v1Group)
+	logger.Info("KYC module initialized")
 }
 
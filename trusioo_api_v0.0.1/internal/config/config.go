@@ -21,6 +21,16 @@ type Config struct {
 	Log             LogConfig                `json:"log"`
 	Security        SecurityConfig           `json:"security"`
 	Health          HealthConfig             `json:"health"`
+	Mail            MailConfig               `json:"mail"`
+	SMS             SMSConfig                `json:"sms"`
+	Admin           AdminConfig              `json:"admin"`
+	Risk            RiskConfig               `json:"risk"`
+	Lockout         LockoutConfig            `json:"lockout"`
+	PasswordPolicy  PasswordPolicyConfig     `json:"password_policy"`
+	Privacy         PrivacyConfig            `json:"privacy"`
+	UserManagement  UserManagementConfig     `json:"user_management"`
+	KYC             KYCConfig                `json:"kyc"`
+	Profile         ProfileConfig            `json:"profile"`
 }
 
 // AppConfig 应用程序基础配置
@@ -64,14 +74,22 @@ type JWTConfig struct {
 	Secret                string        `json:"secret" env:"JWT_SECRET" default:"your-super-secret-jwt-key"`
 	ExpireHours           int           `json:"expire_hours" env:"JWT_EXPIRE_HOURS" default:"24"`
 	RefreshExpireHours    int           `json:"refresh_expire_hours" env:"JWT_REFRESH_EXPIRE_HOURS" default:"168"`
+	Algorithm             string        `json:"algorithm" env:"JWT_ALGORITHM" default:"HS256"` // HS256, RS256, ES256, EdDSA
+	KeyRotationInterval   time.Duration `json:"key_rotation_interval" env:"JWT_KEY_ROTATION_INTERVAL" default:"720h"`
+	KeyGracePeriod        time.Duration `json:"key_grace_period" env:"JWT_KEY_GRACE_PERIOD" default:"192h"`
+	AcceptHS256           bool          `json:"accept_hs256" env:"JWT_ACCEPT_HS256" default:"false"` // 切换到非对称算法后是否仍接受旧的HS256令牌
 	ExpireDuration        time.Duration `json:"-"`
 	RefreshExpireDuration time.Duration `json:"-"`
 }
 
 // PasswordEncryptionConfig 密码加密配置
 type PasswordEncryptionConfig struct {
-	Key    string `json:"key" env:"PASSWORD_ENCRYPTION_KEY" default:"your-ultra-secret-password-encryption-key"`
-	Method string `json:"method" env:"PASSWORD_ENCRYPTION_METHOD" default:"hmac-bcrypt"`
+	Key               string `json:"key" env:"PASSWORD_ENCRYPTION_KEY" default:"your-ultra-secret-password-encryption-key"`
+	Method            string `json:"method" env:"PASSWORD_ENCRYPTION_METHOD" default:"hmac-bcrypt"`
+	BcryptCost        int    `json:"bcrypt_cost" env:"PASSWORD_BCRYPT_COST" default:"10"`
+	Argon2Memory      int    `json:"argon2_memory" env:"PASSWORD_ARGON2_MEMORY" default:"65536"` // KiB
+	Argon2Iterations  int    `json:"argon2_iterations" env:"PASSWORD_ARGON2_ITERATIONS" default:"3"`
+	Argon2Parallelism int    `json:"argon2_parallelism" env:"PASSWORD_ARGON2_PARALLELISM" default:"2"`
 }
 
 // LogConfig 日志配置
@@ -87,12 +105,22 @@ type LogConfig struct {
 
 // SecurityConfig 安全配置
 type SecurityConfig struct {
-	CORSAllowedOrigins   []string `json:"cors_allowed_origins"`
-	CORSAllowedMethods   []string `json:"cors_allowed_methods"`
-	CORSAllowedHeaders   []string `json:"cors_allowed_headers"`
-	CORSAllowCredentials bool     `json:"cors_allow_credentials" env:"CORS_ALLOW_CREDENTIALS" default:"true"`
-	RateLimitRPM         int      `json:"rate_limit_rpm" env:"RATE_LIMIT_RPM" default:"100"`
-	RateLimitWindow      int      `json:"rate_limit_window" env:"RATE_LIMIT_WINDOW" default:"1"`
+	CORSAllowedOrigins   []string      `json:"cors_allowed_origins"`
+	CORSAllowedMethods   []string      `json:"cors_allowed_methods"`
+	CORSAllowedHeaders   []string      `json:"cors_allowed_headers"`
+	CORSAllowCredentials bool          `json:"cors_allow_credentials" env:"CORS_ALLOW_CREDENTIALS" default:"true"`
+	RateLimitRPM         int           `json:"rate_limit_rpm" env:"RATE_LIMIT_RPM" default:"100"`
+	RateLimitWindow      int           `json:"rate_limit_window" env:"RATE_LIMIT_WINDOW" default:"1"`
+	NotifyTokenReuse     bool          `json:"notify_token_reuse" env:"SECURITY_NOTIFY_TOKEN_REUSE" default:"true"`
+	NotifyNewSignIn      bool          `json:"notify_new_sign_in" env:"SECURITY_NOTIFY_NEW_SIGNIN" default:"true"`
+	LoginAlertURL        string        `json:"login_alert_url" env:"SECURITY_LOGIN_ALERT_URL" default:"http://localhost:3000/account/secure"` // “不是我本人”页面地址
+	LoginAlertTTL        time.Duration `json:"login_alert_ttl" env:"SECURITY_LOGIN_ALERT_TTL" default:"168h"`
+	MagicLinkEnabled     bool          `json:"magic_link_enabled" env:"SECURITY_MAGIC_LINK_ENABLED" default:"true"`
+	MagicLinkURL         string        `json:"magic_link_url" env:"SECURITY_MAGIC_LINK_URL" default:"http://localhost:3000/login/magic"` // 登录链接落地页地址
+	MagicLinkTTL         time.Duration `json:"magic_link_ttl" env:"SECURITY_MAGIC_LINK_TTL" default:"15m"`
+	CodeHashKey          string        `json:"-" env:"SECURITY_CODE_HASH_KEY" default:"your-verification-code-hash-key"` // 验证码和一次性令牌入库前的HMAC密钥
+	EmailRevertURL       string        `json:"email_revert_url" env:"SECURITY_EMAIL_REVERT_URL" default:"http://localhost:3000/account/email/revert"` // 邮箱变更撤销页面地址
+	EmailRevertTTL       time.Duration `json:"email_revert_ttl" env:"SECURITY_EMAIL_REVERT_TTL" default:"168h"`
 }
 
 // HealthConfig 健康检查配置
@@ -101,6 +129,113 @@ type HealthConfig struct {
 	Timeout       time.Duration `json:"timeout" env:"HEALTH_CHECK_TIMEOUT" default:"5s"`
 }
 
+// MailConfig 邮件服务配置
+type MailConfig struct {
+	Driver   string `json:"driver" env:"MAIL_DRIVER" default:"log"` // log, smtp
+	Host     string `json:"host" env:"SMTP_HOST" default:""`
+	Port     string `json:"port" env:"SMTP_PORT" default:"587"`
+	Username string `json:"username" env:"SMTP_USERNAME" default:""`
+	Password string `json:"-" env:"SMTP_PASSWORD" default:""`
+	From     string `json:"from" env:"SMTP_FROM" default:"noreply@trusioo.com"`
+}
+
+// SMSConfig 短信发送配置
+type SMSConfig struct {
+	Driver           string `json:"driver" env:"SMS_DRIVER" default:"log"` // log, twilio
+	From             string `json:"from" env:"SMS_FROM" default:""`        // 发送方号码或发送者ID
+	TwilioAccountSID string `json:"twilio_account_sid" env:"SMS_TWILIO_ACCOUNT_SID" default:""`
+	TwilioAuthToken  string `json:"-" env:"SMS_TWILIO_AUTH_TOKEN" default:""`
+}
+
+// AdminConfig 管理员账户配置
+type AdminConfig struct {
+	InvitationURL string        `json:"invitation_url" env:"ADMIN_INVITATION_URL" default:"http://localhost:3000/admin/accept-invitation"` // 邀请邮件中的接受页面地址
+	InvitationTTL time.Duration `json:"invitation_ttl" env:"ADMIN_INVITATION_TTL" default:"72h"`
+}
+
+// RiskConfig 登录风险评估配置
+type RiskConfig struct {
+	Enabled                bool          `json:"enabled" env:"RISK_SCORING_ENABLED" default:"true"`
+	GeoIPDatabase          string        `json:"geoip_database" env:"RISK_GEOIP_DATABASE" default:""`             // 离线GeoIP数据文件(CSV)
+	ChallengeThreshold     int           `json:"challenge_threshold" env:"RISK_CHALLENGE_THRESHOLD" default:"50"` // 达到该分数需要额外验证
+	BlockThreshold         int           `json:"block_threshold" env:"RISK_BLOCK_THRESHOLD" default:"80"`         // 达到该分数直接拒绝登录
+	MaxTravelSpeedKmh      int           `json:"max_travel_speed_kmh" env:"RISK_MAX_TRAVEL_SPEED_KMH" default:"900"`
+	FailedAttemptWindow    time.Duration `json:"failed_attempt_window" env:"RISK_FAILED_ATTEMPT_WINDOW" default:"15m"`
+	FailedAttemptThreshold int           `json:"failed_attempt_threshold" env:"RISK_FAILED_ATTEMPT_THRESHOLD" default:"3"`
+}
+
+// LockoutConfig 登录失败锁定配置（阈值不大于0表示不启用该维度）
+type LockoutConfig struct {
+	Enabled            bool          `json:"enabled" env:"LOGIN_LOCKOUT_ENABLED" default:"true"`
+	AccountThreshold   int           `json:"account_threshold" env:"LOGIN_LOCKOUT_ACCOUNT_THRESHOLD" default:"10"`
+	IPThreshold        int           `json:"ip_threshold" env:"LOGIN_LOCKOUT_IP_THRESHOLD" default:"30"`
+	IPAccountThreshold int           `json:"ip_account_threshold" env:"LOGIN_LOCKOUT_IP_ACCOUNT_THRESHOLD" default:"5"`
+	Window             time.Duration `json:"window" env:"LOGIN_LOCKOUT_WINDOW" default:"15m"`
+	BaseDuration       time.Duration `json:"base_duration" env:"LOGIN_LOCKOUT_BASE_DURATION" default:"1m"`
+	MaxDuration        time.Duration `json:"max_duration" env:"LOGIN_LOCKOUT_MAX_DURATION" default:"24h"`
+	LevelTTL           time.Duration `json:"level_ttl" env:"LOGIN_LOCKOUT_LEVEL_TTL" default:"24h"` // 多久没有再次锁定后退避时长重置
+}
+
+// PasswordPolicyConfig 密码策略配置
+type PasswordPolicyConfig struct {
+	MinLength           int           `json:"min_length" env:"PASSWORD_MIN_LENGTH" default:"8"`
+	MaxLength           int           `json:"max_length" env:"PASSWORD_MAX_LENGTH" default:"128"`
+	MinCharacterClasses int           `json:"min_character_classes" env:"PASSWORD_MIN_CHARACTER_CLASSES" default:"3"` // 大写、小写、数字、特殊字符中至少包含几类
+	BannedWords         []string      `json:"banned_words" env:"PASSWORD_BANNED_WORDS" default:"password,trusioo"`    // 密码中不得包含的词（不区分大小写）
+	HistorySize         int           `json:"history_size" env:"PASSWORD_HISTORY_SIZE" default:"5"`                   // 不得重复使用最近几次的密码，0表示不检查
+	AdminMaxAge         time.Duration `json:"admin_max_age" env:"PASSWORD_ADMIN_MAX_AGE" default:"2160h"`             // 管理员密码最长有效期，0表示不过期
+	BreachedDir         string        `json:"breached_dir" env:"PASSWORD_BREACHED_DIR" default:""`                    // 离线泄露密码库目录，留空则不检查
+	BreachedMinCount    int           `json:"breached_min_count" env:"PASSWORD_BREACHED_MIN_COUNT" default:"1"`       // 泄露次数达到该值才拒绝
+}
+
+// PrivacyConfig 账户注销和个人数据导出配置
+type PrivacyConfig struct {
+	DeletionGracePeriod time.Duration `json:"deletion_grace_period" env:"PRIVACY_DELETION_GRACE_PERIOD" default:"720h"`             // 注销冷静期，期满后匿名化个人数据
+	RestoreURL          string        `json:"restore_url" env:"PRIVACY_RESTORE_URL" default:"http://localhost:3000/account/restore"` // 恢复账户页面地址（链接会附带token参数）
+	ExportDir           string        `json:"export_dir" env:"PRIVACY_EXPORT_DIR" default:"./storage/exports"`                        // 数据导出文件目录
+	ExportTTL           time.Duration `json:"export_ttl" env:"PRIVACY_EXPORT_TTL" default:"168h"`                                     // 导出文件保留时长
+	WorkerInterval      time.Duration `json:"worker_interval" env:"PRIVACY_WORKER_INTERVAL" default:"1m"`                             // 后台任务轮询间隔
+}
+
+// UserManagementConfig 用户管理配置
+type UserManagementConfig struct {
+	BatchApprovalThreshold int `json:"batch_approval_threshold" env:"USER_BATCH_APPROVAL_THRESHOLD" default:"100"` // 批量操作超过该用户数需超级管理员审批
+	BatchMaxUsers          int `json:"batch_max_users" env:"USER_BATCH_MAX_USERS" default:"10000"`                 // 单个批量操作最多涉及的用户数
+
+	ExportDir        string        `json:"export_dir" env:"USER_EXPORT_DIR" default:"./storage/user-exports"`      // 用户导出文件目录
+	ExportTTL        time.Duration `json:"export_ttl" env:"USER_EXPORT_TTL" default:"1h"`                          // 下载链接有效期，过期后删除文件
+	ExportSigningKey string        `json:"-" env:"USER_EXPORT_SIGNING_KEY" default:"your-user-export-signing-key"` // 下载链接签名密钥
+
+	AuditLogKey string `json:"-" env:"USER_AUDIT_LOG_KEY" default:"your-user-audit-log-key"` // 管理操作日志哈希链和检查点的HMAC密钥
+
+	StatsTimezones      []string      `json:"stats_timezones" env:"USER_STATS_TIMEZONES" default:"UTC"`             // 预先汇总统计数据的时区，其他时区实时计算
+	StatsRollupInterval time.Duration `json:"stats_rollup_interval" env:"USER_STATS_ROLLUP_INTERVAL" default:"15m"` // 统计汇总刷新间隔
+
+	ImpersonationTTL    time.Duration `json:"impersonation_ttl" env:"USER_IMPERSONATION_TTL" default:"15m"`             // 管理员模拟登录令牌有效期
+	ImpersonationNotify bool          `json:"impersonation_notify" env:"USER_IMPERSONATION_NOTIFY_USER" default:"true"` // 请求未指定时是否邮件通知被模拟的用户
+}
+
+// KYCConfig KYC身份认证配置
+type KYCConfig struct {
+	DocumentDir         string `json:"document_dir" env:"KYC_DOCUMENT_DIR" default:"./storage/kyc"`        // 证件文件存储目录
+	MaxDocumentSize     int64  `json:"max_document_size" env:"KYC_MAX_DOCUMENT_SIZE" default:"10485760"`   // 单个证件文件大小上限（字节）
+	MaxPendingDocuments int    `json:"max_pending_documents" env:"KYC_MAX_PENDING_DOCUMENTS" default:"10"` // 每个用户未提交审核的证件文件数上限
+
+	Tier1DailyWithdrawalLimit float64 `json:"tier1_daily_withdrawal_limit" env:"KYC_TIER1_DAILY_WITHDRAWAL_LIMIT" default:"100000"`  // 1级认证每日提现限额（TRU）
+	Tier2DailyWithdrawalLimit float64 `json:"tier2_daily_withdrawal_limit" env:"KYC_TIER2_DAILY_WITHDRAWAL_LIMIT" default:"1000000"` // 2级认证每日提现限额（TRU）
+}
+
+// ProfileConfig 用户个人资料配置
+type ProfileConfig struct {
+	AvatarDir     string `json:"avatar_dir" env:"PROFILE_AVATAR_DIR" default:"./storage/avatars"` // 头像文件存储目录
+	AvatarMaxSize int64  `json:"avatar_max_size" env:"PROFILE_AVATAR_MAX_SIZE" default:"5242880"` // 上传头像原图大小上限（字节）
+	AvatarSize    int    `json:"avatar_size" env:"PROFILE_AVATAR_SIZE" default:"256"`             // 头像裁剪为正方形后缩放到的边长（像素）
+
+	Languages      []string      `json:"languages" env:"PROFILE_LANGUAGES" default:"en,zh-CN"`        // 用户可选择的首选语言
+	PhoneCodeTTL   time.Duration `json:"phone_code_ttl" env:"PROFILE_PHONE_CODE_TTL" default:"10m"`   // 手机验证码有效期
+	PhoneCodeLimit int           `json:"phone_code_limit" env:"PROFILE_PHONE_CODE_LIMIT" default:"5"` // 每个用户每小时最多发送的手机验证码数
+}
+
 
 // Load 加载配置
 func Load() (*Config, error) {
@@ -149,17 +284,25 @@ func Load() (*Config, error) {
 
 	// 加载JWT配置
 	cfg.JWT = JWTConfig{
-		Secret:             getEnv("JWT_SECRET", "your-super-secret-jwt-key"),
-		ExpireHours:        getEnvAsInt("JWT_EXPIRE_HOURS", 24),
-		RefreshExpireHours: getEnvAsInt("JWT_REFRESH_EXPIRE_HOURS", 168),
+		Secret:              getEnv("JWT_SECRET", "your-super-secret-jwt-key"),
+		ExpireHours:         getEnvAsInt("JWT_EXPIRE_HOURS", 24),
+		RefreshExpireHours:  getEnvAsInt("JWT_REFRESH_EXPIRE_HOURS", 168),
+		Algorithm:           getEnv("JWT_ALGORITHM", "HS256"),
+		KeyRotationInterval: getEnvAsDuration("JWT_KEY_ROTATION_INTERVAL", 720*time.Hour),
+		KeyGracePeriod:      getEnvAsDuration("JWT_KEY_GRACE_PERIOD", 192*time.Hour),
+		AcceptHS256:         getEnvAsBool("JWT_ACCEPT_HS256", false),
 	}
 	cfg.JWT.ExpireDuration = time.Duration(cfg.JWT.ExpireHours) * time.Hour
 	cfg.JWT.RefreshExpireDuration = time.Duration(cfg.JWT.RefreshExpireHours) * time.Hour
 
 	// 加载密码加密配置
 	cfg.PasswordEncrypt = PasswordEncryptionConfig{
-		Key:    getEnv("PASSWORD_ENCRYPTION_KEY", "your-ultra-secret-password-encryption-key"),
-		Method: getEnv("PASSWORD_ENCRYPTION_METHOD", "hmac-bcrypt"),
+		Key:               getEnv("PASSWORD_ENCRYPTION_KEY", "your-ultra-secret-password-encryption-key"),
+		Method:            getEnv("PASSWORD_ENCRYPTION_METHOD", "hmac-bcrypt"),
+		BcryptCost:        getEnvAsInt("PASSWORD_BCRYPT_COST", 10),
+		Argon2Memory:      getEnvAsInt("PASSWORD_ARGON2_MEMORY", 64*1024),
+		Argon2Iterations:  getEnvAsInt("PASSWORD_ARGON2_ITERATIONS", 3),
+		Argon2Parallelism: getEnvAsInt("PASSWORD_ARGON2_PARALLELISM", 2),
 	}
 
 	// 加载日志配置
@@ -181,6 +324,16 @@ func Load() (*Config, error) {
 		CORSAllowCredentials: getEnvAsBool("CORS_ALLOW_CREDENTIALS", true),
 		RateLimitRPM:         getEnvAsInt("RATE_LIMIT_RPM", 100),
 		RateLimitWindow:      getEnvAsInt("RATE_LIMIT_WINDOW", 1),
+		NotifyTokenReuse:     getEnvAsBool("SECURITY_NOTIFY_TOKEN_REUSE", true),
+		NotifyNewSignIn:      getEnvAsBool("SECURITY_NOTIFY_NEW_SIGNIN", true),
+		LoginAlertURL:        getEnv("SECURITY_LOGIN_ALERT_URL", "http://localhost:3000/account/secure"),
+		LoginAlertTTL:        getEnvAsDuration("SECURITY_LOGIN_ALERT_TTL", 7*24*time.Hour),
+		MagicLinkEnabled:     getEnvAsBool("SECURITY_MAGIC_LINK_ENABLED", true),
+		MagicLinkURL:         getEnv("SECURITY_MAGIC_LINK_URL", "http://localhost:3000/login/magic"),
+		MagicLinkTTL:         getEnvAsDuration("SECURITY_MAGIC_LINK_TTL", 15*time.Minute),
+		CodeHashKey:          getEnv("SECURITY_CODE_HASH_KEY", "your-verification-code-hash-key"),
+		EmailRevertURL:       getEnv("SECURITY_EMAIL_REVERT_URL", "http://localhost:3000/account/email/revert"),
+		EmailRevertTTL:       getEnvAsDuration("SECURITY_EMAIL_REVERT_TTL", 168*time.Hour),
 	}
 
 	// 加载健康检查配置
@@ -189,6 +342,104 @@ func Load() (*Config, error) {
 		Timeout:       getEnvAsDuration("HEALTH_CHECK_TIMEOUT", 5*time.Second),
 	}
 
+	// 加载邮件配置
+	cfg.Mail = MailConfig{
+		Driver:   getEnv("MAIL_DRIVER", "log"),
+		Host:     getEnv("SMTP_HOST", ""),
+		Port:     getEnv("SMTP_PORT", "587"),
+		Username: getEnv("SMTP_USERNAME", ""),
+		Password: getEnv("SMTP_PASSWORD", ""),
+		From:     getEnv("SMTP_FROM", "noreply@trusioo.com"),
+	}
+
+	// 加载短信配置
+	cfg.SMS = SMSConfig{
+		Driver:           getEnv("SMS_DRIVER", "log"),
+		From:             getEnv("SMS_FROM", ""),
+		TwilioAccountSID: getEnv("SMS_TWILIO_ACCOUNT_SID", ""),
+		TwilioAuthToken:  getEnv("SMS_TWILIO_AUTH_TOKEN", ""),
+	}
+
+	// 加载管理员账户配置
+	cfg.Admin = AdminConfig{
+		InvitationURL: getEnv("ADMIN_INVITATION_URL", "http://localhost:3000/admin/accept-invitation"),
+		InvitationTTL: getEnvAsDuration("ADMIN_INVITATION_TTL", 72*time.Hour),
+	}
+
+	// 加载登录风险评估配置
+	cfg.Risk = RiskConfig{
+		Enabled:                getEnvAsBool("RISK_SCORING_ENABLED", true),
+		GeoIPDatabase:          getEnv("RISK_GEOIP_DATABASE", ""),
+		ChallengeThreshold:     getEnvAsInt("RISK_CHALLENGE_THRESHOLD", 50),
+		BlockThreshold:         getEnvAsInt("RISK_BLOCK_THRESHOLD", 80),
+		MaxTravelSpeedKmh:      getEnvAsInt("RISK_MAX_TRAVEL_SPEED_KMH", 900),
+		FailedAttemptWindow:    getEnvAsDuration("RISK_FAILED_ATTEMPT_WINDOW", 15*time.Minute),
+		FailedAttemptThreshold: getEnvAsInt("RISK_FAILED_ATTEMPT_THRESHOLD", 3),
+	}
+
+	// 加载登录锁定配置
+	cfg.Lockout = LockoutConfig{
+		Enabled:            getEnvAsBool("LOGIN_LOCKOUT_ENABLED", true),
+		AccountThreshold:   getEnvAsInt("LOGIN_LOCKOUT_ACCOUNT_THRESHOLD", 10),
+		IPThreshold:        getEnvAsInt("LOGIN_LOCKOUT_IP_THRESHOLD", 30),
+		IPAccountThreshold: getEnvAsInt("LOGIN_LOCKOUT_IP_ACCOUNT_THRESHOLD", 5),
+		Window:             getEnvAsDuration("LOGIN_LOCKOUT_WINDOW", 15*time.Minute),
+		BaseDuration:       getEnvAsDuration("LOGIN_LOCKOUT_BASE_DURATION", time.Minute),
+		MaxDuration:        getEnvAsDuration("LOGIN_LOCKOUT_MAX_DURATION", 24*time.Hour),
+		LevelTTL:           getEnvAsDuration("LOGIN_LOCKOUT_LEVEL_TTL", 24*time.Hour),
+	}
+
+	// 加载密码策略配置
+	cfg.PasswordPolicy = PasswordPolicyConfig{
+		MinLength:           getEnvAsInt("PASSWORD_MIN_LENGTH", 8),
+		MaxLength:           getEnvAsInt("PASSWORD_MAX_LENGTH", 128),
+		MinCharacterClasses: getEnvAsInt("PASSWORD_MIN_CHARACTER_CLASSES", 3),
+		BannedWords:         getEnvAsSlice("PASSWORD_BANNED_WORDS", []string{"password", "trusioo"}),
+		HistorySize:         getEnvAsInt("PASSWORD_HISTORY_SIZE", 5),
+		AdminMaxAge:         getEnvAsDuration("PASSWORD_ADMIN_MAX_AGE", 90*24*time.Hour),
+		BreachedDir:         getEnv("PASSWORD_BREACHED_DIR", ""),
+		BreachedMinCount:    getEnvAsInt("PASSWORD_BREACHED_MIN_COUNT", 1),
+	}
+
+	// 加载账户注销和数据导出配置
+	cfg.Privacy = PrivacyConfig{
+		DeletionGracePeriod: getEnvAsDuration("PRIVACY_DELETION_GRACE_PERIOD", 30*24*time.Hour),
+		RestoreURL:          getEnv("PRIVACY_RESTORE_URL", "http://localhost:3000/account/restore"),
+		ExportDir:           getEnv("PRIVACY_EXPORT_DIR", "./storage/exports"),
+		ExportTTL:           getEnvAsDuration("PRIVACY_EXPORT_TTL", 7*24*time.Hour),
+		WorkerInterval:      getEnvAsDuration("PRIVACY_WORKER_INTERVAL", time.Minute),
+	}
+
+	cfg.UserManagement = UserManagementConfig{
+		BatchApprovalThreshold: getEnvAsInt("USER_BATCH_APPROVAL_THRESHOLD", 100),
+		BatchMaxUsers:          getEnvAsInt("USER_BATCH_MAX_USERS", 10000),
+		ExportDir:              getEnv("USER_EXPORT_DIR", "./storage/user-exports"),
+		ExportTTL:              getEnvAsDuration("USER_EXPORT_TTL", time.Hour),
+		ExportSigningKey:       getEnv("USER_EXPORT_SIGNING_KEY", "your-user-export-signing-key"),
+		AuditLogKey:            getEnv("USER_AUDIT_LOG_KEY", "your-user-audit-log-key"),
+		StatsTimezones:         getEnvAsSlice("USER_STATS_TIMEZONES", []string{"UTC"}),
+		StatsRollupInterval:    getEnvAsDuration("USER_STATS_ROLLUP_INTERVAL", 15*time.Minute),
+		ImpersonationTTL:       getEnvAsDuration("USER_IMPERSONATION_TTL", 15*time.Minute),
+		ImpersonationNotify:    getEnvAsBool("USER_IMPERSONATION_NOTIFY_USER", true),
+	}
+
+	cfg.KYC = KYCConfig{
+		DocumentDir:               getEnv("KYC_DOCUMENT_DIR", "./storage/kyc"),
+		MaxDocumentSize:           int64(getEnvAsInt("KYC_MAX_DOCUMENT_SIZE", 10<<20)),
+		MaxPendingDocuments:       getEnvAsInt("KYC_MAX_PENDING_DOCUMENTS", 10),
+		Tier1DailyWithdrawalLimit: getEnvAsFloat("KYC_TIER1_DAILY_WITHDRAWAL_LIMIT", 100000),
+		Tier2DailyWithdrawalLimit: getEnvAsFloat("KYC_TIER2_DAILY_WITHDRAWAL_LIMIT", 1000000),
+	}
+
+	cfg.Profile = ProfileConfig{
+		AvatarDir:      getEnv("PROFILE_AVATAR_DIR", "./storage/avatars"),
+		AvatarMaxSize:  int64(getEnvAsInt("PROFILE_AVATAR_MAX_SIZE", 5<<20)),
+		AvatarSize:     getEnvAsInt("PROFILE_AVATAR_SIZE", 256),
+		Languages:      getEnvAsSlice("PROFILE_LANGUAGES", []string{"en", "zh-CN"}),
+		PhoneCodeTTL:   getEnvAsDuration("PROFILE_PHONE_CODE_TTL", 10*time.Minute),
+		PhoneCodeLimit: getEnvAsInt("PROFILE_PHONE_CODE_LIMIT", 5),
+	}
+
 
 	return cfg, nil
 }
@@ -237,6 +488,14 @@ func getEnvAsInt(key string, fallback int) int {
 	return fallback
 }
 
+func getEnvAsFloat(key string, fallback float64) float64 {
+	strValue := getEnv(key, "")
+	if value, err := strconv.ParseFloat(strValue, 64); err == nil {
+		return value
+	}
+	return fallback
+}
+
 func getEnvAsBool(key string, fallback bool) bool {
 	strValue := getEnv(key, "")
 	if value, err := strconv.ParseBool(strValue); err == nil {
@@ -0,0 +1,186 @@
+package geoip
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Location IP地理位置信息
+type Location struct {
+	CountryCode string  `json:"country_code"`
+	City        string  `json:"city"`
+	Latitude    float64 `json:"latitude"`
+	Longitude   float64 `json:"longitude"`
+	ASN         uint32  `json:"asn"`
+	ASOrg       string  `json:"as_org"`
+}
+
+// ipRange 单个网段及其位置
+type ipRange struct {
+	start    net.IP // 16字节形式
+	end      net.IP // 16字节形式
+	location *Location
+}
+
+// Database 离线GeoIP数据库（内存中按起始地址排序，二分查找）
+//
+// 数据文件为CSV格式，每行一个互不重叠的网段：
+//
+//	network,country_code,city,latitude,longitude,asn,as_org
+//	1.0.0.0/24,AU,Sydney,-33.8688,151.2093,13335,CLOUDFLARENET
+//
+// 首行为表头时自动跳过，可由GeoLite2等CSV数据合并生成。
+type Database struct {
+	ranges []ipRange
+}
+
+// Open 加载离线GeoIP数据库，path为空时返回nil（所有查询均无结果）
+func Open(path string, logger *logrus.Logger) (*Database, error) {
+	if path == "" {
+		logger.Warn("GeoIP database not configured, location-based risk checks are disabled")
+		return nil, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open geoip database: %w", err)
+	}
+	defer file.Close()
+
+	db, err := Load(file)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.WithFields(logrus.Fields{
+		"path":     path,
+		"networks": len(db.ranges),
+	}).Info("GeoIP database loaded")
+
+	return db, nil
+}
+
+// Load 从CSV数据读取GeoIP数据库
+func Load(r io.Reader) (*Database, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	db := &Database{}
+	line := 0
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read geoip database: %w", err)
+		}
+		line++
+
+		if line == 1 && strings.EqualFold(strings.TrimSpace(record[0]), "network") {
+			continue
+		}
+
+		entry, err := parseRecord(record)
+		if err != nil {
+			return nil, fmt.Errorf("invalid geoip record on line %d: %w", line, err)
+		}
+		db.ranges = append(db.ranges, entry)
+	}
+
+	sort.Slice(db.ranges, func(i, j int) bool {
+		return bytes.Compare(db.ranges[i].start, db.ranges[j].start) < 0
+	})
+
+	return db, nil
+}
+
+// Lookup 查询IP的地理位置
+func (db *Database) Lookup(ip net.IP) (*Location, bool) {
+	if db == nil || ip == nil {
+		return nil, false
+	}
+
+	ip16 := ip.To16()
+	if ip16 == nil {
+		return nil, false
+	}
+
+	// 找到最后一个起始地址不大于ip的网段
+	i := sort.Search(len(db.ranges), func(i int) bool {
+		return bytes.Compare(db.ranges[i].start, ip16) > 0
+	}) - 1
+	if i < 0 {
+		return nil, false
+	}
+
+	entry := db.ranges[i]
+	if bytes.Compare(ip16, entry.end) > 0 {
+		return nil, false
+	}
+
+	return entry.location, true
+}
+
+// parseRecord 解析一行CSV记录
+func parseRecord(record []string) (ipRange, error) {
+	if len(record) < 5 {
+		return ipRange{}, fmt.Errorf("expected at least 5 fields, got %d", len(record))
+	}
+
+	_, network, err := net.ParseCIDR(strings.TrimSpace(record[0]))
+	if err != nil {
+		return ipRange{}, fmt.Errorf("invalid network: %w", err)
+	}
+
+	latitude, err := strconv.ParseFloat(strings.TrimSpace(record[3]), 64)
+	if err != nil {
+		return ipRange{}, fmt.Errorf("invalid latitude: %w", err)
+	}
+	longitude, err := strconv.ParseFloat(strings.TrimSpace(record[4]), 64)
+	if err != nil {
+		return ipRange{}, fmt.Errorf("invalid longitude: %w", err)
+	}
+
+	location := &Location{
+		CountryCode: strings.TrimSpace(record[1]),
+		City:        strings.TrimSpace(record[2]),
+		Latitude:    latitude,
+		Longitude:   longitude,
+	}
+
+	if len(record) > 5 && strings.TrimSpace(record[5]) != "" {
+		asn, err := strconv.ParseUint(strings.TrimPrefix(strings.TrimSpace(record[5]), "AS"), 10, 32)
+		if err != nil {
+			return ipRange{}, fmt.Errorf("invalid asn: %w", err)
+		}
+		location.ASN = uint32(asn)
+	}
+	if len(record) > 6 {
+		location.ASOrg = strings.TrimSpace(record[6])
+	}
+
+	start := network.IP.To16()
+	end := make(net.IP, len(start))
+	copy(end, start)
+
+	// 根据掩码计算网段结束地址
+	mask := network.Mask
+	offset := len(end) - len(mask)
+	for i := range mask {
+		end[offset+i] |= ^mask[i]
+	}
+
+	return ipRange{start: start, end: end, location: location}, nil
+}
@@ -0,0 +1,111 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"trusioo_api_v0.0.1/internal/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Message 邮件消息
+type Message struct {
+	To       []string
+	Subject  string
+	Body     string
+	Language string // 收件人首选语言（BCP 47），设置时写入Content-Language头
+}
+
+// Mailer 邮件发送接口
+type Mailer interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// New 根据配置创建邮件发送器
+func New(cfg *config.MailConfig, logger *logrus.Logger) (Mailer, error) {
+	switch cfg.Driver {
+	case "", "log":
+		return &logMailer{logger: logger}, nil
+	case "smtp":
+		if cfg.Host == "" {
+			return nil, fmt.Errorf("smtp host is required for smtp mail driver")
+		}
+		return &smtpMailer{config: cfg, logger: logger}, nil
+	default:
+		return nil, fmt.Errorf("unsupported mail driver: %s", cfg.Driver)
+	}
+}
+
+// ========== 日志邮件发送器（开发环境） ==========
+
+// logMailer 仅将邮件内容写入日志，不实际发送
+type logMailer struct {
+	logger *logrus.Logger
+}
+
+// Send 将邮件写入日志
+func (m *logMailer) Send(ctx context.Context, msg *Message) error {
+	m.logger.WithFields(logrus.Fields{
+		"to":       strings.Join(msg.To, ","),
+		"subject":  msg.Subject,
+		"language": msg.Language,
+		"body":     msg.Body,
+	}).Info("Mail delivered to log (log mail driver)")
+	return nil
+}
+
+// ========== SMTP邮件发送器 ==========
+
+// smtpMailer 通过SMTP发送邮件
+type smtpMailer struct {
+	config *config.MailConfig
+	logger *logrus.Logger
+}
+
+// Send 通过SMTP发送邮件
+func (m *smtpMailer) Send(ctx context.Context, msg *Message) error {
+	if len(msg.To) == 0 {
+		return fmt.Errorf("mail recipient is required")
+	}
+
+	addr := net.JoinHostPort(m.config.Host, m.config.Port)
+
+	var auth smtp.Auth
+	if m.config.Username != "" {
+		auth = smtp.PlainAuth("", m.config.Username, m.config.Password, m.config.Host)
+	}
+
+	var builder strings.Builder
+	builder.WriteString("From: " + m.config.From + "\r\n")
+	builder.WriteString("To: " + strings.Join(msg.To, ", ") + "\r\n")
+	builder.WriteString("Subject: " + msg.Subject + "\r\n")
+	builder.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	builder.WriteString("MIME-Version: 1.0\r\n")
+	builder.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	if msg.Language != "" {
+		builder.WriteString("Content-Language: " + msg.Language + "\r\n")
+	}
+	builder.WriteString("\r\n")
+	builder.WriteString(msg.Body)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(addr, auth, m.config.From, msg.To, []byte(builder.String()))
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			m.logger.WithError(err).WithField("subject", msg.Subject).Error("Failed to send mail")
+			return fmt.Errorf("failed to send mail: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("failed to send mail: %w", ctx.Err())
+	}
+}
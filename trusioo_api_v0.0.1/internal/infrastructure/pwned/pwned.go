@@ -0,0 +1,104 @@
+package pwned
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// prefixLength k-匿名查询使用的SHA-1前缀长度
+const prefixLength = 5
+
+// Store 离线泄露密码库（k-匿名格式，按SHA-1前缀分文件存放）
+//
+// 目录中每个文件以SHA-1十六进制摘要的前5位命名（可带.txt后缀），
+// 每行记录一个剩余35位后缀及其出现次数，与range API的响应格式一致：
+//
+//	0018A45C4D1DEF81644B54AB7F969B88D65:10
+//	00D4F6E8FA6EECAD2A3AA415EEC418D38EC:2
+//
+// 查询时只读取对应前缀的文件，不需要加载整个数据集。
+type Store struct {
+	dir string
+}
+
+// Open 打开离线泄露密码库目录，dir为空时返回nil（所有查询均无结果）
+func Open(dir string, logger *logrus.Logger) (*Store, error) {
+	if dir == "" {
+		logger.Warn("Breached password list not configured, breached password checks are disabled")
+		return nil, nil
+	}
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open breached password directory: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("breached password path is not a directory: %s", dir)
+	}
+
+	logger.WithField("dir", dir).Info("Breached password list loaded")
+	return &Store{dir: dir}, nil
+}
+
+// Count 查询密码在泄露数据中出现的次数，未出现时返回0
+func (s *Store) Count(password string) (int, error) {
+	if s == nil {
+		return 0, nil
+	}
+
+	sum := sha1.Sum([]byte(password))
+	digest := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := digest[:prefixLength], digest[prefixLength:]
+
+	file, err := s.openRange(prefix)
+	if err != nil {
+		return 0, err
+	}
+	if file == nil {
+		return 0, nil
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		hashSuffix, count, found := strings.Cut(line, ":")
+		if !found || !strings.EqualFold(hashSuffix, suffix) {
+			continue
+		}
+
+		n, err := strconv.Atoi(strings.TrimSpace(count))
+		if err != nil {
+			return 0, fmt.Errorf("invalid count in breached password range %s: %w", prefix, err)
+		}
+		return n, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read breached password range %s: %w", prefix, err)
+	}
+
+	return 0, nil
+}
+
+// openRange 打开前缀对应的文件，文件不存在时返回nil
+func (s *Store) openRange(prefix string) (*os.File, error) {
+	for _, name := range []string{prefix + ".txt", prefix, strings.ToLower(prefix) + ".txt", strings.ToLower(prefix)} {
+		file, err := os.Open(filepath.Join(s.dir, name))
+		if err == nil {
+			return file, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to open breached password range %s: %w", prefix, err)
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,110 @@
+package sms
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"trusioo_api_v0.0.1/internal/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Message 短信消息
+type Message struct {
+	To   string // E.164格式手机号
+	Body string
+}
+
+// Sender 短信发送接口
+type Sender interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// New 根据配置创建短信发送器
+func New(cfg *config.SMSConfig, logger *logrus.Logger) (Sender, error) {
+	switch cfg.Driver {
+	case "", "log":
+		return &logSender{logger: logger}, nil
+	case "twilio":
+		if cfg.TwilioAccountSID == "" || cfg.TwilioAuthToken == "" || cfg.From == "" {
+			return nil, fmt.Errorf("twilio account sid, auth token and sender are required for twilio sms driver")
+		}
+		return &twilioSender{
+			config: cfg,
+			client: &http.Client{Timeout: 10 * time.Second},
+			logger: logger,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported sms driver: %s", cfg.Driver)
+	}
+}
+
+// ========== 日志短信发送器（开发环境） ==========
+
+// logSender 仅将短信内容写入日志，不实际发送
+type logSender struct {
+	logger *logrus.Logger
+}
+
+// Send 将短信写入日志
+func (s *logSender) Send(ctx context.Context, msg *Message) error {
+	s.logger.WithFields(logrus.Fields{
+		"to":   msg.To,
+		"body": msg.Body,
+	}).Info("SMS delivered to log (log sms driver)")
+	return nil
+}
+
+// ========== Twilio短信发送器 ==========
+
+// twilioMessagesURL Twilio发送短信接口地址
+const twilioMessagesURL = "https://api.twilio.com/2010-04-01/Accounts/%s/Messages.json"
+
+// twilioSender 通过Twilio REST API发送短信
+type twilioSender struct {
+	config *config.SMSConfig
+	client *http.Client
+	logger *logrus.Logger
+}
+
+// Send 通过Twilio发送短信
+func (s *twilioSender) Send(ctx context.Context, msg *Message) error {
+	if msg.To == "" {
+		return fmt.Errorf("sms recipient is required")
+	}
+
+	form := url.Values{}
+	form.Set("To", msg.To)
+	form.Set("From", s.config.From)
+	form.Set("Body", msg.Body)
+
+	endpoint := fmt.Sprintf(twilioMessagesURL, url.PathEscape(s.config.TwilioAccountSID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create sms request: %w", err)
+	}
+	req.SetBasicAuth(s.config.TwilioAccountSID, s.config.TwilioAuthToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send sms: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		s.logger.WithFields(logrus.Fields{
+			"status":   resp.StatusCode,
+			"response": string(body),
+		}).Error("Failed to send sms")
+		return fmt.Errorf("failed to send sms: twilio returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"context"
+	"time"
+)
+
+// 用户活动事件类型（管理员可关注用户的这些活动）
+const (
+	UserActivityLogin               = "login"                // 登录成功
+	UserActivityBankAccountAdded    = "bank_account_added"   // 添加银行账户
+	UserActivityWithdrawalRequested = "withdrawal_requested" // 申请提现
+)
+
+// UserActivityEvents 可关注的用户活动事件类型
+// 提现申请创建尚未实现，UserActivityWithdrawalRequested不会被触发，暂不开放关注
+var UserActivityEvents = []string{UserActivityLogin, UserActivityBankAccountAdded}
+
+// UserActivityEvent 用户活动事件
+type UserActivityEvent struct {
+	Type       string                 `json:"type"`
+	UserID     string                 `json:"user_id"`
+	Email      string                 `json:"email,omitempty"` // 为空时由观察者自行查询
+	IPAddress  string                 `json:"ip_address,omitempty"`
+	Details    map[string]interface{} `json:"details,omitempty"`
+	OccurredAt time.Time              `json:"occurred_at"`
+}
+
+// UserActivityObserver 用户活动观察者接口
+// 在请求处理流程中同步调用，实现需尽快返回，耗时操作应异步执行
+type UserActivityObserver interface {
+	OnUserActivity(ctx context.Context, event *UserActivityEvent)
+}
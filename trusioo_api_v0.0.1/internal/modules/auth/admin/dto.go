@@ -1,6 +1,8 @@
 package admin
 
 import (
+	"time"
+
 	"trusioo_api_v0.0.1/internal/modules/auth"
 )
 
@@ -47,14 +49,14 @@ type CreateAdminRequest struct {
 	Email    string `json:"email" binding:"required,email" example:"newadmin@example.com"`
 	Name     string `json:"name" binding:"required,min=2" example:"John Doe"`
 	Password string `json:"password" binding:"required,min=6" example:"password123"`
-	Role     string `json:"role" binding:"required,oneof=super_admin admin" example:"admin"`
+	Role     string `json:"role" binding:"required,max=50" example:"admin"`
 }
 
 // UpdateAdminRequest 更新管理员信息请求
 type UpdateAdminRequest struct {
 	Name   string `json:"name" binding:"omitempty,min=2" example:"Jane Doe"`
 	Email  string `json:"email" binding:"omitempty,email" example:"updated@example.com"`
-	Role   string `json:"role" binding:"omitempty,oneof=super_admin admin" example:"admin"`
+	Role   string `json:"role" binding:"omitempty,max=50" example:"admin"`
 	Active *bool  `json:"active" binding:"omitempty" example:"true"`
 }
 
@@ -62,18 +64,51 @@ type UpdateAdminRequest struct {
 type ListAdminsRequest struct {
 	Page     int    `form:"page" binding:"omitempty,min=1" example:"1"`
 	PageSize int    `form:"page_size" binding:"omitempty,min=1,max=100" example:"10"`
-	Role     string `form:"role" binding:"omitempty,oneof=super_admin admin" example:"admin"`
+	Role     string `form:"role" binding:"omitempty,max=50" example:"admin"`
 	Active   *bool  `form:"active" binding:"omitempty" example:"true"`
 	Search   string `form:"search" binding:"omitempty" example:"john"`
 }
 
+// InviteAdminRequest 邀请管理员请求
+type InviteAdminRequest struct {
+	Email string `json:"email" binding:"required,email" example:"newadmin@example.com"`
+	Name  string `json:"name" binding:"required,min=2" example:"John Doe"`
+	Role  string `json:"role" binding:"required,max=50" example:"admin"`
+}
+
+// AcceptInvitationRequest 接受邀请请求（受邀人设置密码）
+type AcceptInvitationRequest struct {
+	Token    string `json:"token" binding:"required" example:"k3J9..."`
+	Password string `json:"password" binding:"required,min=6" example:"password123"`
+	Name     string `json:"name" binding:"omitempty,min=2" example:"John Doe"`
+}
+
+// ChangeAdminRoleRequest 修改管理员角色请求
+type ChangeAdminRoleRequest struct {
+	Role string `json:"role" binding:"required,max=50" example:"admin"`
+}
+
+// CreateRoleRequest 创建角色请求
+type CreateRoleRequest struct {
+	Name        string   `json:"name" binding:"required,min=2,max=50" example:"finance"`
+	DisplayName string   `json:"display_name" binding:"required,min=2,max=100" example:"Finance"`
+	Description *string  `json:"description" binding:"omitempty,max=500" example:"Reviews and processes withdrawals"`
+	Permissions []string `json:"permissions" binding:"required" example:"withdrawal.view,withdrawal.review"`
+}
+
+// UpdateRoleRequest 更新角色请求（权限列表整体替换）
+type UpdateRoleRequest struct {
+	DisplayName string   `json:"display_name" binding:"omitempty,min=2,max=100" example:"Finance"`
+	Description *string  `json:"description" binding:"omitempty,max=500" example:"Reviews and processes withdrawals"`
+	Permissions []string `json:"permissions" binding:"required" example:"withdrawal.view,withdrawal.review"`
+}
+
 // ========== 响应 DTO ==========
 
 // LoginResponse 登录响应（发送验证码）
 type LoginResponse struct {
-	Message          string `json:"message" example:"Verification code sent"`
-	VerificationCode string `json:"verification_code" example:"123456"` // 仅用于测试，生产环境应删除
-	ExpiresIn        int    `json:"expires_in" example:"300"`
+	Message   string `json:"message" example:"Verification code sent"`
+	ExpiresIn int    `json:"expires_in" example:"300"`
 }
 
 // VerifyLoginResponse 验证登录响应
@@ -85,11 +120,12 @@ type VerifyLoginResponse struct {
 
 // AdminInfo 管理员信息结构（用于API响应）
 type AdminInfo struct {
-	ID     string `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
-	Email  string `json:"email" example:"admin@example.com"`
-	Name   string `json:"name" example:"John Doe"`
-	Role   string `json:"role" example:"admin"`
-	Active bool   `json:"active" example:"true"`
+	ID          string   `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Email       string   `json:"email" example:"admin@example.com"`
+	Name        string   `json:"name" example:"John Doe"`
+	Role        string   `json:"role" example:"admin"`
+	Active      bool     `json:"active" example:"true"`
+	Permissions []string `json:"permissions,omitempty" example:"user.view,wallet.view"`
 }
 
 // RefreshTokenResponse 刷新令牌响应
@@ -105,10 +141,9 @@ type ProfileResponse struct {
 
 // ForgotPasswordResponse 忘记密码响应
 type ForgotPasswordResponse struct {
-	Message          string `json:"message" example:"Password reset code sent"`
-	Email            string `json:"email" example:"admin@example.com"`
-	VerificationCode string `json:"verification_code" example:"123456"` // 仅用于测试，生产环境应删除
-	ExpiresIn        int    `json:"expires_in" example:"300"`
+	Message   string `json:"message" example:"Password reset code sent"`
+	Email     string `json:"email" example:"admin@example.com"`
+	ExpiresIn int    `json:"expires_in" example:"300"`
 }
 
 // ResetPasswordResponse 重置密码响应
@@ -152,6 +187,45 @@ type PaginationInfo struct {
 	TotalPages int   `json:"total_pages" example:"10"`
 }
 
+// InvitationInfo 管理员邀请信息
+type InvitationInfo struct {
+	ID        string    `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Email     string    `json:"email" example:"newadmin@example.com"`
+	Name      string    `json:"name" example:"John Doe"`
+	Role      string    `json:"role" example:"admin"`
+	Status    string    `json:"status" example:"pending"`
+	InvitedBy *string   `json:"invited_by" example:"550e8400-e29b-41d4-a716-446655440000"`
+	ExpiresAt time.Time `json:"expires_at" example:"2024-01-04T00:00:00Z"`
+	CreatedAt time.Time `json:"created_at" example:"2024-01-01T00:00:00Z"`
+}
+
+// InviteAdminResponse 邀请管理员响应
+type InviteAdminResponse struct {
+	Message    string          `json:"message" example:"Invitation sent successfully"`
+	Invitation *InvitationInfo `json:"invitation"`
+}
+
+// ListInvitationsResponse 邀请列表响应
+type ListInvitationsResponse struct {
+	Invitations []*InvitationInfo `json:"invitations"`
+}
+
+// RoleResponse 角色响应
+type RoleResponse struct {
+	Message string `json:"message,omitempty" example:"Role created successfully"`
+	Role    *Role  `json:"role"`
+}
+
+// ListRolesResponse 角色列表响应
+type ListRolesResponse struct {
+	Roles []*Role `json:"roles"`
+}
+
+// ListPermissionsResponse 权限列表响应
+type ListPermissionsResponse struct {
+	Permissions []auth.PermissionInfo `json:"permissions"`
+}
+
 // ========== 通用响应 DTO ==========
 
 // MessageResponse 通用消息响应
@@ -179,6 +253,20 @@ func (a *Admin) ToAdminInfo() *AdminInfo {
 	}
 }
 
+// ToInvitationInfo 将AdminInvitation模型转换为InvitationInfo DTO
+func (i *AdminInvitation) ToInvitationInfo() *InvitationInfo {
+	return &InvitationInfo{
+		ID:        i.ID,
+		Email:     i.Email,
+		Name:      i.Name,
+		Role:      i.Role,
+		Status:    i.Status(),
+		InvitedBy: i.InvitedBy,
+		ExpiresAt: i.ExpiresAt,
+		CreatedAt: i.CreatedAt,
+	}
+}
+
 // ToAdminListItem 将Admin模型转换为AdminListItem DTO
 func (a *Admin) ToAdminListItem() *AdminListItem {
 	return &AdminListItem{
@@ -2,6 +2,7 @@ package admin
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"time"
 
@@ -48,7 +49,7 @@ func (h *Handler) Login(c *gin.Context) {
 	ipAddress := c.ClientIP()
 
 	// 发送管理员登录验证码
-	verificationCode, err := h.service.SendLoginVerificationCode(ctx, req.Email, req.Password, ipAddress)
+	err := h.service.SendLoginVerificationCode(ctx, req.Email, req.Password, ipAddress)
 	if err != nil {
 		h.logger.WithFields(logrus.Fields{
 			"email": req.Email,
@@ -63,6 +64,9 @@ func (h *Handler) Login(c *gin.Context) {
 		case "too many verification attempts":
 			statusCode = http.StatusTooManyRequests
 			message = "Too many attempts, please try again later"
+		case auth.ErrPasswordExpired.Error():
+			statusCode = http.StatusForbidden
+			message = "Your password has expired, please reset it via forgot password"
 		}
 
 		c.JSON(statusCode, gin.H{
@@ -77,9 +81,8 @@ func (h *Handler) Login(c *gin.Context) {
 	}).Info("Admin login verification code sent")
 
 	c.JSON(http.StatusOK, LoginResponse{
-		Message:          "Verification code sent to your email",
-		VerificationCode: verificationCode, // 仅用于测试
-		ExpiresIn:        300,              // 5分钟
+		Message:   "Verification code sent to your email",
+		ExpiresIn: 300, // 5分钟
 	})
 }
 
@@ -116,6 +119,9 @@ func (h *Handler) VerifyLogin(c *gin.Context) {
 			message = "Too many attempts, please try again later"
 		case "admin not found":
 			message = "Invalid email or password"
+		case auth.ErrPasswordExpired.Error():
+			statusCode = http.StatusForbidden
+			message = "Your password has expired, please reset it via forgot password"
 		}
 
 		c.JSON(statusCode, gin.H{
@@ -166,6 +172,9 @@ func (h *Handler) RefreshToken(c *gin.Context) {
 
 	// 验证刷新令牌
 	claims, err := h.jwtManager.ValidateRefreshToken(req.RefreshToken)
+	if err == nil && claims.UserType != "admin" {
+		err = errors.New("refresh token does not belong to an admin")
+	}
 	if err != nil {
 		h.logger.WithError(err).Warn("Invalid refresh token")
 		c.JSON(http.StatusUnauthorized, gin.H{
@@ -186,13 +195,22 @@ func (h *Handler) RefreshToken(c *gin.Context) {
 		return
 	}
 
-	// 生成新的令牌对
-	newTokens, err := h.jwtManager.RefreshTokenPair(req.RefreshToken, admin.Email, admin.Role)
+	// 轮换令牌对（旧刷新令牌立即失效）
+	ipAddress := c.ClientIP()
+	newTokens, err := h.jwtManager.RefreshTokenPairWithRotation(ctx, req.RefreshToken, admin.Email, admin.Role, &ipAddress)
 	if err != nil {
-		h.logger.WithError(err).Error("Failed to refresh tokens")
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Internal server error",
-			"message": "Failed to refresh authentication tokens",
+		if errors.Is(err, auth.ErrRefreshTokenReused) {
+			h.logger.WithField("admin_id", admin.ID).Warn("Admin refresh token reuse detected")
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error":   "Invalid refresh token",
+				"message": "Refresh token has already been used, please log in again",
+			})
+			return
+		}
+		h.logger.WithError(err).Warn("Failed to refresh tokens")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "Invalid refresh token",
+			"message": "The provided refresh token is invalid or expired",
 		})
 		return
 	}
@@ -220,15 +238,17 @@ func (h *Handler) Logout(c *gin.Context) {
 		return
 	}
 
+	// 将当前访问令牌加入撤销列表
+	if err := h.jwtManager.RevokeAccessToken(c.Request.Context(), claims); err != nil {
+		h.logger.WithError(err).Warn("Failed to revoke admin access token on logout")
+	}
+
 	// 记录登出
 	h.logger.WithFields(logrus.Fields{
 		"admin_id": claims.UserID,
 		"email":    claims.Email,
 	}).Info("Admin logout")
 
-	// 在实际应用中，这里可以将令牌加入黑名单
-	// 或者在数据库中标记为已登出
-
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Logout successful",
 	})
@@ -258,12 +278,19 @@ func (h *Handler) GetProfile(c *gin.Context) {
 		return
 	}
 
+	// 当前角色的权限（供前端控制菜单和按钮）
+	permissions, err := h.service.GetRolePermissions(ctx, admin.Role)
+	if err != nil {
+		h.logger.WithError(err).Warn("Failed to get admin permissions")
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"admin": AdminInfo{
-			ID:    admin.ID,
-			Email: admin.Email,
-			Name:  admin.Name,
-			Role:  admin.Role,
+			ID:          admin.ID,
+			Email:       admin.Email,
+			Name:        admin.Name,
+			Role:        admin.Role,
+			Permissions: permissions,
 		},
 	})
 }
@@ -311,6 +338,14 @@ func (h *Handler) ChangePassword(c *gin.Context) {
 
 	// 更新密码
 	if err := h.service.UpdatePassword(ctx, admin.ID, req.NewPassword); err != nil {
+		if message, ok := auth.DescribePasswordError(err); ok {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Weak password",
+				"message": message,
+			})
+			return
+		}
+
 		h.logger.WithError(err).Error("Failed to update admin password")
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Internal server error",
@@ -319,6 +354,11 @@ func (h *Handler) ChangePassword(c *gin.Context) {
 		return
 	}
 
+	// 修改密码后使所有已签发的令牌失效
+	if err := h.jwtManager.RevokeAllUserTokens(ctx, admin.ID, "admin"); err != nil {
+		h.logger.WithError(err).Warn("Failed to revoke admin tokens after password change")
+	}
+
 	h.logger.WithFields(logrus.Fields{
 		"admin_id": admin.ID,
 		"email":    admin.Email,
@@ -348,7 +388,7 @@ func (h *Handler) ForgotPassword(c *gin.Context) {
 	ipAddress := c.ClientIP()
 
 	// 发送密码重置验证码
-	verificationCode, err := h.service.ForgotPassword(ctx, req.Email, ipAddress)
+	err := h.service.ForgotPassword(ctx, req.Email, ipAddress)
 	if err != nil {
 		h.logger.WithFields(logrus.Fields{
 			"email": req.Email,
@@ -381,10 +421,9 @@ func (h *Handler) ForgotPassword(c *gin.Context) {
 	}).Info("Password reset verification code sent")
 
 	c.JSON(http.StatusOK, ForgotPasswordResponse{
-		Message:          "Password reset code sent to your email",
-		Email:            req.Email,
-		VerificationCode: verificationCode, // 仅用于测试
-		ExpiresIn:        900,              // 15分钟
+		Message:   "Password reset code sent to your email",
+		Email:     req.Email,
+		ExpiresIn: 900, // 15分钟
 	})
 }
 
@@ -427,7 +466,11 @@ func (h *Handler) ResetPassword(c *gin.Context) {
 			statusCode = http.StatusTooManyRequests
 			message = "Too many attempts, please try again later"
 		default:
-			message = "Failed to reset password"
+			if policyMessage, ok := auth.DescribePasswordError(err); ok {
+				message = policyMessage
+			} else {
+				message = "Failed to reset password"
+			}
 		}
 
 		c.JSON(statusCode, gin.H{
@@ -437,6 +480,13 @@ func (h *Handler) ResetPassword(c *gin.Context) {
 		return
 	}
 
+	// 重置密码后使所有已签发的令牌失效
+	if admin, err := h.service.GetByEmail(ctx, req.Email); err == nil {
+		if err := h.jwtManager.RevokeAllUserTokens(ctx, admin.ID, "admin"); err != nil {
+			h.logger.WithError(err).Warn("Failed to revoke admin tokens after password reset")
+		}
+	}
+
 	h.logger.WithFields(logrus.Fields{
 		"email": req.Email,
 	}).Info("Password reset successfully")
@@ -445,3 +495,431 @@ func (h *Handler) ResetPassword(c *gin.Context) {
 		Message: "Password reset successfully",
 	})
 }
+
+// ========== 管理员账户管理 ==========
+
+// ListAdmins 获取管理员列表
+func (h *Handler) ListAdmins(c *gin.Context) {
+	var req ListAdminsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid list admins request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	admins, total, err := h.service.SearchAdmins(ctx, &req)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to list admins")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal server error",
+			"message": "Failed to retrieve admins",
+		})
+		return
+	}
+
+	items := make([]*AdminListItem, 0, len(admins))
+	for _, admin := range admins {
+		items = append(items, admin.ToAdminListItem())
+	}
+
+	c.JSON(http.StatusOK, ListAdminsResponse{
+		Admins:     items,
+		Pagination: CalculatePagination(req.Page, req.GetLimit(), total),
+	})
+}
+
+// GetAdmin 获取管理员详情
+func (h *Handler) GetAdmin(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	admin, err := h.service.GetAdmin(ctx, c.Param("admin_id"))
+	if err != nil {
+		h.respondAdminManagementError(c, err, "Failed to retrieve admin")
+		return
+	}
+
+	c.JSON(http.StatusOK, ProfileResponse{
+		Admin: admin.ToAdminInfo(),
+	})
+}
+
+// InviteAdmin 邀请新管理员
+func (h *Handler) InviteAdmin(c *gin.Context) {
+	var req InviteAdminRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid invite admin request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	operator, err := auth.GetCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+	defer cancel()
+
+	invitation, err := h.service.InviteAdmin(ctx, req.Email, req.Name, req.Role, operator.UserID, operator.Role)
+	if err != nil {
+		h.respondAdminManagementError(c, err, "Failed to send invitation")
+		return
+	}
+
+	c.JSON(http.StatusCreated, InviteAdminResponse{
+		Message:    "Invitation sent successfully",
+		Invitation: invitation.ToInvitationInfo(),
+	})
+}
+
+// ListInvitations 获取待接受的邀请
+func (h *Handler) ListInvitations(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	invitations, err := h.service.ListInvitations(ctx)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to list invitations")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal server error",
+			"message": "Failed to retrieve invitations",
+		})
+		return
+	}
+
+	items := make([]*InvitationInfo, 0, len(invitations))
+	for _, invitation := range invitations {
+		items = append(items, invitation.ToInvitationInfo())
+	}
+
+	c.JSON(http.StatusOK, ListInvitationsResponse{
+		Invitations: items,
+	})
+}
+
+// RevokeInvitation 撤销邀请
+func (h *Handler) RevokeInvitation(c *gin.Context) {
+	operatorID, _ := auth.GetCurrentUserID(c)
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.service.RevokeInvitation(ctx, c.Param("invitation_id"), operatorID); err != nil {
+		h.respondAdminManagementError(c, err, "Failed to revoke invitation")
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{
+		Message: "Invitation revoked successfully",
+	})
+}
+
+// AcceptInvitation 接受邀请并设置密码（公开接口，凭邀请令牌访问）
+func (h *Handler) AcceptInvitation(c *gin.Context) {
+	var req AcceptInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid accept invitation request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	admin, err := h.service.AcceptInvitation(ctx, req.Token, req.Password, req.Name)
+	if err != nil {
+		if errors.Is(err, auth.ErrInvitationNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid invitation",
+				"message": "The invitation link is invalid or has expired",
+			})
+			return
+		}
+		if message, ok := auth.DescribePasswordError(err); ok {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Weak password",
+				"message": message,
+			})
+			return
+		}
+		h.respondAdminManagementError(c, err, "Failed to accept invitation")
+		return
+	}
+
+	c.JSON(http.StatusCreated, CreateAdminResponse{
+		Message: "Invitation accepted, you can now log in",
+		Admin:   admin.ToAdminInfo(),
+	})
+}
+
+// DeactivateAdmin 停用管理员
+func (h *Handler) DeactivateAdmin(c *gin.Context) {
+	h.updateAdminAccount(c, "Admin deactivated successfully", h.service.DeactivateAdmin)
+}
+
+// ReactivateAdmin 重新启用管理员
+func (h *Handler) ReactivateAdmin(c *gin.Context) {
+	h.updateAdminAccount(c, "Admin reactivated successfully", h.service.ReactivateAdmin)
+}
+
+// ChangeAdminRole 修改管理员角色
+func (h *Handler) ChangeAdminRole(c *gin.Context) {
+	var req ChangeAdminRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid change admin role request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	h.updateAdminAccount(c, "Admin role updated successfully", func(ctx context.Context, adminID, operatorID, operatorRole string) (*Admin, error) {
+		return h.service.ChangeAdminRole(ctx, adminID, req.Role, operatorID, operatorRole)
+	})
+}
+
+// updateAdminAccount 执行管理员账户变更，成功后使该管理员已签发的令牌全部失效
+func (h *Handler) updateAdminAccount(c *gin.Context, successMessage string, update func(ctx context.Context, adminID, operatorID, operatorRole string) (*Admin, error)) {
+	operator, err := auth.GetCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	admin, err := update(ctx, c.Param("admin_id"), operator.UserID, operator.Role)
+	if err != nil {
+		h.respondAdminManagementError(c, err, "Failed to update admin")
+		return
+	}
+
+	// 角色和状态都写在令牌中，变更后要求重新登录
+	if err := h.jwtManager.RevokeAllUserTokens(ctx, admin.ID, "admin"); err != nil {
+		h.logger.WithError(err).Warn("Failed to revoke admin tokens after account change")
+	}
+
+	c.JSON(http.StatusOK, UpdateAdminResponse{
+		Message: successMessage,
+		Admin:   admin.ToAdminInfo(),
+	})
+}
+
+// respondAdminManagementError 根据管理员账户管理相关错误返回对应响应
+func (h *Handler) respondAdminManagementError(c *gin.Context, err error, fallback string) {
+	statusCode := http.StatusBadRequest
+	message := ""
+
+	switch {
+	case errors.Is(err, auth.ErrAdminNotFound):
+		statusCode = http.StatusNotFound
+		message = "Admin not found"
+	case errors.Is(err, auth.ErrInvitationNotFound):
+		statusCode = http.StatusNotFound
+		message = "Invitation not found"
+	case errors.Is(err, auth.ErrAdminExists):
+		statusCode = http.StatusConflict
+		message = "An admin with this email already exists"
+	case errors.Is(err, auth.ErrRoleNotFound):
+		message = "Role does not exist"
+	case errors.Is(err, auth.ErrCannotModifySelf):
+		message = "You cannot change your own admin account"
+	case errors.Is(err, auth.ErrLastSuperAdmin):
+		statusCode = http.StatusConflict
+		message = "At least one active super admin is required"
+	case errors.Is(err, auth.ErrPermissionDenied):
+		statusCode = http.StatusForbidden
+		message = "Only super admins can manage super admin accounts"
+	default:
+		h.logger.WithError(err).Error(fallback)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal server error",
+			"message": fallback,
+		})
+		return
+	}
+
+	c.JSON(statusCode, gin.H{
+		"error":   "Request failed",
+		"message": message,
+	})
+}
+
+// ========== 角色权限管理 ==========
+
+// ListPermissions 获取系统支持的全部权限
+func (h *Handler) ListPermissions(c *gin.Context) {
+	c.JSON(http.StatusOK, ListPermissionsResponse{
+		Permissions: h.service.ListPermissions(),
+	})
+}
+
+// ListRoles 获取全部角色
+func (h *Handler) ListRoles(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	roles, err := h.service.ListRoles(ctx)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to list roles")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal server error",
+			"message": "Failed to retrieve roles",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, ListRolesResponse{
+		Roles: roles,
+	})
+}
+
+// GetRole 获取角色详情
+func (h *Handler) GetRole(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	role, err := h.service.GetRole(ctx, c.Param("role_name"))
+	if err != nil {
+		h.respondRoleError(c, err, "Failed to retrieve role")
+		return
+	}
+
+	c.JSON(http.StatusOK, RoleResponse{
+		Role: role,
+	})
+}
+
+// CreateRole 创建角色
+func (h *Handler) CreateRole(c *gin.Context) {
+	var req CreateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid create role request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	operatorID, _ := auth.GetCurrentUserID(c)
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	role, err := h.service.CreateRole(ctx, req.Name, req.DisplayName, req.Description, req.Permissions, operatorID)
+	if err != nil {
+		h.respondRoleError(c, err, "Failed to create role")
+		return
+	}
+
+	c.JSON(http.StatusCreated, RoleResponse{
+		Message: "Role created successfully",
+		Role:    role,
+	})
+}
+
+// UpdateRole 更新角色
+func (h *Handler) UpdateRole(c *gin.Context) {
+	var req UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid update role request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	operatorID, _ := auth.GetCurrentUserID(c)
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	role, err := h.service.UpdateRole(ctx, c.Param("role_name"), req.DisplayName, req.Description, req.Permissions, operatorID)
+	if err != nil {
+		h.respondRoleError(c, err, "Failed to update role")
+		return
+	}
+
+	c.JSON(http.StatusOK, RoleResponse{
+		Message: "Role updated successfully",
+		Role:    role,
+	})
+}
+
+// DeleteRole 删除角色
+func (h *Handler) DeleteRole(c *gin.Context) {
+	operatorID, _ := auth.GetCurrentUserID(c)
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	if err := h.service.DeleteRole(ctx, c.Param("role_name"), operatorID); err != nil {
+		h.respondRoleError(c, err, "Failed to delete role")
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{
+		Message: "Role deleted successfully",
+	})
+}
+
+// respondRoleError 根据角色相关错误返回对应响应
+func (h *Handler) respondRoleError(c *gin.Context, err error, fallback string) {
+	statusCode := http.StatusBadRequest
+	message := err.Error()
+
+	switch {
+	case errors.Is(err, auth.ErrRoleNotFound):
+		statusCode = http.StatusNotFound
+		message = "Role not found"
+	case errors.Is(err, auth.ErrRoleExists):
+		statusCode = http.StatusConflict
+		message = "Role already exists"
+	case errors.Is(err, auth.ErrRoleInUse):
+		statusCode = http.StatusConflict
+		message = "Role is still assigned to admins"
+	case errors.Is(err, auth.ErrSystemRoleImmutable):
+		statusCode = http.StatusForbidden
+		message = "System role cannot be modified"
+	case errors.Is(err, auth.ErrInvalidRoleName):
+		message = "Role name must start with a lowercase letter and contain only lowercase letters, digits and underscores"
+	case errors.Is(err, auth.ErrInvalidPermission), errors.Is(err, auth.ErrPermissionNotGrantable):
+		// 保留具体的权限名
+	default:
+		h.logger.WithError(err).Error(fallback)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal server error",
+			"message": fallback,
+		})
+		return
+	}
+
+	c.JSON(statusCode, gin.H{
+		"error":   "Request failed",
+		"message": message,
+	})
+}
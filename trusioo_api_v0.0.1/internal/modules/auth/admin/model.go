@@ -15,6 +15,9 @@ type Admin struct {
 	Role     string `json:"role" db:"role"`     // 角色：super_admin, admin
 	Active   bool   `json:"active" db:"active"` // 是否激活
 
+	// 密码安全
+	PasswordChangedAt time.Time `json:"password_changed_at" db:"password_changed_at"` // 密码最近一次修改时间
+
 	// 审计字段
 	CreatedAt time.Time  `json:"created_at" db:"created_at"` // 创建时间
 	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"` // 更新时间
@@ -86,6 +89,64 @@ func GetValidAdminRoles() []AdminRole {
 	}
 }
 
+// Role 管理员角色定义（角色 → 权限）
+type Role struct {
+	ID          string    `json:"id" db:"id"`
+	Name        string    `json:"name" db:"name"`                 // 角色标识，对应admins.role
+	DisplayName string    `json:"display_name" db:"display_name"` // 显示名称
+	Description *string   `json:"description" db:"description"`
+	IsSystem    bool      `json:"is_system" db:"is_system"` // 系统内置角色不可删除
+	Permissions []string  `json:"permissions" db:"-"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// TableName 返回数据库表名
+func (Role) TableName() string {
+	return "admin_roles"
+}
+
+// AdminInvitation 管理员邀请记录
+type AdminInvitation struct {
+	ID         string     `json:"id" db:"id"`
+	Email      string     `json:"email" db:"email"`
+	Name       string     `json:"name" db:"name"`
+	Role       string     `json:"role" db:"role"`
+	TokenHash  string     `json:"-" db:"token_hash"` // 令牌摘要，明文不落库
+	InvitedBy  *string    `json:"invited_by" db:"invited_by"`
+	ExpiresAt  time.Time  `json:"expires_at" db:"expires_at"`
+	AcceptedAt *time.Time `json:"accepted_at" db:"accepted_at"`
+	RevokedAt  *time.Time `json:"revoked_at" db:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
+
+// 邀请状态常量
+const (
+	InvitationStatusPending  = "pending"
+	InvitationStatusAccepted = "accepted"
+	InvitationStatusRevoked  = "revoked"
+	InvitationStatusExpired  = "expired"
+)
+
+// TableName 返回数据库表名
+func (AdminInvitation) TableName() string {
+	return "admin_invitations"
+}
+
+// Status 获取邀请当前状态
+func (i *AdminInvitation) Status() string {
+	switch {
+	case i.AcceptedAt != nil:
+		return InvitationStatusAccepted
+	case i.RevokedAt != nil:
+		return InvitationStatusRevoked
+	case time.Now().After(i.ExpiresAt):
+		return InvitationStatusExpired
+	default:
+		return InvitationStatusPending
+	}
+}
+
 // LoginLog 管理员登录日志模型
 type LoginLog struct {
 	ID            string                  `json:"id" db:"id"`
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 
 	"trusioo_api_v0.0.1/internal/infrastructure/database"
 
@@ -48,14 +49,14 @@ func (r *Repository) Create(ctx context.Context, admin *Admin) error {
 // GetByID 根据ID获取管理员
 func (r *Repository) GetByID(ctx context.Context, id string) (*Admin, error) {
 	query := `
-		SELECT id, email, name, password, role, active, created_at, updated_at
+		SELECT id, email, name, password, role, active, password_changed_at, created_at, updated_at
 		FROM admins
 		WHERE id = $1 AND deleted_at IS NULL
 	`
 
 	admin := &Admin{}
 	err := r.GetDB().QueryRowContext(ctx, query, id).Scan(
-		&admin.ID, &admin.Email, &admin.Name, &admin.Password, &admin.Role, &admin.Active, &admin.CreatedAt, &admin.UpdatedAt)
+		&admin.ID, &admin.Email, &admin.Name, &admin.Password, &admin.Role, &admin.Active, &admin.PasswordChangedAt, &admin.CreatedAt, &admin.UpdatedAt)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -70,14 +71,14 @@ func (r *Repository) GetByID(ctx context.Context, id string) (*Admin, error) {
 // GetByEmail 根据邮箱获取管理员
 func (r *Repository) GetByEmail(ctx context.Context, email string) (*Admin, error) {
 	query := `
-		SELECT id, email, name, password, role, active, created_at, updated_at
+		SELECT id, email, name, password, role, active, password_changed_at, created_at, updated_at
 		FROM admins
 		WHERE email = $1 AND deleted_at IS NULL
 	`
 
 	admin := &Admin{}
 	err := r.GetDB().QueryRowContext(ctx, query, email).Scan(
-		&admin.ID, &admin.Email, &admin.Name, &admin.Password, &admin.Role, &admin.Active, &admin.CreatedAt, &admin.UpdatedAt)
+		&admin.ID, &admin.Email, &admin.Name, &admin.Password, &admin.Role, &admin.Active, &admin.PasswordChangedAt, &admin.CreatedAt, &admin.UpdatedAt)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -93,7 +94,7 @@ func (r *Repository) GetByEmail(ctx context.Context, email string) (*Admin, erro
 func (r *Repository) UpdatePassword(ctx context.Context, adminID, hashedPassword string) error {
 	query := `
 		UPDATE admins
-		SET password = $1, updated_at = NOW()
+		SET password = $1, password_changed_at = NOW(), updated_at = NOW()
 		WHERE id = $2 AND deleted_at IS NULL
 	`
 
@@ -114,6 +115,22 @@ func (r *Repository) UpdatePassword(ctx context.Context, adminID, hashedPassword
 	return nil
 }
 
+// UpgradePasswordHash 将管理员的密码哈希替换为按当前配置重新生成的哈希
+// 仅在哈希未被并发修改时更新，不影响密码修改时间等字段
+func (r *Repository) UpgradePasswordHash(ctx context.Context, adminID, oldHash, newHash string) error {
+	query := `
+		UPDATE admins
+		SET password = $1
+		WHERE id = $2 AND password = $3 AND deleted_at IS NULL
+	`
+
+	if _, err := r.GetDB().ExecContext(ctx, query, newHash, adminID, oldHash); err != nil {
+		return fmt.Errorf("failed to upgrade password hash: %w", err)
+	}
+
+	return nil
+}
+
 // UpdateStatus 更新管理员状态
 func (r *Repository) UpdateStatus(ctx context.Context, adminID string, active bool) error {
 	query := `
@@ -172,6 +189,109 @@ func (r *Repository) List(ctx context.Context, limit, offset int) ([]*Admin, err
 	return admins, nil
 }
 
+// Search 按条件分页查询管理员
+func (r *Repository) Search(ctx context.Context, role string, active *bool, search string, limit, offset int) ([]*Admin, int64, error) {
+	whereConditions := []string{"deleted_at IS NULL"}
+	args := []interface{}{}
+	argIndex := 1
+
+	if role != "" {
+		whereConditions = append(whereConditions, fmt.Sprintf("role = $%d", argIndex))
+		args = append(args, role)
+		argIndex++
+	}
+
+	if active != nil {
+		whereConditions = append(whereConditions, fmt.Sprintf("active = $%d", argIndex))
+		args = append(args, *active)
+		argIndex++
+	}
+
+	if search != "" {
+		whereConditions = append(whereConditions, fmt.Sprintf("(email ILIKE $%d OR name ILIKE $%d)", argIndex, argIndex))
+		args = append(args, "%"+search+"%")
+		argIndex++
+	}
+
+	whereClause := strings.Join(whereConditions, " AND ")
+
+	var total int64
+	countQuery := fmt.Sprintf(`SELECT COUNT(*) FROM admins WHERE %s`, whereClause)
+	if err := r.GetDB().QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count admins: %w", err)
+	}
+
+	dataQuery := fmt.Sprintf(`
+		SELECT id, email, name, role, active, created_at, updated_at
+		FROM admins
+		WHERE %s
+		ORDER BY created_at DESC
+		LIMIT $%d OFFSET $%d
+	`, whereClause, argIndex, argIndex+1)
+
+	args = append(args, limit, offset)
+
+	rows, err := r.GetDB().QueryContext(ctx, dataQuery, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search admins: %w", err)
+	}
+	defer rows.Close()
+
+	var admins []*Admin
+	for rows.Next() {
+		admin := &Admin{}
+		err := rows.Scan(&admin.ID, &admin.Email, &admin.Name, &admin.Role, &admin.Active, &admin.CreatedAt, &admin.UpdatedAt)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan admin: %w", err)
+		}
+		admins = append(admins, admin)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return admins, total, nil
+}
+
+// AssignRole 更新管理员角色
+func (r *Repository) AssignRole(ctx context.Context, adminID, role string) error {
+	query := `
+		UPDATE admins
+		SET role = $1, updated_at = NOW()
+		WHERE id = $2 AND deleted_at IS NULL
+	`
+
+	result, err := r.GetDB().ExecContext(ctx, query, role, adminID)
+	if err != nil {
+		return fmt.Errorf("failed to update admin role: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("admin not found or no changes made")
+	}
+
+	return nil
+}
+
+// CountActiveByRole 统计指定角色的激活管理员数量
+func (r *Repository) CountActiveByRole(ctx context.Context, role string) (int64, error) {
+	query := `SELECT COUNT(*) FROM admins WHERE role = $1 AND active = true AND deleted_at IS NULL`
+
+	var count int64
+	err := r.GetDB().QueryRowContext(ctx, query, role).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count active admins: %w", err)
+	}
+
+	return count, nil
+}
+
 // Delete 软删除管理员
 func (r *Repository) Delete(ctx context.Context, adminID string) error {
 	query := `
@@ -242,3 +362,382 @@ func (r *Repository) CreatePasswordReset(ctx context.Context, reset *PasswordRes
 
 	return nil
 }
+
+// ========== 角色管理 ==========
+
+// ListRoles 获取全部角色及其权限
+func (r *Repository) ListRoles(ctx context.Context) ([]*Role, error) {
+	query := `
+		SELECT id, name, display_name, description, is_system, created_at, updated_at
+		FROM admin_roles
+		ORDER BY is_system DESC, name ASC
+	`
+
+	rows, err := r.GetDB().QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list roles: %w", err)
+	}
+	defer rows.Close()
+
+	var roles []*Role
+	byName := make(map[string]*Role)
+	for rows.Next() {
+		role := &Role{Permissions: []string{}}
+		err := rows.Scan(&role.ID, &role.Name, &role.DisplayName, &role.Description, &role.IsSystem, &role.CreatedAt, &role.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan role: %w", err)
+		}
+		roles = append(roles, role)
+		byName[role.Name] = role
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	permRows, err := r.GetDB().QueryContext(ctx, `
+		SELECT role_name, permission
+		FROM admin_role_permissions
+		ORDER BY role_name, permission
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list role permissions: %w", err)
+	}
+	defer permRows.Close()
+
+	for permRows.Next() {
+		var roleName, permission string
+		if err := permRows.Scan(&roleName, &permission); err != nil {
+			return nil, fmt.Errorf("failed to scan role permission: %w", err)
+		}
+		if role, ok := byName[roleName]; ok {
+			role.Permissions = append(role.Permissions, permission)
+		}
+	}
+
+	if err = permRows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return roles, nil
+}
+
+// GetRoleByName 根据名称获取角色及其权限
+func (r *Repository) GetRoleByName(ctx context.Context, name string) (*Role, error) {
+	query := `
+		SELECT id, name, display_name, description, is_system, created_at, updated_at
+		FROM admin_roles
+		WHERE name = $1
+	`
+
+	role := &Role{Permissions: []string{}}
+	err := r.GetDB().QueryRowContext(ctx, query, name).Scan(
+		&role.ID, &role.Name, &role.DisplayName, &role.Description, &role.IsSystem, &role.CreatedAt, &role.UpdatedAt)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("role not found")
+		}
+		return nil, fmt.Errorf("failed to get role: %w", err)
+	}
+
+	rows, err := r.GetDB().QueryContext(ctx, `
+		SELECT permission
+		FROM admin_role_permissions
+		WHERE role_name = $1
+		ORDER BY permission
+	`, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role permissions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var permission string
+		if err := rows.Scan(&permission); err != nil {
+			return nil, fmt.Errorf("failed to scan role permission: %w", err)
+		}
+		role.Permissions = append(role.Permissions, permission)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return role, nil
+}
+
+// CreateRole 创建角色及其权限
+func (r *Repository) CreateRole(ctx context.Context, role *Role) error {
+	role.ID = uuid.New().String()
+
+	return r.GetDB().Transaction(func(tx *sql.Tx) error {
+		query := `
+			INSERT INTO admin_roles (id, name, display_name, description, is_system, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, false, NOW(), NOW())
+			RETURNING created_at, updated_at
+		`
+
+		err := tx.QueryRowContext(ctx, query, role.ID, role.Name, role.DisplayName, role.Description).
+			Scan(&role.CreatedAt, &role.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to create role: %w", err)
+		}
+
+		return replaceRolePermissions(ctx, tx, role.Name, role.Permissions)
+	})
+}
+
+// UpdateRole 更新角色信息并替换其权限
+func (r *Repository) UpdateRole(ctx context.Context, role *Role) error {
+	return r.GetDB().Transaction(func(tx *sql.Tx) error {
+		query := `
+			UPDATE admin_roles
+			SET display_name = $1, description = $2, updated_at = NOW()
+			WHERE name = $3
+			RETURNING updated_at
+		`
+
+		err := tx.QueryRowContext(ctx, query, role.DisplayName, role.Description, role.Name).Scan(&role.UpdatedAt)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("role not found")
+			}
+			return fmt.Errorf("failed to update role: %w", err)
+		}
+
+		return replaceRolePermissions(ctx, tx, role.Name, role.Permissions)
+	})
+}
+
+// DeleteRole 删除非系统角色
+func (r *Repository) DeleteRole(ctx context.Context, name string) error {
+	query := `DELETE FROM admin_roles WHERE name = $1 AND is_system = false`
+
+	result, err := r.GetDB().ExecContext(ctx, query, name)
+	if err != nil {
+		return fmt.Errorf("failed to delete role: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("role not found or is a system role")
+	}
+
+	return nil
+}
+
+// CountAdminsByRole 统计使用指定角色的管理员数量
+func (r *Repository) CountAdminsByRole(ctx context.Context, role string) (int64, error) {
+	query := `SELECT COUNT(*) FROM admins WHERE role = $1 AND deleted_at IS NULL`
+
+	var count int64
+	err := r.GetDB().QueryRowContext(ctx, query, role).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count admins by role: %w", err)
+	}
+
+	return count, nil
+}
+
+// RoleExists 检查角色是否存在
+func (r *Repository) RoleExists(ctx context.Context, name string) (bool, error) {
+	query := `SELECT EXISTS(SELECT 1 FROM admin_roles WHERE name = $1)`
+
+	var exists bool
+	err := r.GetDB().QueryRowContext(ctx, query, name).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check role existence: %w", err)
+	}
+
+	return exists, nil
+}
+
+// replaceRolePermissions 在事务中替换角色的全部权限
+func replaceRolePermissions(ctx context.Context, tx *sql.Tx, roleName string, permissions []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM admin_role_permissions WHERE role_name = $1`, roleName); err != nil {
+		return fmt.Errorf("failed to clear role permissions: %w", err)
+	}
+
+	for _, permission := range permissions {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO admin_role_permissions (role_name, permission, created_at)
+			VALUES ($1, $2, NOW())
+			ON CONFLICT (role_name, permission) DO NOTHING
+		`, roleName, permission)
+		if err != nil {
+			return fmt.Errorf("failed to add role permission: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ========== 管理员邀请 ==========
+
+// CreateInvitation 创建邀请，同一邮箱之前未使用的邀请全部作废
+func (r *Repository) CreateInvitation(ctx context.Context, invitation *AdminInvitation) error {
+	invitation.ID = uuid.New().String()
+
+	return r.GetDB().Transaction(func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE admin_invitations
+			SET revoked_at = NOW()
+			WHERE email = $1 AND accepted_at IS NULL AND revoked_at IS NULL
+		`, invitation.Email)
+		if err != nil {
+			return fmt.Errorf("failed to revoke previous invitations: %w", err)
+		}
+
+		query := `
+			INSERT INTO admin_invitations (id, email, name, role, token_hash, invited_by, expires_at, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())
+			RETURNING created_at
+		`
+
+		err = tx.QueryRowContext(ctx, query,
+			invitation.ID, invitation.Email, invitation.Name, invitation.Role,
+			invitation.TokenHash, invitation.InvitedBy, invitation.ExpiresAt,
+		).Scan(&invitation.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to create invitation: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// GetInvitationByID 根据ID获取邀请
+func (r *Repository) GetInvitationByID(ctx context.Context, id string) (*AdminInvitation, error) {
+	return r.getInvitation(ctx, "id = $1", id)
+}
+
+// GetInvitationByTokenHash 根据令牌摘要获取邀请
+func (r *Repository) GetInvitationByTokenHash(ctx context.Context, tokenHash string) (*AdminInvitation, error) {
+	return r.getInvitation(ctx, "token_hash = $1", tokenHash)
+}
+
+// ListPendingInvitations 获取未接受、未撤销且未过期的邀请
+func (r *Repository) ListPendingInvitations(ctx context.Context) ([]*AdminInvitation, error) {
+	query := `
+		SELECT id, email, name, role, token_hash, invited_by, expires_at, accepted_at, revoked_at, created_at
+		FROM admin_invitations
+		WHERE accepted_at IS NULL AND revoked_at IS NULL AND expires_at > NOW()
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.GetDB().QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list invitations: %w", err)
+	}
+	defer rows.Close()
+
+	var invitations []*AdminInvitation
+	for rows.Next() {
+		invitation := &AdminInvitation{}
+		err := rows.Scan(
+			&invitation.ID, &invitation.Email, &invitation.Name, &invitation.Role, &invitation.TokenHash,
+			&invitation.InvitedBy, &invitation.ExpiresAt, &invitation.AcceptedAt, &invitation.RevokedAt, &invitation.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan invitation: %w", err)
+		}
+		invitations = append(invitations, invitation)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return invitations, nil
+}
+
+// RevokeInvitation 撤销未使用的邀请
+func (r *Repository) RevokeInvitation(ctx context.Context, id string) error {
+	query := `
+		UPDATE admin_invitations
+		SET revoked_at = NOW()
+		WHERE id = $1 AND accepted_at IS NULL AND revoked_at IS NULL
+	`
+
+	result, err := r.GetDB().ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke invitation: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("invitation not found")
+	}
+
+	return nil
+}
+
+// AcceptInvitation 接受邀请并创建管理员账户（同一事务内完成，邀请只能使用一次）
+func (r *Repository) AcceptInvitation(ctx context.Context, invitationID string, admin *Admin) error {
+	admin.ID = uuid.New().String()
+
+	return r.GetDB().Transaction(func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, `
+			UPDATE admin_invitations
+			SET accepted_at = NOW()
+			WHERE id = $1 AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > NOW()
+		`, invitationID)
+		if err != nil {
+			return fmt.Errorf("failed to accept invitation: %w", err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rowsAffected == 0 {
+			return fmt.Errorf("invitation not found")
+		}
+
+		query := `
+			INSERT INTO admins (id, email, name, password, role, active, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, true, NOW(), NOW())
+			RETURNING created_at, updated_at
+		`
+
+		err = tx.QueryRowContext(ctx, query,
+			admin.ID, admin.Email, admin.Name, admin.Password, admin.Role,
+		).Scan(&admin.CreatedAt, &admin.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to create admin: %w", err)
+		}
+
+		admin.Active = true
+		return nil
+	})
+}
+
+// getInvitation 按条件获取单条邀请
+func (r *Repository) getInvitation(ctx context.Context, condition string, arg interface{}) (*AdminInvitation, error) {
+	query := `
+		SELECT id, email, name, role, token_hash, invited_by, expires_at, accepted_at, revoked_at, created_at
+		FROM admin_invitations
+		WHERE ` + condition
+
+	invitation := &AdminInvitation{}
+	err := r.GetDB().QueryRowContext(ctx, query, arg).Scan(
+		&invitation.ID, &invitation.Email, &invitation.Name, &invitation.Role, &invitation.TokenHash,
+		&invitation.InvitedBy, &invitation.ExpiresAt, &invitation.AcceptedAt, &invitation.RevokedAt, &invitation.CreatedAt)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("invitation not found")
+		}
+		return nil, fmt.Errorf("failed to get invitation: %w", err)
+	}
+
+	return invitation, nil
+}
@@ -29,6 +29,7 @@ func (r *Routes) RegisterRoutes(router *gin.RouterGroup) {
 		admin.POST("/verify-login", r.handler.VerifyLogin)
 		admin.POST("/forgot-password", r.handler.ForgotPassword)
 		admin.POST("/reset-password", r.handler.ResetPassword)
+		admin.POST("/accept-invitation", r.handler.AcceptInvitation)
 
 		// 需要认证的路由
 		authenticated := admin.Group("")
@@ -42,6 +43,33 @@ func (r *Routes) RegisterRoutes(router *gin.RouterGroup) {
 			// 个人资料
 			authenticated.GET("/profile", r.handler.GetProfile)
 			authenticated.PUT("/password", r.handler.ChangePassword)
+
+			// 管理员账户管理
+			accounts := authenticated.Group("")
+			accounts.Use(r.authMiddle.RequirePermission(auth.PermAdminManage))
+			{
+				accounts.GET("/admins", r.handler.ListAdmins)
+				accounts.GET("/admins/:admin_id", r.handler.GetAdmin)
+				accounts.POST("/admins/:admin_id/deactivate", r.handler.DeactivateAdmin)
+				accounts.POST("/admins/:admin_id/reactivate", r.handler.ReactivateAdmin)
+				accounts.PUT("/admins/:admin_id/role", r.handler.ChangeAdminRole)
+
+				accounts.GET("/invitations", r.handler.ListInvitations)
+				accounts.POST("/invitations", r.handler.InviteAdmin)
+				accounts.DELETE("/invitations/:invitation_id", r.handler.RevokeInvitation)
+			}
+
+			// 角色权限管理
+			roles := authenticated.Group("")
+			roles.Use(r.authMiddle.RequirePermission(auth.PermRoleManage))
+			{
+				roles.GET("/permissions", r.handler.ListPermissions)
+				roles.GET("/roles", r.handler.ListRoles)
+				roles.GET("/roles/:role_name", r.handler.GetRole)
+				roles.POST("/roles", r.handler.CreateRole)
+				roles.PUT("/roles/:role_name", r.handler.UpdateRole)
+				roles.DELETE("/roles/:role_name", r.handler.DeleteRole)
+			}
 		}
 	}
 }
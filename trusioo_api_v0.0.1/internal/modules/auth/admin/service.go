@@ -3,11 +3,19 @@ package admin
 import (
 	"context"
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"math/big"
+	"net/url"
+	"regexp"
+	"strings"
 	"time"
 
+	"trusioo_api_v0.0.1/internal/config"
+	"trusioo_api_v0.0.1/internal/infrastructure/mailer"
 	"trusioo_api_v0.0.1/internal/modules/auth"
 	"trusioo_api_v0.0.1/internal/modules/auth/user"
 	"trusioo_api_v0.0.1/pkg/cryptoutil"
@@ -17,21 +25,29 @@ import (
 
 // Service 管理员认证服务
 type Service struct {
-	repo       *Repository
-	verifyRepo *user.VerificationRepository
-	encryptor  *cryptoutil.PasswordEncryptor
-	logger     *logrus.Logger
+	repo           *Repository
+	verifyRepo     *user.VerificationRepository
+	encryptor      *cryptoutil.PasswordEncryptor
+	passwordPolicy *auth.PasswordPolicy
+	permissions    *auth.PermissionStore
+	mailer         mailer.Mailer
+	config         *config.AdminConfig
+	logger         *logrus.Logger
 }
 
 // Admin结构体已移至model.go文件
 
 // NewService 创建新的管理员认证服务
-func NewService(repo *Repository, verifyRepo *user.VerificationRepository, encryptor *cryptoutil.PasswordEncryptor, logger *logrus.Logger) *Service {
+func NewService(repo *Repository, verifyRepo *user.VerificationRepository, encryptor *cryptoutil.PasswordEncryptor, passwordPolicy *auth.PasswordPolicy, permissions *auth.PermissionStore, mailSender mailer.Mailer, cfg *config.AdminConfig, logger *logrus.Logger) *Service {
 	return &Service{
-		repo:       repo,
-		verifyRepo: verifyRepo,
-		encryptor:  encryptor,
-		logger:     logger,
+		repo:           repo,
+		verifyRepo:     verifyRepo,
+		encryptor:      encryptor,
+		passwordPolicy: passwordPolicy,
+		permissions:    permissions,
+		mailer:         mailSender,
+		config:         cfg,
+		logger:         logger,
 	}
 }
 
@@ -49,13 +65,36 @@ func (s *Service) ValidateCredentials(ctx context.Context, email, password strin
 	}
 
 	// 验证密码
-	if err := s.encryptor.VerifyPassword(password, admin.Password); err != nil {
+	needsRehash, err := s.encryptor.VerifyAndCheckRehash(password, admin.Password)
+	if err != nil {
 		return nil, errors.New("invalid password")
 	}
 
+	// 旧算法或低强度的哈希在验证成功时按当前配置升级
+	if needsRehash {
+		s.upgradePasswordHash(ctx, admin, password)
+	}
+
 	return admin, nil
 }
 
+// upgradePasswordHash 使用当前配置重新生成密码哈希，失败时只记录日志（不影响登录）
+func (s *Service) upgradePasswordHash(ctx context.Context, admin *Admin, password string) {
+	newHash, err := s.encryptor.HashPassword(password)
+	if err != nil {
+		s.logger.WithError(err).WithField("admin_id", admin.ID).Warn("Failed to rehash admin password")
+		return
+	}
+
+	if err := s.repo.UpgradePasswordHash(ctx, admin.ID, admin.Password, newHash); err != nil {
+		s.logger.WithError(err).WithField("admin_id", admin.ID).Warn("Failed to upgrade admin password hash")
+		return
+	}
+
+	admin.Password = newHash
+	s.logger.WithField("admin_id", admin.ID).Info("Admin password hash upgraded")
+}
+
 // GetByID 根据ID获取管理员信息
 func (s *Service) GetByID(ctx context.Context, id string) (*Admin, error) {
 	admin, err := s.repo.GetByID(ctx, id)
@@ -86,6 +125,16 @@ func (s *Service) GetByEmail(ctx context.Context, email string) (*Admin, error)
 
 // UpdatePassword 更新管理员密码
 func (s *Service) UpdatePassword(ctx context.Context, adminID, newPassword string) error {
+	admin, err := s.repo.GetByID(ctx, adminID)
+	if err != nil {
+		return fmt.Errorf("failed to get admin: %w", err)
+	}
+
+	// 检查密码策略
+	if err := s.validateNewPassword(ctx, admin, newPassword); err != nil {
+		return err
+	}
+
 	// 加密新密码
 	hashedPassword, err := s.encryptor.HashPassword(newPassword)
 	if err != nil {
@@ -96,6 +145,7 @@ func (s *Service) UpdatePassword(ctx context.Context, adminID, newPassword strin
 	if err := s.repo.UpdatePassword(ctx, adminID, hashedPassword); err != nil {
 		return fmt.Errorf("failed to update password: %w", err)
 	}
+	s.recordPasswordHistory(ctx, adminID, hashedPassword)
 
 	s.logger.WithField("admin_id", adminID).Info("Admin password updated")
 	return nil
@@ -108,6 +158,20 @@ func (s *Service) CreateAdmin(ctx context.Context, email, name, password, role s
 		return nil, errors.New("admin with this email already exists")
 	}
 
+	// 检查角色是否存在
+	exists, err := s.repo.RoleExists(ctx, role)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check role existence: %w", err)
+	}
+	if !exists {
+		return nil, auth.ErrRoleNotFound
+	}
+
+	// 检查密码策略
+	if err := s.passwordPolicy.Validate(ctx, &auth.PasswordSubject{UserType: "admin", Email: email, Name: name}, password); err != nil {
+		return nil, err
+	}
+
 	// 加密密码
 	hashedPassword, err := s.encryptor.HashPassword(password)
 	if err != nil {
@@ -126,6 +190,7 @@ func (s *Service) CreateAdmin(ctx context.Context, email, name, password, role s
 	if err := s.repo.Create(ctx, admin); err != nil {
 		return nil, fmt.Errorf("failed to create admin: %w", err)
 	}
+	s.recordPasswordHistory(ctx, admin.ID, hashedPassword)
 
 	s.logger.WithFields(logrus.Fields{
 		"admin_id": admin.ID,
@@ -166,26 +231,31 @@ func (s *Service) ListAdmins(ctx context.Context, limit, offset int) ([]*Admin,
 }
 
 // SendLoginVerificationCode 发送管理员登录验证码
-func (s *Service) SendLoginVerificationCode(ctx context.Context, email, password, ipAddress string) (string, error) {
+func (s *Service) SendLoginVerificationCode(ctx context.Context, email, password, ipAddress string) error {
 	// 首先验证管理员凭证
 	admin, err := s.ValidateCredentials(ctx, email, password)
 	if err != nil {
-		return "", err
+		return err
+	}
+
+	// 密码过期的管理员需先通过忘记密码重置
+	if s.passwordPolicy.IsAdminPasswordExpired(admin.PasswordChangedAt) {
+		return auth.ErrPasswordExpired
 	}
 
 	// 检查频率限制（5分钟内最多3次）
 	allowed, err := s.verifyRepo.CheckRateLimit(ctx, email, "admin", "login_code", 5*time.Minute, 3)
 	if err != nil {
-		return "", fmt.Errorf("failed to check rate limit: %w", err)
+		return fmt.Errorf("failed to check rate limit: %w", err)
 	}
 	if !allowed {
-		return "", auth.ErrTooManyAttempts
+		return auth.ErrTooManyAttempts
 	}
 
 	// 生成6位数字验证码
 	code, err := s.generateVerificationCode()
 	if err != nil {
-		return "", fmt.Errorf("failed to generate verification code: %w", err)
+		return fmt.Errorf("failed to generate verification code: %w", err)
 	}
 
 	// 创建验证记录
@@ -203,17 +273,19 @@ func (s *Service) SendLoginVerificationCode(ctx context.Context, email, password
 	}
 
 	if err := s.verifyRepo.CreateVerification(ctx, verification); err != nil {
-		return "", fmt.Errorf("failed to create verification: %w", err)
+		return fmt.Errorf("failed to create verification: %w", err)
+	}
+
+	if err := auth.SendVerificationCodeEmail(ctx, s.mailer, email, verification.Type, code, verification.ExpiresAt); err != nil {
+		return err
 	}
 
-	// TODO: 这里应该发送邮件，现在先记录日志
 	s.logger.WithFields(logrus.Fields{
 		"email": email,
-		"code":  code, // 生产环境中不应该记录验证码
 		"type":  "admin_login_code",
-	}).Info("Admin login verification code generated")
+	}).Info("Admin login verification code sent")
 
-	return code, nil
+	return nil
 }
 
 // VerifyLoginCode 验证管理员登录验证码
@@ -223,6 +295,9 @@ func (s *Service) VerifyLoginCode(ctx context.Context, email, password, code str
 	if err != nil {
 		return nil, err
 	}
+	if s.passwordPolicy.IsAdminPasswordExpired(admin.PasswordChangedAt) {
+		return nil, auth.ErrPasswordExpired
+	}
 
 	// 获取活跃的验证记录
 	verification, err := s.verifyRepo.GetActiveVerification(ctx, email, "admin", "login_code")
@@ -231,7 +306,7 @@ func (s *Service) VerifyLoginCode(ctx context.Context, email, password, code str
 	}
 
 	// 检查验证码是否匹配
-	if verification.VerificationCode != code {
+	if !s.verifyRepo.MatchCode(verification, code) {
 		// 增加尝试次数
 		if err := s.verifyRepo.IncrementAttempts(ctx, verification.ID); err != nil {
 			s.logger.WithError(err).Error("Failed to increment attempts")
@@ -273,26 +348,26 @@ func (s *Service) generateVerificationCode() (string, error) {
 }
 
 // ForgotPassword 忘记密码，发送密码重置验证码
-func (s *Service) ForgotPassword(ctx context.Context, email, ipAddress string) (string, error) {
+func (s *Service) ForgotPassword(ctx context.Context, email, ipAddress string) error {
 	// 验证邮箱是否存在
 	_, err := s.repo.GetByEmail(ctx, email)
 	if err != nil {
-		return "", auth.ErrAdminNotFound
+		return auth.ErrAdminNotFound
 	}
 
 	// 检查频率限制（5分钟内最多3次）
 	allowed, err := s.verifyRepo.CheckRateLimit(ctx, email, "admin", "password_reset", 5*time.Minute, 3)
 	if err != nil {
-		return "", fmt.Errorf("failed to check rate limit: %w", err)
+		return fmt.Errorf("failed to check rate limit: %w", err)
 	}
 	if !allowed {
-		return "", auth.ErrTooManyAttempts
+		return auth.ErrTooManyAttempts
 	}
 
 	// 生成6位数字验证码
 	code, err := s.generateVerificationCode()
 	if err != nil {
-		return "", fmt.Errorf("failed to generate verification code: %w", err)
+		return fmt.Errorf("failed to generate verification code: %w", err)
 	}
 
 	// 创建验证记录
@@ -309,17 +384,19 @@ func (s *Service) ForgotPassword(ctx context.Context, email, ipAddress string) (
 	}
 
 	if err := s.verifyRepo.CreateVerification(ctx, verification); err != nil {
-		return "", fmt.Errorf("failed to create verification: %w", err)
+		return fmt.Errorf("failed to create verification: %w", err)
+	}
+
+	if err := auth.SendVerificationCodeEmail(ctx, s.mailer, email, verification.Type, code, verification.ExpiresAt); err != nil {
+		return err
 	}
 
-	// TODO: 这里应该发送邮件，现在先记录日志
 	s.logger.WithFields(logrus.Fields{
 		"email": email,
-		"code":  code, // 生产环境中不应该记录验证码
 		"type":  "admin_password_reset",
-	}).Info("Admin password reset verification code generated")
+	}).Info("Admin password reset verification code sent")
 
-	return code, nil
+	return nil
 }
 
 // ResetPassword 重置密码
@@ -337,7 +414,7 @@ func (s *Service) ResetPassword(ctx context.Context, email, code, newPassword, i
 	}
 
 	// 检查验证码是否匹配
-	if verification.VerificationCode != code {
+	if !s.verifyRepo.MatchCode(verification, code) {
 		// 增加尝试次数
 		if err := s.verifyRepo.IncrementAttempts(ctx, verification.ID); err != nil {
 			s.logger.WithError(err).Error("Failed to increment attempts")
@@ -350,6 +427,11 @@ func (s *Service) ResetPassword(ctx context.Context, email, code, newPassword, i
 		return auth.ErrTooManyAttempts
 	}
 
+	// 检查密码策略（在消耗验证码之前，以便换一个密码重试）
+	if err := s.validateNewPassword(ctx, admin, newPassword); err != nil {
+		return err
+	}
+
 	// 标记验证码为已使用
 	if err := s.verifyRepo.MarkAsVerified(ctx, verification.ID); err != nil {
 		return fmt.Errorf("failed to mark verification as used: %w", err)
@@ -365,12 +447,13 @@ func (s *Service) ResetPassword(ctx context.Context, email, code, newPassword, i
 	if err := s.repo.UpdatePassword(ctx, admin.ID, hashedPassword); err != nil {
 		return fmt.Errorf("failed to update password: %w", err)
 	}
+	s.recordPasswordHistory(ctx, admin.ID, hashedPassword)
 
 	// 记录密码重置操作到password_resets表
 	if err := s.repo.CreatePasswordReset(ctx, &PasswordReset{
 		Email:     email,
 		UserType:  "admin",
-		Token:     verification.ID, // 使用验证ID作为token
+		Token:     s.verifyRepo.HashToken(verification.ID), // 使用验证ID的哈希作为token
 		Used:      true,
 		UsedAt:    verification.VerifiedAt,
 		ExpiresAt: verification.ExpiresAt,
@@ -386,3 +469,530 @@ func (s *Service) ResetPassword(ctx context.Context, email, code, newPassword, i
 
 	return nil
 }
+
+// ========== 管理员账户管理 ==========
+
+// SearchAdmins 按条件分页查询管理员
+func (s *Service) SearchAdmins(ctx context.Context, req *ListAdminsRequest) ([]*Admin, int64, error) {
+	admins, total, err := s.repo.Search(ctx, req.Role, req.Active, strings.TrimSpace(req.Search), req.GetLimit(), req.GetOffset())
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search admins: %w", err)
+	}
+
+	return admins, total, nil
+}
+
+// GetAdmin 获取管理员（包含已停用的账户）
+func (s *Service) GetAdmin(ctx context.Context, adminID string) (*Admin, error) {
+	admin, err := s.repo.GetByID(ctx, adminID)
+	if err != nil {
+		if err.Error() == "admin not found" {
+			return nil, auth.ErrAdminNotFound
+		}
+		return nil, fmt.Errorf("failed to get admin: %w", err)
+	}
+
+	return admin, nil
+}
+
+// InviteAdmin 邀请新管理员，邀请链接通过邮件发送
+func (s *Service) InviteAdmin(ctx context.Context, email, name, role, operatorID, operatorRole string) (*AdminInvitation, error) {
+	if err := s.checkRoleAssignable(ctx, role, operatorRole); err != nil {
+		return nil, err
+	}
+
+	exists, err := s.repo.ExistsByEmail(ctx, email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check email existence: %w", err)
+	}
+	if exists {
+		return nil, auth.ErrAdminExists
+	}
+
+	token, tokenHash, err := s.generateInvitationToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate invitation token: %w", err)
+	}
+
+	invitation := &AdminInvitation{
+		Email:     email,
+		Name:      name,
+		Role:      role,
+		TokenHash: tokenHash,
+		InvitedBy: &operatorID,
+		ExpiresAt: time.Now().Add(s.config.InvitationTTL),
+	}
+
+	if err := s.repo.CreateInvitation(ctx, invitation); err != nil {
+		return nil, fmt.Errorf("failed to create invitation: %w", err)
+	}
+
+	if err := s.sendInvitationEmail(ctx, invitation, token); err != nil {
+		// 邮件发送失败时作废邀请，避免留下无法使用的记录
+		if revokeErr := s.repo.RevokeInvitation(ctx, invitation.ID); revokeErr != nil {
+			s.logger.WithError(revokeErr).Warn("Failed to revoke undelivered invitation")
+		}
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"invitation_id": invitation.ID,
+		"email":         email,
+		"role":          role,
+		"operator_id":   operatorID,
+	}).Info("Admin invitation sent")
+
+	return invitation, nil
+}
+
+// ListInvitations 获取待接受的邀请
+func (s *Service) ListInvitations(ctx context.Context) ([]*AdminInvitation, error) {
+	invitations, err := s.repo.ListPendingInvitations(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list invitations: %w", err)
+	}
+
+	return invitations, nil
+}
+
+// RevokeInvitation 撤销邀请
+func (s *Service) RevokeInvitation(ctx context.Context, invitationID, operatorID string) error {
+	if err := s.repo.RevokeInvitation(ctx, invitationID); err != nil {
+		if err.Error() == "invitation not found" {
+			return auth.ErrInvitationNotFound
+		}
+		return fmt.Errorf("failed to revoke invitation: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"invitation_id": invitationID,
+		"operator_id":   operatorID,
+	}).Info("Admin invitation revoked")
+
+	return nil
+}
+
+// AcceptInvitation 接受邀请，由受邀人设置自己的密码后创建管理员账户
+func (s *Service) AcceptInvitation(ctx context.Context, token, password, name string) (*Admin, error) {
+	invitation, err := s.repo.GetInvitationByTokenHash(ctx, s.hashInvitationToken(token))
+	if err != nil {
+		if err.Error() == "invitation not found" {
+			return nil, auth.ErrInvitationNotFound
+		}
+		return nil, fmt.Errorf("failed to get invitation: %w", err)
+	}
+	if invitation.Status() != InvitationStatusPending {
+		return nil, auth.ErrInvitationNotFound
+	}
+
+	exists, err := s.repo.ExistsByEmail(ctx, invitation.Email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check email existence: %w", err)
+	}
+	if exists {
+		return nil, auth.ErrAdminExists
+	}
+
+	if name == "" {
+		name = invitation.Name
+	}
+
+	if err := s.passwordPolicy.Validate(ctx, &auth.PasswordSubject{UserType: "admin", Email: invitation.Email, Name: name}, password); err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := s.encryptor.HashPassword(password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	admin := &Admin{
+		Email:    invitation.Email,
+		Name:     name,
+		Password: hashedPassword,
+		Role:     invitation.Role,
+	}
+
+	if err := s.repo.AcceptInvitation(ctx, invitation.ID, admin); err != nil {
+		if err.Error() == "invitation not found" {
+			return nil, auth.ErrInvitationNotFound
+		}
+		return nil, fmt.Errorf("failed to accept invitation: %w", err)
+	}
+	s.recordPasswordHistory(ctx, admin.ID, hashedPassword)
+
+	s.logger.WithFields(logrus.Fields{
+		"admin_id":      admin.ID,
+		"email":         admin.Email,
+		"role":          admin.Role,
+		"invitation_id": invitation.ID,
+	}).Info("Admin invitation accepted")
+
+	return admin, nil
+}
+
+// DeactivateAdmin 停用管理员
+func (s *Service) DeactivateAdmin(ctx context.Context, adminID, operatorID, operatorRole string) (*Admin, error) {
+	admin, err := s.getManageableAdmin(ctx, adminID, operatorID, operatorRole)
+	if err != nil {
+		return nil, err
+	}
+
+	if admin.Active && admin.Role == auth.RoleSuperAdmin {
+		if err := s.ensureAnotherSuperAdmin(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.UpdateAdminStatus(ctx, adminID, false); err != nil {
+		return nil, err
+	}
+
+	admin.Active = false
+	return admin, nil
+}
+
+// ReactivateAdmin 重新启用管理员
+func (s *Service) ReactivateAdmin(ctx context.Context, adminID, operatorID, operatorRole string) (*Admin, error) {
+	admin, err := s.getManageableAdmin(ctx, adminID, operatorID, operatorRole)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.UpdateAdminStatus(ctx, adminID, true); err != nil {
+		return nil, err
+	}
+
+	admin.Active = true
+	return admin, nil
+}
+
+// ChangeAdminRole 修改管理员角色
+func (s *Service) ChangeAdminRole(ctx context.Context, adminID, role, operatorID, operatorRole string) (*Admin, error) {
+	admin, err := s.getManageableAdmin(ctx, adminID, operatorID, operatorRole)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkRoleAssignable(ctx, role, operatorRole); err != nil {
+		return nil, err
+	}
+
+	if admin.Active && admin.Role == auth.RoleSuperAdmin && role != auth.RoleSuperAdmin {
+		if err := s.ensureAnotherSuperAdmin(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.repo.AssignRole(ctx, adminID, role); err != nil {
+		return nil, fmt.Errorf("failed to update admin role: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"admin_id":    adminID,
+		"old_role":    admin.Role,
+		"new_role":    role,
+		"operator_id": operatorID,
+	}).Info("Admin role changed")
+
+	admin.Role = role
+	return admin, nil
+}
+
+// getManageableAdmin 获取可被当前操作人管理的管理员
+// 不能操作自己的账户；只有超级管理员可以管理其他超级管理员
+func (s *Service) getManageableAdmin(ctx context.Context, adminID, operatorID, operatorRole string) (*Admin, error) {
+	if adminID == operatorID {
+		return nil, auth.ErrCannotModifySelf
+	}
+
+	admin, err := s.GetAdmin(ctx, adminID)
+	if err != nil {
+		return nil, err
+	}
+
+	if admin.Role == auth.RoleSuperAdmin && operatorRole != auth.RoleSuperAdmin {
+		return nil, auth.ErrPermissionDenied
+	}
+
+	return admin, nil
+}
+
+// checkRoleAssignable 检查角色存在且当前操作人有权分配
+func (s *Service) checkRoleAssignable(ctx context.Context, role, operatorRole string) error {
+	if role == auth.RoleSuperAdmin && operatorRole != auth.RoleSuperAdmin {
+		return auth.ErrPermissionDenied
+	}
+
+	exists, err := s.repo.RoleExists(ctx, role)
+	if err != nil {
+		return fmt.Errorf("failed to check role existence: %w", err)
+	}
+	if !exists {
+		return auth.ErrRoleNotFound
+	}
+
+	return nil
+}
+
+// ensureAnotherSuperAdmin 确保操作后仍至少保留一个激活的超级管理员
+func (s *Service) ensureAnotherSuperAdmin(ctx context.Context) error {
+	count, err := s.repo.CountActiveByRole(ctx, auth.RoleSuperAdmin)
+	if err != nil {
+		return fmt.Errorf("failed to count super admins: %w", err)
+	}
+	if count <= 1 {
+		return auth.ErrLastSuperAdmin
+	}
+	return nil
+}
+
+// sendInvitationEmail 发送邀请邮件
+func (s *Service) sendInvitationEmail(ctx context.Context, invitation *AdminInvitation, token string) error {
+	link := s.config.InvitationURL + "?token=" + url.QueryEscape(token)
+
+	var body strings.Builder
+	body.WriteString("Hello " + invitation.Name + ",\n\n")
+	body.WriteString("You have been invited to join the Trusioo admin console as " + invitation.Role + ".\n")
+	body.WriteString("Open the link below to set your password and activate your account:\n\n")
+	body.WriteString(link + "\n\n")
+	body.WriteString("This link can be used once and expires at " + invitation.ExpiresAt.UTC().Format(time.RFC1123) + ".\n")
+	body.WriteString("If you were not expecting this invitation, you can ignore this email.\n")
+
+	if err := s.mailer.Send(ctx, &mailer.Message{
+		To:      []string{invitation.Email},
+		Subject: "You have been invited to the Trusioo admin console",
+		Body:    body.String(),
+	}); err != nil {
+		return fmt.Errorf("failed to send invitation email: %w", err)
+	}
+
+	return nil
+}
+
+// generateInvitationToken 生成邀请令牌，返回明文令牌及其带密钥哈希
+func (s *Service) generateInvitationToken() (string, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(buf)
+	return token, s.hashInvitationToken(token), nil
+}
+
+// hashInvitationToken 计算邀请令牌的带密钥哈希（对SHA-256摘要再做HMAC，与登录提醒令牌相同）
+func (s *Service) hashInvitationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return s.verifyRepo.HashToken(hex.EncodeToString(sum[:]))
+}
+
+// ========== 角色权限管理 ==========
+
+// roleNamePattern 角色标识格式：小写字母开头，仅含小写字母、数字和下划线
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{1,49}$`)
+
+// ListPermissions 获取系统支持的全部权限
+func (s *Service) ListPermissions() []auth.PermissionInfo {
+	return auth.AllPermissions()
+}
+
+// GetRolePermissions 获取角色拥有的权限
+func (s *Service) GetRolePermissions(ctx context.Context, role string) ([]string, error) {
+	return s.permissions.GetRolePermissions(ctx, role)
+}
+
+// ListRoles 获取全部角色
+func (s *Service) ListRoles(ctx context.Context) ([]*Role, error) {
+	roles, err := s.repo.ListRoles(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list roles: %w", err)
+	}
+
+	for _, role := range roles {
+		s.fillImplicitPermissions(role)
+	}
+
+	return roles, nil
+}
+
+// GetRole 获取角色详情
+func (s *Service) GetRole(ctx context.Context, name string) (*Role, error) {
+	role, err := s.repo.GetRoleByName(ctx, name)
+	if err != nil {
+		if err.Error() == "role not found" {
+			return nil, auth.ErrRoleNotFound
+		}
+		return nil, fmt.Errorf("failed to get role: %w", err)
+	}
+
+	s.fillImplicitPermissions(role)
+	return role, nil
+}
+
+// CreateRole 创建自定义角色
+func (s *Service) CreateRole(ctx context.Context, name, displayName string, description *string, permissions []string, operatorID string) (*Role, error) {
+	if !roleNamePattern.MatchString(name) {
+		return nil, auth.ErrInvalidRoleName
+	}
+
+	normalized, err := normalizePermissions(permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := s.repo.RoleExists(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check role existence: %w", err)
+	}
+	if exists {
+		return nil, auth.ErrRoleExists
+	}
+
+	role := &Role{
+		Name:        name,
+		DisplayName: displayName,
+		Description: description,
+		Permissions: normalized,
+	}
+
+	if err := s.repo.CreateRole(ctx, role); err != nil {
+		return nil, fmt.Errorf("failed to create role: %w", err)
+	}
+
+	s.permissions.Invalidate(name)
+
+	s.logger.WithFields(logrus.Fields{
+		"role":        name,
+		"permissions": normalized,
+		"operator_id": operatorID,
+	}).Info("Admin role created")
+
+	return role, nil
+}
+
+// UpdateRole 更新角色信息及权限（超级管理员角色不可修改）
+func (s *Service) UpdateRole(ctx context.Context, name, displayName string, description *string, permissions []string, operatorID string) (*Role, error) {
+	if name == auth.RoleSuperAdmin {
+		return nil, auth.ErrSystemRoleImmutable
+	}
+
+	role, err := s.GetRole(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	normalized, err := normalizePermissions(permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	if displayName != "" {
+		role.DisplayName = displayName
+	}
+	if description != nil {
+		role.Description = description
+	}
+	role.Permissions = normalized
+
+	if err := s.repo.UpdateRole(ctx, role); err != nil {
+		if err.Error() == "role not found" {
+			return nil, auth.ErrRoleNotFound
+		}
+		return nil, fmt.Errorf("failed to update role: %w", err)
+	}
+
+	s.permissions.Invalidate(name)
+
+	s.logger.WithFields(logrus.Fields{
+		"role":        name,
+		"permissions": normalized,
+		"operator_id": operatorID,
+	}).Info("Admin role updated")
+
+	return role, nil
+}
+
+// DeleteRole 删除自定义角色（系统角色及仍有管理员使用的角色不可删除）
+func (s *Service) DeleteRole(ctx context.Context, name, operatorID string) error {
+	role, err := s.GetRole(ctx, name)
+	if err != nil {
+		return err
+	}
+	if role.IsSystem {
+		return auth.ErrSystemRoleImmutable
+	}
+
+	count, err := s.repo.CountAdminsByRole(ctx, name)
+	if err != nil {
+		return fmt.Errorf("failed to count admins by role: %w", err)
+	}
+	if count > 0 {
+		return auth.ErrRoleInUse
+	}
+
+	if err := s.repo.DeleteRole(ctx, name); err != nil {
+		return fmt.Errorf("failed to delete role: %w", err)
+	}
+
+	s.permissions.Invalidate(name)
+
+	s.logger.WithFields(logrus.Fields{
+		"role":        name,
+		"operator_id": operatorID,
+	}).Info("Admin role deleted")
+
+	return nil
+}
+
+// fillImplicitPermissions 超级管理员的权限是隐式的，返回时补全
+func (s *Service) fillImplicitPermissions(role *Role) {
+	if role.Name != auth.RoleSuperAdmin {
+		return
+	}
+
+	role.Permissions = role.Permissions[:0]
+	for _, p := range auth.AllPermissions() {
+		role.Permissions = append(role.Permissions, p.Name)
+	}
+}
+
+// normalizePermissions 校验并去重权限列表，超级管理员专属权限不能授予其他角色
+func normalizePermissions(permissions []string) ([]string, error) {
+	seen := make(map[string]struct{}, len(permissions))
+	normalized := make([]string, 0, len(permissions))
+
+	for _, permission := range permissions {
+		if !auth.IsValidPermission(permission) {
+			return nil, fmt.Errorf("%w: %s", auth.ErrInvalidPermission, permission)
+		}
+		if auth.IsSuperAdminOnlyPermission(permission) {
+			return nil, fmt.Errorf("%w: %s", auth.ErrPermissionNotGrantable, permission)
+		}
+		if _, ok := seen[permission]; ok {
+			continue
+		}
+		seen[permission] = struct{}{}
+		normalized = append(normalized, permission)
+	}
+
+	return normalized, nil
+}
+
+// validateNewPassword 按密码策略检查管理员的新密码（包括历史密码）
+func (s *Service) validateNewPassword(ctx context.Context, admin *Admin, password string) error {
+	return s.passwordPolicy.Validate(ctx, &auth.PasswordSubject{
+		UserID:              admin.ID,
+		UserType:            "admin",
+		Email:               admin.Email,
+		Name:                admin.Name,
+		CurrentPasswordHash: admin.Password,
+	}, password)
+}
+
+// recordPasswordHistory 记录密码历史，失败时只记录日志（密码已经修改成功）
+func (s *Service) recordPasswordHistory(ctx context.Context, adminID, hashedPassword string) {
+	if err := s.passwordPolicy.RecordPassword(ctx, adminID, "admin", hashedPassword); err != nil {
+		s.logger.WithError(err).WithField("admin_id", adminID).Warn("Failed to record password history")
+	}
+}
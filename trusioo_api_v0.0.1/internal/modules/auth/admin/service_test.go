@@ -0,0 +1,66 @@
+package admin
+
+import (
+	"errors"
+	"testing"
+
+	"trusioo_api_v0.0.1/internal/modules/auth"
+)
+
+func TestNormalizePermissions(t *testing.T) {
+	tests := []struct {
+		name        string
+		permissions []string
+		want        []string
+		wantErr     error
+	}{
+		{
+			name:        "deduplicates",
+			permissions: []string{auth.PermUserView, auth.PermWalletView, auth.PermUserView},
+			want:        []string{auth.PermUserView, auth.PermWalletView},
+		},
+		{
+			name:        "unknown permission",
+			permissions: []string{auth.PermUserView, "user.unknown"},
+			wantErr:     auth.ErrInvalidPermission,
+		},
+		{
+			name:        "update email is super admin only",
+			permissions: []string{auth.PermUserView, auth.PermUserUpdateEmail},
+			wantErr:     auth.ErrPermissionNotGrantable,
+		},
+		{
+			name:        "delete is super admin only",
+			permissions: []string{auth.PermUserDelete},
+			wantErr:     auth.ErrPermissionNotGrantable,
+		},
+		{
+			name:        "impersonate is super admin only",
+			permissions: []string{auth.PermUserImpersonate},
+			wantErr:     auth.ErrPermissionNotGrantable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizePermissions(tt.permissions)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("normalizePermissions() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("normalizePermissions() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("normalizePermissions() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("normalizePermissions() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
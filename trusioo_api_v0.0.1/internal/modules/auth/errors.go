@@ -22,6 +22,12 @@ var (
 	ErrVerificationCodeExpired = errors.New("verification code has expired")
 	ErrVerificationCodeUsed    = errors.New("verification code has already been used")
 	ErrTooManyAttempts         = errors.New("too many verification attempts")
+
+	// 密码策略相关
+	ErrPasswordPolicy   = errors.New("password does not meet the password policy")
+	ErrPasswordReused   = errors.New("password was used recently")
+	ErrPasswordBreached = errors.New("password has appeared in a data breach")
+	ErrPasswordExpired  = errors.New("password has expired")
 )
 
 // ========== 状态错误 ==========
@@ -45,6 +51,94 @@ var (
 	ErrUserExists    = errors.New("user already exists")
 	ErrUserInactive  = errors.New("user account is inactive")
 	ErrUserSuspended = errors.New("user account is suspended")
+
+	// 登录提醒
+	ErrLoginAlertNotFound    = errors.New("login alert not found or expired")
+	ErrPasswordResetRequired = errors.New("password reset required")
+
+	// 登录锁定
+	ErrLoginLocked = errors.New("too many failed login attempts")
+
+	// 登录链接
+	ErrMagicLinkDisabled = errors.New("magic link login is disabled")
+	ErrMagicLinkInvalid  = errors.New("magic link is invalid or has expired")
+
+	// 邮箱变更
+	ErrEmailInUse          = errors.New("email address is already in use")
+	ErrEmailUnchanged      = errors.New("new email is the same as the current email")
+	ErrEmailChangeNotFound = errors.New("email change not found or revert link expired")
+
+	// 个人资料（时区无效复用ErrInvalidTimezone）
+	ErrInvalidLanguage      = errors.New("unsupported language")
+	ErrAvatarTooLarge       = errors.New("avatar image is too large")
+	ErrAvatarTypeNotAllowed = errors.New("avatar image type is not allowed")
+	ErrInvalidAvatarImage   = errors.New("avatar image is invalid")
+	ErrAvatarNotFound       = errors.New("avatar not found")
+	ErrInvalidPhoneNumber   = errors.New("invalid phone number")
+	ErrPhoneNumberInUse     = errors.New("phone number is already in use")
+	ErrPhoneNumberUnchanged = errors.New("new phone number is the same as the current phone number")
+	ErrPhoneNumberNotSet    = errors.New("no phone number is set")
+
+	// 账户注销与数据导出
+	ErrAccountDeletionNotFound = errors.New("account deletion not found or restore link expired")
+	ErrWithdrawalsInProgress   = errors.New("account has withdrawals in progress")
+	ErrDataExportTooFrequent   = errors.New("a data export is already in progress or was requested recently")
+	ErrDataExportNotFound      = errors.New("data export not found")
+	ErrDataExportNotReady      = errors.New("data export is not ready or has expired")
+
+	// 用户暂停
+	ErrInvalidSuspensionPeriod = errors.New("suspension end time must be in the future")
+
+	// 用户批量操作
+	ErrInvalidBatchTarget          = errors.New("exactly one of user_ids or filter must be provided")
+	ErrBatchNoTargets              = errors.New("batch operation matched no users")
+	ErrBatchTooManyUsers           = errors.New("batch operation exceeds the maximum number of users")
+	ErrBatchJobNotFound            = errors.New("batch job not found")
+	ErrBatchJobNotCancellable      = errors.New("batch job has already finished")
+	ErrBatchJobNotAwaitingApproval = errors.New("batch job is not awaiting approval")
+
+	// 用户导出
+	ErrInvalidExportField = errors.New("invalid export field")
+	ErrInvalidTimezone    = errors.New("invalid timezone")
+	ErrExportLinkInvalid  = errors.New("export download link is invalid or has expired")
+
+	// 用户统计
+	ErrInvalidStatisticsRange = errors.New("invalid statistics date range")
+
+	// 用户备注、标签和关注
+	ErrUserNoteNotFound  = errors.New("user note not found")
+	ErrEmptyUserNote     = errors.New("user note content is empty")
+	ErrInvalidUserTag    = errors.New("invalid user tag")
+	ErrTooManyUserTags   = errors.New("too many user tags")
+	ErrUserWatchNotFound = errors.New("user watch not found")
+	ErrInvalidWatchEvent = errors.New("invalid watch event")
+
+	// 管理员模拟用户登录
+	ErrImpersonationNotFound   = errors.New("impersonation not found")
+	ErrImpersonationEnded      = errors.New("impersonation has ended")
+	ErrImpersonationNotAllowed = errors.New("user cannot be impersonated")
+
+	// 用户高级查询和保存的搜索
+	ErrInvalidUserQuery     = errors.New("invalid user query")
+	ErrSavedSearchNotFound  = errors.New("saved search not found")
+	ErrSavedSearchNameTaken = errors.New("saved search name already exists")
+	ErrTooManySavedSearches = errors.New("too many saved searches")
+
+	// KYC身份认证
+	ErrKYCDocumentNotFound       = errors.New("kyc document not found")
+	ErrKYCDocumentTooLarge       = errors.New("kyc document is too large")
+	ErrKYCDocumentTypeNotAllowed = errors.New("kyc document type is not allowed")
+	ErrTooManyKYCDocuments       = errors.New("too many unsubmitted kyc documents")
+	ErrKYCSubmissionNotFound     = errors.New("kyc submission not found")
+	ErrKYCSubmissionPending      = errors.New("a kyc submission is already pending review")
+	ErrKYCSubmissionReviewed     = errors.New("kyc submission has already been reviewed")
+	ErrInvalidKYCTier            = errors.New("invalid kyc tier")
+	ErrInvalidKYCDetails         = errors.New("invalid kyc details")
+	ErrKYCDocumentsMissing       = errors.New("required kyc documents are missing")
+
+	// 钱包提现限制
+	ErrWithdrawalNotEnabled         = errors.New("withdrawal is not enabled for this wallet")
+	ErrDailyWithdrawalLimitExceeded = errors.New("daily withdrawal limit exceeded")
 )
 
 // ========== 管理员相关错误 ==========
@@ -53,6 +147,22 @@ var (
 	ErrAdminExists    = errors.New("admin already exists")
 	ErrAdminInactive  = errors.New("admin account is inactive")
 	ErrAdminSuspended = errors.New("admin account is suspended")
+
+	// 管理员账户管理
+	ErrInvitationNotFound = errors.New("invitation not found or expired")
+	ErrCannotModifySelf   = errors.New("cannot modify your own admin account")
+	ErrLastSuperAdmin     = errors.New("cannot remove the last active super admin")
+)
+
+// ========== 角色权限相关错误 ==========
+var (
+	ErrRoleNotFound           = errors.New("role not found")
+	ErrRoleExists             = errors.New("role already exists")
+	ErrRoleInUse              = errors.New("role is assigned to admins")
+	ErrInvalidRoleName        = errors.New("invalid role name")
+	ErrSystemRoleImmutable    = errors.New("system role cannot be modified")
+	ErrInvalidPermission      = errors.New("invalid permission")
+	ErrPermissionNotGrantable = errors.New("permission is reserved for super_admin")
 )
 
 // ========== JWT相关错误 ==========
@@ -61,6 +171,7 @@ var (
 	ErrJWTValidationFailed = errors.New("failed to validate JWT token")
 	ErrRefreshTokenExpired = errors.New("refresh token has expired")
 	ErrRefreshTokenInvalid = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
 )
 
 // ========== 数据库相关错误 ==========
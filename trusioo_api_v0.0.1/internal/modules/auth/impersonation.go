@@ -0,0 +1,177 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// ImpersonatedRequest 模拟登录令牌发起的一次请求
+type ImpersonatedRequest struct {
+	ImpersonationID string
+	AdminID         string
+	UserID          string
+	TokenID         string // 令牌jti
+	Method          string
+	Path            string
+	Route           string // 匹配的路由模板，未匹配时为空
+	StatusCode      int
+	Blocked         bool // 被只读限制或禁止模拟的路由拦截
+	IPAddress       string
+	UserAgent       string
+	OccurredAt      time.Time
+}
+
+// ImpersonationAuditor 模拟登录审计接口
+// CheckImpersonation 在每个模拟请求前调用，会话已结束时返回ErrImpersonationEnded；
+// RecordImpersonatedRequest 在请求处理完成（包括被拦截）后调用
+type ImpersonationAuditor interface {
+	CheckImpersonation(ctx context.Context, claims *Claims) error
+	RecordImpersonatedRequest(ctx context.Context, req *ImpersonatedRequest)
+}
+
+// IsImpersonation 是否为管理员模拟用户登录签发的令牌
+func (c *Claims) IsImpersonation() bool {
+	return c.ImpersonatorID != ""
+}
+
+// GenerateImpersonationToken 为管理员模拟用户登录签发只读访问令牌（不签发刷新令牌）
+func (j *JWTManager) GenerateImpersonationToken(userID, email, adminID, impersonationID string, ttl time.Duration) (string, *Claims, error) {
+	now := time.Now()
+
+	claims := &Claims{
+		UserID:          userID,
+		Email:           email,
+		Role:            "user",
+		UserType:        "user",
+		ImpersonatorID:  adminID,
+		ImpersonationID: impersonationID,
+		IssuedAtMicros:  now.UnixMicro(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			Subject:   userID,
+			Issuer:    "trusioo_api",
+			Audience:  []string{"trusioo_app"},
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+
+	token, err := j.signClaims(claims)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to sign impersonation token: %w", err)
+	}
+
+	return token, claims, nil
+}
+
+// SetImpersonationAuditor 设置模拟登录审计（未设置时拒绝所有模拟登录令牌）
+func (am *AuthMiddleware) SetImpersonationAuditor(auditor ImpersonationAuditor) {
+	am.impersonation = auditor
+}
+
+// DenyImpersonation 禁止模拟登录令牌访问的中间件（资金操作、交易密码等）
+func (am *AuthMiddleware) DenyImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if IsImpersonated(c) {
+			am.rejectImpersonation(c, "This action is not available while impersonating a user")
+			return
+		}
+		c.Next()
+	}
+}
+
+// IsImpersonated 当前请求是否使用模拟登录令牌
+func IsImpersonated(c *gin.Context) bool {
+	claims, err := GetCurrentUser(c)
+	if err != nil {
+		return false
+	}
+	return claims.IsImpersonation()
+}
+
+// authorizeImpersonation 校验模拟登录会话并限制为只读请求，通过时返回true
+func (am *AuthMiddleware) authorizeImpersonation(c *gin.Context, claims *Claims) bool {
+	if am.impersonation == nil {
+		am.logger.WithField("admin_id", claims.ImpersonatorID).Warn("Impersonation token rejected: impersonation is not enabled")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "Unauthorized",
+			"message": "Invalid token",
+		})
+		c.Abort()
+		return false
+	}
+
+	if err := am.impersonation.CheckImpersonation(c.Request.Context(), claims); err != nil {
+		if errors.Is(err, ErrImpersonationEnded) {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error":   "Unauthorized",
+				"message": "Impersonation session has ended",
+			})
+		} else {
+			am.logger.WithError(err).Error("Impersonation check failed")
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error":   "Service unavailable",
+				"message": "Unable to verify token, please try again later",
+			})
+		}
+		c.Abort()
+		return false
+	}
+
+	c.Set("impersonator_id", claims.ImpersonatorID)
+	c.Set("impersonation_id", claims.ImpersonationID)
+
+	// 模拟登录令牌只读
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	am.rejectImpersonation(c, "Impersonation tokens are read-only")
+	return false
+}
+
+// rejectImpersonation 拒绝模拟登录请求（标记为被拦截，供审计记录）
+func (am *AuthMiddleware) rejectImpersonation(c *gin.Context, message string) {
+	c.Set("impersonation_blocked", true)
+	c.JSON(http.StatusForbidden, gin.H{
+		"error":   "Forbidden",
+		"message": message,
+	})
+	c.Abort()
+}
+
+// recordImpersonatedRequest 记录模拟登录请求（在请求处理完成后调用）
+func (am *AuthMiddleware) recordImpersonatedRequest(c *gin.Context, claims *Claims, startedAt time.Time) {
+	am.impersonation.RecordImpersonatedRequest(c.Request.Context(), &ImpersonatedRequest{
+		ImpersonationID: claims.ImpersonationID,
+		AdminID:         claims.ImpersonatorID,
+		UserID:          claims.UserID,
+		TokenID:         claims.ID,
+		Method:          c.Request.Method,
+		Path:            c.Request.URL.Path,
+		Route:           c.FullPath(),
+		StatusCode:      c.Writer.Status(),
+		Blocked:         c.GetBool("impersonation_blocked"),
+		IPAddress:       c.ClientIP(),
+		UserAgent:       c.Request.UserAgent(),
+		OccurredAt:      startedAt,
+	})
+
+	am.logger.WithFields(logrus.Fields{
+		"impersonation_id": claims.ImpersonationID,
+		"admin_id":         claims.ImpersonatorID,
+		"user_id":          claims.UserID,
+		"method":           c.Request.Method,
+		"path":             c.Request.URL.Path,
+		"status":           c.Writer.Status(),
+	}).Info("Impersonated request")
+}
@@ -0,0 +1,34 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// JWKSHandler 公开验证密钥处理器
+type JWKSHandler struct {
+	jwtManager *JWTManager
+	logger     *logrus.Logger
+}
+
+// NewJWKSHandler 创建公开验证密钥处理器
+func NewJWKSHandler(jwtManager *JWTManager, logger *logrus.Logger) *JWKSHandler {
+	return &JWKSHandler{
+		jwtManager: jwtManager,
+		logger:     logger,
+	}
+}
+
+// GetJWKS 获取JWKS（其他内部服务用于验证本服务签发的令牌）
+func (h *JWKSHandler) GetJWKS(c *gin.Context) {
+	// 允许短时间缓存，轮换后的新密钥在宽限期内会同时发布
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.jwtManager.JWKS())
+}
+
+// RegisterRoutes 注册JWKS路由
+func (h *JWKSHandler) RegisterRoutes(router *gin.Engine) {
+	router.GET("/.well-known/jwks.json", h.GetJWKS)
+}
@@ -18,17 +18,29 @@ import (
 
 // JWTManager JWT管理器
 type JWTManager struct {
-	config *config.JWTConfig
-	db     *database.Database
-	logger *logrus.Logger
+	config     *config.JWTConfig
+	db         *database.Database
+	keys       *KeyManager
+	notifier   SecurityNotifier
+	revocation *TokenRevocationStore
+	logger     *logrus.Logger
 }
 
 // Claims JWT声明结构
 type Claims struct {
-	UserID   string `json:"user_id"`
-	Email    string `json:"email"`
-	Role     string `json:"role"`
-	UserType string `json:"user_type"` // admin, user
+	UserID    string `json:"user_id"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+	UserType  string `json:"user_type"`     // admin, user
+	SessionID string `json:"sid,omitempty"` // 登录会话ID
+
+	// IssuedAtMicros 微秒精度的签发时间（iat只有秒级），用于与用户级撤销水位线比较
+	IssuedAtMicros int64 `json:"iat_us,omitempty"`
+
+	// 管理员模拟用户登录（只读令牌）
+	ImpersonatorID  string `json:"act_admin_id,omitempty"` // 发起模拟登录的管理员ID
+	ImpersonationID string `json:"imp_id,omitempty"`       // 模拟登录会话ID
+
 	jwt.RegisteredClaims
 }
 
@@ -38,22 +50,36 @@ type TokenPair struct {
 	RefreshToken string `json:"refresh_token"`
 	TokenType    string `json:"token_type"`
 	ExpiresIn    int64  `json:"expires_in"`
+
+	RefreshTokenID   string    `json:"-"` // refresh_tokens表记录ID，用于关联会话
+	RefreshExpiresAt time.Time `json:"-"`
 }
 
 // RefreshToken 刷新令牌模型（集成到JWT管理器中）
 type RefreshToken struct {
-	ID         string                  `json:"id" db:"id"`
-	TokenID    string                  `json:"token_id" db:"token_id"` // JWT jti claim
-	UserID     string                  `json:"user_id" db:"user_id"`
-	UserType   string                  `json:"user_type" db:"user_type"` // admin, user
-	DeviceInfo *map[string]interface{} `json:"device_info" db:"device_info"`
-	IPAddress  *string                 `json:"ip_address" db:"ip_address"`
-	IsRevoked  bool                    `json:"is_revoked" db:"is_revoked"`
-	ExpiresAt  time.Time               `json:"expires_at" db:"expires_at"`
-	CreatedAt  time.Time               `json:"created_at" db:"created_at"`
-	UpdatedAt  time.Time               `json:"updated_at" db:"updated_at"`
+	ID            string                  `json:"id" db:"id"`
+	TokenID       string                  `json:"token_id" db:"token_id"`               // JWT jti claim
+	FamilyID      string                  `json:"family_id" db:"family_id"`             // 令牌族ID（首个令牌的jti）
+	ParentTokenID *string                 `json:"parent_token_id" db:"parent_token_id"` // 轮换前的父令牌jti
+	UserID        string                  `json:"user_id" db:"user_id"`
+	UserType      string                  `json:"user_type" db:"user_type"` // admin, user
+	DeviceInfo    *map[string]interface{} `json:"device_info" db:"device_info"`
+	IPAddress     *string                 `json:"ip_address" db:"ip_address"`
+	IsRevoked     bool                    `json:"is_revoked" db:"is_revoked"`
+	RevokedReason *string                 `json:"revoked_reason" db:"revoked_reason"`
+	ExpiresAt     time.Time               `json:"expires_at" db:"expires_at"`
+	CreatedAt     time.Time               `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time               `json:"updated_at" db:"updated_at"`
 }
 
+// 刷新令牌撤销原因
+const (
+	RefreshTokenRevokedRotated       = "rotated"        // 轮换后被新令牌替代
+	RefreshTokenRevokedLogout        = "logout"         // 用户主动登出
+	RefreshTokenRevokedReuseDetected = "reuse_detected" // 检测到重放，整个令牌族被撤销
+	RefreshTokenRevokedAll           = "revoke_all"     // 撤销用户所有令牌
+)
+
 // IsExpired 检查令牌是否过期
 func (rt *RefreshToken) IsExpired() bool {
 	return time.Now().After(rt.ExpiresAt)
@@ -64,6 +90,11 @@ func (rt *RefreshToken) IsValid() bool {
 	return !rt.IsRevoked && !rt.IsExpired()
 }
 
+// IsRotated 检查令牌是否因轮换而被撤销
+func (rt *RefreshToken) IsRotated() bool {
+	return rt.IsRevoked && rt.RevokedReason != nil && *rt.RevokedReason == RefreshTokenRevokedRotated
+}
+
 // Revoke 撤销令牌
 func (rt *RefreshToken) Revoke() {
 	rt.IsRevoked = true
@@ -79,6 +110,21 @@ func NewJWTManager(cfg *config.JWTConfig, db *database.Database, logger *logrus.
 	}
 }
 
+// SetSecurityNotifier 设置安全事件通知器（可选）
+func (j *JWTManager) SetSecurityNotifier(notifier SecurityNotifier) {
+	j.notifier = notifier
+}
+
+// SetKeyManager 设置非对称签名密钥管理器（未设置时使用HS256共享密钥）
+func (j *JWTManager) SetKeyManager(keys *KeyManager) {
+	j.keys = keys
+}
+
+// SetRevocationStore 设置访问令牌撤销列表（可选）
+func (j *JWTManager) SetRevocationStore(store *TokenRevocationStore) {
+	j.revocation = store
+}
+
 // GenerateTokenPair 生成访问令牌和刷新令牌对
 func (j *JWTManager) GenerateTokenPair(userID, email, role, userType string) (*TokenPair, error) {
 	return j.GenerateTokenPairWithContext(context.Background(), userID, email, role, userType, nil, nil)
@@ -86,35 +132,54 @@ func (j *JWTManager) GenerateTokenPair(userID, email, role, userType string) (*T
 
 // GenerateTokenPairWithContext 生成令牌对（带上下文和设备信息）
 func (j *JWTManager) GenerateTokenPairWithContext(ctx context.Context, userID, email, role, userType string, deviceInfo *map[string]interface{}, ipAddress *string) (*TokenPair, error) {
+	return j.generateTokenPair(ctx, userID, email, role, userType, "", deviceInfo, ipAddress, nil)
+}
+
+// GenerateSessionTokenPair 为登录会话生成令牌对，令牌携带会话ID以便按会话撤销
+func (j *JWTManager) GenerateSessionTokenPair(ctx context.Context, userID, email, role, userType, sessionID string, deviceInfo *map[string]interface{}, ipAddress *string) (*TokenPair, error) {
+	return j.generateTokenPair(ctx, userID, email, role, userType, sessionID, deviceInfo, ipAddress, nil)
+}
+
+// generateTokenPair 生成令牌对，parent不为空时新刷新令牌加入父令牌所在的令牌族
+func (j *JWTManager) generateTokenPair(ctx context.Context, userID, email, role, userType, sessionID string, deviceInfo *map[string]interface{}, ipAddress *string, parent *RefreshToken) (*TokenPair, error) {
 	// 生成访问令牌
-	accessToken, err := j.GenerateAccessToken(userID, email, role, userType)
+	accessToken, err := j.generateAccessToken(userID, email, role, userType, sessionID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate access token: %w", err)
 	}
 
 	// 生成刷新令牌并保存到数据库
-	refreshTokenString, _, err := j.GenerateAndStoreRefreshToken(ctx, userID, userType, deviceInfo, ipAddress)
+	refreshTokenString, refreshToken, err := j.generateAndStoreRefreshToken(ctx, userID, userType, sessionID, deviceInfo, ipAddress, parent)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate and store refresh token: %w", err)
 	}
 
 	return &TokenPair{
-		AccessToken:  accessToken,
-		RefreshToken: refreshTokenString,
-		TokenType:    "Bearer",
-		ExpiresIn:    int64(j.config.ExpireDuration.Seconds()),
+		AccessToken:      accessToken,
+		RefreshToken:     refreshTokenString,
+		TokenType:        "Bearer",
+		ExpiresIn:        int64(j.config.ExpireDuration.Seconds()),
+		RefreshTokenID:   refreshToken.ID,
+		RefreshExpiresAt: refreshToken.ExpiresAt,
 	}, nil
 }
 
 // GenerateAccessToken 生成访问令牌
 func (j *JWTManager) GenerateAccessToken(userID, email, role, userType string) (string, error) {
+	return j.generateAccessToken(userID, email, role, userType, "")
+}
+
+// generateAccessToken 生成访问令牌（可携带会话ID）
+func (j *JWTManager) generateAccessToken(userID, email, role, userType, sessionID string) (string, error) {
 	now := time.Now()
 
 	claims := &Claims{
-		UserID:   userID,
-		Email:    email,
-		Role:     role,
-		UserType: userType,
+		UserID:         userID,
+		Email:          email,
+		Role:           role,
+		UserType:       userType,
+		SessionID:      sessionID,
+		IssuedAtMicros: now.UnixMicro(),
 		RegisteredClaims: jwt.RegisteredClaims{
 			ID:        uuid.New().String(),
 			Subject:   userID,
@@ -126,8 +191,7 @@ func (j *JWTManager) GenerateAccessToken(userID, email, role, userType string) (
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(j.config.Secret))
+	return j.signClaims(claims)
 }
 
 // GenerateRefreshToken 生成刷新令牌
@@ -148,19 +212,12 @@ func (j *JWTManager) GenerateRefreshToken(userID, userType string) (string, erro
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(j.config.Secret))
+	return j.signClaims(claims)
 }
 
 // ValidateToken 验证令牌
 func (j *JWTManager) ValidateToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		// 验证签名方法
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(j.config.Secret), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, j.verificationKey)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse token: %w", err)
@@ -237,12 +294,30 @@ func (j *JWTManager) GetTokenRemainingTime(claims *Claims) time.Duration {
 
 // GenerateAndStoreRefreshToken 生成并存储刷新令牌
 func (j *JWTManager) GenerateAndStoreRefreshToken(ctx context.Context, userID, userType string, deviceInfo *map[string]interface{}, ipAddress *string) (string, string, error) {
+	tokenString, refreshToken, err := j.generateAndStoreRefreshToken(ctx, userID, userType, "", deviceInfo, ipAddress, nil)
+	if err != nil {
+		return "", "", err
+	}
+	return tokenString, refreshToken.TokenID, nil
+}
+
+// generateAndStoreRefreshToken 生成并存储刷新令牌，parent为空时开启新的令牌族
+func (j *JWTManager) generateAndStoreRefreshToken(ctx context.Context, userID, userType, sessionID string, deviceInfo *map[string]interface{}, ipAddress *string, parent *RefreshToken) (string, *RefreshToken, error) {
 	now := time.Now()
 	tokenID := uuid.New().String()
 
+	// 确定令牌族
+	familyID := tokenID
+	var parentTokenID *string
+	if parent != nil {
+		familyID = parent.FamilyID
+		parentTokenID = &parent.TokenID
+	}
+
 	claims := &Claims{
-		UserID:   userID,
-		UserType: userType,
+		UserID:    userID,
+		UserType:  userType,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ID:        tokenID,
 			Subject:   userID,
@@ -254,35 +329,41 @@ func (j *JWTManager) GenerateAndStoreRefreshToken(ctx context.Context, userID, u
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(j.config.Secret))
+	tokenString, err := j.signClaims(claims)
 	if err != nil {
-		return "", "", err
+		return "", nil, err
 	}
 
 	// 存储到数据库
 	refreshToken := &RefreshToken{
-		ID:         uuid.New().String(),
-		TokenID:    tokenID,
-		UserID:     userID,
-		UserType:   userType,
-		DeviceInfo: deviceInfo,
-		IPAddress:  ipAddress,
-		IsRevoked:  false,
-		ExpiresAt:  claims.ExpiresAt.Time,
-		CreatedAt:  now,
-		UpdatedAt:  now,
+		ID:            uuid.New().String(),
+		TokenID:       tokenID,
+		FamilyID:      familyID,
+		ParentTokenID: parentTokenID,
+		UserID:        userID,
+		UserType:      userType,
+		DeviceInfo:    deviceInfo,
+		IPAddress:     ipAddress,
+		IsRevoked:     false,
+		ExpiresAt:     claims.ExpiresAt.Time,
+		CreatedAt:     now,
+		UpdatedAt:     now,
 	}
 
 	if err := j.storeRefreshToken(ctx, refreshToken); err != nil {
-		return "", "", fmt.Errorf("failed to store refresh token: %w", err)
+		return "", nil, fmt.Errorf("failed to store refresh token: %w", err)
 	}
 
-	return tokenString, tokenID, nil
+	return tokenString, refreshToken, nil
 }
 
 // ValidateAndGetRefreshToken 验证并获取刷新令牌
 func (j *JWTManager) ValidateAndGetRefreshToken(ctx context.Context, tokenString string) (*RefreshToken, *Claims, error) {
+	return j.validateAndGetRefreshToken(ctx, tokenString, nil)
+}
+
+// validateAndGetRefreshToken 验证并获取刷新令牌，已轮换的令牌被再次使用时撤销整个令牌族
+func (j *JWTManager) validateAndGetRefreshToken(ctx context.Context, tokenString string, ipAddress *string) (*RefreshToken, *Claims, error) {
 	// 验证JWT的签名和结构
 	claims, err := j.ValidateRefreshToken(tokenString)
 	if err != nil {
@@ -297,6 +378,11 @@ func (j *JWTManager) ValidateAndGetRefreshToken(ctx context.Context, tokenString
 
 	// 检查令牌是否有效
 	if !refreshToken.IsValid() {
+		if refreshToken.IsRotated() {
+			// 已轮换的令牌被再次出示，说明令牌可能已被窃取
+			j.handleRefreshTokenReuse(ctx, refreshToken, ipAddress)
+			return nil, nil, ErrRefreshTokenReused
+		}
 		if refreshToken.IsRevoked {
 			return nil, nil, errors.New("refresh token has been revoked")
 		}
@@ -308,27 +394,41 @@ func (j *JWTManager) ValidateAndGetRefreshToken(ctx context.Context, tokenString
 	return refreshToken, claims, nil
 }
 
-// RefreshTokenPairWithRotation 刷新令牌轮换（撤销旧令牌并创建新令牌）
-func (j *JWTManager) RefreshTokenPairWithRotation(ctx context.Context, refreshTokenString, email, role string) (*TokenPair, error) {
+// RefreshTokenPairWithRotation 刷新令牌轮换（撤销旧令牌并在同一令牌族中创建新令牌）
+func (j *JWTManager) RefreshTokenPairWithRotation(ctx context.Context, refreshTokenString, email, role string, ipAddress *string) (*TokenPair, error) {
 	// 验证旧刷新令牌
-	oldToken, claims, err := j.ValidateAndGetRefreshToken(ctx, refreshTokenString)
+	oldToken, claims, err := j.validateAndGetRefreshToken(ctx, refreshTokenString, ipAddress)
 	if err != nil {
 		return nil, fmt.Errorf("invalid refresh token: %w", err)
 	}
 
-	// 撤销旧令牌
-	if err := j.revokeRefreshToken(ctx, oldToken.TokenID); err != nil {
-		return nil, fmt.Errorf("failed to revoke old token: %w", err)
+	// 撤销旧令牌（标记为已轮换）
+	if err := j.revokeRefreshToken(ctx, oldToken.TokenID, RefreshTokenRevokedRotated); err != nil {
+		// 并发请求已抢先轮换了该令牌，按重放处理
+		j.handleRefreshTokenReuse(ctx, oldToken, ipAddress)
+		return nil, fmt.Errorf("failed to revoke old token: %w", ErrRefreshTokenReused)
 	}
 
-	// 生成新的令牌对
-	newTokenPair, err := j.GenerateTokenPairWithContext(ctx, claims.UserID, email, role, claims.UserType, oldToken.DeviceInfo, oldToken.IPAddress)
+	if ipAddress == nil {
+		ipAddress = oldToken.IPAddress
+	}
+
+	// 生成新的令牌对（沿用原会话）
+	newTokenPair, err := j.generateTokenPair(ctx, claims.UserID, email, role, claims.UserType, claims.SessionID, oldToken.DeviceInfo, ipAddress, oldToken)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate new token pair: %w", err)
 	}
 
+	// 会话关联到新的刷新令牌
+	if claims.SessionID != "" {
+		if err := j.relinkSession(ctx, claims.SessionID, newTokenPair.RefreshTokenID, newTokenPair.RefreshExpiresAt); err != nil {
+			j.logger.WithError(err).WithField("session_id", claims.SessionID).Warn("Failed to relink session to rotated refresh token")
+		}
+	}
+
 	j.logger.WithFields(logrus.Fields{
 		"old_token_id": oldToken.TokenID,
+		"family_id":    oldToken.FamilyID,
 		"user_id":      claims.UserID,
 	}).Info("Refresh token rotation completed")
 
@@ -337,18 +437,55 @@ func (j *JWTManager) RefreshTokenPairWithRotation(ctx context.Context, refreshTo
 
 // RevokeRefreshToken 撤销刷新令牌
 func (j *JWTManager) RevokeRefreshToken(ctx context.Context, tokenID string) error {
-	return j.revokeRefreshToken(ctx, tokenID)
+	return j.revokeRefreshToken(ctx, tokenID, RefreshTokenRevokedLogout)
+}
+
+// RevokeRefreshTokenFamily 撤销整个令牌族
+func (j *JWTManager) RevokeRefreshTokenFamily(ctx context.Context, familyID, reason string) (int64, error) {
+	query := `
+		UPDATE refresh_tokens 
+		SET is_revoked = true, revoked_reason = $2, updated_at = NOW() 
+		WHERE family_id = $1 AND is_revoked = false
+	`
+
+	result, err := j.db.ExecContext(ctx, query, familyID, reason)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke refresh token family: %w", err)
+	}
+
+	revoked, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	// 停用关联到该令牌族的会话
+	if _, err := j.db.ExecContext(ctx, `
+		UPDATE user_sessions
+		SET is_active = false, last_activity = NOW()
+		WHERE is_active = true
+		  AND refresh_token_id IN (SELECT id FROM refresh_tokens WHERE family_id = $1)
+	`, familyID); err != nil {
+		j.logger.WithError(err).WithField("family_id", familyID).Warn("Failed to deactivate sessions of revoked token family")
+	}
+
+	j.logger.WithFields(logrus.Fields{
+		"family_id":     familyID,
+		"reason":        reason,
+		"revoked_count": revoked,
+	}).Info("Refresh token family revoked")
+
+	return revoked, nil
 }
 
 // RevokeAllUserRefreshTokens 撤销用户所有刷新令牌
 func (j *JWTManager) RevokeAllUserRefreshTokens(ctx context.Context, userID, userType string) error {
 	query := `
 		UPDATE refresh_tokens 
-		SET is_revoked = true, updated_at = NOW() 
+		SET is_revoked = true, revoked_reason = $3, updated_at = NOW() 
 		WHERE user_id = $1 AND user_type = $2 AND is_revoked = false
 	`
 
-	_, err := j.db.ExecContext(ctx, query, userID, userType)
+	_, err := j.db.ExecContext(ctx, query, userID, userType, RefreshTokenRevokedAll)
 	if err != nil {
 		j.logger.WithError(err).WithFields(logrus.Fields{
 			"user_id":   userID,
@@ -365,6 +502,80 @@ func (j *JWTManager) RevokeAllUserRefreshTokens(ctx context.Context, userID, use
 	return nil
 }
 
+// RevokeSession 撤销登录会话：撤销会话关联的刷新令牌族，并使该会话的访问令牌立即失效
+func (j *JWTManager) RevokeSession(ctx context.Context, sessionID string, refreshTokenID *string) error {
+	if refreshTokenID != nil && *refreshTokenID != "" {
+		var familyID string
+		err := j.db.QueryRowContext(ctx, `SELECT family_id FROM refresh_tokens WHERE id = $1`, *refreshTokenID).Scan(&familyID)
+		if err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("failed to get session refresh token: %w", err)
+		}
+		if err == nil {
+			if _, err := j.RevokeRefreshTokenFamily(ctx, familyID, RefreshTokenRevokedLogout); err != nil {
+				return err
+			}
+		}
+	}
+
+	if j.revocation != nil {
+		if err := j.revocation.RevokeSession(ctx, sessionID); err != nil {
+			j.logger.WithError(err).WithField("session_id", sessionID).Error("Failed to revoke session access tokens")
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RevokeAllUserTokens 撤销用户所有令牌（刷新令牌 + 当前时间之前签发的访问令牌）
+func (j *JWTManager) RevokeAllUserTokens(ctx context.Context, userID, userType string) error {
+	if err := j.RevokeAllUserRefreshTokens(ctx, userID, userType); err != nil {
+		return err
+	}
+
+	if j.revocation != nil {
+		if err := j.revocation.RevokeUserTokensBefore(ctx, userID, userType, time.Now()); err != nil {
+			j.logger.WithError(err).WithFields(logrus.Fields{
+				"user_id":   userID,
+				"user_type": userType,
+			}).Error("Failed to revoke user access tokens")
+			return fmt.Errorf("failed to revoke user access tokens: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// RevokeAccessToken 立即撤销单个访问令牌
+func (j *JWTManager) RevokeAccessToken(ctx context.Context, claims *Claims) error {
+	if j.revocation == nil || claims.ExpiresAt == nil {
+		return nil
+	}
+
+	if err := j.revocation.RevokeToken(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+		j.logger.WithError(err).WithField("jti", claims.ID).Error("Failed to revoke access token")
+		return err
+	}
+
+	return nil
+}
+
+// JWKS 获取公开验证密钥集合（HS256模式下为空）
+func (j *JWTManager) JWKS() *JWKSet {
+	if j.keys == nil {
+		return &JWKSet{Keys: []JWK{}}
+	}
+	return j.keys.JWKS()
+}
+
+// IsAccessTokenRevoked 检查访问令牌是否已被撤销
+func (j *JWTManager) IsAccessTokenRevoked(ctx context.Context, claims *Claims) (bool, error) {
+	if j.revocation == nil {
+		return false, nil
+	}
+	return j.revocation.IsRevoked(ctx, claims)
+}
+
 // CleanupExpiredRefreshTokens 清理过期的刷新令牌
 func (j *JWTManager) CleanupExpiredRefreshTokens(ctx context.Context) (int64, error) {
 	query := `DELETE FROM refresh_tokens WHERE expires_at < NOW()`
@@ -388,6 +599,60 @@ func (j *JWTManager) CleanupExpiredRefreshTokens(ctx context.Context) (int64, er
 
 // ========== 私有辅助方法 ==========
 
+// signClaims 使用当前签名密钥签名（非对称密钥在头部携带kid）
+func (j *JWTManager) signClaims(claims *Claims) (string, error) {
+	if j.keys == nil {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		return token.SignedString([]byte(j.config.Secret))
+	}
+
+	key, err := j.keys.SigningKey()
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(key.SigningMethod(), claims)
+	token.Header["kid"] = key.KID
+	return token.SignedString(key.PrivateKey)
+}
+
+// verificationKey 根据令牌头部的alg和kid选择验证密钥
+func (j *JWTManager) verificationKey(token *jwt.Token) (interface{}, error) {
+	switch token.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		// 启用非对称签名后，仅在迁移期内接受旧的HS256令牌
+		if j.keys != nil && !j.config.AcceptHS256 {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(j.config.Secret), nil
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodECDSA, *jwt.SigningMethodEd25519:
+		if j.keys == nil {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			return nil, errors.New("token is missing kid header")
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		key, err := j.keys.VerificationKey(ctx, kid)
+		if err != nil {
+			return nil, err
+		}
+
+		// 防止算法混淆：令牌算法必须与密钥算法一致
+		if key.Algorithm != token.Method.Alg() {
+			return nil, fmt.Errorf("signing method %v does not match key %s", token.Header["alg"], kid)
+		}
+		return key.PublicKey, nil
+	default:
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+}
+
 // storeRefreshToken 存储刷新令牌到数据库
 func (j *JWTManager) storeRefreshToken(ctx context.Context, token *RefreshToken) error {
 	// 序列化设备信息
@@ -404,13 +669,13 @@ func (j *JWTManager) storeRefreshToken(ctx context.Context, token *RefreshToken)
 
 	query := `
 		INSERT INTO refresh_tokens (
-			id, token_id, user_id, user_type, device_info, ip_address,
+			id, token_id, family_id, parent_token_id, user_id, user_type, device_info, ip_address,
 			is_revoked, expires_at, created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 	`
 
 	_, err := j.db.ExecContext(ctx, query,
-		token.ID, token.TokenID, token.UserID, token.UserType,
+		token.ID, token.TokenID, token.FamilyID, token.ParentTokenID, token.UserID, token.UserType,
 		deviceInfoJSON, token.IPAddress, token.IsRevoked,
 		token.ExpiresAt, token.CreatedAt, token.UpdatedAt)
 
@@ -424,8 +689,8 @@ func (j *JWTManager) storeRefreshToken(ctx context.Context, token *RefreshToken)
 // getRefreshTokenByTokenID 根据TokenID获取刷新令牌
 func (j *JWTManager) getRefreshTokenByTokenID(ctx context.Context, tokenID string) (*RefreshToken, error) {
 	query := `
-		SELECT id, token_id, user_id, user_type, device_info, ip_address,
-		       is_revoked, expires_at, created_at, updated_at
+		SELECT id, token_id, family_id, parent_token_id, user_id, user_type, device_info, ip_address,
+		       is_revoked, revoked_reason, expires_at, created_at, updated_at
 		FROM refresh_tokens
 		WHERE token_id = $1
 	`
@@ -434,8 +699,8 @@ func (j *JWTManager) getRefreshTokenByTokenID(ctx context.Context, tokenID strin
 	var deviceInfoJSON sql.NullString
 
 	err := j.db.QueryRowContext(ctx, query, tokenID).Scan(
-		&token.ID, &token.TokenID, &token.UserID, &token.UserType,
-		&deviceInfoJSON, &token.IPAddress, &token.IsRevoked,
+		&token.ID, &token.TokenID, &token.FamilyID, &token.ParentTokenID, &token.UserID, &token.UserType,
+		&deviceInfoJSON, &token.IPAddress, &token.IsRevoked, &token.RevokedReason,
 		&token.ExpiresAt, &token.CreatedAt, &token.UpdatedAt)
 
 	if err != nil {
@@ -456,15 +721,30 @@ func (j *JWTManager) getRefreshTokenByTokenID(ctx context.Context, tokenID strin
 	return token, nil
 }
 
+// relinkSession 将会话关联到轮换后的刷新令牌并刷新活动时间
+func (j *JWTManager) relinkSession(ctx context.Context, sessionID, refreshTokenID string, expiresAt time.Time) error {
+	query := `
+		UPDATE user_sessions
+		SET refresh_token_id = $2, expires_at = $3, last_activity = NOW()
+		WHERE session_id = $1 AND is_active = true
+	`
+
+	if _, err := j.db.ExecContext(ctx, query, sessionID, refreshTokenID, expiresAt); err != nil {
+		return fmt.Errorf("failed to relink session: %w", err)
+	}
+
+	return nil
+}
+
 // revokeRefreshToken 撤销刷新令牌
-func (j *JWTManager) revokeRefreshToken(ctx context.Context, tokenID string) error {
+func (j *JWTManager) revokeRefreshToken(ctx context.Context, tokenID, reason string) error {
 	query := `
 		UPDATE refresh_tokens 
-		SET is_revoked = true, updated_at = NOW() 
+		SET is_revoked = true, revoked_reason = $2, updated_at = NOW() 
 		WHERE token_id = $1 AND is_revoked = false
 	`
 
-	result, err := j.db.ExecContext(ctx, query, tokenID)
+	result, err := j.db.ExecContext(ctx, query, tokenID, reason)
 	if err != nil {
 		return fmt.Errorf("failed to revoke refresh token: %w", err)
 	}
@@ -481,3 +761,91 @@ func (j *JWTManager) revokeRefreshToken(ctx context.Context, tokenID string) err
 	j.logger.WithField("token_id", tokenID).Info("Refresh token revoked")
 	return nil
 }
+
+// handleRefreshTokenReuse 处理刷新令牌重放：撤销整个令牌族、记录安全事件并通知用户
+func (j *JWTManager) handleRefreshTokenReuse(ctx context.Context, token *RefreshToken, ipAddress *string) {
+	logger := j.logger.WithFields(logrus.Fields{
+		"token_id":  token.TokenID,
+		"family_id": token.FamilyID,
+		"user_id":   token.UserID,
+		"user_type": token.UserType,
+	})
+	logger.Warn("Refresh token reuse detected, revoking token family")
+
+	if _, err := j.RevokeRefreshTokenFamily(ctx, token.FamilyID, RefreshTokenRevokedReuseDetected); err != nil {
+		logger.WithError(err).Error("Failed to revoke refresh token family")
+	}
+
+	if ipAddress == nil {
+		ipAddress = token.IPAddress
+	}
+
+	event := &SecurityEvent{
+		Type:       SecurityEventRefreshTokenReuse,
+		UserID:     token.UserID,
+		UserType:   token.UserType,
+		FamilyID:   token.FamilyID,
+		IPAddress:  ipAddress,
+		DeviceInfo: token.DeviceInfo,
+		OccurredAt: time.Now(),
+	}
+
+	email, err := j.logSecurityEvent(ctx, event)
+	if err != nil {
+		logger.WithError(err).Error("Failed to log refresh token reuse event")
+	}
+	event.Email = email
+
+	// 异步通知用户（不影响响应速度）
+	if j.notifier != nil && event.Email != "" {
+		go func() {
+			notifyCtx, notifyCancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer notifyCancel()
+			if notifyErr := j.notifier.NotifySecurityEvent(notifyCtx, event); notifyErr != nil {
+				logger.WithError(notifyErr).Error("Failed to notify user of refresh token reuse")
+			}
+		}()
+	}
+}
+
+// logSecurityEvent 将安全事件写入登录日志，返回事件所属账户的邮箱
+func (j *JWTManager) logSecurityEvent(ctx context.Context, event *SecurityEvent) (string, error) {
+	var deviceInfoJSON interface{}
+	if event.DeviceInfo != nil {
+		data, err := json.Marshal(event.DeviceInfo)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal device info: %w", err)
+		}
+		deviceInfoJSON = string(data)
+	}
+
+	ipAddress := "0.0.0.0"
+	if event.IPAddress != nil && *event.IPAddress != "" {
+		ipAddress = *event.IPAddress
+	}
+
+	query := `
+		INSERT INTO login_logs (
+			id, user_id, email, user_type, login_status, failure_reason,
+			ip_address, device_info, session_id, risk_score, created_at
+		)
+		SELECT $1::uuid, $2::uuid,
+		       COALESCE(
+		           (SELECT email FROM users WHERE id = $2::uuid AND $3::varchar = 'user'),
+		           (SELECT email FROM admins WHERE id = $2::uuid AND $3::varchar = 'admin'),
+		           ''
+		       ),
+		       $3::varchar, 'suspicious', $4::varchar, $5::inet, $6::jsonb, $7::varchar, 100, $8
+		RETURNING email
+	`
+
+	var email string
+	err := j.db.QueryRowContext(ctx, query,
+		uuid.New().String(), event.UserID, event.UserType, event.Type,
+		ipAddress, deviceInfoJSON, event.FamilyID, event.OccurredAt).Scan(&email)
+	if err != nil {
+		return "", fmt.Errorf("failed to create security event log: %w", err)
+	}
+
+	return email, nil
+}
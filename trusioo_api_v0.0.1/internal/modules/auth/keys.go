@@ -0,0 +1,518 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"database/sql"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sync"
+	"time"
+
+	"trusioo_api_v0.0.1/internal/config"
+	"trusioo_api_v0.0.1/internal/infrastructure/database"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// 支持的签名算法
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+	AlgorithmES256 = "ES256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+// 签名密钥状态
+const (
+	SigningKeyStatusActive  = "active"  // 用于签名和验证
+	SigningKeyStatusRetired = "retired" // 仅在宽限期内用于验证
+)
+
+const (
+	// keyRotationLockID 密钥轮换的PostgreSQL咨询锁ID，避免多实例同时轮换
+	keyRotationLockID = 7283910451
+	// keyReloadMinInterval 遇到未知kid时重新加载密钥的最小间隔
+	keyReloadMinInterval = 30 * time.Second
+	// keyMaintenanceInterval 后台检查轮换和刷新密钥的间隔
+	keyMaintenanceInterval = 10 * time.Minute
+)
+
+// SigningKey JWT签名密钥
+type SigningKey struct {
+	ID         string           `json:"id" db:"id"`
+	KID        string           `json:"kid" db:"kid"`
+	Algorithm  string           `json:"algorithm" db:"algorithm"`
+	PrivateKey crypto.Signer    `json:"-" db:"private_key"`
+	PublicKey  crypto.PublicKey `json:"-" db:"public_key"`
+	Status     string           `json:"status" db:"status"`
+	RetiredAt  *time.Time       `json:"retired_at" db:"retired_at"`
+	ExpiresAt  *time.Time       `json:"expires_at" db:"expires_at"`
+	CreatedAt  time.Time        `json:"created_at" db:"created_at"`
+}
+
+// IsUsableForVerification 检查密钥是否仍可用于验证
+func (k *SigningKey) IsUsableForVerification() bool {
+	return k.Status == SigningKeyStatusActive || (k.ExpiresAt != nil && time.Now().Before(*k.ExpiresAt))
+}
+
+// SigningMethod 获取对应的JWT签名方法
+func (k *SigningKey) SigningMethod() jwt.SigningMethod {
+	return signingMethodFor(k.Algorithm)
+}
+
+// KeyManager 非对称签名密钥管理器（存储在数据库中，支持定期轮换）
+type KeyManager struct {
+	config        *config.JWTConfig
+	db            *database.Database
+	encryptionKey []byte
+	logger        *logrus.Logger
+
+	mu         sync.RWMutex
+	active     *SigningKey
+	keys       map[string]*SigningKey
+	lastReload time.Time
+}
+
+// NewKeyManager 创建密钥管理器
+func NewKeyManager(cfg *config.JWTConfig, db *database.Database, logger *logrus.Logger) (*KeyManager, error) {
+	if signingMethodFor(cfg.Algorithm) == nil || cfg.Algorithm == AlgorithmHS256 {
+		return nil, fmt.Errorf("unsupported asymmetric jwt algorithm: %s", cfg.Algorithm)
+	}
+
+	// 私钥以AES-GCM加密后存储，加密密钥由JWT密钥派生
+	sum := sha256.Sum256([]byte("jwt-signing-key:" + cfg.Secret))
+
+	return &KeyManager{
+		config:        cfg,
+		db:            db,
+		encryptionKey: sum[:],
+		logger:        logger,
+		keys:          make(map[string]*SigningKey),
+	}, nil
+}
+
+// Init 加载密钥，必要时生成首个签名密钥
+func (km *KeyManager) Init(ctx context.Context) error {
+	if err := km.RotateIfDue(ctx); err != nil {
+		return err
+	}
+	return km.Reload(ctx)
+}
+
+// Start 启动后台密钥维护（轮换检查和刷新其他实例生成的密钥）
+func (km *KeyManager) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(keyMaintenanceInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				runCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+				if err := km.RotateIfDue(runCtx); err != nil {
+					km.logger.WithError(err).Error("Failed to rotate jwt signing key")
+				}
+				if err := km.Reload(runCtx); err != nil {
+					km.logger.WithError(err).Error("Failed to reload jwt signing keys")
+				}
+				cancel()
+			}
+		}
+	}()
+}
+
+// SigningKey 获取当前用于签名的密钥
+func (km *KeyManager) SigningKey() (*SigningKey, error) {
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+
+	if km.active == nil {
+		return nil, errors.New("no active jwt signing key")
+	}
+	return km.active, nil
+}
+
+// VerificationKey 根据kid获取验证密钥，未知kid时尝试重新加载
+func (km *KeyManager) VerificationKey(ctx context.Context, kid string) (*SigningKey, error) {
+	km.mu.RLock()
+	key, ok := km.keys[kid]
+	canReload := time.Since(km.lastReload) > keyReloadMinInterval
+	km.mu.RUnlock()
+
+	if !ok && canReload {
+		if err := km.Reload(ctx); err != nil {
+			return nil, err
+		}
+		km.mu.RLock()
+		key, ok = km.keys[kid]
+		km.mu.RUnlock()
+	}
+
+	if !ok || !key.IsUsableForVerification() {
+		return nil, fmt.Errorf("unknown or expired signing key: %s", kid)
+	}
+
+	return key, nil
+}
+
+// Reload 从数据库重新加载可用密钥
+func (km *KeyManager) Reload(ctx context.Context) error {
+	query := `
+		SELECT id, kid, algorithm, private_key, public_key, status, retired_at, expires_at, created_at
+		FROM jwt_signing_keys
+		WHERE status = $1 OR expires_at > NOW()
+		ORDER BY created_at DESC
+	`
+
+	rows, err := km.db.QueryContext(ctx, query, SigningKeyStatusActive)
+	if err != nil {
+		return fmt.Errorf("failed to load signing keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := make(map[string]*SigningKey)
+	var active *SigningKey
+
+	for rows.Next() {
+		key := &SigningKey{}
+		var encryptedPrivate, publicPEM string
+		if err := rows.Scan(&key.ID, &key.KID, &key.Algorithm, &encryptedPrivate, &publicPEM,
+			&key.Status, &key.RetiredAt, &key.ExpiresAt, &key.CreatedAt); err != nil {
+			return fmt.Errorf("failed to scan signing key: %w", err)
+		}
+
+		if key.PublicKey, err = parsePublicKeyPEM(publicPEM); err != nil {
+			km.logger.WithError(err).WithField("kid", key.KID).Error("Skipping signing key with invalid public key")
+			continue
+		}
+
+		if key.Status == SigningKeyStatusActive {
+			if key.PrivateKey, err = km.decryptPrivateKey(encryptedPrivate); err != nil {
+				km.logger.WithError(err).WithField("kid", key.KID).Error("Skipping signing key with undecryptable private key")
+				continue
+			}
+			// 多个active时以最新的为准
+			if active == nil && key.Algorithm == km.config.Algorithm {
+				active = key
+			}
+		}
+
+		keys[key.KID] = key
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to iterate signing keys: %w", err)
+	}
+
+	km.mu.Lock()
+	km.keys = keys
+	if active != nil {
+		km.active = active
+	}
+	km.lastReload = time.Now()
+	km.mu.Unlock()
+
+	return nil
+}
+
+// RotateIfDue 在当前密钥超过轮换周期（或算法变更）时生成新密钥
+func (km *KeyManager) RotateIfDue(ctx context.Context) error {
+	return km.rotate(ctx, false)
+}
+
+// Rotate 立即轮换签名密钥
+func (km *KeyManager) Rotate(ctx context.Context) error {
+	return km.rotate(ctx, true)
+}
+
+// rotate 轮换签名密钥：旧密钥退役并保留宽限期用于验证
+func (km *KeyManager) rotate(ctx context.Context, force bool) error {
+	var rotatedKID string
+
+	err := km.db.Transaction(func(tx *sql.Tx) error {
+		// 多实例部署时只允许一个实例执行轮换
+		if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, keyRotationLockID); err != nil {
+			return fmt.Errorf("failed to acquire key rotation lock: %w", err)
+		}
+
+		if !force {
+			var algorithm string
+			var createdAt time.Time
+			err := tx.QueryRowContext(ctx, `
+				SELECT algorithm, created_at FROM jwt_signing_keys
+				WHERE status = $1
+				ORDER BY created_at DESC
+				LIMIT 1
+			`, SigningKeyStatusActive).Scan(&algorithm, &createdAt)
+			if err != nil && err != sql.ErrNoRows {
+				return fmt.Errorf("failed to get active signing key: %w", err)
+			}
+			if err == nil && algorithm == km.config.Algorithm && time.Since(createdAt) < km.config.KeyRotationInterval {
+				return nil
+			}
+		}
+
+		kid, privatePEM, publicPEM, err := km.generateKey()
+		if err != nil {
+			return err
+		}
+
+		// 退役当前密钥
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE jwt_signing_keys
+			SET status = $1, retired_at = NOW(), expires_at = NOW() + ($2 * INTERVAL '1 second')
+			WHERE status = $3
+		`, SigningKeyStatusRetired, int64(km.config.KeyGracePeriod.Seconds()), SigningKeyStatusActive); err != nil {
+			return fmt.Errorf("failed to retire signing keys: %w", err)
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO jwt_signing_keys (id, kid, algorithm, private_key, public_key, status, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		`, uuid.New().String(), kid, km.config.Algorithm, privatePEM, publicPEM, SigningKeyStatusActive); err != nil {
+			return fmt.Errorf("failed to store signing key: %w", err)
+		}
+
+		// 删除宽限期已过的旧密钥
+		if _, err := tx.ExecContext(ctx, `
+			DELETE FROM jwt_signing_keys WHERE status = $1 AND expires_at < NOW()
+		`, SigningKeyStatusRetired); err != nil {
+			return fmt.Errorf("failed to cleanup expired signing keys: %w", err)
+		}
+
+		rotatedKID = kid
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if rotatedKID != "" {
+		km.logger.WithFields(logrus.Fields{
+			"kid":       rotatedKID,
+			"algorithm": km.config.Algorithm,
+		}).Info("JWT signing key rotated")
+		return km.Reload(ctx)
+	}
+
+	return nil
+}
+
+// JWKS 获取可公开的验证密钥集合
+func (km *KeyManager) JWKS() *JWKSet {
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+
+	set := &JWKSet{Keys: make([]JWK, 0, len(km.keys))}
+	for _, key := range km.keys {
+		if !key.IsUsableForVerification() {
+			continue
+		}
+		jwk, err := publicKeyToJWK(key)
+		if err != nil {
+			km.logger.WithError(err).WithField("kid", key.KID).Warn("Failed to convert key to JWK")
+			continue
+		}
+		set.Keys = append(set.Keys, *jwk)
+	}
+
+	return set
+}
+
+// ========== 密钥生成与序列化 ==========
+
+// generateKey 按配置算法生成新的密钥对
+func (km *KeyManager) generateKey() (string, string, string, error) {
+	var private crypto.Signer
+	var err error
+
+	switch km.config.Algorithm {
+	case AlgorithmRS256:
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	case AlgorithmES256:
+		private, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case AlgorithmEdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		err = fmt.Errorf("unsupported jwt algorithm: %s", km.config.Algorithm)
+	}
+	if err != nil {
+		return "", "", "", fmt.Errorf("failed to generate signing key: %w", err)
+	}
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return "", "", "", fmt.Errorf("failed to marshal private key: %w", err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(private.Public())
+	if err != nil {
+		return "", "", "", fmt.Errorf("failed to marshal public key: %w", err)
+	}
+
+	encryptedPrivate, err := km.encryptPrivateKey(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}))
+	if err != nil {
+		return "", "", "", err
+	}
+	publicPEM := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}))
+
+	// kid 使用公钥指纹，便于排查
+	fingerprint := sha256.Sum256(publicDER)
+	kid := base64.RawURLEncoding.EncodeToString(fingerprint[:16])
+
+	return kid, encryptedPrivate, publicPEM, nil
+}
+
+// encryptPrivateKey 使用AES-GCM加密私钥
+func (km *KeyManager) encryptPrivateKey(plaintext []byte) (string, error) {
+	block, err := aes.NewCipher(km.encryptionKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to create cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", fmt.Errorf("failed to create gcm: %w", err)
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	ciphertext := gcm.Seal(nonce, nonce, plaintext, nil)
+	return base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// decryptPrivateKey 解密并解析私钥
+func (km *KeyManager) decryptPrivateKey(encoded string) (crypto.Signer, error) {
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode private key: %w", err)
+	}
+
+	block, err := aes.NewCipher(km.encryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gcm: %w", err)
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("encrypted private key is too short")
+	}
+
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt private key: %w", err)
+	}
+
+	pemBlock, _ := pem.Decode(plaintext)
+	if pemBlock == nil {
+		return nil, errors.New("invalid private key pem")
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(pemBlock.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+
+	signer, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("private key is not a signer")
+	}
+
+	return signer, nil
+}
+
+// parsePublicKeyPEM 解析PEM格式公钥
+func parsePublicKeyPEM(publicPEM string) (crypto.PublicKey, error) {
+	block, _ := pem.Decode([]byte(publicPEM))
+	if block == nil {
+		return nil, errors.New("invalid public key pem")
+	}
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+// signingMethodFor 根据算法名获取JWT签名方法
+func signingMethodFor(algorithm string) jwt.SigningMethod {
+	switch algorithm {
+	case AlgorithmHS256:
+		return jwt.SigningMethodHS256
+	case AlgorithmRS256:
+		return jwt.SigningMethodRS256
+	case AlgorithmES256:
+		return jwt.SigningMethodES256
+	case AlgorithmEdDSA:
+		return jwt.SigningMethodEdDSA
+	default:
+		return nil
+	}
+}
+
+// ========== JWKS ==========
+
+// JWK JSON Web Key（RFC 7517）
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKSet JSON Web Key Set
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// publicKeyToJWK 将公钥转换为JWK
+func publicKeyToJWK(key *SigningKey) (*JWK, error) {
+	jwk := &JWK{
+		Use: "sig",
+		Alg: key.Algorithm,
+		Kid: key.KID,
+	}
+
+	switch pub := key.PublicKey.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		jwk.Kty = "EC"
+		jwk.Crv = pub.Curve.Params().Name
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub.X.FillBytes(make([]byte, size)))
+		jwk.Y = base64.RawURLEncoding.EncodeToString(pub.Y.FillBytes(make([]byte, size)))
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", key.PublicKey)
+	}
+
+	return jwk, nil
+}
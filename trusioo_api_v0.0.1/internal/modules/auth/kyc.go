@@ -0,0 +1,29 @@
+package auth
+
+import "context"
+
+// KYC认证等级
+const (
+	KYCTierNone     = 0 // 未认证，不允许提现
+	KYCTierBasic    = 1 // 已验证身份证件
+	KYCTierAdvanced = 2 // 已验证证件、人脸和住址
+)
+
+// KYCLimits KYC等级对应的钱包限制
+type KYCLimits struct {
+	Tier                 int     `json:"tier"`
+	WithdrawalEnabled    bool    `json:"withdrawal_enabled"`
+	DailyWithdrawalLimit float64 `json:"daily_withdrawal_limit"` // 每日提现限额（TRU）
+}
+
+// KYCLimitsProvider 按用户当前的KYC等级获取钱包限制
+// 钱包模块提现时以此为准，不依赖等级变更时同步到钱包上的开关和限额
+type KYCLimitsProvider interface {
+	GetKYCLimits(ctx context.Context, userID string) (*KYCLimits, error)
+}
+
+// KYCTierObserver KYC等级变更观察者接口（钱包模块据此更新提现开关和每日限额）
+// 在审核流程中同步调用，返回错误时由调用方记录日志，不影响审核结果（提现以KYCLimitsProvider为准）
+type KYCTierObserver interface {
+	OnKYCTierChanged(ctx context.Context, userID string, limits *KYCLimits) error
+}
@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"context"
+	"time"
+	_ "time/tzdata" // 内置时区数据，容器中没有系统时区库时也能按用户时区格式化时间
+)
+
+// 用户未设置时的默认语言和时区
+const (
+	DefaultLanguage = "en"
+	DefaultTimezone = "UTC"
+)
+
+// UserLocale 用户的首选语言和时区，供邮件、账单等需要本地化的功能使用
+type UserLocale struct {
+	Language string `json:"language" example:"en"`           // BCP 47语言标签
+	Timezone string `json:"timezone" example:"Africa/Lagos"` // IANA时区
+}
+
+// UserLocaleProvider 查询用户语言和时区的接口（由用户认证服务实现）
+type UserLocaleProvider interface {
+	GetUserLocale(ctx context.Context, userID string) (*UserLocale, error)
+}
+
+// DefaultUserLocale 返回默认语言和时区
+func DefaultUserLocale() *UserLocale {
+	return &UserLocale{Language: DefaultLanguage, Timezone: DefaultTimezone}
+}
+
+// Location 返回用户时区，未设置或无法识别时返回UTC
+func (l *UserLocale) Location() *time.Location {
+	if l == nil || l.Timezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(l.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// LanguageTag 返回用户语言，未设置时返回默认语言
+func (l *UserLocale) LanguageTag() string {
+	if l == nil || l.Language == "" {
+		return DefaultLanguage
+	}
+	return l.Language
+}
+
+// FormatTime 按用户时区格式化时间（用于邮件正文），locale为空时使用UTC
+func (l *UserLocale) FormatTime(t time.Time) string {
+	return t.In(l.Location()).Format(time.RFC1123)
+}
@@ -0,0 +1,400 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"trusioo_api_v0.0.1/internal/config"
+	"trusioo_api_v0.0.1/internal/infrastructure/redis"
+
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// lockoutKeyPrefix 登录锁定相关键前缀
+	lockoutKeyPrefix = "auth:lockout:"
+
+	// 计数维度
+	lockoutScopeAccount   = "account"    // 单个账户（防止针对账户的暴力破解）
+	lockoutScopeIP        = "ip"         // 单个IP（防止撞库）
+	lockoutScopeIPAccount = "ip_account" // IP+账户（最先触发，减少对正常用户的误伤）
+
+	// 键类型
+	lockoutKindFailures = "fail"  // 窗口内失败次数
+	lockoutKindLock     = "lock"  // 锁定标记（TTL即剩余锁定时间）
+	lockoutKindLevel    = "level" // 锁定升级次数（用于指数退避）
+)
+
+// LoginLockedError 登录因失败次数过多被临时锁定
+type LoginLockedError struct {
+	RetryAfter time.Duration
+}
+
+// Error 实现error接口（不区分锁定维度，避免泄露账户是否存在）
+func (e *LoginLockedError) Error() string {
+	return ErrLoginLocked.Error()
+}
+
+// Unwrap 支持 errors.Is(err, ErrLoginLocked)
+func (e *LoginLockedError) Unwrap() error {
+	return ErrLoginLocked
+}
+
+// LockoutStatus 锁定检查结果
+type LockoutStatus struct {
+	Locked     bool
+	RetryAfter time.Duration
+}
+
+// IPLockoutInfo 某个IP针对账户的锁定情况
+type IPLockoutInfo struct {
+	IPAddress      string     `json:"ip_address"`
+	FailedAttempts int64      `json:"failed_attempts"`
+	LockedUntil    *time.Time `json:"locked_until,omitempty"`
+}
+
+// AccountLockoutInfo 账户锁定情况（管理端查看）
+type AccountLockoutInfo struct {
+	Email          string          `json:"email"`
+	Locked         bool            `json:"locked"`
+	LockedUntil    *time.Time      `json:"locked_until,omitempty"`
+	FailedAttempts int64           `json:"failed_attempts"`
+	LockoutLevel   int64           `json:"lockout_level"`
+	IPLockouts     []IPLockoutInfo `json:"ip_lockouts"`
+}
+
+// LoginLockout 基于Redis的登录失败计数与渐进式锁定
+//
+// 失败次数按账户、IP、IP+账户三个维度分别计数，任一维度在窗口内达到阈值即锁定该维度，
+// 锁定时长从基础时长开始随锁定次数指数增长，直至上限。
+type LoginLockout struct {
+	redis  *redis.Client
+	config *config.LockoutConfig
+	logger *logrus.Logger
+}
+
+// NewLoginLockout 创建登录锁定服务
+func NewLoginLockout(redisClient *redis.Client, cfg *config.LockoutConfig, logger *logrus.Logger) *LoginLockout {
+	return &LoginLockout{
+		redis:  redisClient,
+		config: cfg,
+		logger: logger,
+	}
+}
+
+// Check 检查登录尝试是否处于锁定状态
+func (l *LoginLockout) Check(ctx context.Context, userType, email, ipAddress string) (*LockoutStatus, error) {
+	status := &LockoutStatus{}
+	if !l.config.Enabled {
+		return status, nil
+	}
+
+	scopes := lockoutScopes(userType, email, ipAddress)
+	pipe := l.redis.Pipeline()
+	ttls := make([]*goredis.DurationCmd, 0, len(scopes))
+	for _, scope := range scopes {
+		ttls = append(ttls, pipe.PTTL(ctx, lockoutKey(lockoutKindLock, scope)))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, goredis.Nil) {
+		return nil, fmt.Errorf("failed to check login lockout: %w", err)
+	}
+
+	for _, ttl := range ttls {
+		if remaining := ttl.Val(); remaining > 0 {
+			status.Locked = true
+			if remaining > status.RetryAfter {
+				status.RetryAfter = remaining
+			}
+		}
+	}
+
+	return status, nil
+}
+
+// RecordFailure 记录一次失败登录，达到阈值时锁定对应维度
+func (l *LoginLockout) RecordFailure(ctx context.Context, userType, email, ipAddress string) (*LockoutStatus, error) {
+	status := &LockoutStatus{}
+	if !l.config.Enabled {
+		return status, nil
+	}
+
+	thresholds := map[string]int{
+		lockoutScopeAccount:   l.config.AccountThreshold,
+		lockoutScopeIP:        l.config.IPThreshold,
+		lockoutScopeIPAccount: l.config.IPAccountThreshold,
+	}
+
+	for _, scope := range lockoutScopes(userType, email, ipAddress) {
+		threshold := thresholds[scopeType(scope)]
+		if threshold <= 0 {
+			continue
+		}
+
+		failuresKey := lockoutKey(lockoutKindFailures, scope)
+		count, err := l.redis.Incr(ctx, failuresKey).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to record login failure: %w", err)
+		}
+		// 首次失败时开始计时窗口
+		if count == 1 {
+			if err := l.redis.Expire(ctx, failuresKey, l.config.Window).Err(); err != nil {
+				return nil, fmt.Errorf("failed to set login failure window: %w", err)
+			}
+		}
+
+		if count < int64(threshold) {
+			continue
+		}
+
+		duration, err := l.lock(ctx, scope)
+		if err != nil {
+			return nil, err
+		}
+
+		status.Locked = true
+		if duration > status.RetryAfter {
+			status.RetryAfter = duration
+		}
+	}
+
+	return status, nil
+}
+
+// RecordSuccess 登录成功后清除账户相关的失败计数和锁定升级记录（IP维度保留）
+func (l *LoginLockout) RecordSuccess(ctx context.Context, userType, email, ipAddress string) error {
+	if !l.config.Enabled {
+		return nil
+	}
+
+	email = normalizeLockoutEmail(email)
+	keys := []string{
+		lockoutKey(lockoutKindFailures, accountScope(userType, email)),
+		lockoutKey(lockoutKindLevel, accountScope(userType, email)),
+		lockoutKey(lockoutKindFailures, ipAccountScope(userType, email, ipAddress)),
+		lockoutKey(lockoutKindLevel, ipAccountScope(userType, email, ipAddress)),
+	}
+
+	if err := l.redis.Del(ctx, keys...).Err(); err != nil {
+		return fmt.Errorf("failed to reset login failures: %w", err)
+	}
+	return nil
+}
+
+// GetAccountLockout 获取账户的锁定情况（包括各IP针对该账户的锁定）
+func (l *LoginLockout) GetAccountLockout(ctx context.Context, userType, email string) (*AccountLockoutInfo, error) {
+	email = normalizeLockoutEmail(email)
+	scope := accountScope(userType, email)
+
+	info := &AccountLockoutInfo{Email: email, IPLockouts: []IPLockoutInfo{}}
+
+	failures, err := l.getInt(ctx, lockoutKey(lockoutKindFailures, scope))
+	if err != nil {
+		return nil, err
+	}
+	level, err := l.getInt(ctx, lockoutKey(lockoutKindLevel, scope))
+	if err != nil {
+		return nil, err
+	}
+	lockedUntil, err := l.lockedUntil(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	info.FailedAttempts = failures
+	info.LockoutLevel = level
+	info.LockedUntil = lockedUntil
+	info.Locked = lockedUntil != nil
+
+	// 收集各IP针对该账户的计数和锁定
+	ips := make(map[string]*IPLockoutInfo)
+	for _, kind := range []string{lockoutKindFailures, lockoutKindLock} {
+		keys, err := l.scanKeys(ctx, lockoutKey(kind, ipAccountScope(userType, escapeLockoutPattern(email), "*")))
+		if err != nil {
+			return nil, err
+		}
+
+		prefix := lockoutKey(kind, ipAccountScope(userType, email, ""))
+		for _, key := range keys {
+			ip := strings.TrimPrefix(key, prefix)
+			entry, ok := ips[ip]
+			if !ok {
+				entry = &IPLockoutInfo{IPAddress: ip}
+				ips[ip] = entry
+			}
+
+			if kind == lockoutKindFailures {
+				if entry.FailedAttempts, err = l.getInt(ctx, key); err != nil {
+					return nil, err
+				}
+			} else if entry.LockedUntil, err = l.lockedUntil(ctx, ipAccountScope(userType, email, ip)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, entry := range ips {
+		if entry.LockedUntil != nil {
+			info.Locked = true
+		}
+		info.IPLockouts = append(info.IPLockouts, *entry)
+	}
+
+	return info, nil
+}
+
+// UnlockAccount 解除账户锁定，清除账户维度及所有IP+账户维度的计数和锁定
+func (l *LoginLockout) UnlockAccount(ctx context.Context, userType, email string) error {
+	email = normalizeLockoutEmail(email)
+
+	keys := make([]string, 0, 8)
+	for _, kind := range []string{lockoutKindFailures, lockoutKindLock, lockoutKindLevel} {
+		keys = append(keys, lockoutKey(kind, accountScope(userType, email)))
+
+		ipKeys, err := l.scanKeys(ctx, lockoutKey(kind, ipAccountScope(userType, escapeLockoutPattern(email), "*")))
+		if err != nil {
+			return err
+		}
+		keys = append(keys, ipKeys...)
+	}
+
+	if err := l.redis.Del(ctx, keys...).Err(); err != nil {
+		return fmt.Errorf("failed to unlock account: %w", err)
+	}
+
+	l.logger.WithFields(logrus.Fields{
+		"user_type": userType,
+		"email":     email,
+	}).Info("Login lockout cleared for account")
+
+	return nil
+}
+
+// lock 锁定某个维度，锁定时长按锁定次数指数增长
+func (l *LoginLockout) lock(ctx context.Context, scope string) (time.Duration, error) {
+	levelKey := lockoutKey(lockoutKindLevel, scope)
+	pipe := l.redis.TxPipeline()
+	level := pipe.Incr(ctx, levelKey)
+	pipe.Expire(ctx, levelKey, l.config.LevelTTL)
+	pipe.Del(ctx, lockoutKey(lockoutKindFailures, scope))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("failed to escalate login lockout: %w", err)
+	}
+
+	duration := l.lockDuration(level.Val())
+	if err := l.redis.Set(ctx, lockoutKey(lockoutKindLock, scope), time.Now().Add(duration).Unix(), duration).Err(); err != nil {
+		return 0, fmt.Errorf("failed to lock login: %w", err)
+	}
+
+	l.logger.WithFields(logrus.Fields{
+		"scope":    scope,
+		"level":    level.Val(),
+		"duration": duration.String(),
+	}).Warn("Login temporarily locked after repeated failures")
+
+	return duration, nil
+}
+
+// lockDuration 计算第level次锁定的时长：base * 2^(level-1)，不超过上限
+func (l *LoginLockout) lockDuration(level int64) time.Duration {
+	duration := l.config.BaseDuration
+	if duration <= 0 {
+		duration = time.Minute
+	}
+	for i := int64(1); i < level && duration < l.config.MaxDuration; i++ {
+		duration *= 2
+	}
+	if l.config.MaxDuration > 0 && duration > l.config.MaxDuration {
+		duration = l.config.MaxDuration
+	}
+	return duration
+}
+
+// lockedUntil 获取维度的锁定截止时间，未锁定时返回nil
+func (l *LoginLockout) lockedUntil(ctx context.Context, scope string) (*time.Time, error) {
+	ttl, err := l.redis.PTTL(ctx, lockoutKey(lockoutKindLock, scope)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get login lockout: %w", err)
+	}
+	if ttl <= 0 {
+		return nil, nil
+	}
+
+	until := time.Now().Add(ttl)
+	return &until, nil
+}
+
+// getInt 读取整数值，不存在时返回0
+func (l *LoginLockout) getInt(ctx context.Context, key string) (int64, error) {
+	value, err := l.redis.Get(ctx, key).Result()
+	if err != nil {
+		if errors.Is(err, goredis.Nil) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to get login lockout counter: %w", err)
+	}
+
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid login lockout counter: %w", err)
+	}
+	return n, nil
+}
+
+// scanKeys 按模式查找键
+func (l *LoginLockout) scanKeys(ctx context.Context, pattern string) ([]string, error) {
+	var keys []string
+	iter := l.redis.Scan(ctx, 0, pattern, 100).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan login lockout keys: %w", err)
+	}
+	return keys, nil
+}
+
+// lockoutScopes 一次登录尝试涉及的全部计数维度
+func lockoutScopes(userType, email, ipAddress string) []string {
+	email = normalizeLockoutEmail(email)
+	scopes := []string{accountScope(userType, email)}
+	if ipAddress != "" {
+		scopes = append(scopes, lockoutScopeIP+":"+ipAddress, ipAccountScope(userType, email, ipAddress))
+	}
+	return scopes
+}
+
+// accountScope 账户维度标识
+func accountScope(userType, email string) string {
+	return lockoutScopeAccount + ":" + userType + ":" + email
+}
+
+// ipAccountScope IP+账户维度标识（IP放在最后，便于按账户查找）
+func ipAccountScope(userType, email, ipAddress string) string {
+	return lockoutScopeIPAccount + ":" + userType + ":" + email + ":" + ipAddress
+}
+
+// scopeType 维度标识中的维度类型
+func scopeType(scope string) string {
+	return scope[:strings.Index(scope, ":")]
+}
+
+// lockoutKey 拼接Redis键
+func lockoutKey(kind, scope string) string {
+	return lockoutKeyPrefix + kind + ":" + scope
+}
+
+// normalizeLockoutEmail 统一邮箱格式，避免通过大小写绕过计数
+func normalizeLockoutEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// escapeLockoutPattern 转义Redis SCAN模式中的通配符
+func escapeLockoutPattern(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`, `]`, `\]`)
+	return replacer.Replace(value)
+}
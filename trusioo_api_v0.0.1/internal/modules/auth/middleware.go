@@ -3,6 +3,7 @@ package auth
 import (
 	"errors"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
@@ -10,8 +11,10 @@ import (
 
 // AuthMiddleware 认证中间件结构
 type AuthMiddleware struct {
-	jwtManager *JWTManager
-	logger     *logrus.Logger
+	jwtManager    *JWTManager
+	permissions   *PermissionStore
+	impersonation ImpersonationAuditor
+	logger        *logrus.Logger
 }
 
 // NewAuthMiddleware 创建新的认证中间件
@@ -22,6 +25,11 @@ func NewAuthMiddleware(jwtManager *JWTManager, logger *logrus.Logger) *AuthMiddl
 	}
 }
 
+// SetPermissionStore 设置角色权限查询（用于RequirePermission）
+func (am *AuthMiddleware) SetPermissionStore(store *PermissionStore) {
+	am.permissions = store
+}
+
 // RequireAuth 要求认证的中间件
 func (am *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -47,6 +55,31 @@ func (am *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 			return
 		}
 
+		// 检查令牌是否已被撤销
+		revoked, err := am.jwtManager.IsAccessTokenRevoked(c.Request.Context(), claims)
+		if err != nil {
+			// 撤销列表不可用时拒绝请求，避免已撤销的令牌继续生效
+			am.logger.WithError(err).Error("Token revocation check failed")
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error":   "Service unavailable",
+				"message": "Unable to verify token, please try again later",
+			})
+			c.Abort()
+			return
+		}
+		if revoked {
+			am.logger.WithFields(logrus.Fields{
+				"user_id": claims.UserID,
+				"jti":     claims.ID,
+			}).Warn("Revoked token rejected")
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error":   "Unauthorized",
+				"message": "Token has been revoked",
+			})
+			c.Abort()
+			return
+		}
+
 		// 将用户信息存储到上下文中
 		c.Set("user_id", claims.UserID)
 		c.Set("user_email", claims.Email)
@@ -54,6 +87,16 @@ func (am *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 		c.Set("user_type", claims.UserType)
 		c.Set("claims", claims)
 
+		// 模拟登录令牌：校验会话、限制只读，并记录每个请求（包括被拦截的请求）
+		if claims.IsImpersonation() {
+			if am.impersonation != nil {
+				defer am.recordImpersonatedRequest(c, claims, time.Now())
+			}
+			if !am.authorizeImpersonation(c, claims) {
+				return
+			}
+		}
+
 		c.Next()
 	}
 }
@@ -134,6 +177,57 @@ func (am *AuthMiddleware) RequireUserType(requiredTypes ...string) gin.HandlerFu
 	}
 }
 
+// RequirePermission 要求管理员拥有全部指定权限的中间件
+func (am *AuthMiddleware) RequirePermission(requiredPermissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// 首先检查是否已经通过认证
+		claims, exists := c.Get("claims")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error":   "Unauthorized",
+				"message": "Authentication required",
+			})
+			c.Abort()
+			return
+		}
+
+		userClaims := claims.(*Claims)
+
+		// 权限只适用于管理员；未配置权限查询时一律拒绝
+		allowed := false
+		if userClaims.UserType == "admin" && am.permissions != nil {
+			var err error
+			allowed, err = am.permissions.HasPermissions(c.Request.Context(), userClaims.Role, requiredPermissions...)
+			if err != nil {
+				am.logger.WithError(err).Error("Permission check failed")
+				c.JSON(http.StatusServiceUnavailable, gin.H{
+					"error":   "Service unavailable",
+					"message": "Unable to verify permissions, please try again later",
+				})
+				c.Abort()
+				return
+			}
+		}
+
+		if allowed {
+			c.Next()
+			return
+		}
+
+		am.logger.WithFields(logrus.Fields{
+			"user_id":              userClaims.UserID,
+			"user_role":            userClaims.Role,
+			"required_permissions": requiredPermissions,
+		}).Warn("Access denied: missing permission")
+
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "Forbidden",
+			"message": "Insufficient permissions",
+		})
+		c.Abort()
+	}
+}
+
 // OptionalAuth 可选认证中间件（不强制要求认证）
 func (am *AuthMiddleware) OptionalAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -152,6 +246,18 @@ func (am *AuthMiddleware) OptionalAuth() gin.HandlerFunc {
 			return
 		}
 
+		// 已撤销（或无法确认）的令牌按未认证处理
+		if revoked, err := am.jwtManager.IsAccessTokenRevoked(c.Request.Context(), claims); err != nil || revoked {
+			c.Next()
+			return
+		}
+
+		// 模拟登录令牌不用于可选认证的接口，按未认证处理
+		if claims.IsImpersonation() {
+			c.Next()
+			return
+		}
+
 		// 设置用户信息到上下文
 		c.Set("user_id", claims.UserID)
 		c.Set("user_email", claims.Email)
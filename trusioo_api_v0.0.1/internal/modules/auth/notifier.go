@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"trusioo_api_v0.0.1/internal/infrastructure/mailer"
+
+	"github.com/sirupsen/logrus"
+)
+
+// 安全事件类型
+const (
+	SecurityEventRefreshTokenReuse = "refresh_token_reuse" // 刷新令牌重放
+	SecurityEventNewSignIn         = "new_sign_in"         // 新设备或新位置登录
+)
+
+// SecurityEvent 安全事件
+type SecurityEvent struct {
+	Type         string                  `json:"type"`
+	UserID       string                  `json:"user_id"`
+	UserType     string                  `json:"user_type"`
+	Email        string                  `json:"email"`
+	FamilyID     string                  `json:"family_id,omitempty"`
+	IPAddress    *string                 `json:"ip_address,omitempty"`
+	DeviceInfo   *map[string]interface{} `json:"device_info,omitempty"`
+	LocationInfo *map[string]interface{} `json:"location_info,omitempty"`
+	NewDevice    bool                    `json:"new_device,omitempty"`
+	NewLocation  bool                    `json:"new_location,omitempty"`
+	ReportURL    string                  `json:"report_url,omitempty"` // “不是我本人”链接
+	Locale       *UserLocale             `json:"locale,omitempty"`     // 收件人语言和时区，为空时按UTC显示时间
+	OccurredAt   time.Time               `json:"occurred_at"`
+}
+
+// SecurityNotifier 安全事件通知接口
+type SecurityNotifier interface {
+	NotifySecurityEvent(ctx context.Context, event *SecurityEvent) error
+}
+
+// MailSecurityNotifier 通过邮件发送安全事件通知
+type MailSecurityNotifier struct {
+	mailer mailer.Mailer
+	logger *logrus.Logger
+}
+
+// NewMailSecurityNotifier 创建邮件安全通知器
+func NewMailSecurityNotifier(m mailer.Mailer, logger *logrus.Logger) *MailSecurityNotifier {
+	return &MailSecurityNotifier{
+		mailer: m,
+		logger: logger,
+	}
+}
+
+// NotifySecurityEvent 发送安全事件通知邮件
+func (n *MailSecurityNotifier) NotifySecurityEvent(ctx context.Context, event *SecurityEvent) error {
+	if event.Email == "" {
+		return fmt.Errorf("security event has no recipient")
+	}
+
+	var subject string
+	var body strings.Builder
+
+	switch event.Type {
+	case SecurityEventRefreshTokenReuse:
+		subject = "Security alert: your sessions have been signed out"
+		body.WriteString("We detected that a previously used sign-in token for your account was presented again.\n")
+		body.WriteString("This can happen when a token has been stolen, so we have signed out the affected session on all devices.\n\n")
+	case SecurityEventNewSignIn:
+		subject = "New sign-in to your account"
+		switch {
+		case event.NewDevice && event.NewLocation:
+			body.WriteString("Your account was just signed in to from a new device and a new location.\n\n")
+		case event.NewDevice:
+			body.WriteString("Your account was just signed in to from a new device.\n\n")
+		default:
+			body.WriteString("Your account was just signed in to from a new location.\n\n")
+		}
+	default:
+		subject = "Security alert on your account"
+		body.WriteString("We detected unusual activity on your account.\n\n")
+	}
+
+	body.WriteString("Time: " + event.Locale.FormatTime(event.OccurredAt) + "\n")
+	if event.IPAddress != nil && *event.IPAddress != "" {
+		body.WriteString("IP address: " + *event.IPAddress + "\n")
+	}
+	if event.DeviceInfo != nil {
+		device := *event.DeviceInfo
+		body.WriteString(fmt.Sprintf("Device: %v / %v\n", device["browser"], device["os"]))
+	}
+	if location := describeLocation(event.LocationInfo); location != "" {
+		body.WriteString("Approximate location: " + location + "\n")
+	}
+
+	if event.ReportURL != "" {
+		body.WriteString("\nIf this was you, you can ignore this email.\n")
+		body.WriteString("If this wasn't you, use the link below to sign out all sessions and reset your password:\n")
+		body.WriteString(event.ReportURL + "\n")
+	} else {
+		body.WriteString("\nIf this was not you, please sign in again and change your password.\n")
+	}
+
+	if err := n.mailer.Send(ctx, &mailer.Message{
+		To:       []string{event.Email},
+		Subject:  subject,
+		Body:     body.String(),
+		Language: event.Locale.LanguageTag(),
+	}); err != nil {
+		return fmt.Errorf("failed to send security notification: %w", err)
+	}
+
+	n.logger.WithFields(logrus.Fields{
+		"event_type": event.Type,
+		"user_id":    event.UserID,
+	}).Info("Security notification sent")
+
+	return nil
+}
+
+// describeLocation 将位置信息格式化为“城市, 国家”
+func describeLocation(locationInfo *map[string]interface{}) string {
+	if locationInfo == nil {
+		return ""
+	}
+
+	info := *locationInfo
+	parts := make([]string, 0, 2)
+	for _, key := range []string{"city", "country_code"} {
+		if value, ok := info[key].(string); ok && value != "" {
+			parts = append(parts, value)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,294 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"trusioo_api_v0.0.1/internal/config"
+	"trusioo_api_v0.0.1/internal/infrastructure/database"
+	"trusioo_api_v0.0.1/internal/infrastructure/pwned"
+	"trusioo_api_v0.0.1/pkg/cryptoutil"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// minPersonalWordLength 邮箱用户名、姓名等个人信息作为禁用词的最小长度（过短的片段误伤太多）
+const minPersonalWordLength = 3
+
+// PasswordPolicyError 密码不符合策略，Violations列出所有未满足的规则
+type PasswordPolicyError struct {
+	Violations []string
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrPasswordPolicy.Error(), strings.Join(e.Violations, "; "))
+}
+
+func (e *PasswordPolicyError) Unwrap() error {
+	return ErrPasswordPolicy
+}
+
+// DescribePasswordError 将密码策略错误转换为面向用户的提示，不是密码策略错误时返回false
+func DescribePasswordError(err error) (string, bool) {
+	var policyErr *PasswordPolicyError
+	switch {
+	case errors.As(err, &policyErr):
+		return "Password " + strings.Join(policyErr.Violations, "; "), true
+	case errors.Is(err, ErrPasswordReused):
+		return "Password was used recently, please choose a different password", true
+	case errors.Is(err, ErrPasswordBreached):
+		return "Password has appeared in a known data breach, please choose a different password", true
+	default:
+		return "", false
+	}
+}
+
+// PasswordSubject 设置密码的账户信息
+type PasswordSubject struct {
+	UserID              string // 新建账户时为空，不检查密码历史
+	UserType            string
+	Email               string
+	Name                string
+	CurrentPasswordHash string // 当前密码哈希，早于密码历史记录的账户也不能沿用当前密码
+}
+
+// PasswordPolicy 密码策略（强度、禁用词、泄露密码库、历史密码和管理员密码有效期）
+type PasswordPolicy struct {
+	db        *database.Database
+	breached  *pwned.Store
+	encryptor *cryptoutil.PasswordEncryptor
+	config    *config.PasswordPolicyConfig
+	logger    *logrus.Logger
+}
+
+// NewPasswordPolicy 创建密码策略，breached为nil时跳过泄露密码检查
+func NewPasswordPolicy(db *database.Database, breached *pwned.Store, encryptor *cryptoutil.PasswordEncryptor, cfg *config.PasswordPolicyConfig, logger *logrus.Logger) *PasswordPolicy {
+	return &PasswordPolicy{
+		db:        db,
+		breached:  breached,
+		encryptor: encryptor,
+		config:    cfg,
+		logger:    logger,
+	}
+}
+
+// Validate 检查新密码是否符合策略
+func (p *PasswordPolicy) Validate(ctx context.Context, subject *PasswordSubject, password string) error {
+	violations := p.checkStrength(password)
+	violations = append(violations, p.checkBannedWords(subject, password)...)
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+
+	if err := p.checkBreached(password); err != nil {
+		return err
+	}
+
+	return p.checkHistory(ctx, subject, password)
+}
+
+// RecordPassword 记录新密码哈希并只保留最近的历史
+func (p *PasswordPolicy) RecordPassword(ctx context.Context, userID, userType, passwordHash string) error {
+	if p.config.HistorySize <= 0 {
+		return nil
+	}
+
+	return p.db.Transaction(func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO password_history (id, user_id, user_type, password_hash, created_at)
+			VALUES ($1, $2, $3, $4, NOW())
+		`, uuid.New().String(), userID, userType, passwordHash); err != nil {
+			return fmt.Errorf("failed to record password history: %w", err)
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			DELETE FROM password_history
+			WHERE user_id = $1 AND user_type = $2 AND id NOT IN (
+				SELECT id FROM password_history
+				WHERE user_id = $1 AND user_type = $2
+				ORDER BY created_at DESC
+				LIMIT $3
+			)
+		`, userID, userType, p.config.HistorySize); err != nil {
+			return fmt.Errorf("failed to prune password history: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// IsAdminPasswordExpired 管理员密码是否超过最长有效期
+func (p *PasswordPolicy) IsAdminPasswordExpired(changedAt time.Time) bool {
+	if p.config.AdminMaxAge <= 0 || changedAt.IsZero() {
+		return false
+	}
+	return time.Now().After(changedAt.Add(p.config.AdminMaxAge))
+}
+
+// checkStrength 检查长度和字符类别
+func (p *PasswordPolicy) checkStrength(password string) []string {
+	var violations []string
+
+	length := utf8.RuneCountInString(password)
+	if p.config.MinLength > 0 && length < p.config.MinLength {
+		violations = append(violations, fmt.Sprintf("must be at least %d characters", p.config.MinLength))
+	}
+	if p.config.MaxLength > 0 && length > p.config.MaxLength {
+		violations = append(violations, fmt.Sprintf("must be at most %d characters", p.config.MaxLength))
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSpecial bool
+	for _, char := range password {
+		switch {
+		case unicode.IsUpper(char):
+			hasUpper = true
+		case unicode.IsLower(char):
+			hasLower = true
+		case unicode.IsDigit(char):
+			hasDigit = true
+		default:
+			hasSpecial = true
+		}
+	}
+
+	classes := 0
+	for _, has := range []bool{hasUpper, hasLower, hasDigit, hasSpecial} {
+		if has {
+			classes++
+		}
+	}
+	if classes < p.config.MinCharacterClasses {
+		violations = append(violations, fmt.Sprintf("must contain at least %d of: uppercase letters, lowercase letters, digits, special characters", p.config.MinCharacterClasses))
+	}
+
+	return violations
+}
+
+// checkBannedWords 检查密码是否包含禁用词、邮箱用户名或姓名
+func (p *PasswordPolicy) checkBannedWords(subject *PasswordSubject, password string) []string {
+	lower := strings.ToLower(password)
+	seen := make(map[string]bool)
+	var violations []string
+
+	check := func(word string, personal bool) {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if word == "" || seen[word] {
+			return
+		}
+		if personal && utf8.RuneCountInString(word) < minPersonalWordLength {
+			return
+		}
+		seen[word] = true
+
+		if strings.Contains(lower, word) {
+			if personal {
+				violations = append(violations, "must not contain your email address or name")
+			} else {
+				violations = append(violations, fmt.Sprintf("must not contain %q", word))
+			}
+		}
+	}
+
+	for _, word := range p.config.BannedWords {
+		check(word, false)
+	}
+
+	if subject != nil {
+		if localPart, _, found := strings.Cut(subject.Email, "@"); found {
+			check(localPart, true)
+		}
+		for _, part := range strings.Fields(subject.Name) {
+			check(part, true)
+		}
+	}
+
+	// 个人信息可能命中多次，只提示一次
+	return dedupeStrings(violations)
+}
+
+// checkBreached 检查密码是否出现在离线泄露密码库中，查询失败时放行
+func (p *PasswordPolicy) checkBreached(password string) error {
+	count, err := p.breached.Count(password)
+	if err != nil {
+		p.logger.WithError(err).Warn("Failed to check breached password list")
+		return nil
+	}
+
+	minCount := p.config.BreachedMinCount
+	if minCount <= 0 {
+		minCount = 1
+	}
+	if count >= minCount {
+		return ErrPasswordBreached
+	}
+
+	return nil
+}
+
+// checkHistory 检查密码是否与当前密码或最近使用过的密码相同
+func (p *PasswordPolicy) checkHistory(ctx context.Context, subject *PasswordSubject, password string) error {
+	if subject == nil || subject.UserID == "" || p.config.HistorySize <= 0 {
+		return nil
+	}
+
+	hashes, err := p.getRecentHashes(ctx, subject.UserID, subject.UserType)
+	if err != nil {
+		return err
+	}
+	if subject.CurrentPasswordHash != "" {
+		hashes = append(hashes, subject.CurrentPasswordHash)
+	}
+
+	for _, hash := range hashes {
+		if p.encryptor.VerifyPassword(password, hash) == nil {
+			return ErrPasswordReused
+		}
+	}
+
+	return nil
+}
+
+// getRecentHashes 获取最近使用过的密码哈希
+func (p *PasswordPolicy) getRecentHashes(ctx context.Context, userID, userType string) ([]string, error) {
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT password_hash FROM password_history
+		WHERE user_id = $1 AND user_type = $2
+		ORDER BY created_at DESC
+		LIMIT $3
+	`, userID, userType, p.config.HistorySize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get password history: %w", err)
+	}
+	defer rows.Close()
+
+	var hashes []string
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return nil, fmt.Errorf("failed to scan password history: %w", err)
+		}
+		hashes = append(hashes, hash)
+	}
+
+	return hashes, rows.Err()
+}
+
+// dedupeStrings 去除重复项并保持顺序
+func dedupeStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := values[:0]
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			result = append(result, value)
+		}
+	}
+	return result
+}
@@ -0,0 +1,257 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"trusioo_api_v0.0.1/internal/infrastructure/database"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RoleSuperAdmin 超级管理员角色，隐式拥有全部权限
+const RoleSuperAdmin = "super_admin"
+
+// 管理员权限常量
+const (
+	// 用户管理
+	PermUserView          = "user.view"
+	PermUserUpdateStatus  = "user.update_status"
+	PermUserSuspend       = "user.suspend"
+	PermUserResetPassword = "user.reset_password"
+	PermUserForceLogout   = "user.force_logout"
+	PermUserVerifyEmail   = "user.verify_email"
+	PermUserUpdateEmail   = "user.update_email"
+	PermUserDelete        = "user.delete"
+	PermUserExport        = "user.export"
+	PermUserNotes         = "user.notes"
+	PermUserTags          = "user.tags"
+	PermUserWatch         = "user.watch"
+	PermUserImpersonate   = "user.impersonate"
+
+	// 钱包管理
+	PermWalletView   = "wallet.view"
+	PermWalletAdjust = "wallet.adjust"
+	PermWalletFreeze = "wallet.freeze"
+
+	// 提现管理
+	PermWithdrawalView    = "withdrawal.view"
+	PermWithdrawalReview  = "withdrawal.review"
+	PermWithdrawalProcess = "withdrawal.process"
+
+	// 汇率管理
+	PermExchangeRateView   = "exchange_rate.view"
+	PermExchangeRateManage = "exchange_rate.manage"
+
+	// 统计
+	PermStatisticsView = "statistics.view"
+
+	// KYC身份认证
+	PermKYCView   = "kyc.view"
+	PermKYCReview = "kyc.review"
+
+	// 审计
+	PermAuditLogView = "audit_log.view"
+
+	// 系统管理
+	PermRoleManage  = "role.manage"
+	PermAdminManage = "admin.manage"
+)
+
+// PermissionInfo 权限说明
+type PermissionInfo struct {
+	Name        string `json:"name"`
+	Group       string `json:"group"`
+	Description string `json:"description"`
+	// SuperAdminOnly 仅超级管理员拥有，不能授予其他角色
+	SuperAdminOnly bool `json:"super_admin_only"`
+}
+
+// permissionCatalog 系统支持的全部权限
+var permissionCatalog = []PermissionInfo{
+	{Name: PermUserView, Group: "user", Description: "View users, user details and activity"},
+	{Name: PermUserUpdateStatus, Group: "user", Description: "Change user account status"},
+	{Name: PermUserSuspend, Group: "user", Description: "Suspend and reactivate users"},
+	{Name: PermUserResetPassword, Group: "user", Description: "Reset user passwords"},
+	{Name: PermUserForceLogout, Group: "user", Description: "Force users to log out"},
+	{Name: PermUserVerifyEmail, Group: "user", Description: "Mark user emails as verified"},
+	{Name: PermUserUpdateEmail, Group: "user", Description: "Change user email addresses", SuperAdminOnly: true},
+	{Name: PermUserDelete, Group: "user", Description: "Delete and restore user accounts", SuperAdminOnly: true},
+	{Name: PermUserExport, Group: "user", Description: "Export user lists to CSV or Excel"},
+	{Name: PermUserNotes, Group: "user", Description: "Add and delete internal notes on users"},
+	{Name: PermUserTags, Group: "user", Description: "Add and remove user tags"},
+	{Name: PermUserWatch, Group: "user", Description: "Watch users and receive notifications about their activity"},
+	{Name: PermUserImpersonate, Group: "user", Description: "Sign in as a user with a short-lived read-only token", SuperAdminOnly: true},
+	{Name: PermWalletView, Group: "wallet", Description: "View user wallets"},
+	{Name: PermWalletAdjust, Group: "wallet", Description: "Adjust wallet balances"},
+	{Name: PermWalletFreeze, Group: "wallet", Description: "Freeze and unfreeze wallets"},
+	{Name: PermWithdrawalView, Group: "withdrawal", Description: "View withdrawal requests"},
+	{Name: PermWithdrawalReview, Group: "withdrawal", Description: "Approve or reject withdrawal requests"},
+	{Name: PermWithdrawalProcess, Group: "withdrawal", Description: "Mark withdrawals as processed"},
+	{Name: PermExchangeRateView, Group: "exchange_rate", Description: "View exchange rates"},
+	{Name: PermExchangeRateManage, Group: "exchange_rate", Description: "Create and update exchange rates"},
+	{Name: PermStatisticsView, Group: "statistics", Description: "View statistics and reports"},
+	{Name: PermKYCView, Group: "kyc", Description: "View KYC submissions and identity documents"},
+	{Name: PermKYCReview, Group: "kyc", Description: "Approve or reject KYC submissions"},
+	{Name: PermAuditLogView, Group: "audit", Description: "View, export and verify the user management audit log"},
+	{Name: PermRoleManage, Group: "system", Description: "Manage admin roles and their permissions"},
+	{Name: PermAdminManage, Group: "system", Description: "Manage admin accounts"},
+}
+
+// AllPermissions 获取全部权限说明
+func AllPermissions() []PermissionInfo {
+	result := make([]PermissionInfo, len(permissionCatalog))
+	copy(result, permissionCatalog)
+	return result
+}
+
+// IsValidPermission 检查权限是否存在
+func IsValidPermission(permission string) bool {
+	for _, p := range permissionCatalog {
+		if p.Name == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSuperAdminOnlyPermission 检查权限是否仅限超级管理员
+func IsSuperAdminOnlyPermission(permission string) bool {
+	for _, p := range permissionCatalog {
+		if p.Name == permission {
+			return p.SuperAdminOnly
+		}
+	}
+	return false
+}
+
+// permissionCacheTTL 角色权限缓存有效期
+const permissionCacheTTL = 30 * time.Second
+
+// cachedPermissions 缓存的角色权限
+type cachedPermissions struct {
+	permissions map[string]struct{}
+	loadedAt    time.Time
+}
+
+// PermissionStore 角色权限查询（带短期内存缓存）
+type PermissionStore struct {
+	db     *database.Database
+	logger *logrus.Logger
+
+	mu    sync.RWMutex
+	cache map[string]*cachedPermissions
+}
+
+// NewPermissionStore 创建角色权限查询
+func NewPermissionStore(db *database.Database, logger *logrus.Logger) *PermissionStore {
+	return &PermissionStore{
+		db:     db,
+		logger: logger,
+		cache:  make(map[string]*cachedPermissions),
+	}
+}
+
+// GetRolePermissions 获取角色拥有的权限列表
+func (s *PermissionStore) GetRolePermissions(ctx context.Context, role string) ([]string, error) {
+	if role == RoleSuperAdmin {
+		permissions := make([]string, 0, len(permissionCatalog))
+		for _, p := range permissionCatalog {
+			permissions = append(permissions, p.Name)
+		}
+		return permissions, nil
+	}
+
+	set, err := s.load(ctx, role)
+	if err != nil {
+		return nil, err
+	}
+
+	permissions := make([]string, 0, len(set))
+	for _, p := range permissionCatalog {
+		if _, ok := set[p.Name]; ok {
+			permissions = append(permissions, p.Name)
+		}
+	}
+	return permissions, nil
+}
+
+// HasPermissions 检查角色是否同时拥有全部指定权限
+func (s *PermissionStore) HasPermissions(ctx context.Context, role string, permissions ...string) (bool, error) {
+	if role == RoleSuperAdmin {
+		return true, nil
+	}
+
+	set, err := s.load(ctx, role)
+	if err != nil {
+		return false, err
+	}
+
+	for _, permission := range permissions {
+		if _, ok := set[permission]; !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// Invalidate 清除角色权限缓存，不指定角色时清除全部
+func (s *PermissionStore) Invalidate(roles ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(roles) == 0 {
+		s.cache = make(map[string]*cachedPermissions)
+		return
+	}
+	for _, role := range roles {
+		delete(s.cache, role)
+	}
+}
+
+// load 从缓存或数据库加载角色权限
+func (s *PermissionStore) load(ctx context.Context, role string) (map[string]struct{}, error) {
+	s.mu.RLock()
+	cached, ok := s.cache[role]
+	s.mu.RUnlock()
+	if ok && time.Since(cached.loadedAt) < permissionCacheTTL {
+		return cached.permissions, nil
+	}
+
+	query := `
+		SELECT p.permission
+		FROM admin_roles r
+		JOIN admin_role_permissions p ON p.role_name = r.name
+		WHERE r.name = $1
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, role)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load role permissions: %w", err)
+	}
+	defer rows.Close()
+
+	permissions := make(map[string]struct{})
+	for rows.Next() {
+		var permission string
+		if err := rows.Scan(&permission); err != nil {
+			return nil, fmt.Errorf("failed to scan role permission: %w", err)
+		}
+		// 直接写入数据库的超级管理员专属权限不生效
+		if IsSuperAdminOnlyPermission(permission) {
+			continue
+		}
+		permissions[permission] = struct{}{}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating role permissions: %w", err)
+	}
+
+	s.mu.Lock()
+	s.cache[role] = &cachedPermissions{permissions: permissions, loadedAt: time.Now()}
+	s.mu.Unlock()
+
+	return permissions, nil
+}
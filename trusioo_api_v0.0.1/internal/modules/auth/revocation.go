@@ -0,0 +1,164 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"trusioo_api_v0.0.1/internal/infrastructure/redis"
+
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// revokedTokenKeyPrefix 已撤销访问令牌（按jti）
+	revokedTokenKeyPrefix = "auth:revoked:jti:"
+	// revokedSessionKeyPrefix 已撤销会话（按sid）
+	revokedSessionKeyPrefix = "auth:revoked:sid:"
+	// revokedBeforeKeyPrefix 用户级撤销水位线（早于该时间签发的令牌全部失效）
+	revokedBeforeKeyPrefix = "auth:revoked:before:"
+	// legacyWatermarkMaxSeconds 小于该值的水位线是以秒保存的旧格式（微秒时间戳远大于该值）
+	legacyWatermarkMaxSeconds = 1e12
+)
+
+// TokenRevocationStore 基于Redis的访问令牌撤销列表
+type TokenRevocationStore struct {
+	redis     *redis.Client
+	accessTTL time.Duration
+	logger    *logrus.Logger
+}
+
+// NewTokenRevocationStore 创建访问令牌撤销列表
+// accessTTL 为访问令牌有效期，用户级水位线只需保留这么久
+func NewTokenRevocationStore(redisClient *redis.Client, accessTTL time.Duration, logger *logrus.Logger) *TokenRevocationStore {
+	return &TokenRevocationStore{
+		redis:     redisClient,
+		accessTTL: accessTTL,
+		logger:    logger,
+	}
+}
+
+// RevokeToken 撤销单个访问令牌，条目在令牌过期时自动失效
+func (s *TokenRevocationStore) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		// 令牌已过期，无需加入撤销列表
+		return nil
+	}
+
+	if err := s.redis.Set(ctx, revokedTokenKeyPrefix+jti, 1, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to revoke access token: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeSession 使某个会话签发的所有访问令牌失效
+func (s *TokenRevocationStore) RevokeSession(ctx context.Context, sessionID string) error {
+	if err := s.redis.Set(ctx, revokedSessionKeyPrefix+sessionID, 1, s.accessTTL).Err(); err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	return nil
+}
+
+// RevokeUserTokensBefore 使指定用户在某时间之前签发的所有访问令牌失效（微秒精度，之后签发的令牌不受影响）
+func (s *TokenRevocationStore) RevokeUserTokensBefore(ctx context.Context, userID, userType string, before time.Time) error {
+	key := revokedBeforeKeyPrefix + userType + ":" + userID
+
+	// 只允许水位线前移，避免并发写入把较新的水位线覆盖掉
+	current, err := s.getWatermark(ctx, key)
+	if err != nil {
+		return err
+	}
+	if current != nil && !before.After(*current) {
+		return nil
+	}
+
+	if err := s.redis.Set(ctx, key, before.UnixMicro(), s.accessTTL).Err(); err != nil {
+		return fmt.Errorf("failed to set user revocation watermark: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":   userID,
+		"user_type": userType,
+		"before":    before.Format(time.RFC3339Nano),
+	}).Info("User access tokens revoked")
+
+	return nil
+}
+
+// IsRevoked 检查访问令牌是否已被撤销
+func (s *TokenRevocationStore) IsRevoked(ctx context.Context, claims *Claims) (bool, error) {
+	if claims.ID != "" {
+		exists, err := s.redis.Exists(ctx, revokedTokenKeyPrefix+claims.ID).Result()
+		if err != nil {
+			return false, fmt.Errorf("failed to check token revocation: %w", err)
+		}
+		if exists > 0 {
+			return true, nil
+		}
+	}
+
+	if claims.SessionID != "" {
+		exists, err := s.redis.Exists(ctx, revokedSessionKeyPrefix+claims.SessionID).Result()
+		if err != nil {
+			return false, fmt.Errorf("failed to check session revocation: %w", err)
+		}
+		if exists > 0 {
+			return true, nil
+		}
+	}
+
+	watermark, err := s.getWatermark(ctx, revokedBeforeKeyPrefix+claims.UserType+":"+claims.UserID)
+	if err != nil {
+		return false, err
+	}
+	if watermark != nil {
+		issuedAt, ok := tokenIssuedAt(claims)
+		if !ok || !issuedAt.After(*watermark) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// getWatermark 读取用户级撤销水位线
+func (s *TokenRevocationStore) getWatermark(ctx context.Context, key string) (*time.Time, error) {
+	value, err := s.redis.Get(ctx, key).Result()
+	if err != nil {
+		if errors.Is(err, goredis.Nil) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get user revocation watermark: %w", err)
+	}
+
+	micros, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user revocation watermark: %w", err)
+	}
+
+	// 兼容升级前以秒保存、尚未过期的水位线
+	if micros < legacyWatermarkMaxSeconds {
+		watermark := time.Unix(micros, 0)
+		return &watermark, nil
+	}
+
+	watermark := time.UnixMicro(micros)
+	return &watermark, nil
+}
+
+// tokenIssuedAt 获取令牌的签发时间，优先使用微秒精度的iat_us
+// 升级前签发的令牌只有秒级iat，按该秒的起点比较（这些令牌都早于升级后设置的水位线）
+func tokenIssuedAt(claims *Claims) (time.Time, bool) {
+	if claims.IssuedAtMicros > 0 {
+		return time.UnixMicro(claims.IssuedAtMicros), true
+	}
+	if claims.IssuedAt != nil {
+		return claims.IssuedAt.Time, true
+	}
+	return time.Time{}, false
+}
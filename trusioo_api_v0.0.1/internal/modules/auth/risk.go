@@ -0,0 +1,538 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"strings"
+	"time"
+
+	"trusioo_api_v0.0.1/internal/config"
+	"trusioo_api_v0.0.1/internal/infrastructure/database"
+	"trusioo_api_v0.0.1/internal/infrastructure/geoip"
+
+	"github.com/sirupsen/logrus"
+)
+
+// 登录风险决策
+const (
+	RiskDecisionAllow     = "allow"     // 正常放行
+	RiskDecisionChallenge = "challenge" // 需要额外验证
+	RiskDecisionBlock     = "block"     // 拒绝登录
+)
+
+// 登录风险因素
+const (
+	RiskFactorNoHistory        = "no_login_history"
+	RiskFactorNewDevice        = "new_device"
+	RiskFactorNewIP            = "new_ip"
+	RiskFactorNewASN           = "new_asn"
+	RiskFactorNewCountry       = "new_country"
+	RiskFactorImpossibleTravel = "impossible_travel"
+	RiskFactorFailedBurst      = "failed_attempt_burst"
+	RiskFactorIPFailedBurst    = "ip_failed_attempt_burst"
+	RiskFactorUnusualTime      = "unusual_time"
+)
+
+// riskFactorWeights 各风险因素的分值
+var riskFactorWeights = map[string]int{
+	RiskFactorNoHistory:        10,
+	RiskFactorNewDevice:        25,
+	RiskFactorNewIP:            10,
+	RiskFactorNewASN:           15,
+	RiskFactorNewCountry:       15,
+	RiskFactorImpossibleTravel: 40,
+	RiskFactorFailedBurst:      20,
+	RiskFactorIPFailedBurst:    20,
+	RiskFactorUnusualTime:      10,
+}
+
+const (
+	// riskHistoryLimit 参与比对的历史成功登录条数
+	riskHistoryLimit = 50
+	// riskMinTravelDistanceKm 距离过近时不判定不可能的旅行（GeoIP精度有限）
+	riskMinTravelDistanceKm = 300
+	// riskMinHistoryForTimeCheck 历史登录不足时不判断登录时段
+	riskMinHistoryForTimeCheck = 5
+	// riskIPBurstMultiplier 同一IP失败次数阈值相对单账户阈值的倍数（撞库检测）
+	riskIPBurstMultiplier = 3
+)
+
+// LoginAttempt 待评估的登录尝试
+type LoginAttempt struct {
+	UserID       string // 用户不存在时为空
+	UserType     string
+	Email        string
+	IPAddress    string
+	DeviceInfo   *map[string]interface{}
+	LocationInfo *map[string]interface{}
+	OccurredAt   time.Time
+}
+
+// RiskAssessment 风险评估结果
+type RiskAssessment struct {
+	Score    int      `json:"score"`
+	Factors  []string `json:"factors"`
+	Decision string   `json:"decision"`
+}
+
+// SignInRecognition 登录设备和位置的识别结果
+type SignInRecognition struct {
+	FirstSignIn bool `json:"first_sign_in"` // 账户首次登录（没有可比对的设备）
+	NewDevice   bool `json:"new_device"`
+	NewLocation bool `json:"new_location"`
+}
+
+// IsUnrecognized 是否为需要提醒用户的陌生登录
+func (r *SignInRecognition) IsUnrecognized() bool {
+	return !r.FirstSignIn && (r.NewDevice || r.NewLocation)
+}
+
+// loginHistoryEntry 历史成功登录记录
+type loginHistoryEntry struct {
+	ipAddress string
+	device    map[string]interface{}
+	location  map[string]interface{}
+	createdAt time.Time
+}
+
+// LoginRiskScorer 登录风险评分器
+type LoginRiskScorer struct {
+	db     *database.Database
+	geo    *geoip.Database
+	config *config.RiskConfig
+	logger *logrus.Logger
+}
+
+// NewLoginRiskScorer 创建登录风险评分器，geo为nil时跳过位置相关检查
+func NewLoginRiskScorer(db *database.Database, geo *geoip.Database, cfg *config.RiskConfig, logger *logrus.Logger) *LoginRiskScorer {
+	return &LoginRiskScorer{
+		db:     db,
+		geo:    geo,
+		config: cfg,
+		logger: logger,
+	}
+}
+
+// EnrichLocation 使用GeoIP数据补充位置信息
+func (s *LoginRiskScorer) EnrichLocation(ipAddress string, locationInfo *map[string]interface{}) {
+	if locationInfo == nil {
+		return
+	}
+
+	location, ok := s.geo.Lookup(net.ParseIP(ipAddress))
+	if !ok {
+		return
+	}
+
+	info := *locationInfo
+	info["country_code"] = location.CountryCode
+	info["city"] = location.City
+	info["latitude"] = location.Latitude
+	info["longitude"] = location.Longitude
+	if location.ASN != 0 {
+		info["asn"] = location.ASN
+		info["as_org"] = location.ASOrg
+	}
+}
+
+// Assess 评估登录尝试的风险
+func (s *LoginRiskScorer) Assess(ctx context.Context, attempt *LoginAttempt) (*RiskAssessment, error) {
+	s.EnrichLocation(attempt.IPAddress, attempt.LocationInfo)
+
+	assessment := &RiskAssessment{Factors: []string{}, Decision: RiskDecisionAllow}
+	if !s.config.Enabled {
+		return assessment, nil
+	}
+
+	if attempt.OccurredAt.IsZero() {
+		attempt.OccurredAt = time.Now()
+	}
+
+	// 与账户的历史登录比对（用户存在时）
+	if attempt.UserID != "" {
+		history, err := s.loadHistory(ctx, attempt.UserID, attempt.UserType)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(history) == 0 {
+			assessment.add(RiskFactorNoHistory)
+		} else {
+			knownDevices, err := s.loadKnownDevices(ctx, attempt.UserID, attempt.UserType)
+			if err != nil {
+				return nil, err
+			}
+			for _, entry := range history {
+				if fp := DeviceFingerprint(&entry.device); fp != "" {
+					knownDevices[fp] = struct{}{}
+				}
+			}
+
+			s.checkDevice(assessment, attempt, knownDevices)
+			s.checkNetwork(assessment, attempt, history)
+			s.checkTravel(assessment, attempt, history[0])
+			s.checkTimeOfDay(assessment, attempt, history)
+		}
+	}
+
+	// 失败登录爆发（账户维度和IP维度）
+	if err := s.checkFailedBursts(ctx, assessment, attempt); err != nil {
+		return nil, err
+	}
+
+	if assessment.Score > 100 {
+		assessment.Score = 100
+	}
+	assessment.Decision = s.decide(assessment.Score)
+
+	if assessment.Decision != RiskDecisionAllow {
+		s.logger.WithFields(logrus.Fields{
+			"user_id":    attempt.UserID,
+			"email":      attempt.Email,
+			"ip_address": attempt.IPAddress,
+			"score":      assessment.Score,
+			"factors":    assessment.Factors,
+			"decision":   assessment.Decision,
+		}).Warn("High risk login attempt")
+	}
+
+	return assessment, nil
+}
+
+// RecognizeSignIn 判断登录是否来自账户从未使用过的设备或位置
+// 设备以会话和刷新令牌上保存的设备信息为准，需在签发本次登录的令牌之前调用
+func (s *LoginRiskScorer) RecognizeSignIn(ctx context.Context, attempt *LoginAttempt) (*SignInRecognition, error) {
+	s.EnrichLocation(attempt.IPAddress, attempt.LocationInfo)
+
+	knownDevices, err := s.loadKnownDevices(ctx, attempt.UserID, attempt.UserType)
+	if err != nil {
+		return nil, err
+	}
+	if len(knownDevices) == 0 {
+		return &SignInRecognition{FirstSignIn: true}, nil
+	}
+
+	recognition := &SignInRecognition{}
+	if fp := DeviceFingerprint(attempt.DeviceInfo); fp != "" {
+		_, known := knownDevices[fp]
+		recognition.NewDevice = !known
+	}
+
+	// 位置按“国家+城市”比对，没有GeoIP数据时不判断
+	current := locationKey(attempt.LocationInfo)
+	if current == "" {
+		return recognition, nil
+	}
+
+	history, err := s.loadHistory(ctx, attempt.UserID, attempt.UserType)
+	if err != nil {
+		return nil, err
+	}
+
+	knownLocations := make(map[string]struct{})
+	for _, entry := range history {
+		if key := locationKey(&entry.location); key != "" {
+			knownLocations[key] = struct{}{}
+		}
+	}
+	if len(knownLocations) > 0 {
+		_, known := knownLocations[current]
+		recognition.NewLocation = !known
+	}
+
+	return recognition, nil
+}
+
+// decide 根据分数和阈值给出决策（阈值不大于0表示不启用）
+func (s *LoginRiskScorer) decide(score int) string {
+	if s.config.BlockThreshold > 0 && score >= s.config.BlockThreshold {
+		return RiskDecisionBlock
+	}
+	if s.config.ChallengeThreshold > 0 && score >= s.config.ChallengeThreshold {
+		return RiskDecisionChallenge
+	}
+	return RiskDecisionAllow
+}
+
+// checkDevice 检查是否为新设备
+func (s *LoginRiskScorer) checkDevice(assessment *RiskAssessment, attempt *LoginAttempt, knownDevices map[string]struct{}) {
+	fp := DeviceFingerprint(attempt.DeviceInfo)
+	if fp == "" {
+		return
+	}
+	if _, ok := knownDevices[fp]; !ok {
+		assessment.add(RiskFactorNewDevice)
+	}
+}
+
+// checkNetwork 检查IP、ASN和国家是否首次出现
+func (s *LoginRiskScorer) checkNetwork(assessment *RiskAssessment, attempt *LoginAttempt, history []*loginHistoryEntry) {
+	knownIP := false
+	knownASNs := make(map[uint32]struct{})
+	knownCountries := make(map[string]struct{})
+
+	for _, entry := range history {
+		if entry.ipAddress == attempt.IPAddress {
+			knownIP = true
+		}
+		if asn, ok := entry.location["asn"].(float64); ok && asn > 0 {
+			knownASNs[uint32(asn)] = struct{}{}
+		}
+		if country, ok := entry.location["country_code"].(string); ok && country != "" {
+			knownCountries[country] = struct{}{}
+		}
+	}
+
+	if !knownIP {
+		assessment.add(RiskFactorNewIP)
+	}
+
+	location, ok := s.geo.Lookup(net.ParseIP(attempt.IPAddress))
+	if !ok {
+		return
+	}
+	if location.ASN != 0 && len(knownASNs) > 0 {
+		if _, ok := knownASNs[location.ASN]; !ok {
+			assessment.add(RiskFactorNewASN)
+		}
+	}
+	if location.CountryCode != "" && len(knownCountries) > 0 {
+		if _, ok := knownCountries[location.CountryCode]; !ok {
+			assessment.add(RiskFactorNewCountry)
+		}
+	}
+}
+
+// checkTravel 与上一次成功登录比较，检查是否存在不可能的旅行
+func (s *LoginRiskScorer) checkTravel(assessment *RiskAssessment, attempt *LoginAttempt, last *loginHistoryEntry) {
+	if s.config.MaxTravelSpeedKmh <= 0 {
+		return
+	}
+
+	location, ok := s.geo.Lookup(net.ParseIP(attempt.IPAddress))
+	if !ok {
+		return
+	}
+
+	lastLat, latOK := last.location["latitude"].(float64)
+	lastLon, lonOK := last.location["longitude"].(float64)
+	if !latOK || !lonOK {
+		return
+	}
+
+	distance := haversineKm(lastLat, lastLon, location.Latitude, location.Longitude)
+	if distance < riskMinTravelDistanceKm {
+		return
+	}
+
+	hours := attempt.OccurredAt.Sub(last.createdAt).Hours()
+	if hours < 1.0/60 {
+		hours = 1.0 / 60
+	}
+
+	if distance/hours > float64(s.config.MaxTravelSpeedKmh) {
+		assessment.add(RiskFactorImpossibleTravel)
+	}
+}
+
+// checkTimeOfDay 检查登录时段是否与历史习惯明显不同
+func (s *LoginRiskScorer) checkTimeOfDay(assessment *RiskAssessment, attempt *LoginAttempt, history []*loginHistoryEntry) {
+	if len(history) < riskMinHistoryForTimeCheck {
+		return
+	}
+
+	hour := attempt.OccurredAt.UTC().Hour()
+	for _, entry := range history {
+		diff := hour - entry.createdAt.UTC().Hour()
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff > 12 {
+			diff = 24 - diff
+		}
+		if diff <= 1 {
+			return
+		}
+	}
+
+	assessment.add(RiskFactorUnusualTime)
+}
+
+// checkFailedBursts 检查近期失败登录次数
+func (s *LoginRiskScorer) checkFailedBursts(ctx context.Context, assessment *RiskAssessment, attempt *LoginAttempt) error {
+	if s.config.FailedAttemptThreshold <= 0 {
+		return nil
+	}
+
+	since := attempt.OccurredAt.Add(-s.config.FailedAttemptWindow)
+
+	var emailFailures int
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM login_logs
+		WHERE email = $1 AND user_type = $2 AND login_status IN ('failed', 'blocked') AND created_at > $3
+	`, attempt.Email, attempt.UserType, since).Scan(&emailFailures)
+	if err != nil {
+		return fmt.Errorf("failed to count failed logins: %w", err)
+	}
+	if emailFailures >= s.config.FailedAttemptThreshold {
+		assessment.add(RiskFactorFailedBurst)
+	}
+
+	if net.ParseIP(attempt.IPAddress) == nil {
+		return nil
+	}
+
+	var ipFailures int
+	err = s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM login_logs
+		WHERE ip_address = $1::inet AND login_status IN ('failed', 'blocked') AND created_at > $2
+	`, attempt.IPAddress, since).Scan(&ipFailures)
+	if err != nil {
+		return fmt.Errorf("failed to count failed logins by ip: %w", err)
+	}
+	if ipFailures >= s.config.FailedAttemptThreshold*riskIPBurstMultiplier {
+		assessment.add(RiskFactorIPFailedBurst)
+	}
+
+	return nil
+}
+
+// loadHistory 加载最近的成功登录记录（按时间倒序）
+func (s *LoginRiskScorer) loadHistory(ctx context.Context, userID, userType string) ([]*loginHistoryEntry, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT host(ip_address), device_info, location_info, created_at
+		FROM login_logs
+		WHERE user_id = $1 AND user_type = $2 AND login_status = 'success'
+		ORDER BY created_at DESC
+		LIMIT $3
+	`, userID, userType, riskHistoryLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load login history: %w", err)
+	}
+	defer rows.Close()
+
+	var history []*loginHistoryEntry
+	for rows.Next() {
+		entry := &loginHistoryEntry{}
+		var deviceJSON, locationJSON sql.NullString
+		if err := rows.Scan(&entry.ipAddress, &deviceJSON, &locationJSON, &entry.createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan login history: %w", err)
+		}
+		entry.device = decodeJSONMap(deviceJSON)
+		entry.location = decodeJSONMap(locationJSON)
+		history = append(history, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating login history: %w", err)
+	}
+
+	return history, nil
+}
+
+// loadKnownDevices 从会话和刷新令牌中收集账户用过的设备指纹
+func (s *LoginRiskScorer) loadKnownDevices(ctx context.Context, userID, userType string) (map[string]struct{}, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT device_info FROM user_sessions
+		WHERE user_id = $1 AND user_type = $2 AND device_info IS NOT NULL
+		UNION ALL
+		SELECT device_info FROM refresh_tokens
+		WHERE user_id = $1 AND user_type = $2 AND device_info IS NOT NULL
+	`, userID, userType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load known devices: %w", err)
+	}
+	defer rows.Close()
+
+	devices := make(map[string]struct{})
+	for rows.Next() {
+		var deviceJSON sql.NullString
+		if err := rows.Scan(&deviceJSON); err != nil {
+			return nil, fmt.Errorf("failed to scan device info: %w", err)
+		}
+		device := decodeJSONMap(deviceJSON)
+		if fp := DeviceFingerprint(&device); fp != "" {
+			devices[fp] = struct{}{}
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating devices: %w", err)
+	}
+
+	return devices, nil
+}
+
+// add 记录风险因素并累加分值
+func (a *RiskAssessment) add(factor string) {
+	a.Factors = append(a.Factors, factor)
+	a.Score += riskFactorWeights[factor]
+}
+
+// DeviceFingerprint 根据设备信息（浏览器、操作系统、设备类型）计算设备指纹
+func DeviceFingerprint(deviceInfo *map[string]interface{}) string {
+	if deviceInfo == nil || len(*deviceInfo) == 0 {
+		return ""
+	}
+
+	info := *deviceInfo
+	parts := make([]string, 0, 3)
+	for _, key := range []string{"browser", "os", "device_type"} {
+		value, _ := info[key].(string)
+		parts = append(parts, strings.ToLower(value))
+	}
+	if strings.Join(parts, "") == "" {
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(parts, "|")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// locationKey 位置比对键（国家+城市），缺少国家信息时返回空
+func locationKey(locationInfo *map[string]interface{}) string {
+	if locationInfo == nil {
+		return ""
+	}
+
+	info := *locationInfo
+	country, _ := info["country_code"].(string)
+	if country == "" {
+		return ""
+	}
+	city, _ := info["city"].(string)
+	return strings.ToUpper(country) + "|" + strings.ToLower(city)
+}
+
+// decodeJSONMap 解析JSONB字段，失败或为空时返回空map
+func decodeJSONMap(value sql.NullString) map[string]interface{} {
+	result := make(map[string]interface{})
+	if !value.Valid || value.String == "" {
+		return result
+	}
+	if err := json.Unmarshal([]byte(value.String), &result); err != nil {
+		return make(map[string]interface{})
+	}
+	return result
+}
+
+// haversineKm 计算两个经纬度之间的球面距离（公里）
+func haversineKm(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadiusKm = 6371.0
+
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := toRad(lat2 - lat1)
+	dLon := toRad(lon2 - lon1)
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
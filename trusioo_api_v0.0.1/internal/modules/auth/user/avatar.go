@@ -0,0 +1,157 @@
+package user
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	_ "image/png" // 注册PNG解码器
+	"io"
+	"net/http"
+
+	"trusioo_api_v0.0.1/internal/modules/auth"
+)
+
+const (
+	// maxAvatarPixels 头像原图的最大像素数，解码前按图片头校验，防止小文件解码出超大图片耗尽内存
+	maxAvatarPixels = 4096 * 4096
+
+	// avatarJPEGQuality 头像重新编码的JPEG质量
+	avatarJPEGQuality = 85
+
+	// avatarContentType 处理后头像统一的文件类型
+	avatarContentType = "image/jpeg"
+)
+
+// allowedAvatarTypes 允许上传的头像类型（按文件内容识别，不信任文件名和请求头）
+var allowedAvatarTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+}
+
+// processAvatar 校验并处理上传的头像：居中裁剪为正方形、缩小到size像素（不放大）、
+// 透明背景合成为白色后重新编码为JPEG。重新编码同时去掉了原图中的EXIF等元数据
+func processAvatar(content io.Reader, maxSize int64, size int) ([]byte, error) {
+	// 多读1字节用于判断是否超过大小上限（上传大小可能与实际内容不符）
+	data, err := io.ReadAll(io.LimitReader(content, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read avatar: %w", err)
+	}
+	if int64(len(data)) > maxSize {
+		return nil, auth.ErrAvatarTooLarge
+	}
+	if !allowedAvatarTypes[http.DetectContentType(data)] {
+		return nil, auth.ErrAvatarTypeNotAllowed
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, auth.ErrInvalidAvatarImage
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxAvatarPixels {
+		return nil, fmt.Errorf("%w: image dimensions %dx%d are not allowed", auth.ErrInvalidAvatarImage, cfg.Width, cfg.Height)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, auth.ErrInvalidAvatarImage
+	}
+
+	square := cropSquare(src)
+	if side := square.Bounds().Dx(); side > size {
+		square = resizeSquare(square, size)
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, square, &jpeg.Options{Quality: avatarJPEGQuality}); err != nil {
+		return nil, fmt.Errorf("failed to encode avatar: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// cropSquare 居中裁剪出最大的正方形，合成到白色背景上
+func cropSquare(src image.Image) *image.RGBA {
+	bounds := src.Bounds()
+	side := bounds.Dx()
+	if bounds.Dy() < side {
+		side = bounds.Dy()
+	}
+	offset := image.Pt(bounds.Min.X+(bounds.Dx()-side)/2, bounds.Min.Y+(bounds.Dy()-side)/2)
+
+	dst := image.NewRGBA(image.Rect(0, 0, side, side))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), src, offset, draw.Over)
+	return dst
+}
+
+// resizeSquare 将正方形图片按区域平均缩小到size×size（每个目标像素取其覆盖的原图像素的加权平均）
+func resizeSquare(src *image.RGBA, size int) *image.RGBA {
+	side := src.Bounds().Dx()
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	scale := float64(side) / float64(size)
+
+	// 每个目标行/列覆盖的原图范围及边缘像素的权重，行列共用
+	spans := make([]avatarSpan, size)
+	for i := range spans {
+		spans[i] = newAvatarSpan(float64(i)*scale, float64(i+1)*scale, side)
+	}
+
+	for y, ySpan := range spans {
+		for x, xSpan := range spans {
+			var r, g, b, a, total float64
+			for sy := ySpan.start; sy < ySpan.end; sy++ {
+				wy := ySpan.weight(sy)
+				row := src.Pix[sy*src.Stride:]
+				for sx := xSpan.start; sx < xSpan.end; sx++ {
+					w := wy * xSpan.weight(sx)
+					p := row[sx*4 : sx*4+4]
+					r += float64(p[0]) * w
+					g += float64(p[1]) * w
+					b += float64(p[2]) * w
+					a += float64(p[3]) * w
+					total += w
+				}
+			}
+			offset := y*dst.Stride + x*4
+			dst.Pix[offset] = uint8(r/total + 0.5)
+			dst.Pix[offset+1] = uint8(g/total + 0.5)
+			dst.Pix[offset+2] = uint8(b/total + 0.5)
+			dst.Pix[offset+3] = uint8(a/total + 0.5)
+		}
+	}
+
+	return dst
+}
+
+// avatarSpan 目标像素在原图一个方向上覆盖的范围[from, to)
+type avatarSpan struct {
+	from, to   float64
+	start, end int
+}
+
+// newAvatarSpan 计算覆盖范围对应的原图像素下标
+func newAvatarSpan(from, to float64, limit int) avatarSpan {
+	start := int(from)
+	end := int(to)
+	if float64(end) < to {
+		end++
+	}
+	if end > limit {
+		end = limit
+	}
+	return avatarSpan{from: from, to: to, start: start, end: end}
+}
+
+// weight 原图像素i被覆盖的比例（边缘像素只计算重叠部分）
+func (s avatarSpan) weight(i int) float64 {
+	lo, hi := float64(i), float64(i+1)
+	if lo < s.from {
+		lo = s.from
+	}
+	if hi > s.to {
+		hi = s.to
+	}
+	return hi - lo
+}
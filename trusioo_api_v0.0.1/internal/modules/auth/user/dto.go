@@ -1,6 +1,8 @@
 package user
 
 import (
+	"time"
+
 	"trusioo_api_v0.0.1/internal/modules/auth"
 )
 
@@ -33,6 +35,28 @@ type VerifyLoginRequest struct {
 	UserAgent string `json:"user_agent" binding:"omitempty" example:"Mozilla/5.0..."`
 }
 
+// VerifyLoginChallengeRequest 高风险登录额外验证请求
+type VerifyLoginChallengeRequest struct {
+	Email         string `json:"email" binding:"required,email" example:"user@example.com"`
+	Password      string `json:"password" binding:"required,min=6" example:"password123"`
+	ChallengeCode string `json:"challenge_code" binding:"required,len=6" example:"654321"`
+	UserAgent     string `json:"user_agent" binding:"omitempty" example:"Mozilla/5.0..."`
+}
+
+// MagicLinkRequest 请求免密登录链接
+type MagicLinkRequest struct {
+	Email string `json:"email" binding:"required,email" example:"user@example.com"`
+	// ReturnNonce 非浏览器客户端无法使用Cookie时显式要求在响应中返回设备nonce
+	ReturnNonce bool `json:"return_nonce" binding:"omitempty" example:"false"`
+}
+
+// VerifyMagicLinkRequest 登录链接验证请求（未提供nonce时从Cookie读取）
+type VerifyMagicLinkRequest struct {
+	Token     string `json:"token" binding:"required" example:"Hk3p9Z..."`
+	Nonce     string `json:"nonce" binding:"omitempty" example:"q81Xv0..."`
+	UserAgent string `json:"user_agent" binding:"omitempty" example:"Mozilla/5.0..."`
+}
+
 // RefreshTokenRequest 刷新令牌请求
 type RefreshTokenRequest struct {
 	RefreshToken string `json:"refresh_token" binding:"required" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
@@ -56,10 +80,39 @@ type ResetPasswordRequest struct {
 	NewPassword      string `json:"new_password" binding:"required,min=6" example:"newpassword123"`
 }
 
-// UpdateProfileRequest 更新用户资料请求
+// ChangeEmailRequest 修改邮箱请求（向新邮箱发送验证码）
+type ChangeEmailRequest struct {
+	NewEmail string `json:"new_email" binding:"required,email" example:"newemail@example.com"`
+	Password string `json:"password" binding:"required" example:"password123"`
+}
+
+// ConfirmEmailChangeRequest 确认修改邮箱请求
+type ConfirmEmailChangeRequest struct {
+	NewEmail         string `json:"new_email" binding:"required,email" example:"newemail@example.com"`
+	VerificationCode string `json:"verification_code" binding:"required,len=6" example:"123456"`
+}
+
+// RevertEmailChangeRequest 撤销邮箱变更请求（发往原邮箱的撤销链接）
+type RevertEmailChangeRequest struct {
+	Token string `json:"token" binding:"required" example:"3q2-7wAAAAB..."`
+}
+
+// UpdateProfileRequest 更新用户资料请求（只更新提供的字段，邮箱通过修改邮箱流程变更）
 type UpdateProfileRequest struct {
-	Name  string `json:"name" binding:"omitempty,min=2" example:"Jane Doe"`
-	Email string `json:"email" binding:"omitempty,email" example:"updated@example.com"`
+	Name     *string `json:"name" binding:"omitempty,min=2,max=100" example:"Jane Doe"`
+	Language *string `json:"language" binding:"omitempty,max=10" example:"zh-CN"`        // 须为PROFILE_LANGUAGES中的语言
+	Timezone *string `json:"timezone" binding:"omitempty,max=64" example:"Africa/Lagos"` // IANA时区
+}
+
+// RequestPhoneVerificationRequest 绑定手机号请求（向新手机号发送短信验证码）
+type RequestPhoneVerificationRequest struct {
+	PhoneNumber string `json:"phone_number" binding:"required,max=32" example:"+2348012345678"` // E.164格式，可包含空格和连字符
+}
+
+// ConfirmPhoneVerificationRequest 确认绑定手机号请求
+type ConfirmPhoneVerificationRequest struct {
+	PhoneNumber      string `json:"phone_number" binding:"required,max=32" example:"+2348012345678"`
+	VerificationCode string `json:"verification_code" binding:"required,len=6" example:"123456"`
 }
 
 // ListUsersRequest 用户列表查询请求
@@ -91,9 +144,8 @@ type SimpleRegisterResponse struct {
 
 // LoginResponse 登录响应（发送验证码）
 type LoginResponse struct {
-	Message          string `json:"message" example:"Verification code sent"`
-	VerificationCode string `json:"verification_code" example:"123456"` // 仅用于测试，生产环境应删除
-	ExpiresIn        int    `json:"expires_in" example:"300"`
+	Message   string `json:"message" example:"Verification code sent"`
+	ExpiresIn int    `json:"expires_in" example:"300"`
 }
 
 // VerifyLoginResponse 验证登录响应
@@ -104,6 +156,22 @@ type VerifyLoginResponse struct {
 	Session *UserSessionInfo `json:"session"` // 新增会话信息
 }
 
+// LoginChallengeResponse 需要额外验证的登录响应
+type LoginChallengeResponse struct {
+	Error             string   `json:"error" example:"Additional verification required"`
+	Message           string   `json:"message" example:"We sent a verification code to your email"`
+	ChallengeRequired bool     `json:"challenge_required" example:"true"`
+	RiskFactors       []string `json:"risk_factors" example:"new_device,new_country"`
+	ExpiresIn         int      `json:"expires_in" example:"600"`
+}
+
+// MagicLinkResponse 请求登录链接响应
+type MagicLinkResponse struct {
+	Message   string `json:"message" example:"If the email is registered, a sign-in link has been sent"`
+	Nonce     string `json:"nonce,omitempty" example:"q81Xv0..."` // 设备绑定nonce，仅在请求return_nonce时返回
+	ExpiresIn int    `json:"expires_in" example:"900"`
+}
+
 // UserInfo 用户信息结构（用于API响应）
 type UserInfo struct {
 	ID            string `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
@@ -119,17 +187,65 @@ type RefreshTokenResponse struct {
 	Tokens  *auth.TokenPair `json:"tokens"`
 }
 
+// UserProfile 用户个人资料（用于资料接口响应）
+type UserProfile struct {
+	ID              string     `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Email           string     `json:"email" example:"user@example.com"`
+	Name            string     `json:"name" example:"John Doe"`
+	Status          string     `json:"status" example:"active"`
+	EmailVerified   bool       `json:"email_verified" example:"true"`
+	HasAvatar       bool       `json:"has_avatar" example:"true"` // 头像通过GET /auth/user/profile/avatar获取
+	AvatarUpdatedAt *time.Time `json:"avatar_updated_at,omitempty"`
+	Language        string     `json:"language" example:"en"`
+	Timezone        string     `json:"timezone" example:"Africa/Lagos"`
+	PhoneNumber     *string    `json:"phone_number,omitempty" example:"+2348012345678"`
+	PhoneVerifiedAt *time.Time `json:"phone_verified_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
 // ProfileResponse 获取资料响应
 type ProfileResponse struct {
-	User *UserInfo `json:"user"`
+	User *UserProfile `json:"user"`
+}
+
+// PhoneVerificationResponse 发送手机验证码响应
+type PhoneVerificationResponse struct {
+	Message   string `json:"message" example:"Verification code sent to your phone"`
+	ExpiresIn int    `json:"expires_in" example:"600"`
 }
 
 // ForgotPasswordResponse 忘记密码响应
 type ForgotPasswordResponse struct {
-	Message          string `json:"message" example:"Password reset code sent"`
-	Email            string `json:"email" example:"user@example.com"`
-	VerificationCode string `json:"verification_code" example:"123456"` // 仅用于测试，生产环境应删除
-	ExpiresIn        int    `json:"expires_in" example:"300"`
+	Message   string `json:"message" example:"Password reset code sent"`
+	Email     string `json:"email" example:"user@example.com"`
+	ExpiresIn int    `json:"expires_in" example:"300"`
+}
+
+// ReportSignInRequest 举报陌生登录请求（“不是我本人”链接）
+type ReportSignInRequest struct {
+	Token string `json:"token" binding:"required" example:"3q2-7wAAAAB..."`
+}
+
+// ReportSignInResponse 举报陌生登录响应
+type ReportSignInResponse struct {
+	Message string `json:"message" example:"All sessions have been signed out. Please reset your password to sign in again"`
+}
+
+// ChangeEmailResponse 修改邮箱响应（验证码已发送至新邮箱）
+type ChangeEmailResponse struct {
+	Message   string `json:"message" example:"Verification code sent to your new email"`
+	ExpiresIn int    `json:"expires_in" example:"900"`
+}
+
+// ConfirmEmailChangeResponse 确认修改邮箱响应
+type ConfirmEmailChangeResponse struct {
+	Message string    `json:"message" example:"Email changed successfully"`
+	User    *UserInfo `json:"user"`
+}
+
+// RevertEmailChangeResponse 撤销邮箱变更响应
+type RevertEmailChangeResponse struct {
+	Message string `json:"message" example:"Your email has been restored. Please reset your password to sign in again"`
 }
 
 // ResetPasswordResponse 重置密码响应
@@ -139,8 +255,8 @@ type ResetPasswordResponse struct {
 
 // UpdateProfileResponse 更新资料响应
 type UpdateProfileResponse struct {
-	Message string    `json:"message" example:"Profile updated successfully"`
-	User    *UserInfo `json:"user"`
+	Message string       `json:"message" example:"Profile updated successfully"`
+	User    *UserProfile `json:"user"`
 }
 
 // UserListItem 用户列表项
@@ -199,6 +315,25 @@ func (u *User) ToUserInfo() *UserInfo {
 	}
 }
 
+// ToProfile 将User模型转换为UserProfile DTO
+func (u *User) ToProfile() *UserProfile {
+	locale := u.Locale()
+	return &UserProfile{
+		ID:              u.ID,
+		Email:           u.Email,
+		Name:            u.Name,
+		Status:          u.Status,
+		EmailVerified:   u.EmailVerified,
+		HasAvatar:       u.AvatarPath != nil,
+		AvatarUpdatedAt: u.AvatarUpdatedAt,
+		Language:        locale.Language,
+		Timezone:        locale.Timezone,
+		PhoneNumber:     u.PhoneNumber,
+		PhoneVerifiedAt: u.PhoneVerifiedAt,
+		CreatedAt:       u.CreatedAt,
+	}
+}
+
 // ToUserListItem 将User模型转换为UserListItem DTO
 func (u *User) ToUserListItem() *UserListItem {
 	return &UserListItem{
@@ -230,13 +365,16 @@ func (req *SimpleRegisterRequest) ToUser() *User {
 	}
 }
 
-// ApplyUpdate 将UpdateProfileRequest的更新应用到User模型
+// ApplyUpdate 将UpdateProfileRequest的更新应用到User模型（调用前需已校验并规范化各字段）
 func (req *UpdateProfileRequest) ApplyUpdate(user *User) {
-	if req.Name != "" {
-		user.Name = req.Name
+	if req.Name != nil {
+		user.Name = *req.Name
 	}
-	if req.Email != "" {
-		user.Email = req.Email
+	if req.Language != nil {
+		user.Language = *req.Language
+	}
+	if req.Timezone != nil {
+		user.Timezone = *req.Timezone
 	}
 }
 
@@ -292,6 +430,7 @@ type UserSessionInfo struct {
 	DeviceInfo   *map[string]interface{} `json:"device_info"`
 	LocationInfo *map[string]interface{} `json:"location_info"`
 	IsActive     bool                    `json:"is_active"`
+	IsCurrent    bool                    `json:"is_current"`
 	LastActivity string                  `json:"last_activity"`
 	ExpiresAt    string                  `json:"expires_at"`
 	CreatedAt    string                  `json:"created_at"`
@@ -319,6 +458,12 @@ type UserSessionsResponse struct {
 	Total    int                `json:"total"`
 }
 
+// RevokeSessionsResponse 撤销会话响应
+type RevokeSessionsResponse struct {
+	Message      string `json:"message"`
+	RevokedCount int    `json:"revoked_count"`
+}
+
 // ========== 验证方法 ==========
 
 // Validate 验证RegisterRequest
@@ -2,8 +2,12 @@ package user
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math"
 	"net"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -18,14 +22,16 @@ import (
 type Handler struct {
 	service    *Service
 	jwtManager *auth.JWTManager
+	riskScorer *auth.LoginRiskScorer
 	logger     *logrus.Logger
 }
 
 // NewHandler 创建新的用户认证处理器
-func NewHandler(service *Service, jwtManager *auth.JWTManager, logger *logrus.Logger) *Handler {
+func NewHandler(service *Service, jwtManager *auth.JWTManager, riskScorer *auth.LoginRiskScorer, logger *logrus.Logger) *Handler {
 	return &Handler{
 		service:    service,
 		jwtManager: jwtManager,
+		riskScorer: riskScorer,
 		logger:     logger,
 	}
 }
@@ -50,6 +56,14 @@ func (h *Handler) Register(c *gin.Context) {
 	// 创建新用户
 	user, err := h.service.CreateSimpleUser(ctx, req.Email, req.Password)
 	if err != nil {
+		if message, ok := auth.DescribePasswordError(err); ok {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Weak password",
+				"message": message,
+			})
+			return
+		}
+
 		h.logger.WithError(err).Error("Failed to create user")
 		c.JSON(http.StatusConflict, gin.H{
 			"error":   "Registration failed",
@@ -88,27 +102,20 @@ func (h *Handler) Login(c *gin.Context) {
 	ipAddress := c.ClientIP()
 
 	// 发送登录验证码
-	verificationCode, err := h.service.SendLoginVerificationCode(ctx, req.Email, req.Password, ipAddress)
+	err := h.service.SendLoginVerificationCode(ctx, req.Email, req.Password, ipAddress)
 	if err != nil {
 		h.logger.WithFields(logrus.Fields{
 			"email": req.Email,
 			"error": err.Error(),
 		}).Warn("Failed to send login verification code")
 
-		statusCode := http.StatusUnauthorized
-		message := "Invalid email or password"
-
-		// 根据错误类型返回不同的响应
-		switch err.Error() {
-		case "too many verification attempts":
-			statusCode = http.StatusTooManyRequests
-			message = "Too many attempts, please try again later"
+		// 凭证错误和锁定计入失败登录日志（验证码发送频率限制除外）
+		if !errors.Is(err, auth.ErrTooManyAttempts) {
+			userAgent := c.GetHeader("User-Agent")
+			h.logFailedLogin(req.Email, ipAddress, &userAgent, h.parseDeviceInfo(userAgent), h.parseLocationInfo(ipAddress), loginFailureReason(err))
 		}
 
-		c.JSON(statusCode, gin.H{
-			"error":   "Login failed",
-			"message": message,
-		})
+		h.respondLoginError(c, err, "Login failed")
 		return
 	}
 
@@ -116,15 +123,893 @@ func (h *Handler) Login(c *gin.Context) {
 		"email": req.Email,
 	}).Info("Login verification code sent")
 
-	c.JSON(http.StatusOK, LoginResponse{
-		Message:          "Verification code sent to your email",
-		VerificationCode: verificationCode, // 仅用于测试
-		ExpiresIn:        300,              // 5分钟
-	})
+	c.JSON(http.StatusOK, LoginResponse{
+		Message:   "Verification code sent to your email",
+		ExpiresIn: 300, // 5分钟
+	})
+}
+
+// GetProfile 获取用户资料
+func (h *Handler) GetProfile(c *gin.Context) {
+	claims, err := auth.GetCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.service.GetProfile(ctx, claims.UserID)
+	if err != nil {
+		h.respondProfileError(c, err, "Failed to retrieve user profile")
+		return
+	}
+
+	c.JSON(http.StatusOK, ProfileResponse{
+		User: user.ToProfile(),
+	})
+}
+
+// ========== 个人资料 ==========
+
+// avatarUploadOverhead multipart请求中除头像文件外允许的额外字节数
+const avatarUploadOverhead = 1 << 20
+
+// UpdateProfile 更新显示名称、首选语言和时区
+func (h *Handler) UpdateProfile(c *gin.Context) {
+	claims, err := auth.GetCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
+		})
+		return
+	}
+
+	var req UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid update profile request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.service.UpdateProfile(ctx, claims.UserID, &req)
+	if err != nil {
+		h.respondProfileError(c, err, "Failed to update profile")
+		return
+	}
+
+	c.JSON(http.StatusOK, UpdateProfileResponse{
+		Message: "Profile updated successfully",
+		User:    user.ToProfile(),
+	})
+}
+
+// UploadAvatar 上传头像（JPEG或PNG，裁剪缩放后保存）
+func (h *Handler) UploadAvatar(c *gin.Context) {
+	claims, err := auth.GetCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
+		})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.service.AvatarMaxSize()+avatarUploadOverhead)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			h.respondProfileError(c, auth.ErrAvatarTooLarge, "Failed to upload avatar")
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": "An image file is required in the \"file\" field",
+		})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		h.respondProfileError(c, err, "Failed to upload avatar")
+		return
+	}
+	defer file.Close()
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	user, err := h.service.UploadAvatar(ctx, claims.UserID, file)
+	if err != nil {
+		h.respondProfileError(c, err, "Failed to upload avatar")
+		return
+	}
+
+	c.JSON(http.StatusOK, UpdateProfileResponse{
+		Message: "Avatar updated successfully",
+		User:    user.ToProfile(),
+	})
+}
+
+// GetAvatar 获取当前用户的头像图片
+func (h *Handler) GetAvatar(c *gin.Context) {
+	claims, err := auth.GetCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	path, contentType, err := h.service.GetAvatar(ctx, claims.UserID)
+	if err != nil {
+		h.respondProfileError(c, err, "Failed to get avatar")
+		return
+	}
+
+	c.Header("Content-Type", contentType)
+	c.Header("Cache-Control", "private, max-age=300")
+	c.File(path)
+}
+
+// DeleteAvatar 删除头像
+func (h *Handler) DeleteAvatar(c *gin.Context) {
+	claims, err := auth.GetCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.service.DeleteAvatar(ctx, claims.UserID); err != nil {
+		h.respondProfileError(c, err, "Failed to delete avatar")
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{
+		Message: "Avatar deleted successfully",
+	})
+}
+
+// RequestPhoneVerification 绑定手机号：向新手机号发送短信验证码
+func (h *Handler) RequestPhoneVerification(c *gin.Context) {
+	claims, err := auth.GetCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
+		})
+		return
+	}
+
+	var req RequestPhoneVerificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid phone verification request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+	defer cancel()
+
+	ttl, err := h.service.RequestPhoneVerification(ctx, claims.UserID, req.PhoneNumber, c.ClientIP())
+	if err != nil {
+		h.respondProfileError(c, err, "Failed to send verification code")
+		return
+	}
+
+	c.JSON(http.StatusOK, PhoneVerificationResponse{
+		Message:   "Verification code sent to your phone",
+		ExpiresIn: int(ttl.Seconds()),
+	})
+}
+
+// ConfirmPhoneVerification 确认绑定手机号：校验短信验证码
+func (h *Handler) ConfirmPhoneVerification(c *gin.Context) {
+	claims, err := auth.GetCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
+		})
+		return
+	}
+
+	var req ConfirmPhoneVerificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid confirm phone verification request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	user, err := h.service.ConfirmPhoneVerification(ctx, claims.UserID, req.PhoneNumber, req.VerificationCode)
+	if err != nil {
+		h.respondProfileError(c, err, "Failed to verify phone number")
+		return
+	}
+
+	c.JSON(http.StatusOK, UpdateProfileResponse{
+		Message: "Phone number verified successfully",
+		User:    user.ToProfile(),
+	})
+}
+
+// RemovePhoneNumber 解绑手机号
+func (h *Handler) RemovePhoneNumber(c *gin.Context) {
+	claims, err := auth.GetCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.service.RemovePhoneNumber(ctx, claims.UserID); err != nil {
+		h.respondProfileError(c, err, "Failed to remove phone number")
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{
+		Message: "Phone number removed successfully",
+	})
+}
+
+// respondProfileError 个人资料相关的错误响应
+func (h *Handler) respondProfileError(c *gin.Context, err error, title string) {
+	switch {
+	case errors.Is(err, auth.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   title,
+			"message": "User not found",
+		})
+	case errors.Is(err, auth.ErrNameTooShort),
+		errors.Is(err, auth.ErrInvalidLanguage),
+		errors.Is(err, auth.ErrInvalidTimezone),
+		errors.Is(err, auth.ErrInvalidPhoneNumber),
+		errors.Is(err, auth.ErrInvalidAvatarImage):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   title,
+			"message": err.Error(),
+		})
+	case errors.Is(err, auth.ErrAvatarTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error":   title,
+			"message": fmt.Sprintf("The image must not be larger than %d bytes", h.service.AvatarMaxSize()),
+		})
+	case errors.Is(err, auth.ErrAvatarTypeNotAllowed):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{
+			"error":   title,
+			"message": "Only JPEG and PNG images are allowed",
+		})
+	case errors.Is(err, auth.ErrAvatarNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   title,
+			"message": "No avatar has been uploaded",
+		})
+	case errors.Is(err, auth.ErrPhoneNumberNotSet):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   title,
+			"message": "No phone number is linked to your account",
+		})
+	case errors.Is(err, auth.ErrPhoneNumberUnchanged):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   title,
+			"message": "This phone number is already linked to your account",
+		})
+	case errors.Is(err, auth.ErrPhoneNumberInUse):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   title,
+			"message": "This phone number is already in use",
+		})
+	case errors.Is(err, auth.ErrInvalidVerificationCode):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   title,
+			"message": "Invalid verification code",
+		})
+	case errors.Is(err, auth.ErrTooManyAttempts):
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":   title,
+			"message": "Too many attempts, please try again later",
+		})
+	default:
+		h.logger.WithError(err).Error("Profile operation failed")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal server error",
+			"message": title,
+		})
+	}
+}
+
+// Logout 用户登出
+func (h *Handler) Logout(c *gin.Context) {
+	claims, err := auth.GetCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	// 结束当前会话（撤销会话关联的刷新令牌）
+	if claims.SessionID != "" {
+		if err := h.endSession(ctx, claims.UserID, claims.SessionID); err != nil {
+			h.logger.WithError(err).Warn("Failed to end session on logout")
+		}
+	}
+
+	// 将当前访问令牌加入撤销列表
+	if err := h.jwtManager.RevokeAccessToken(ctx, claims); err != nil {
+		h.logger.WithError(err).Warn("Failed to revoke user access token on logout")
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"user_id": claims.UserID,
+		"email":   claims.Email,
+	}).Info("User logout")
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Logout successful",
+	})
+}
+
+// RefreshToken 刷新访问令牌（刷新令牌轮换）
+func (h *Handler) RefreshToken(c *gin.Context) {
+	var req RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid refresh token request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	// 验证刷新令牌签名
+	claims, err := h.jwtManager.ValidateRefreshToken(req.RefreshToken)
+	if err == nil && claims.UserType != "user" {
+		err = errors.New("refresh token does not belong to a user")
+	}
+	if err != nil {
+		h.logger.WithError(err).Warn("Invalid refresh token")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "Invalid refresh token",
+			"message": "The provided refresh token is invalid or expired",
+		})
+		return
+	}
+
+	// 获取用户信息
+	user, err := h.service.GetByID(ctx, claims.UserID)
+	if err != nil || !user.CanLogin() {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "Authentication failed",
+			"message": "User account is not available",
+		})
+		return
+	}
+
+	// 轮换令牌对（旧刷新令牌立即失效）
+	ipAddress := c.ClientIP()
+	tokens, err := h.jwtManager.RefreshTokenPairWithRotation(ctx, req.RefreshToken, user.Email, "user", &ipAddress)
+	if err != nil {
+		if errors.Is(err, auth.ErrRefreshTokenReused) {
+			h.logger.WithField("user_id", user.ID).Warn("User refresh token reuse detected")
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error":   "Invalid refresh token",
+				"message": "Refresh token has already been used, please log in again",
+			})
+			return
+		}
+		h.logger.WithError(err).Warn("Failed to refresh tokens")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "Invalid refresh token",
+			"message": "The provided refresh token is invalid or expired",
+		})
+		return
+	}
+
+	h.logger.WithField("user_id", user.ID).Info("User token refreshed")
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Token refreshed successfully",
+		"tokens":  tokens,
+	})
+}
+
+// VerifyLogin 验证登录验证码
+func (h *Handler) VerifyLogin(c *gin.Context) {
+	var req VerifyLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid verify login request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	// 解析设备和位置信息（用于日志记录）
+	ipAddress := c.ClientIP()
+	userAgent := c.GetHeader("User-Agent")
+	deviceInfo := h.parseDeviceInfo(userAgent)
+	locationInfo := h.parseLocationInfo(ipAddress)
+
+	// 验证登录验证码
+	user, err := h.service.VerifyLoginCode(ctx, req.Email, req.Password, req.LoginCode, ipAddress)
+	if err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"email": req.Email,
+			"error": err.Error(),
+		}).Warn("Login verification failed")
+
+		// 记录失败登录日志
+		h.logFailedLogin(req.Email, ipAddress, &userAgent, deviceInfo, locationInfo, loginFailureReason(err))
+
+		h.respondLoginError(c, err, "Verification failed")
+		return
+	}
+
+	// 评估登录风险
+	assessment := h.assessLoginRisk(ctx, user, ipAddress, deviceInfo, locationInfo)
+
+	switch assessment.Decision {
+	case auth.RiskDecisionBlock:
+		h.logRiskyLogin(user, ipAddress, &userAgent, deviceInfo, locationInfo, LoginStatusBlocked, FailureReasonHighRisk, assessment.Score)
+
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "Login blocked",
+			"message": "This login attempt was blocked for security reasons",
+		})
+		return
+
+	case auth.RiskDecisionChallenge:
+		if err := h.service.SendLoginChallengeCode(ctx, user, ipAddress); err != nil {
+			h.logger.WithError(err).Warn("Failed to send login challenge code")
+
+			statusCode := http.StatusInternalServerError
+			message := "Failed to send verification code"
+			if errors.Is(err, auth.ErrTooManyAttempts) {
+				statusCode = http.StatusTooManyRequests
+				message = "Too many attempts, please try again later"
+			}
+
+			c.JSON(statusCode, gin.H{
+				"error":   "Verification failed",
+				"message": message,
+			})
+			return
+		}
+
+		h.logRiskyLogin(user, ipAddress, &userAgent, deviceInfo, locationInfo, LoginStatusSuspicious, FailureReasonChallengeRequired, assessment.Score)
+
+		c.JSON(http.StatusForbidden, LoginChallengeResponse{
+			Error:             "Additional verification required",
+			Message:           "We sent a verification code to your email",
+			ChallengeRequired: true,
+			RiskFactors:       assessment.Factors,
+			ExpiresIn:         600, // 10分钟
+		})
+		return
+	}
+
+	h.completeLogin(c, ctx, user, req.UserAgent, ipAddress, userAgent, deviceInfo, locationInfo, assessment.Score)
+}
+
+// VerifyLoginChallenge 验证高风险登录的额外验证码
+func (h *Handler) VerifyLoginChallenge(c *gin.Context) {
+	var req VerifyLoginChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid verify login challenge request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	ipAddress := c.ClientIP()
+	userAgent := c.GetHeader("User-Agent")
+	deviceInfo := h.parseDeviceInfo(userAgent)
+	locationInfo := h.parseLocationInfo(ipAddress)
+
+	user, err := h.service.VerifyLoginChallenge(ctx, req.Email, req.Password, req.ChallengeCode, ipAddress)
+	if err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"email": req.Email,
+			"error": err.Error(),
+		}).Warn("Login challenge verification failed")
+
+		h.logFailedLogin(req.Email, ipAddress, &userAgent, deviceInfo, locationInfo, loginFailureReason(err))
+
+		h.respondLoginError(c, err, "Verification failed")
+		return
+	}
+
+	// 通过额外验证后只拦截评分达到拦截阈值的登录
+	assessment := h.assessLoginRisk(ctx, user, ipAddress, deviceInfo, locationInfo)
+	if assessment.Decision == auth.RiskDecisionBlock {
+		h.logRiskyLogin(user, ipAddress, &userAgent, deviceInfo, locationInfo, LoginStatusBlocked, FailureReasonHighRisk, assessment.Score)
+
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "Login blocked",
+			"message": "This login attempt was blocked for security reasons",
+		})
+		return
+	}
+
+	h.completeLogin(c, ctx, user, req.UserAgent, ipAddress, userAgent, deviceInfo, locationInfo, assessment.Score)
+}
+
+// magicLinkNonceCookie 保存登录链接设备nonce的Cookie名称
+const magicLinkNonceCookie = "magic_link_nonce"
+
+// RequestMagicLink 发送免密登录链接（与验证码登录共用发送频率限制）
+func (h *Handler) RequestMagicLink(c *gin.Context) {
+	var req MagicLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid magic link request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	nonce, err := h.service.RequestMagicLink(ctx, req.Email, c.ClientIP())
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrMagicLinkDisabled):
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "Not available",
+				"message": "Sign-in links are not enabled",
+			})
+		case errors.Is(err, auth.ErrTooManyAttempts):
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error":   "Request failed",
+				"message": "Too many attempts, please try again later",
+			})
+		default:
+			h.logger.WithError(err).WithField("email", req.Email).Error("Failed to send magic link")
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Internal server error",
+				"message": "Failed to send sign-in link",
+			})
+		}
+		return
+	}
+
+	// 链接只能在发起请求的浏览器中使用
+	ttl := h.service.MagicLinkTTL()
+	h.setMagicLinkNonceCookie(c, nonce, int(ttl.Seconds()))
+
+	resp := MagicLinkResponse{
+		Message:   "If the email is registered, a sign-in link has been sent",
+		ExpiresIn: int(ttl.Seconds()),
+	}
+	// 浏览器只通过HttpOnly Cookie获得nonce，避免被页面脚本或日志读取
+	if req.ReturnNonce {
+		resp.Nonce = nonce
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// VerifyMagicLink 验证登录链接并获取token
+func (h *Handler) VerifyMagicLink(c *gin.Context) {
+	var req VerifyMagicLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid verify magic link request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	nonce := req.Nonce
+	if nonce == "" {
+		nonce, _ = c.Cookie(magicLinkNonceCookie)
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	ipAddress := c.ClientIP()
+	userAgent := c.GetHeader("User-Agent")
+	deviceInfo := h.parseDeviceInfo(userAgent)
+	locationInfo := h.parseLocationInfo(ipAddress)
+
+	user, err := h.service.VerifyMagicLink(ctx, req.Token, nonce)
+	if err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"ip":    ipAddress,
+			"error": err.Error(),
+		}).Warn("Magic link verification failed")
+
+		switch {
+		case errors.Is(err, auth.ErrMagicLinkDisabled):
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "Not available",
+				"message": "Sign-in links are not enabled",
+			})
+		case errors.Is(err, auth.ErrMagicLinkInvalid):
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error":   "Verification failed",
+				"message": "The sign-in link is invalid, has expired, or was opened on a different device",
+			})
+		default:
+			h.respondLoginError(c, err, "Verification failed")
+		}
+		return
+	}
+
+	h.setMagicLinkNonceCookie(c, "", -1)
+
+	// 评估登录风险（打开邮件链接已证明拥有邮箱，等同于通过额外验证，只拦截需要直接拒绝的登录）
+	assessment := h.assessLoginRisk(ctx, user, ipAddress, deviceInfo, locationInfo)
+	if assessment.Decision == auth.RiskDecisionBlock {
+		h.logRiskyLogin(user, ipAddress, &userAgent, deviceInfo, locationInfo, LoginStatusBlocked, FailureReasonHighRisk, assessment.Score)
+
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "Login blocked",
+			"message": "This login attempt was blocked for security reasons",
+		})
+		return
+	}
+
+	h.completeLogin(c, ctx, user, req.UserAgent, ipAddress, userAgent, deviceInfo, locationInfo, assessment.Score)
+}
+
+// setMagicLinkNonceCookie 设置或清除（maxAge<0）登录链接设备nonce
+func (h *Handler) setMagicLinkNonceCookie(c *gin.Context, nonce string, maxAge int) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(magicLinkNonceCookie, nonce, maxAge, "/", "", c.Request.TLS != nil, true)
+}
+
+// completeLogin 签发令牌、创建会话并记录成功登录
+func (h *Handler) completeLogin(c *gin.Context, ctx context.Context, user *User, clientUserAgent, ipAddress, userAgent string, deviceInfo, locationInfo *map[string]interface{}, riskScore int) {
+	// 识别设备和位置（必须在签发令牌前进行，否则本次设备会被当作已知设备）
+	recognition := h.recognizeSignIn(ctx, user, ipAddress, deviceInfo, locationInfo)
+
+	// 生成JWT令牌（带会话ID、设备信息和IP地址）
+	sessionID := uuid.New().String()
+	tokens, err := h.jwtManager.GenerateSessionTokenPair(ctx, user.ID, user.Email, "user", "user", sessionID, deviceInfo, &ipAddress)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to generate tokens")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal server error",
+			"message": "Failed to generate authentication tokens",
+		})
+		return
+	}
+
+	// 创建用户会话
+	session := &UserSession{
+		SessionID:      sessionID,
+		UserID:         user.ID,
+		UserType:       "user",
+		RefreshTokenID: &tokens.RefreshTokenID, // 关联刷新令牌，撤销会话时一并撤销
+		IPAddress:      ipAddress,
+		UserAgent:      &clientUserAgent,
+		DeviceInfo:     deviceInfo,
+		LocationInfo:   locationInfo,
+		IsActive:       true,
+		ExpiresAt:      tokens.RefreshExpiresAt, // 与刷新令牌同时过期
+	}
+
+	// 保存会话到数据库
+	if err := h.service.CreateUserSession(ctx, session); err != nil {
+		h.logger.WithError(err).Warn("Failed to create user session")
+		// 不返回错误，因为这不影响登录成功
+	}
+
+	// 陌生设备或位置登录时提醒用户（异步处理）
+	if recognition != nil && recognition.IsUnrecognized() {
+		go func() {
+			notifyCtx, notifyCancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer notifyCancel()
+			if notifyErr := h.service.NotifyUnrecognizedSignIn(notifyCtx, user, session.SessionID, ipAddress, deviceInfo, locationInfo, recognition); notifyErr != nil {
+				h.logger.WithError(notifyErr).WithField("user_id", user.ID).Error("Failed to send unrecognized sign-in alert")
+			}
+		}()
+	}
+
+	// 记录成功登录日志（异步处理）
+	go func() {
+		logCtx, logCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer logCancel()
+		if logErr := h.service.LogSuccessfulLogin(logCtx, user.ID, user.Email, ipAddress, &userAgent, deviceInfo, locationInfo, &session.SessionID, riskScore); logErr != nil {
+			h.logger.WithError(logErr).Error("Failed to log successful login")
+		}
+	}()
+
+	h.logger.WithFields(logrus.Fields{
+		"user_id":    user.ID,
+		"email":      user.Email,
+		"session_id": session.SessionID,
+		"risk_score": riskScore,
+	}).Info("User login verification successful")
+
+	c.JSON(http.StatusOK, VerifyLoginResponse{
+		Message: "Login successful",
+		User:    user.ToUserInfo(),
+		Tokens:  tokens,
+		Session: session.ToUserSessionInfo(), // 返回会话信息
+	})
+}
+
+// respondLoginError 登录失败的统一响应
+// 用户不存在、密码错误、账户不可用均返回相同响应；锁定不区分维度，避免枚举账户
+func (h *Handler) respondLoginError(c *gin.Context, err error, title string) {
+	statusCode := http.StatusUnauthorized
+	message := "Invalid email or password"
+
+	var lockedErr *auth.LoginLockedError
+	switch {
+	case errors.As(err, &lockedErr):
+		statusCode = http.StatusTooManyRequests
+		message = "Too many failed attempts, please try again later"
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(lockedErr.RetryAfter.Seconds()))))
+	case errors.Is(err, auth.ErrTooManyAttempts):
+		statusCode = http.StatusTooManyRequests
+		message = "Too many attempts, please try again later"
+	case errors.Is(err, auth.ErrPasswordResetRequired):
+		statusCode = http.StatusForbidden
+		message = "Please reset your password before signing in"
+	case errors.Is(err, auth.ErrInvalidVerificationCode):
+		message = "Invalid verification code"
+	}
+
+	c.JSON(statusCode, gin.H{
+		"error":   title,
+		"message": message,
+	})
+}
+
+// loginFailureReason 登录失败原因（用于登录日志）
+func loginFailureReason(err error) string {
+	switch {
+	case errors.Is(err, auth.ErrLoginLocked):
+		return FailureReasonAccountLocked
+	case errors.Is(err, auth.ErrTooManyAttempts):
+		return FailureReasonTooManyAttempts
+	case errors.Is(err, auth.ErrPasswordResetRequired):
+		return FailureReasonPasswordResetRequired
+	case errors.Is(err, auth.ErrInvalidVerificationCode):
+		return FailureReasonInvalidCode
+	default:
+		return FailureReasonInvalidCredentials
+	}
+}
+
+// ========== 登录风险 ==========
+
+// assessLoginRisk 评估登录风险，评估失败时放行（不因风控故障阻断登录）
+func (h *Handler) assessLoginRisk(ctx context.Context, user *User, ipAddress string, deviceInfo, locationInfo *map[string]interface{}) *auth.RiskAssessment {
+	assessment, err := h.riskScorer.Assess(ctx, &auth.LoginAttempt{
+		UserID:       user.ID,
+		UserType:     "user",
+		Email:        user.Email,
+		IPAddress:    ipAddress,
+		DeviceInfo:   deviceInfo,
+		LocationInfo: locationInfo,
+	})
+	if err != nil {
+		h.logger.WithError(err).WithField("user_id", user.ID).Warn("Failed to assess login risk, allowing login")
+		return &auth.RiskAssessment{Factors: []string{}, Decision: auth.RiskDecisionAllow}
+	}
+	return assessment
+}
+
+// recognizeSignIn 识别登录设备和位置，识别失败时返回nil（不发送提醒）
+func (h *Handler) recognizeSignIn(ctx context.Context, user *User, ipAddress string, deviceInfo, locationInfo *map[string]interface{}) *auth.SignInRecognition {
+	recognition, err := h.riskScorer.RecognizeSignIn(ctx, &auth.LoginAttempt{
+		UserID:       user.ID,
+		UserType:     "user",
+		Email:        user.Email,
+		IPAddress:    ipAddress,
+		DeviceInfo:   deviceInfo,
+		LocationInfo: locationInfo,
+	})
+	if err != nil {
+		h.logger.WithError(err).WithField("user_id", user.ID).Warn("Failed to recognize sign-in device")
+		return nil
+	}
+	return recognition
+}
+
+// logFailedLogin 异步评估风险并记录失败登录日志（不影响响应速度）
+func (h *Handler) logFailedLogin(email, ipAddress string, userAgent *string, deviceInfo, locationInfo *map[string]interface{}, failureReason string) {
+	go func() {
+		logCtx, logCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer logCancel()
+
+		riskScore := 0
+		assessment, err := h.riskScorer.Assess(logCtx, &auth.LoginAttempt{
+			UserType:     "user",
+			Email:        email,
+			IPAddress:    ipAddress,
+			DeviceInfo:   deviceInfo,
+			LocationInfo: locationInfo,
+		})
+		if err != nil {
+			h.logger.WithError(err).Warn("Failed to assess failed login risk")
+		} else {
+			riskScore = assessment.Score
+		}
+
+		if logErr := h.service.LogFailedLogin(logCtx, email, ipAddress, userAgent, deviceInfo, locationInfo, failureReason, riskScore); logErr != nil {
+			h.logger.WithError(logErr).Error("Failed to log failed login attempt")
+		}
+	}()
+}
+
+// logRiskyLogin 异步记录被拦截或要求额外验证的登录
+func (h *Handler) logRiskyLogin(user *User, ipAddress string, userAgent *string, deviceInfo, locationInfo *map[string]interface{}, status, reason string, riskScore int) {
+	go func() {
+		logCtx, logCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer logCancel()
+		if logErr := h.service.LogRiskyLogin(logCtx, user.ID, user.Email, ipAddress, userAgent, deviceInfo, locationInfo, status, reason, riskScore); logErr != nil {
+			h.logger.WithError(logErr).Error("Failed to log risky login attempt")
+		}
+	}()
 }
 
-// GetProfile 获取用户资料
-func (h *Handler) GetProfile(c *gin.Context) {
+// ========== 会话管理 ==========
+
+// GetSessions 获取当前用户的活跃会话（设备）列表
+func (h *Handler) GetSessions(c *gin.Context) {
 	claims, err := auth.GetCurrentUser(c)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{
@@ -137,28 +1022,34 @@ func (h *Handler) GetProfile(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 	defer cancel()
 
-	user, err := h.service.GetByID(ctx, claims.UserID)
+	sessions, err := h.service.GetUserSessions(ctx, claims.UserID, false)
 	if err != nil {
-		h.logger.WithError(err).Error("Failed to get user profile")
+		h.logger.WithError(err).Error("Failed to get user sessions")
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Internal server error",
-			"message": "Failed to retrieve user profile",
+			"message": "Failed to retrieve sessions",
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"user": UserInfo{
-			ID:     user.ID,
-			Email:  user.Email,
-			Name:   user.Name,
-			Status: user.Status,
-		},
-	})
+	response := UserSessionsResponse{
+		Sessions: make([]*UserSessionInfo, 0, len(sessions)),
+	}
+	for _, session := range sessions {
+		if session.IsExpired() {
+			continue
+		}
+		info := session.ToUserSessionInfo()
+		info.IsCurrent = session.SessionID == claims.SessionID
+		response.Sessions = append(response.Sessions, info)
+	}
+	response.Total = len(response.Sessions)
+
+	c.JSON(http.StatusOK, response)
 }
 
-// Logout 用户登出
-func (h *Handler) Logout(c *gin.Context) {
+// RevokeSession 撤销指定会话（该设备立即下线）
+func (h *Handler) RevokeSession(c *gin.Context) {
 	claims, err := auth.GetCurrentUser(c)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{
@@ -168,138 +1059,94 @@ func (h *Handler) Logout(c *gin.Context) {
 		return
 	}
 
-	h.logger.WithFields(logrus.Fields{
-		"user_id": claims.UserID,
-		"email":   claims.Email,
-	}).Info("User logout")
+	sessionID := c.Param("session_id")
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Logout successful",
-	})
-}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
 
-// VerifyLogin 验证登录验证码
-func (h *Handler) VerifyLogin(c *gin.Context) {
-	var req VerifyLoginRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.WithError(err).Warn("Invalid verify login request")
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request",
-			"message": err.Error(),
+	if err := h.endSession(ctx, claims.UserID, sessionID); err != nil {
+		if err.Error() == "session not found" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "Not found",
+				"message": "Session not found",
+			})
+			return
+		}
+		h.logger.WithError(err).Error("Failed to revoke session")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal server error",
+			"message": "Failed to revoke session",
 		})
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
-	defer cancel()
-
-	// 解析设备和位置信息（用于日志记录）
-	ipAddress := c.ClientIP()
-	userAgent := c.GetHeader("User-Agent")
-	deviceInfo := h.parseDeviceInfo(userAgent)
-	locationInfo := h.parseLocationInfo(ipAddress)
+	c.JSON(http.StatusOK, RevokeSessionsResponse{
+		Message:      "Session revoked successfully",
+		RevokedCount: 1,
+	})
+}
 
-	// 验证登录验证码
-	user, err := h.service.VerifyLoginCode(ctx, req.Email, req.Password, req.LoginCode)
+// RevokeOtherSessions 撤销除当前会话外的所有会话
+func (h *Handler) RevokeOtherSessions(c *gin.Context) {
+	claims, err := auth.GetCurrentUser(c)
 	if err != nil {
-		h.logger.WithFields(logrus.Fields{
-			"email": req.Email,
-			"error": err.Error(),
-		}).Warn("Login verification failed")
-
-		// 记录失败登录日志
-		failureReason := FailureReasonInvalidCode
-		riskScore := 3 // 默认风险分数
-		switch err.Error() {
-		case "too many verification attempts":
-			failureReason = FailureReasonTooManyAttempts
-			riskScore = 8
-		case "user not found":
-			failureReason = FailureReasonInvalidCredentials
-			riskScore = 5
-		}
-
-		// 异步记录失败日志（不影响响应速度）
-		go func() {
-			logCtx, logCancel := context.WithTimeout(context.Background(), 5*time.Second)
-			defer logCancel()
-			if logErr := h.service.LogFailedLogin(logCtx, req.Email, ipAddress, &userAgent, deviceInfo, locationInfo, failureReason, riskScore); logErr != nil {
-				h.logger.WithError(logErr).Error("Failed to log failed login attempt")
-			}
-		}()
-
-		statusCode := http.StatusUnauthorized
-		message := "Invalid verification code"
-
-		// 根据错误类型返回不同的响应
-		switch err.Error() {
-		case "too many verification attempts":
-			statusCode = http.StatusTooManyRequests
-			message = "Too many attempts, please try again later"
-		case "user not found":
-			message = "Invalid email or password"
-		}
-
-		c.JSON(statusCode, gin.H{
-			"error":   "Verification failed",
-			"message": message,
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
 		})
 		return
 	}
 
-	// 生成JWT令牌（带设备信息和IP地址）
-	tokens, err := h.jwtManager.GenerateTokenPairWithContext(ctx, user.ID, user.Email, "user", "user", deviceInfo, &ipAddress)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+	defer cancel()
+
+	sessions, err := h.service.GetUserSessions(ctx, claims.UserID, false)
 	if err != nil {
-		h.logger.WithError(err).Error("Failed to generate tokens")
+		h.logger.WithError(err).Error("Failed to get user sessions")
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Internal server error",
-			"message": "Failed to generate authentication tokens",
+			"message": "Failed to retrieve sessions",
 		})
 		return
 	}
 
-	// 创建用户会话
-	session := &UserSession{
-		SessionID:    uuid.New().String(),
-		UserID:       user.ID,
-		UserType:     "user",
-		IPAddress:    ipAddress,
-		UserAgent:    &req.UserAgent,
-		DeviceInfo:   deviceInfo,
-		LocationInfo: locationInfo,
-		IsActive:     true,
-		ExpiresAt:    GetDefaultExpirationTime(false), // 使用默认过期时间
-	}
-
-	// 保存会话到数据库
-	if err := h.service.CreateUserSession(ctx, session); err != nil {
-		h.logger.WithError(err).Warn("Failed to create user session")
-		// 不返回错误，因为这不影响登录成功
-	}
-
-	// 记录成功登录日志（异步处理）
-	go func() {
-		logCtx, logCancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer logCancel()
-		if logErr := h.service.LogSuccessfulLogin(logCtx, user.ID, user.Email, ipAddress, &userAgent, deviceInfo, locationInfo, &session.SessionID); logErr != nil {
-			h.logger.WithError(logErr).Error("Failed to log successful login")
+	revoked := 0
+	for _, session := range sessions {
+		if session.SessionID == claims.SessionID {
+			continue
 		}
-	}()
+		if err := h.endSession(ctx, claims.UserID, session.SessionID); err != nil {
+			h.logger.WithError(err).WithField("session_id", session.SessionID).Error("Failed to revoke session")
+			continue
+		}
+		revoked++
+	}
 
 	h.logger.WithFields(logrus.Fields{
-		"user_id":    user.ID,
-		"email":      user.Email,
-		"session_id": session.SessionID,
-	}).Info("User login verification successful")
+		"user_id":       claims.UserID,
+		"revoked_count": revoked,
+	}).Info("Other user sessions revoked")
 
-	c.JSON(http.StatusOK, VerifyLoginResponse{
-		Message: "Login successful",
-		User:    user.ToUserInfo(),
-		Tokens:  tokens,
-		Session: session.ToUserSessionInfo(), // 返回会话信息
+	c.JSON(http.StatusOK, RevokeSessionsResponse{
+		Message:      "Other sessions revoked successfully",
+		RevokedCount: revoked,
 	})
 }
 
+// endSession 结束会话：停用会话记录、撤销关联的刷新令牌族并使会话的访问令牌失效
+func (h *Handler) endSession(ctx context.Context, userID, sessionID string) error {
+	session, err := h.service.GetUserSession(ctx, userID, sessionID)
+	if err != nil {
+		return err
+	}
+
+	if err := h.jwtManager.RevokeSession(ctx, session.SessionID, session.RefreshTokenID); err != nil {
+		return err
+	}
+
+	return h.service.EndSession(ctx, userID, session.SessionID)
+}
+
 // ========== 辅助方法 ==========
 
 // parseDeviceInfo 解析设备信息
@@ -376,6 +1223,227 @@ func (h *Handler) parseLocationInfo(ipAddress string) *map[string]interface{} {
 	return &locationInfo
 }
 
+// ReportUnrecognizedSignIn 用户通过提醒邮件中的链接确认登录不是本人操作
+func (h *Handler) ReportUnrecognizedSignIn(c *gin.Context) {
+	var req ReportSignInRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid report sign-in request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	alert, err := h.service.GetPendingLoginAlert(ctx, req.Token)
+	if err != nil {
+		h.respondReportSignInError(c, err)
+		return
+	}
+
+	// 先撤销全部令牌（刷新令牌和已签发的访问令牌），再标记举报
+	if err := h.jwtManager.RevokeAllUserTokens(ctx, alert.UserID, "user"); err != nil {
+		h.logger.WithError(err).WithField("user_id", alert.UserID).Error("Failed to revoke user tokens")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal server error",
+			"message": "Failed to sign out your sessions, please try again",
+		})
+		return
+	}
+
+	if err := h.service.ReportUnrecognizedSignIn(ctx, alert, c.ClientIP()); err != nil {
+		h.respondReportSignInError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ReportSignInResponse{
+		Message: "All sessions have been signed out. Please reset your password to sign in again",
+	})
+}
+
+// respondReportSignInError 举报陌生登录的错误响应
+func (h *Handler) respondReportSignInError(c *gin.Context, err error) {
+	if errors.Is(err, auth.ErrLoginAlertNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Invalid link",
+			"message": "This link is invalid, expired or has already been used",
+		})
+		return
+	}
+
+	h.logger.WithError(err).Error("Failed to report unrecognized sign-in")
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":   "Internal server error",
+		"message": "Failed to process your report",
+	})
+}
+
+// ========== 邮箱变更 ==========
+
+// RequestEmailChange 修改邮箱：确认当前密码并向新邮箱发送验证码
+func (h *Handler) RequestEmailChange(c *gin.Context) {
+	claims, err := auth.GetCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
+		})
+		return
+	}
+
+	var req ChangeEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid change email request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	if err := h.service.RequestEmailChange(ctx, claims.UserID, req.NewEmail, req.Password, c.ClientIP()); err != nil {
+		h.respondEmailChangeError(c, err, "Failed to send verification code")
+		return
+	}
+
+	c.JSON(http.StatusOK, ChangeEmailResponse{
+		Message:   "Verification code sent to your new email",
+		ExpiresIn: 900, // 15分钟
+	})
+}
+
+// ConfirmEmailChange 确认修改邮箱：校验新邮箱收到的验证码并更新邮箱
+func (h *Handler) ConfirmEmailChange(c *gin.Context) {
+	claims, err := auth.GetCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
+		})
+		return
+	}
+
+	var req ConfirmEmailChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid confirm email change request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	user, err := h.service.ConfirmEmailChange(ctx, claims.UserID, req.NewEmail, req.VerificationCode, c.ClientIP())
+	if err != nil {
+		h.respondEmailChangeError(c, err, "Failed to change email")
+		return
+	}
+
+	c.JSON(http.StatusOK, ConfirmEmailChangeResponse{
+		Message: "Email changed successfully",
+		User:    user.ToUserInfo(),
+	})
+}
+
+// RevertEmailChange 原邮箱通过撤销链接恢复邮箱，并结束所有会话
+func (h *Handler) RevertEmailChange(c *gin.Context) {
+	var req RevertEmailChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid revert email change request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	change, err := h.service.GetRevertableEmailChange(ctx, req.Token)
+	if err != nil {
+		h.respondEmailChangeError(c, err, "Failed to revert email change")
+		return
+	}
+
+	// 先撤销全部令牌（刷新令牌和已签发的访问令牌），再恢复邮箱
+	if err := h.jwtManager.RevokeAllUserTokens(ctx, change.UserID, "user"); err != nil {
+		h.logger.WithError(err).WithField("user_id", change.UserID).Error("Failed to revoke user tokens")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal server error",
+			"message": "Failed to sign out your sessions, please try again",
+		})
+		return
+	}
+
+	if err := h.service.RevertEmailChange(ctx, change, c.ClientIP()); err != nil {
+		h.respondEmailChangeError(c, err, "Failed to revert email change")
+		return
+	}
+
+	c.JSON(http.StatusOK, RevertEmailChangeResponse{
+		Message: "Your email has been restored. Please reset your password to sign in again",
+	})
+}
+
+// respondEmailChangeError 邮箱变更的错误响应
+func (h *Handler) respondEmailChangeError(c *gin.Context, err error, title string) {
+	var lockedErr *auth.LoginLockedError
+	switch {
+	case errors.As(err, &lockedErr):
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(lockedErr.RetryAfter.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":   title,
+			"message": "Too many failed attempts, please try again later",
+		})
+	case errors.Is(err, auth.ErrTooManyAttempts):
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":   title,
+			"message": "Too many attempts, please try again later",
+		})
+	case errors.Is(err, auth.ErrInvalidCredentials):
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   title,
+			"message": "Invalid password",
+		})
+	case errors.Is(err, auth.ErrInvalidVerificationCode):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   title,
+			"message": "Invalid verification code",
+		})
+	case errors.Is(err, auth.ErrEmailUnchanged):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   title,
+			"message": "The new email is the same as your current email",
+		})
+	case errors.Is(err, auth.ErrEmailInUse):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   title,
+			"message": "This email address is already in use",
+		})
+	case errors.Is(err, auth.ErrEmailChangeNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Invalid link",
+			"message": "This link is invalid, expired or has already been used",
+		})
+	default:
+		h.logger.WithError(err).Error("Email change failed")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal server error",
+			"message": title,
+		})
+	}
+}
+
 // ForgotPassword 忘记密码，发送密码重置验证码
 func (h *Handler) ForgotPassword(c *gin.Context) {
 	var req ForgotPasswordRequest
@@ -395,7 +1463,12 @@ func (h *Handler) ForgotPassword(c *gin.Context) {
 	ipAddress := c.ClientIP()
 
 	// 发送密码重置验证码
-	verificationCode, err := h.service.ForgotPassword(ctx, req.Email, ipAddress)
+	err := h.service.ForgotPassword(ctx, req.Email, ipAddress)
+	if errors.Is(err, auth.ErrUserNotFound) {
+		// 邮箱未注册时返回与成功相同的响应，避免枚举账户
+		h.logger.WithField("email", req.Email).Info("Password reset requested for unknown email")
+		err = nil
+	}
 	if err != nil {
 		h.logger.WithFields(logrus.Fields{
 			"email": req.Email,
@@ -407,8 +1480,6 @@ func (h *Handler) ForgotPassword(c *gin.Context) {
 
 		// 根据错误类型返回不同的响应
 		switch err {
-		case auth.ErrUserNotFound:
-			message = "Please register first, email not found"
 		case auth.ErrTooManyAttempts:
 			statusCode = http.StatusTooManyRequests
 			message = "Too many attempts, please try again later"
@@ -428,10 +1499,9 @@ func (h *Handler) ForgotPassword(c *gin.Context) {
 	}).Info("Password reset verification code sent")
 
 	c.JSON(http.StatusOK, ForgotPasswordResponse{
-		Message:          "Password reset code sent to your email",
-		Email:            req.Email,
-		VerificationCode: verificationCode, // 仅用于测试
-		ExpiresIn:        900,              // 15分钟
+		Message:   "If the email is registered, a password reset code has been sent",
+		Email:     req.Email,
+		ExpiresIn: 900, // 15分钟
 	})
 }
 
@@ -466,15 +1536,18 @@ func (h *Handler) ResetPassword(c *gin.Context) {
 
 		// 根据错误类型返回不同的响应
 		switch err {
-		case auth.ErrUserNotFound:
-			message = "User not found"
-		case auth.ErrInvalidVerificationCode:
+		case auth.ErrUserNotFound, auth.ErrInvalidVerificationCode:
+			// 邮箱未注册与验证码错误返回相同响应，避免枚举账户
 			message = "Invalid or expired verification code"
 		case auth.ErrTooManyAttempts:
 			statusCode = http.StatusTooManyRequests
 			message = "Too many attempts, please try again later"
 		default:
-			message = "Failed to reset password"
+			if policyMessage, ok := auth.DescribePasswordError(err); ok {
+				message = policyMessage
+			} else {
+				message = "Failed to reset password"
+			}
 		}
 
 		c.JSON(statusCode, gin.H{
@@ -484,6 +1557,13 @@ func (h *Handler) ResetPassword(c *gin.Context) {
 		return
 	}
 
+	// 重置密码后使所有已签发的令牌失效
+	if user, err := h.service.GetByEmail(ctx, req.Email); err == nil {
+		if err := h.jwtManager.RevokeAllUserTokens(ctx, user.ID, "user"); err != nil {
+			h.logger.WithError(err).Warn("Failed to revoke user tokens after password reset")
+		}
+	}
+
 	h.logger.WithFields(logrus.Fields{
 		"email": req.Email,
 	}).Info("Password reset successfully")
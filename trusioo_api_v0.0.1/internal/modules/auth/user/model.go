@@ -18,6 +18,17 @@ type User struct {
 	EmailVerified   bool       `json:"email_verified" db:"email_verified"`       // 邮箱是否已验证
 	EmailVerifiedAt *time.Time `json:"email_verified_at" db:"email_verified_at"` // 邮箱验证时间
 
+	// 个人资料
+	AvatarPath      *string    `json:"-" db:"avatar_path"`                       // 头像文件路径（服务端本地存储，不对外暴露）
+	AvatarUpdatedAt *time.Time `json:"avatar_updated_at" db:"avatar_updated_at"` // 头像更新时间
+	Language        string     `json:"language" db:"language"`                   // 首选语言（BCP 47）
+	Timezone        string     `json:"timezone" db:"timezone"`                   // IANA时区
+	PhoneNumber     *string    `json:"phone_number" db:"phone_number"`           // 已验证的手机号（E.164）
+	PhoneVerifiedAt *time.Time `json:"phone_verified_at" db:"phone_verified_at"` // 手机号验证时间
+
+	// 安全字段
+	PasswordResetRequired bool `json:"password_reset_required" db:"password_reset_required"` // 需重置密码后才能登录
+
 	// 审计字段
 	CreatedAt time.Time  `json:"created_at" db:"created_at"` // 创建时间
 	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"` // 更新时间
@@ -56,6 +67,18 @@ func (u *User) CanLogin() bool {
 	return u.IsActive() && u.Status != string(UserStatusSuspended)
 }
 
+// Locale 返回用户的语言和时区（用于邮件等本地化内容）
+func (u *User) Locale() *auth.UserLocale {
+	locale := auth.DefaultUserLocale()
+	if u.Language != "" {
+		locale.Language = u.Language
+	}
+	if u.Timezone != "" {
+		locale.Timezone = u.Timezone
+	}
+	return locale
+}
+
 // GetPublicInfo 获取可公开的用户信息（不包含敏感数据）
 func (u *User) GetPublicInfo() *UserPublicInfo {
 	return &UserPublicInfo{
@@ -188,7 +211,7 @@ type LoginLog struct {
 	UserID        *string                 `json:"user_id" db:"user_id"`
 	Email         string                  `json:"email" db:"email"`
 	UserType      string                  `json:"user_type" db:"user_type"`       // 固定为"user"
-	LoginStatus   string                  `json:"login_status" db:"login_status"` // success, failed, blocked
+	LoginStatus   string                  `json:"login_status" db:"login_status"` // success, failed, blocked, suspicious
 	FailureReason *string                 `json:"failure_reason" db:"failure_reason"`
 	IPAddress     string                  `json:"ip_address" db:"ip_address"`
 	UserAgent     *string                 `json:"user_agent" db:"user_agent"`
@@ -201,17 +224,37 @@ type LoginLog struct {
 
 // LoginLog 相关常量
 const (
-	LoginStatusSuccess = "success"
-	LoginStatusFailed  = "failed"
-	LoginStatusBlocked = "blocked"
-
-	FailureReasonInvalidCredentials = "invalid_credentials"
-	FailureReasonInvalidCode        = "invalid_verification_code"
-	FailureReasonTooManyAttempts    = "too_many_attempts"
-	FailureReasonAccountLocked      = "account_locked"
-	FailureReasonAccountInactive    = "account_inactive"
+	LoginStatusSuccess    = "success"
+	LoginStatusFailed     = "failed"
+	LoginStatusBlocked    = "blocked"
+	LoginStatusSuspicious = "suspicious" // 风险较高，已要求额外验证
+
+	FailureReasonInvalidCredentials    = "invalid_credentials"
+	FailureReasonInvalidCode           = "invalid_verification_code"
+	FailureReasonTooManyAttempts       = "too_many_attempts"
+	FailureReasonAccountLocked         = "account_locked"
+	FailureReasonAccountInactive       = "account_inactive"
+	FailureReasonHighRisk              = "high_risk"
+	FailureReasonChallengeRequired     = "challenge_required"
+	FailureReasonPasswordResetRequired = "password_reset_required"
 )
 
+// LoginAlert 新设备/新位置登录提醒
+type LoginAlert struct {
+	ID           string                  `json:"id" db:"id"`
+	UserID       string                  `json:"user_id" db:"user_id"`
+	SessionID    *string                 `json:"session_id" db:"session_id"`
+	TokenHash    string                  `json:"-" db:"token_hash"` // “不是我本人”链接令牌的摘要
+	IPAddress    string                  `json:"ip_address" db:"ip_address"`
+	DeviceInfo   *map[string]interface{} `json:"device_info" db:"device_info"`
+	LocationInfo *map[string]interface{} `json:"location_info" db:"location_info"`
+	NewDevice    bool                    `json:"new_device" db:"new_device"`
+	NewLocation  bool                    `json:"new_location" db:"new_location"`
+	ExpiresAt    time.Time               `json:"expires_at" db:"expires_at"`
+	ReportedAt   *time.Time              `json:"reported_at" db:"reported_at"`
+	CreatedAt    time.Time               `json:"created_at" db:"created_at"`
+}
+
 // PasswordReset 密码重置记录模型
 type PasswordReset struct {
 	ID        string     `json:"id" db:"id"`
@@ -224,3 +267,18 @@ type PasswordReset struct {
 	ExpiresAt time.Time  `json:"expires_at" db:"expires_at"`
 	CreatedAt time.Time  `json:"created_at" db:"created_at"`
 }
+
+// EmailChange 邮箱变更记录模型
+type EmailChange struct {
+	ID              string     `json:"id" db:"id"`
+	UserID          string     `json:"user_id" db:"user_id"`
+	OldEmail        string     `json:"old_email" db:"old_email"`
+	NewEmail        string     `json:"new_email" db:"new_email"`
+	ChangedBy       string     `json:"changed_by" db:"changed_by"` // user, admin
+	AdminID         *string    `json:"admin_id" db:"admin_id"`
+	RevertTokenHash string     `json:"-" db:"revert_token_hash"` // 撤销链接令牌的哈希
+	RevertExpiresAt time.Time  `json:"revert_expires_at" db:"revert_expires_at"`
+	RevertedAt      *time.Time `json:"reverted_at" db:"reverted_at"`
+	IPAddress       *string    `json:"ip_address" db:"ip_address"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+}
@@ -10,6 +10,7 @@ import (
 	"trusioo_api_v0.0.1/internal/infrastructure/database"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/sirupsen/logrus"
 )
 
@@ -50,14 +51,16 @@ func (r *Repository) Create(ctx context.Context, user *User) error {
 // GetByID 根据ID获取用户
 func (r *Repository) GetByID(ctx context.Context, id string) (*User, error) {
 	query := `
-		SELECT id, email, name, password, status, email_verified, email_verified_at, created_at, updated_at
+		SELECT id, email, name, password, status, email_verified, email_verified_at, password_reset_required,
+		       avatar_path, avatar_updated_at, language, timezone, phone_number, phone_verified_at, created_at, updated_at
 		FROM users
 		WHERE id = $1 AND deleted_at IS NULL
 	`
 
 	user := &User{}
 	err := r.GetDB().QueryRowContext(ctx, query, id).Scan(
-		&user.ID, &user.Email, &user.Name, &user.Password, &user.Status, &user.EmailVerified, &user.EmailVerifiedAt, &user.CreatedAt, &user.UpdatedAt)
+		&user.ID, &user.Email, &user.Name, &user.Password, &user.Status, &user.EmailVerified, &user.EmailVerifiedAt, &user.PasswordResetRequired,
+		&user.AvatarPath, &user.AvatarUpdatedAt, &user.Language, &user.Timezone, &user.PhoneNumber, &user.PhoneVerifiedAt, &user.CreatedAt, &user.UpdatedAt)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -72,14 +75,16 @@ func (r *Repository) GetByID(ctx context.Context, id string) (*User, error) {
 // GetByEmail 根据邮箱获取用户
 func (r *Repository) GetByEmail(ctx context.Context, email string) (*User, error) {
 	query := `
-		SELECT id, email, name, password, status, email_verified, email_verified_at, created_at, updated_at
+		SELECT id, email, name, password, status, email_verified, email_verified_at, password_reset_required,
+		       avatar_path, avatar_updated_at, language, timezone, phone_number, phone_verified_at, created_at, updated_at
 		FROM users
 		WHERE email = $1 AND deleted_at IS NULL
 	`
 
 	user := &User{}
 	err := r.GetDB().QueryRowContext(ctx, query, email).Scan(
-		&user.ID, &user.Email, &user.Name, &user.Password, &user.Status, &user.EmailVerified, &user.EmailVerifiedAt, &user.CreatedAt, &user.UpdatedAt)
+		&user.ID, &user.Email, &user.Name, &user.Password, &user.Status, &user.EmailVerified, &user.EmailVerifiedAt, &user.PasswordResetRequired,
+		&user.AvatarPath, &user.AvatarUpdatedAt, &user.Language, &user.Timezone, &user.PhoneNumber, &user.PhoneVerifiedAt, &user.CreatedAt, &user.UpdatedAt)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -91,11 +96,11 @@ func (r *Repository) GetByEmail(ctx context.Context, email string) (*User, error
 	return user, nil
 }
 
-// UpdatePassword 更新用户密码
+// UpdatePassword 更新用户密码（同时解除强制重置密码）
 func (r *Repository) UpdatePassword(ctx context.Context, userID, hashedPassword string) error {
 	query := `
 		UPDATE users
-		SET password = $1, updated_at = NOW()
+		SET password = $1, password_reset_required = false, updated_at = NOW()
 		WHERE id = $2 AND deleted_at IS NULL
 	`
 
@@ -116,6 +121,22 @@ func (r *Repository) UpdatePassword(ctx context.Context, userID, hashedPassword
 	return nil
 }
 
+// UpgradePasswordHash 将用户的密码哈希替换为按当前配置重新生成的哈希
+// 仅在哈希未被并发修改时更新，不影响密码修改时间等字段
+func (r *Repository) UpgradePasswordHash(ctx context.Context, userID, oldHash, newHash string) error {
+	query := `
+		UPDATE users
+		SET password = $1
+		WHERE id = $2 AND password = $3 AND deleted_at IS NULL
+	`
+
+	if _, err := r.GetDB().ExecContext(ctx, query, newHash, userID, oldHash); err != nil {
+		return fmt.Errorf("failed to upgrade password hash: %w", err)
+	}
+
+	return nil
+}
+
 // UpdateStatus 更新用户状态
 func (r *Repository) UpdateStatus(ctx context.Context, userID, status string) error {
 	query := `
@@ -141,9 +162,9 @@ func (r *Repository) UpdateStatus(ctx context.Context, userID, status string) er
 	return nil
 }
 
-// ExistsByEmail 检查邮箱是否已存在
+// ExistsByEmail 检查邮箱是否已存在（注销冷静期内的账户仍占用邮箱，匿名化后才释放）
 func (r *Repository) ExistsByEmail(ctx context.Context, email string) (bool, error) {
-	query := `SELECT EXISTS(SELECT 1 FROM users WHERE email = $1 AND deleted_at IS NULL)`
+	query := `SELECT EXISTS(SELECT 1 FROM users WHERE email = $1)`
 
 	var exists bool
 	err := r.GetDB().QueryRowContext(ctx, query, email).Scan(&exists)
@@ -179,6 +200,104 @@ func (r *Repository) UpdateEmailVerified(ctx context.Context, userID string, ver
 	return nil
 }
 
+// ========== 个人资料相关方法 ==========
+
+// UpdateProfile 更新用户的显示名称、首选语言和时区
+func (r *Repository) UpdateProfile(ctx context.Context, userID, name, language, timezone string) error {
+	query := `
+		UPDATE users
+		SET name = $2, language = $3, timezone = $4, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+
+	result, err := r.GetDB().ExecContext(ctx, query, userID, name, language, timezone)
+	if err != nil {
+		return fmt.Errorf("failed to update profile: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
+// UpdateAvatar 更新用户头像文件路径（avatarPath为空表示删除头像），返回被替换的旧文件路径
+func (r *Repository) UpdateAvatar(ctx context.Context, userID string, avatarPath *string) (*string, error) {
+	query := `
+		UPDATE users u
+		SET avatar_path = $2,
+		    avatar_updated_at = CASE WHEN $2::varchar IS NULL THEN NULL ELSE NOW() END,
+		    updated_at = NOW()
+		FROM users old
+		WHERE u.id = old.id AND u.id = $1 AND u.deleted_at IS NULL
+		RETURNING old.avatar_path
+	`
+
+	var oldPath sql.NullString
+	err := r.GetDB().QueryRowContext(ctx, query, userID, avatarPath).Scan(&oldPath)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("user not found")
+		}
+		return nil, fmt.Errorf("failed to update avatar: %w", err)
+	}
+
+	if !oldPath.Valid {
+		return nil, nil
+	}
+	return &oldPath.String, nil
+}
+
+// ExistsByPhoneNumber 检查手机号是否已被其他账户绑定
+func (r *Repository) ExistsByPhoneNumber(ctx context.Context, phoneNumber, excludeUserID string) (bool, error) {
+	query := `SELECT EXISTS(SELECT 1 FROM users WHERE phone_number = $1 AND id <> $2 AND deleted_at IS NULL)`
+
+	var exists bool
+	err := r.GetDB().QueryRowContext(ctx, query, phoneNumber, excludeUserID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check phone number existence: %w", err)
+	}
+
+	return exists, nil
+}
+
+// UpdatePhoneNumber 绑定通过验证的手机号（phoneNumber为空表示解绑）
+// 手机号已被其他账户绑定时返回"phone number already in use"
+func (r *Repository) UpdatePhoneNumber(ctx context.Context, userID string, phoneNumber *string) error {
+	query := `
+		UPDATE users
+		SET phone_number = $2,
+		    phone_verified_at = CASE WHEN $2::varchar IS NULL THEN NULL ELSE NOW() END,
+		    updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+
+	result, err := r.GetDB().ExecContext(ctx, query, userID, phoneNumber)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("phone number already in use")
+		}
+		return fmt.Errorf("failed to update phone number: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
 // List 获取用户列表
 func (r *Repository) List(ctx context.Context, limit, offset int) ([]*User, error) {
 	query := `
@@ -288,34 +407,11 @@ func (r *Repository) GetUserSessions(ctx context.Context, userID string, include
 
 	var sessions []*UserSession
 	for rows.Next() {
-		session := &UserSession{}
-		var deviceInfoJSON, locationInfoJSON sql.NullString
-
-		err := rows.Scan(
-			&session.ID, &session.SessionID, &session.UserID, &session.UserType,
-			&session.RefreshTokenID, &session.IPAddress, &session.UserAgent,
-			&deviceInfoJSON, &locationInfoJSON, &session.IsActive,
-			&session.LastActivity, &session.ExpiresAt, &session.CreatedAt)
-
+		session, err := scanUserSession(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan user session: %w", err)
 		}
 
-		// 反序列化JSON字段
-		if deviceInfoJSON.Valid {
-			var deviceInfo map[string]interface{}
-			if err := json.Unmarshal([]byte(deviceInfoJSON.String), &deviceInfo); err == nil {
-				session.DeviceInfo = &deviceInfo
-			}
-		}
-
-		if locationInfoJSON.Valid {
-			var locationInfo map[string]interface{}
-			if err := json.Unmarshal([]byte(locationInfoJSON.String), &locationInfo); err == nil {
-				session.LocationInfo = &locationInfo
-			}
-		}
-
 		sessions = append(sessions, session)
 	}
 
@@ -326,6 +422,80 @@ func (r *Repository) GetUserSessions(ctx context.Context, userID string, include
 	return sessions, nil
 }
 
+// GetUserSession 获取用户的指定会话
+func (r *Repository) GetUserSession(ctx context.Context, userID, sessionID string) (*UserSession, error) {
+	query := `
+		SELECT id, session_id, user_id, user_type, refresh_token_id,
+		       ip_address, user_agent, device_info, location_info,
+		       is_active, last_activity, expires_at, created_at
+		FROM user_sessions
+		WHERE user_id = $1 AND user_type = 'user' AND session_id = $2
+	`
+
+	session, err := scanUserSession(r.GetDB().QueryRowContext(ctx, query, userID, sessionID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("session not found")
+		}
+		return nil, fmt.Errorf("failed to get user session: %w", err)
+	}
+
+	return session, nil
+}
+
+// DeactivateUserSession 停用用户的指定会话
+func (r *Repository) DeactivateUserSession(ctx context.Context, userID, sessionID string) error {
+	query := `
+		UPDATE user_sessions
+		SET is_active = false, last_activity = NOW()
+		WHERE user_id = $1 AND user_type = 'user' AND session_id = $2 AND is_active = true
+	`
+
+	_, err := r.GetDB().ExecContext(ctx, query, userID, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to deactivate user session: %w", err)
+	}
+
+	return nil
+}
+
+// sessionScanner 会话行扫描接口（兼容 *sql.Row 和 *sql.Rows）
+type sessionScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanUserSession 扫描会话记录并反序列化JSON字段
+func scanUserSession(scanner sessionScanner) (*UserSession, error) {
+	session := &UserSession{}
+	var deviceInfoJSON, locationInfoJSON sql.NullString
+
+	err := scanner.Scan(
+		&session.ID, &session.SessionID, &session.UserID, &session.UserType,
+		&session.RefreshTokenID, &session.IPAddress, &session.UserAgent,
+		&deviceInfoJSON, &locationInfoJSON, &session.IsActive,
+		&session.LastActivity, &session.ExpiresAt, &session.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	// 反序列化JSON字段
+	if deviceInfoJSON.Valid {
+		var deviceInfo map[string]interface{}
+		if err := json.Unmarshal([]byte(deviceInfoJSON.String), &deviceInfo); err == nil {
+			session.DeviceInfo = &deviceInfo
+		}
+	}
+
+	if locationInfoJSON.Valid {
+		var locationInfo map[string]interface{}
+		if err := json.Unmarshal([]byte(locationInfoJSON.String), &locationInfo); err == nil {
+			session.LocationInfo = &locationInfo
+		}
+	}
+
+	return session, nil
+}
+
 // DeactivateAllUserSessions 停用用户所有会话
 func (r *Repository) DeactivateAllUserSessions(ctx context.Context, userID string) error {
 	query := `
@@ -469,3 +639,263 @@ func (r *Repository) CreatePasswordReset(ctx context.Context, reset *PasswordRes
 
 	return nil
 }
+
+// ========== 邮箱变更相关方法 ==========
+
+// ChangeEmail 在同一事务中更新用户邮箱并写入变更记录
+// 只有当前邮箱仍为change.OldEmail时才会更新，新邮箱已被其他账户（包括已删除账户）占用时返回"email already in use"
+func (r *Repository) ChangeEmail(ctx context.Context, change *EmailChange, emailVerified bool) error {
+	change.ID = uuid.New().String()
+
+	err := r.GetDB().Transaction(func(tx *sql.Tx) error {
+		var taken bool
+		if err := tx.QueryRowContext(ctx,
+			`SELECT EXISTS(SELECT 1 FROM users WHERE email = $1 AND id <> $2)`,
+			change.NewEmail, change.UserID).Scan(&taken); err != nil {
+			return fmt.Errorf("failed to check email existence: %w", err)
+		}
+		if taken {
+			return fmt.Errorf("email already in use")
+		}
+
+		result, err := tx.ExecContext(ctx, `
+			UPDATE users
+			SET email = $1, email_verified = $2,
+			    email_verified_at = CASE WHEN $2 = true THEN NOW() ELSE NULL END, updated_at = NOW()
+			WHERE id = $3 AND email = $4 AND deleted_at IS NULL
+		`, change.NewEmail, emailVerified, change.UserID, change.OldEmail)
+		if err != nil {
+			if isUniqueViolation(err) {
+				return fmt.Errorf("email already in use")
+			}
+			return fmt.Errorf("failed to update email: %w", err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rowsAffected == 0 {
+			return fmt.Errorf("user not found")
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO email_changes (
+				id, user_id, old_email, new_email, changed_by, admin_id,
+				revert_token_hash, revert_expires_at, ip_address, created_at
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW())
+		`, change.ID, change.UserID, change.OldEmail, change.NewEmail, change.ChangedBy, change.AdminID,
+			change.RevertTokenHash, change.RevertExpiresAt, change.IPAddress)
+		if err != nil {
+			return fmt.Errorf("failed to create email change: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	change.CreatedAt = time.Now()
+	return nil
+}
+
+// GetRevertableEmailChange 根据撤销令牌哈希获取未撤销且未过期的邮箱变更
+func (r *Repository) GetRevertableEmailChange(ctx context.Context, tokenHash string) (*EmailChange, error) {
+	query := `
+		SELECT id, user_id, old_email, new_email, changed_by, admin_id, revert_token_hash,
+		       revert_expires_at, reverted_at, host(ip_address), created_at
+		FROM email_changes
+		WHERE revert_token_hash = $1 AND reverted_at IS NULL AND revert_expires_at > NOW()
+	`
+
+	change := &EmailChange{}
+	var ipAddress sql.NullString
+	err := r.GetDB().QueryRowContext(ctx, query, tokenHash).Scan(
+		&change.ID, &change.UserID, &change.OldEmail, &change.NewEmail, &change.ChangedBy, &change.AdminID,
+		&change.RevertTokenHash, &change.RevertExpiresAt, &change.RevertedAt, &ipAddress, &change.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("email change not found")
+		}
+		return nil, fmt.Errorf("failed to get email change: %w", err)
+	}
+	if ipAddress.Valid {
+		change.IPAddress = &ipAddress.String
+	}
+
+	return change, nil
+}
+
+// RevertEmailChange 撤销邮箱变更：恢复原邮箱（视为已验证）、要求用户重置密码，
+// 并将该变更及之后的所有变更标记为已撤销，避免攻击者连续修改邮箱绕过撤销
+func (r *Repository) RevertEmailChange(ctx context.Context, change *EmailChange) error {
+	return r.GetDB().Transaction(func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, `
+			UPDATE email_changes
+			SET reverted_at = NOW()
+			WHERE user_id = $1 AND reverted_at IS NULL AND created_at >= $2
+		`, change.UserID, change.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to revert email change: %w", err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rowsAffected == 0 {
+			return fmt.Errorf("email change not found")
+		}
+
+		var taken bool
+		if err := tx.QueryRowContext(ctx,
+			`SELECT EXISTS(SELECT 1 FROM users WHERE email = $1 AND id <> $2)`,
+			change.OldEmail, change.UserID).Scan(&taken); err != nil {
+			return fmt.Errorf("failed to check email existence: %w", err)
+		}
+		if taken {
+			return fmt.Errorf("email already in use")
+		}
+
+		result, err = tx.ExecContext(ctx, `
+			UPDATE users
+			SET email = $1, email_verified = true, email_verified_at = NOW(),
+			    password_reset_required = true, updated_at = NOW()
+			WHERE id = $2 AND deleted_at IS NULL
+		`, change.OldEmail, change.UserID)
+		if err != nil {
+			if isUniqueViolation(err) {
+				return fmt.Errorf("email already in use")
+			}
+			return fmt.Errorf("failed to restore email: %w", err)
+		}
+
+		rowsAffected, err = result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rowsAffected == 0 {
+			return fmt.Errorf("user not found")
+		}
+
+		return nil
+	})
+}
+
+// isUniqueViolation 判断是否为唯一约束冲突
+func isUniqueViolation(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code == "23505"
+}
+
+// ========== 登录提醒相关方法 ==========
+
+// CreateLoginAlert 创建新设备/新位置登录提醒
+func (r *Repository) CreateLoginAlert(ctx context.Context, alert *LoginAlert) error {
+	alert.ID = uuid.New().String()
+
+	var deviceInfoJSON, locationInfoJSON []byte
+	var err error
+
+	if alert.DeviceInfo != nil {
+		deviceInfoJSON, err = json.Marshal(alert.DeviceInfo)
+		if err != nil {
+			return fmt.Errorf("failed to marshal device info: %w", err)
+		}
+	}
+
+	if alert.LocationInfo != nil {
+		locationInfoJSON, err = json.Marshal(alert.LocationInfo)
+		if err != nil {
+			return fmt.Errorf("failed to marshal location info: %w", err)
+		}
+	}
+
+	query := `
+		INSERT INTO login_alerts (id, user_id, session_id, token_hash, ip_address, device_info, location_info, new_device, new_location, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NOW())
+		RETURNING created_at
+	`
+
+	err = r.GetDB().QueryRowContext(ctx, query,
+		alert.ID, alert.UserID, alert.SessionID, alert.TokenHash, alert.IPAddress,
+		deviceInfoJSON, locationInfoJSON, alert.NewDevice, alert.NewLocation, alert.ExpiresAt,
+	).Scan(&alert.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create login alert: %w", err)
+	}
+
+	return nil
+}
+
+// GetPendingLoginAlertByTokenHash 根据令牌摘要获取未举报且未过期的登录提醒
+func (r *Repository) GetPendingLoginAlertByTokenHash(ctx context.Context, tokenHash string) (*LoginAlert, error) {
+	query := `
+		SELECT id, user_id, session_id, token_hash, host(ip_address), device_info, location_info,
+		       new_device, new_location, expires_at, reported_at, created_at
+		FROM login_alerts
+		WHERE token_hash = $1 AND reported_at IS NULL AND expires_at > NOW()
+	`
+
+	alert := &LoginAlert{}
+	var ipAddress, deviceInfoJSON, locationInfoJSON sql.NullString
+	err := r.GetDB().QueryRowContext(ctx, query, tokenHash).Scan(
+		&alert.ID, &alert.UserID, &alert.SessionID, &alert.TokenHash, &ipAddress, &deviceInfoJSON, &locationInfoJSON,
+		&alert.NewDevice, &alert.NewLocation, &alert.ExpiresAt, &alert.ReportedAt, &alert.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("login alert not found")
+		}
+		return nil, fmt.Errorf("failed to get login alert: %w", err)
+	}
+
+	alert.IPAddress = ipAddress.String
+	if deviceInfoJSON.Valid {
+		var deviceInfo map[string]interface{}
+		if err := json.Unmarshal([]byte(deviceInfoJSON.String), &deviceInfo); err == nil {
+			alert.DeviceInfo = &deviceInfo
+		}
+	}
+	if locationInfoJSON.Valid {
+		var locationInfo map[string]interface{}
+		if err := json.Unmarshal([]byte(locationInfoJSON.String), &locationInfo); err == nil {
+			alert.LocationInfo = &locationInfo
+		}
+	}
+
+	return alert, nil
+}
+
+// ReportLoginAlert 用户确认登录不是本人操作：标记提醒已举报并要求用户重置密码
+func (r *Repository) ReportLoginAlert(ctx context.Context, alertID, userID string) error {
+	return r.GetDB().Transaction(func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, `
+			UPDATE login_alerts
+			SET reported_at = NOW()
+			WHERE id = $1 AND user_id = $2 AND reported_at IS NULL
+		`, alertID, userID)
+		if err != nil {
+			return fmt.Errorf("failed to report login alert: %w", err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rowsAffected == 0 {
+			return fmt.Errorf("login alert not found")
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE users
+			SET password_reset_required = true, updated_at = NOW()
+			WHERE id = $1 AND deleted_at IS NULL
+		`, userID)
+		if err != nil {
+			return fmt.Errorf("failed to require password reset: %w", err)
+		}
+
+		return nil
+	})
+}
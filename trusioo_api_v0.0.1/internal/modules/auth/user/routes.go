@@ -28,9 +28,23 @@ func (r *Routes) RegisterRoutes(router *gin.RouterGroup) {
 		user.POST("/register", r.handler.Register)              // 简化注册：仅需email+password
 		user.POST("/login", r.handler.Login)                    // 发送登录验证码
 		user.POST("/verify-login", r.handler.VerifyLogin)       // 验证登录验证码并获取token
+		user.POST("/refresh", r.handler.RefreshToken)           // 刷新令牌（轮换）
 		user.POST("/forgot-password", r.handler.ForgotPassword) // 忘记密码
 		user.POST("/reset-password", r.handler.ResetPassword)   // 重置密码
 
+		// 高风险登录额外验证（verify-login要求额外验证时调用）
+		user.POST("/verify-login-challenge", r.handler.VerifyLoginChallenge)
+
+		// 举报陌生登录（新设备/新位置登录提醒邮件中的“不是我本人”链接）
+		user.POST("/report-sign-in", r.handler.ReportUnrecognizedSignIn)
+
+		// 免密登录链接（与验证码登录共用发送频率限制）
+		user.POST("/magic-link", r.handler.RequestMagicLink)
+		user.POST("/magic-link/verify", r.handler.VerifyMagicLink)
+
+		// 撤销邮箱变更（发往原邮箱的通知邮件中的撤销链接）
+		user.POST("/email/revert", r.handler.RevertEmailChange)
+
 		// 需要认证的路由
 		authenticated := user.Group("")
 		authenticated.Use(r.authMiddle.RequireAuth())
@@ -38,6 +52,26 @@ func (r *Routes) RegisterRoutes(router *gin.RouterGroup) {
 		{
 			authenticated.GET("/profile", r.handler.GetProfile)
 			authenticated.POST("/logout", r.handler.Logout)
+
+			// 个人资料（显示名称、语言、时区、头像）
+			authenticated.PATCH("/profile", r.handler.UpdateProfile)
+			authenticated.GET("/profile/avatar", r.handler.GetAvatar)
+			authenticated.POST("/profile/avatar", r.handler.UploadAvatar)
+			authenticated.DELETE("/profile/avatar", r.handler.DeleteAvatar)
+
+			// 绑定手机号（先向新手机号发送短信验证码，再提交验证码完成绑定）
+			authenticated.POST("/profile/phone", r.handler.RequestPhoneVerification)
+			authenticated.POST("/profile/phone/confirm", r.handler.ConfirmPhoneVerification)
+			authenticated.DELETE("/profile/phone", r.handler.RemovePhoneNumber)
+
+			// 会话与设备管理
+			authenticated.GET("/sessions", r.handler.GetSessions)
+			authenticated.DELETE("/sessions/:session_id", r.handler.RevokeSession)
+			authenticated.POST("/sessions/revoke-others", r.handler.RevokeOtherSessions)
+
+			// 修改邮箱（先确认密码并向新邮箱发送验证码，再提交验证码完成修改）
+			authenticated.POST("/email/change", r.handler.RequestEmailChange)
+			authenticated.POST("/email/change/confirm", r.handler.ConfirmEmailChange)
 		}
 	}
 }
@@ -3,37 +3,109 @@ package user
 import (
 	"context"
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
 	"math/big"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
 	"time"
 
+	"trusioo_api_v0.0.1/internal/config"
+	"trusioo_api_v0.0.1/internal/infrastructure/mailer"
+	"trusioo_api_v0.0.1/internal/infrastructure/sms"
 	"trusioo_api_v0.0.1/internal/modules/auth"
 	"trusioo_api_v0.0.1/pkg/cryptoutil"
 
+	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 )
 
 // Service 用户认证服务
 type Service struct {
-	repo       *Repository
-	verifyRepo *VerificationRepository
-	encryptor  *cryptoutil.PasswordEncryptor
-	logger     *logrus.Logger
+	repo           *Repository
+	verifyRepo     *VerificationRepository
+	encryptor      *cryptoutil.PasswordEncryptor
+	passwordPolicy *auth.PasswordPolicy
+	mailer         mailer.Mailer
+	logger         *logrus.Logger
+
+	// 陌生登录提醒（未设置时不发送）
+	alertNotifier auth.SecurityNotifier
+	alertConfig   *config.SecurityConfig
+
+	// 邮件登录链接（未设置时不可用）
+	magicLinkConfig *config.SecurityConfig
+
+	// 邮箱变更撤销链接配置
+	emailChangeConfig *config.SecurityConfig
+
+	// 用户活动观察者（管理员关注列表，未设置时不通知）
+	activityObserver auth.UserActivityObserver
+
+	// 个人资料（头像存储、可选语言和手机号验证短信，未设置时不能修改资料）
+	profileConfig *config.ProfileConfig
+	smsSender     sms.Sender
+
+	// 登录失败锁定（未设置时不锁定）
+	lockout       *auth.LoginLockout
+	dummyHash     string
+	dummyHashOnce sync.Once
 }
 
 // User结构体已移至model.go文件
 
 // NewService 创建新的用户认证服务
-func NewService(repo *Repository, verifyRepo *VerificationRepository, encryptor *cryptoutil.PasswordEncryptor, logger *logrus.Logger) *Service {
+func NewService(repo *Repository, verifyRepo *VerificationRepository, encryptor *cryptoutil.PasswordEncryptor, passwordPolicy *auth.PasswordPolicy, mailSender mailer.Mailer, logger *logrus.Logger) *Service {
 	return &Service{
-		repo:       repo,
-		verifyRepo: verifyRepo,
-		encryptor:  encryptor,
-		logger:     logger,
+		repo:           repo,
+		verifyRepo:     verifyRepo,
+		encryptor:      encryptor,
+		passwordPolicy: passwordPolicy,
+		mailer:         mailSender,
+		logger:         logger,
 	}
 }
 
+// SetLoginLockout 设置登录失败锁定服务
+func (s *Service) SetLoginLockout(lockout *auth.LoginLockout) {
+	s.lockout = lockout
+}
+
+// SetMagicLinkConfig 启用邮件登录链接
+func (s *Service) SetMagicLinkConfig(cfg *config.SecurityConfig) {
+	s.magicLinkConfig = cfg
+}
+
+// SetEmailChangeConfig 设置邮箱变更撤销链接配置
+func (s *Service) SetEmailChangeConfig(cfg *config.SecurityConfig) {
+	s.emailChangeConfig = cfg
+}
+
+// SetLoginAlertNotifier 设置新设备/新位置登录提醒的通知器
+func (s *Service) SetLoginAlertNotifier(notifier auth.SecurityNotifier, cfg *config.SecurityConfig) {
+	s.alertNotifier = notifier
+	s.alertConfig = cfg
+}
+
+// SetActivityObserver 设置用户活动观察者
+func (s *Service) SetActivityObserver(observer auth.UserActivityObserver) {
+	s.activityObserver = observer
+}
+
+// SetProfileConfig 启用个人资料编辑，手机号验证码通过smsSender发送
+func (s *Service) SetProfileConfig(cfg *config.ProfileConfig, smsSender sms.Sender) {
+	s.profileConfig = cfg
+	s.smsSender = smsSender
+}
+
 // CreateUser 创建新用户
 func (s *Service) CreateUser(ctx context.Context, email, name, password string) (*User, error) {
 	// 检查邮箱是否已存在
@@ -43,6 +115,11 @@ func (s *Service) CreateUser(ctx context.Context, email, name, password string)
 		return nil, errors.New("user with this email already exists")
 	}
 
+	// 检查密码策略
+	if err := s.passwordPolicy.Validate(ctx, &auth.PasswordSubject{UserType: "user", Email: email, Name: name}, password); err != nil {
+		return nil, err
+	}
+
 	// 加密密码
 	hashedPassword, err := s.encryptor.HashPassword(password)
 	if err != nil {
@@ -60,6 +137,7 @@ func (s *Service) CreateUser(ctx context.Context, email, name, password string)
 	if err := s.repo.Create(ctx, user); err != nil {
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
+	s.recordPasswordHistory(ctx, user.ID, hashedPassword)
 
 	s.logger.WithFields(logrus.Fields{
 		"user_id": user.ID,
@@ -70,26 +148,127 @@ func (s *Service) CreateUser(ctx context.Context, email, name, password string)
 }
 
 // ValidateCredentials 验证用户凭证
-func (s *Service) ValidateCredentials(ctx context.Context, email, password string) (*User, error) {
+// 用户不存在和密码错误返回相同的错误并同样计入失败次数，避免枚举账户
+func (s *Service) ValidateCredentials(ctx context.Context, email, password, ipAddress string) (*User, error) {
+	// 检查登录锁定
+	if err := s.checkLoginLockout(ctx, email, ipAddress); err != nil {
+		return nil, err
+	}
+
 	// 根据邮箱获取用户信息
 	user, err := s.repo.GetByEmail(ctx, email)
 	if err != nil {
-		return nil, fmt.Errorf("user not found: %w", err)
+		if err.Error() != "user not found" {
+			return nil, err
+		}
+		// 用户不存在时同样执行一次密码校验，使响应时间与密码错误一致
+		_ = s.encryptor.VerifyPassword(password, s.getDummyPasswordHash())
+		return nil, s.recordLoginFailure(ctx, email, ipAddress)
+	}
+
+	// 验证密码
+	needsRehash, err := s.encryptor.VerifyAndCheckRehash(password, user.Password)
+	if err != nil {
+		return nil, s.recordLoginFailure(ctx, email, ipAddress)
+	}
+
+	s.recordLoginSuccess(ctx, email, ipAddress)
+
+	// 旧算法或低强度的哈希在登录成功时按当前配置升级
+	if needsRehash {
+		s.upgradePasswordHash(ctx, user, password)
 	}
 
-	// 检查用户状态
+	// 检查用户状态（密码正确后才检查，避免泄露账户状态）
 	if user.Status != "active" {
 		return nil, fmt.Errorf("user account is %s", user.Status)
 	}
 
-	// 验证密码
-	if err := s.encryptor.VerifyPassword(password, user.Password); err != nil {
-		return nil, errors.New("invalid password")
+	// 举报过陌生登录的账户必须先重置密码
+	if user.PasswordResetRequired {
+		return nil, auth.ErrPasswordResetRequired
 	}
 
 	return user, nil
 }
 
+// upgradePasswordHash 使用当前配置重新生成密码哈希，失败时只记录日志（不影响登录）
+func (s *Service) upgradePasswordHash(ctx context.Context, user *User, password string) {
+	newHash, err := s.encryptor.HashPassword(password)
+	if err != nil {
+		s.logger.WithError(err).WithField("user_id", user.ID).Warn("Failed to rehash user password")
+		return
+	}
+
+	if err := s.repo.UpgradePasswordHash(ctx, user.ID, user.Password, newHash); err != nil {
+		s.logger.WithError(err).WithField("user_id", user.ID).Warn("Failed to upgrade user password hash")
+		return
+	}
+
+	user.Password = newHash
+	s.logger.WithField("user_id", user.ID).Info("User password hash upgraded")
+}
+
+// ========== 登录锁定 ==========
+
+// checkLoginLockout 检查账户、IP是否被锁定（Redis故障时放行，不阻断登录）
+func (s *Service) checkLoginLockout(ctx context.Context, email, ipAddress string) error {
+	if s.lockout == nil {
+		return nil
+	}
+
+	status, err := s.lockout.Check(ctx, "user", email, ipAddress)
+	if err != nil {
+		s.logger.WithError(err).Warn("Failed to check login lockout")
+		return nil
+	}
+	if status.Locked {
+		return &auth.LoginLockedError{RetryAfter: status.RetryAfter}
+	}
+	return nil
+}
+
+// recordLoginFailure 记录失败登录，本次失败触发锁定时返回锁定错误
+func (s *Service) recordLoginFailure(ctx context.Context, email, ipAddress string) error {
+	if s.lockout == nil {
+		return auth.ErrInvalidCredentials
+	}
+
+	status, err := s.lockout.RecordFailure(ctx, "user", email, ipAddress)
+	if err != nil {
+		s.logger.WithError(err).Warn("Failed to record login failure")
+		return auth.ErrInvalidCredentials
+	}
+	if status.Locked {
+		return &auth.LoginLockedError{RetryAfter: status.RetryAfter}
+	}
+	return auth.ErrInvalidCredentials
+}
+
+// recordLoginSuccess 密码验证成功后清除失败计数
+func (s *Service) recordLoginSuccess(ctx context.Context, email, ipAddress string) {
+	if s.lockout == nil {
+		return
+	}
+
+	if err := s.lockout.RecordSuccess(ctx, "user", email, ipAddress); err != nil {
+		s.logger.WithError(err).Warn("Failed to reset login failures")
+	}
+}
+
+// getDummyPasswordHash 获取用于等时比较的占位密码哈希
+func (s *Service) getDummyPasswordHash() string {
+	s.dummyHashOnce.Do(func() {
+		hash, err := s.encryptor.HashPassword("dummy-password-for-timing")
+		if err != nil {
+			s.logger.WithError(err).Error("Failed to generate dummy password hash")
+			return
+		}
+		s.dummyHash = hash
+	})
+	return s.dummyHash
+}
+
 // GetByID 根据ID获取用户信息
 func (s *Service) GetByID(ctx context.Context, id string) (*User, error) {
 	user, err := s.repo.GetByID(ctx, id)
@@ -112,6 +291,16 @@ func (s *Service) GetByEmail(ctx context.Context, email string) (*User, error) {
 
 // UpdatePassword 更新用户密码
 func (s *Service) UpdatePassword(ctx context.Context, userID, newPassword string) error {
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	// 检查密码策略
+	if err := s.validateNewPassword(ctx, user, newPassword); err != nil {
+		return err
+	}
+
 	// 加密新密码
 	hashedPassword, err := s.encryptor.HashPassword(newPassword)
 	if err != nil {
@@ -122,6 +311,7 @@ func (s *Service) UpdatePassword(ctx context.Context, userID, newPassword string
 	if err := s.repo.UpdatePassword(ctx, userID, hashedPassword); err != nil {
 		return fmt.Errorf("failed to update password: %w", err)
 	}
+	s.recordPasswordHistory(ctx, userID, hashedPassword)
 
 	s.logger.WithField("user_id", userID).Info("User password updated")
 	return nil
@@ -155,6 +345,11 @@ func (s *Service) CreateSimpleUser(ctx context.Context, email, password string)
 		return nil, errors.New("user with this email already exists")
 	}
 
+	// 检查密码策略
+	if err := s.passwordPolicy.Validate(ctx, &auth.PasswordSubject{UserType: "user", Email: email}, password); err != nil {
+		return nil, err
+	}
+
 	// 加密密码
 	hashedPassword, err := s.encryptor.HashPassword(password)
 	if err != nil {
@@ -173,6 +368,7 @@ func (s *Service) CreateSimpleUser(ctx context.Context, email, password string)
 	if err := s.repo.Create(ctx, user); err != nil {
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
+	s.recordPasswordHistory(ctx, user.ID, hashedPassword)
 
 	s.logger.WithFields(logrus.Fields{
 		"user_id": user.ID,
@@ -183,26 +379,22 @@ func (s *Service) CreateSimpleUser(ctx context.Context, email, password string)
 }
 
 // SendLoginVerificationCode 发送登录验证码
-func (s *Service) SendLoginVerificationCode(ctx context.Context, email, password, ipAddress string) (string, error) {
+func (s *Service) SendLoginVerificationCode(ctx context.Context, email, password, ipAddress string) error {
 	// 首先验证用户凭证
-	user, err := s.ValidateCredentials(ctx, email, password)
+	user, err := s.ValidateCredentials(ctx, email, password, ipAddress)
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	// 检查频率限制（5分钟内最多3次）
-	allowed, err := s.verifyRepo.CheckRateLimit(ctx, email, "user", "login_code", 5*time.Minute, 3)
-	if err != nil {
-		return "", fmt.Errorf("failed to check rate limit: %w", err)
-	}
-	if !allowed {
-		return "", auth.ErrTooManyAttempts
+	// 检查频率限制（与登录链接共用额度）
+	if err := s.checkLoginRateLimit(ctx, email); err != nil {
+		return err
 	}
 
 	// 生成6位数字验证码
 	code, err := s.generateVerificationCode()
 	if err != nil {
-		return "", fmt.Errorf("failed to generate verification code: %w", err)
+		return fmt.Errorf("failed to generate verification code: %w", err)
 	}
 
 	// 创建验证记录
@@ -220,23 +412,25 @@ func (s *Service) SendLoginVerificationCode(ctx context.Context, email, password
 	}
 
 	if err := s.verifyRepo.CreateVerification(ctx, verification); err != nil {
-		return "", fmt.Errorf("failed to create verification: %w", err)
+		return fmt.Errorf("failed to create verification: %w", err)
+	}
+
+	if err := auth.SendLocalizedVerificationCodeEmail(ctx, s.mailer, email, verification.Type, code, verification.ExpiresAt, user.Locale()); err != nil {
+		return err
 	}
 
-	// TODO: 这里应该发送邮件，现在先记录日志
 	s.logger.WithFields(logrus.Fields{
 		"email": email,
-		"code":  code, // 生产环境中不应该记录验证码
 		"type":  "login_code",
-	}).Info("Login verification code generated")
+	}).Info("Login verification code sent")
 
-	return code, nil
+	return nil
 }
 
 // VerifyLoginCode 验证登录验证码
-func (s *Service) VerifyLoginCode(ctx context.Context, email, password, code string) (*User, error) {
+func (s *Service) VerifyLoginCode(ctx context.Context, email, password, code, ipAddress string) (*User, error) {
 	// 首先验证用户凭证
-	user, err := s.ValidateCredentials(ctx, email, password)
+	user, err := s.ValidateCredentials(ctx, email, password, ipAddress)
 	if err != nil {
 		return nil, err
 	}
@@ -248,7 +442,7 @@ func (s *Service) VerifyLoginCode(ctx context.Context, email, password, code str
 	}
 
 	// 检查验证码是否匹配
-	if verification.VerificationCode != code {
+	if !s.verifyRepo.MatchCode(verification, code) {
 		// 增加尝试次数
 		if err := s.verifyRepo.IncrementAttempts(ctx, verification.ID); err != nil {
 			s.logger.WithError(err).Error("Failed to increment attempts")
@@ -283,6 +477,18 @@ func (s *Service) VerifyLoginCode(ctx context.Context, email, password, code str
 	return user, nil
 }
 
+// checkLoginRateLimit 检查登录验证码和登录链接合计的发送频率（5分钟内最多3次）
+func (s *Service) checkLoginRateLimit(ctx context.Context, email string) error {
+	allowed, err := s.verifyRepo.CheckCombinedRateLimit(ctx, email, "user", []string{"login_code", "magic_link"}, 5*time.Minute, 3)
+	if err != nil {
+		return fmt.Errorf("failed to check rate limit: %w", err)
+	}
+	if !allowed {
+		return auth.ErrTooManyAttempts
+	}
+	return nil
+}
+
 // generateVerificationCode 生成6位数字验证码
 func (s *Service) generateVerificationCode() (string, error) {
 	// 生成6位随机数字
@@ -325,6 +531,32 @@ func (s *Service) GetUserSessions(ctx context.Context, userID string, includeIna
 	return sessions, nil
 }
 
+// GetUserSession 获取用户的指定会话
+func (s *Service) GetUserSession(ctx context.Context, userID, sessionID string) (*UserSession, error) {
+	session, err := s.repo.GetUserSession(ctx, userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// EndSession 结束用户的指定会话
+func (s *Service) EndSession(ctx context.Context, userID, sessionID string) error {
+	if err := s.repo.DeactivateUserSession(ctx, userID, sessionID); err != nil {
+		s.logger.WithError(err).WithFields(logrus.Fields{
+			"user_id":    userID,
+			"session_id": sessionID,
+		}).Error("Failed to deactivate user session")
+		return fmt.Errorf("failed to end session: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":    userID,
+		"session_id": sessionID,
+	}).Info("User session ended")
+	return nil
+}
+
 // LogoutAllSessions 登出用户所有会话
 func (s *Service) LogoutAllSessions(ctx context.Context, userID string) error {
 	if err := s.repo.DeactivateAllUserSessions(ctx, userID); err != nil {
@@ -366,7 +598,7 @@ func (s *Service) GetUserLoginLogs(ctx context.Context, userID string, limit, of
 }
 
 // LogSuccessfulLogin 记录成功登录
-func (s *Service) LogSuccessfulLogin(ctx context.Context, userID, email, ipAddress string, userAgent *string, deviceInfo, locationInfo *map[string]interface{}, sessionID *string) error {
+func (s *Service) LogSuccessfulLogin(ctx context.Context, userID, email, ipAddress string, userAgent *string, deviceInfo, locationInfo *map[string]interface{}, sessionID *string, riskScore int) error {
 	log := &LoginLog{
 		UserID:       &userID,
 		Email:        email,
@@ -377,10 +609,28 @@ func (s *Service) LogSuccessfulLogin(ctx context.Context, userID, email, ipAddre
 		DeviceInfo:   deviceInfo,
 		LocationInfo: locationInfo,
 		SessionID:    sessionID,
-		RiskScore:    0, // 成功登录，风险评分为0
+		RiskScore:    riskScore,
 	}
 
-	return s.CreateLoginLog(ctx, log)
+	if err := s.CreateLoginLog(ctx, log); err != nil {
+		return err
+	}
+
+	if s.activityObserver != nil {
+		event := &auth.UserActivityEvent{
+			Type:       auth.UserActivityLogin,
+			UserID:     userID,
+			Email:      email,
+			IPAddress:  ipAddress,
+			OccurredAt: time.Now(),
+		}
+		if locationInfo != nil {
+			event.Details = map[string]interface{}{"location": *locationInfo}
+		}
+		s.activityObserver.OnUserActivity(ctx, event)
+	}
+
+	return nil
 }
 
 // LogFailedLogin 记录失败登录
@@ -401,117 +651,1168 @@ func (s *Service) LogFailedLogin(ctx context.Context, email, ipAddress string, u
 	return s.CreateLoginLog(ctx, log)
 }
 
-// ForgotPassword 忘记密码，发送密码重置验证码
-func (s *Service) ForgotPassword(ctx context.Context, email, ipAddress string) (string, error) {
-	// 验证邮箱是否存在
-	_, err := s.repo.GetByEmail(ctx, email)
-	if err != nil {
-		return "", auth.ErrUserNotFound
+// LogRiskyLogin 记录因风险过高被拦截或要求额外验证的登录
+func (s *Service) LogRiskyLogin(ctx context.Context, userID, email, ipAddress string, userAgent *string, deviceInfo, locationInfo *map[string]interface{}, status, reason string, riskScore int) error {
+	log := &LoginLog{
+		UserID:        &userID,
+		Email:         email,
+		UserType:      "user",
+		LoginStatus:   status,
+		FailureReason: &reason,
+		IPAddress:     ipAddress,
+		UserAgent:     userAgent,
+		DeviceInfo:    deviceInfo,
+		LocationInfo:  locationInfo,
+		RiskScore:     riskScore,
 	}
 
+	return s.CreateLoginLog(ctx, log)
+}
+
+// ========== 登录风险验证 ==========
+
+// SendLoginChallengeCode 发送高风险登录的额外验证码
+func (s *Service) SendLoginChallengeCode(ctx context.Context, user *User, ipAddress string) error {
 	// 检查频率限制（5分钟内最多3次）
-	allowed, err := s.verifyRepo.CheckRateLimit(ctx, email, "user", "password_reset", 5*time.Minute, 3)
+	allowed, err := s.verifyRepo.CheckRateLimit(ctx, user.Email, "user", "login_challenge", 5*time.Minute, 3)
 	if err != nil {
-		return "", fmt.Errorf("failed to check rate limit: %w", err)
+		return fmt.Errorf("failed to check rate limit: %w", err)
 	}
 	if !allowed {
-		return "", auth.ErrTooManyAttempts
+		return auth.ErrTooManyAttempts
 	}
 
-	// 生成6位数字验证码
 	code, err := s.generateVerificationCode()
 	if err != nil {
-		return "", fmt.Errorf("failed to generate verification code: %w", err)
+		return fmt.Errorf("failed to generate verification code: %w", err)
 	}
 
-	// 创建验证记录
 	verification := &EmailVerification{
-		Email:            email,
+		Email:            user.Email,
 		UserType:         "user",
-		Type:             "password_reset",
+		Type:             "login_challenge",
 		VerificationCode: code,
 		Attempts:         0,
 		MaxAttempts:      3,
 		Verified:         false,
 		IPAddress:        &ipAddress,
-		ExpiresAt:        time.Now().Add(15 * time.Minute), // 15分钟过期
+		ReferenceID:      &user.ID,
+		ExpiresAt:        time.Now().Add(10 * time.Minute), // 10分钟过期
 	}
 
 	if err := s.verifyRepo.CreateVerification(ctx, verification); err != nil {
-		return "", fmt.Errorf("failed to create verification: %w", err)
+		return fmt.Errorf("failed to create verification: %w", err)
+	}
+
+	if err := auth.SendLocalizedVerificationCodeEmail(ctx, s.mailer, user.Email, verification.Type, code, verification.ExpiresAt, user.Locale()); err != nil {
+		return err
 	}
 
-	// TODO: 这里应该发送邮件，现在先记录日志
 	s.logger.WithFields(logrus.Fields{
-		"email": email,
-		"code":  code, // 生产环境中不应该记录验证码
-		"type":  "user_password_reset",
-	}).Info("User password reset verification code generated")
+		"user_id": user.ID,
+		"type":    "login_challenge",
+	}).Info("Login challenge code sent")
 
-	return code, nil
+	return nil
 }
 
-// ResetPassword 重置密码
-func (s *Service) ResetPassword(ctx context.Context, email, code, newPassword, ipAddress string) error {
-	// 验证邮箱是否存在
-	user, err := s.repo.GetByEmail(ctx, email)
+// VerifyLoginChallenge 验证高风险登录的额外验证码
+func (s *Service) VerifyLoginChallenge(ctx context.Context, email, password, code, ipAddress string) (*User, error) {
+	// 重新验证用户凭证
+	user, err := s.ValidateCredentials(ctx, email, password, ipAddress)
 	if err != nil {
-		return auth.ErrUserNotFound
+		return nil, err
 	}
 
-	// 获取活跃的验证记录
-	verification, err := s.verifyRepo.GetActiveVerification(ctx, email, "user", "password_reset")
+	verification, err := s.verifyRepo.GetActiveVerification(ctx, email, "user", "login_challenge")
 	if err != nil {
-		return auth.ErrInvalidVerificationCode
+		return nil, auth.ErrInvalidVerificationCode
 	}
 
-	// 检查验证码是否匹配
-	if verification.VerificationCode != code {
-		// 增加尝试次数
+	// 检查是否可以继续尝试
+	if !verification.CanAttempt() {
+		return nil, auth.ErrTooManyAttempts
+	}
+
+	if !s.verifyRepo.MatchCode(verification, code) {
 		if err := s.verifyRepo.IncrementAttempts(ctx, verification.ID); err != nil {
 			s.logger.WithError(err).Error("Failed to increment attempts")
 		}
-		return auth.ErrInvalidVerificationCode
+		return nil, auth.ErrInvalidVerificationCode
 	}
 
-	// 检查是否可以继续尝试
-	if !verification.CanAttempt() {
-		return auth.ErrTooManyAttempts
+	if err := s.verifyRepo.MarkAsVerified(ctx, verification.ID); err != nil {
+		return nil, fmt.Errorf("failed to mark verification as used: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id": user.ID,
+		"email":   user.Email,
+	}).Info("Login challenge verification successful")
+
+	return user, nil
+}
+
+// ========== 登录链接 ==========
+
+// RequestMagicLink 发送免密登录链接，返回将链接绑定到当前设备的nonce
+// 邮箱未注册或账户不可用时同样返回nonce但不发送邮件，避免枚举账户
+func (s *Service) RequestMagicLink(ctx context.Context, email, ipAddress string) (string, error) {
+	if s.magicLinkConfig == nil {
+		return "", auth.ErrMagicLinkDisabled
+	}
+
+	if err := s.checkLoginRateLimit(ctx, email); err != nil {
+		return "", err
+	}
+
+	nonce, err := generateURLSafeToken()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate magic link nonce: %w", err)
+	}
+
+	user, err := s.repo.GetByEmail(ctx, email)
+	if err != nil {
+		if err.Error() != "user not found" {
+			return "", err
+		}
+		s.logger.WithField("email", email).Info("Magic link requested for unknown email")
+		return nonce, nil
+	}
+	if user.Status != "active" {
+		s.logger.WithField("user_id", user.ID).Info("Magic link requested for inactive account")
+		return nonce, nil
+	}
+
+	token, err := generateURLSafeToken()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate magic link token: %w", err)
+	}
+	boundToken := bindMagicLinkToken(token, nonce)
+
+	verification := &EmailVerification{
+		Email:       user.Email,
+		UserType:    "user",
+		Type:        "magic_link",
+		Token:       &boundToken,
+		Attempts:    0,
+		MaxAttempts: 1,
+		Verified:    false,
+		IPAddress:   &ipAddress,
+		ReferenceID: &user.ID,
+		ExpiresAt:   time.Now().Add(s.magicLinkConfig.MagicLinkTTL),
+	}
+
+	if err := s.verifyRepo.CreateVerification(ctx, verification); err != nil {
+		return "", fmt.Errorf("failed to create verification: %w", err)
+	}
+
+	if err := s.sendMagicLinkEmail(ctx, user, token, verification.ExpiresAt); err != nil {
+		return "", err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id": user.ID,
+		"email":   user.Email,
+	}).Info("Magic link sent")
+
+	return nonce, nil
+}
+
+// MagicLinkTTL 登录链接有效期
+func (s *Service) MagicLinkTTL() time.Duration {
+	if s.magicLinkConfig == nil {
+		return 0
+	}
+	return s.magicLinkConfig.MagicLinkTTL
+}
+
+// VerifyMagicLink 验证登录链接（一次性，且只能在发起请求的设备上使用）
+func (s *Service) VerifyMagicLink(ctx context.Context, token, nonce string) (*User, error) {
+	if s.magicLinkConfig == nil {
+		return nil, auth.ErrMagicLinkDisabled
+	}
+
+	// 入库的令牌哈希包含nonce，其他设备打开链接时查不到记录
+	verification, err := s.verifyRepo.GetActiveVerificationByToken(ctx, bindMagicLinkToken(token, nonce), "user", "magic_link")
+	if err != nil {
+		if err.Error() == "no active verification found" {
+			return nil, auth.ErrMagicLinkInvalid
+		}
+		return nil, err
 	}
 
-	// 标记验证码为已使用
 	if err := s.verifyRepo.MarkAsVerified(ctx, verification.ID); err != nil {
-		return fmt.Errorf("failed to mark verification as used: %w", err)
+		if err.Error() == "verification not found" {
+			return nil, auth.ErrMagicLinkInvalid
+		}
+		return nil, fmt.Errorf("failed to mark verification as used: %w", err)
 	}
 
-	// 加密新密码
-	hashedPassword, err := s.encryptor.HashPassword(newPassword)
+	if verification.ReferenceID == nil {
+		return nil, auth.ErrMagicLinkInvalid
+	}
+	user, err := s.repo.GetByID(ctx, *verification.ReferenceID)
 	if err != nil {
-		return fmt.Errorf("failed to hash password: %w", err)
+		if err.Error() == "user not found" {
+			return nil, auth.ErrMagicLinkInvalid
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
-	// 更新数据库中的密码
-	if err := s.repo.UpdatePassword(ctx, user.ID, hashedPassword); err != nil {
-		return fmt.Errorf("failed to update password: %w", err)
+	if user.Status != "active" {
+		return nil, fmt.Errorf("user account is %s", user.Status)
+	}
+	if user.PasswordResetRequired {
+		return nil, auth.ErrPasswordResetRequired
 	}
 
-	// 记录密码重置操作到password_resets表
-	if err := s.repo.CreatePasswordReset(ctx, &PasswordReset{
-		Email:     email,
-		UserType:  "user",
-		Token:     verification.ID, // 使用验证ID作为token
-		Used:      true,
-		UsedAt:    verification.VerifiedAt,
-		ExpiresAt: verification.ExpiresAt,
-	}); err != nil {
-		s.logger.WithError(err).Warn("Failed to record password reset")
-		// 不返回错误，因为密码已经重置成功
+	// 能够打开邮件中的链接即证明拥有该邮箱
+	if !user.EmailVerified {
+		if err := s.repo.UpdateEmailVerified(ctx, user.ID, true); err != nil {
+			s.logger.WithError(err).Error("Failed to update email verified status")
+		}
+		user.EmailVerified = true
+		user.VerifyEmail()
 	}
 
 	s.logger.WithFields(logrus.Fields{
 		"user_id": user.ID,
 		"email":   user.Email,
-	}).Info("User password reset successfully")
+	}).Info("Magic link login verification successful")
+
+	return user, nil
+}
+
+// sendMagicLinkEmail 发送登录链接邮件
+func (s *Service) sendMagicLinkEmail(ctx context.Context, user *User, token string, expiresAt time.Time) error {
+	link := s.magicLinkConfig.MagicLinkURL + "?token=" + url.QueryEscape(token)
+
+	var body strings.Builder
+	body.WriteString("Hello,\n\n")
+	body.WriteString("Use the link below to sign in to your Trusioo account:\n\n")
+	body.WriteString(link + "\n\n")
+	body.WriteString("Open it in the same browser or app where you requested it. ")
+	body.WriteString("The link can be used once and expires at " + user.Locale().FormatTime(expiresAt) + ".\n")
+	body.WriteString("If you did not request this link, you can ignore this email.\n")
+
+	if err := s.mailer.Send(ctx, &mailer.Message{
+		To:       []string{user.Email},
+		Subject:  "Your Trusioo sign-in link",
+		Body:     body.String(),
+		Language: user.Locale().LanguageTag(),
+	}); err != nil {
+		return fmt.Errorf("failed to send magic link email: %w", err)
+	}
 
 	return nil
 }
+
+// generateURLSafeToken 生成URL安全的随机令牌（登录链接令牌、设备nonce、邮箱变更撤销令牌）
+func generateURLSafeToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// bindMagicLinkToken 将登录链接令牌与设备nonce绑定，整体作为令牌哈希入库
+func bindMagicLinkToken(token, nonce string) string {
+	return token + "." + nonce
+}
+
+// ========== 邮箱变更 ==========
+
+// RequestEmailChange 用户修改邮箱第一步：校验当前密码，向新邮箱发送验证码
+func (s *Service) RequestEmailChange(ctx context.Context, userID, newEmail, password, ipAddress string) error {
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		if err.Error() == "user not found" {
+			return auth.ErrUserNotFound
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if strings.EqualFold(user.Email, newEmail) {
+		return auth.ErrEmailUnchanged
+	}
+
+	// 确认密码（与登录共用失败锁定）
+	if _, err := s.ValidateCredentials(ctx, user.Email, password, ipAddress); err != nil {
+		return err
+	}
+
+	exists, err := s.repo.ExistsByEmail(ctx, newEmail)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return auth.ErrEmailInUse
+	}
+
+	// 检查频率限制（5分钟内最多3次）
+	allowed, err := s.verifyRepo.CheckRateLimit(ctx, newEmail, "user", "email_change", 5*time.Minute, 3)
+	if err != nil {
+		return fmt.Errorf("failed to check rate limit: %w", err)
+	}
+	if !allowed {
+		return auth.ErrTooManyAttempts
+	}
+
+	code, err := s.generateVerificationCode()
+	if err != nil {
+		return fmt.Errorf("failed to generate verification code: %w", err)
+	}
+
+	verification := &EmailVerification{
+		Email:            newEmail,
+		UserType:         "user",
+		Type:             "email_change",
+		VerificationCode: code,
+		Attempts:         0,
+		MaxAttempts:      3,
+		Verified:         false,
+		IPAddress:        &ipAddress,
+		ReferenceID:      &user.ID,
+		ExpiresAt:        time.Now().Add(15 * time.Minute), // 15分钟过期
+	}
+
+	if err := s.verifyRepo.CreateVerification(ctx, verification); err != nil {
+		return fmt.Errorf("failed to create verification: %w", err)
+	}
+
+	if err := auth.SendLocalizedVerificationCodeEmail(ctx, s.mailer, newEmail, verification.Type, code, verification.ExpiresAt, user.Locale()); err != nil {
+		return err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id": user.ID,
+		"type":    "email_change",
+	}).Info("Email change verification code sent")
+
+	return nil
+}
+
+// ConfirmEmailChange 用户修改邮箱第二步：校验新邮箱收到的验证码并更新邮箱，同时通知原邮箱
+func (s *Service) ConfirmEmailChange(ctx context.Context, userID, newEmail, code, ipAddress string) (*User, error) {
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		if err.Error() == "user not found" {
+			return nil, auth.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	verification, err := s.verifyRepo.GetActiveVerification(ctx, newEmail, "user", "email_change")
+	if err != nil {
+		return nil, auth.ErrInvalidVerificationCode
+	}
+
+	// 验证码必须是当前用户发起的
+	if verification.ReferenceID == nil || *verification.ReferenceID != user.ID {
+		return nil, auth.ErrInvalidVerificationCode
+	}
+
+	if !verification.CanAttempt() {
+		return nil, auth.ErrTooManyAttempts
+	}
+
+	if !s.verifyRepo.MatchCode(verification, code) {
+		if err := s.verifyRepo.IncrementAttempts(ctx, verification.ID); err != nil {
+			s.logger.WithError(err).Error("Failed to increment attempts")
+		}
+		return nil, auth.ErrInvalidVerificationCode
+	}
+
+	if err := s.verifyRepo.MarkAsVerified(ctx, verification.ID); err != nil {
+		if err.Error() == "verification not found" {
+			return nil, auth.ErrInvalidVerificationCode
+		}
+		return nil, fmt.Errorf("failed to mark verification as used: %w", err)
+	}
+
+	// 验证码证明了新邮箱归属，变更后邮箱视为已验证
+	if _, err := s.applyEmailChange(ctx, user, newEmail, nil, ipAddress, true); err != nil {
+		return nil, err
+	}
+
+	user.Email = newEmail
+	user.EmailVerified = true
+	return user, nil
+}
+
+// ChangeEmailByAdmin 管理员直接修改用户邮箱，原邮箱同样会收到撤销链接
+// requireVerification为true时新邮箱标记为未验证，用户下次通过邮件验证码或登录链接登录时完成验证
+func (s *Service) ChangeEmailByAdmin(ctx context.Context, userID, newEmail, adminID, ipAddress string, requireVerification bool) (*EmailChange, error) {
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		if err.Error() == "user not found" {
+			return nil, auth.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if strings.EqualFold(user.Email, newEmail) {
+		return nil, auth.ErrEmailUnchanged
+	}
+
+	change, err := s.applyEmailChange(ctx, user, newEmail, &adminID, ipAddress, !requireVerification)
+	if err != nil {
+		return nil, err
+	}
+
+	// 通知新邮箱（失败只记录日志，邮箱已经修改成功）
+	var body strings.Builder
+	body.WriteString("Hello,\n\n")
+	body.WriteString("An administrator has changed the email address of your Trusioo account to this address.\n")
+	if requireVerification {
+		body.WriteString("Sign in with an email code or sign-in link to verify it.\n")
+	}
+	body.WriteString("If you do not recognise this account, please contact support.\n")
+
+	if err := s.mailer.Send(ctx, &mailer.Message{
+		To:      []string{newEmail},
+		Subject: "Your Trusioo account email was changed",
+		Body:    body.String(),
+	}); err != nil {
+		s.logger.WithError(err).WithField("user_id", user.ID).Warn("Failed to notify new email address")
+	}
+
+	return change, nil
+}
+
+// GetRevertableEmailChange 根据撤销链接令牌获取邮箱变更
+func (s *Service) GetRevertableEmailChange(ctx context.Context, token string) (*EmailChange, error) {
+	change, err := s.repo.GetRevertableEmailChange(ctx, s.verifyRepo.HashToken(token))
+	if err != nil {
+		if err.Error() == "email change not found" {
+			return nil, auth.ErrEmailChangeNotFound
+		}
+		return nil, err
+	}
+	return change, nil
+}
+
+// RevertEmailChange 原邮箱撤销邮箱变更：恢复原邮箱、结束所有会话、要求重置密码并向原邮箱发送重置验证码
+// 调用方需先撤销用户的全部令牌
+func (s *Service) RevertEmailChange(ctx context.Context, change *EmailChange, ipAddress string) error {
+	if err := s.repo.RevertEmailChange(ctx, change); err != nil {
+		switch err.Error() {
+		case "email change not found":
+			return auth.ErrEmailChangeNotFound
+		case "email already in use":
+			return auth.ErrEmailInUse
+		case "user not found":
+			return auth.ErrUserNotFound
+		}
+		return err
+	}
+
+	if err := s.LogoutAllSessions(ctx, change.UserID); err != nil {
+		return err
+	}
+
+	// 发送密码重置验证码（失败不影响撤销结果，用户仍可通过忘记密码重新获取）
+	if err := s.ForgotPassword(ctx, change.OldEmail, ipAddress); err != nil {
+		s.logger.WithError(err).WithField("user_id", change.UserID).Warn("Failed to send password reset code after email change revert")
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":   change.UserID,
+		"change_id": change.ID,
+	}).Warn("Email change reverted by previous address, all sessions revoked")
+
+	return nil
+}
+
+// applyEmailChange 更新邮箱、记录变更并向原邮箱发送撤销链接
+func (s *Service) applyEmailChange(ctx context.Context, user *User, newEmail string, adminID *string, ipAddress string, emailVerified bool) (*EmailChange, error) {
+	if s.emailChangeConfig == nil {
+		return nil, fmt.Errorf("email change is not configured")
+	}
+
+	token, err := generateURLSafeToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate email revert token: %w", err)
+	}
+
+	change := &EmailChange{
+		UserID:          user.ID,
+		OldEmail:        user.Email,
+		NewEmail:        newEmail,
+		ChangedBy:       "user",
+		AdminID:         adminID,
+		RevertTokenHash: s.verifyRepo.HashToken(token),
+		RevertExpiresAt: time.Now().Add(s.emailChangeConfig.EmailRevertTTL),
+		IPAddress:       &ipAddress,
+	}
+	if adminID != nil {
+		change.ChangedBy = "admin"
+	}
+
+	if err := s.repo.ChangeEmail(ctx, change, emailVerified); err != nil {
+		switch err.Error() {
+		case "email already in use":
+			return nil, auth.ErrEmailInUse
+		case "user not found":
+			return nil, auth.ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	// 通知原邮箱（失败只记录日志，邮箱已经修改成功）
+	if err := s.sendEmailRevertLink(ctx, change, token, user.Locale()); err != nil {
+		s.logger.WithError(err).WithField("user_id", user.ID).Error("Failed to send email change notice to previous address")
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":    user.ID,
+		"change_id":  change.ID,
+		"changed_by": change.ChangedBy,
+	}).Info("User email changed")
+
+	return change, nil
+}
+
+// sendEmailRevertLink 向原邮箱发送邮箱变更通知及撤销链接（按用户的语言和时区）
+func (s *Service) sendEmailRevertLink(ctx context.Context, change *EmailChange, token string, locale *auth.UserLocale) error {
+	link := s.emailChangeConfig.EmailRevertURL + "?token=" + url.QueryEscape(token)
+
+	var body strings.Builder
+	body.WriteString("Hello,\n\n")
+	if change.ChangedBy == "admin" {
+		body.WriteString("An administrator changed the email address of your Trusioo account to " + change.NewEmail + ".\n")
+	} else {
+		body.WriteString("The email address of your Trusioo account was changed to " + change.NewEmail + ".\n")
+	}
+	body.WriteString("If this was you, you can ignore this email.\n\n")
+	body.WriteString("If this wasn't you, use the link below to restore this address, sign out all sessions and reset your password:\n")
+	body.WriteString(link + "\n\n")
+	body.WriteString("The link expires at " + locale.FormatTime(change.RevertExpiresAt) + ".\n")
+
+	if err := s.mailer.Send(ctx, &mailer.Message{
+		To:       []string{change.OldEmail},
+		Subject:  "Your Trusioo account email was changed",
+		Body:     body.String(),
+		Language: locale.LanguageTag(),
+	}); err != nil {
+		return fmt.Errorf("failed to send email change notice: %w", err)
+	}
+
+	return nil
+}
+
+// ========== 个人资料 ==========
+
+// phoneNumberPattern E.164格式手机号
+var phoneNumberPattern = regexp.MustCompile(`^\+[1-9][0-9]{7,14}$`)
+
+// GetProfile 获取用户个人资料
+func (s *Service) GetProfile(ctx context.Context, userID string) (*User, error) {
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		if err.Error() == "user not found" {
+			return nil, auth.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return user, nil
+}
+
+// GetUserLocale 获取用户的语言和时区（实现auth.UserLocaleProvider，供邮件等功能本地化）
+func (s *Service) GetUserLocale(ctx context.Context, userID string) (*auth.UserLocale, error) {
+	user, err := s.GetProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return user.Locale(), nil
+}
+
+// UpdateProfile 更新显示名称、首选语言和时区（只更新请求中提供的字段）
+func (s *Service) UpdateProfile(ctx context.Context, userID string, req *UpdateProfileRequest) (*User, error) {
+	if s.profileConfig == nil {
+		return nil, fmt.Errorf("profile editing is not configured")
+	}
+
+	user, err := s.GetProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if len([]rune(name)) < 2 {
+			return nil, auth.ErrNameTooShort
+		}
+		req.Name = &name
+	}
+	if req.Language != nil {
+		language, err := s.normalizeLanguage(*req.Language)
+		if err != nil {
+			return nil, err
+		}
+		req.Language = &language
+	}
+	if req.Timezone != nil {
+		if err := validateTimezone(*req.Timezone); err != nil {
+			return nil, err
+		}
+	}
+
+	req.ApplyUpdate(user)
+	if err := s.repo.UpdateProfile(ctx, user.ID, user.Name, user.Locale().Language, user.Locale().Timezone); err != nil {
+		if err.Error() == "user not found" {
+			return nil, auth.ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	s.logger.WithField("user_id", user.ID).Info("User profile updated")
+	return user, nil
+}
+
+// normalizeLanguage 校验语言是否在支持列表中（忽略大小写），返回配置中的写法
+func (s *Service) normalizeLanguage(language string) (string, error) {
+	for _, supported := range s.profileConfig.Languages {
+		if strings.EqualFold(supported, strings.TrimSpace(language)) {
+			return supported, nil
+		}
+	}
+	return "", fmt.Errorf("%w: supported languages are %s", auth.ErrInvalidLanguage, strings.Join(s.profileConfig.Languages, ", "))
+}
+
+// validateTimezone 校验IANA时区名称（不接受服务器本地时区）
+func validateTimezone(timezone string) error {
+	if timezone == "" || timezone == "Local" {
+		return auth.ErrInvalidTimezone
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return auth.ErrInvalidTimezone
+	}
+	return nil
+}
+
+// AvatarMaxSize 上传头像原图的大小上限（字节）
+func (s *Service) AvatarMaxSize() int64 {
+	if s.profileConfig == nil {
+		return 0
+	}
+	return s.profileConfig.AvatarMaxSize
+}
+
+// UploadAvatar 处理并保存用户头像，替换原有头像
+func (s *Service) UploadAvatar(ctx context.Context, userID string, content io.Reader) (*User, error) {
+	if s.profileConfig == nil {
+		return nil, fmt.Errorf("profile editing is not configured")
+	}
+
+	avatar, err := processAvatar(content, s.profileConfig.AvatarMaxSize, s.profileConfig.AvatarSize)
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := s.storeAvatar(userID, avatar)
+	if err != nil {
+		return nil, err
+	}
+
+	oldPath, err := s.repo.UpdateAvatar(ctx, userID, &path)
+	if err != nil {
+		os.Remove(path)
+		if err.Error() == "user not found" {
+			return nil, auth.ErrUserNotFound
+		}
+		return nil, err
+	}
+	s.removeAvatarFile(oldPath)
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":   userID,
+		"file_size": len(avatar),
+	}).Info("User avatar updated")
+
+	return s.GetProfile(ctx, userID)
+}
+
+// storeAvatar 将处理后的头像写入用户目录（随机文件名，替换头像时客户端缓存自然失效）
+func (s *Service) storeAvatar(userID string, avatar []byte) (string, error) {
+	dir := filepath.Join(s.profileConfig.AvatarDir, userID)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("failed to create avatar directory: %w", err)
+	}
+
+	path := filepath.Join(dir, uuid.New().String()+".jpg")
+	if err := os.WriteFile(path, avatar, 0o600); err != nil {
+		os.Remove(path)
+		return "", fmt.Errorf("failed to write avatar file: %w", err)
+	}
+	return path, nil
+}
+
+// removeAvatarFile 删除被替换的头像文件（文件已不存在时忽略）
+func (s *Service) removeAvatarFile(path *string) {
+	if path == nil {
+		return
+	}
+	if err := os.Remove(*path); err != nil && !os.IsNotExist(err) {
+		s.logger.WithError(err).WithField("path", *path).Warn("Failed to remove avatar file")
+	}
+}
+
+// GetAvatar 获取用户头像文件路径和内容类型
+func (s *Service) GetAvatar(ctx context.Context, userID string) (string, string, error) {
+	user, err := s.GetProfile(ctx, userID)
+	if err != nil {
+		return "", "", err
+	}
+	if user.AvatarPath == nil {
+		return "", "", auth.ErrAvatarNotFound
+	}
+	return *user.AvatarPath, avatarContentType, nil
+}
+
+// DeleteAvatar 删除用户头像
+func (s *Service) DeleteAvatar(ctx context.Context, userID string) error {
+	oldPath, err := s.repo.UpdateAvatar(ctx, userID, nil)
+	if err != nil {
+		if err.Error() == "user not found" {
+			return auth.ErrUserNotFound
+		}
+		return err
+	}
+	if oldPath == nil {
+		return auth.ErrAvatarNotFound
+	}
+	s.removeAvatarFile(oldPath)
+
+	s.logger.WithField("user_id", userID).Info("User avatar removed")
+	return nil
+}
+
+// normalizePhoneNumber 去掉空格、连字符和括号后校验E.164格式
+func normalizePhoneNumber(phoneNumber string) (string, error) {
+	normalized := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '(', ')', '.':
+			return -1
+		}
+		return r
+	}, phoneNumber)
+	if !phoneNumberPattern.MatchString(normalized) {
+		return "", auth.ErrInvalidPhoneNumber
+	}
+	return normalized, nil
+}
+
+// RequestPhoneVerification 绑定手机号第一步：向新手机号发送短信验证码
+// 返回验证码有效期
+func (s *Service) RequestPhoneVerification(ctx context.Context, userID, phoneNumber, ipAddress string) (time.Duration, error) {
+	if s.profileConfig == nil || s.smsSender == nil {
+		return 0, fmt.Errorf("phone verification is not configured")
+	}
+
+	phoneNumber, err := normalizePhoneNumber(phoneNumber)
+	if err != nil {
+		return 0, err
+	}
+
+	user, err := s.GetProfile(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	if user.PhoneNumber != nil && *user.PhoneNumber == phoneNumber {
+		return 0, auth.ErrPhoneNumberUnchanged
+	}
+
+	exists, err := s.repo.ExistsByPhoneNumber(ctx, phoneNumber, user.ID)
+	if err != nil {
+		return 0, err
+	}
+	if exists {
+		return 0, auth.ErrPhoneNumberInUse
+	}
+
+	// 频率限制：同一手机号10分钟内最多3次，同一用户每小时合计不超过配置的次数（防止借接口向任意号码刷短信）
+	allowed, err := s.verifyRepo.CheckRateLimit(ctx, phoneNumber, "user", "phone_verification", 10*time.Minute, 3)
+	if err != nil {
+		return 0, fmt.Errorf("failed to check rate limit: %w", err)
+	}
+	if allowed {
+		allowed, err = s.verifyRepo.CheckReferenceRateLimit(ctx, user.ID, "user", "phone_verification", time.Hour, s.profileConfig.PhoneCodeLimit)
+		if err != nil {
+			return 0, fmt.Errorf("failed to check rate limit: %w", err)
+		}
+	}
+	if !allowed {
+		return 0, auth.ErrTooManyAttempts
+	}
+
+	code, err := s.generateVerificationCode()
+	if err != nil {
+		return 0, fmt.Errorf("failed to generate verification code: %w", err)
+	}
+
+	// 手机验证码与邮件验证码共用验证记录表，手机号保存在email字段
+	verification := &EmailVerification{
+		Email:            phoneNumber,
+		UserType:         "user",
+		Type:             "phone_verification",
+		VerificationCode: code,
+		Attempts:         0,
+		MaxAttempts:      3,
+		Verified:         false,
+		IPAddress:        &ipAddress,
+		ReferenceID:      &user.ID,
+		ExpiresAt:        time.Now().Add(s.profileConfig.PhoneCodeTTL),
+	}
+
+	if err := s.verifyRepo.CreateVerification(ctx, verification); err != nil {
+		return 0, fmt.Errorf("failed to create verification: %w", err)
+	}
+
+	message := fmt.Sprintf("Your Trusioo verification code is %s. It expires in %d minutes. Never share this code with anyone.",
+		code, int(s.profileConfig.PhoneCodeTTL.Minutes()))
+	if err := s.smsSender.Send(ctx, &sms.Message{To: phoneNumber, Body: message}); err != nil {
+		return 0, fmt.Errorf("failed to send phone verification code: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id": user.ID,
+		"type":    "phone_verification",
+	}).Info("Phone verification code sent")
+
+	return s.profileConfig.PhoneCodeTTL, nil
+}
+
+// ConfirmPhoneVerification 绑定手机号第二步：校验短信验证码并绑定手机号
+func (s *Service) ConfirmPhoneVerification(ctx context.Context, userID, phoneNumber, code string) (*User, error) {
+	phoneNumber, err := normalizePhoneNumber(phoneNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	verification, err := s.verifyRepo.GetActiveVerification(ctx, phoneNumber, "user", "phone_verification")
+	if err != nil {
+		return nil, auth.ErrInvalidVerificationCode
+	}
+
+	// 验证码必须是当前用户发起的
+	if verification.ReferenceID == nil || *verification.ReferenceID != userID {
+		return nil, auth.ErrInvalidVerificationCode
+	}
+
+	if !verification.CanAttempt() {
+		return nil, auth.ErrTooManyAttempts
+	}
+
+	if !s.verifyRepo.MatchCode(verification, code) {
+		if err := s.verifyRepo.IncrementAttempts(ctx, verification.ID); err != nil {
+			s.logger.WithError(err).Error("Failed to increment attempts")
+		}
+		return nil, auth.ErrInvalidVerificationCode
+	}
+
+	if err := s.verifyRepo.MarkAsVerified(ctx, verification.ID); err != nil {
+		if err.Error() == "verification not found" {
+			return nil, auth.ErrInvalidVerificationCode
+		}
+		return nil, fmt.Errorf("failed to mark verification as used: %w", err)
+	}
+
+	if err := s.repo.UpdatePhoneNumber(ctx, userID, &phoneNumber); err != nil {
+		switch err.Error() {
+		case "phone number already in use":
+			return nil, auth.ErrPhoneNumberInUse
+		case "user not found":
+			return nil, auth.ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	s.logger.WithField("user_id", userID).Info("User phone number verified")
+	return s.GetProfile(ctx, userID)
+}
+
+// RemovePhoneNumber 解绑手机号
+func (s *Service) RemovePhoneNumber(ctx context.Context, userID string) error {
+	user, err := s.GetProfile(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.PhoneNumber == nil {
+		return auth.ErrPhoneNumberNotSet
+	}
+
+	if err := s.repo.UpdatePhoneNumber(ctx, userID, nil); err != nil {
+		if err.Error() == "user not found" {
+			return auth.ErrUserNotFound
+		}
+		return err
+	}
+
+	s.logger.WithField("user_id", userID).Info("User phone number removed")
+	return nil
+}
+
+// ========== 陌生登录提醒 ==========
+
+// NotifyUnrecognizedSignIn 发送新设备/新位置登录提醒，附带“不是我本人”链接
+func (s *Service) NotifyUnrecognizedSignIn(ctx context.Context, user *User, sessionID, ipAddress string, deviceInfo, locationInfo *map[string]interface{}, recognition *auth.SignInRecognition) error {
+	if s.alertNotifier == nil {
+		return nil
+	}
+
+	token, tokenHash, err := s.generateLoginAlertToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate login alert token: %w", err)
+	}
+
+	alert := &LoginAlert{
+		UserID:       user.ID,
+		SessionID:    &sessionID,
+		TokenHash:    tokenHash,
+		IPAddress:    ipAddress,
+		DeviceInfo:   deviceInfo,
+		LocationInfo: locationInfo,
+		NewDevice:    recognition.NewDevice,
+		NewLocation:  recognition.NewLocation,
+		ExpiresAt:    time.Now().Add(s.alertConfig.LoginAlertTTL),
+	}
+
+	if err := s.repo.CreateLoginAlert(ctx, alert); err != nil {
+		return err
+	}
+
+	event := &auth.SecurityEvent{
+		Type:         auth.SecurityEventNewSignIn,
+		UserID:       user.ID,
+		UserType:     "user",
+		Email:        user.Email,
+		IPAddress:    &ipAddress,
+		DeviceInfo:   deviceInfo,
+		LocationInfo: locationInfo,
+		NewDevice:    recognition.NewDevice,
+		NewLocation:  recognition.NewLocation,
+		ReportURL:    s.alertConfig.LoginAlertURL + "?token=" + url.QueryEscape(token),
+		Locale:       user.Locale(),
+		OccurredAt:   alert.CreatedAt,
+	}
+
+	if err := s.alertNotifier.NotifySecurityEvent(ctx, event); err != nil {
+		return err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":      user.ID,
+		"alert_id":     alert.ID,
+		"new_device":   alert.NewDevice,
+		"new_location": alert.NewLocation,
+	}).Info("Unrecognized sign-in alert sent")
+
+	return nil
+}
+
+// GetPendingLoginAlert 根据“不是我本人”链接令牌获取登录提醒
+func (s *Service) GetPendingLoginAlert(ctx context.Context, token string) (*LoginAlert, error) {
+	alert, err := s.repo.GetPendingLoginAlertByTokenHash(ctx, s.hashLoginAlertToken(token))
+	if err != nil {
+		if err.Error() == "login alert not found" {
+			return nil, auth.ErrLoginAlertNotFound
+		}
+		return nil, err
+	}
+	return alert, nil
+}
+
+// ReportUnrecognizedSignIn 用户确认登录不是本人：结束所有会话、要求重置密码并发送重置验证码
+// 调用方需先撤销用户的全部令牌
+func (s *Service) ReportUnrecognizedSignIn(ctx context.Context, alert *LoginAlert, ipAddress string) error {
+	if err := s.repo.ReportLoginAlert(ctx, alert.ID, alert.UserID); err != nil {
+		if err.Error() == "login alert not found" {
+			return auth.ErrLoginAlertNotFound
+		}
+		return err
+	}
+
+	if err := s.LogoutAllSessions(ctx, alert.UserID); err != nil {
+		return err
+	}
+
+	user, err := s.repo.GetByID(ctx, alert.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	// 发送密码重置验证码（失败不影响举报结果，用户仍可通过忘记密码重新获取）
+	if err := s.ForgotPassword(ctx, user.Email, ipAddress); err != nil {
+		s.logger.WithError(err).WithField("user_id", user.ID).Warn("Failed to send password reset code after unrecognized sign-in report")
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":    user.ID,
+		"alert_id":   alert.ID,
+		"session_id": alert.SessionID,
+	}).Warn("User reported unrecognized sign-in, all sessions revoked")
+
+	return nil
+}
+
+// generateLoginAlertToken 生成“不是我本人”链接令牌，返回明文和带密钥哈希
+func (s *Service) generateLoginAlertToken() (string, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(buf)
+	return token, s.hashLoginAlertToken(token), nil
+}
+
+// hashLoginAlertToken 计算登录提醒令牌的带密钥哈希
+// 对SHA-256摘要再做HMAC，升级前只保存了摘要的记录可以在启动时改写为同一格式
+func (s *Service) hashLoginAlertToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return s.verifyRepo.HashToken(hex.EncodeToString(sum[:]))
+}
+
+// ForgotPassword 忘记密码，发送密码重置验证码
+func (s *Service) ForgotPassword(ctx context.Context, email, ipAddress string) error {
+	// 验证邮箱是否存在
+	user, err := s.repo.GetByEmail(ctx, email)
+	if err != nil {
+		return auth.ErrUserNotFound
+	}
+
+	// 检查频率限制（5分钟内最多3次）
+	allowed, err := s.verifyRepo.CheckRateLimit(ctx, email, "user", "password_reset", 5*time.Minute, 3)
+	if err != nil {
+		return fmt.Errorf("failed to check rate limit: %w", err)
+	}
+	if !allowed {
+		return auth.ErrTooManyAttempts
+	}
+
+	// 生成6位数字验证码
+	code, err := s.generateVerificationCode()
+	if err != nil {
+		return fmt.Errorf("failed to generate verification code: %w", err)
+	}
+
+	// 创建验证记录
+	verification := &EmailVerification{
+		Email:            email,
+		UserType:         "user",
+		Type:             "password_reset",
+		VerificationCode: code,
+		Attempts:         0,
+		MaxAttempts:      3,
+		Verified:         false,
+		IPAddress:        &ipAddress,
+		ExpiresAt:        time.Now().Add(15 * time.Minute), // 15分钟过期
+	}
+
+	if err := s.verifyRepo.CreateVerification(ctx, verification); err != nil {
+		return fmt.Errorf("failed to create verification: %w", err)
+	}
+
+	if err := auth.SendLocalizedVerificationCodeEmail(ctx, s.mailer, email, verification.Type, code, verification.ExpiresAt, user.Locale()); err != nil {
+		return err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"email": email,
+		"type":  "user_password_reset",
+	}).Info("User password reset verification code sent")
+
+	return nil
+}
+
+// ResetPassword 重置密码
+func (s *Service) ResetPassword(ctx context.Context, email, code, newPassword, ipAddress string) error {
+	// 验证邮箱是否存在
+	user, err := s.repo.GetByEmail(ctx, email)
+	if err != nil {
+		return auth.ErrUserNotFound
+	}
+
+	// 获取活跃的验证记录
+	verification, err := s.verifyRepo.GetActiveVerification(ctx, email, "user", "password_reset")
+	if err != nil {
+		return auth.ErrInvalidVerificationCode
+	}
+
+	// 检查验证码是否匹配
+	if !s.verifyRepo.MatchCode(verification, code) {
+		// 增加尝试次数
+		if err := s.verifyRepo.IncrementAttempts(ctx, verification.ID); err != nil {
+			s.logger.WithError(err).Error("Failed to increment attempts")
+		}
+		return auth.ErrInvalidVerificationCode
+	}
+
+	// 检查是否可以继续尝试
+	if !verification.CanAttempt() {
+		return auth.ErrTooManyAttempts
+	}
+
+	// 检查密码策略（在消耗验证码之前，以便用户换一个密码重试）
+	if err := s.validateNewPassword(ctx, user, newPassword); err != nil {
+		return err
+	}
+
+	// 标记验证码为已使用
+	if err := s.verifyRepo.MarkAsVerified(ctx, verification.ID); err != nil {
+		return fmt.Errorf("failed to mark verification as used: %w", err)
+	}
+
+	// 加密新密码
+	hashedPassword, err := s.encryptor.HashPassword(newPassword)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	// 更新数据库中的密码
+	if err := s.repo.UpdatePassword(ctx, user.ID, hashedPassword); err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+	s.recordPasswordHistory(ctx, user.ID, hashedPassword)
+
+	// 记录密码重置操作到password_resets表
+	if err := s.repo.CreatePasswordReset(ctx, &PasswordReset{
+		Email:     email,
+		UserType:  "user",
+		Token:     s.verifyRepo.HashToken(verification.ID), // 使用验证ID的哈希作为token
+		Used:      true,
+		UsedAt:    verification.VerifiedAt,
+		ExpiresAt: verification.ExpiresAt,
+	}); err != nil {
+		s.logger.WithError(err).Warn("Failed to record password reset")
+		// 不返回错误，因为密码已经重置成功
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id": user.ID,
+		"email":   user.Email,
+	}).Info("User password reset successfully")
+
+	return nil
+}
+
+// validateNewPassword 按密码策略检查用户的新密码（包括历史密码）
+func (s *Service) validateNewPassword(ctx context.Context, user *User, password string) error {
+	return s.passwordPolicy.Validate(ctx, &auth.PasswordSubject{
+		UserID:              user.ID,
+		UserType:            "user",
+		Email:               user.Email,
+		Name:                user.Name,
+		CurrentPasswordHash: user.Password,
+	}, password)
+}
+
+// recordPasswordHistory 记录密码历史，失败时只记录日志（密码已经修改成功）
+func (s *Service) recordPasswordHistory(ctx context.Context, userID, hashedPassword string) {
+	if err := s.passwordPolicy.RecordPassword(ctx, userID, "user", hashedPassword); err != nil {
+		s.logger.WithError(err).WithField("user_id", userID).Warn("Failed to record password history")
+	}
+}
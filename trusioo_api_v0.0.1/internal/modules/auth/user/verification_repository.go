@@ -7,8 +7,10 @@ import (
 	"time"
 
 	"trusioo_api_v0.0.1/internal/infrastructure/database"
+	"trusioo_api_v0.0.1/pkg/cryptoutil"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/sirupsen/logrus"
 )
 
@@ -30,24 +32,47 @@ type EmailVerification struct {
 	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
 }
 
+// secretHashLength 凭据哈希（十六进制HMAC-SHA256）的长度，用于识别尚未迁移的明文记录
+const secretHashLength = 64
+
+const (
+	// legacyDigestPrefix 迁移时加在升级前不带密钥的SHA-256令牌摘要前的标记
+	legacyDigestPrefix = "sha256:"
+	// legacyDigestOffset 去掉标记后摘要在字段中的起始位置（SQL substr从1开始）
+	legacyDigestOffset = "8"
+)
+
 // VerificationRepository 验证码仓储
+// 验证码和令牌只以带密钥哈希的形式入库，明文仅存在于发给用户的邮件中
 type VerificationRepository struct {
 	*database.BaseRepository
+	hasher *cryptoutil.SecretHasher
 	logger *logrus.Logger
 }
 
 // NewVerificationRepository 创建新的验证码仓储
-func NewVerificationRepository(db *database.Database, logger *logrus.Logger) *VerificationRepository {
+func NewVerificationRepository(db *database.Database, hasher *cryptoutil.SecretHasher, logger *logrus.Logger) *VerificationRepository {
 	return &VerificationRepository{
 		BaseRepository: database.NewBaseRepository(db, logger),
+		hasher:         hasher,
 		logger:         logger,
 	}
 }
 
-// CreateVerification 创建验证码记录
+// CreateVerification 创建验证码记录（入库前对验证码和令牌做哈希，调用方持有的明文不变）
 func (r *VerificationRepository) CreateVerification(ctx context.Context, verification *EmailVerification) error {
 	// 生成UUID
 	verification.ID = uuid.New().String()
+
+	var codeHash string
+	if verification.VerificationCode != "" {
+		codeHash = r.hasher.Hash(verification.VerificationCode)
+	}
+	var tokenHash *string
+	if verification.Token != nil {
+		hashed := r.hasher.Hash(*verification.Token)
+		tokenHash = &hashed
+	}
 	
 	query := `
 		INSERT INTO email_verifications (
@@ -59,7 +84,7 @@ func (r *VerificationRepository) CreateVerification(ctx context.Context, verific
 
 	_, err := r.GetDB().ExecContext(ctx, query,
 		verification.ID, verification.Email, verification.UserType, verification.Type,
-		verification.VerificationCode, verification.Token, verification.Attempts,
+		codeHash, tokenHash, verification.Attempts,
 		verification.MaxAttempts, verification.Verified, verification.IPAddress,
 		verification.ReferenceID, verification.ExpiresAt)
 
@@ -126,12 +151,12 @@ func (r *VerificationRepository) IncrementAttempts(ctx context.Context, verifica
 	return nil
 }
 
-// MarkAsVerified 标记为已验证
+// MarkAsVerified 标记为已验证（已使用过的记录不会再次标记成功，保证一次性）
 func (r *VerificationRepository) MarkAsVerified(ctx context.Context, verificationID string) error {
 	query := `
 		UPDATE email_verifications
 		SET verified = true, verified_at = NOW()
-		WHERE id = $1
+		WHERE id = $1 AND verified = false
 	`
 
 	result, err := r.GetDB().ExecContext(ctx, query, verificationID)
@@ -181,6 +206,162 @@ func (r *VerificationRepository) CheckRateLimit(ctx context.Context, email, user
 	return count < maxCount, nil
 }
 
+// CheckCombinedRateLimit 检查多种验证类型合计的频率限制（例如登录验证码和登录链接共用额度）
+func (r *VerificationRepository) CheckCombinedRateLimit(ctx context.Context, email, userType string, verificationTypes []string, within time.Duration, maxCount int) (bool, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM email_verifications
+		WHERE email = $1 AND user_type = $2 AND type = ANY($3)
+		  AND created_at > NOW() - INTERVAL '%d seconds'
+	`
+
+	var count int
+	err := r.GetDB().QueryRowContext(ctx, fmt.Sprintf(query, int(within.Seconds())), email, userType, pq.Array(verificationTypes)).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to check rate limit: %w", err)
+	}
+
+	return count < maxCount, nil
+}
+
+// CheckReferenceRateLimit 按关联用户检查频率限制（例如同一用户向不同手机号发送验证码的合计次数）
+func (r *VerificationRepository) CheckReferenceRateLimit(ctx context.Context, referenceID, userType, verificationType string, within time.Duration, maxCount int) (bool, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM email_verifications
+		WHERE reference_id = $1 AND user_type = $2 AND type = $3
+		  AND created_at > NOW() - INTERVAL '%d seconds'
+	`
+
+	var count int
+	err := r.GetDB().QueryRowContext(ctx, fmt.Sprintf(query, int(within.Seconds())), referenceID, userType, verificationType).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to check rate limit: %w", err)
+	}
+
+	return count < maxCount, nil
+}
+
+// GetActiveVerificationByToken 根据令牌获取有效的验证记录
+func (r *VerificationRepository) GetActiveVerificationByToken(ctx context.Context, token, userType, verificationType string) (*EmailVerification, error) {
+	query := `
+		SELECT id, email, user_type, type, verification_code, token,
+			   attempts, max_attempts, verified, ip_address, reference_id,
+			   expires_at, verified_at, created_at
+		FROM email_verifications
+		WHERE token = $1 AND user_type = $2 AND type = $3
+		  AND verified = false AND expires_at > NOW()
+	`
+
+	verification := &EmailVerification{}
+	err := r.GetDB().QueryRowContext(ctx, query, r.hasher.Hash(token), userType, verificationType).Scan(
+		&verification.ID, &verification.Email, &verification.UserType, &verification.Type,
+		&verification.VerificationCode, &verification.Token, &verification.Attempts,
+		&verification.MaxAttempts, &verification.Verified, &verification.IPAddress,
+		&verification.ReferenceID, &verification.ExpiresAt, &verification.VerifiedAt,
+		&verification.CreatedAt)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("no active verification found")
+		}
+		return nil, fmt.Errorf("failed to get verification: %w", err)
+	}
+
+	return verification, nil
+}
+
+// MatchCode 以常量时间比较用户提交的验证码与记录中的哈希
+func (r *VerificationRepository) MatchCode(verification *EmailVerification, code string) bool {
+	if verification.VerificationCode == "" || code == "" {
+		return false
+	}
+	return r.hasher.Equal(code, verification.VerificationCode)
+}
+
+// HashToken 计算需要落库的一次性令牌哈希（例如密码重置记录）
+func (r *VerificationRepository) HashToken(token string) string {
+	return r.hasher.Hash(token)
+}
+
+// HashPlaintextSecrets 将升级前以明文保存的验证码和密码重置令牌，以及只保存了SHA-256摘要的
+// 登录提醒和管理员邀请令牌改写为带密钥哈希
+// 哈希依赖服务端密钥，无法在SQL迁移中完成，因此在启动时执行；已迁移的记录不会重复处理
+func (r *VerificationRepository) HashPlaintextSecrets(ctx context.Context) (int, error) {
+	codes, err := r.hashPlaintextColumn(ctx,
+		`SELECT id, verification_code FROM email_verifications
+		 WHERE verification_code <> '' AND length(verification_code) <> $1`,
+		`UPDATE email_verifications SET verification_code = $1 WHERE id = $2 AND verification_code = $3`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to hash verification codes: %w", err)
+	}
+
+	tokens, err := r.hashPlaintextColumn(ctx,
+		`SELECT id, token FROM password_resets WHERE length(token) <> $1`,
+		`UPDATE password_resets SET token = $1 WHERE id = $2 AND token = $3`)
+	if err != nil {
+		return codes, fmt.Errorf("failed to hash password reset tokens: %w", err)
+	}
+
+	// 迁移时给旧摘要加上了legacyDigestPrefix前缀，改写为对摘要的HMAC
+	alerts, err := r.hashPlaintextColumn(ctx,
+		`SELECT id, substr(token_hash, `+legacyDigestOffset+`) FROM login_alerts
+		 WHERE token_hash LIKE '`+legacyDigestPrefix+`%' AND length(token_hash) <> $1`,
+		`UPDATE login_alerts SET token_hash = $1 WHERE id = $2 AND token_hash = '`+legacyDigestPrefix+`' || $3`)
+	if err != nil {
+		return codes + tokens, fmt.Errorf("failed to hash login alert tokens: %w", err)
+	}
+
+	invitations, err := r.hashPlaintextColumn(ctx,
+		`SELECT id, substr(token_hash, `+legacyDigestOffset+`) FROM admin_invitations
+		 WHERE token_hash LIKE '`+legacyDigestPrefix+`%' AND length(token_hash) <> $1`,
+		`UPDATE admin_invitations SET token_hash = $1 WHERE id = $2 AND token_hash = '`+legacyDigestPrefix+`' || $3`)
+	if err != nil {
+		return codes + tokens + alerts, fmt.Errorf("failed to hash admin invitation tokens: %w", err)
+	}
+
+	return codes + tokens + alerts + invitations, nil
+}
+
+// hashPlaintextColumn 在同一事务中读取明文记录并逐行改写为哈希
+func (r *VerificationRepository) hashPlaintextColumn(ctx context.Context, selectQuery, updateQuery string) (int, error) {
+	updated := 0
+	err := r.GetDB().Transaction(func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, selectQuery, secretHashLength)
+		if err != nil {
+			return err
+		}
+
+		plaintext := make(map[string]string)
+		for rows.Next() {
+			var id, value string
+			if err := rows.Scan(&id, &value); err != nil {
+				rows.Close()
+				return err
+			}
+			plaintext[id] = value
+		}
+		if err := rows.Err(); err != nil {
+			rows.Close()
+			return err
+		}
+		rows.Close()
+
+		for id, value := range plaintext {
+			if _, err := tx.ExecContext(ctx, updateQuery, r.hasher.Hash(value), id, value); err != nil {
+				return err
+			}
+			updated++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return updated, nil
+}
+
 // IsExceedingAttempts 检查是否超过最大尝试次数
 func (v *EmailVerification) IsExceedingAttempts() bool {
 	return v.Attempts >= v.MaxAttempts
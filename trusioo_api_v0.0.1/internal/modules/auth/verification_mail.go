@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"trusioo_api_v0.0.1/internal/infrastructure/mailer"
+)
+
+// SendVerificationCodeEmail 通过邮件发送验证码（验证码只以哈希形式入库，邮件是唯一的明文出口）
+func SendVerificationCodeEmail(ctx context.Context, m mailer.Mailer, email, verificationType, code string, expiresAt time.Time) error {
+	return SendLocalizedVerificationCodeEmail(ctx, m, email, verificationType, code, expiresAt, nil)
+}
+
+// SendLocalizedVerificationCodeEmail 通过邮件发送验证码，过期时间按收件人时区显示（locale为空时使用UTC）
+func SendLocalizedVerificationCodeEmail(ctx context.Context, m mailer.Mailer, email, verificationType, code string, expiresAt time.Time, locale *UserLocale) error {
+	var subject string
+	var body strings.Builder
+
+	switch verificationType {
+	case "login_code":
+		subject = "Your Trusioo sign-in code"
+		body.WriteString("Use the code below to finish signing in to your account.\n\n")
+	case "login_challenge":
+		subject = "Confirm your Trusioo sign-in"
+		body.WriteString("We noticed a sign-in attempt that looks different from your usual activity.\n")
+		body.WriteString("Use the code below to confirm it was you.\n\n")
+	case "password_reset":
+		subject = "Your Trusioo password reset code"
+		body.WriteString("Use the code below to reset your password.\n\n")
+	case "email_change":
+		subject = "Confirm your new Trusioo email address"
+		body.WriteString("Use the code below to confirm this address as the new email of your account.\n\n")
+	default:
+		subject = "Your Trusioo verification code"
+		body.WriteString("Use the code below to verify your request.\n\n")
+	}
+
+	body.WriteString("Verification code: " + code + "\n\n")
+	body.WriteString("The code can be used once and expires at " + locale.FormatTime(expiresAt) + ".\n")
+	body.WriteString("If you did not request this code, you can ignore this email. Never share it with anyone.\n")
+
+	if err := m.Send(ctx, &mailer.Message{
+		To:       []string{email},
+		Subject:  subject,
+		Body:     body.String(),
+		Language: locale.LanguageTag(),
+	}); err != nil {
+		return fmt.Errorf("failed to send verification code email: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,189 @@
+package kyc
+
+import (
+	"strings"
+	"time"
+
+	"trusioo_api_v0.0.1/internal/modules/auth"
+)
+
+// ========== 请求DTO ==========
+
+// CreateSubmissionRequest 提交KYC认证申请请求（证件文件需先通过上传接口上传）
+type CreateSubmissionRequest struct {
+	RequestedTier  int          `json:"requested_tier" binding:"required,oneof=1 2" example:"1"`
+	FirstName      string       `json:"first_name" binding:"required,max=100" example:"John"`
+	LastName       string       `json:"last_name" binding:"required,max=100" example:"Doe"`
+	DateOfBirth    string       `json:"date_of_birth" binding:"required,datetime=2006-01-02" example:"1990-01-31"`
+	Nationality    string       `json:"nationality" binding:"required,len=2,alpha" example:"NG"`
+	DocumentType   DocumentType `json:"document_type" binding:"required,oneof=passport national_id driving_licence" example:"passport"`
+	DocumentNumber string       `json:"document_number" binding:"required,max=64" example:"A12345678"`
+	AddressLine1   *string      `json:"address_line1" binding:"omitempty,max=255" example:"1 Marina Road"` // 2级认证必填
+	AddressLine2   *string      `json:"address_line2" binding:"omitempty,max=255"`
+	City           *string      `json:"city" binding:"omitempty,max=100" example:"Lagos"` // 2级认证必填
+	PostalCode     *string      `json:"postal_code" binding:"omitempty,max=20" example:"101001"`
+	Country        *string      `json:"country" binding:"omitempty,len=2,alpha" example:"NG"` // 2级认证必填
+	DocumentIDs    []string     `json:"document_ids" binding:"required,min=1,max=10,dive,uuid"`
+}
+
+// ListSubmissionsRequest 管理员查询KYC认证申请请求
+type ListSubmissionsRequest struct {
+	Page     int    `form:"page" binding:"omitempty,min=1" example:"1"`
+	PageSize int    `form:"page_size" binding:"omitempty,min=1,max=100" example:"20"`
+	Status   string `form:"status" binding:"omitempty,oneof=pending approved rejected" example:"pending"`
+	UserID   string `form:"user_id" binding:"omitempty,uuid" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Tier     int    `form:"tier" binding:"omitempty,oneof=1 2" example:"2"` // 申请等级
+}
+
+// ToFilter 转换为查询条件
+func (r *ListSubmissionsRequest) ToFilter() *SubmissionFilter {
+	filter := &SubmissionFilter{}
+	if r.Status != "" {
+		status := SubmissionStatus(r.Status)
+		filter.Status = &status
+	}
+	if r.UserID != "" {
+		filter.UserID = &r.UserID
+	}
+	if r.Tier != 0 {
+		filter.Tier = &r.Tier
+	}
+	return filter
+}
+
+// ApproveSubmissionRequest 管理员通过KYC认证申请请求
+type ApproveSubmissionRequest struct {
+	Tier  *int    `json:"tier" binding:"omitempty,oneof=1 2" example:"1"` // 认可的等级，不填时按申请等级，不能高于申请等级
+	Notes *string `json:"notes" binding:"omitempty,max=1000" example:"Document checked against selfie"`
+}
+
+// RejectSubmissionRequest 管理员拒绝KYC认证申请请求
+type RejectSubmissionRequest struct {
+	Reason string  `json:"reason" binding:"required,max=500" example:"The document photo is blurred, please upload a clearer picture"` // 会发送给用户
+	Notes  *string `json:"notes" binding:"omitempty,max=1000"`
+}
+
+// SetUserTierRequest 管理员直接调整用户KYC等级请求（例如发现欺诈后降级）
+type SetUserTierRequest struct {
+	Tier   *int   `json:"tier" binding:"required,min=0,max=2" example:"0"`
+	Reason string `json:"reason" binding:"required,max=500" example:"Document reported as stolen"`
+}
+
+// ========== 响应DTO ==========
+
+// DocumentResponse 证件文件响应
+type DocumentResponse struct {
+	ID          string       `json:"id"`
+	Kind        DocumentKind `json:"kind" example:"id_front"`
+	ContentType string       `json:"content_type" example:"image/jpeg"`
+	FileSize    int64        `json:"file_size" example:"524288"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
+
+// SubmissionResponse 用户查看的KYC认证申请（证件号码脱敏）
+type SubmissionResponse struct {
+	ID              string             `json:"id"`
+	RequestedTier   int                `json:"requested_tier" example:"1"`
+	ApprovedTier    *int               `json:"approved_tier,omitempty" example:"1"`
+	Status          SubmissionStatus   `json:"status" example:"pending"`
+	FirstName       string             `json:"first_name" example:"John"`
+	LastName        string             `json:"last_name" example:"Doe"`
+	DocumentType    DocumentType       `json:"document_type" example:"passport"`
+	DocumentNumber  string             `json:"document_number" example:"*****5678"`
+	RejectionReason *string            `json:"rejection_reason,omitempty"`
+	ReviewedAt      *time.Time         `json:"reviewed_at,omitempty"`
+	CreatedAt       time.Time          `json:"created_at"`
+	Documents       []DocumentResponse `json:"documents,omitempty"`
+}
+
+// SubmissionListResponse 用户的KYC认证申请列表响应
+type SubmissionListResponse struct {
+	Submissions []SubmissionResponse `json:"submissions"`
+}
+
+// DocumentRequirement 认证等级要求的证件文件
+type DocumentRequirement struct {
+	Kind        DocumentKind `json:"kind" example:"id_front"`
+	Description string       `json:"description" example:"Front of your passport, national ID card or driving licence"`
+}
+
+// TierRequirements 认证等级的要求和限额
+type TierRequirements struct {
+	Tier      int                   `json:"tier" example:"1"`
+	Limits    auth.KYCLimits        `json:"limits"`
+	Fields    []string              `json:"fields"`
+	Documents []DocumentRequirement `json:"documents"`
+}
+
+// StatusResponse 用户KYC认证状态响应
+type StatusResponse struct {
+	Tier              int                 `json:"tier" example:"0"`
+	VerifiedAt        *time.Time          `json:"verified_at,omitempty"`
+	Limits            auth.KYCLimits      `json:"limits"`
+	PendingSubmission *SubmissionResponse `json:"pending_submission,omitempty"`
+	UploadedDocuments []DocumentResponse  `json:"uploaded_documents"`  // 已上传但尚未提交审核的证件文件
+	NextTier          *TierRequirements   `json:"next_tier,omitempty"` // 已是最高等级时为空
+}
+
+// AdminSubmissionListResponse 管理员查看的KYC认证申请列表响应
+type AdminSubmissionListResponse struct {
+	Submissions []*Submission `json:"submissions"`
+	Total       int64         `json:"total" example:"12"`
+	Page        int           `json:"page" example:"1"`
+	PageSize    int           `json:"page_size" example:"20"`
+	TotalPages  int           `json:"total_pages" example:"1"`
+	HasNext     bool          `json:"has_next" example:"false"`
+	HasPrev     bool          `json:"has_prev" example:"false"`
+}
+
+// AdminSubmissionDetailResponse 管理员查看的KYC认证申请详情响应
+type AdminSubmissionDetailResponse struct {
+	Submission *Submission `json:"submission"`
+	UserKYC    *UserKYC    `json:"user_kyc"` // 用户当前等级
+}
+
+// UserKYCResponse 管理员调整等级后的用户KYC响应
+type UserKYCResponse struct {
+	UserKYC *UserKYC       `json:"user_kyc"`
+	Limits  auth.KYCLimits `json:"limits"`
+}
+
+// ToResponse 转换为响应格式
+func (d *Document) ToResponse() DocumentResponse {
+	return DocumentResponse{
+		ID:          d.ID,
+		Kind:        d.Kind,
+		ContentType: d.ContentType,
+		FileSize:    d.FileSize,
+		CreatedAt:   d.CreatedAt,
+	}
+}
+
+// ToResponse 转换为用户可见的响应格式
+func (s *Submission) ToResponse() SubmissionResponse {
+	resp := SubmissionResponse{
+		ID:              s.ID,
+		RequestedTier:   s.RequestedTier,
+		ApprovedTier:    s.ApprovedTier,
+		Status:          s.Status,
+		FirstName:       s.FirstName,
+		LastName:        s.LastName,
+		DocumentType:    s.DocumentType,
+		DocumentNumber:  maskDocumentNumber(s.DocumentNumber),
+		RejectionReason: s.RejectionReason,
+		ReviewedAt:      s.ReviewedAt,
+		CreatedAt:       s.CreatedAt,
+	}
+	for _, doc := range s.Documents {
+		resp.Documents = append(resp.Documents, doc.ToResponse())
+	}
+	return resp
+}
+
+// maskDocumentNumber 脱敏证件号码，仅保留后4位
+func maskDocumentNumber(number string) string {
+	if len(number) <= 4 {
+		return strings.Repeat("*", len(number))
+	}
+	return strings.Repeat("*", len(number)-4) + number[len(number)-4:]
+}
@@ -0,0 +1,435 @@
+package kyc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"trusioo_api_v0.0.1/internal/modules/auth"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// uploadOverhead 上传请求中除文件外的multipart开销上限
+const uploadOverhead = 1 << 20
+
+// Handler KYC身份认证处理器
+type Handler struct {
+	service *Service
+	logger  *logrus.Logger
+}
+
+// NewHandler 创建新的KYC身份认证处理器
+func NewHandler(service *Service, logger *logrus.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// ========== 用户接口 ==========
+
+// GetStatus 获取当前用户的KYC等级、限额和下一等级的要求
+func (h *Handler) GetStatus(c *gin.Context) {
+	claims, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	status, err := h.service.GetStatus(ctx, claims.UserID)
+	if err != nil {
+		h.respondError(c, err, "Failed to get verification status")
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// UploadDocument 上传证件文件（multipart表单：kind和file）
+func (h *Handler) UploadDocument(c *gin.Context) {
+	claims, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.service.MaxDocumentSize()+uploadOverhead)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			h.respondError(c, auth.ErrKYCDocumentTooLarge, "Failed to upload document")
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": "A document file is required in the \"file\" field",
+		})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		h.respondError(c, err, "Failed to upload document")
+		return
+	}
+	defer file.Close()
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	doc, err := h.service.UploadDocument(ctx, claims.UserID, DocumentKind(c.PostForm("kind")), file, fileHeader.Size)
+	if err != nil {
+		h.respondError(c, err, "Failed to upload document")
+		return
+	}
+
+	c.JSON(http.StatusCreated, doc.ToResponse())
+}
+
+// DeleteDocument 删除尚未提交审核的证件文件
+func (h *Handler) DeleteDocument(c *gin.Context) {
+	claims, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	documentID, ok := h.idParam(c, "document_id", auth.ErrKYCDocumentNotFound, "Failed to delete document")
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	if err := h.service.DeleteDocument(ctx, claims.UserID, documentID); err != nil {
+		h.respondError(c, err, "Failed to delete document")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Document deleted",
+	})
+}
+
+// CreateSubmission 提交KYC认证申请
+func (h *Handler) CreateSubmission(c *gin.Context) {
+	claims, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	var req CreateSubmissionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid kyc submission request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	sub, err := h.service.CreateSubmission(ctx, claims.UserID, c.ClientIP(), &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to submit verification")
+		return
+	}
+
+	c.JSON(http.StatusCreated, sub.ToResponse())
+}
+
+// ListSubmissions 获取当前用户的KYC认证申请
+func (h *Handler) ListSubmissions(c *gin.Context) {
+	claims, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	submissions, err := h.service.ListUserSubmissions(ctx, claims.UserID)
+	if err != nil {
+		h.respondError(c, err, "Failed to get verification submissions")
+		return
+	}
+
+	resp := SubmissionListResponse{Submissions: make([]SubmissionResponse, 0, len(submissions))}
+	for _, sub := range submissions {
+		resp.Submissions = append(resp.Submissions, sub.ToResponse())
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// ========== 管理员接口 ==========
+
+// AdminListSubmissions 分页查询KYC认证申请
+func (h *Handler) AdminListSubmissions(c *gin.Context) {
+	var req ListSubmissionsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid list kyc submissions request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	resp, err := h.service.ListSubmissions(ctx, &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to get verification submissions")
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// AdminGetSubmission 获取KYC认证申请详情
+func (h *Handler) AdminGetSubmission(c *gin.Context) {
+	submissionID, ok := h.idParam(c, "submission_id", auth.ErrKYCSubmissionNotFound, "Failed to get verification submission")
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	resp, err := h.service.GetSubmission(ctx, submissionID)
+	if err != nil {
+		h.respondError(c, err, "Failed to get verification submission")
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// AdminDownloadDocument 下载证件文件原件
+func (h *Handler) AdminDownloadDocument(c *gin.Context) {
+	claims, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	documentID, ok := h.idParam(c, "document_id", auth.ErrKYCDocumentNotFound, "Failed to download document")
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	doc, err := h.service.GetDocument(ctx, documentID, claims.UserID)
+	if err != nil {
+		h.respondError(c, err, "Failed to download document")
+		return
+	}
+
+	c.Header("Content-Type", doc.ContentType)
+	c.Header("Cache-Control", "no-store")
+	c.FileAttachment(doc.FilePath, string(doc.Kind)+"-"+doc.ID+filepath.Ext(doc.FilePath))
+}
+
+// AdminApproveSubmission 通过KYC认证申请
+func (h *Handler) AdminApproveSubmission(c *gin.Context) {
+	claims, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	submissionID, ok := h.idParam(c, "submission_id", auth.ErrKYCSubmissionNotFound, "Failed to approve verification")
+	if !ok {
+		return
+	}
+
+	// 请求体可以为空，此时按申请等级通过
+	var req ApproveSubmissionRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.logger.WithError(err).Warn("Invalid approve kyc submission request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	sub, err := h.service.ApproveSubmission(ctx, submissionID, claims.UserID, &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to approve verification")
+		return
+	}
+
+	c.JSON(http.StatusOK, sub)
+}
+
+// AdminRejectSubmission 拒绝KYC认证申请（原因会发送给用户）
+func (h *Handler) AdminRejectSubmission(c *gin.Context) {
+	claims, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	submissionID, ok := h.idParam(c, "submission_id", auth.ErrKYCSubmissionNotFound, "Failed to reject verification")
+	if !ok {
+		return
+	}
+
+	var req RejectSubmissionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid reject kyc submission request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	sub, err := h.service.RejectSubmission(ctx, submissionID, claims.UserID, &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to reject verification")
+		return
+	}
+
+	c.JSON(http.StatusOK, sub)
+}
+
+// AdminSetUserTier 直接调整用户的KYC等级
+func (h *Handler) AdminSetUserTier(c *gin.Context) {
+	claims, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	userID, ok := h.idParam(c, "user_id", auth.ErrUserNotFound, "Failed to change verification level")
+	if !ok {
+		return
+	}
+
+	var req SetUserTierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid set kyc tier request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	resp, err := h.service.SetUserTier(ctx, userID, claims.UserID, &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to change verification level")
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// ========== 辅助方法 ==========
+
+// currentUser 获取当前认证信息，未认证时直接响应
+func (h *Handler) currentUser(c *gin.Context) (*auth.Claims, bool) {
+	claims, err := auth.GetCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
+		})
+		return nil, false
+	}
+	return claims, true
+}
+
+// idParam 获取UUID路径参数，格式无效时按资源不存在响应
+func (h *Handler) idParam(c *gin.Context, name string, notFound error, title string) (string, bool) {
+	id := c.Param(name)
+	if _, err := uuid.Parse(id); err != nil {
+		h.respondError(c, notFound, title)
+		return "", false
+	}
+	return id, true
+}
+
+// respondError KYC身份认证的错误响应
+func (h *Handler) respondError(c *gin.Context, err error, title string) {
+	switch {
+	case errors.Is(err, auth.ErrInvalidKYCDetails), errors.Is(err, auth.ErrKYCDocumentsMissing):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   title,
+			"message": err.Error(),
+		})
+	case errors.Is(err, auth.ErrInvalidKYCTier):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   title,
+			"message": "The requested level must be higher than your current level, and an approved level cannot exceed the requested level",
+		})
+	case errors.Is(err, auth.ErrKYCDocumentTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error":   title,
+			"message": "The document file is too large",
+		})
+	case errors.Is(err, auth.ErrKYCDocumentTypeNotAllowed):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{
+			"error":   title,
+			"message": "Only JPEG, PNG and PDF documents are accepted",
+		})
+	case errors.Is(err, auth.ErrTooManyKYCDocuments):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   title,
+			"message": "Too many uploaded documents, please submit or delete some of them first",
+		})
+	case errors.Is(err, auth.ErrKYCDocumentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   title,
+			"message": "Document not found or already submitted",
+		})
+	case errors.Is(err, auth.ErrKYCSubmissionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   title,
+			"message": "Verification submission not found",
+		})
+	case errors.Is(err, auth.ErrKYCSubmissionPending):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   title,
+			"message": "A verification submission is already pending review",
+		})
+	case errors.Is(err, auth.ErrKYCSubmissionReviewed):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   title,
+			"message": "This verification submission has already been reviewed",
+		})
+	case errors.Is(err, auth.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   title,
+			"message": "User not found",
+		})
+	default:
+		h.logger.WithError(err).Error(title)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal server error",
+			"message": title,
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package kyc
+
+import (
+	"time"
+)
+
+// SubmissionStatus KYC认证申请状态
+type SubmissionStatus string
+
+const (
+	SubmissionStatusPending  SubmissionStatus = "pending"
+	SubmissionStatusApproved SubmissionStatus = "approved"
+	SubmissionStatusRejected SubmissionStatus = "rejected"
+)
+
+// DocumentType 身份证件类型
+type DocumentType string
+
+const (
+	DocumentTypePassport       DocumentType = "passport"
+	DocumentTypeNationalID     DocumentType = "national_id"
+	DocumentTypeDrivingLicence DocumentType = "driving_licence"
+)
+
+// DocumentKind 证件文件类别
+type DocumentKind string
+
+const (
+	DocumentKindIDFront        DocumentKind = "id_front"         // 证件正面
+	DocumentKindIDBack         DocumentKind = "id_back"          // 证件背面（护照不需要）
+	DocumentKindSelfie         DocumentKind = "selfie"           // 手持证件自拍
+	DocumentKindProofOfAddress DocumentKind = "proof_of_address" // 住址证明
+)
+
+// IsValid 证件文件类别是否有效
+func (k DocumentKind) IsValid() bool {
+	switch k {
+	case DocumentKindIDFront, DocumentKindIDBack, DocumentKindSelfie, DocumentKindProofOfAddress:
+		return true
+	}
+	return false
+}
+
+// Submission KYC认证申请
+type Submission struct {
+	ID                string           `json:"id" db:"id"`
+	UserID            string           `json:"user_id" db:"user_id"`
+	RequestedTier     int              `json:"requested_tier" db:"requested_tier"`
+	ApprovedTier      *int             `json:"approved_tier" db:"approved_tier"`
+	Status            SubmissionStatus `json:"status" db:"status"`
+	FirstName         string           `json:"first_name" db:"first_name"`
+	LastName          string           `json:"last_name" db:"last_name"`
+	DateOfBirth       *time.Time       `json:"date_of_birth" db:"date_of_birth"`
+	Nationality       string           `json:"nationality" db:"nationality"`
+	DocumentType      DocumentType     `json:"document_type" db:"document_type"`
+	DocumentNumber    string           `json:"document_number" db:"document_number"`
+	AddressLine1      *string          `json:"address_line1" db:"address_line1"`
+	AddressLine2      *string          `json:"address_line2" db:"address_line2"`
+	City              *string          `json:"city" db:"city"`
+	PostalCode        *string          `json:"postal_code" db:"postal_code"`
+	Country           *string          `json:"country" db:"country"`
+	Provider          string           `json:"provider" db:"provider"`
+	ProviderReference *string          `json:"provider_reference" db:"provider_reference"`
+	ReviewedBy        *string          `json:"reviewed_by" db:"reviewed_by"`
+	ReviewedAt        *time.Time       `json:"reviewed_at" db:"reviewed_at"`
+	RejectionReason   *string          `json:"rejection_reason" db:"rejection_reason"`
+	ReviewNotes       *string          `json:"review_notes" db:"review_notes"`
+	IPAddress         *string          `json:"ip_address" db:"ip_address"`
+	CreatedAt         time.Time        `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time        `json:"updated_at" db:"updated_at"`
+
+	// 关联数据
+	Documents []*Document `json:"documents,omitempty"`
+}
+
+// Document KYC证件文件
+type Document struct {
+	ID           string       `json:"id" db:"id"`
+	UserID       string       `json:"user_id" db:"user_id"`
+	SubmissionID *string      `json:"submission_id" db:"submission_id"`
+	Kind         DocumentKind `json:"kind" db:"kind"`
+	FilePath     string       `json:"-" db:"file_path"` // 服务端文件路径，不对外暴露
+	ContentType  string       `json:"content_type" db:"content_type"`
+	FileSize     int64        `json:"file_size" db:"file_size"`
+	SHA256       string       `json:"sha256" db:"sha256"`
+	CreatedAt    time.Time    `json:"created_at" db:"created_at"`
+}
+
+// UserKYC 用户当前的KYC等级
+type UserKYC struct {
+	UserID       string     `json:"user_id" db:"user_id"`
+	Tier         int        `json:"tier" db:"tier"`
+	SubmissionID *string    `json:"submission_id" db:"submission_id"`
+	VerifiedAt   *time.Time `json:"verified_at" db:"verified_at"`
+	UpdatedBy    *string    `json:"updated_by" db:"updated_by"`
+	Reason       *string    `json:"reason" db:"reason"`
+	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// SubmissionFilter KYC认证申请查询条件
+type SubmissionFilter struct {
+	Status *SubmissionStatus
+	UserID *string
+	Tier   *int
+}
@@ -0,0 +1,47 @@
+package kyc
+
+import (
+	"context"
+)
+
+// ProviderDecision 审核方给出的结论
+type ProviderDecision string
+
+const (
+	ProviderDecisionPending  ProviderDecision = ""         // 尚无结论（人工审核或等待服务商回调）
+	ProviderDecisionApproved ProviderDecision = "approved" // 通过
+	ProviderDecisionRejected ProviderDecision = "rejected" // 拒绝
+)
+
+// ProviderResult 审核方处理申请的结果
+type ProviderResult struct {
+	Reference string           // 服务商的申请编号，人工审核时为空
+	Decision  ProviderDecision // 同步给出结论时直接生效，否则等待人工审核或服务商回调
+	Tier      int              // 通过时认可的等级，为0时按申请等级
+	Reason    string           // 拒绝原因（会发送给用户）
+}
+
+// Provider KYC审核方接口
+// 申请提交后调用Submit；外部服务商异步给出结论时通过Service.ApplyProviderDecision回写
+type Provider interface {
+	Name() string
+	Submit(ctx context.Context, submission *Submission, documents []*Document) (*ProviderResult, error)
+}
+
+// ManualProvider 管理员人工审核，不做任何自动判断
+type ManualProvider struct{}
+
+// NewManualProvider 创建人工审核方
+func NewManualProvider() *ManualProvider {
+	return &ManualProvider{}
+}
+
+// Name 审核方名称
+func (p *ManualProvider) Name() string {
+	return "manual"
+}
+
+// Submit 申请保持待审核状态，由管理员在后台审核
+func (p *ManualProvider) Submit(ctx context.Context, submission *Submission, documents []*Document) (*ProviderResult, error) {
+	return &ProviderResult{Decision: ProviderDecisionPending}, nil
+}
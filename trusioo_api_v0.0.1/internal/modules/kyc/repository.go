@@ -0,0 +1,494 @@
+package kyc
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"trusioo_api_v0.0.1/internal/infrastructure/database"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+// Repository KYC身份认证仓储
+type Repository struct {
+	*database.BaseRepository
+	logger *logrus.Logger
+}
+
+// NewRepository 创建新的KYC身份认证仓储
+func NewRepository(db *database.Database, logger *logrus.Logger) *Repository {
+	return &Repository{
+		BaseRepository: database.NewBaseRepository(db, logger),
+		logger:         logger,
+	}
+}
+
+// ========== 证件文件相关方法 ==========
+
+const documentColumns = `id, user_id, submission_id, kind, file_path, content_type, file_size, sha256, created_at`
+
+// scanDocument 扫描证件文件
+func scanDocument(scanner interface{ Scan(...interface{}) error }) (*Document, error) {
+	doc := &Document{}
+	err := scanner.Scan(&doc.ID, &doc.UserID, &doc.SubmissionID, &doc.Kind, &doc.FilePath,
+		&doc.ContentType, &doc.FileSize, &doc.SHA256, &doc.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// CreateDocument 保存已上传的证件文件记录
+func (r *Repository) CreateDocument(ctx context.Context, doc *Document) error {
+	doc.ID = uuid.New().String()
+
+	query := `
+		INSERT INTO kyc_documents (id, user_id, kind, file_path, content_type, file_size, sha256)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING created_at
+	`
+
+	err := r.GetDB().QueryRowContext(ctx, query,
+		doc.ID, doc.UserID, doc.Kind, doc.FilePath, doc.ContentType, doc.FileSize, doc.SHA256,
+	).Scan(&doc.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create kyc document: %w", err)
+	}
+
+	return nil
+}
+
+// CountUnattachedDocuments 统计用户已上传但尚未提交审核的证件文件数
+func (r *Repository) CountUnattachedDocuments(ctx context.Context, userID string) (int, error) {
+	var count int
+	err := r.GetDB().QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM kyc_documents WHERE user_id = $1 AND submission_id IS NULL`, userID,
+	).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count kyc documents: %w", err)
+	}
+	return count, nil
+}
+
+// ListUnattachedDocuments 获取用户已上传但尚未提交审核的证件文件
+func (r *Repository) ListUnattachedDocuments(ctx context.Context, userID string) ([]*Document, error) {
+	return r.listDocuments(ctx, `user_id = $1 AND submission_id IS NULL`, userID)
+}
+
+// ListSubmissionDocuments 获取申请关联的证件文件
+func (r *Repository) ListSubmissionDocuments(ctx context.Context, submissionID string) ([]*Document, error) {
+	return r.listDocuments(ctx, `submission_id = $1`, submissionID)
+}
+
+// listDocuments 按条件查询证件文件
+func (r *Repository) listDocuments(ctx context.Context, condition string, args ...interface{}) ([]*Document, error) {
+	query := `SELECT ` + documentColumns + ` FROM kyc_documents WHERE ` + condition + ` ORDER BY created_at, id`
+
+	rows, err := r.GetDB().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get kyc documents: %w", err)
+	}
+	defer rows.Close()
+
+	documents := make([]*Document, 0)
+	for rows.Next() {
+		doc, err := scanDocument(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan kyc document: %w", err)
+		}
+		documents = append(documents, doc)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate kyc documents: %w", err)
+	}
+
+	return documents, nil
+}
+
+// GetDocument 根据ID获取证件文件
+func (r *Repository) GetDocument(ctx context.Context, documentID string) (*Document, error) {
+	query := `SELECT ` + documentColumns + ` FROM kyc_documents WHERE id = $1`
+
+	doc, err := scanDocument(r.GetDB().QueryRowContext(ctx, query, documentID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("kyc document not found")
+		}
+		return nil, fmt.Errorf("failed to get kyc document: %w", err)
+	}
+
+	return doc, nil
+}
+
+// DeleteUnattachedDocument 删除用户尚未提交审核的证件文件记录，返回被删除的记录
+// 文件不存在、不属于该用户或已提交审核时返回"kyc document not found"
+func (r *Repository) DeleteUnattachedDocument(ctx context.Context, userID, documentID string) (*Document, error) {
+	query := `
+		DELETE FROM kyc_documents
+		WHERE id = $1 AND user_id = $2 AND submission_id IS NULL
+		RETURNING ` + documentColumns
+
+	doc, err := scanDocument(r.GetDB().QueryRowContext(ctx, query, documentID, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("kyc document not found")
+		}
+		return nil, fmt.Errorf("failed to delete kyc document: %w", err)
+	}
+
+	return doc, nil
+}
+
+// ========== 认证申请相关方法 ==========
+
+const submissionColumns = `
+	id, user_id, requested_tier, approved_tier, status, first_name, last_name, date_of_birth,
+	nationality, document_type, document_number, address_line1, address_line2, city, postal_code,
+	country, provider, provider_reference, reviewed_by, reviewed_at, rejection_reason, review_notes,
+	host(ip_address), created_at, updated_at
+`
+
+// scanSubmission 扫描认证申请
+func scanSubmission(scanner interface{ Scan(...interface{}) error }) (*Submission, error) {
+	sub := &Submission{}
+	var ipAddress sql.NullString
+	err := scanner.Scan(
+		&sub.ID, &sub.UserID, &sub.RequestedTier, &sub.ApprovedTier, &sub.Status, &sub.FirstName,
+		&sub.LastName, &sub.DateOfBirth, &sub.Nationality, &sub.DocumentType, &sub.DocumentNumber,
+		&sub.AddressLine1, &sub.AddressLine2, &sub.City, &sub.PostalCode, &sub.Country, &sub.Provider,
+		&sub.ProviderReference, &sub.ReviewedBy, &sub.ReviewedAt, &sub.RejectionReason, &sub.ReviewNotes,
+		&ipAddress, &sub.CreatedAt, &sub.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if ipAddress.Valid {
+		sub.IPAddress = &ipAddress.String
+	}
+	return sub, nil
+}
+
+// CreateSubmission 在同一事务中创建认证申请并关联用户已上传的证件文件
+// 已有待审核申请时返回"kyc submission already pending"，证件文件无效时返回"kyc document not found"
+func (r *Repository) CreateSubmission(ctx context.Context, sub *Submission, documentIDs []string) error {
+	sub.ID = uuid.New().String()
+
+	err := r.GetDB().Transaction(func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO kyc_submissions (
+				id, user_id, requested_tier, status, first_name, last_name, date_of_birth, nationality,
+				document_type, document_number, address_line1, address_line2, city, postal_code, country,
+				provider, ip_address
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, NULLIF($17, '')::inet)
+			RETURNING created_at, updated_at
+		`, sub.ID, sub.UserID, sub.RequestedTier, sub.Status, sub.FirstName, sub.LastName, sub.DateOfBirth,
+			sub.Nationality, sub.DocumentType, sub.DocumentNumber, sub.AddressLine1, sub.AddressLine2,
+			sub.City, sub.PostalCode, sub.Country, sub.Provider, stringValue(sub.IPAddress),
+		).Scan(&sub.CreatedAt, &sub.UpdatedAt)
+		if err != nil {
+			if isUniqueViolation(err) {
+				return fmt.Errorf("kyc submission already pending")
+			}
+			return fmt.Errorf("failed to create kyc submission: %w", err)
+		}
+
+		result, err := tx.ExecContext(ctx, `
+			UPDATE kyc_documents SET submission_id = $1
+			WHERE id = ANY($2) AND user_id = $3 AND submission_id IS NULL
+		`, sub.ID, pq.Array(documentIDs), sub.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to attach kyc documents: %w", err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rowsAffected != int64(len(documentIDs)) {
+			return fmt.Errorf("kyc document not found")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SetProviderReference 记录外部服务商的申请编号
+func (r *Repository) SetProviderReference(ctx context.Context, submissionID, reference string) error {
+	_, err := r.GetDB().ExecContext(ctx, `
+		UPDATE kyc_submissions SET provider_reference = $2, updated_at = NOW() WHERE id = $1
+	`, submissionID, reference)
+	if err != nil {
+		return fmt.Errorf("failed to set kyc provider reference: %w", err)
+	}
+	return nil
+}
+
+// GetSubmission 根据ID获取认证申请
+func (r *Repository) GetSubmission(ctx context.Context, submissionID string) (*Submission, error) {
+	query := `SELECT ` + submissionColumns + ` FROM kyc_submissions WHERE id = $1`
+
+	sub, err := scanSubmission(r.GetDB().QueryRowContext(ctx, query, submissionID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("kyc submission not found")
+		}
+		return nil, fmt.Errorf("failed to get kyc submission: %w", err)
+	}
+
+	return sub, nil
+}
+
+// GetSubmissionByProviderReference 根据外部服务商的申请编号获取认证申请
+func (r *Repository) GetSubmissionByProviderReference(ctx context.Context, provider, reference string) (*Submission, error) {
+	query := `SELECT ` + submissionColumns + ` FROM kyc_submissions WHERE provider = $1 AND provider_reference = $2`
+
+	sub, err := scanSubmission(r.GetDB().QueryRowContext(ctx, query, provider, reference))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("kyc submission not found")
+		}
+		return nil, fmt.Errorf("failed to get kyc submission: %w", err)
+	}
+
+	return sub, nil
+}
+
+// ListUserSubmissions 获取用户的认证申请（最新的在前）
+func (r *Repository) ListUserSubmissions(ctx context.Context, userID string, limit int) ([]*Submission, error) {
+	query := `SELECT ` + submissionColumns + `
+		FROM kyc_submissions
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id
+		LIMIT $2`
+
+	return r.querySubmissions(ctx, query, userID, limit)
+}
+
+// ListSubmissions 按条件分页查询认证申请（待审核的按提交顺序，其他按最新在前）
+func (r *Repository) ListSubmissions(ctx context.Context, filter *SubmissionFilter, limit, offset int) ([]*Submission, int64, error) {
+	conditions := []string{"TRUE"}
+	args := []interface{}{}
+	argIndex := 1
+
+	if filter.Status != nil {
+		conditions = append(conditions, fmt.Sprintf("status = $%d", argIndex))
+		args = append(args, *filter.Status)
+		argIndex++
+	}
+	if filter.UserID != nil {
+		conditions = append(conditions, fmt.Sprintf("user_id = $%d", argIndex))
+		args = append(args, *filter.UserID)
+		argIndex++
+	}
+	if filter.Tier != nil {
+		conditions = append(conditions, fmt.Sprintf("requested_tier = $%d", argIndex))
+		args = append(args, *filter.Tier)
+		argIndex++
+	}
+
+	whereClause := strings.Join(conditions, " AND ")
+
+	var total int64
+	countQuery := `SELECT COUNT(*) FROM kyc_submissions WHERE ` + whereClause
+	if err := r.GetDB().QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count kyc submissions: %w", err)
+	}
+
+	orderBy := "created_at DESC, id"
+	if filter.Status != nil && *filter.Status == SubmissionStatusPending {
+		orderBy = "created_at, id"
+	}
+
+	query := fmt.Sprintf(`SELECT %s
+		FROM kyc_submissions
+		WHERE %s
+		ORDER BY %s
+		LIMIT $%d OFFSET $%d
+	`, submissionColumns, whereClause, orderBy, argIndex, argIndex+1)
+	args = append(args, limit, offset)
+
+	submissions, err := r.querySubmissions(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return submissions, total, nil
+}
+
+// querySubmissions 执行认证申请查询
+func (r *Repository) querySubmissions(ctx context.Context, query string, args ...interface{}) ([]*Submission, error) {
+	rows, err := r.GetDB().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get kyc submissions: %w", err)
+	}
+	defer rows.Close()
+
+	submissions := make([]*Submission, 0)
+	for rows.Next() {
+		sub, err := scanSubmission(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan kyc submission: %w", err)
+		}
+		submissions = append(submissions, sub)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate kyc submissions: %w", err)
+	}
+
+	return submissions, nil
+}
+
+// ApproveSubmission 在同一事务中通过待审核的申请并更新用户的KYC等级（不会降低已有等级）
+// 申请不是待审核状态时返回"kyc submission already reviewed"
+func (r *Repository) ApproveSubmission(ctx context.Context, submissionID string, tier int, reviewedBy, notes *string) (*UserKYC, error) {
+	var userKYC *UserKYC
+
+	err := r.GetDB().Transaction(func(tx *sql.Tx) error {
+		var userID string
+		err := tx.QueryRowContext(ctx, `
+			UPDATE kyc_submissions
+			SET status = 'approved', approved_tier = $2, reviewed_by = $3, reviewed_at = NOW(),
+			    review_notes = $4, updated_at = NOW()
+			WHERE id = $1 AND status = 'pending'
+			RETURNING user_id
+		`, submissionID, tier, reviewedBy, notes).Scan(&userID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("kyc submission already reviewed")
+			}
+			return fmt.Errorf("failed to approve kyc submission: %w", err)
+		}
+
+		userKYC, err = scanUserKYC(tx.QueryRowContext(ctx, `
+			INSERT INTO user_kyc (user_id, tier, submission_id, verified_at, updated_by, reason, updated_at)
+			VALUES ($1, $2, $3, NOW(), $4, NULL, NOW())
+			ON CONFLICT (user_id) DO UPDATE SET
+				tier = GREATEST(user_kyc.tier, EXCLUDED.tier),
+				submission_id = EXCLUDED.submission_id,
+				verified_at = EXCLUDED.verified_at,
+				updated_by = EXCLUDED.updated_by,
+				reason = NULL,
+				updated_at = NOW()
+			RETURNING `+userKYCColumns,
+			userID, tier, submissionID, reviewedBy))
+		if err != nil {
+			return fmt.Errorf("failed to update user kyc tier: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return userKYC, nil
+}
+
+// RejectSubmission 拒绝待审核的申请
+// 申请不是待审核状态时返回"kyc submission already reviewed"
+func (r *Repository) RejectSubmission(ctx context.Context, submissionID string, reviewedBy *string, reason string, notes *string) error {
+	result, err := r.GetDB().ExecContext(ctx, `
+		UPDATE kyc_submissions
+		SET status = 'rejected', reviewed_by = $2, reviewed_at = NOW(), rejection_reason = $3,
+		    review_notes = $4, updated_at = NOW()
+		WHERE id = $1 AND status = 'pending'
+	`, submissionID, reviewedBy, reason, notes)
+	if err != nil {
+		return fmt.Errorf("failed to reject kyc submission: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("kyc submission already reviewed")
+	}
+
+	return nil
+}
+
+// ========== 用户KYC等级相关方法 ==========
+
+const userKYCColumns = `user_id, tier, submission_id, verified_at, updated_by, reason, updated_at`
+
+// scanUserKYC 扫描用户KYC等级
+func scanUserKYC(scanner interface{ Scan(...interface{}) error }) (*UserKYC, error) {
+	userKYC := &UserKYC{}
+	err := scanner.Scan(&userKYC.UserID, &userKYC.Tier, &userKYC.SubmissionID, &userKYC.VerifiedAt,
+		&userKYC.UpdatedBy, &userKYC.Reason, &userKYC.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return userKYC, nil
+}
+
+// GetUserKYC 获取用户的KYC等级，没有记录时返回0级
+func (r *Repository) GetUserKYC(ctx context.Context, userID string) (*UserKYC, error) {
+	query := `SELECT ` + userKYCColumns + ` FROM user_kyc WHERE user_id = $1`
+
+	userKYC, err := scanUserKYC(r.GetDB().QueryRowContext(ctx, query, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return &UserKYC{UserID: userID}, nil
+		}
+		return nil, fmt.Errorf("failed to get user kyc: %w", err)
+	}
+
+	return userKYC, nil
+}
+
+// SetUserTier 管理员直接调整用户的KYC等级
+func (r *Repository) SetUserTier(ctx context.Context, userID string, tier int, adminID, reason string) (*UserKYC, error) {
+	query := `
+		INSERT INTO user_kyc (user_id, tier, updated_by, reason, updated_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (user_id) DO UPDATE SET
+			tier = EXCLUDED.tier,
+			updated_by = EXCLUDED.updated_by,
+			reason = EXCLUDED.reason,
+			updated_at = NOW()
+		RETURNING ` + userKYCColumns
+
+	userKYC, err := scanUserKYC(r.GetDB().QueryRowContext(ctx, query, userID, tier, adminID, reason))
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return nil, fmt.Errorf("user not found")
+		}
+		return nil, fmt.Errorf("failed to set user kyc tier: %w", err)
+	}
+
+	return userKYC, nil
+}
+
+// ========== 辅助函数 ==========
+
+// isUniqueViolation 是否为唯一约束冲突
+func isUniqueViolation(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code == "23505"
+}
+
+// isForeignKeyViolation 是否为外键约束冲突
+func isForeignKeyViolation(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code == "23503"
+}
+
+// stringValue 返回字符串指针的值，nil时返回空字符串
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,59 @@
+package kyc
+
+import (
+	"trusioo_api_v0.0.1/internal/modules/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Routes KYC身份认证路由
+type Routes struct {
+	handler    *Handler
+	authMiddle *auth.AuthMiddleware
+}
+
+// NewRoutes 创建新的KYC身份认证路由
+func NewRoutes(handler *Handler, authMiddle *auth.AuthMiddleware) *Routes {
+	return &Routes{
+		handler:    handler,
+		authMiddle: authMiddle,
+	}
+}
+
+// RegisterRoutes 注册KYC身份认证路由
+func (r *Routes) RegisterRoutes(router *gin.RouterGroup) {
+	// 用户接口（提交资料在管理员模拟登录时禁止）
+	kyc := router.Group("/kyc")
+	kyc.Use(r.authMiddle.RequireAuth())
+	kyc.Use(r.authMiddle.RequireUserType("user"))
+	{
+		// 当前等级、限额和下一等级的要求
+		kyc.GET("", r.handler.GetStatus)
+
+		// 证件文件（先上传，提交申请时引用）
+		kyc.POST("/documents", r.authMiddle.DenyImpersonation(), r.handler.UploadDocument)
+		kyc.DELETE("/documents/:document_id", r.authMiddle.DenyImpersonation(), r.handler.DeleteDocument)
+
+		// 认证申请
+		kyc.POST("/submissions", r.authMiddle.DenyImpersonation(), r.handler.CreateSubmission)
+		kyc.GET("/submissions", r.handler.ListSubmissions)
+	}
+
+	// 管理员接口 - 需要管理员认证，各接口按权限控制
+	admin := router.Group("/admin/kyc")
+	admin.Use(r.authMiddle.RequireAuth())
+	admin.Use(r.authMiddle.RequireUserType("admin"))
+	{
+		// 认证申请审核
+		admin.GET("/submissions", r.authMiddle.RequirePermission(auth.PermKYCView), r.handler.AdminListSubmissions)
+		admin.GET("/submissions/:submission_id", r.authMiddle.RequirePermission(auth.PermKYCView), r.handler.AdminGetSubmission)
+		admin.POST("/submissions/:submission_id/approve", r.authMiddle.RequirePermission(auth.PermKYCReview), r.handler.AdminApproveSubmission)
+		admin.POST("/submissions/:submission_id/reject", r.authMiddle.RequirePermission(auth.PermKYCReview), r.handler.AdminRejectSubmission)
+
+		// 证件文件原件
+		admin.GET("/documents/:document_id/file", r.authMiddle.RequirePermission(auth.PermKYCView), r.handler.AdminDownloadDocument)
+
+		// 直接调整用户等级（例如发现欺诈后降级）
+		admin.PUT("/users/:user_id/tier", r.authMiddle.RequirePermission(auth.PermKYCReview), r.handler.AdminSetUserTier)
+	}
+}
@@ -0,0 +1,747 @@
+package kyc
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"trusioo_api_v0.0.1/internal/config"
+	"trusioo_api_v0.0.1/internal/infrastructure/mailer"
+	"trusioo_api_v0.0.1/internal/modules/auth"
+	"trusioo_api_v0.0.1/internal/modules/auth/user"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// submissionListLimit 用户查看的认证申请列表最大条数
+	submissionListLimit = 20
+	// minimumAge 申请人最低年龄
+	minimumAge = 18
+	// sniffLength 识别文件类型读取的字节数
+	sniffLength = 512
+)
+
+// allowedContentTypes 允许上传的证件文件类型及保存时使用的扩展名
+var allowedContentTypes = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"application/pdf": ".pdf",
+}
+
+// documentDescriptions 证件文件类别说明
+var documentDescriptions = map[DocumentKind]string{
+	DocumentKindIDFront:        "Front of your passport, national ID card or driving licence",
+	DocumentKindIDBack:         "Back of your national ID card or driving licence (not needed for passports)",
+	DocumentKindSelfie:         "A photo of yourself holding the same ID document",
+	DocumentKindProofOfAddress: "A utility bill or bank statement from the last 3 months showing your address",
+}
+
+// Service KYC身份认证服务
+type Service struct {
+	repo         *Repository
+	userRepo     *user.Repository
+	provider     Provider
+	tierObserver auth.KYCTierObserver
+	mailer       mailer.Mailer
+	config       *config.KYCConfig
+	logger       *logrus.Logger
+}
+
+// NewService 创建新的KYC身份认证服务
+func NewService(repo *Repository, userRepo *user.Repository, provider Provider, mailSender mailer.Mailer, cfg *config.KYCConfig, logger *logrus.Logger) *Service {
+	return &Service{
+		repo:     repo,
+		userRepo: userRepo,
+		provider: provider,
+		mailer:   mailSender,
+		config:   cfg,
+		logger:   logger,
+	}
+}
+
+// SetTierObserver 设置KYC等级变更观察者（钱包模块）
+func (s *Service) SetTierObserver(observer auth.KYCTierObserver) {
+	s.tierObserver = observer
+}
+
+// MaxDocumentSize 单个证件文件大小上限（字节）
+func (s *Service) MaxDocumentSize() int64 {
+	return s.config.MaxDocumentSize
+}
+
+// ========== 等级和限额 ==========
+
+// LimitsForTier 获取认证等级对应的钱包限制
+func (s *Service) LimitsForTier(tier int) auth.KYCLimits {
+	limits := auth.KYCLimits{Tier: tier}
+	switch tier {
+	case auth.KYCTierBasic:
+		limits.WithdrawalEnabled = true
+		limits.DailyWithdrawalLimit = s.config.Tier1DailyWithdrawalLimit
+	case auth.KYCTierAdvanced:
+		limits.WithdrawalEnabled = true
+		limits.DailyWithdrawalLimit = s.config.Tier2DailyWithdrawalLimit
+	}
+	return limits
+}
+
+// GetKYCLimits 获取用户当前认证等级对应的钱包限制
+func (s *Service) GetKYCLimits(ctx context.Context, userID string) (*auth.KYCLimits, error) {
+	userKYC, err := s.repo.GetUserKYC(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	limits := s.LimitsForTier(userKYC.Tier)
+	return &limits, nil
+}
+
+// requiredDocumentKinds 认证等级要求的证件文件类别（要求逐级累加，护照不需要背面）
+func requiredDocumentKinds(tier int, documentType DocumentType) []DocumentKind {
+	kinds := []DocumentKind{DocumentKindIDFront}
+	if documentType != DocumentTypePassport {
+		kinds = append(kinds, DocumentKindIDBack)
+	}
+	if tier >= auth.KYCTierAdvanced {
+		kinds = append(kinds, DocumentKindSelfie, DocumentKindProofOfAddress)
+	}
+	return kinds
+}
+
+// tierRequirements 认证等级的要求说明
+func (s *Service) tierRequirements(tier int) *TierRequirements {
+	req := &TierRequirements{
+		Tier:   tier,
+		Limits: s.LimitsForTier(tier),
+		Fields: []string{"first_name", "last_name", "date_of_birth", "nationality", "document_type", "document_number"},
+	}
+	if tier >= auth.KYCTierAdvanced {
+		req.Fields = append(req.Fields, "address_line1", "city", "country")
+	}
+	for _, kind := range requiredDocumentKinds(tier, DocumentTypeNationalID) {
+		req.Documents = append(req.Documents, DocumentRequirement{Kind: kind, Description: documentDescriptions[kind]})
+	}
+	return req
+}
+
+// notifyTierChanged 通知等级变更观察者（失败只记录日志，审核结果已生效，提现按user_kyc中的等级检查）
+func (s *Service) notifyTierChanged(ctx context.Context, userID string, tier int) {
+	if s.tierObserver == nil {
+		return
+	}
+	limits := s.LimitsForTier(tier)
+	if err := s.tierObserver.OnKYCTierChanged(ctx, userID, &limits); err != nil {
+		s.logger.WithError(err).WithFields(logrus.Fields{
+			"user_id": userID,
+			"tier":    tier,
+		}).Error("Failed to apply kyc tier limits")
+	}
+}
+
+// ========== 用户接口 ==========
+
+// GetStatus 获取用户的KYC等级、限额、待审核申请和下一等级的要求
+func (s *Service) GetStatus(ctx context.Context, userID string) (*StatusResponse, error) {
+	userKYC, err := s.repo.GetUserKYC(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	documents, err := s.repo.ListUnattachedDocuments(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &StatusResponse{
+		Tier:              userKYC.Tier,
+		VerifiedAt:        userKYC.VerifiedAt,
+		Limits:            s.LimitsForTier(userKYC.Tier),
+		UploadedDocuments: make([]DocumentResponse, 0, len(documents)),
+	}
+	for _, doc := range documents {
+		resp.UploadedDocuments = append(resp.UploadedDocuments, doc.ToResponse())
+	}
+	if userKYC.Tier < auth.KYCTierAdvanced {
+		resp.NextTier = s.tierRequirements(userKYC.Tier + 1)
+	}
+
+	submissions, err := s.repo.ListUserSubmissions(ctx, userID, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(submissions) > 0 && submissions[0].Status == SubmissionStatusPending {
+		pending := submissions[0].ToResponse()
+		resp.PendingSubmission = &pending
+	}
+
+	return resp, nil
+}
+
+// UploadDocument 保存用户上传的证件文件（按文件内容识别类型，只接受JPEG、PNG和PDF）
+func (s *Service) UploadDocument(ctx context.Context, userID string, kind DocumentKind, file io.Reader, size int64) (*Document, error) {
+	if !kind.IsValid() {
+		return nil, fmt.Errorf("%w: unknown document kind %q", auth.ErrInvalidKYCDetails, kind)
+	}
+	if size > s.config.MaxDocumentSize {
+		return nil, auth.ErrKYCDocumentTooLarge
+	}
+
+	count, err := s.repo.CountUnattachedDocuments(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= s.config.MaxPendingDocuments {
+		return nil, auth.ErrTooManyKYCDocuments
+	}
+
+	reader := bufio.NewReaderSize(file, sniffLength)
+	head, err := reader.Peek(sniffLength)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, fmt.Errorf("failed to read kyc document: %w", err)
+	}
+	contentType := http.DetectContentType(head)
+	ext, ok := allowedContentTypes[contentType]
+	if !ok {
+		return nil, auth.ErrKYCDocumentTypeNotAllowed
+	}
+
+	path, written, checksum, err := s.storeDocument(userID, ext, reader)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &Document{
+		UserID:      userID,
+		Kind:        kind,
+		FilePath:    path,
+		ContentType: contentType,
+		FileSize:    written,
+		SHA256:      checksum,
+	}
+	if err := s.repo.CreateDocument(ctx, doc); err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":     userID,
+		"document_id": doc.ID,
+		"kind":        kind,
+		"file_size":   written,
+	}).Info("KYC document uploaded")
+
+	return doc, nil
+}
+
+// storeDocument 将证件文件写入用户目录（随机文件名，仅服务进程可读），返回路径、大小和SHA-256
+func (s *Service) storeDocument(userID, ext string, content io.Reader) (string, int64, string, error) {
+	dir := filepath.Join(s.config.DocumentDir, userID)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", 0, "", fmt.Errorf("failed to create kyc document directory: %w", err)
+	}
+
+	path := filepath.Join(dir, uuid.New().String()+ext)
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return "", 0, "", fmt.Errorf("failed to create kyc document file: %w", err)
+	}
+
+	hash := sha256.New()
+	// 多读1字节用于判断是否超过大小上限（上传大小可能与实际内容不符）
+	written, err := io.Copy(io.MultiWriter(file, hash), io.LimitReader(content, s.config.MaxDocumentSize+1))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return "", 0, "", fmt.Errorf("failed to write kyc document file: %w", err)
+	}
+	if written > s.config.MaxDocumentSize {
+		os.Remove(path)
+		return "", 0, "", auth.ErrKYCDocumentTooLarge
+	}
+
+	return path, written, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// DeleteDocument 删除尚未提交审核的证件文件
+func (s *Service) DeleteDocument(ctx context.Context, userID, documentID string) error {
+	doc, err := s.repo.DeleteUnattachedDocument(ctx, userID, documentID)
+	if err != nil {
+		if err.Error() == "kyc document not found" {
+			return auth.ErrKYCDocumentNotFound
+		}
+		return err
+	}
+
+	if err := os.Remove(doc.FilePath); err != nil && !os.IsNotExist(err) {
+		s.logger.WithError(err).WithField("document_id", doc.ID).Error("Failed to remove kyc document file")
+	}
+
+	return nil
+}
+
+// CreateSubmission 提交KYC认证申请并交给审核方处理
+func (s *Service) CreateSubmission(ctx context.Context, userID, ipAddress string, req *CreateSubmissionRequest) (*Submission, error) {
+	userKYC, err := s.repo.GetUserKYC(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if req.RequestedTier <= userKYC.Tier {
+		return nil, auth.ErrInvalidKYCTier
+	}
+
+	dateOfBirth, err := validateDateOfBirth(req.DateOfBirth)
+	if err != nil {
+		return nil, err
+	}
+	if req.RequestedTier >= auth.KYCTierAdvanced {
+		if isBlank(req.AddressLine1) || isBlank(req.City) || isBlank(req.Country) {
+			return nil, fmt.Errorf("%w: address_line1, city and country are required for tier %d",
+				auth.ErrInvalidKYCDetails, req.RequestedTier)
+		}
+	}
+
+	documents, err := s.selectDocuments(ctx, userID, req.DocumentIDs)
+	if err != nil {
+		return nil, err
+	}
+	if missing := missingDocumentKinds(req.RequestedTier, req.DocumentType, documents); len(missing) > 0 {
+		return nil, fmt.Errorf("%w: %s", auth.ErrKYCDocumentsMissing, strings.Join(missing, ", "))
+	}
+
+	sub := &Submission{
+		UserID:         userID,
+		RequestedTier:  req.RequestedTier,
+		Status:         SubmissionStatusPending,
+		FirstName:      strings.TrimSpace(req.FirstName),
+		LastName:       strings.TrimSpace(req.LastName),
+		DateOfBirth:    &dateOfBirth,
+		Nationality:    strings.ToUpper(req.Nationality),
+		DocumentType:   req.DocumentType,
+		DocumentNumber: strings.TrimSpace(req.DocumentNumber),
+		AddressLine1:   req.AddressLine1,
+		AddressLine2:   req.AddressLine2,
+		City:           req.City,
+		PostalCode:     req.PostalCode,
+		Provider:       s.provider.Name(),
+		IPAddress:      &ipAddress,
+	}
+	if req.Country != nil {
+		country := strings.ToUpper(*req.Country)
+		sub.Country = &country
+	}
+
+	if err := s.repo.CreateSubmission(ctx, sub, req.DocumentIDs); err != nil {
+		switch err.Error() {
+		case "kyc submission already pending":
+			return nil, auth.ErrKYCSubmissionPending
+		case "kyc document not found":
+			return nil, auth.ErrKYCDocumentNotFound
+		}
+		return nil, err
+	}
+	sub.Documents = documents
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":        userID,
+		"submission_id":  sub.ID,
+		"requested_tier": sub.RequestedTier,
+		"provider":       sub.Provider,
+	}).Info("KYC submission created")
+
+	s.submitToProvider(ctx, sub, documents)
+
+	return sub, nil
+}
+
+// submitToProvider 将申请交给审核方，审核方同步给出结论时直接生效
+// 审核方出错时申请保持待审核状态，管理员仍可人工审核
+func (s *Service) submitToProvider(ctx context.Context, sub *Submission, documents []*Document) {
+	result, err := s.provider.Submit(ctx, sub, documents)
+	if err != nil {
+		s.logger.WithError(err).WithFields(logrus.Fields{
+			"submission_id": sub.ID,
+			"provider":      sub.Provider,
+		}).Error("Failed to submit kyc submission to provider")
+		return
+	}
+
+	if result.Reference != "" {
+		if err := s.repo.SetProviderReference(ctx, sub.ID, result.Reference); err != nil {
+			s.logger.WithError(err).WithField("submission_id", sub.ID).Error("Failed to save kyc provider reference")
+		}
+		sub.ProviderReference = &result.Reference
+	}
+
+	if result.Decision != ProviderDecisionPending {
+		if _, err := s.applyProviderResult(ctx, sub, result); err != nil {
+			s.logger.WithError(err).WithField("submission_id", sub.ID).Error("Failed to apply kyc provider decision")
+		}
+	}
+}
+
+// selectDocuments 获取申请引用的证件文件，必须是用户已上传且尚未提交审核的文件
+func (s *Service) selectDocuments(ctx context.Context, userID string, documentIDs []string) ([]*Document, error) {
+	uploaded, err := s.repo.ListUnattachedDocuments(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]*Document, len(uploaded))
+	for _, doc := range uploaded {
+		byID[doc.ID] = doc
+	}
+
+	selected := make([]*Document, 0, len(documentIDs))
+	seen := make(map[string]bool, len(documentIDs))
+	for _, id := range documentIDs {
+		doc, ok := byID[id]
+		if !ok || seen[id] {
+			return nil, auth.ErrKYCDocumentNotFound
+		}
+		seen[id] = true
+		selected = append(selected, doc)
+	}
+
+	return selected, nil
+}
+
+// missingDocumentKinds 检查申请等级要求但未提供的证件文件类别
+func missingDocumentKinds(tier int, documentType DocumentType, documents []*Document) []string {
+	provided := make(map[DocumentKind]bool, len(documents))
+	for _, doc := range documents {
+		provided[doc.Kind] = true
+	}
+
+	missing := make([]string, 0)
+	for _, kind := range requiredDocumentKinds(tier, documentType) {
+		if !provided[kind] {
+			missing = append(missing, string(kind))
+		}
+	}
+	return missing
+}
+
+// validateDateOfBirth 解析出生日期并检查申请人已满最低年龄
+func validateDateOfBirth(value string) (time.Time, error) {
+	dateOfBirth, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: date_of_birth must be formatted as YYYY-MM-DD", auth.ErrInvalidKYCDetails)
+	}
+	if dateOfBirth.Year() < 1900 || dateOfBirth.AddDate(minimumAge, 0, 0).After(time.Now()) {
+		return time.Time{}, fmt.Errorf("%w: applicants must be at least %d years old", auth.ErrInvalidKYCDetails, minimumAge)
+	}
+	return dateOfBirth, nil
+}
+
+// isBlank 字符串指针是否为空或只包含空白
+func isBlank(value *string) bool {
+	return value == nil || strings.TrimSpace(*value) == ""
+}
+
+// ListUserSubmissions 获取用户最近的认证申请
+func (s *Service) ListUserSubmissions(ctx context.Context, userID string) ([]*Submission, error) {
+	submissions, err := s.repo.ListUserSubmissions(ctx, userID, submissionListLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, sub := range submissions {
+		documents, err := s.repo.ListSubmissionDocuments(ctx, sub.ID)
+		if err != nil {
+			return nil, err
+		}
+		sub.Documents = documents
+	}
+
+	return submissions, nil
+}
+
+// ========== 管理员接口 ==========
+
+// ListSubmissions 分页查询认证申请
+func (s *Service) ListSubmissions(ctx context.Context, req *ListSubmissionsRequest) (*AdminSubmissionListResponse, error) {
+	page, pageSize := req.Page, req.PageSize
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+
+	submissions, total, err := s.repo.ListSubmissions(ctx, req.ToFilter(), pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	totalPages := int((total + int64(pageSize) - 1) / int64(pageSize))
+
+	return &AdminSubmissionListResponse{
+		Submissions: submissions,
+		Total:       total,
+		Page:        page,
+		PageSize:    pageSize,
+		TotalPages:  totalPages,
+		HasNext:     page < totalPages,
+		HasPrev:     page > 1,
+	}, nil
+}
+
+// GetSubmission 获取认证申请详情（包括证件文件和用户当前等级）
+func (s *Service) GetSubmission(ctx context.Context, submissionID string) (*AdminSubmissionDetailResponse, error) {
+	sub, err := s.getSubmission(ctx, submissionID)
+	if err != nil {
+		return nil, err
+	}
+
+	documents, err := s.repo.ListSubmissionDocuments(ctx, sub.ID)
+	if err != nil {
+		return nil, err
+	}
+	sub.Documents = documents
+
+	userKYC, err := s.repo.GetUserKYC(ctx, sub.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AdminSubmissionDetailResponse{Submission: sub, UserKYC: userKYC}, nil
+}
+
+// getSubmission 获取认证申请
+func (s *Service) getSubmission(ctx context.Context, submissionID string) (*Submission, error) {
+	sub, err := s.repo.GetSubmission(ctx, submissionID)
+	if err != nil {
+		if err.Error() == "kyc submission not found" {
+			return nil, auth.ErrKYCSubmissionNotFound
+		}
+		return nil, err
+	}
+	return sub, nil
+}
+
+// GetDocument 获取证件文件（管理员查看原件）
+func (s *Service) GetDocument(ctx context.Context, documentID, adminID string) (*Document, error) {
+	doc, err := s.repo.GetDocument(ctx, documentID)
+	if err != nil {
+		if err.Error() == "kyc document not found" {
+			return nil, auth.ErrKYCDocumentNotFound
+		}
+		return nil, err
+	}
+
+	if _, err := os.Stat(doc.FilePath); err != nil {
+		s.logger.WithError(err).WithField("document_id", doc.ID).Error("KYC document file is missing")
+		return nil, auth.ErrKYCDocumentNotFound
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"document_id": doc.ID,
+		"user_id":     doc.UserID,
+		"admin_id":    adminID,
+	}).Info("KYC document viewed by admin")
+
+	return doc, nil
+}
+
+// ApproveSubmission 管理员通过认证申请
+func (s *Service) ApproveSubmission(ctx context.Context, submissionID, adminID string, req *ApproveSubmissionRequest) (*Submission, error) {
+	sub, err := s.getSubmission(ctx, submissionID)
+	if err != nil {
+		return nil, err
+	}
+
+	tier := sub.RequestedTier
+	if req.Tier != nil {
+		tier = *req.Tier
+	}
+
+	if err := s.approve(ctx, sub, tier, &adminID, req.Notes); err != nil {
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+// RejectSubmission 管理员拒绝认证申请
+func (s *Service) RejectSubmission(ctx context.Context, submissionID, adminID string, req *RejectSubmissionRequest) (*Submission, error) {
+	sub, err := s.getSubmission(ctx, submissionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.reject(ctx, sub, &adminID, req.Reason, req.Notes); err != nil {
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+// ApplyProviderDecision 回写外部服务商异步给出的审核结论（供服务商回调接口使用）
+func (s *Service) ApplyProviderDecision(ctx context.Context, provider, reference string, result *ProviderResult) (*Submission, error) {
+	sub, err := s.repo.GetSubmissionByProviderReference(ctx, provider, reference)
+	if err != nil {
+		if err.Error() == "kyc submission not found" {
+			return nil, auth.ErrKYCSubmissionNotFound
+		}
+		return nil, err
+	}
+
+	return s.applyProviderResult(ctx, sub, result)
+}
+
+// applyProviderResult 按审核方的结论通过或拒绝申请
+func (s *Service) applyProviderResult(ctx context.Context, sub *Submission, result *ProviderResult) (*Submission, error) {
+	switch result.Decision {
+	case ProviderDecisionApproved:
+		tier := result.Tier
+		if tier == 0 {
+			tier = sub.RequestedTier
+		}
+		if err := s.approve(ctx, sub, tier, nil, nil); err != nil {
+			return nil, err
+		}
+	case ProviderDecisionRejected:
+		if err := s.reject(ctx, sub, nil, result.Reason, nil); err != nil {
+			return nil, err
+		}
+	}
+	return sub, nil
+}
+
+// approve 通过申请、更新用户等级、同步钱包限额并通知用户
+// reviewedBy为空表示由审核方自动通过
+func (s *Service) approve(ctx context.Context, sub *Submission, tier int, reviewedBy, notes *string) error {
+	if sub.Status != SubmissionStatusPending {
+		return auth.ErrKYCSubmissionReviewed
+	}
+	if tier < auth.KYCTierBasic || tier > sub.RequestedTier {
+		return auth.ErrInvalidKYCTier
+	}
+
+	userKYC, err := s.repo.ApproveSubmission(ctx, sub.ID, tier, reviewedBy, notes)
+	if err != nil {
+		if err.Error() == "kyc submission already reviewed" {
+			return auth.ErrKYCSubmissionReviewed
+		}
+		return err
+	}
+
+	now := time.Now()
+	sub.Status = SubmissionStatusApproved
+	sub.ApprovedTier = &tier
+	sub.ReviewedBy = reviewedBy
+	sub.ReviewedAt = &now
+	sub.ReviewNotes = notes
+
+	s.notifyTierChanged(ctx, sub.UserID, userKYC.Tier)
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":       sub.UserID,
+		"submission_id": sub.ID,
+		"approved_tier": tier,
+		"tier":          userKYC.Tier,
+		"reviewed_by":   stringValue(reviewedBy),
+	}).Info("KYC submission approved")
+
+	limits := s.LimitsForTier(userKYC.Tier)
+	s.sendUserMail(ctx, sub.UserID, "Your identity verification has been approved",
+		fmt.Sprintf("Hello,\n\nYour identity verification has been approved and your account is now verified at level %d.\n"+
+			"You can now withdraw up to %.2f TRU per day.\n", userKYC.Tier, limits.DailyWithdrawalLimit))
+
+	return nil
+}
+
+// reject 拒绝申请并通知用户原因
+// reviewedBy为空表示由审核方自动拒绝
+func (s *Service) reject(ctx context.Context, sub *Submission, reviewedBy *string, reason string, notes *string) error {
+	if sub.Status != SubmissionStatusPending {
+		return auth.ErrKYCSubmissionReviewed
+	}
+
+	if err := s.repo.RejectSubmission(ctx, sub.ID, reviewedBy, reason, notes); err != nil {
+		if err.Error() == "kyc submission already reviewed" {
+			return auth.ErrKYCSubmissionReviewed
+		}
+		return err
+	}
+
+	now := time.Now()
+	sub.Status = SubmissionStatusRejected
+	sub.ReviewedBy = reviewedBy
+	sub.ReviewedAt = &now
+	sub.RejectionReason = &reason
+	sub.ReviewNotes = notes
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":       sub.UserID,
+		"submission_id": sub.ID,
+		"reviewed_by":   stringValue(reviewedBy),
+	}).Info("KYC submission rejected")
+
+	s.sendUserMail(ctx, sub.UserID, "Your identity verification was not approved",
+		"Hello,\n\nWe could not approve your identity verification for the following reason:\n\n"+
+			reason+"\n\nYou can upload new documents and submit a new application in the app.\n")
+
+	return nil
+}
+
+// SetUserTier 管理员直接调整用户的KYC等级（可降级），同步钱包限额并通知用户
+func (s *Service) SetUserTier(ctx context.Context, userID, adminID string, req *SetUserTierRequest) (*UserKYCResponse, error) {
+	userKYC, err := s.repo.SetUserTier(ctx, userID, *req.Tier, adminID, req.Reason)
+	if err != nil {
+		if err.Error() == "user not found" {
+			return nil, auth.ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	s.notifyTierChanged(ctx, userID, userKYC.Tier)
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":  userID,
+		"tier":     userKYC.Tier,
+		"admin_id": adminID,
+		"reason":   req.Reason,
+	}).Info("User kyc tier changed by admin")
+
+	limits := s.LimitsForTier(userKYC.Tier)
+	body := fmt.Sprintf("Hello,\n\nYour account verification level has been changed to %d.\n", userKYC.Tier)
+	if limits.WithdrawalEnabled {
+		body += fmt.Sprintf("You can withdraw up to %.2f TRU per day.\n", limits.DailyWithdrawalLimit)
+	} else {
+		body += "Withdrawals are disabled until your identity is verified again.\n"
+	}
+	body += "If you have questions, please contact support.\n"
+	s.sendUserMail(ctx, userID, "Your account verification level has changed", body)
+
+	return &UserKYCResponse{UserKYC: userKYC, Limits: limits}, nil
+}
+
+// sendUserMail 向用户发送KYC通知邮件（失败只记录日志）
+func (s *Service) sendUserMail(ctx context.Context, userID, subject, body string) {
+	u, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		s.logger.WithError(err).WithField("user_id", userID).Error("Failed to get user for kyc notification")
+		return
+	}
+
+	if err := s.mailer.Send(ctx, &mailer.Message{
+		To:       []string{u.Email},
+		Subject:  subject,
+		Body:     body,
+		Language: u.Locale().LanguageTag(),
+	}); err != nil {
+		s.logger.WithError(err).WithField("user_id", userID).Error("Failed to send kyc notification")
+	}
+}
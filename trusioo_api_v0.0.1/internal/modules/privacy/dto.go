@@ -0,0 +1,61 @@
+package privacy
+
+import "time"
+
+// ========== 请求DTO ==========
+
+// DeleteAccountRequest 注销账户请求
+type DeleteAccountRequest struct {
+	Password string  `json:"password" binding:"required" example:"password123"`
+	Reason   *string `json:"reason" binding:"omitempty,max=500" example:"I no longer use this service"`
+}
+
+// RestoreAccountRequest 恢复账户请求（注销通知邮件中的恢复链接）
+type RestoreAccountRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// ========== 响应DTO ==========
+
+// DeleteAccountResponse 注销账户响应
+type DeleteAccountResponse struct {
+	Message      string    `json:"message" example:"Your account has been deleted"`
+	ScheduledFor time.Time `json:"scheduled_for"` // 个人数据匿名化时间，之前可恢复
+}
+
+// RestoreAccountResponse 恢复账户响应
+type RestoreAccountResponse struct {
+	Message string `json:"message" example:"Your account has been restored, you can sign in again"`
+}
+
+// DataExportResponse 数据导出任务响应
+type DataExportResponse struct {
+	ID          string           `json:"id"`
+	Status      DataExportStatus `json:"status" example:"pending"`
+	FileSize    *int64           `json:"file_size,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+	CompletedAt *time.Time       `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time       `json:"expires_at,omitempty"`
+	DownloadURL string           `json:"download_url,omitempty"`
+}
+
+// DataExportListResponse 数据导出任务列表响应
+type DataExportListResponse struct {
+	Exports []DataExportResponse `json:"exports"`
+}
+
+// ToResponse 转换为响应格式，可下载时附带下载地址
+func (j *DataExportJob) ToResponse() DataExportResponse {
+	resp := DataExportResponse{
+		ID:          j.ID,
+		Status:      j.Status,
+		FileSize:    j.FileSize,
+		CreatedAt:   j.CreatedAt,
+		CompletedAt: j.CompletedAt,
+		ExpiresAt:   j.ExpiresAt,
+	}
+	if j.IsDownloadable() {
+		resp.DownloadURL = "/api/v1/privacy/exports/" + j.ID + "/download"
+	}
+	return resp
+}
@@ -0,0 +1,122 @@
+package privacy
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// exportManifest 导出包说明文件
+type exportManifest struct {
+	JobID       string    `json:"job_id"`
+	UserID      string    `json:"user_id"`
+	GeneratedAt time.Time `json:"generated_at"`
+	Files       []string  `json:"files"`
+}
+
+// writeExportArchive 将收集到的个人数据写成ZIP包（每类数据一个JSON文件，附带manifest.json）
+// 先写入临时文件再重命名，避免下载到写了一半的文件
+func writeExportArchive(dir, jobID, userID string, data map[string]json.RawMessage) (string, int64, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", 0, fmt.Errorf("failed to create export directory: %w", err)
+	}
+
+	path := filepath.Join(dir, jobID+".zip")
+	tmpPath := path + ".tmp"
+
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create export file: %w", err)
+	}
+
+	if err := writeExportEntries(file, jobID, userID, data); err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return "", 0, err
+	}
+
+	if err := file.Close(); err != nil {
+		os.Remove(tmpPath)
+		return "", 0, fmt.Errorf("failed to close export file: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return "", 0, fmt.Errorf("failed to move export file: %w", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to stat export file: %w", err)
+	}
+
+	return path, info.Size(), nil
+}
+
+// writeExportEntries 写入ZIP包内容
+func writeExportEntries(file *os.File, jobID, userID string, data map[string]json.RawMessage) error {
+	archive := zip.NewWriter(file)
+
+	names := make([]string, 0, len(data))
+	for name := range data {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	manifest := exportManifest{
+		JobID:       jobID,
+		UserID:      userID,
+		GeneratedAt: time.Now().UTC(),
+		Files:       make([]string, 0, len(names)),
+	}
+
+	for _, name := range names {
+		var content bytes.Buffer
+		if err := json.Indent(&content, data[name], "", "  "); err != nil {
+			return fmt.Errorf("failed to format %s: %w", name, err)
+		}
+
+		fileName := name + ".json"
+		if err := writeZipEntry(archive, fileName, content.Bytes()); err != nil {
+			return err
+		}
+		manifest.Files = append(manifest.Files, fileName)
+	}
+
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal export manifest: %w", err)
+	}
+	if err := writeZipEntry(archive, "manifest.json", manifestJSON); err != nil {
+		return err
+	}
+
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("failed to finalize export archive: %w", err)
+	}
+
+	return nil
+}
+
+// writeZipEntry 向ZIP包写入一个文件
+func writeZipEntry(archive *zip.Writer, name string, content []byte) error {
+	writer, err := archive.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add %s to export archive: %w", name, err)
+	}
+
+	if _, err := writer.Write(content); err != nil {
+		return fmt.Errorf("failed to write %s to export archive: %w", name, err)
+	}
+
+	return nil
+}
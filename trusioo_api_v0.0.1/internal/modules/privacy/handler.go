@@ -0,0 +1,226 @@
+package privacy
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"trusioo_api_v0.0.1/internal/modules/auth"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// Handler 账户注销和数据导出处理器
+type Handler struct {
+	service *Service
+	logger  *logrus.Logger
+}
+
+// NewHandler 创建新的账户注销和数据导出处理器
+func NewHandler(service *Service, logger *logrus.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// DeleteAccount 注销当前账户（冷静期内可通过邮件中的链接恢复）
+func (h *Handler) DeleteAccount(c *gin.Context) {
+	claims, err := auth.GetCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
+		})
+		return
+	}
+
+	var req DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid delete account request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	deletion, err := h.service.RequestAccountDeletion(ctx, claims.UserID, req.Password, req.Reason, c.ClientIP())
+	if err != nil {
+		h.respondError(c, err, "Failed to delete account")
+		return
+	}
+
+	c.JSON(http.StatusOK, DeleteAccountResponse{
+		Message:      "Your account has been deleted. You can restore it with the link sent to your email before the scheduled date",
+		ScheduledFor: deletion.ScheduledFor,
+	})
+}
+
+// RestoreAccount 通过恢复链接恢复已注销的账户
+func (h *Handler) RestoreAccount(c *gin.Context) {
+	var req RestoreAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid restore account request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	if _, err := h.service.RestoreAccount(ctx, req.Token, c.ClientIP()); err != nil {
+		h.respondError(c, err, "Failed to restore account")
+		return
+	}
+
+	c.JSON(http.StatusOK, RestoreAccountResponse{
+		Message: "Your account has been restored, you can sign in again",
+	})
+}
+
+// RequestDataExport 申请导出个人数据
+func (h *Handler) RequestDataExport(c *gin.Context) {
+	claims, err := auth.GetCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	job, err := h.service.RequestDataExport(ctx, claims.UserID, c.ClientIP())
+	if err != nil {
+		h.respondError(c, err, "Failed to request data export")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job.ToResponse())
+}
+
+// ListDataExports 获取数据导出任务列表
+func (h *Handler) ListDataExports(c *gin.Context) {
+	claims, err := auth.GetCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	jobs, err := h.service.ListDataExports(ctx, claims.UserID)
+	if err != nil {
+		h.respondError(c, err, "Failed to get data exports")
+		return
+	}
+
+	exports := make([]DataExportResponse, 0, len(jobs))
+	for _, job := range jobs {
+		exports = append(exports, job.ToResponse())
+	}
+
+	c.JSON(http.StatusOK, DataExportListResponse{Exports: exports})
+}
+
+// DownloadDataExport 下载数据导出包
+func (h *Handler) DownloadDataExport(c *gin.Context) {
+	claims, err := auth.GetCurrentUser(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "Unauthorized",
+			"message": "Authentication required",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	jobID := c.Param("job_id")
+	if _, err := uuid.Parse(jobID); err != nil {
+		h.respondError(c, auth.ErrDataExportNotFound, "Failed to download data export")
+		return
+	}
+
+	job, err := h.service.GetDownloadableExport(ctx, claims.UserID, jobID)
+	if err != nil {
+		h.respondError(c, err, "Failed to download data export")
+		return
+	}
+
+	fileName := "trusioo-data-export-" + job.CreatedAt.UTC().Format("20060102") + ".zip"
+	c.FileAttachment(*job.FilePath, fileName)
+}
+
+// respondError 账户注销和数据导出的错误响应
+func (h *Handler) respondError(c *gin.Context, err error, title string) {
+	var lockedErr *auth.LoginLockedError
+	switch {
+	case errors.As(err, &lockedErr):
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(lockedErr.RetryAfter.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":   title,
+			"message": "Too many failed attempts, please try again later",
+		})
+	case errors.Is(err, auth.ErrInvalidCredentials):
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   title,
+			"message": "Invalid password",
+		})
+	case errors.Is(err, auth.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   title,
+			"message": "User not found",
+		})
+	case errors.Is(err, auth.ErrWithdrawalsInProgress):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   title,
+			"message": "You have withdrawals in progress, please wait until they are completed",
+		})
+	case errors.Is(err, auth.ErrAccountDeletionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Invalid link",
+			"message": "This link is invalid, expired or has already been used",
+		})
+	case errors.Is(err, auth.ErrDataExportTooFrequent):
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":   title,
+			"message": "An export is already in progress or was requested in the last 24 hours",
+		})
+	case errors.Is(err, auth.ErrDataExportNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   title,
+			"message": "Data export not found",
+		})
+	case errors.Is(err, auth.ErrDataExportNotReady):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   title,
+			"message": "This export is not ready yet or has expired",
+		})
+	default:
+		h.logger.WithError(err).Error(title)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal server error",
+			"message": title,
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package privacy
+
+import (
+	"time"
+)
+
+// AccountDeletion 账户注销记录（冷静期内可恢复，期满后匿名化个人数据）
+type AccountDeletion struct {
+	ID               string     `json:"id" db:"id"`
+	UserID           string     `json:"user_id" db:"user_id"`
+	RequestedBy      string     `json:"requested_by" db:"requested_by"` // user, admin
+	AdminID          *string    `json:"admin_id" db:"admin_id"`
+	Reason           *string    `json:"reason" db:"reason"`
+	RestoreTokenHash string     `json:"-" db:"restore_token_hash"` // 恢复链接令牌的哈希
+	ScheduledFor     time.Time  `json:"scheduled_for" db:"scheduled_for"`
+	RestoredAt       *time.Time `json:"restored_at" db:"restored_at"`
+	AnonymizedAt     *time.Time `json:"anonymized_at" db:"anonymized_at"`
+	IPAddress        *string    `json:"ip_address" db:"ip_address"`
+	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
+}
+
+// DataExportStatus 数据导出任务状态
+type DataExportStatus string
+
+const (
+	DataExportStatusPending    DataExportStatus = "pending"
+	DataExportStatusProcessing DataExportStatus = "processing"
+	DataExportStatusCompleted  DataExportStatus = "completed"
+	DataExportStatusFailed     DataExportStatus = "failed"
+	DataExportStatusExpired    DataExportStatus = "expired"
+)
+
+// DataExportJob 个人数据导出任务
+type DataExportJob struct {
+	ID           string           `json:"id" db:"id"`
+	UserID       string           `json:"user_id" db:"user_id"`
+	Status       DataExportStatus `json:"status" db:"status"`
+	FilePath     *string          `json:"-" db:"file_path"` // 服务端文件路径，不对外暴露
+	FileSize     *int64           `json:"file_size" db:"file_size"`
+	ErrorMessage *string          `json:"-" db:"error_message"`
+	IPAddress    *string          `json:"ip_address" db:"ip_address"`
+	StartedAt    *time.Time       `json:"started_at" db:"started_at"`
+	CompletedAt  *time.Time       `json:"completed_at" db:"completed_at"`
+	ExpiresAt    *time.Time       `json:"expires_at" db:"expires_at"`
+	CreatedAt    time.Time        `json:"created_at" db:"created_at"`
+}
+
+// IsDownloadable 导出文件是否可以下载
+func (j *DataExportJob) IsDownloadable() bool {
+	return j.Status == DataExportStatusCompleted && j.FilePath != nil &&
+		j.ExpiresAt != nil && time.Now().Before(*j.ExpiresAt)
+}
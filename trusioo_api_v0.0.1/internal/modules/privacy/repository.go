@@ -0,0 +1,654 @@
+package privacy
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"trusioo_api_v0.0.1/internal/infrastructure/database"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+// Repository 账户注销和数据导出仓储
+type Repository struct {
+	*database.BaseRepository
+	logger *logrus.Logger
+}
+
+// NewRepository 创建新的账户注销和数据导出仓储
+func NewRepository(db *database.Database, logger *logrus.Logger) *Repository {
+	return &Repository{
+		BaseRepository: database.NewBaseRepository(db, logger),
+		logger:         logger,
+	}
+}
+
+// ========== 账户注销相关方法 ==========
+
+// HasWithdrawalsInProgress 检查用户是否有未结束的提现申请
+func (r *Repository) HasWithdrawalsInProgress(ctx context.Context, userID string) (bool, error) {
+	query := `
+		SELECT EXISTS(
+			SELECT 1 FROM withdrawal_requests
+			WHERE user_id = $1 AND status IN ('pending', 'approved', 'processing')
+		)
+	`
+
+	var exists bool
+	if err := r.GetDB().QueryRowContext(ctx, query, userID).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check withdrawals: %w", err)
+	}
+
+	return exists, nil
+}
+
+// CreateDeletion 在同一事务中软删除用户、停用其会话并写入注销记录
+// 用户不存在或已被删除时返回"user not found"
+func (r *Repository) CreateDeletion(ctx context.Context, deletion *AccountDeletion) error {
+	deletion.ID = uuid.New().String()
+
+	err := r.GetDB().Transaction(func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, `
+			UPDATE users
+			SET deleted_at = NOW(), updated_at = NOW()
+			WHERE id = $1 AND deleted_at IS NULL
+		`, deletion.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to delete user: %w", err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rowsAffected == 0 {
+			return fmt.Errorf("user not found")
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE user_sessions SET is_active = false
+			WHERE user_id = $1 AND user_type = 'user' AND is_active = true
+		`, deletion.UserID); err != nil {
+			return fmt.Errorf("failed to deactivate sessions: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO account_deletions (
+				id, user_id, requested_by, admin_id, reason, restore_token_hash,
+				scheduled_for, ip_address, created_at
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW())
+		`, deletion.ID, deletion.UserID, deletion.RequestedBy, deletion.AdminID, deletion.Reason,
+			deletion.RestoreTokenHash, deletion.ScheduledFor, deletion.IPAddress)
+		if err != nil {
+			return fmt.Errorf("failed to create account deletion: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	deletion.CreatedAt = time.Now()
+	return nil
+}
+
+const accountDeletionColumns = `
+	id, user_id, requested_by, admin_id, reason, restore_token_hash, scheduled_for,
+	restored_at, anonymized_at, host(ip_address), created_at
+`
+
+// scanAccountDeletion 扫描账户注销记录
+func scanAccountDeletion(scanner interface{ Scan(...interface{}) error }) (*AccountDeletion, error) {
+	deletion := &AccountDeletion{}
+	var ipAddress sql.NullString
+	err := scanner.Scan(
+		&deletion.ID, &deletion.UserID, &deletion.RequestedBy, &deletion.AdminID, &deletion.Reason,
+		&deletion.RestoreTokenHash, &deletion.ScheduledFor, &deletion.RestoredAt, &deletion.AnonymizedAt,
+		&ipAddress, &deletion.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if ipAddress.Valid {
+		deletion.IPAddress = &ipAddress.String
+	}
+	return deletion, nil
+}
+
+// GetRestorableDeletionByTokenHash 根据恢复令牌哈希获取冷静期内、尚未恢复的注销记录
+func (r *Repository) GetRestorableDeletionByTokenHash(ctx context.Context, tokenHash string) (*AccountDeletion, error) {
+	query := `SELECT ` + accountDeletionColumns + `
+		FROM account_deletions
+		WHERE restore_token_hash = $1 AND restored_at IS NULL AND anonymized_at IS NULL
+		  AND scheduled_for > NOW()
+	`
+
+	deletion, err := scanAccountDeletion(r.GetDB().QueryRowContext(ctx, query, tokenHash))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("account deletion not found")
+		}
+		return nil, fmt.Errorf("failed to get account deletion: %w", err)
+	}
+
+	return deletion, nil
+}
+
+// GetRestorableDeletionByUserID 获取用户冷静期内、尚未恢复的注销记录
+func (r *Repository) GetRestorableDeletionByUserID(ctx context.Context, userID string) (*AccountDeletion, error) {
+	query := `SELECT ` + accountDeletionColumns + `
+		FROM account_deletions
+		WHERE user_id = $1 AND restored_at IS NULL AND anonymized_at IS NULL
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+
+	deletion, err := scanAccountDeletion(r.GetDB().QueryRowContext(ctx, query, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("account deletion not found")
+		}
+		return nil, fmt.Errorf("failed to get account deletion: %w", err)
+	}
+
+	return deletion, nil
+}
+
+// RestoreDeletion 恢复已注销但尚未匿名化的账户
+func (r *Repository) RestoreDeletion(ctx context.Context, deletion *AccountDeletion) error {
+	return r.GetDB().Transaction(func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, `
+			UPDATE account_deletions
+			SET restored_at = NOW()
+			WHERE id = $1 AND restored_at IS NULL AND anonymized_at IS NULL
+		`, deletion.ID)
+		if err != nil {
+			return fmt.Errorf("failed to restore account deletion: %w", err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rowsAffected == 0 {
+			return fmt.Errorf("account deletion not found")
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE users SET deleted_at = NULL, updated_at = NOW()
+			WHERE id = $1 AND deleted_at IS NOT NULL
+		`, deletion.UserID); err != nil {
+			return fmt.Errorf("failed to restore user: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// ListDueDeletions 获取冷静期已结束、等待匿名化的注销记录
+func (r *Repository) ListDueDeletions(ctx context.Context, limit int) ([]*AccountDeletion, error) {
+	query := `SELECT ` + accountDeletionColumns + `
+		FROM account_deletions
+		WHERE restored_at IS NULL AND anonymized_at IS NULL AND scheduled_for <= NOW()
+		ORDER BY scheduled_for
+		LIMIT $1
+	`
+
+	rows, err := r.GetDB().QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due account deletions: %w", err)
+	}
+	defer rows.Close()
+
+	var deletions []*AccountDeletion
+	for rows.Next() {
+		deletion, err := scanAccountDeletion(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan account deletion: %w", err)
+		}
+		deletions = append(deletions, deletion)
+	}
+
+	return deletions, rows.Err()
+}
+
+// AnonymizeUser 匿名化已注销用户的个人数据，返回需要删除的导出文件、KYC证件文件和头像文件路径
+// 钱包流水、提现申请（金额、银行信息）作为财务审计记录保留，只移除其中的联系方式和设备信息；
+// KYC认证申请保留审核结果，清除申请人信息并删除证件文件
+func (r *Repository) AnonymizeUser(ctx context.Context, deletion *AccountDeletion, anonymizedEmail, anonymizedName, unusablePassword string) ([]string, error) {
+	var exportFiles []string
+
+	err := r.GetDB().Transaction(func(tx *sql.Tx) error {
+		// 先标记匿名化，避免与恢复操作并发
+		result, err := tx.ExecContext(ctx, `
+			UPDATE account_deletions SET anonymized_at = NOW()
+			WHERE id = $1 AND restored_at IS NULL AND anonymized_at IS NULL
+		`, deletion.ID)
+		if err != nil {
+			return fmt.Errorf("failed to mark account deletion: %w", err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rowsAffected == 0 {
+			return fmt.Errorf("account deletion not found")
+		}
+
+		// 收集该用户用过的所有邮箱，清理按邮箱关联的记录
+		var emails []string
+		rows, err := tx.QueryContext(ctx, `
+			SELECT email FROM users WHERE id = $1
+			UNION SELECT old_email FROM email_changes WHERE user_id = $1
+			UNION SELECT new_email FROM email_changes WHERE user_id = $1
+		`, deletion.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to collect user emails: %w", err)
+		}
+		for rows.Next() {
+			var email string
+			if err := rows.Scan(&email); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan user email: %w", err)
+			}
+			emails = append(emails, email)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("failed to collect user emails: %w", err)
+		}
+
+		rows, err = tx.QueryContext(ctx, `
+			SELECT file_path FROM data_export_jobs WHERE user_id = $1 AND file_path IS NOT NULL
+			UNION ALL SELECT file_path FROM kyc_documents WHERE user_id = $1
+			UNION ALL SELECT avatar_path FROM users WHERE id = $1 AND avatar_path IS NOT NULL
+		`, deletion.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to collect export files: %w", err)
+		}
+		for rows.Next() {
+			var path string
+			if err := rows.Scan(&path); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan export file: %w", err)
+			}
+			exportFiles = append(exportFiles, path)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("failed to collect export files: %w", err)
+		}
+
+		statements := []struct {
+			query string
+			args  []interface{}
+		}{
+			// 用户资料
+			{`UPDATE users
+			  SET email = $2, name = $3, password = $4, email_verified = false, email_verified_at = NULL,
+			      avatar_path = NULL, avatar_updated_at = NULL, phone_number = NULL, phone_verified_at = NULL,
+			      status = 'inactive', updated_at = NOW()
+			  WHERE id = $1`,
+				[]interface{}{deletion.UserID, anonymizedEmail, anonymizedName, unusablePassword}},
+			// 认证相关数据（删除refresh_tokens会级联删除关联的会话）
+			{`DELETE FROM user_sessions WHERE user_id = $1 AND user_type = 'user'`, []interface{}{deletion.UserID}},
+			{`DELETE FROM refresh_tokens WHERE user_id = $1 AND user_type = 'user'`, []interface{}{deletion.UserID}},
+			{`DELETE FROM oauth_tokens WHERE user_id = $1 AND user_type = 'user'`, []interface{}{deletion.UserID}},
+			{`DELETE FROM password_history WHERE user_id = $1 AND user_type = 'user'`, []interface{}{deletion.UserID}},
+			{`DELETE FROM login_alerts WHERE user_id = $1`, []interface{}{deletion.UserID}},
+			{`DELETE FROM email_changes WHERE user_id = $1`, []interface{}{deletion.UserID}},
+			{`DELETE FROM email_verifications WHERE user_type = 'user' AND email = ANY($1)`, []interface{}{pq.Array(emails)}},
+			{`DELETE FROM email_verifications WHERE user_type = 'user' AND type = 'phone_verification' AND reference_id = $1`, []interface{}{deletion.UserID}},
+			{`DELETE FROM password_resets WHERE user_type = 'user' AND email = ANY($1)`, []interface{}{pq.Array(emails)}},
+			// 登录日志保留安全统计字段，移除邮箱、IP和设备信息
+			{`UPDATE login_logs
+			  SET email = $2, ip_address = '0.0.0.0', user_agent = NULL,
+			      device_info = NULL, location_info = NULL
+			  WHERE user_type = 'user' AND (user_id = $1 OR email = ANY($3))`,
+				[]interface{}{deletion.UserID, anonymizedEmail, pq.Array(emails)}},
+			// 财务记录保留，只移除联系方式和设备信息
+			{`UPDATE withdrawal_requests
+			  SET user_email = $2, ip_address = NULL, user_agent = NULL, updated_at = NOW()
+			  WHERE user_id = $1`,
+				[]interface{}{deletion.UserID, anonymizedEmail}},
+			{`UPDATE user_bank_accounts
+			  SET status = 'inactive', is_default = false, updated_at = NOW()
+			  WHERE user_id = $1`,
+				[]interface{}{deletion.UserID}},
+			{`UPDATE wallets
+			  SET transaction_pin_hash = NULL, is_withdrawal_enabled = false, updated_at = NOW()
+			  WHERE user_id = $1`,
+				[]interface{}{deletion.UserID}},
+			// 管理员对该用户的备注、标签和关注一并删除，关注通知只保留事件类型和时间
+			{`DELETE FROM user_notes WHERE user_id = $1`, []interface{}{deletion.UserID}},
+			{`DELETE FROM user_tags WHERE user_id = $1`, []interface{}{deletion.UserID}},
+			{`DELETE FROM user_watches WHERE user_id = $1`, []interface{}{deletion.UserID}},
+			{`UPDATE user_watch_notifications
+			  SET user_email = $2, details = NULL, ip_address = NULL
+			  WHERE user_id = $1`,
+				[]interface{}{deletion.UserID, anonymizedEmail}},
+			// 管理操作审计日志保留，只替换目标邮箱（哈希链只包含邮箱的HMAC，details中不记录用户邮箱）
+			{`UPDATE user_management_logs SET target_email = $2 WHERE target_user_id = $1`,
+				[]interface{}{deletion.UserID, anonymizedEmail}},
+			// 模拟登录记录作为管理员审计保留，只替换用户邮箱
+			{`UPDATE user_impersonations SET user_email = $2 WHERE user_id = $1`,
+				[]interface{}{deletion.UserID, anonymizedEmail}},
+			// 导出文件随账户一起删除
+			{`DELETE FROM data_export_jobs WHERE user_id = $1`, []interface{}{deletion.UserID}},
+			// KYC证件文件删除，认证申请只保留审核结果
+			{`DELETE FROM kyc_documents WHERE user_id = $1`, []interface{}{deletion.UserID}},
+			{`UPDATE kyc_submissions
+			  SET first_name = '', last_name = '', date_of_birth = NULL, document_number = '',
+			      address_line1 = NULL, address_line2 = NULL, city = NULL, postal_code = NULL,
+			      ip_address = NULL, updated_at = NOW()
+			  WHERE user_id = $1`,
+				[]interface{}{deletion.UserID}},
+			// 认证资料已清除，等级一并清零（提现按等级检查）
+			{`UPDATE user_kyc SET tier = 0, updated_at = NOW() WHERE user_id = $1`, []interface{}{deletion.UserID}},
+		}
+
+		for _, stmt := range statements {
+			if _, err := tx.ExecContext(ctx, stmt.query, stmt.args...); err != nil {
+				return fmt.Errorf("failed to anonymize user data: %w", err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return exportFiles, nil
+}
+
+// ========== 数据导出相关方法 ==========
+
+const dataExportJobColumns = `
+	id, user_id, status, file_path, file_size, error_message, host(ip_address),
+	started_at, completed_at, expires_at, created_at
+`
+
+// scanDataExportJob 扫描数据导出任务
+func scanDataExportJob(scanner interface{ Scan(...interface{}) error }) (*DataExportJob, error) {
+	job := &DataExportJob{}
+	var ipAddress sql.NullString
+	err := scanner.Scan(
+		&job.ID, &job.UserID, &job.Status, &job.FilePath, &job.FileSize, &job.ErrorMessage, &ipAddress,
+		&job.StartedAt, &job.CompletedAt, &job.ExpiresAt, &job.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if ipAddress.Valid {
+		job.IPAddress = &ipAddress.String
+	}
+	return job, nil
+}
+
+// CreateExportJob 创建数据导出任务
+func (r *Repository) CreateExportJob(ctx context.Context, job *DataExportJob) error {
+	job.ID = uuid.New().String()
+	job.Status = DataExportStatusPending
+
+	query := `
+		INSERT INTO data_export_jobs (id, user_id, status, ip_address, created_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		RETURNING created_at
+	`
+
+	if err := r.GetDB().QueryRowContext(ctx, query, job.ID, job.UserID, job.Status, job.IPAddress).Scan(&job.CreatedAt); err != nil {
+		return fmt.Errorf("failed to create data export job: %w", err)
+	}
+
+	return nil
+}
+
+// GetLatestExportJob 获取用户最近一次数据导出任务，没有时返回nil
+func (r *Repository) GetLatestExportJob(ctx context.Context, userID string) (*DataExportJob, error) {
+	query := `SELECT ` + dataExportJobColumns + `
+		FROM data_export_jobs
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+
+	job, err := scanDataExportJob(r.GetDB().QueryRowContext(ctx, query, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get latest data export job: %w", err)
+	}
+
+	return job, nil
+}
+
+// ListExportJobs 获取用户的数据导出任务
+func (r *Repository) ListExportJobs(ctx context.Context, userID string, limit int) ([]*DataExportJob, error) {
+	query := `SELECT ` + dataExportJobColumns + `
+		FROM data_export_jobs
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`
+
+	rows, err := r.GetDB().QueryContext(ctx, query, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list data export jobs: %w", err)
+	}
+	defer rows.Close()
+
+	jobs := make([]*DataExportJob, 0)
+	for rows.Next() {
+		job, err := scanDataExportJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan data export job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, rows.Err()
+}
+
+// GetExportJob 获取用户的指定数据导出任务
+func (r *Repository) GetExportJob(ctx context.Context, userID, jobID string) (*DataExportJob, error) {
+	query := `SELECT ` + dataExportJobColumns + `
+		FROM data_export_jobs
+		WHERE id = $1 AND user_id = $2
+	`
+
+	job, err := scanDataExportJob(r.GetDB().QueryRowContext(ctx, query, jobID, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("data export job not found")
+		}
+		return nil, fmt.Errorf("failed to get data export job: %w", err)
+	}
+
+	return job, nil
+}
+
+// ClaimPendingExportJob 领取一个待处理的导出任务（处理超时的任务会被重新领取），没有时返回nil
+func (r *Repository) ClaimPendingExportJob(ctx context.Context, staleAfter time.Duration) (*DataExportJob, error) {
+	query := `
+		UPDATE data_export_jobs
+		SET status = 'processing', started_at = NOW()
+		WHERE id = (
+			SELECT id FROM data_export_jobs
+			WHERE status = 'pending' OR (status = 'processing' AND started_at < $1)
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + dataExportJobColumns
+
+	job, err := scanDataExportJob(r.GetDB().QueryRowContext(ctx, query, time.Now().Add(-staleAfter)))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to claim data export job: %w", err)
+	}
+
+	return job, nil
+}
+
+// CompleteExportJob 标记导出任务完成
+func (r *Repository) CompleteExportJob(ctx context.Context, jobID, filePath string, fileSize int64, expiresAt time.Time) error {
+	query := `
+		UPDATE data_export_jobs
+		SET status = 'completed', file_path = $2, file_size = $3, expires_at = $4,
+		    error_message = NULL, completed_at = NOW()
+		WHERE id = $1
+	`
+
+	if _, err := r.GetDB().ExecContext(ctx, query, jobID, filePath, fileSize, expiresAt); err != nil {
+		return fmt.Errorf("failed to complete data export job: %w", err)
+	}
+
+	return nil
+}
+
+// FailExportJob 标记导出任务失败
+func (r *Repository) FailExportJob(ctx context.Context, jobID, message string) error {
+	query := `
+		UPDATE data_export_jobs
+		SET status = 'failed', error_message = $2, completed_at = NOW()
+		WHERE id = $1
+	`
+
+	if _, err := r.GetDB().ExecContext(ctx, query, jobID, message); err != nil {
+		return fmt.Errorf("failed to mark data export job as failed: %w", err)
+	}
+
+	return nil
+}
+
+// ExpireExportJobs 将过期的导出任务标记为已过期，返回需要删除的文件路径
+func (r *Repository) ExpireExportJobs(ctx context.Context) ([]string, error) {
+	query := `
+		UPDATE data_export_jobs j
+		SET status = 'expired', file_path = NULL
+		FROM data_export_jobs old
+		WHERE j.id = old.id AND j.status = 'completed' AND j.expires_at <= NOW()
+		RETURNING old.file_path
+	`
+
+	rows, err := r.GetDB().QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expire data export jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var paths []string
+	for rows.Next() {
+		var path sql.NullString
+		if err := rows.Scan(&path); err != nil {
+			return nil, fmt.Errorf("failed to scan expired export file: %w", err)
+		}
+		if path.Valid {
+			paths = append(paths, path.String)
+		}
+	}
+
+	return paths, rows.Err()
+}
+
+// exportSection 导出包中的一个数据文件及其查询（查询返回单个JSON值）
+type exportSection struct {
+	name  string
+	query string
+}
+
+// exportSections 导出包包含的数据，敏感字段（密码哈希、交易密码、令牌）不导出
+var exportSections = []exportSection{
+	{"profile", `
+		SELECT row_to_json(t) FROM (
+			SELECT id, email, name, status, email_verified, email_verified_at, language, timezone,
+			       phone_number, phone_verified_at, avatar_updated_at, created_at, updated_at
+			FROM users WHERE id = $1
+		) t`},
+	{"sessions", `
+		SELECT COALESCE(json_agg(t ORDER BY t.created_at DESC), '[]'::json) FROM (
+			SELECT session_id, host(ip_address) AS ip_address, user_agent, device_info, location_info,
+			       is_active, last_activity, expires_at, created_at
+			FROM user_sessions WHERE user_id = $1 AND user_type = 'user'
+		) t`},
+	{"login_logs", `
+		SELECT COALESCE(json_agg(t ORDER BY t.created_at DESC), '[]'::json) FROM (
+			SELECT login_status, failure_reason, host(ip_address) AS ip_address, user_agent, device_info,
+			       location_info, session_id, risk_score, created_at
+			FROM login_logs WHERE user_id = $1 AND user_type = 'user'
+		) t`},
+	{"email_changes", `
+		SELECT COALESCE(json_agg(t ORDER BY t.created_at DESC), '[]'::json) FROM (
+			SELECT old_email, new_email, changed_by, reverted_at, created_at
+			FROM email_changes WHERE user_id = $1
+		) t`},
+	{"bank_accounts", `
+		SELECT COALESCE(json_agg(t ORDER BY t.created_at), '[]'::json) FROM (
+			SELECT a.id, b.name AS bank_name, a.account_number, a.account_name, a.account_type,
+			       a.sort_code, a.iban, a.bic_code, a.status, a.is_default, a.is_verified,
+			       a.verified_at, a.last_used_at, a.created_at, a.updated_at
+			FROM user_bank_accounts a
+			LEFT JOIN banks b ON b.id = a.bank_id
+			WHERE a.user_id = $1
+		) t`},
+	{"wallet", `
+		SELECT row_to_json(t) FROM (
+			SELECT id, balance, frozen_balance, status, is_withdrawal_enabled, daily_withdrawal_limit,
+			       total_deposited, total_withdrawn, withdrawal_count, last_transaction_at, created_at, updated_at
+			FROM wallets WHERE user_id = $1
+		) t`},
+	{"kyc_submissions", `
+		SELECT COALESCE(json_agg(t ORDER BY t.created_at), '[]'::json) FROM (
+			SELECT s.id, s.requested_tier, s.approved_tier, s.status, s.first_name, s.last_name,
+			       s.date_of_birth, s.nationality, s.document_type, s.document_number, s.address_line1,
+			       s.address_line2, s.city, s.postal_code, s.country, s.rejection_reason, s.reviewed_at,
+			       s.created_at,
+			       (SELECT COALESCE(json_agg(json_build_object(
+			           'kind', d.kind, 'content_type', d.content_type, 'file_size', d.file_size,
+			           'sha256', d.sha256, 'created_at', d.created_at) ORDER BY d.created_at), '[]'::json)
+			        FROM kyc_documents d WHERE d.submission_id = s.id) AS documents
+			FROM kyc_submissions s WHERE s.user_id = $1
+		) t`},
+	{"transactions", `
+		SELECT COALESCE(json_agg(t ORDER BY t.created_at), '[]'::json) FROM (
+			SELECT id, type, status, amount, fee, net_amount, balance_before, balance_after,
+			       exchange_rate, original_amount, reference_id, reference_type, description,
+			       processed_at, created_at
+			FROM wallet_transactions WHERE user_id = $1
+		) t`},
+	{"withdrawals", `
+		SELECT COALESCE(json_agg(t ORDER BY t.created_at), '[]'::json) FROM (
+			SELECT id, amount_tru, amount_local, exchange_rate, fee_tru, net_amount_tru, status,
+			       bank_name, account_number, account_name, rejection_reason, failure_reason,
+			       transaction_reference, completed_at, created_at
+			FROM withdrawal_requests WHERE user_id = $1
+		) t`},
+}
+
+// CollectUserData 收集用户的个人数据，按导出文件名返回JSON
+func (r *Repository) CollectUserData(ctx context.Context, userID string) (map[string]json.RawMessage, error) {
+	data := make(map[string]json.RawMessage, len(exportSections))
+
+	for _, section := range exportSections {
+		var raw []byte
+		err := r.GetDB().QueryRowContext(ctx, section.query, userID).Scan(&raw)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, fmt.Errorf("failed to collect %s: %w", section.name, err)
+		}
+		if len(raw) == 0 {
+			raw = []byte("null")
+		}
+		data[section.name] = raw
+	}
+
+	return data, nil
+}
@@ -0,0 +1,44 @@
+package privacy
+
+import (
+	"trusioo_api_v0.0.1/internal/modules/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Routes 账户注销和数据导出路由
+type Routes struct {
+	handler    *Handler
+	authMiddle *auth.AuthMiddleware
+}
+
+// NewRoutes 创建新的账户注销和数据导出路由
+func NewRoutes(handler *Handler, authMiddle *auth.AuthMiddleware) *Routes {
+	return &Routes{
+		handler:    handler,
+		authMiddle: authMiddle,
+	}
+}
+
+// RegisterRoutes 注册账户注销和数据导出路由
+func (r *Routes) RegisterRoutes(router *gin.RouterGroup) {
+	privacy := router.Group("/privacy")
+	{
+		// 恢复已注销账户（注销通知邮件中的恢复链接）
+		privacy.POST("/account/restore", r.handler.RestoreAccount)
+
+		// 需要用户认证的路由
+		authenticated := privacy.Group("")
+		authenticated.Use(r.authMiddle.RequireAuth())
+		authenticated.Use(r.authMiddle.RequireUserType("user"))
+		{
+			// 注销账户（需确认密码）
+			authenticated.POST("/account/delete", r.handler.DeleteAccount)
+
+			// 个人数据导出（异步生成ZIP包）
+			authenticated.POST("/exports", r.handler.RequestDataExport)
+			authenticated.GET("/exports", r.handler.ListDataExports)
+			authenticated.GET("/exports/:job_id/download", r.handler.DownloadDataExport)
+		}
+	}
+}
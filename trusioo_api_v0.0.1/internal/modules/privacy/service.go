@@ -0,0 +1,435 @@
+package privacy
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"trusioo_api_v0.0.1/internal/config"
+	"trusioo_api_v0.0.1/internal/infrastructure/mailer"
+	"trusioo_api_v0.0.1/internal/modules/auth"
+	"trusioo_api_v0.0.1/internal/modules/auth/user"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// exportRequestInterval 两次数据导出申请的最小间隔
+	exportRequestInterval = 24 * time.Hour
+	// exportStaleAfter 处理中的导出任务超过该时长视为中断，会被重新领取
+	exportStaleAfter = time.Hour
+	// exportListLimit 导出任务列表返回的最大条数
+	exportListLimit = 20
+	// anonymizeBatchSize 每轮匿名化处理的账户数
+	anonymizeBatchSize = 50
+
+	anonymizedName     = "Deleted User"
+	anonymizedPassword = "!" // 不是任何算法的合法哈希，无法通过密码校验
+)
+
+// Service 账户注销和个人数据导出服务
+type Service struct {
+	repo        *Repository
+	userRepo    *user.Repository
+	userService *user.Service
+	verifyRepo  *user.VerificationRepository // 复用带密钥的令牌哈希
+	jwtManager  *auth.JWTManager
+	mailer      mailer.Mailer
+	config      *config.PrivacyConfig
+	logger      *logrus.Logger
+	wake        chan struct{}
+}
+
+// NewService 创建新的账户注销和数据导出服务
+func NewService(repo *Repository, userRepo *user.Repository, userService *user.Service, verifyRepo *user.VerificationRepository, jwtManager *auth.JWTManager, mailSender mailer.Mailer, cfg *config.PrivacyConfig, logger *logrus.Logger) *Service {
+	return &Service{
+		repo:        repo,
+		userRepo:    userRepo,
+		userService: userService,
+		verifyRepo:  verifyRepo,
+		jwtManager:  jwtManager,
+		mailer:      mailSender,
+		config:      cfg,
+		logger:      logger,
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// ========== 账户注销 ==========
+
+// RequestAccountDeletion 用户注销账户：校验当前密码后软删除账户，冷静期内可通过邮件中的链接恢复
+func (s *Service) RequestAccountDeletion(ctx context.Context, userID, password string, reason *string, ipAddress string) (*AccountDeletion, error) {
+	u, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if err.Error() == "user not found" {
+			return nil, auth.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	// 确认密码（与登录共用失败锁定）
+	if _, err := s.userService.ValidateCredentials(ctx, u.Email, password, ipAddress); err != nil {
+		return nil, err
+	}
+
+	return s.scheduleDeletion(ctx, u, nil, reason, ipAddress)
+}
+
+// DeleteUserByAdmin 管理员注销用户账户，同样进入冷静期
+func (s *Service) DeleteUserByAdmin(ctx context.Context, userID, adminID string, reason *string, ipAddress string) (*AccountDeletion, error) {
+	u, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if err.Error() == "user not found" {
+			return nil, auth.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return s.scheduleDeletion(ctx, u, &adminID, reason, ipAddress)
+}
+
+// scheduleDeletion 软删除账户、撤销全部令牌并向用户发送恢复链接
+func (s *Service) scheduleDeletion(ctx context.Context, u *user.User, adminID, reason *string, ipAddress string) (*AccountDeletion, error) {
+	// 提现处理中的账户不能注销，避免资金流转中途失去联系方式
+	inProgress, err := s.repo.HasWithdrawalsInProgress(ctx, u.ID)
+	if err != nil {
+		return nil, err
+	}
+	if inProgress {
+		return nil, auth.ErrWithdrawalsInProgress
+	}
+
+	token, err := generateRestoreToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate restore token: %w", err)
+	}
+
+	deletion := &AccountDeletion{
+		UserID:           u.ID,
+		RequestedBy:      "user",
+		AdminID:          adminID,
+		Reason:           reason,
+		RestoreTokenHash: s.verifyRepo.HashToken(token),
+		ScheduledFor:     time.Now().Add(s.config.DeletionGracePeriod),
+		IPAddress:        &ipAddress,
+	}
+	if adminID != nil {
+		deletion.RequestedBy = "admin"
+	}
+
+	if err := s.repo.CreateDeletion(ctx, deletion); err != nil {
+		if err.Error() == "user not found" {
+			return nil, auth.ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	if err := s.jwtManager.RevokeAllUserTokens(ctx, u.ID, "user"); err != nil {
+		s.logger.WithError(err).WithField("user_id", u.ID).Error("Failed to revoke tokens of deleted user")
+	}
+
+	// 发送恢复链接（失败只记录日志，账户已经注销）
+	if err := s.sendRestoreLink(ctx, u.Email, u.Locale(), deletion, token); err != nil {
+		s.logger.WithError(err).WithField("user_id", u.ID).Error("Failed to send account restore link")
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":       u.ID,
+		"deletion_id":   deletion.ID,
+		"requested_by":  deletion.RequestedBy,
+		"scheduled_for": deletion.ScheduledFor,
+	}).Info("User account deletion scheduled")
+
+	return deletion, nil
+}
+
+// RestoreAccount 通过恢复链接恢复冷静期内的账户
+func (s *Service) RestoreAccount(ctx context.Context, token, ipAddress string) (*AccountDeletion, error) {
+	deletion, err := s.repo.GetRestorableDeletionByTokenHash(ctx, s.verifyRepo.HashToken(token))
+	if err != nil {
+		if err.Error() == "account deletion not found" {
+			return nil, auth.ErrAccountDeletionNotFound
+		}
+		return nil, err
+	}
+
+	if err := s.restore(ctx, deletion); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":     deletion.UserID,
+		"deletion_id": deletion.ID,
+		"ip_address":  ipAddress,
+	}).Info("User account restored by restore link")
+
+	return deletion, nil
+}
+
+// RestoreUserByAdmin 管理员恢复尚未匿名化的已注销账户
+func (s *Service) RestoreUserByAdmin(ctx context.Context, userID, adminID string) (*AccountDeletion, error) {
+	deletion, err := s.repo.GetRestorableDeletionByUserID(ctx, userID)
+	if err != nil {
+		if err.Error() == "account deletion not found" {
+			return nil, auth.ErrAccountDeletionNotFound
+		}
+		return nil, err
+	}
+
+	if err := s.restore(ctx, deletion); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":     deletion.UserID,
+		"deletion_id": deletion.ID,
+		"admin_id":    adminID,
+	}).Info("User account restored by admin")
+
+	return deletion, nil
+}
+
+// restore 恢复注销记录对应的账户
+func (s *Service) restore(ctx context.Context, deletion *AccountDeletion) error {
+	if err := s.repo.RestoreDeletion(ctx, deletion); err != nil {
+		if err.Error() == "account deletion not found" {
+			return auth.ErrAccountDeletionNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// sendRestoreLink 发送账户注销通知及恢复链接（匿名化时间按用户时区显示）
+func (s *Service) sendRestoreLink(ctx context.Context, email string, locale *auth.UserLocale, deletion *AccountDeletion, token string) error {
+	link := s.config.RestoreURL + "?token=" + url.QueryEscape(token)
+
+	var body strings.Builder
+	body.WriteString("Hello,\n\n")
+	if deletion.RequestedBy == "admin" {
+		body.WriteString("An administrator has deleted your Trusioo account.\n")
+	} else {
+		body.WriteString("Your Trusioo account has been deleted as you requested.\n")
+	}
+	body.WriteString("Your personal data will be permanently anonymised on " + locale.FormatTime(deletion.ScheduledFor) + ".\n")
+	body.WriteString("Records of past transactions and withdrawals are kept as required for financial audits.\n\n")
+	body.WriteString("If you change your mind, use the link below before then to restore your account:\n")
+	body.WriteString(link + "\n")
+
+	if err := s.mailer.Send(ctx, &mailer.Message{
+		To:       []string{email},
+		Subject:  "Your Trusioo account has been deleted",
+		Body:     body.String(),
+		Language: locale.LanguageTag(),
+	}); err != nil {
+		return fmt.Errorf("failed to send account restore link: %w", err)
+	}
+
+	return nil
+}
+
+// generateRestoreToken 生成URL安全的账户恢复令牌
+func generateRestoreToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// ========== 个人数据导出 ==========
+
+// RequestDataExport 申请导出个人数据，由后台任务异步生成ZIP包
+func (s *Service) RequestDataExport(ctx context.Context, userID, ipAddress string) (*DataExportJob, error) {
+	latest, err := s.repo.GetLatestExportJob(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if latest != nil {
+		switch latest.Status {
+		case DataExportStatusPending, DataExportStatusProcessing:
+			return nil, auth.ErrDataExportTooFrequent
+		case DataExportStatusFailed:
+			// 失败的任务允许立即重新申请
+		default:
+			if time.Since(latest.CreatedAt) < exportRequestInterval {
+				return nil, auth.ErrDataExportTooFrequent
+			}
+		}
+	}
+
+	job := &DataExportJob{
+		UserID:    userID,
+		IPAddress: &ipAddress,
+	}
+	if err := s.repo.CreateExportJob(ctx, job); err != nil {
+		return nil, err
+	}
+
+	// 唤醒后台任务尽快处理
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id": userID,
+		"job_id":  job.ID,
+	}).Info("Data export requested")
+
+	return job, nil
+}
+
+// ListDataExports 获取用户最近的数据导出任务
+func (s *Service) ListDataExports(ctx context.Context, userID string) ([]*DataExportJob, error) {
+	return s.repo.ListExportJobs(ctx, userID, exportListLimit)
+}
+
+// GetDownloadableExport 获取可下载的导出任务
+func (s *Service) GetDownloadableExport(ctx context.Context, userID, jobID string) (*DataExportJob, error) {
+	job, err := s.repo.GetExportJob(ctx, userID, jobID)
+	if err != nil {
+		if err.Error() == "data export job not found" {
+			return nil, auth.ErrDataExportNotFound
+		}
+		return nil, err
+	}
+
+	if !job.IsDownloadable() {
+		return nil, auth.ErrDataExportNotReady
+	}
+
+	return job, nil
+}
+
+// ========== 后台任务 ==========
+
+// Start 启动后台任务：生成数据导出包、清理过期导出文件、匿名化冷静期已结束的账户
+func (s *Service) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(s.config.WorkerInterval)
+		defer ticker.Stop()
+
+		s.runOnce(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.runOnce(ctx)
+			case <-s.wake:
+				s.processExports(ctx)
+			}
+		}
+	}()
+}
+
+// runOnce 执行一轮后台任务
+func (s *Service) runOnce(ctx context.Context) {
+	s.processExports(ctx)
+	s.expireExports(ctx)
+	s.anonymizeDueAccounts(ctx)
+}
+
+// processExports 处理全部待处理的导出任务
+func (s *Service) processExports(ctx context.Context) {
+	for ctx.Err() == nil {
+		runCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+		job, err := s.repo.ClaimPendingExportJob(runCtx, exportStaleAfter)
+		if err != nil {
+			cancel()
+			s.logger.WithError(err).Error("Failed to claim data export job")
+			return
+		}
+		if job == nil {
+			cancel()
+			return
+		}
+
+		s.processExport(runCtx, job)
+		cancel()
+	}
+}
+
+// processExport 生成单个导出包
+func (s *Service) processExport(ctx context.Context, job *DataExportJob) {
+	logger := s.logger.WithFields(logrus.Fields{"job_id": job.ID, "user_id": job.UserID})
+
+	data, err := s.repo.CollectUserData(ctx, job.UserID)
+	if err == nil {
+		var path string
+		var size int64
+		path, size, err = writeExportArchive(s.config.ExportDir, job.ID, job.UserID, data)
+		if err == nil {
+			if err = s.repo.CompleteExportJob(ctx, job.ID, path, size, time.Now().Add(s.config.ExportTTL)); err != nil {
+				os.Remove(path)
+			}
+		}
+	}
+
+	if err != nil {
+		logger.WithError(err).Error("Failed to build data export")
+		if failErr := s.repo.FailExportJob(ctx, job.ID, err.Error()); failErr != nil {
+			logger.WithError(failErr).Error("Failed to mark data export job as failed")
+		}
+		return
+	}
+
+	logger.Info("Data export completed")
+}
+
+// expireExports 删除过期的导出文件
+func (s *Service) expireExports(ctx context.Context) {
+	runCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	paths, err := s.repo.ExpireExportJobs(runCtx)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to expire data exports")
+		return
+	}
+	s.removeFiles(paths)
+}
+
+// anonymizeDueAccounts 匿名化冷静期已结束的账户
+func (s *Service) anonymizeDueAccounts(ctx context.Context) {
+	runCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+
+	deletions, err := s.repo.ListDueDeletions(runCtx, anonymizeBatchSize)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to list due account deletions")
+		return
+	}
+
+	for _, deletion := range deletions {
+		anonymizedEmail := "deleted-" + deletion.UserID + "@anonymized.invalid"
+		paths, err := s.repo.AnonymizeUser(runCtx, deletion, anonymizedEmail, anonymizedName, anonymizedPassword)
+		if err != nil {
+			if err.Error() != "account deletion not found" {
+				s.logger.WithError(err).WithField("user_id", deletion.UserID).Error("Failed to anonymize deleted user")
+			}
+			continue
+		}
+		s.removeFiles(paths)
+
+		s.logger.WithFields(logrus.Fields{
+			"user_id":     deletion.UserID,
+			"deletion_id": deletion.ID,
+		}).Info("Deleted user anonymized")
+	}
+}
+
+// removeFiles 删除导出文件、KYC证件文件和头像文件（文件已不存在时忽略）
+func (s *Service) removeFiles(paths []string) {
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			s.logger.WithError(err).WithField("path", path).Warn("Failed to remove user file")
+		}
+	}
+}
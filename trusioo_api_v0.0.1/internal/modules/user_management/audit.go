@@ -0,0 +1,206 @@
+package user_management
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxChainIssues 哈希链校验最多返回的问题数
+const maxChainIssues = 100
+
+// 哈希链问题类型
+const (
+	ChainIssueHashMismatch       = "hash_mismatch"       // 记录内容被修改
+	ChainIssuePrevHashMismatch   = "prev_hash_mismatch"  // 前面的记录被删除、插入或替换
+	ChainIssueCheckpointMissing  = "checkpoint_missing"  // 有日志但检查点不存在
+	ChainIssueCheckpointInvalid  = "checkpoint_invalid"  // 检查点签名无效
+	ChainIssueCheckpointMismatch = "checkpoint_mismatch" // 检查点指向的记录不存在或hash不同（末尾记录被删除或替换）
+)
+
+// logHashPayload 参与哈希计算的日志内容（字段顺序固定）
+type logHashPayload struct {
+	PrevHash        string          `json:"prev_hash"`
+	ID              string          `json:"id"`
+	AdminID         string          `json:"admin_id"`
+	AdminEmail      string          `json:"admin_email"`
+	TargetUserID    string          `json:"target_user_id"`
+	TargetEmailHash string          `json:"target_email_hash"` // 明文邮箱会被匿名化，只对其HMAC计算hash
+	Action          string          `json:"action"`
+	Reason          *string         `json:"reason"`
+	Details         json.RawMessage `json:"details"`
+	IPAddress       string          `json:"ip_address"`
+	UserAgent       *string         `json:"user_agent"`
+	CreatedAt       string          `json:"created_at"`
+}
+
+// computeLogHash 计算日志的哈希：上一条日志的hash与本条日志内容一起做HMAC-SHA256
+// details需要是canonicalJSON处理后的内容，created_at按UTC微秒精度（与数据库一致）格式化
+func computeLogHash(key []byte, prevHash string, log *UserManagementLog, details json.RawMessage) (string, error) {
+	payload, err := json.Marshal(logHashPayload{
+		PrevHash:        prevHash,
+		ID:              log.ID,
+		AdminID:         log.AdminID,
+		AdminEmail:      log.AdminEmail,
+		TargetUserID:    log.TargetUserID,
+		TargetEmailHash: log.TargetEmailHash,
+		Action:          log.Action.String(),
+		Reason:          log.Reason,
+		Details:         details,
+		IPAddress:       log.IPAddress,
+		UserAgent:       log.UserAgent,
+		CreatedAt:       log.CreatedAt.UTC().Truncate(time.Microsecond).Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal log hash payload: %w", err)
+	}
+
+	return signLogChain(key, payload), nil
+}
+
+// hashTargetEmail 计算目标邮箱的HMAC（邮箱不区分大小写，空邮箱返回空字符串）
+func hashTargetEmail(key []byte, email string) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" {
+		return ""
+	}
+	return signLogChain(key, []byte("target_email:"+email))
+}
+
+// signLogCheckpoint 计算检查点签名
+func signLogCheckpoint(key []byte, seq int64, hash string) string {
+	return signLogChain(key, []byte("checkpoint:"+strconv.FormatInt(seq, 10)+":"+hash))
+}
+
+// signLogChain 使用审计日志密钥计算HMAC-SHA256
+func signLogChain(key, data []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// canonicalJSON 规范化JSON（对象键排序、去除空白）
+// 数字按原样保留，写入时需对从JSONB读出的文本规范化（JSONB会改写数字的写法，如1e2变为100）
+func canonicalJSON(data []byte) (json.RawMessage, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("failed to decode log details: %w", err)
+	}
+
+	canonical, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode log details: %w", err)
+	}
+	return canonical, nil
+}
+
+// LogChainIssue 哈希链校验发现的问题
+type LogChainIssue struct {
+	Seq     int64  `json:"seq"`
+	LogID   string `json:"log_id,omitempty"` // 检查点问题可能没有对应的记录
+	Problem string `json:"problem"`
+}
+
+// LogCheckpoint 哈希链检查点：最新一条日志的seq和hash，签名后保存在日志表之外
+type LogCheckpoint struct {
+	Seq       int64     `json:"seq"`
+	Hash      string    `json:"hash"`
+	Signature string    `json:"-"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// logChainVerifier 按seq顺序逐条校验哈希链，并与检查点对比发现末尾记录被删除
+type logChainVerifier struct {
+	key             []byte
+	checkpointFound bool           // 校验开始时检查点是否存在
+	checkpoint      *LogCheckpoint // 签名有效的检查点
+	reached         bool           // 是否已校验到检查点指向的记录
+	checked         int64
+	firstSeq        int64
+	lastSeq         int64
+	lastHash        string
+	issues          []LogChainIssue
+	total           int64 // 问题总数（issues最多保留maxChainIssues条）
+}
+
+// newLogChainVerifier 创建哈希链校验器，checkpoint为校验开始前读取的检查点（不存在时为nil）
+func newLogChainVerifier(key []byte, checkpoint *LogCheckpoint) *logChainVerifier {
+	v := &logChainVerifier{key: key, checkpointFound: checkpoint != nil}
+	if checkpoint != nil {
+		expected := signLogCheckpoint(key, checkpoint.Seq, checkpoint.Hash)
+		if hmac.Equal([]byte(expected), []byte(checkpoint.Signature)) {
+			v.checkpoint = checkpoint
+		} else {
+			v.addIssue(checkpoint.Seq, "", ChainIssueCheckpointInvalid)
+		}
+	}
+	return v
+}
+
+// check 校验一条日志，details为数据库中读出的原始JSON
+func (v *logChainVerifier) check(log *UserManagementLog, details []byte) error {
+	canonical, err := canonicalJSON(details)
+	if err != nil {
+		return err
+	}
+
+	// seq由序列生成，事务回滚时会跳号，因此只按prev_hash判断记录是否连续
+	if v.checked == 0 {
+		v.firstSeq = log.Seq
+	}
+	if log.PrevHash != v.lastHash {
+		v.addIssue(log.Seq, log.ID, ChainIssuePrevHashMismatch)
+	}
+
+	hash, err := computeLogHash(v.key, log.PrevHash, log, canonical)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal([]byte(hash), []byte(log.Hash)) {
+		v.addIssue(log.Seq, log.ID, ChainIssueHashMismatch)
+	}
+
+	// 校验期间新写入的日志在检查点之后，只要求检查点指向的记录仍然存在且未被替换
+	if v.checkpoint != nil && log.Seq == v.checkpoint.Seq {
+		v.reached = true
+		if log.Hash != v.checkpoint.Hash {
+			v.addIssue(log.Seq, log.ID, ChainIssueCheckpointMismatch)
+		}
+	}
+
+	v.checked++
+	v.lastSeq = log.Seq
+	v.lastHash = log.Hash
+	return nil
+}
+
+// finish 全部日志校验完成后与检查点对比
+func (v *logChainVerifier) finish() {
+	switch {
+	case v.checkpoint != nil && !v.reached:
+		v.addIssue(v.checkpoint.Seq, "", ChainIssueCheckpointMismatch)
+	case !v.checkpointFound && v.checked > 0:
+		v.addIssue(v.lastSeq, "", ChainIssueCheckpointMissing)
+	}
+}
+
+// addIssue 记录问题
+func (v *logChainVerifier) addIssue(seq int64, logID, problem string) {
+	v.total++
+	if len(v.issues) < maxChainIssues {
+		v.issues = append(v.issues, LogChainIssue{Seq: seq, LogID: logID, Problem: problem})
+	}
+}
@@ -0,0 +1,225 @@
+package user_management
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"trusioo_api_v0.0.1/internal/modules/auth/user"
+)
+
+var testAuditLogKey = []byte("test-audit-log-key")
+
+// buildLogChain 构造n条哈希链日志（不含details）
+func buildLogChain(t *testing.T, n int) []*UserManagementLog {
+	t.Helper()
+
+	logs := make([]*UserManagementLog, 0, n)
+	prevHash := ""
+	for i := 0; i < n; i++ {
+		log := &UserManagementLog{
+			ID:              "log-" + string(rune('a'+i)),
+			Seq:             int64(i + 1),
+			AdminID:         "admin-1",
+			AdminEmail:      "admin@example.com",
+			TargetUserID:    "user-1",
+			TargetEmail:     "user@example.com",
+			TargetEmailHash: hashTargetEmail(testAuditLogKey, "user@example.com"),
+			Action:          UserManagementAction("suspend"),
+			IPAddress:       "127.0.0.1",
+			CreatedAt:       time.Date(2024, 1, 1, 0, 0, i, 0, time.UTC),
+			PrevHash:        prevHash,
+		}
+		hash, err := computeLogHash(testAuditLogKey, prevHash, log, nil)
+		if err != nil {
+			t.Fatalf("computeLogHash() error = %v", err)
+		}
+		log.Hash = hash
+		prevHash = hash
+		logs = append(logs, log)
+	}
+	return logs
+}
+
+// verifyLogChain 校验日志，返回发现的问题类型
+func verifyLogChain(t *testing.T, key []byte, logs []*UserManagementLog, checkpoint *LogCheckpoint) []string {
+	t.Helper()
+
+	v := newLogChainVerifier(key, checkpoint)
+	for _, log := range logs {
+		if err := v.check(log, nil); err != nil {
+			t.Fatalf("check() error = %v", err)
+		}
+	}
+	v.finish()
+
+	problems := make([]string, 0, len(v.issues))
+	for _, issue := range v.issues {
+		problems = append(problems, issue.Problem)
+	}
+	return problems
+}
+
+// checkpointFor 为日志生成签名的检查点
+func checkpointFor(log *UserManagementLog) *LogCheckpoint {
+	return &LogCheckpoint{Seq: log.Seq, Hash: log.Hash, Signature: signLogCheckpoint(testAuditLogKey, log.Seq, log.Hash)}
+}
+
+func TestLogChainVerifier(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(logs []*UserManagementLog, cp *LogCheckpoint) ([]*UserManagementLog, *LogCheckpoint)
+		key    []byte
+		want   []string
+	}{
+		{
+			name: "intact chain",
+			want: []string{},
+		},
+		{
+			name: "rows written after checkpoint was read",
+			mutate: func(logs []*UserManagementLog, _ *LogCheckpoint) ([]*UserManagementLog, *LogCheckpoint) {
+				return logs, checkpointFor(logs[1])
+			},
+			want: []string{},
+		},
+		{
+			name: "target email anonymized",
+			mutate: func(logs []*UserManagementLog, cp *LogCheckpoint) ([]*UserManagementLog, *LogCheckpoint) {
+				logs[1].TargetEmail = "deleted-user@invalid"
+				return logs, cp
+			},
+			want: []string{},
+		},
+		{
+			name: "modified reason",
+			mutate: func(logs []*UserManagementLog, cp *LogCheckpoint) ([]*UserManagementLog, *LogCheckpoint) {
+				reason := "tampered"
+				logs[1].Reason = &reason
+				return logs, cp
+			},
+			want: []string{ChainIssueHashMismatch},
+		},
+		{
+			name: "middle row deleted",
+			mutate: func(logs []*UserManagementLog, cp *LogCheckpoint) ([]*UserManagementLog, *LogCheckpoint) {
+				return append(logs[:1], logs[2:]...), cp
+			},
+			want: []string{ChainIssuePrevHashMismatch},
+		},
+		{
+			name: "latest row deleted",
+			mutate: func(logs []*UserManagementLog, cp *LogCheckpoint) ([]*UserManagementLog, *LogCheckpoint) {
+				return logs[:2], cp
+			},
+			want: []string{ChainIssueCheckpointMismatch},
+		},
+		{
+			name: "latest row deleted and checkpoint rewritten without key",
+			mutate: func(logs []*UserManagementLog, cp *LogCheckpoint) ([]*UserManagementLog, *LogCheckpoint) {
+				forged := *cp
+				forged.Seq, forged.Hash = logs[1].Seq, logs[1].Hash
+				return logs[:2], &forged
+			},
+			want: []string{ChainIssueCheckpointInvalid},
+		},
+		{
+			name: "checkpoint deleted",
+			mutate: func(logs []*UserManagementLog, _ *LogCheckpoint) ([]*UserManagementLog, *LogCheckpoint) {
+				return logs, nil
+			},
+			want: []string{ChainIssueCheckpointMissing},
+		},
+		{
+			name: "wrong key",
+			key:  []byte("other-key"),
+			want: []string{ChainIssueCheckpointInvalid, ChainIssueHashMismatch, ChainIssueHashMismatch, ChainIssueHashMismatch},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := buildLogChain(t, 3)
+			checkpoint := checkpointFor(logs[2])
+			if tt.mutate != nil {
+				logs, checkpoint = tt.mutate(logs, checkpoint)
+			}
+			key := testAuditLogKey
+			if tt.key != nil {
+				key = tt.key
+			}
+
+			got := verifyLogChain(t, key, logs, checkpoint)
+			if len(got) != len(tt.want) {
+				t.Fatalf("problems = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("problems = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestLogChainSurvivesAnonymizationAfterEmailUpdate(t *testing.T) {
+	const oldEmail, newEmail = "old@example.com", "new@example.com"
+
+	raw, err := json.Marshal(emailChangeLogDetails(&user.EmailChange{
+		ID:       "change-1",
+		OldEmail: oldEmail,
+		NewEmail: newEmail,
+	}, true))
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	for _, email := range []string{oldEmail, newEmail} {
+		if strings.Contains(string(raw), email) {
+			t.Fatalf("details %s contain user email %s", raw, email)
+		}
+	}
+	details, err := canonicalJSON(raw)
+	if err != nil {
+		t.Fatalf("canonicalJSON() error = %v", err)
+	}
+
+	log := &UserManagementLog{
+		ID:              "log-a",
+		Seq:             1,
+		AdminID:         "admin-1",
+		AdminEmail:      "admin@example.com",
+		TargetUserID:    "user-1",
+		TargetEmail:     newEmail,
+		TargetEmailHash: hashTargetEmail(testAuditLogKey, newEmail),
+		Action:          ActionUpdateEmail,
+		IPAddress:       "127.0.0.1",
+		CreatedAt:       time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	if log.Hash, err = computeLogHash(testAuditLogKey, "", log, details); err != nil {
+		t.Fatalf("computeLogHash() error = %v", err)
+	}
+	checkpoint := checkpointFor(log)
+
+	// 注销匿名化只替换目标邮箱
+	log.TargetEmail = "deleted-user@invalid"
+
+	v := newLogChainVerifier(testAuditLogKey, checkpoint)
+	if err := v.check(log, raw); err != nil {
+		t.Fatalf("check() error = %v", err)
+	}
+	v.finish()
+	if len(v.issues) != 0 {
+		t.Fatalf("issues = %v, want none", v.issues)
+	}
+}
+
+func TestCanonicalJSONSortsKeys(t *testing.T) {
+	got, err := canonicalJSON([]byte(`{"b": 1, "a": {"d": [1, 2.50], "c": "x"}}`))
+	if err != nil {
+		t.Fatalf("canonicalJSON() error = %v", err)
+	}
+	if want := `{"a":{"c":"x","d":[1,2.50]},"b":1}`; string(got) != want {
+		t.Errorf("canonicalJSON() = %s, want %s", got, want)
+	}
+}
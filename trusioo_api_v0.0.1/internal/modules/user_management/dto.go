@@ -3,6 +3,7 @@ package user_management
 import (
 	"time"
 
+	"trusioo_api_v0.0.1/internal/modules/auth"
 	"trusioo_api_v0.0.1/internal/modules/auth/user"
 )
 
@@ -10,26 +11,31 @@ import (
 
 // GetUsersRequest 获取用户列表请求
 type GetUsersRequest struct {
-	// 分页参数
-	Page     int    `form:"page" binding:"omitempty,min=1" example:"1"`
-	PageSize int    `form:"page_size" binding:"omitempty,min=1,max=100" example:"20"`
-	SortBy   string `form:"sort_by" binding:"omitempty,oneof=created_at updated_at email name last_login_at login_count" example:"created_at"`
-	SortDir  string `form:"sort_dir" binding:"omitempty,oneof=asc desc" example:"desc"`
+	// 分页参数（用作批量操作筛选条件时忽略）
+	Page     int    `json:"page" form:"page" binding:"omitempty,min=1" example:"1"`
+	PageSize int    `json:"page_size" form:"page_size" binding:"omitempty,min=1,max=100" example:"20"`
+	SortBy   string `json:"sort_by" form:"sort_by" binding:"omitempty,oneof=created_at updated_at email name last_login_at login_count" example:"created_at"`
+	SortDir  string `json:"sort_dir" form:"sort_dir" binding:"omitempty,oneof=asc desc" example:"desc"`
 
 	// 搜索过滤器
-	Email         string `form:"email" binding:"omitempty,email" example:"user@example.com"`
-	Name          string `form:"name" binding:"omitempty" example:"张三"`
-	Status        string `form:"status" binding:"omitempty,oneof=active inactive suspended" example:"active"`
-	EmailVerified *bool  `form:"email_verified" binding:"omitempty" example:"true"`
+	Email         string `json:"email" form:"email" binding:"omitempty,email" example:"user@example.com"`
+	Name          string `json:"name" form:"name" binding:"omitempty" example:"张三"`
+	Status        string `json:"status" form:"status" binding:"omitempty,oneof=active inactive suspended" example:"active"`
+	EmailVerified *bool  `json:"email_verified" form:"email_verified" binding:"omitempty" example:"true"`
+	Tags          string `json:"tags" form:"tags" binding:"omitempty,max=500" example:"vip,fraud-suspect"` // 逗号分隔，需同时拥有全部标签
 
 	// 时间范围过滤
-	CreatedFrom   string `form:"created_from" binding:"omitempty" example:"2024-01-01"`
-	CreatedTo     string `form:"created_to" binding:"omitempty" example:"2024-12-31"`
-	LastLoginFrom string `form:"last_login_from" binding:"omitempty" example:"2024-01-01"`
-	LastLoginTo   string `form:"last_login_to" binding:"omitempty" example:"2024-12-31"`
+	CreatedFrom   string `json:"created_from" form:"created_from" binding:"omitempty" example:"2024-01-01"`
+	CreatedTo     string `json:"created_to" form:"created_to" binding:"omitempty" example:"2024-12-31"`
+	LastLoginFrom string `json:"last_login_from" form:"last_login_from" binding:"omitempty" example:"2024-01-01"`
+	LastLoginTo   string `json:"last_login_to" form:"last_login_to" binding:"omitempty" example:"2024-12-31"`
 
 	// 搜索关键词（支持邮箱、姓名模糊搜索）
-	Search string `form:"search" binding:"omitempty" example:"张三"`
+	Search string `json:"search" form:"search" binding:"omitempty" example:"张三"`
+
+	// 高级查询：保存的搜索和查询条件树（只能在JSON请求体中传入），与上面的过滤条件为AND关系
+	SavedSearchID string     `json:"saved_search_id" form:"saved_search_id" binding:"omitempty,uuid" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Query         *UserQuery `json:"query" form:"-"`
 }
 
 // UpdateUserStatusRequest 更新用户状态请求
@@ -41,8 +47,8 @@ type UpdateUserStatusRequest struct {
 // SuspendUserRequest 暂停用户请求
 type SuspendUserRequest struct {
 	Reason   string     `json:"reason" binding:"required" example:"违反用户协议"`
-	Duration *int       `json:"duration" binding:"omitempty,min=1" example:"7"` // 暂停天数，nil表示永久暂停
-	Until    *time.Time `json:"until" binding:"omitempty" example:"2024-12-31T23:59:59Z"`
+	Duration *int       `json:"duration" binding:"omitempty,min=1" example:"7"`           // 暂停天数，与until都为空表示永久暂停
+	Until    *time.Time `json:"until" binding:"omitempty" example:"2024-12-31T23:59:59Z"` // 暂停结束时间，同时提供时优先于duration
 }
 
 // ResetPasswordRequest 重置密码请求
@@ -54,7 +60,7 @@ type ResetPasswordRequest struct {
 // UpdateUserEmailRequest 更新用户邮箱请求
 type UpdateUserEmailRequest struct {
 	NewEmail         string `json:"new_email" binding:"required,email" example:"newemail@example.com"`
-	SendVerification bool   `json:"send_verification" example:"true"` // 是否发送验证邮件
+	SendVerification bool   `json:"send_verification" example:"true"` // 为true时新邮箱标记为未验证，用户下次邮件验证码登录时完成验证
 }
 
 // ForceLogoutRequest 强制登出请求
@@ -63,12 +69,75 @@ type ForceLogoutRequest struct {
 	Reason    string `json:"reason" binding:"required" example:"安全原因"`
 }
 
+// UnlockUserRequest 解除登录锁定请求
+type UnlockUserRequest struct {
+	Reason string `json:"reason" binding:"required" example:"用户已通过客服核实身份"`
+}
+
+// DeleteUserRequest 注销用户请求
+type DeleteUserRequest struct {
+	Reason string `json:"reason" binding:"required" example:"用户通过客服申请注销"`
+}
+
+// BatchTargetRequest 批量操作目标（user_ids与filter二选一）
+type BatchTargetRequest struct {
+	UserIDs []string         `json:"user_ids" binding:"omitempty,dive,uuid" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Filter  *GetUsersRequest `json:"filter"`                  // 与用户列表相同的筛选条件
+	DryRun  bool             `json:"dry_run" example:"false"` // 为true时只返回预计影响的用户数，不创建任务
+}
+
+// BatchUpdateUserStatusRequest 批量更新用户状态请求
+type BatchUpdateUserStatusRequest struct {
+	BatchTargetRequest
+	UpdateUserStatusRequest
+}
+
+// BatchSuspendUsersRequest 批量暂停用户请求
+type BatchSuspendUsersRequest struct {
+	BatchTargetRequest
+	SuspendUserRequest
+}
+
+// BatchReactivateUsersRequest 批量重新激活用户请求
+type BatchReactivateUsersRequest struct {
+	BatchTargetRequest
+}
+
+// BatchForceLogoutRequest 批量强制登出请求
+type BatchForceLogoutRequest struct {
+	BatchTargetRequest
+	ForceLogoutRequest
+}
+
+// ListBatchJobsRequest 批量操作任务列表请求
+type ListBatchJobsRequest struct {
+	Page     int    `form:"page" binding:"omitempty,min=1" example:"1"`
+	PageSize int    `form:"page_size" binding:"omitempty,min=1,max=100" example:"20"`
+	Status   string `form:"status" binding:"omitempty,oneof=awaiting_approval queued running completed cancelled" example:"running"`
+}
+
+// ListBatchJobItemsRequest 批量操作执行结果列表请求
+type ListBatchJobItemsRequest struct {
+	Page     int    `form:"page" binding:"omitempty,min=1" example:"1"`
+	PageSize int    `form:"page_size" binding:"omitempty,min=1,max=100" example:"20"`
+	Status   string `form:"status" binding:"omitempty,oneof=pending succeeded failed skipped cancelled" example:"failed"`
+}
+
 // GetStatisticsRequest 获取统计信息请求
 type GetStatisticsRequest struct {
-	DateFrom   string `form:"date_from" binding:"omitempty" example:"2024-01-01"`
-	DateTo     string `form:"date_to" binding:"omitempty" example:"2024-12-31"`
+	DateFrom   string `form:"date_from" binding:"omitempty,datetime=2006-01-02" example:"2024-01-01"`
+	DateTo     string `form:"date_to" binding:"omitempty,datetime=2006-01-02" example:"2024-12-31"`
 	GroupBy    string `form:"group_by" binding:"omitempty,oneof=day week month" example:"day"`
 	MetricType string `form:"metric_type" binding:"omitempty,oneof=registration verification activity" example:"registration"`
+	Timezone   string `form:"timezone" binding:"omitempty" example:"Asia/Shanghai"` // 分桶使用的时区，默认UTC
+}
+
+// GetRetentionRequest 获取注册周留存请求
+type GetRetentionRequest struct {
+	DateFrom string `form:"date_from" binding:"omitempty,datetime=2006-01-02" example:"2024-01-01"` // 注册周范围，按所在周计算
+	DateTo   string `form:"date_to" binding:"omitempty,datetime=2006-01-02" example:"2024-03-31"`
+	Weeks    int    `form:"weeks" binding:"omitempty,min=1,max=12" example:"8"` // 跟踪注册后的周数，默认8
+	Timezone string `form:"timezone" binding:"omitempty" example:"Asia/Shanghai"`
 }
 
 // ExportUsersRequest 导出用户请求
@@ -81,6 +150,101 @@ type ExportUsersRequest struct {
 	GetUsersRequest
 }
 
+// GetManagementLogsRequest 管理操作日志查询请求
+type GetManagementLogsRequest struct {
+	Page         int    `form:"page" binding:"omitempty,min=1" example:"1"`
+	PageSize     int    `form:"page_size" binding:"omitempty,min=1,max=100" example:"20"`
+	AdminID      string `form:"admin_id" binding:"omitempty,max=100" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Action       string `form:"action" binding:"omitempty,oneof=activate deactivate suspend unsuspend delete reset_password force_logout update_email verify_email unlock_login restore add_note delete_note add_tag remove_tag impersonate end_impersonation" example:"suspend"`
+	TargetUserID string `form:"target_user_id" binding:"omitempty,uuid" example:"123e4567-e89b-12d3-a456-426614174000"`
+	DateFrom     string `form:"date_from" binding:"omitempty,datetime=2006-01-02" example:"2024-01-01"`
+	DateTo       string `form:"date_to" binding:"omitempty,datetime=2006-01-02" example:"2024-12-31"`
+	Query        string `form:"q" binding:"omitempty,max=200" example:"违反用户协议"` // 在操作原因中模糊搜索
+}
+
+// ExportManagementLogsRequest 导出管理操作日志请求
+type ExportManagementLogsRequest struct {
+	Timezone string `form:"timezone" binding:"omitempty" example:"Asia/Shanghai"`
+
+	// 继承过滤器参数（分页参数忽略）
+	GetManagementLogsRequest
+}
+
+// ListUserNotesRequest 用户备注列表请求
+type ListUserNotesRequest struct {
+	Page     int `form:"page" binding:"omitempty,min=1" example:"1"`
+	PageSize int `form:"page_size" binding:"omitempty,min=1,max=100" example:"20"`
+}
+
+// CreateUserNoteRequest 添加用户备注请求
+type CreateUserNoteRequest struct {
+	Content string `json:"content" binding:"required,max=5000" example:"用户来电核实过提现账户"`
+}
+
+// AddUserTagsRequest 添加用户标签请求
+type AddUserTagsRequest struct {
+	Tags []string `json:"tags" binding:"required,min=1,max=20,dive,required,max=32" example:"vip,fraud-suspect"`
+}
+
+// WatchUserRequest 关注用户请求（已关注时更新关注的事件和原因）
+type WatchUserRequest struct {
+	Events []string `json:"events" binding:"omitempty,dive,oneof=login bank_account_added" example:"login,bank_account_added"` // 为空表示关注全部事件
+	Reason *string  `json:"reason" binding:"omitempty,max=500" example:"疑似盗号，需跟进提现"`
+}
+
+// ListWatchesRequest 关注列表请求
+type ListWatchesRequest struct {
+	Page     int `form:"page" binding:"omitempty,min=1" example:"1"`
+	PageSize int `form:"page_size" binding:"omitempty,min=1,max=100" example:"20"`
+}
+
+// ListWatchNotificationsRequest 关注通知列表请求
+type ListWatchNotificationsRequest struct {
+	Page     int  `form:"page" binding:"omitempty,min=1" example:"1"`
+	PageSize int  `form:"page_size" binding:"omitempty,min=1,max=100" example:"20"`
+	Unread   bool `form:"unread" example:"true"` // 只返回未读通知
+}
+
+// MarkWatchNotificationsReadRequest 标记关注通知已读请求
+type MarkWatchNotificationsReadRequest struct {
+	IDs []string `json:"ids" binding:"omitempty,max=100,dive,uuid" example:"123e4567-e89b-12d3-a456-426614174000"` // 为空表示全部标记为已读
+}
+
+// ImpersonateUserRequest 模拟用户登录请求
+type ImpersonateUserRequest struct {
+	Reason     string `json:"reason" binding:"required,max=500" example:"用户反馈提现页面显示异常，需查看用户所见页面"`
+	NotifyUser *bool  `json:"notify_user" example:"true"` // 是否邮件通知用户，未指定时使用系统默认配置
+}
+
+// ListImpersonationsRequest 模拟登录记录列表请求
+type ListImpersonationsRequest struct {
+	Page     int    `form:"page" binding:"omitempty,min=1" example:"1"`
+	PageSize int    `form:"page_size" binding:"omitempty,min=1,max=100" example:"20"`
+	AdminID  string `form:"admin_id" binding:"omitempty,uuid" example:"123e4567-e89b-12d3-a456-426614174000"`
+	UserID   string `form:"user_id" binding:"omitempty,uuid" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Active   *bool  `form:"active" example:"true"` // 只返回仍有效（或已失效）的记录
+}
+
+// ListImpersonationRequestsRequest 模拟登录请求日志列表请求
+type ListImpersonationRequestsRequest struct {
+	Page     int `form:"page" binding:"omitempty,min=1" example:"1"`
+	PageSize int `form:"page_size" binding:"omitempty,min=1,max=100" example:"50"`
+}
+
+// CreateSavedSearchRequest 保存搜索请求
+type CreateSavedSearchRequest struct {
+	Name        string     `json:"name" binding:"required,max=100" example:"高余额未绑卡用户"`
+	Description *string    `json:"description" binding:"omitempty,max=500" example:"余额超过1000且没有银行账户"`
+	Query       *UserQuery `json:"query" binding:"required"`
+}
+
+// UpdateSavedSearchRequest 更新保存的搜索请求（未传入的字段保持不变）
+type UpdateSavedSearchRequest struct {
+	Name        *string    `json:"name" binding:"omitempty,min=1,max=100" example:"高余额未绑卡用户"`
+	Description *string    `json:"description" binding:"omitempty,max=500" example:"余额超过1000且没有银行账户"`
+	Query       *UserQuery `json:"query"`
+}
+
 // === 响应DTO ===
 
 // UserDetailResponse 用户详情响应
@@ -100,9 +264,11 @@ type UserDetailResponse struct {
 	ActiveSessions int        `json:"active_sessions" example:"2"`
 
 	// 管理信息
-	SuspendedAt     *time.Time `json:"suspended_at,omitempty" example:"2024-01-22T09:00:00Z"`
-	SuspendedReason *string    `json:"suspended_reason,omitempty" example:"违反用户协议"`
-	Notes           *string    `json:"notes,omitempty" example:"VIP用户"`
+	SuspendedAt     *time.Time  `json:"suspended_at,omitempty" example:"2024-01-22T09:00:00Z"`
+	SuspendedReason *string     `json:"suspended_reason,omitempty" example:"违反用户协议"`
+	SuspendedUntil  *time.Time  `json:"suspended_until,omitempty" example:"2024-01-29T09:00:00Z"` // 为空表示永久暂停
+	Tags            []string    `json:"tags" example:"vip"`
+	RecentNotes     []*UserNote `json:"recent_notes"` // 最近的备注，完整列表见备注接口
 
 	// 审计信息
 	CreatedAt time.Time  `json:"created_at" example:"2024-01-01T08:00:00Z"`
@@ -110,6 +276,14 @@ type UserDetailResponse struct {
 	DeletedAt *time.Time `json:"deleted_at,omitempty"`
 }
 
+// UserSuspensionHistoryResponse 用户暂停历史响应
+type UserSuspensionHistoryResponse struct {
+	UserID           string            `json:"user_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	TotalSuspensions int               `json:"total_suspensions" example:"2"`
+	Active           *UserSuspension   `json:"active,omitempty"` // 当前生效的暂停
+	Suspensions      []*UserSuspension `json:"suspensions"`
+}
+
 // UserListResponse 用户列表响应
 type UserListResponse struct {
 	Users      []UserSummaryResponse `json:"users"`
@@ -131,6 +305,7 @@ type UserSummaryResponse struct {
 	LastLoginAt    *time.Time `json:"last_login_at" example:"2024-01-20T14:30:00Z"`
 	LoginCount     int64      `json:"login_count" example:"25"`
 	ActiveSessions int        `json:"active_sessions" example:"2"`
+	Tags           []string   `json:"tags" example:"vip"`
 	CreatedAt      time.Time  `json:"created_at" example:"2024-01-01T08:00:00Z"`
 }
 
@@ -173,6 +348,50 @@ type DailyCountResponse struct {
 	Count int64  `json:"count" example:"15"`
 }
 
+// TimeSeriesResponse 统计时间序列响应
+type TimeSeriesResponse struct {
+	Metric     string            `json:"metric" example:"registration"`
+	GroupBy    string            `json:"group_by" example:"day"`
+	Timezone   string            `json:"timezone" example:"Asia/Shanghai"`
+	DateFrom   string            `json:"date_from" example:"2024-01-01"` // 第一个时间桶的开始
+	DateTo     string            `json:"date_to" example:"2024-01-30"`   // 最后一个时间桶的结束（包含）
+	Total      *int64            `json:"total,omitempty" example:"450"`  // 注册和验证的合计，活跃用户按时间桶去重，不提供合计
+	Source     string            `json:"source" example:"rollup"`        // rollup为读取汇总表，live为实时计算
+	ComputedAt *time.Time        `json:"computed_at,omitempty"`          // 汇总数据中最早的计算时间
+	Points     []TimeSeriesPoint `json:"points"`
+}
+
+// TimeSeriesPoint 时间序列中的一个时间桶
+type TimeSeriesPoint struct {
+	Bucket      string `json:"bucket" example:"2024-01-22"`
+	Count       *int64 `json:"count,omitempty" example:"15"`         // 注册或验证数
+	ActiveUsers *int64 `json:"active_users,omitempty" example:"120"` // 成功登录的去重用户数
+	Logins      *int64 `json:"logins,omitempty" example:"340"`       // 成功登录次数
+}
+
+// RetentionResponse 注册周留存响应
+type RetentionResponse struct {
+	Timezone   string                    `json:"timezone" example:"Asia/Shanghai"`
+	Weeks      int                       `json:"weeks" example:"8"`
+	Source     string                    `json:"source" example:"rollup"`
+	ComputedAt *time.Time                `json:"computed_at,omitempty"`
+	Cohorts    []CohortRetentionResponse `json:"cohorts"` // 没有注册用户的周不返回
+}
+
+// CohortRetentionResponse 一个注册周的留存
+type CohortRetentionResponse struct {
+	CohortWeek string                  `json:"cohort_week" example:"2024-01-15"` // 注册周的周一
+	CohortSize int64                   `json:"cohort_size" example:"80"`
+	Retention  []RetentionWeekResponse `json:"retention"` // 只包含已经开始的周
+}
+
+// RetentionWeekResponse 注册后第N周的活跃情况
+type RetentionWeekResponse struct {
+	Week        int     `json:"week" example:"1"` // 0为注册当周
+	ActiveUsers int64   `json:"active_users" example:"36"`
+	Rate        float64 `json:"rate" example:"45"` // 占注册用户的百分比
+}
+
 // UserActivityResponse 用户活动响应
 type UserActivityResponse struct {
 	UserID string `json:"user_id" example:"123e4567-e89b-12d3-a456-426614174000"`
@@ -224,6 +443,165 @@ type UserManagementLogResponse struct {
 	IPAddress    string                  `json:"ip_address" example:"192.168.1.100"`
 	UserAgent    *string                 `json:"user_agent" example:"Mozilla/5.0..."`
 	CreatedAt    time.Time               `json:"created_at" example:"2024-01-22T10:15:00Z"`
+	Seq          int64                   `json:"seq" example:"1024"`
+	PrevHash     string                  `json:"prev_hash" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	Hash         string                  `json:"hash" example:"60303ae22b998861bce3b28f33eec1be758a213c86c93c076dbe9f558c11c752"`
+}
+
+// ManagementLogListResponse 管理操作日志列表响应
+type ManagementLogListResponse struct {
+	Logs       []UserManagementLogResponse `json:"logs"`
+	Total      int64                       `json:"total" example:"320"`
+	Page       int                         `json:"page" example:"1"`
+	PageSize   int                         `json:"page_size" example:"20"`
+	TotalPages int                         `json:"total_pages" example:"16"`
+	HasNext    bool                        `json:"has_next" example:"true"`
+	HasPrev    bool                        `json:"has_prev" example:"false"`
+}
+
+// LogChainVerificationResponse 管理操作日志哈希链校验结果
+type LogChainVerificationResponse struct {
+	Valid          bool            `json:"valid" example:"true"`
+	CheckedEntries int64           `json:"checked_entries" example:"320"`
+	FirstSeq       int64           `json:"first_seq" example:"1"`
+	LastSeq        int64           `json:"last_seq" example:"320"`
+	LastHash       string          `json:"last_hash" example:"60303ae22b998861bce3b28f33eec1be758a213c86c93c076dbe9f558c11c752"`
+	Checkpoint     *LogCheckpoint  `json:"checkpoint"` // 校验开始时的检查点，用于发现末尾记录被删除
+	IssueCount     int64           `json:"issue_count" example:"0"`
+	Issues         []LogChainIssue `json:"issues"` // 最多返回前100个问题
+	VerifiedAt     time.Time       `json:"verified_at" example:"2024-01-22T10:15:00Z"`
+}
+
+// BatchPreviewResponse 批量操作预览响应（dry_run）
+type BatchPreviewResponse struct {
+	Action            BatchAction    `json:"action" example:"suspend"`
+	TotalUsers        int            `json:"total_users" example:"250"`
+	NotFoundIDs       []string       `json:"not_found_ids,omitempty"` // 按ID提交时不存在或已注销的用户
+	RequiresApproval  bool           `json:"requires_approval" example:"true"`
+	ApprovalThreshold int            `json:"approval_threshold" example:"100"`
+	MaxUsers          int            `json:"max_users" example:"10000"`
+	Sample            []*BatchTarget `json:"sample"` // 部分目标用户，便于确认筛选条件
+}
+
+// BatchJobResponse 批量操作任务响应
+type BatchJobResponse struct {
+	*BatchJob
+	Progress         float64 `json:"progress" example:"42.5"` // 进度百分比
+	RequiresApproval bool    `json:"requires_approval" example:"false"`
+}
+
+// BatchJobListResponse 批量操作任务列表响应
+type BatchJobListResponse struct {
+	Jobs       []BatchJobResponse `json:"jobs"`
+	Total      int64              `json:"total" example:"12"`
+	Page       int                `json:"page" example:"1"`
+	PageSize   int                `json:"page_size" example:"20"`
+	TotalPages int                `json:"total_pages" example:"1"`
+	HasNext    bool               `json:"has_next" example:"false"`
+	HasPrev    bool               `json:"has_prev" example:"false"`
+}
+
+// BatchJobItemListResponse 批量操作执行结果列表响应
+type BatchJobItemListResponse struct {
+	JobID      string          `json:"job_id"`
+	Items      []*BatchJobItem `json:"items"`
+	Total      int64           `json:"total" example:"250"`
+	Page       int             `json:"page" example:"1"`
+	PageSize   int             `json:"page_size" example:"20"`
+	TotalPages int             `json:"total_pages" example:"13"`
+	HasNext    bool            `json:"has_next" example:"true"`
+	HasPrev    bool            `json:"has_prev" example:"false"`
+}
+
+// UserNoteListResponse 用户备注列表响应
+type UserNoteListResponse struct {
+	UserID     string      `json:"user_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Notes      []*UserNote `json:"notes"` // 按时间倒序
+	Total      int64       `json:"total" example:"3"`
+	Page       int         `json:"page" example:"1"`
+	PageSize   int         `json:"page_size" example:"20"`
+	TotalPages int         `json:"total_pages" example:"1"`
+	HasNext    bool        `json:"has_next" example:"false"`
+	HasPrev    bool        `json:"has_prev" example:"false"`
+}
+
+// UserTagsResponse 用户标签响应
+type UserTagsResponse struct {
+	UserID string   `json:"user_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Tags   []string `json:"tags" example:"vip"`
+}
+
+// TagListResponse 全部标签响应
+type TagListResponse struct {
+	Tags []*TagCount `json:"tags"` // 按使用人数倒序
+}
+
+// WatchListResponse 关注列表响应
+type WatchListResponse struct {
+	Watches    []*UserWatch `json:"watches"`
+	Total      int64        `json:"total" example:"5"`
+	Page       int          `json:"page" example:"1"`
+	PageSize   int          `json:"page_size" example:"20"`
+	TotalPages int          `json:"total_pages" example:"1"`
+	HasNext    bool         `json:"has_next" example:"false"`
+	HasPrev    bool         `json:"has_prev" example:"false"`
+}
+
+// WatchNotificationListResponse 关注通知列表响应
+type WatchNotificationListResponse struct {
+	Notifications []*WatchNotification `json:"notifications"`
+	UnreadCount   int64                `json:"unread_count" example:"2"`
+	Total         int64                `json:"total" example:"12"`
+	Page          int                  `json:"page" example:"1"`
+	PageSize      int                  `json:"page_size" example:"20"`
+	TotalPages    int                  `json:"total_pages" example:"1"`
+	HasNext       bool                 `json:"has_next" example:"false"`
+	HasPrev       bool                 `json:"has_prev" example:"false"`
+}
+
+// ImpersonationTokenResponse 模拟用户登录令牌响应
+type ImpersonationTokenResponse struct {
+	Impersonation *UserImpersonation `json:"impersonation"`
+	AccessToken   string             `json:"access_token"`
+	TokenType     string             `json:"token_type" example:"Bearer"`
+	ExpiresIn     int64              `json:"expires_in" example:"900"` // 秒
+	ReadOnly      bool               `json:"read_only" example:"true"`
+}
+
+// ImpersonationListResponse 模拟登录记录列表响应
+type ImpersonationListResponse struct {
+	Impersonations []*UserImpersonation `json:"impersonations"`
+	Total          int64                `json:"total" example:"8"`
+	Page           int                  `json:"page" example:"1"`
+	PageSize       int                  `json:"page_size" example:"20"`
+	TotalPages     int                  `json:"total_pages" example:"1"`
+	HasNext        bool                 `json:"has_next" example:"false"`
+	HasPrev        bool                 `json:"has_prev" example:"false"`
+}
+
+// ImpersonationRequestListResponse 模拟登录请求日志列表响应
+type ImpersonationRequestListResponse struct {
+	Impersonation *UserImpersonation         `json:"impersonation"`
+	Requests      []*ImpersonationRequestLog `json:"requests"` // 按请求顺序
+	Total         int64                      `json:"total" example:"36"`
+	Page          int                        `json:"page" example:"1"`
+	PageSize      int                        `json:"page_size" example:"50"`
+	TotalPages    int                        `json:"total_pages" example:"1"`
+	HasNext       bool                       `json:"has_next" example:"false"`
+	HasPrev       bool                       `json:"has_prev" example:"false"`
+}
+
+// SavedSearchListResponse 保存的搜索列表响应
+type SavedSearchListResponse struct {
+	SavedSearches []*SavedSearch `json:"saved_searches"` // 按名称排序
+	Total         int            `json:"total" example:"3"`
+	Limit         int            `json:"limit" example:"50"` // 每个管理员最多保存的搜索数
+}
+
+// UserLockoutResponse 用户登录锁定情况响应
+type UserLockoutResponse struct {
+	UserID  string                   `json:"user_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Lockout *auth.AccountLockoutInfo `json:"lockout"`
 }
 
 // OperationResponse 操作响应（通用）
@@ -261,7 +639,9 @@ func (u *UserManagementModel) ToUserDetailResponse(activeSessions int) *UserDeta
 		ActiveSessions:  activeSessions,
 		SuspendedAt:     u.SuspendedAt,
 		SuspendedReason: u.SuspendedReason,
-		Notes:           u.Notes,
+		SuspendedUntil:  u.SuspendedUntil,
+		Tags:            nonNilTags(u.Tags),
+		RecentNotes:     []*UserNote{},
 		CreatedAt:       u.CreatedAt,
 		UpdatedAt:       u.UpdatedAt,
 		DeletedAt:       u.DeletedAt,
@@ -279,10 +659,19 @@ func (u *UserManagementModel) ToUserSummaryResponse(activeSessions int) *UserSum
 		LastLoginAt:    u.LastLoginAt,
 		LoginCount:     u.LoginCount,
 		ActiveSessions: activeSessions,
+		Tags:           nonNilTags(u.Tags),
 		CreatedAt:      u.CreatedAt,
 	}
 }
 
+// nonNilTags 没有标签时返回空数组，保证JSON输出为[]
+func nonNilTags(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
+
 // ToSearchFilter 将请求转换为搜索过滤器
 func (req *GetUsersRequest) ToSearchFilter() *SearchFilter {
 	filter := &SearchFilter{}
@@ -300,6 +689,9 @@ func (req *GetUsersRequest) ToSearchFilter() *SearchFilter {
 	if req.EmailVerified != nil {
 		filter.EmailVerified = req.EmailVerified
 	}
+	if req.Tags != "" {
+		filter.Tags = parseTagList(req.Tags)
+	}
 
 	// 解析时间范围
 	if req.CreatedFrom != "" {
@@ -348,3 +740,133 @@ func (req *GetUsersRequest) ToPaginationParams() PaginationParams {
 
 	return params
 }
+
+// ToBatchJobResponse 转换为批量操作任务响应
+func (j *BatchJob) ToBatchJobResponse() BatchJobResponse {
+	return BatchJobResponse{
+		BatchJob:         j,
+		Progress:         j.Progress(),
+		RequiresApproval: j.Status == BatchJobAwaitingApproval,
+	}
+}
+
+// ToPaginationParams 将请求转换为分页参数
+func (req *ListBatchJobsRequest) ToPaginationParams() PaginationParams {
+	return batchPaginationParams(req.Page, req.PageSize)
+}
+
+// ToPaginationParams 将请求转换为分页参数
+func (req *ListBatchJobItemsRequest) ToPaginationParams() PaginationParams {
+	return batchPaginationParams(req.Page, req.PageSize)
+}
+
+// batchPaginationParams 批量操作列表的分页参数
+func batchPaginationParams(page, pageSize int) PaginationParams {
+	params := DefaultPaginationParams()
+
+	if page > 0 {
+		params.Page = page
+	}
+	if pageSize > 0 {
+		params.PageSize = pageSize
+	}
+
+	return params
+}
+
+// ToResponse 转换为管理操作日志响应
+func (l *UserManagementLog) ToResponse() UserManagementLogResponse {
+	return UserManagementLogResponse{
+		ID:           l.ID,
+		AdminID:      l.AdminID,
+		AdminEmail:   l.AdminEmail,
+		TargetUserID: l.TargetUserID,
+		TargetEmail:  l.TargetEmail,
+		Action:       l.Action.String(),
+		Reason:       l.Reason,
+		Details:      l.Details,
+		IPAddress:    l.IPAddress,
+		UserAgent:    l.UserAgent,
+		CreatedAt:    l.CreatedAt,
+		Seq:          l.Seq,
+		PrevHash:     l.PrevHash,
+		Hash:         l.Hash,
+	}
+}
+
+// ToLogFilter 将请求转换为管理操作日志过滤器（日期已由binding校验格式，按UTC自然日处理）
+func (req *GetManagementLogsRequest) ToLogFilter() *ManagementLogFilter {
+	filter := &ManagementLogFilter{}
+
+	if req.AdminID != "" {
+		filter.AdminID = &req.AdminID
+	}
+	if req.Action != "" {
+		action := UserManagementAction(req.Action)
+		filter.Action = &action
+	}
+	if req.TargetUserID != "" {
+		filter.TargetUserID = &req.TargetUserID
+	}
+	if req.DateFrom != "" {
+		if t, err := time.Parse("2006-01-02", req.DateFrom); err == nil {
+			filter.DateFrom = &t
+		}
+	}
+	if req.DateTo != "" {
+		if t, err := time.Parse("2006-01-02", req.DateTo); err == nil {
+			// 包含结束日期当天
+			t = t.AddDate(0, 0, 1)
+			filter.DateTo = &t
+		}
+	}
+	if req.Query != "" {
+		filter.Query = &req.Query
+	}
+
+	return filter
+}
+
+// ToPaginationParams 将请求转换为分页参数
+func (req *GetManagementLogsRequest) ToPaginationParams() PaginationParams {
+	return batchPaginationParams(req.Page, req.PageSize)
+}
+
+// ToPaginationParams 将请求转换为分页参数
+func (req *ListUserNotesRequest) ToPaginationParams() PaginationParams {
+	return batchPaginationParams(req.Page, req.PageSize)
+}
+
+// ToPaginationParams 将请求转换为分页参数
+func (req *ListWatchesRequest) ToPaginationParams() PaginationParams {
+	return batchPaginationParams(req.Page, req.PageSize)
+}
+
+// ToPaginationParams 将请求转换为分页参数
+func (req *ListWatchNotificationsRequest) ToPaginationParams() PaginationParams {
+	return batchPaginationParams(req.Page, req.PageSize)
+}
+
+// ToPaginationParams 将请求转换为分页参数
+func (req *ListImpersonationsRequest) ToPaginationParams() PaginationParams {
+	return batchPaginationParams(req.Page, req.PageSize)
+}
+
+// ToFilter 将请求转换为模拟登录记录过滤器
+func (req *ListImpersonationsRequest) ToFilter() *ImpersonationFilter {
+	filter := &ImpersonationFilter{Active: req.Active}
+
+	if req.AdminID != "" {
+		filter.AdminID = &req.AdminID
+	}
+	if req.UserID != "" {
+		filter.UserID = &req.UserID
+	}
+
+	return filter
+}
+
+// ToPaginationParams 将请求转换为分页参数
+func (req *ListImpersonationRequestsRequest) ToPaginationParams() PaginationParams {
+	return batchPaginationParams(req.Page, req.PageSize)
+}
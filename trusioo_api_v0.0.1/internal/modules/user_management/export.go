@@ -0,0 +1,321 @@
+package user_management
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "time/tzdata" // 内置时区数据，容器中没有系统时区库时也能转换时区
+
+	"trusioo_api_v0.0.1/internal/modules/auth"
+)
+
+// exportColumn 可导出的用户字段
+type exportColumn struct {
+	Key     string
+	Header  string
+	Numeric bool // Excel中按数字单元格写入
+	Value   func(u *UserManagementModel, loc *time.Location) string
+}
+
+// exportColumns 可导出的全部字段（未指定fields时按此顺序全部导出）
+var exportColumns = []exportColumn{
+	{Key: "id", Header: "用户ID", Value: func(u *UserManagementModel, _ *time.Location) string { return u.ID }},
+	{Key: "email", Header: "邮箱", Value: func(u *UserManagementModel, _ *time.Location) string { return u.Email }},
+	{Key: "name", Header: "姓名", Value: func(u *UserManagementModel, _ *time.Location) string { return u.Name }},
+	{Key: "status", Header: "状态", Value: func(u *UserManagementModel, _ *time.Location) string { return u.Status }},
+	{Key: "email_verified", Header: "邮箱已验证", Value: func(u *UserManagementModel, _ *time.Location) string {
+		return boolToString(u.EmailVerified)
+	}},
+	{Key: "email_verified_at", Header: "邮箱验证时间", Value: func(u *UserManagementModel, loc *time.Location) string {
+		return formatExportTime(u.EmailVerifiedAt, loc)
+	}},
+	{Key: "last_login_at", Header: "最后登录时间", Value: func(u *UserManagementModel, loc *time.Location) string {
+		return formatExportTime(u.LastLoginAt, loc)
+	}},
+	{Key: "login_count", Header: "登录次数", Numeric: true, Value: func(u *UserManagementModel, _ *time.Location) string {
+		return strconv.FormatInt(u.LoginCount, 10)
+	}},
+	{Key: "created_at", Header: "注册时间", Value: func(u *UserManagementModel, loc *time.Location) string {
+		return formatExportTime(&u.CreatedAt, loc)
+	}},
+	{Key: "updated_at", Header: "更新时间", Value: func(u *UserManagementModel, loc *time.Location) string {
+		return formatExportTime(&u.UpdatedAt, loc)
+	}},
+}
+
+// parseExportFields 解析逗号分隔的导出字段，为空时导出全部字段
+func parseExportFields(fields string) ([]exportColumn, error) {
+	if strings.TrimSpace(fields) == "" {
+		return exportColumns, nil
+	}
+
+	byKey := make(map[string]exportColumn, len(exportColumns))
+	for _, column := range exportColumns {
+		byKey[column.Key] = column
+	}
+
+	columns := make([]exportColumn, 0)
+	seen := make(map[string]bool)
+	for _, key := range strings.Split(fields, ",") {
+		key = strings.TrimSpace(key)
+		if key == "" || seen[key] {
+			continue
+		}
+		column, ok := byKey[key]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", auth.ErrInvalidExportField, key)
+		}
+		seen[key] = true
+		columns = append(columns, column)
+	}
+
+	if len(columns) == 0 {
+		return exportColumns, nil
+	}
+	return columns, nil
+}
+
+// formatExportTime 将时间转换到指定时区后格式化
+func formatExportTime(t *time.Time, loc *time.Location) string {
+	if t == nil {
+		return ""
+	}
+	return t.In(loc).Format("2006-01-02 15:04:05")
+}
+
+// exportWriter 逐行写入导出文件
+type exportWriter interface {
+	WriteRow(values []string) error
+	Close() error
+}
+
+// writeUserExport 将用户逐行写入导出文件（先写临时文件再重命名，避免下载到写了一半的文件）
+// stream每读取一个用户调用一次回调，全程不在内存中保留全部数据
+func writeUserExport(path, format string, columns []exportColumn, loc *time.Location,
+	stream func(fn func(*UserManagementModel) error) (int64, error)) (int64, int64, error) {
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return 0, 0, fmt.Errorf("failed to create export directory: %w", err)
+	}
+
+	tmpPath := path + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to create export file: %w", err)
+	}
+
+	count, err := writeUserExportRows(file, format, columns, loc, stream)
+	if err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return 0, 0, err
+	}
+
+	if err := file.Close(); err != nil {
+		os.Remove(tmpPath)
+		return 0, 0, fmt.Errorf("failed to close export file: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return 0, 0, fmt.Errorf("failed to move export file: %w", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to stat export file: %w", err)
+	}
+
+	return count, info.Size(), nil
+}
+
+// writeUserExportRows 写入表头和用户数据
+func writeUserExportRows(file *os.File, format string, columns []exportColumn, loc *time.Location,
+	stream func(fn func(*UserManagementModel) error) (int64, error)) (int64, error) {
+
+	buffered := bufio.NewWriter(file)
+
+	var writer exportWriter
+	if format == "excel" {
+		numeric := make([]bool, len(columns))
+		for i, column := range columns {
+			numeric[i] = column.Numeric
+		}
+		xlsxWriter, err := newXLSXExportWriter(buffered, numeric)
+		if err != nil {
+			return 0, err
+		}
+		writer = xlsxWriter
+	} else {
+		csvWriter, err := newCSVExportWriter(buffered)
+		if err != nil {
+			return 0, err
+		}
+		writer = csvWriter
+	}
+
+	headers := make([]string, len(columns))
+	for i, column := range columns {
+		headers[i] = column.Header
+	}
+	if err := writer.WriteRow(headers); err != nil {
+		return 0, err
+	}
+
+	values := make([]string, len(columns))
+	count, err := stream(func(u *UserManagementModel) error {
+		for i, column := range columns {
+			values[i] = column.Value(u, loc)
+		}
+		return writer.WriteRow(values)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if err := writer.Close(); err != nil {
+		return 0, err
+	}
+	if err := buffered.Flush(); err != nil {
+		return 0, fmt.Errorf("failed to write export file: %w", err)
+	}
+
+	return count, nil
+}
+
+// csvExportWriter CSV导出（带UTF-8 BOM，Excel直接打开时中文不乱码）
+type csvExportWriter struct {
+	writer *csv.Writer
+}
+
+// newCSVExportWriter 创建CSV导出
+func newCSVExportWriter(w io.Writer) (*csvExportWriter, error) {
+	if _, err := w.Write([]byte("\xEF\xBB\xBF")); err != nil {
+		return nil, fmt.Errorf("failed to write export file: %w", err)
+	}
+	return &csvExportWriter{writer: csv.NewWriter(w)}, nil
+}
+
+// WriteRow 写入一行，以公式字符开头的值加单引号前缀，防止在表格软件中被当作公式执行
+func (w *csvExportWriter) WriteRow(values []string) error {
+	record := make([]string, len(values))
+	for i, value := range values {
+		if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+			value = "'" + value
+		}
+		record[i] = value
+	}
+
+	if err := w.writer.Write(record); err != nil {
+		return fmt.Errorf("failed to write export row: %w", err)
+	}
+	return nil
+}
+
+// Close 刷新缓冲
+func (w *csvExportWriter) Close() error {
+	w.writer.Flush()
+	if err := w.writer.Error(); err != nil {
+		return fmt.Errorf("failed to write export file: %w", err)
+	}
+	return nil
+}
+
+// xlsx包中固定不变的部分
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`
+	xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`
+	xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Users" sheetId="1" r:id="rId1"/></sheets></workbook>`
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`
+	xlsxSheetHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	xlsxSheetFooter = `</sheetData></worksheet>`
+)
+
+// xlsxExportWriter Excel导出，工作表XML直接流式写入ZIP，不依赖第三方库
+type xlsxExportWriter struct {
+	archive *zip.Writer
+	sheet   io.Writer
+	numeric []bool
+	row     int
+}
+
+// newXLSXExportWriter 创建Excel导出，写入固定部分并打开工作表
+func newXLSXExportWriter(w io.Writer, numeric []bool) (*xlsxExportWriter, error) {
+	archive := zip.NewWriter(w)
+
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", xlsxWorkbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+	}
+	for _, part := range parts {
+		entry, err := archive.Create(part.name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add %s to export file: %w", part.name, err)
+		}
+		if _, err := io.WriteString(entry, part.content); err != nil {
+			return nil, fmt.Errorf("failed to write %s to export file: %w", part.name, err)
+		}
+	}
+
+	sheet, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, fmt.Errorf("failed to add worksheet to export file: %w", err)
+	}
+	if _, err := io.WriteString(sheet, xlsxSheetHeader); err != nil {
+		return nil, fmt.Errorf("failed to write worksheet: %w", err)
+	}
+
+	return &xlsxExportWriter{archive: archive, sheet: sheet, numeric: numeric}, nil
+}
+
+// WriteRow 写入一行（表头行全部为文本单元格）
+func (w *xlsxExportWriter) WriteRow(values []string) error {
+	w.row++
+
+	var row strings.Builder
+	fmt.Fprintf(&row, `<row r="%d">`, w.row)
+	for i, value := range values {
+		if w.row > 1 && i < len(w.numeric) && w.numeric[i] && value != "" {
+			fmt.Fprintf(&row, `<c><v>%s</v></c>`, value)
+			continue
+		}
+		row.WriteString(`<c t="inlineStr"><is><t xml:space="preserve">`)
+		if err := xml.EscapeText(&row, []byte(value)); err != nil {
+			return fmt.Errorf("failed to escape export value: %w", err)
+		}
+		row.WriteString(`</t></is></c>`)
+	}
+	row.WriteString(`</row>`)
+
+	if _, err := io.WriteString(w.sheet, row.String()); err != nil {
+		return fmt.Errorf("failed to write export row: %w", err)
+	}
+	return nil
+}
+
+// Close 结束工作表并写入ZIP目录
+func (w *xlsxExportWriter) Close() error {
+	if _, err := io.WriteString(w.sheet, xlsxSheetFooter); err != nil {
+		return fmt.Errorf("failed to write worksheet: %w", err)
+	}
+	if err := w.archive.Close(); err != nil {
+		return fmt.Errorf("failed to finalize export file: %w", err)
+	}
+	return nil
+}
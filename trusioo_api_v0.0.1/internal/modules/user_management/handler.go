@@ -2,10 +2,16 @@ package user_management
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
+	"trusioo_api_v0.0.1/internal/modules/auth"
+
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 )
 
@@ -39,11 +45,14 @@ func NewHandler(service *Service, logger *logrus.Logger) *Handler {
 // @Param name query string false "姓名筛选"
 // @Param status query string false "状态筛选" Enums(active,inactive,suspended)
 // @Param email_verified query bool false "邮箱验证状态筛选"
+// @Param tags query string false "标签筛选（逗号分隔，需同时拥有全部标签）"
 // @Param search query string false "搜索关键词"
+// @Param saved_search_id query string false "保存的搜索ID"
 // @Security ApiKeyAuth
 // @Success 200 {object} UserListResponse
 // @Failure 400 {object} object
 // @Failure 401 {object} object
+// @Failure 404 {object} object
 // @Failure 500 {object} object
 // @Router /api/v1/admin/user-management/users [get]
 func (h *Handler) GetUsers(c *gin.Context) {
@@ -57,16 +66,48 @@ func (h *Handler) GetUsers(c *gin.Context) {
 		return
 	}
 
+	h.listUsers(c, &req)
+}
+
+// SearchUsersAdvanced 高级查询用户
+// @Summary 高级查询用户
+// @Description 在请求体中传入用户列表的参数和高级查询条件树，条件可用and/or任意组合。支持的字段：email、name（eq/neq/contains/starts_with），status（eq/neq/in/not_in），email_verified、has_bank_account（eq），created_at、last_login_at（gt/gte/lt/lte/between/in_last_days/not_in_last_days，last_login_at另支持is_null/not_null），wallet_balance（eq/gt/gte/lt/lte/between），login_country、tag（eq/neq/in/not_in）
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Param request body GetUsersRequest true "高级查询请求"
+// @Security ApiKeyAuth
+// @Success 200 {object} UserListResponse
+// @Failure 400 {object} object
+// @Failure 401 {object} object
+// @Failure 404 {object} object
+// @Failure 500 {object} object
+// @Router /api/v1/admin/user-management/users/search [post]
+func (h *Handler) SearchUsersAdvanced(c *gin.Context) {
+	var req GetUsersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid advanced user search request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	h.listUsers(c, &req)
+}
+
+// listUsers 查询用户列表并返回
+func (h *Handler) listUsers(c *gin.Context, req *GetUsersRequest) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
 	defer cancel()
 
-	response, err := h.service.GetUsers(ctx, &req)
+	// 获取管理员信息
+	adminInfo := h.getAdminInfoFromContext(c)
+
+	response, err := h.service.GetUsers(ctx, req, adminInfo)
 	if err != nil {
-		h.logger.WithError(err).Error("Failed to get users")
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Internal server error",
-			"message": "Failed to retrieve users",
-		})
+		h.respondUserQueryError(c, err, "Failed to retrieve users")
 		return
 	}
 
@@ -201,6 +242,152 @@ func (h *Handler) GetStatistics(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// GetRegistrationStatistics 获取注册数时间序列
+// @Summary 获取注册数时间序列
+// @Description 按天、周或月统计注册用户数（包含之后已注销的用户），按指定时区划分自然日
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Param date_from query string false "开始日期" example(2024-01-01)
+// @Param date_to query string false "结束日期（包含）" example(2024-01-31)
+// @Param group_by query string false "分组粒度" Enums(day,week,month) default(day)
+// @Param timezone query string false "时区（IANA名称）" default(UTC)
+// @Security ApiKeyAuth
+// @Success 200 {object} TimeSeriesResponse
+// @Failure 400 {object} object
+// @Failure 401 {object} object
+// @Failure 500 {object} object
+// @Router /api/v1/admin/user-management/statistics/registration [get]
+func (h *Handler) GetRegistrationStatistics(c *gin.Context) {
+	h.getTimeSeries(c, StatisticsMetricRegistration)
+}
+
+// GetVerificationStatistics 获取邮箱验证数时间序列
+// @Summary 获取邮箱验证数时间序列
+// @Description 按天、周或月统计完成邮箱验证的用户数，按指定时区划分自然日
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Param date_from query string false "开始日期" example(2024-01-01)
+// @Param date_to query string false "结束日期（包含）" example(2024-01-31)
+// @Param group_by query string false "分组粒度" Enums(day,week,month) default(day)
+// @Param timezone query string false "时区（IANA名称）" default(UTC)
+// @Security ApiKeyAuth
+// @Success 200 {object} TimeSeriesResponse
+// @Failure 400 {object} object
+// @Failure 401 {object} object
+// @Failure 500 {object} object
+// @Router /api/v1/admin/user-management/statistics/verification [get]
+func (h *Handler) GetVerificationStatistics(c *gin.Context) {
+	h.getTimeSeries(c, StatisticsMetricVerification)
+}
+
+// GetActivityStatistics 获取活跃用户时间序列
+// @Summary 获取活跃用户时间序列
+// @Description 按天、周或月统计成功登录的去重用户数和登录次数，按指定时区划分自然日
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Param date_from query string false "开始日期" example(2024-01-01)
+// @Param date_to query string false "结束日期（包含）" example(2024-01-31)
+// @Param group_by query string false "分组粒度" Enums(day,week,month) default(day)
+// @Param timezone query string false "时区（IANA名称）" default(UTC)
+// @Security ApiKeyAuth
+// @Success 200 {object} TimeSeriesResponse
+// @Failure 400 {object} object
+// @Failure 401 {object} object
+// @Failure 500 {object} object
+// @Router /api/v1/admin/user-management/statistics/activity [get]
+func (h *Handler) GetActivityStatistics(c *gin.Context) {
+	h.getTimeSeries(c, StatisticsMetricActivity)
+}
+
+// GetRetentionStatistics 获取注册周留存
+// @Summary 获取注册周留存
+// @Description 按注册周分组，统计注册后第N周有成功登录的用户数和占比
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Param date_from query string false "注册周开始日期" example(2024-01-01)
+// @Param date_to query string false "注册周结束日期（包含）" example(2024-03-31)
+// @Param weeks query int false "跟踪注册后的周数" default(8)
+// @Param timezone query string false "时区（IANA名称）" default(UTC)
+// @Security ApiKeyAuth
+// @Success 200 {object} RetentionResponse
+// @Failure 400 {object} object
+// @Failure 401 {object} object
+// @Failure 500 {object} object
+// @Router /api/v1/admin/user-management/statistics/retention [get]
+func (h *Handler) GetRetentionStatistics(c *gin.Context) {
+	var req GetRetentionRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid get retention request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	response, err := h.service.GetRetention(ctx, &req)
+	if err != nil {
+		h.respondStatisticsError(c, err, "Failed to retrieve retention statistics")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// getTimeSeries 获取指定指标的时间序列
+func (h *Handler) getTimeSeries(c *gin.Context, metric string) {
+	var req GetStatisticsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid get statistics request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// 未汇总的时区需要实时计算，超时时间较长
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	response, err := h.service.GetTimeSeries(ctx, metric, &req)
+	if err != nil {
+		h.respondStatisticsError(c, err, "Failed to retrieve "+metric+" statistics")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// respondStatisticsError 统计接口的错误响应
+func (h *Handler) respondStatisticsError(c *gin.Context, err error, title string) {
+	switch {
+	case errors.Is(err, auth.ErrInvalidTimezone):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": "Unknown timezone, use an IANA name such as Asia/Shanghai",
+		})
+	case errors.Is(err, auth.ErrInvalidStatisticsRange):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": "date_from must not be after date_to, and the range may cover at most 366 buckets (53 weeks for retention)",
+		})
+	default:
+		h.logger.WithError(err).Error(title)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal server error",
+			"message": title,
+		})
+	}
+}
+
 // === 用户管理操作接口 ===
 
 // UpdateUserStatus 更新用户状态
@@ -321,7 +508,61 @@ func (h *Handler) SuspendUser(c *gin.Context) {
 			"reason":   req.Reason,
 		}).Error("Failed to suspend user")
 
-		if err.Error() == "user not found" {
+		switch {
+		case errors.Is(err, auth.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "User not found",
+				"message": "The specified user does not exist",
+			})
+		case errors.Is(err, auth.ErrInvalidSuspensionPeriod):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request",
+				"message": "The suspension end time must be in the future",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Internal server error",
+				"message": "Failed to suspend user",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetUserSuspensions 获取用户暂停历史
+// @Summary 获取用户暂停历史
+// @Description 获取用户的全部暂停记录（含当前生效的暂停），便于判断是否屡次违规
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Param user_id path string true "用户ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} UserSuspensionHistoryResponse
+// @Failure 400 {object} object
+// @Failure 401 {object} object
+// @Failure 404 {object} object
+// @Failure 500 {object} object
+// @Router /api/v1/admin/user-management/users/{user_id}/suspensions [get]
+func (h *Handler) GetUserSuspensions(c *gin.Context) {
+	userID := c.Param("user_id")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": "User ID is required",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	response, err := h.service.GetUserSuspensions(ctx, userID)
+	if err != nil {
+		h.logger.WithError(err).WithField("user_id", userID).Error("Failed to get user suspensions")
+
+		if errors.Is(err, auth.ErrUserNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error":   "User not found",
 				"message": "The specified user does not exist",
@@ -331,7 +572,7 @@ func (h *Handler) SuspendUser(c *gin.Context) {
 
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Internal server error",
-			"message": "Failed to suspend user",
+			"message": "Failed to get user suspensions",
 		})
 		return
 	}
@@ -439,6 +680,14 @@ func (h *Handler) ResetUserPassword(c *gin.Context) {
 
 	response, err := h.service.ResetUserPassword(ctx, userID, adminInfo.ID, adminInfo.Email, ipAddress, &req)
 	if err != nil {
+		if message, ok := auth.DescribePasswordError(err); ok {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Weak password",
+				"message": message,
+			})
+			return
+		}
+
 		h.logger.WithError(err).WithFields(logrus.Fields{
 			"user_id":  userID,
 			"admin_id": adminInfo.ID,
@@ -530,21 +779,71 @@ func (h *Handler) ForceLogoutUser(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
-// VerifyUserEmail 验证用户邮箱
-// @Summary 验证用户邮箱
-// @Description 管理员手动验证用户邮箱
+// GetUserLockout 获取用户登录锁定情况
+// @Summary 获取用户登录锁定情况
+// @Description 查看用户账户及各IP针对该账户的登录失败次数和锁定状态
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Param user_id path string true "用户ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} UserLockoutResponse
+// @Failure 400 {object} object
+// @Failure 401 {object} object
+// @Failure 404 {object} object
+// @Failure 500 {object} object
+// @Router /api/v1/admin/user-management/users/{user_id}/lockout [get]
+func (h *Handler) GetUserLockout(c *gin.Context) {
+	userID := c.Param("user_id")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": "User ID is required",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	response, err := h.service.GetUserLockout(ctx, userID)
+	if err != nil {
+		h.logger.WithError(err).WithField("user_id", userID).Error("Failed to get user lockout")
+
+		if err.Error() == "user not found" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "User not found",
+				"message": "The specified user does not exist",
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal server error",
+			"message": "Failed to retrieve user lockout",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// UnlockUser 解除用户登录锁定
+// @Summary 解除用户登录锁定
+// @Description 清除用户账户及各IP针对该账户的登录失败计数和锁定
 // @Tags 用户管理
 // @Accept json
 // @Produce json
 // @Param user_id path string true "用户ID"
+// @Param request body UnlockUserRequest true "解除锁定请求"
 // @Security ApiKeyAuth
 // @Success 200 {object} OperationResponse
 // @Failure 400 {object} object
 // @Failure 401 {object} object
 // @Failure 404 {object} object
 // @Failure 500 {object} object
-// @Router /api/v1/admin/user-management/users/{user_id}/verify-email [post]
-func (h *Handler) VerifyUserEmail(c *gin.Context) {
+// @Router /api/v1/admin/user-management/users/{user_id}/unlock [post]
+func (h *Handler) UnlockUser(c *gin.Context) {
 	userID := c.Param("user_id")
 	if userID == "" {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -554,6 +853,16 @@ func (h *Handler) VerifyUserEmail(c *gin.Context) {
 		return
 	}
 
+	var req UnlockUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid unlock user request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 	defer cancel()
 
@@ -561,12 +870,12 @@ func (h *Handler) VerifyUserEmail(c *gin.Context) {
 	adminInfo := h.getAdminInfoFromContext(c)
 	ipAddress := c.ClientIP()
 
-	response, err := h.service.VerifyUserEmail(ctx, userID, adminInfo.ID, adminInfo.Email, ipAddress)
+	response, err := h.service.UnlockUser(ctx, userID, adminInfo.ID, adminInfo.Email, ipAddress, &req)
 	if err != nil {
 		h.logger.WithError(err).WithFields(logrus.Fields{
 			"user_id":  userID,
 			"admin_id": adminInfo.ID,
-		}).Error("Failed to verify user email")
+		}).Error("Failed to unlock user")
 
 		if err.Error() == "user not found" {
 			c.JSON(http.StatusNotFound, gin.H{
@@ -578,7 +887,7 @@ func (h *Handler) VerifyUserEmail(c *gin.Context) {
 
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Internal server error",
-			"message": "Failed to verify user email",
+			"message": "Failed to unlock user",
 		})
 		return
 	}
@@ -586,6 +895,1935 @@ func (h *Handler) VerifyUserEmail(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// UpdateUserEmail 修改用户邮箱
+// @Summary 修改用户邮箱
+// @Description 管理员修改用户邮箱，原邮箱会收到通知和撤销链接
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Param user_id path string true "用户ID"
+// @Param request body UpdateUserEmailRequest true "修改邮箱请求"
+// @Security ApiKeyAuth
+// @Success 200 {object} OperationResponse
+// @Failure 400 {object} object
+// @Failure 401 {object} object
+// @Failure 404 {object} object
+// @Failure 409 {object} object
+// @Failure 500 {object} object
+// @Router /api/v1/admin/user-management/users/{user_id}/email [put]
+func (h *Handler) UpdateUserEmail(c *gin.Context) {
+	userID := c.Param("user_id")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": "User ID is required",
+		})
+		return
+	}
+
+	var req UpdateUserEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid update user email request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	// 获取管理员信息
+	adminInfo := h.getAdminInfoFromContext(c)
+	ipAddress := c.ClientIP()
+
+	response, err := h.service.UpdateUserEmail(ctx, userID, adminInfo.ID, adminInfo.Email, ipAddress, &req)
+	if err != nil {
+		h.logger.WithError(err).WithFields(logrus.Fields{
+			"user_id":  userID,
+			"admin_id": adminInfo.ID,
+		}).Error("Failed to update user email")
+
+		switch {
+		case errors.Is(err, auth.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "User not found",
+				"message": "The specified user does not exist",
+			})
+		case errors.Is(err, auth.ErrEmailUnchanged):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request",
+				"message": "The new email is the same as the current email",
+			})
+		case errors.Is(err, auth.ErrEmailInUse):
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "Email in use",
+				"message": "This email address is already in use",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Internal server error",
+				"message": "Failed to update user email",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// DeleteUser 注销用户
+// @Summary 注销用户
+// @Description 管理员注销用户账户，冷静期内可恢复，期满后匿名化个人数据（财务记录保留）
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Param user_id path string true "用户ID"
+// @Param request body DeleteUserRequest true "注销原因"
+// @Security ApiKeyAuth
+// @Success 200 {object} OperationResponse
+// @Failure 400 {object} object
+// @Failure 401 {object} object
+// @Failure 404 {object} object
+// @Failure 409 {object} object
+// @Failure 500 {object} object
+// @Router /api/v1/admin/user-management/users/{user_id} [delete]
+func (h *Handler) DeleteUser(c *gin.Context) {
+	userID := c.Param("user_id")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": "User ID is required",
+		})
+		return
+	}
+
+	var req DeleteUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid delete user request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	// 获取管理员信息
+	adminInfo := h.getAdminInfoFromContext(c)
+	ipAddress := c.ClientIP()
+
+	response, err := h.service.DeleteUser(ctx, userID, adminInfo.ID, adminInfo.Email, ipAddress, &req)
+	if err != nil {
+		h.logger.WithError(err).WithFields(logrus.Fields{
+			"user_id":  userID,
+			"admin_id": adminInfo.ID,
+		}).Error("Failed to delete user")
+
+		switch {
+		case errors.Is(err, auth.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "User not found",
+				"message": "The specified user does not exist or has already been deleted",
+			})
+		case errors.Is(err, auth.ErrWithdrawalsInProgress):
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "Withdrawals in progress",
+				"message": "The user has withdrawals in progress and cannot be deleted yet",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Internal server error",
+				"message": "Failed to delete user",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RestoreUser 恢复已注销用户
+// @Summary 恢复已注销用户
+// @Description 在冷静期内（个人数据匿名化之前）恢复已注销的用户账户
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Param user_id path string true "用户ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} OperationResponse
+// @Failure 400 {object} object
+// @Failure 401 {object} object
+// @Failure 404 {object} object
+// @Failure 500 {object} object
+// @Router /api/v1/admin/user-management/users/{user_id}/restore [post]
+func (h *Handler) RestoreUser(c *gin.Context) {
+	userID := c.Param("user_id")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": "User ID is required",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	// 获取管理员信息
+	adminInfo := h.getAdminInfoFromContext(c)
+	ipAddress := c.ClientIP()
+
+	response, err := h.service.RestoreUser(ctx, userID, adminInfo.ID, adminInfo.Email, ipAddress)
+	if err != nil {
+		h.logger.WithError(err).WithFields(logrus.Fields{
+			"user_id":  userID,
+			"admin_id": adminInfo.ID,
+		}).Error("Failed to restore user")
+
+		switch {
+		case errors.Is(err, auth.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "User not found",
+				"message": "The specified user does not exist",
+			})
+		case errors.Is(err, auth.ErrAccountDeletionNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "Nothing to restore",
+				"message": "The user is not deleted or has already been anonymized",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Internal server error",
+				"message": "Failed to restore user",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// VerifyUserEmail 验证用户邮箱
+// @Summary 验证用户邮箱
+// @Description 管理员手动验证用户邮箱
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Param user_id path string true "用户ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} OperationResponse
+// @Failure 400 {object} object
+// @Failure 401 {object} object
+// @Failure 404 {object} object
+// @Failure 500 {object} object
+// @Router /api/v1/admin/user-management/users/{user_id}/verify-email [post]
+func (h *Handler) VerifyUserEmail(c *gin.Context) {
+	userID := c.Param("user_id")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": "User ID is required",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	// 获取管理员信息
+	adminInfo := h.getAdminInfoFromContext(c)
+	ipAddress := c.ClientIP()
+
+	response, err := h.service.VerifyUserEmail(ctx, userID, adminInfo.ID, adminInfo.Email, ipAddress)
+	if err != nil {
+		h.logger.WithError(err).WithFields(logrus.Fields{
+			"user_id":  userID,
+			"admin_id": adminInfo.ID,
+		}).Error("Failed to verify user email")
+
+		if err.Error() == "user not found" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "User not found",
+				"message": "The specified user does not exist",
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal server error",
+			"message": "Failed to verify user email",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// === 备注、标签和关注接口 ===
+
+// ListUserNotes 获取用户备注列表
+// @Summary 获取用户备注列表
+// @Description 获取管理员对用户的内部备注，按时间倒序
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Param user_id path string true "用户ID"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Security ApiKeyAuth
+// @Success 200 {object} UserNoteListResponse
+// @Failure 400 {object} object
+// @Failure 401 {object} object
+// @Failure 403 {object} object
+// @Failure 404 {object} object
+// @Failure 500 {object} object
+// @Router /api/v1/admin/user-management/users/{user_id}/notes [get]
+func (h *Handler) ListUserNotes(c *gin.Context) {
+	userID, ok := h.userIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req ListUserNotesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid list user notes request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	response, err := h.service.ListUserNotes(ctx, userID, &req)
+	if err != nil {
+		h.respondAnnotationError(c, err, "Failed to get user notes")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// CreateUserNote 添加用户备注
+// @Summary 添加用户备注
+// @Description 为用户添加一条内部备注，记录作者和时间，并写入管理操作日志
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Param user_id path string true "用户ID"
+// @Param request body CreateUserNoteRequest true "添加备注请求"
+// @Security ApiKeyAuth
+// @Success 201 {object} UserNote
+// @Failure 400 {object} object
+// @Failure 401 {object} object
+// @Failure 403 {object} object
+// @Failure 404 {object} object
+// @Failure 500 {object} object
+// @Router /api/v1/admin/user-management/users/{user_id}/notes [post]
+func (h *Handler) CreateUserNote(c *gin.Context) {
+	userID, ok := h.userIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req CreateUserNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid create user note request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	// 获取管理员信息
+	adminInfo := h.getAdminInfoFromContext(c)
+
+	note, err := h.service.CreateUserNote(ctx, userID, adminInfo, c.ClientIP(), &req)
+	if err != nil {
+		h.respondAnnotationError(c, err, "Failed to create user note")
+		return
+	}
+
+	c.JSON(http.StatusCreated, note)
+}
+
+// DeleteUserNote 删除用户备注
+// @Summary 删除用户备注
+// @Description 删除用户备注，仅备注作者或超级管理员可删除，被删除的内容保留在管理操作日志中
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Param user_id path string true "用户ID"
+// @Param note_id path string true "备注ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} OperationResponse
+// @Failure 400 {object} object
+// @Failure 401 {object} object
+// @Failure 403 {object} object
+// @Failure 404 {object} object
+// @Failure 500 {object} object
+// @Router /api/v1/admin/user-management/users/{user_id}/notes/{note_id} [delete]
+func (h *Handler) DeleteUserNote(c *gin.Context) {
+	userID, ok := h.userIDParam(c)
+	if !ok {
+		return
+	}
+
+	noteID := c.Param("note_id")
+	if _, err := uuid.Parse(noteID); err != nil {
+		h.respondAnnotationError(c, auth.ErrUserNoteNotFound, "Failed to delete user note")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	// 获取管理员信息
+	adminInfo := h.getAdminInfoFromContext(c)
+
+	response, err := h.service.DeleteUserNote(ctx, userID, noteID, adminInfo, c.ClientIP())
+	if err != nil {
+		h.respondAnnotationError(c, err, "Failed to delete user note")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ListTags 获取全部用户标签
+// @Summary 获取全部用户标签
+// @Description 获取已使用的用户标签及使用人数，可用于用户列表的标签筛选
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} TagListResponse
+// @Failure 401 {object} object
+// @Failure 403 {object} object
+// @Failure 500 {object} object
+// @Router /api/v1/admin/user-management/tags [get]
+func (h *Handler) ListTags(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	response, err := h.service.ListTags(ctx)
+	if err != nil {
+		h.respondAnnotationError(c, err, "Failed to get tags")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// AddUserTags 添加用户标签
+// @Summary 添加用户标签
+// @Description 为用户添加标签（小写字母、数字、-和_，最长32个字符），已有的标签忽略
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Param user_id path string true "用户ID"
+// @Param request body AddUserTagsRequest true "添加标签请求"
+// @Security ApiKeyAuth
+// @Success 200 {object} UserTagsResponse
+// @Failure 400 {object} object
+// @Failure 401 {object} object
+// @Failure 403 {object} object
+// @Failure 404 {object} object
+// @Failure 500 {object} object
+// @Router /api/v1/admin/user-management/users/{user_id}/tags [post]
+func (h *Handler) AddUserTags(c *gin.Context) {
+	userID, ok := h.userIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req AddUserTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid add user tags request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	// 获取管理员信息
+	adminInfo := h.getAdminInfoFromContext(c)
+
+	response, err := h.service.AddUserTags(ctx, userID, adminInfo, c.ClientIP(), &req)
+	if err != nil {
+		h.respondAnnotationError(c, err, "Failed to add user tags")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RemoveUserTag 移除用户标签
+// @Summary 移除用户标签
+// @Description 移除用户的一个标签，返回用户当前的标签
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Param user_id path string true "用户ID"
+// @Param tag path string true "标签"
+// @Security ApiKeyAuth
+// @Success 200 {object} UserTagsResponse
+// @Failure 400 {object} object
+// @Failure 401 {object} object
+// @Failure 403 {object} object
+// @Failure 404 {object} object
+// @Failure 500 {object} object
+// @Router /api/v1/admin/user-management/users/{user_id}/tags/{tag} [delete]
+func (h *Handler) RemoveUserTag(c *gin.Context) {
+	userID, ok := h.userIDParam(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	// 获取管理员信息
+	adminInfo := h.getAdminInfoFromContext(c)
+
+	response, err := h.service.RemoveUserTag(ctx, userID, c.Param("tag"), adminInfo, c.ClientIP())
+	if err != nil {
+		h.respondAnnotationError(c, err, "Failed to remove user tag")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// WatchUser 关注用户
+// @Summary 关注用户
+// @Description 关注用户，用户登录或添加银行账户时通知当前管理员（站内通知和邮件）；已关注时更新关注的事件和原因
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Param user_id path string true "用户ID"
+// @Param request body WatchUserRequest true "关注请求"
+// @Security ApiKeyAuth
+// @Success 200 {object} UserWatch
+// @Failure 400 {object} object
+// @Failure 401 {object} object
+// @Failure 403 {object} object
+// @Failure 404 {object} object
+// @Failure 500 {object} object
+// @Router /api/v1/admin/user-management/users/{user_id}/watch [put]
+func (h *Handler) WatchUser(c *gin.Context) {
+	userID, ok := h.userIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req WatchUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid watch user request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	// 获取管理员信息
+	adminInfo := h.getAdminInfoFromContext(c)
+
+	watch, err := h.service.WatchUser(ctx, userID, adminInfo, &req)
+	if err != nil {
+		h.respondAnnotationError(c, err, "Failed to watch user")
+		return
+	}
+
+	c.JSON(http.StatusOK, watch)
+}
+
+// UnwatchUser 取消关注用户
+// @Summary 取消关注用户
+// @Description 将用户从当前管理员的关注列表移除
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Param user_id path string true "用户ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} OperationResponse
+// @Failure 400 {object} object
+// @Failure 401 {object} object
+// @Failure 403 {object} object
+// @Failure 404 {object} object
+// @Failure 500 {object} object
+// @Router /api/v1/admin/user-management/users/{user_id}/watch [delete]
+func (h *Handler) UnwatchUser(c *gin.Context) {
+	userID, ok := h.userIDParam(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	// 获取管理员信息
+	adminInfo := h.getAdminInfoFromContext(c)
+
+	response, err := h.service.UnwatchUser(ctx, userID, adminInfo)
+	if err != nil {
+		h.respondAnnotationError(c, err, "Failed to unwatch user")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ListWatches 获取关注列表
+// @Summary 获取关注列表
+// @Description 获取当前管理员关注的用户
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Security ApiKeyAuth
+// @Success 200 {object} WatchListResponse
+// @Failure 400 {object} object
+// @Failure 401 {object} object
+// @Failure 403 {object} object
+// @Failure 500 {object} object
+// @Router /api/v1/admin/user-management/watchlist [get]
+func (h *Handler) ListWatches(c *gin.Context) {
+	var req ListWatchesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid list watches request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	// 获取管理员信息
+	adminInfo := h.getAdminInfoFromContext(c)
+
+	response, err := h.service.ListWatches(ctx, adminInfo, &req)
+	if err != nil {
+		h.respondAnnotationError(c, err, "Failed to get watchlist")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ListWatchNotifications 获取关注通知
+// @Summary 获取关注通知
+// @Description 获取当前管理员关注的用户的活动通知，按时间倒序
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Param unread query bool false "只返回未读通知"
+// @Security ApiKeyAuth
+// @Success 200 {object} WatchNotificationListResponse
+// @Failure 400 {object} object
+// @Failure 401 {object} object
+// @Failure 403 {object} object
+// @Failure 500 {object} object
+// @Router /api/v1/admin/user-management/watchlist/notifications [get]
+func (h *Handler) ListWatchNotifications(c *gin.Context) {
+	var req ListWatchNotificationsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid list watch notifications request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	// 获取管理员信息
+	adminInfo := h.getAdminInfoFromContext(c)
+
+	response, err := h.service.ListWatchNotifications(ctx, adminInfo, &req)
+	if err != nil {
+		h.respondAnnotationError(c, err, "Failed to get watch notifications")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// MarkWatchNotificationsRead 标记关注通知已读
+// @Summary 标记关注通知已读
+// @Description 将当前管理员的指定通知标记为已读，未指定ID时全部标记为已读
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Param request body MarkWatchNotificationsReadRequest false "标记已读请求"
+// @Security ApiKeyAuth
+// @Success 200 {object} OperationResponse
+// @Failure 400 {object} object
+// @Failure 401 {object} object
+// @Failure 403 {object} object
+// @Failure 500 {object} object
+// @Router /api/v1/admin/user-management/watchlist/notifications/read [post]
+func (h *Handler) MarkWatchNotificationsRead(c *gin.Context) {
+	var req MarkWatchNotificationsReadRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			h.logger.WithError(err).Warn("Invalid mark watch notifications read request")
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request",
+				"message": err.Error(),
+			})
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	// 获取管理员信息
+	adminInfo := h.getAdminInfoFromContext(c)
+
+	response, err := h.service.MarkWatchNotificationsRead(ctx, adminInfo, &req)
+	if err != nil {
+		h.respondAnnotationError(c, err, "Failed to mark watch notifications read")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// userIDParam 获取并校验路径中的用户ID，无效时直接返回400
+func (h *Handler) userIDParam(c *gin.Context) (string, bool) {
+	userID := c.Param("user_id")
+	if _, err := uuid.Parse(userID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": "Invalid user ID",
+		})
+		return "", false
+	}
+	return userID, true
+}
+
+// respondAnnotationError 备注、标签和关注的错误响应
+func (h *Handler) respondAnnotationError(c *gin.Context, err error, title string) {
+	switch {
+	case errors.Is(err, auth.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "User not found",
+			"message": "The specified user does not exist",
+		})
+	case errors.Is(err, auth.ErrUserNoteNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Note not found",
+			"message": "The specified note does not exist",
+		})
+	case errors.Is(err, auth.ErrUserWatchNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Watch not found",
+			"message": "You are not watching this user",
+		})
+	case errors.Is(err, auth.ErrEmptyUserNote):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": "Note content must not be empty",
+		})
+	case errors.Is(err, auth.ErrInvalidUserTag):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": "Tags may only contain lowercase letters, digits, '-' and '_', start with a letter or digit and be at most 32 characters",
+		})
+	case errors.Is(err, auth.ErrTooManyUserTags):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   title,
+			"message": "A user can have at most 20 tags",
+		})
+	case errors.Is(err, auth.ErrInvalidWatchEvent):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": "Watch events must be login or bank_account_added",
+		})
+	case errors.Is(err, auth.ErrPermissionDenied):
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "Forbidden",
+			"message": "Only the author of the note or a super admin can delete it",
+		})
+	default:
+		h.logger.WithError(err).Error(title)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal server error",
+			"message": title,
+		})
+	}
+}
+
+// === 用户导出接口 ===
+
+// ExportUsers 导出用户
+// @Summary 导出用户
+// @Description 按用户列表的过滤条件导出用户为CSV或Excel，可选择字段和时区，返回带签名的限时下载链接
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Param format query string false "导出格式" Enums(csv,excel) default(csv)
+// @Param fields query string false "导出字段（逗号分隔）" default(id,email,name,status,email_verified,email_verified_at,last_login_at,login_count,created_at,updated_at)
+// @Param timezone query string false "时间字段的时区" default(UTC)
+// @Param status query string false "状态筛选" Enums(active,inactive,suspended)
+// @Param search query string false "搜索关键词"
+// @Security ApiKeyAuth
+// @Success 200 {object} ExportResponse
+// @Failure 400 {object} object
+// @Failure 401 {object} object
+// @Failure 500 {object} object
+// @Router /api/v1/admin/user-management/export/users [get]
+func (h *Handler) ExportUsers(c *gin.Context) {
+	var req ExportUsersRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid export users request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// 导出逐行写入文件，大量用户时耗时较长
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Minute)
+	defer cancel()
+
+	// 获取管理员信息
+	adminInfo := h.getAdminInfoFromContext(c)
+
+	response, err := h.service.ExportUsers(ctx, &req, adminInfo)
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrInvalidExportField):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request",
+				"message": err.Error(),
+			})
+		case errors.Is(err, auth.ErrInvalidTimezone):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request",
+				"message": "Unknown timezone, use an IANA name such as Asia/Shanghai",
+			})
+		case errors.Is(err, auth.ErrInvalidUserQuery), errors.Is(err, auth.ErrSavedSearchNotFound):
+			h.respondUserQueryError(c, err, "Failed to export users")
+		default:
+			h.logger.WithError(err).WithField("admin_id", adminInfo.ID).Error("Failed to export users")
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Internal server error",
+				"message": "Failed to export users",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// DownloadExportFile 下载导出文件
+// @Summary 下载导出文件
+// @Description 通过导出接口返回的签名链接下载文件，链接过期后失效（无需认证）
+// @Tags 用户管理
+// @Produce octet-stream
+// @Param file_name path string true "文件名"
+// @Param expires query int true "过期时间（Unix秒）"
+// @Param signature query string true "签名"
+// @Success 200 {file} file
+// @Failure 404 {object} object
+// @Router /api/v1/admin/user-management/downloads/{file_name} [get]
+func (h *Handler) DownloadExportFile(c *gin.Context) {
+	fileName := c.Param("file_name")
+
+	path, err := h.service.ResolveExportDownload(fileName, c.Query("expires"), c.Query("signature"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Invalid link",
+			"message": "This download link is invalid or has expired",
+		})
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"file_name":  fileName,
+		"ip_address": c.ClientIP(),
+	}).Info("User export downloaded")
+
+	if strings.HasSuffix(fileName, ".xlsx") {
+		c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	} else {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+	}
+	c.FileAttachment(path, fileName)
+}
+
+// === 审计日志接口 ===
+
+// GetAllManagementLogs 查询管理操作日志
+// @Summary 查询管理操作日志
+// @Description 按管理员、操作类型、目标用户、日期范围和原因关键词查询管理操作日志，按写入顺序倒序
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Param admin_id query string false "管理员ID"
+// @Param action query string false "操作类型"
+// @Param target_user_id query string false "目标用户ID"
+// @Param date_from query string false "开始日期（UTC）" example(2024-01-01)
+// @Param date_to query string false "结束日期（UTC，包含当天）" example(2024-12-31)
+// @Param q query string false "原因关键词"
+// @Security ApiKeyAuth
+// @Success 200 {object} ManagementLogListResponse
+// @Failure 400 {object} object
+// @Failure 401 {object} object
+// @Failure 403 {object} object
+// @Failure 500 {object} object
+// @Router /api/v1/admin/user-management/logs [get]
+func (h *Handler) GetAllManagementLogs(c *gin.Context) {
+	var req GetManagementLogsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid get management logs request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	response, err := h.service.GetManagementLogs(ctx, &req)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to get management logs")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal server error",
+			"message": "Failed to get management logs",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetUserManagementLogs 查询针对某个用户的管理操作日志
+// @Summary 查询用户的管理操作日志
+// @Description 查询针对指定用户的管理操作日志（已注销的用户也可查询），支持与日志列表相同的过滤条件
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Param user_id path string true "用户ID"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Param admin_id query string false "管理员ID"
+// @Param action query string false "操作类型"
+// @Param date_from query string false "开始日期（UTC）" example(2024-01-01)
+// @Param date_to query string false "结束日期（UTC，包含当天）" example(2024-12-31)
+// @Param q query string false "原因关键词"
+// @Security ApiKeyAuth
+// @Success 200 {object} ManagementLogListResponse
+// @Failure 400 {object} object
+// @Failure 401 {object} object
+// @Failure 403 {object} object
+// @Failure 500 {object} object
+// @Router /api/v1/admin/user-management/users/{user_id}/logs [get]
+func (h *Handler) GetUserManagementLogs(c *gin.Context) {
+	userID := c.Param("user_id")
+	if _, err := uuid.Parse(userID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": "Invalid user ID",
+		})
+		return
+	}
+
+	var req GetManagementLogsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid get user management logs request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	response, err := h.service.GetUserManagementLogs(ctx, userID, &req)
+	if err != nil {
+		h.logger.WithError(err).WithField("user_id", userID).Error("Failed to get user management logs")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal server error",
+			"message": "Failed to get user management logs",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ExportManagementLogs 导出管理操作日志
+// @Summary 导出管理操作日志
+// @Description 按与日志列表相同的过滤条件将管理操作日志导出为CSV（直接下载，包含hash便于离线核对）
+// @Tags 用户管理
+// @Produce text/csv
+// @Param admin_id query string false "管理员ID"
+// @Param action query string false "操作类型"
+// @Param target_user_id query string false "目标用户ID"
+// @Param date_from query string false "开始日期（UTC）" example(2024-01-01)
+// @Param date_to query string false "结束日期（UTC，包含当天）" example(2024-12-31)
+// @Param q query string false "原因关键词"
+// @Param timezone query string false "时间列使用的时区（IANA名称）" default(UTC)
+// @Security ApiKeyAuth
+// @Success 200 {file} file
+// @Failure 400 {object} object
+// @Failure 401 {object} object
+// @Failure 403 {object} object
+// @Failure 500 {object} object
+// @Router /api/v1/admin/user-management/logs/export [get]
+func (h *Handler) ExportManagementLogs(c *gin.Context) {
+	var req ExportManagementLogsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid export management logs request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Minute)
+	defer cancel()
+
+	adminInfo := h.getAdminInfoFromContext(c)
+	fileName := "management_logs_" + time.Now().UTC().Format("20060102_150405") + ".csv"
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="`+fileName+`"`)
+
+	count, err := h.service.ExportManagementLogs(ctx, &req, c.Writer)
+	if err != nil {
+		// 已开始输出文件内容时无法再返回错误响应，只记录日志
+		if c.Writer.Written() {
+			h.logger.WithError(err).WithField("admin_id", adminInfo.ID).Error("Management log export interrupted")
+			return
+		}
+
+		c.Writer.Header().Del("Content-Disposition")
+		if errors.Is(err, auth.ErrInvalidTimezone) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request",
+				"message": "Unknown timezone, use an IANA name such as Asia/Shanghai",
+			})
+			return
+		}
+
+		h.logger.WithError(err).WithField("admin_id", adminInfo.ID).Error("Failed to export management logs")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal server error",
+			"message": "Failed to export management logs",
+		})
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"admin_id": adminInfo.ID,
+		"rows":     count,
+	}).Info("Management logs exported")
+}
+
+// VerifyManagementLogs 校验管理操作日志哈希链
+// @Summary 校验管理操作日志哈希链
+// @Description 按写入顺序重新计算全部管理操作日志的hash，检查记录是否被修改、删除或插入；末尾记录被删除通过签名的检查点发现
+// @Tags 用户管理
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} LogChainVerificationResponse
+// @Failure 401 {object} object
+// @Failure 403 {object} object
+// @Failure 500 {object} object
+// @Router /api/v1/admin/user-management/logs/verify [get]
+func (h *Handler) VerifyManagementLogs(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Minute)
+	defer cancel()
+
+	response, err := h.service.VerifyManagementLogChain(ctx)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to verify management logs")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal server error",
+			"message": "Failed to verify management logs",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// === 批量操作接口 ===
+
+// BatchUpdateUserStatus 批量更新用户状态
+// @Summary 批量更新用户状态
+// @Description 按用户ID列表或筛选条件批量更新用户状态，异步执行；dry_run为true时只返回预计影响的用户数
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Param request body BatchUpdateUserStatusRequest true "批量更新状态请求"
+// @Security ApiKeyAuth
+// @Success 200 {object} BatchPreviewResponse
+// @Success 202 {object} BatchJobResponse
+// @Failure 400 {object} object
+// @Failure 401 {object} object
+// @Failure 500 {object} object
+// @Router /api/v1/admin/user-management/users/batch/status [post]
+func (h *Handler) BatchUpdateUserStatus(c *gin.Context) {
+	var req BatchUpdateUserStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid batch update user status request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	h.submitBatchJob(c, BatchActionUpdateStatus, &req.BatchTargetRequest, &req.UpdateUserStatusRequest)
+}
+
+// BatchSuspendUsers 批量暂停用户
+// @Summary 批量暂停用户
+// @Description 按用户ID列表或筛选条件批量暂停用户，异步执行；duration从每个用户实际执行时开始计算
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Param request body BatchSuspendUsersRequest true "批量暂停请求"
+// @Security ApiKeyAuth
+// @Success 200 {object} BatchPreviewResponse
+// @Success 202 {object} BatchJobResponse
+// @Failure 400 {object} object
+// @Failure 401 {object} object
+// @Failure 500 {object} object
+// @Router /api/v1/admin/user-management/users/batch/suspend [post]
+func (h *Handler) BatchSuspendUsers(c *gin.Context) {
+	var req BatchSuspendUsersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid batch suspend users request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	h.submitBatchJob(c, BatchActionSuspend, &req.BatchTargetRequest, &req.SuspendUserRequest)
+}
+
+// BatchReactivateUsers 批量重新激活用户
+// @Summary 批量重新激活用户
+// @Description 按用户ID列表或筛选条件批量重新激活用户，异步执行；已激活的用户会被跳过
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Param request body BatchReactivateUsersRequest true "批量重新激活请求"
+// @Security ApiKeyAuth
+// @Success 200 {object} BatchPreviewResponse
+// @Success 202 {object} BatchJobResponse
+// @Failure 400 {object} object
+// @Failure 401 {object} object
+// @Failure 500 {object} object
+// @Router /api/v1/admin/user-management/users/batch/reactivate [post]
+func (h *Handler) BatchReactivateUsers(c *gin.Context) {
+	var req BatchReactivateUsersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid batch reactivate users request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	h.submitBatchJob(c, BatchActionReactivate, &req.BatchTargetRequest, struct{}{})
+}
+
+// BatchForceLogoutUsers 批量强制用户登出
+// @Summary 批量强制用户登出
+// @Description 按用户ID列表或筛选条件批量强制用户登出，异步执行；没有活跃会话的用户会被跳过
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Param request body BatchForceLogoutRequest true "批量强制登出请求"
+// @Security ApiKeyAuth
+// @Success 200 {object} BatchPreviewResponse
+// @Success 202 {object} BatchJobResponse
+// @Failure 400 {object} object
+// @Failure 401 {object} object
+// @Failure 500 {object} object
+// @Router /api/v1/admin/user-management/users/batch/force-logout [post]
+func (h *Handler) BatchForceLogoutUsers(c *gin.Context) {
+	var req BatchForceLogoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid batch force logout request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	h.submitBatchJob(c, BatchActionForceLogout, &req.BatchTargetRequest, &req.ForceLogoutRequest)
+}
+
+// submitBatchJob 预览或创建批量操作任务
+func (h *Handler) submitBatchJob(c *gin.Context, action BatchAction, target *BatchTargetRequest, params interface{}) {
+	// 解析筛选条件可能涉及大量用户
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	// 获取管理员信息
+	adminInfo := h.getAdminInfoFromContext(c)
+	ipAddress := c.ClientIP()
+
+	if target.DryRun {
+		preview, err := h.service.PreviewBatchJob(ctx, action, target, params, adminInfo)
+		if err != nil {
+			h.respondBatchError(c, err, "Failed to preview batch operation")
+			return
+		}
+		c.JSON(http.StatusOK, preview)
+		return
+	}
+
+	job, err := h.service.CreateBatchJob(ctx, action, target, params, adminInfo, ipAddress)
+	if err != nil {
+		h.respondBatchError(c, err, "Failed to create batch job")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+// ListBatchJobs 获取批量操作任务列表
+// @Summary 获取批量操作任务列表
+// @Description 获取批量操作任务列表（按创建时间倒序），可按状态筛选
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Param status query string false "状态筛选" Enums(awaiting_approval,queued,running,completed,cancelled)
+// @Security ApiKeyAuth
+// @Success 200 {object} BatchJobListResponse
+// @Failure 400 {object} object
+// @Failure 401 {object} object
+// @Failure 500 {object} object
+// @Router /api/v1/admin/user-management/batch-jobs [get]
+func (h *Handler) ListBatchJobs(c *gin.Context) {
+	var req ListBatchJobsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid list batch jobs request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	response, err := h.service.ListBatchJobs(ctx, &req)
+	if err != nil {
+		h.respondBatchError(c, err, "Failed to get batch jobs")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetBatchJob 获取批量操作任务进度
+// @Summary 获取批量操作任务进度
+// @Description 获取批量操作任务的状态、进度和成功/失败/跳过数量，用于轮询
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Param job_id path string true "任务ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} BatchJobResponse
+// @Failure 401 {object} object
+// @Failure 404 {object} object
+// @Failure 500 {object} object
+// @Router /api/v1/admin/user-management/batch-jobs/{job_id} [get]
+func (h *Handler) GetBatchJob(c *gin.Context) {
+	jobID, ok := h.batchJobIDParam(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	response, err := h.service.GetBatchJob(ctx, jobID)
+	if err != nil {
+		h.respondBatchError(c, err, "Failed to get batch job")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetBatchJobItems 获取批量操作中每个用户的执行结果
+// @Summary 获取批量操作执行结果
+// @Description 分页获取批量操作中每个用户的执行结果，可按结果状态筛选
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Param job_id path string true "任务ID"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Param status query string false "状态筛选" Enums(pending,succeeded,failed,skipped,cancelled)
+// @Security ApiKeyAuth
+// @Success 200 {object} BatchJobItemListResponse
+// @Failure 400 {object} object
+// @Failure 401 {object} object
+// @Failure 404 {object} object
+// @Failure 500 {object} object
+// @Router /api/v1/admin/user-management/batch-jobs/{job_id}/items [get]
+func (h *Handler) GetBatchJobItems(c *gin.Context) {
+	jobID, ok := h.batchJobIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req ListBatchJobItemsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid list batch job items request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	response, err := h.service.ListBatchJobItems(ctx, jobID, &req)
+	if err != nil {
+		h.respondBatchError(c, err, "Failed to get batch job items")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// CancelBatchJob 取消批量操作任务
+// @Summary 取消批量操作任务
+// @Description 取消等待审批、排队中或执行中的批量操作任务（仅提交人或超级管理员），已执行的用户不会回滚
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Param job_id path string true "任务ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} BatchJobResponse
+// @Failure 401 {object} object
+// @Failure 403 {object} object
+// @Failure 404 {object} object
+// @Failure 409 {object} object
+// @Failure 500 {object} object
+// @Router /api/v1/admin/user-management/batch-jobs/{job_id}/cancel [post]
+func (h *Handler) CancelBatchJob(c *gin.Context) {
+	jobID, ok := h.batchJobIDParam(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	// 获取管理员信息
+	adminInfo := h.getAdminInfoFromContext(c)
+
+	response, err := h.service.CancelBatchJob(ctx, jobID, adminInfo)
+	if err != nil {
+		h.respondBatchError(c, err, "Failed to cancel batch job")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ApproveBatchJob 审批批量操作任务
+// @Summary 审批批量操作任务
+// @Description 超级管理员审批超过用户数阈值的批量操作任务，审批后开始执行
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Param job_id path string true "任务ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} BatchJobResponse
+// @Failure 401 {object} object
+// @Failure 403 {object} object
+// @Failure 404 {object} object
+// @Failure 409 {object} object
+// @Failure 500 {object} object
+// @Router /api/v1/admin/user-management/batch-jobs/{job_id}/approve [post]
+func (h *Handler) ApproveBatchJob(c *gin.Context) {
+	jobID, ok := h.batchJobIDParam(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	// 获取管理员信息
+	adminInfo := h.getAdminInfoFromContext(c)
+
+	response, err := h.service.ApproveBatchJob(ctx, jobID, adminInfo)
+	if err != nil {
+		h.respondBatchError(c, err, "Failed to approve batch job")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// batchJobIDParam 获取并校验路径中的任务ID，无效时直接返回404
+func (h *Handler) batchJobIDParam(c *gin.Context) (string, bool) {
+	jobID := c.Param("job_id")
+	if _, err := uuid.Parse(jobID); err != nil {
+		h.respondBatchError(c, auth.ErrBatchJobNotFound, "Failed to get batch job")
+		return "", false
+	}
+	return jobID, true
+}
+
+// respondBatchError 批量操作的错误响应
+func (h *Handler) respondBatchError(c *gin.Context, err error, title string) {
+	switch {
+	case errors.Is(err, auth.ErrInvalidBatchTarget):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": "Provide either user_ids or filter, but not both",
+		})
+	case errors.Is(err, auth.ErrInvalidSuspensionPeriod):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": "The suspension end time must be in the future",
+		})
+	case errors.Is(err, auth.ErrBatchNoTargets):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   title,
+			"message": "No users matched the batch operation",
+		})
+	case errors.Is(err, auth.ErrBatchTooManyUsers):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   title,
+			"message": "Too many users for a single batch operation, narrow down the filter",
+		})
+	case errors.Is(err, auth.ErrBatchJobNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Batch job not found",
+			"message": "The specified batch job does not exist",
+		})
+	case errors.Is(err, auth.ErrPermissionDenied):
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "Forbidden",
+			"message": "Only the admin who submitted the batch job or a super admin can cancel it",
+		})
+	case errors.Is(err, auth.ErrBatchJobNotCancellable):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   title,
+			"message": "The batch job has already finished",
+		})
+	case errors.Is(err, auth.ErrBatchJobNotAwaitingApproval):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   title,
+			"message": "The batch job is not awaiting approval",
+		})
+	case errors.Is(err, auth.ErrInvalidUserQuery), errors.Is(err, auth.ErrSavedSearchNotFound):
+		h.respondUserQueryError(c, err, title)
+	default:
+		h.logger.WithError(err).Error(title)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal server error",
+			"message": title,
+		})
+	}
+}
+
+// === 保存的搜索接口 ===
+
+// ListSavedSearches 获取保存的搜索
+// @Summary 获取保存的搜索
+// @Description 获取当前管理员保存的用户搜索（按名称排序）
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} SavedSearchListResponse
+// @Failure 401 {object} object
+// @Failure 500 {object} object
+// @Router /api/v1/admin/user-management/saved-searches [get]
+func (h *Handler) ListSavedSearches(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	// 获取管理员信息
+	adminInfo := h.getAdminInfoFromContext(c)
+
+	response, err := h.service.ListSavedSearches(ctx, adminInfo)
+	if err != nil {
+		h.respondUserQueryError(c, err, "Failed to get saved searches")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// CreateSavedSearch 保存搜索
+// @Summary 保存搜索
+// @Description 保存命名的用户高级查询，之后可通过saved_search_id用于用户列表、导出和批量操作
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Param request body CreateSavedSearchRequest true "保存搜索请求"
+// @Security ApiKeyAuth
+// @Success 201 {object} SavedSearch
+// @Failure 400 {object} object
+// @Failure 401 {object} object
+// @Failure 409 {object} object
+// @Failure 500 {object} object
+// @Router /api/v1/admin/user-management/saved-searches [post]
+func (h *Handler) CreateSavedSearch(c *gin.Context) {
+	var req CreateSavedSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid create saved search request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	// 获取管理员信息
+	adminInfo := h.getAdminInfoFromContext(c)
+
+	search, err := h.service.CreateSavedSearch(ctx, adminInfo, &req)
+	if err != nil {
+		h.respondUserQueryError(c, err, "Failed to save search")
+		return
+	}
+
+	c.JSON(http.StatusCreated, search)
+}
+
+// GetSavedSearch 获取保存的搜索详情
+// @Summary 获取保存的搜索详情
+// @Description 获取当前管理员保存的一个用户搜索
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Param search_id path string true "保存的搜索ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} SavedSearch
+// @Failure 401 {object} object
+// @Failure 404 {object} object
+// @Failure 500 {object} object
+// @Router /api/v1/admin/user-management/saved-searches/{search_id} [get]
+func (h *Handler) GetSavedSearch(c *gin.Context) {
+	searchID, ok := h.savedSearchIDParam(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	// 获取管理员信息
+	adminInfo := h.getAdminInfoFromContext(c)
+
+	search, err := h.service.GetSavedSearch(ctx, searchID, adminInfo)
+	if err != nil {
+		h.respondUserQueryError(c, err, "Failed to get saved search")
+		return
+	}
+
+	c.JSON(http.StatusOK, search)
+}
+
+// UpdateSavedSearch 更新保存的搜索
+// @Summary 更新保存的搜索
+// @Description 修改保存的搜索的名称、说明或查询条件（未传入的字段保持不变）
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Param search_id path string true "保存的搜索ID"
+// @Param request body UpdateSavedSearchRequest true "更新保存的搜索请求"
+// @Security ApiKeyAuth
+// @Success 200 {object} SavedSearch
+// @Failure 400 {object} object
+// @Failure 401 {object} object
+// @Failure 404 {object} object
+// @Failure 409 {object} object
+// @Failure 500 {object} object
+// @Router /api/v1/admin/user-management/saved-searches/{search_id} [put]
+func (h *Handler) UpdateSavedSearch(c *gin.Context) {
+	searchID, ok := h.savedSearchIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateSavedSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid update saved search request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	// 获取管理员信息
+	adminInfo := h.getAdminInfoFromContext(c)
+
+	search, err := h.service.UpdateSavedSearch(ctx, searchID, adminInfo, &req)
+	if err != nil {
+		h.respondUserQueryError(c, err, "Failed to update saved search")
+		return
+	}
+
+	c.JSON(http.StatusOK, search)
+}
+
+// DeleteSavedSearch 删除保存的搜索
+// @Summary 删除保存的搜索
+// @Description 删除当前管理员保存的一个用户搜索
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Param search_id path string true "保存的搜索ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} OperationResponse
+// @Failure 401 {object} object
+// @Failure 404 {object} object
+// @Failure 500 {object} object
+// @Router /api/v1/admin/user-management/saved-searches/{search_id} [delete]
+func (h *Handler) DeleteSavedSearch(c *gin.Context) {
+	searchID, ok := h.savedSearchIDParam(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	// 获取管理员信息
+	adminInfo := h.getAdminInfoFromContext(c)
+
+	response, err := h.service.DeleteSavedSearch(ctx, searchID, adminInfo)
+	if err != nil {
+		h.respondUserQueryError(c, err, "Failed to delete saved search")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// savedSearchIDParam 解析并校验路径中的保存的搜索ID
+func (h *Handler) savedSearchIDParam(c *gin.Context) (string, bool) {
+	searchID := c.Param("search_id")
+	if _, err := uuid.Parse(searchID); err != nil {
+		h.respondUserQueryError(c, auth.ErrSavedSearchNotFound, "Failed to get saved search")
+		return "", false
+	}
+	return searchID, true
+}
+
+// respondUserQueryError 用户高级查询和保存的搜索的错误响应
+func (h *Handler) respondUserQueryError(c *gin.Context, err error, title string) {
+	switch {
+	case errors.Is(err, auth.ErrInvalidUserQuery):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid query",
+			"message": err.Error(),
+		})
+	case errors.Is(err, auth.ErrSavedSearchNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Saved search not found",
+			"message": "The specified saved search does not exist",
+		})
+	case errors.Is(err, auth.ErrSavedSearchNameTaken):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   title,
+			"message": "A saved search with this name already exists",
+		})
+	case errors.Is(err, auth.ErrTooManySavedSearches):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   title,
+			"message": fmt.Sprintf("At most %d saved searches are allowed", maxSavedSearches),
+		})
+	default:
+		h.logger.WithError(err).Error(title)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal server error",
+			"message": title,
+		})
+	}
+}
+
+// === 模拟登录接口 ===
+
+// ImpersonateUser 以用户身份登录
+// @Summary 以用户身份登录
+// @Description 为用户签发短期只读访问令牌，令牌携带发起的管理员ID，不能访问资金操作和交易密码接口；每个请求都会记录审计日志，并可邮件通知用户
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Param user_id path string true "用户ID"
+// @Param request body ImpersonateUserRequest true "模拟登录请求"
+// @Security ApiKeyAuth
+// @Success 201 {object} ImpersonationTokenResponse
+// @Failure 400 {object} object
+// @Failure 401 {object} object
+// @Failure 403 {object} object
+// @Failure 404 {object} object
+// @Failure 500 {object} object
+// @Router /api/v1/admin/user-management/users/{user_id}/impersonate [post]
+func (h *Handler) ImpersonateUser(c *gin.Context) {
+	userID, ok := h.userIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req ImpersonateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid impersonate user request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	// 获取管理员信息
+	adminInfo := h.getAdminInfoFromContext(c)
+
+	response, err := h.service.ImpersonateUser(ctx, userID, adminInfo, c.ClientIP(), c.Request.UserAgent(), &req)
+	if err != nil {
+		h.respondImpersonationError(c, err, "Failed to impersonate user")
+		return
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// ListImpersonations 获取模拟登录记录列表
+// @Summary 获取模拟登录记录列表
+// @Description 获取管理员模拟用户登录的记录（按时间倒序），可按管理员、用户和是否有效筛选
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Param admin_id query string false "管理员ID"
+// @Param user_id query string false "用户ID"
+// @Param active query bool false "是否仍然有效"
+// @Security ApiKeyAuth
+// @Success 200 {object} ImpersonationListResponse
+// @Failure 400 {object} object
+// @Failure 401 {object} object
+// @Failure 403 {object} object
+// @Failure 500 {object} object
+// @Router /api/v1/admin/user-management/impersonations [get]
+func (h *Handler) ListImpersonations(c *gin.Context) {
+	var req ListImpersonationsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid list impersonations request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	response, err := h.service.ListImpersonations(ctx, &req)
+	if err != nil {
+		h.respondImpersonationError(c, err, "Failed to get impersonations")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetImpersonationRequests 获取模拟登录请求日志
+// @Summary 获取模拟登录请求日志
+// @Description 获取模拟登录令牌发起的每个请求（包括被拦截的请求），按请求顺序排列
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Param impersonation_id path string true "模拟登录ID"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(50)
+// @Security ApiKeyAuth
+// @Success 200 {object} ImpersonationRequestListResponse
+// @Failure 400 {object} object
+// @Failure 401 {object} object
+// @Failure 403 {object} object
+// @Failure 404 {object} object
+// @Failure 500 {object} object
+// @Router /api/v1/admin/user-management/impersonations/{impersonation_id}/requests [get]
+func (h *Handler) GetImpersonationRequests(c *gin.Context) {
+	impersonationID, ok := h.impersonationIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req ListImpersonationRequestsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid list impersonation requests request")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	response, err := h.service.ListImpersonationRequests(ctx, impersonationID, &req)
+	if err != nil {
+		h.respondImpersonationError(c, err, "Failed to get impersonation requests")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// EndImpersonation 结束模拟登录
+// @Summary 结束模拟登录
+// @Description 提前结束模拟登录并撤销令牌（仅发起的管理员或超级管理员）
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Param impersonation_id path string true "模拟登录ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} UserImpersonation
+// @Failure 401 {object} object
+// @Failure 403 {object} object
+// @Failure 404 {object} object
+// @Failure 409 {object} object
+// @Failure 500 {object} object
+// @Router /api/v1/admin/user-management/impersonations/{impersonation_id}/end [post]
+func (h *Handler) EndImpersonation(c *gin.Context) {
+	impersonationID, ok := h.impersonationIDParam(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	// 获取管理员信息
+	adminInfo := h.getAdminInfoFromContext(c)
+
+	response, err := h.service.EndImpersonation(ctx, impersonationID, adminInfo, c.ClientIP())
+	if err != nil {
+		h.respondImpersonationError(c, err, "Failed to end impersonation")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// impersonationIDParam 解析并校验路径中的模拟登录ID
+func (h *Handler) impersonationIDParam(c *gin.Context) (string, bool) {
+	impersonationID := c.Param("impersonation_id")
+	if _, err := uuid.Parse(impersonationID); err != nil {
+		h.respondImpersonationError(c, auth.ErrImpersonationNotFound, "Failed to get impersonation")
+		return "", false
+	}
+	return impersonationID, true
+}
+
+// respondImpersonationError 模拟登录的错误响应
+func (h *Handler) respondImpersonationError(c *gin.Context, err error, title string) {
+	switch {
+	case errors.Is(err, auth.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "User not found",
+			"message": "The specified user does not exist",
+		})
+	case errors.Is(err, auth.ErrImpersonationNotAllowed):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   title,
+			"message": "Deleted users cannot be impersonated",
+		})
+	case errors.Is(err, auth.ErrImpersonationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Impersonation not found",
+			"message": "The specified impersonation does not exist",
+		})
+	case errors.Is(err, auth.ErrPermissionDenied):
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "Forbidden",
+			"message": "Only the admin who started the impersonation or a super admin can end it",
+		})
+	case errors.Is(err, auth.ErrImpersonationEnded):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   title,
+			"message": "The impersonation has already ended or expired",
+		})
+	default:
+		h.logger.WithError(err).Error(title)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal server error",
+			"message": title,
+		})
+	}
+}
+
 // === 辅助方法 ===
 
 // AdminInfo 管理员信息结构
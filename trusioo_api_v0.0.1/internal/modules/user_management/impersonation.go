@@ -0,0 +1,29 @@
+package user_management
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultImpersonationTTL 未配置时模拟登录令牌的有效期
+	defaultImpersonationTTL = 15 * time.Minute
+	// impersonationAuditTimeout 写入一条模拟请求记录的超时时间（不受请求取消影响）
+	impersonationAuditTimeout = 5 * time.Second
+)
+
+// impersonationNotificationMessage 生成通知被模拟用户的邮件标题和正文
+func impersonationNotificationMessage(imp *UserImpersonation) (string, string) {
+	subject := "A support agent accessed your account"
+
+	var body strings.Builder
+	body.WriteString("A member of our support team has started a read-only session on your account.\n\n")
+	fmt.Fprintf(&body, "Started at: %s\n", imp.CreatedAt.UTC().Format(time.RFC3339))
+	fmt.Fprintf(&body, "Expires at: %s\n", imp.ExpiresAt.UTC().Format(time.RFC3339))
+	fmt.Fprintf(&body, "Reference: %s\n", imp.ID)
+	body.WriteString("\nDuring this session no changes can be made to your account, your transaction PIN or your funds.")
+	body.WriteString("\nIf you did not expect this, please contact support and quote the reference above.")
+
+	return subject, body.String()
+}
@@ -1,6 +1,8 @@
 package user_management
 
 import (
+	"encoding/json"
+	"math"
 	"time"
 
 	"trusioo_api_v0.0.1/internal/modules/auth/user"
@@ -15,7 +17,8 @@ type UserManagementModel struct {
 	FailedAttempts  int        `json:"failed_attempts" db:"failed_attempts"`
 	SuspendedAt     *time.Time `json:"suspended_at" db:"suspended_at"`
 	SuspendedReason *string    `json:"suspended_reason" db:"suspended_reason"`
-	Notes           *string    `json:"notes" db:"notes"` // 管理员备注
+	SuspendedUntil  *time.Time `json:"suspended_until" db:"suspended_until"` // 为空表示永久暂停
+	Tags            []string   `json:"tags" db:"-"`                          // 用户标签（单独查询user_tags填充）
 }
 
 // UserStatistics 用户统计数据结构
@@ -57,6 +60,32 @@ type DailyCount struct {
 	Count int64  `json:"count"`
 }
 
+// 统计时间粒度
+const (
+	StatisticsPeriodDay   = "day"
+	StatisticsPeriodWeek  = "week"
+	StatisticsPeriodMonth = "month"
+)
+
+// StatisticsBucket 一个时间桶内的统计数据（bucket_start为所在时区的自然日、周一或月初）
+type StatisticsBucket struct {
+	BucketStart   time.Time  `json:"bucket_start" db:"bucket_start"`
+	Registrations int64      `json:"registrations" db:"registrations"`
+	Verifications int64      `json:"verifications" db:"verifications"`
+	ActiveUsers   int64      `json:"active_users" db:"active_users"`
+	Logins        int64      `json:"logins" db:"logins"`
+	ComputedAt    *time.Time `json:"computed_at" db:"computed_at"` // 实时计算时为空
+}
+
+// RetentionCell 注册周cohort在注册后第N周的活跃用户数
+type RetentionCell struct {
+	CohortWeek  time.Time  `json:"cohort_week" db:"cohort_week"`
+	WeekOffset  int        `json:"week_offset" db:"week_offset"`
+	CohortSize  int64      `json:"cohort_size" db:"cohort_size"`
+	ActiveUsers int64      `json:"active_users" db:"active_users"`
+	ComputedAt  *time.Time `json:"computed_at" db:"computed_at"` // 实时计算时为空
+}
+
 // UserActivitySummary 用户活动摘要
 type UserActivitySummary struct {
 	UserID              string     `json:"user_id"`
@@ -73,22 +102,32 @@ type UserActivitySummary struct {
 type UserManagementAction string
 
 const (
-	ActionActivate      UserManagementAction = "activate"
-	ActionDeactivate    UserManagementAction = "deactivate"
-	ActionSuspend       UserManagementAction = "suspend"
-	ActionUnsuspend     UserManagementAction = "unsuspend"
-	ActionDelete        UserManagementAction = "delete"
-	ActionResetPassword UserManagementAction = "reset_password"
-	ActionForceLogout   UserManagementAction = "force_logout"
-	ActionUpdateEmail   UserManagementAction = "update_email"
-	ActionVerifyEmail   UserManagementAction = "verify_email"
+	ActionActivate         UserManagementAction = "activate"
+	ActionDeactivate       UserManagementAction = "deactivate"
+	ActionSuspend          UserManagementAction = "suspend"
+	ActionUnsuspend        UserManagementAction = "unsuspend"
+	ActionDelete           UserManagementAction = "delete"
+	ActionResetPassword    UserManagementAction = "reset_password"
+	ActionForceLogout      UserManagementAction = "force_logout"
+	ActionUpdateEmail      UserManagementAction = "update_email"
+	ActionVerifyEmail      UserManagementAction = "verify_email"
+	ActionUnlockLogin      UserManagementAction = "unlock_login"
+	ActionRestore          UserManagementAction = "restore"
+	ActionAddNote          UserManagementAction = "add_note"
+	ActionDeleteNote       UserManagementAction = "delete_note"
+	ActionAddTag           UserManagementAction = "add_tag"
+	ActionRemoveTag        UserManagementAction = "remove_tag"
+	ActionImpersonate      UserManagementAction = "impersonate"
+	ActionEndImpersonation UserManagementAction = "end_impersonation"
 )
 
 // IsValid 验证操作类型是否有效
 func (a UserManagementAction) IsValid() bool {
 	switch a {
 	case ActionActivate, ActionDeactivate, ActionSuspend, ActionUnsuspend,
-		ActionDelete, ActionResetPassword, ActionForceLogout, ActionUpdateEmail, ActionVerifyEmail:
+		ActionDelete, ActionResetPassword, ActionForceLogout, ActionUpdateEmail, ActionVerifyEmail,
+		ActionUnlockLogin, ActionRestore, ActionAddNote, ActionDeleteNote, ActionAddTag, ActionRemoveTag,
+		ActionImpersonate, ActionEndImpersonation:
 		return true
 	default:
 		return false
@@ -102,17 +141,242 @@ func (a UserManagementAction) String() string {
 
 // UserManagementLog 用户管理操作日志
 type UserManagementLog struct {
-	ID           string                  `json:"id" db:"id"`
-	AdminID      string                  `json:"admin_id" db:"admin_id"`
-	AdminEmail   string                  `json:"admin_email" db:"admin_email"`
-	TargetUserID string                  `json:"target_user_id" db:"target_user_id"`
-	TargetEmail  string                  `json:"target_email" db:"target_email"`
-	Action       UserManagementAction    `json:"action" db:"action"`
-	Reason       *string                 `json:"reason" db:"reason"`
-	Details      *map[string]interface{} `json:"details" db:"details"` // JSON格式的详细信息
-	IPAddress    string                  `json:"ip_address" db:"ip_address"`
-	UserAgent    *string                 `json:"user_agent" db:"user_agent"`
-	CreatedAt    time.Time               `json:"created_at" db:"created_at"`
+	ID           string `json:"id" db:"id"`
+	AdminID      string `json:"admin_id" db:"admin_id"`
+	AdminEmail   string `json:"admin_email" db:"admin_email"`
+	TargetUserID string `json:"target_user_id" db:"target_user_id"`
+	TargetEmail  string `json:"target_email" db:"target_email"`
+	// TargetEmailHash 操作时目标邮箱的HMAC，代替明文邮箱参与hash计算（明文邮箱在用户注销时会被匿名化）
+	TargetEmailHash string                  `json:"-" db:"target_email_hash"`
+	Action          UserManagementAction    `json:"action" db:"action"`
+	Reason          *string                 `json:"reason" db:"reason"`
+	Details         *map[string]interface{} `json:"details" db:"details"` // JSON格式的详细信息
+	IPAddress       string                  `json:"ip_address" db:"ip_address"`
+	UserAgent       *string                 `json:"user_agent" db:"user_agent"`
+	CreatedAt       time.Time               `json:"created_at" db:"created_at"`
+	Seq             int64                   `json:"seq" db:"seq"`             // 写入顺序
+	PrevHash        string                  `json:"prev_hash" db:"prev_hash"` // 上一条日志的hash
+	Hash            string                  `json:"hash" db:"hash"`           // 本条日志内容与prev_hash的HMAC-SHA256
+}
+
+// ManagementLogFilter 管理操作日志过滤器
+type ManagementLogFilter struct {
+	AdminID      *string
+	Action       *UserManagementAction
+	TargetUserID *string
+	DateFrom     *time.Time
+	DateTo       *time.Time
+	Query        *string // 在操作原因中模糊搜索
+}
+
+// 暂停解除原因
+const (
+	SuspensionLiftExpired    = "expired"    // 到期自动解除
+	SuspensionLiftAdmin      = "admin"      // 管理员手动解除
+	SuspensionLiftSuperseded = "superseded" // 被新的暂停替代
+)
+
+// UserSuspension 用户暂停记录
+type UserSuspension struct {
+	ID         string     `json:"id" db:"id"`
+	UserID     string     `json:"user_id" db:"user_id"`
+	AdminID    *string    `json:"admin_id" db:"admin_id"`
+	Reason     string     `json:"reason" db:"reason"`
+	StartsAt   time.Time  `json:"starts_at" db:"starts_at"`
+	EndsAt     *time.Time `json:"ends_at" db:"ends_at"` // 为空表示永久暂停
+	LiftedAt   *time.Time `json:"lifted_at" db:"lifted_at"`
+	LiftedBy   *string    `json:"lifted_by" db:"lifted_by"`
+	LiftReason *string    `json:"lift_reason" db:"lift_reason"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
+
+// IsActive 暂停是否仍然生效
+func (s *UserSuspension) IsActive() bool {
+	return s.LiftedAt == nil
+}
+
+// UserNote 管理员对用户的内部备注
+type UserNote struct {
+	ID          string    `json:"id" db:"id"`
+	UserID      string    `json:"user_id" db:"user_id"`
+	AuthorID    string    `json:"author_id" db:"author_id"`
+	AuthorEmail string    `json:"author_email" db:"author_email"`
+	Content     string    `json:"content" db:"content"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// TagCount 标签及使用该标签的用户数
+type TagCount struct {
+	Tag   string `json:"tag" db:"tag"`
+	Count int64  `json:"count" db:"count"`
+}
+
+// UserWatch 管理员对用户的关注
+type UserWatch struct {
+	ID         string    `json:"id" db:"id"`
+	AdminID    string    `json:"admin_id" db:"admin_id"`
+	AdminEmail string    `json:"admin_email" db:"admin_email"`
+	UserID     string    `json:"user_id" db:"user_id"`
+	UserEmail  string    `json:"user_email" db:"-"`
+	Events     []string  `json:"events" db:"events"` // 需要通知的用户活动，见auth.UserActivityEvents
+	Reason     *string   `json:"reason" db:"reason"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// WatchNotification 关注用户的活动通知
+type WatchNotification struct {
+	ID         string                 `json:"id" db:"id"`
+	AdminID    string                 `json:"admin_id" db:"admin_id"`
+	UserID     string                 `json:"user_id" db:"user_id"`
+	UserEmail  string                 `json:"user_email" db:"user_email"`
+	Event      string                 `json:"event" db:"event"`
+	Details    map[string]interface{} `json:"details,omitempty" db:"details"`
+	IPAddress  *string                `json:"ip_address,omitempty" db:"ip_address"`
+	OccurredAt time.Time              `json:"occurred_at" db:"occurred_at"`
+	ReadAt     *time.Time             `json:"read_at" db:"read_at"`
+	CreatedAt  time.Time              `json:"created_at" db:"created_at"`
+}
+
+// UserImpersonation 管理员模拟用户登录记录
+type UserImpersonation struct {
+	ID           string     `json:"id" db:"id"`
+	AdminID      string     `json:"admin_id" db:"admin_id"`
+	AdminEmail   string     `json:"admin_email" db:"admin_email"`
+	UserID       string     `json:"user_id" db:"user_id"`
+	UserEmail    string     `json:"user_email" db:"user_email"`
+	Reason       string     `json:"reason" db:"reason"`
+	TokenID      string     `json:"-" db:"token_id"` // 访问令牌jti
+	IPAddress    *string    `json:"ip_address" db:"ip_address"`
+	UserAgent    *string    `json:"user_agent" db:"user_agent"`
+	UserNotified bool       `json:"user_notified" db:"user_notified"`
+	RequestCount int64      `json:"request_count" db:"-"` // 模拟令牌发起的请求数
+	ExpiresAt    time.Time  `json:"expires_at" db:"expires_at"`
+	EndedAt      *time.Time `json:"ended_at" db:"ended_at"`
+	EndedBy      *string    `json:"ended_by" db:"ended_by"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+}
+
+// IsActive 模拟登录是否仍然有效
+func (i *UserImpersonation) IsActive() bool {
+	return i.EndedAt == nil && time.Now().Before(i.ExpiresAt)
+}
+
+// ImpersonationFilter 模拟登录记录过滤器
+type ImpersonationFilter struct {
+	AdminID *string
+	UserID  *string
+	Active  *bool
+}
+
+// ImpersonationRequestLog 模拟登录令牌发起的请求
+type ImpersonationRequestLog struct {
+	ID              int64     `json:"id" db:"id"`
+	ImpersonationID string    `json:"impersonation_id" db:"impersonation_id"`
+	Method          string    `json:"method" db:"method"`
+	Path            string    `json:"path" db:"path"`
+	Route           *string   `json:"route" db:"route"`
+	StatusCode      int       `json:"status_code" db:"status_code"`
+	Blocked         bool      `json:"blocked" db:"blocked"`
+	IPAddress       *string   `json:"ip_address" db:"ip_address"`
+	UserAgent       *string   `json:"user_agent" db:"user_agent"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+}
+
+// BatchAction 批量操作类型
+type BatchAction string
+
+const (
+	BatchActionUpdateStatus BatchAction = "update_status"
+	BatchActionSuspend      BatchAction = "suspend"
+	BatchActionReactivate   BatchAction = "reactivate"
+	BatchActionForceLogout  BatchAction = "force_logout"
+)
+
+// BatchJobStatus 批量操作任务状态
+type BatchJobStatus string
+
+const (
+	BatchJobAwaitingApproval BatchJobStatus = "awaiting_approval" // 超过阈值，等待超级管理员审批
+	BatchJobQueued           BatchJobStatus = "queued"
+	BatchJobRunning          BatchJobStatus = "running"
+	BatchJobCompleted        BatchJobStatus = "completed"
+	BatchJobCancelled        BatchJobStatus = "cancelled"
+)
+
+// BatchItemStatus 批量操作单个用户的执行状态
+type BatchItemStatus string
+
+const (
+	BatchItemPending   BatchItemStatus = "pending"
+	BatchItemSucceeded BatchItemStatus = "succeeded"
+	BatchItemFailed    BatchItemStatus = "failed"
+	BatchItemSkipped   BatchItemStatus = "skipped" // 用户已处于目标状态等无需操作的情况
+	BatchItemCancelled BatchItemStatus = "cancelled"
+)
+
+// 批量操作目标类型
+const (
+	BatchTargetIDs    = "ids"
+	BatchTargetFilter = "filter"
+)
+
+// BatchJob 用户批量操作任务（目标用户在创建时确定，由后台任务逐个执行）
+type BatchJob struct {
+	ID             string          `json:"id" db:"id"`
+	Action         BatchAction     `json:"action" db:"action"`
+	Params         json.RawMessage `json:"params" db:"params"` // 单个用户操作的请求参数
+	TargetType     string          `json:"target_type" db:"target_type"`
+	TargetFilter   json.RawMessage `json:"target_filter" db:"target_filter"`
+	Status         BatchJobStatus  `json:"status" db:"status"`
+	TotalCount     int             `json:"total_count" db:"total_count"`
+	ProcessedCount int             `json:"processed_count" db:"processed_count"`
+	SucceededCount int             `json:"succeeded_count" db:"succeeded_count"`
+	FailedCount    int             `json:"failed_count" db:"failed_count"`
+	SkippedCount   int             `json:"skipped_count" db:"skipped_count"`
+	CreatedBy      string          `json:"created_by" db:"created_by"`
+	CreatedByEmail string          `json:"created_by_email" db:"created_by_email"`
+	IPAddress      *string         `json:"ip_address" db:"ip_address"`
+	ApprovedBy     *string         `json:"approved_by" db:"approved_by"`
+	ApprovedAt     *time.Time      `json:"approved_at" db:"approved_at"`
+	CancelledBy    *string         `json:"cancelled_by" db:"cancelled_by"`
+	CancelledAt    *time.Time      `json:"cancelled_at" db:"cancelled_at"`
+	StartedAt      *time.Time      `json:"started_at" db:"started_at"`
+	CompletedAt    *time.Time      `json:"completed_at" db:"completed_at"`
+	CreatedAt      time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at" db:"updated_at"`
+}
+
+// IsFinished 任务是否已结束（取消后仍在执行的用户完成前completed_at为空）
+func (j *BatchJob) IsFinished() bool {
+	return j.CompletedAt != nil
+}
+
+// Progress 任务进度百分比
+func (j *BatchJob) Progress() float64 {
+	if j.TotalCount == 0 {
+		return 100
+	}
+	return math.Round(float64(j.ProcessedCount)*10000/float64(j.TotalCount)) / 100
+}
+
+// BatchJobItem 批量操作中单个用户的执行结果
+type BatchJobItem struct {
+	ID          string          `json:"id" db:"id"`
+	JobID       string          `json:"job_id" db:"job_id"`
+	UserID      string          `json:"user_id" db:"user_id"`
+	Email       *string         `json:"email" db:"email"`
+	Status      BatchItemStatus `json:"status" db:"status"`
+	Message     *string         `json:"message" db:"message"` // 失败或跳过的原因
+	ProcessedAt *time.Time      `json:"processed_at" db:"processed_at"`
+	CreatedAt   time.Time       `json:"created_at" db:"created_at"`
+}
+
+// BatchTarget 批量操作的目标用户
+type BatchTarget struct {
+	UserID string          `json:"user_id"`
+	Email  string          `json:"email"`
+	Status user.UserStatus `json:"status"`
 }
 
 // SearchFilter 用户搜索过滤器
@@ -125,6 +389,34 @@ type SearchFilter struct {
 	CreatedTo     *time.Time       `json:"created_to"`
 	LastLoginFrom *time.Time       `json:"last_login_from"`
 	LastLoginTo   *time.Time       `json:"last_login_to"`
+	Tags          []string         `json:"tags,omitempty"`  // 需同时拥有的全部标签
+	Query         *UserQuery       `json:"query,omitempty"` // 高级查询条件，与其他条件为AND关系
+}
+
+// UserQuery 用户高级查询条件树
+// 分支节点用op（and/or）组合conditions中的子条件；叶子节点对field使用operator与value比较
+type UserQuery struct {
+	Op         string          `json:"op,omitempty" example:"and"`
+	Conditions []*UserQuery    `json:"conditions,omitempty"`
+	Field      string          `json:"field,omitempty" example:"wallet_balance"`
+	Operator   string          `json:"operator,omitempty" example:"gte"`
+	Value      json.RawMessage `json:"value,omitempty"`
+}
+
+// IsBranch 是否为组合条件的分支节点
+func (q *UserQuery) IsBranch() bool {
+	return q.Op != ""
+}
+
+// SavedSearch 管理员保存的用户搜索
+type SavedSearch struct {
+	ID          string     `json:"id" db:"id"`
+	AdminID     string     `json:"admin_id" db:"admin_id"`
+	Name        string     `json:"name" db:"name"`
+	Description *string    `json:"description" db:"description"`
+	Query       *UserQuery `json:"query" db:"query"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
 }
 
 // PaginationParams 分页参数
@@ -0,0 +1,502 @@
+package user_management
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"trusioo_api_v0.0.1/internal/modules/auth"
+	"trusioo_api_v0.0.1/internal/modules/auth/user"
+
+	"github.com/lib/pq"
+)
+
+const (
+	// maxUserQueryDepth 高级查询最多的嵌套层数
+	maxUserQueryDepth = 5
+	// maxUserQueryConditions 高级查询最多的叶子条件数
+	maxUserQueryConditions = 50
+	// maxUserQueryValues in/not_in最多的取值数
+	maxUserQueryValues = 100
+	// maxUserQueryText 文本条件的最大长度
+	maxUserQueryText = 255
+	// maxUserQueryDays 相对时间条件最多的天数
+	maxUserQueryDays = 3650
+	// maxSavedSearches 每个管理员最多保存的搜索数
+	maxSavedSearches = 50
+)
+
+var (
+	// countryCodePattern 国家代码（ISO 3166 两位或三位字母）
+	countryCodePattern = regexp.MustCompile(`^[A-Z]{2,3}$`)
+	// decimalPattern 金额（最多8位小数，与钱包余额精度一致）
+	decimalPattern = regexp.MustCompile(`^-?[0-9]{1,12}(\.[0-9]{1,8})?$`)
+	// likeEscaper 转义LIKE模式中的通配符
+	likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+)
+
+// userQueryField 可查询字段：operators为允许的运算符，compile生成条件SQL（值全部作为参数传入）
+type userQueryField struct {
+	operators []string
+	compile   func(b *userQueryBuilder, operator string, value json.RawMessage) (string, error)
+}
+
+// userQueryFields 高级查询支持的字段
+// last_login_at依赖用户查询中的stats子查询，其余字段只引用users表（别名u）或使用子查询
+var userQueryFields = map[string]userQueryField{
+	"email": {
+		operators: []string{"eq", "neq", "contains", "starts_with"},
+		compile:   textCondition("u.email"),
+	},
+	"name": {
+		operators: []string{"eq", "neq", "contains", "starts_with"},
+		compile:   textCondition("u.name"),
+	},
+	"status": {
+		operators: []string{"eq", "neq", "in", "not_in"},
+		compile:   statusCondition,
+	},
+	"email_verified": {
+		operators: []string{"eq"},
+		compile:   boolCondition("u.email_verified = %s", "u.email_verified <> %s"),
+	},
+	"created_at": {
+		operators: []string{"gt", "gte", "lt", "lte", "between", "in_last_days", "not_in_last_days"},
+		compile:   timeCondition("u.created_at"),
+	},
+	"last_login_at": {
+		operators: []string{"gt", "gte", "lt", "lte", "between", "in_last_days", "not_in_last_days", "is_null", "not_null"},
+		compile:   timeCondition("stats.last_login_at"),
+	},
+	"has_bank_account": {
+		operators: []string{"eq"},
+		compile: boolCondition(
+			"(EXISTS (SELECT 1 FROM user_bank_accounts ba WHERE ba.user_id = u.id) = %s)",
+			"(EXISTS (SELECT 1 FROM user_bank_accounts ba WHERE ba.user_id = u.id) <> %s)"),
+	},
+	"wallet_balance": {
+		operators: []string{"eq", "gt", "gte", "lt", "lte", "between"},
+		compile:   walletBalanceCondition,
+	},
+	"login_country": {
+		operators: []string{"eq", "neq", "in", "not_in"},
+		compile:   loginCountryCondition,
+	},
+	"tag": {
+		operators: []string{"eq", "neq", "in", "not_in"},
+		compile:   tagCondition,
+	},
+}
+
+// comparisonOperators 比较运算符对应的SQL
+var comparisonOperators = map[string]string{
+	"eq":  "=",
+	"neq": "<>",
+	"gt":  ">",
+	"gte": ">=",
+	"lt":  "<",
+	"lte": "<=",
+}
+
+// userQueryBuilder 将高级查询编译为参数化的SQL条件
+type userQueryBuilder struct {
+	args       []interface{}
+	conditions int
+}
+
+// arg 添加参数并返回占位符
+func (b *userQueryBuilder) arg(value interface{}) string {
+	b.args = append(b.args, value)
+	return fmt.Sprintf("$%d", len(b.args))
+}
+
+// compileUserQuery 编译高级查询，args为已有参数（占位符编号在其后继续），返回条件SQL和全部参数
+func compileUserQuery(q *UserQuery, args []interface{}) (string, []interface{}, error) {
+	b := &userQueryBuilder{args: args}
+	clause, err := b.build(q, 1)
+	if err != nil {
+		return "", nil, err
+	}
+	return clause, b.args, nil
+}
+
+// validateUserQuery 校验高级查询（字段、运算符、取值和规模）
+func validateUserQuery(q *UserQuery) error {
+	_, _, err := compileUserQuery(q, nil)
+	return err
+}
+
+// build 递归编译查询节点
+func (b *userQueryBuilder) build(q *UserQuery, depth int) (string, error) {
+	if q == nil {
+		return "", invalidUserQuery("empty condition")
+	}
+	if depth > maxUserQueryDepth {
+		return "", invalidUserQuery("conditions are nested more than %d levels deep", maxUserQueryDepth)
+	}
+
+	if q.IsBranch() {
+		if q.Field != "" || q.Operator != "" || len(q.Value) > 0 {
+			return "", invalidUserQuery("a condition cannot have both op and field")
+		}
+
+		var joiner string
+		switch q.Op {
+		case "and":
+			joiner = " AND "
+		case "or":
+			joiner = " OR "
+		default:
+			return "", invalidUserQuery(`unknown op %q, expected "and" or "or"`, q.Op)
+		}
+		if len(q.Conditions) == 0 {
+			return "", invalidUserQuery("%s requires at least one condition", q.Op)
+		}
+
+		parts := make([]string, 0, len(q.Conditions))
+		for _, child := range q.Conditions {
+			part, err := b.build(child, depth+1)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, part)
+		}
+		return "(" + strings.Join(parts, joiner) + ")", nil
+	}
+
+	if len(q.Conditions) > 0 {
+		return "", invalidUserQuery("conditions require op")
+	}
+
+	b.conditions++
+	if b.conditions > maxUserQueryConditions {
+		return "", invalidUserQuery("at most %d conditions are allowed", maxUserQueryConditions)
+	}
+
+	field, ok := userQueryFields[q.Field]
+	if !ok {
+		return "", invalidUserQuery("unknown field %q", q.Field)
+	}
+	if !containsString(field.operators, q.Operator) {
+		return "", invalidUserQuery("operator %q is not supported for field %s", q.Operator, q.Field)
+	}
+
+	clause, err := field.compile(b, q.Operator, q.Value)
+	if err != nil {
+		return "", fmt.Errorf("%w (field %s)", err, q.Field)
+	}
+	return clause, nil
+}
+
+// textCondition 文本字段条件（不区分大小写）
+func textCondition(column string) func(*userQueryBuilder, string, json.RawMessage) (string, error) {
+	return func(b *userQueryBuilder, operator string, value json.RawMessage) (string, error) {
+		text, err := decodeQueryString(value)
+		if err != nil {
+			return "", err
+		}
+
+		switch operator {
+		case "contains":
+			return fmt.Sprintf("%s ILIKE %s", column, b.arg("%"+likeEscaper.Replace(text)+"%")), nil
+		case "starts_with":
+			return fmt.Sprintf("%s ILIKE %s", column, b.arg(likeEscaper.Replace(text)+"%")), nil
+		default:
+			return fmt.Sprintf("LOWER(%s) %s LOWER(%s)", column, comparisonOperators[operator], b.arg(text)), nil
+		}
+	}
+}
+
+// boolCondition 布尔字段条件，eqFormat/neqFormat中的%s为参数占位符
+func boolCondition(eqFormat, neqFormat string) func(*userQueryBuilder, string, json.RawMessage) (string, error) {
+	return func(b *userQueryBuilder, operator string, value json.RawMessage) (string, error) {
+		var v bool
+		if err := json.Unmarshal(value, &v); err != nil {
+			return "", invalidUserQuery("value must be true or false")
+		}
+		if operator == "neq" {
+			return fmt.Sprintf(neqFormat, b.arg(v)), nil
+		}
+		return fmt.Sprintf(eqFormat, b.arg(v)), nil
+	}
+}
+
+// timeCondition 时间字段条件，支持绝对时间、时间范围和相对天数
+func timeCondition(column string) func(*userQueryBuilder, string, json.RawMessage) (string, error) {
+	return func(b *userQueryBuilder, operator string, value json.RawMessage) (string, error) {
+		switch operator {
+		case "is_null", "not_null":
+			if len(value) > 0 && string(value) != "null" {
+				return "", invalidUserQuery("%s does not take a value", operator)
+			}
+			if operator == "is_null" {
+				return column + " IS NULL", nil
+			}
+			return column + " IS NOT NULL", nil
+
+		case "in_last_days", "not_in_last_days":
+			var days int
+			if err := json.Unmarshal(value, &days); err != nil || days < 1 || days > maxUserQueryDays {
+				return "", invalidUserQuery("value must be a number of days between 1 and %d", maxUserQueryDays)
+			}
+			since := fmt.Sprintf("NOW() - (%s::int * INTERVAL '1 day')", b.arg(days))
+			if operator == "in_last_days" {
+				return fmt.Sprintf("%s >= %s", column, since), nil
+			}
+			return fmt.Sprintf("%s < %s", column, since), nil
+
+		case "between":
+			bounds, err := decodeQueryRange(value)
+			if err != nil {
+				return "", err
+			}
+			from, err := parseQueryTime(bounds[0], false)
+			if err != nil {
+				return "", err
+			}
+			to, err := parseQueryTime(bounds[1], true)
+			if err != nil {
+				return "", err
+			}
+			if to.Before(from) {
+				return "", invalidUserQuery("range end is before range start")
+			}
+			return fmt.Sprintf("%s BETWEEN %s AND %s", column, b.arg(from), b.arg(to)), nil
+
+		default:
+			text, err := decodeQueryString(value)
+			if err != nil {
+				return "", err
+			}
+			// 日期作为上限时包含当天全天
+			t, err := parseQueryTime(text, operator == "lte" || operator == "gt")
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%s %s %s", column, comparisonOperators[operator], b.arg(t)), nil
+		}
+	}
+}
+
+// statusCondition 用户状态条件
+func statusCondition(b *userQueryBuilder, operator string, value json.RawMessage) (string, error) {
+	statuses, err := decodeQueryStrings(operator, value, func(v string) (string, error) {
+		if !user.UserStatus(v).IsValid() {
+			return "", invalidUserQuery("unknown status %q", v)
+		}
+		return v, nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if operator == "eq" || operator == "in" {
+		return fmt.Sprintf("u.status = ANY(%s::text[])", b.arg(pq.Array(statuses))), nil
+	}
+	return fmt.Sprintf("u.status <> ALL(%s::text[])", b.arg(pq.Array(statuses))), nil
+}
+
+// walletBalanceCondition 钱包余额条件
+func walletBalanceCondition(b *userQueryBuilder, operator string, value json.RawMessage) (string, error) {
+	var comparison string
+	if operator == "between" {
+		bounds, err := decodeQueryRange(value)
+		if err != nil {
+			return "", err
+		}
+		for _, bound := range bounds {
+			if !decimalPattern.MatchString(bound) {
+				return "", invalidUserQuery("invalid amount %q", bound)
+			}
+		}
+		comparison = fmt.Sprintf("w.balance BETWEEN %s::numeric AND %s::numeric", b.arg(bounds[0]), b.arg(bounds[1]))
+	} else {
+		amount, err := decodeQueryAmount(value)
+		if err != nil {
+			return "", err
+		}
+		comparison = fmt.Sprintf("w.balance %s %s::numeric", comparisonOperators[operator], b.arg(amount))
+	}
+
+	return "EXISTS (SELECT 1 FROM wallets w WHERE w.user_id = u.id AND " + comparison + ")", nil
+}
+
+// loginCountryCondition 登录国家条件：曾经从这些国家成功登录（neq/not_in为从未从这些国家登录）
+func loginCountryCondition(b *userQueryBuilder, operator string, value json.RawMessage) (string, error) {
+	countries, err := decodeQueryStrings(operator, value, func(v string) (string, error) {
+		v = strings.ToUpper(strings.TrimSpace(v))
+		if !countryCodePattern.MatchString(v) {
+			return "", invalidUserQuery("invalid country code %q", v)
+		}
+		return v, nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	exists := fmt.Sprintf(`EXISTS (
+		SELECT 1 FROM login_logs ll
+		WHERE ll.user_id = u.id AND ll.user_type = 'user' AND ll.login_status = 'success'
+			AND UPPER(ll.location_info->>'country_code') = ANY(%s::text[])
+	)`, b.arg(pq.Array(countries)))
+
+	if operator == "eq" || operator == "in" {
+		return exists, nil
+	}
+	return "NOT " + exists, nil
+}
+
+// tagCondition 标签条件：拥有其中任一标签（neq/not_in为不拥有其中任何标签）
+func tagCondition(b *userQueryBuilder, operator string, value json.RawMessage) (string, error) {
+	tags, err := decodeQueryStrings(operator, value, normalizeUserTag)
+	if err != nil {
+		return "", err
+	}
+
+	exists := fmt.Sprintf("EXISTS (SELECT 1 FROM user_tags ut WHERE ut.user_id = u.id AND ut.tag = ANY(%s::text[]))",
+		b.arg(pq.Array(tags)))
+
+	if operator == "eq" || operator == "in" {
+		return exists, nil
+	}
+	return "NOT " + exists, nil
+}
+
+// decodeQueryString 解析非空字符串值
+func decodeQueryString(value json.RawMessage) (string, error) {
+	var text string
+	if err := json.Unmarshal(value, &text); err != nil {
+		return "", invalidUserQuery("value must be a string")
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", invalidUserQuery("value must not be empty")
+	}
+	if len(text) > maxUserQueryText {
+		return "", invalidUserQuery("value must be at most %d characters", maxUserQueryText)
+	}
+	return text, nil
+}
+
+// decodeQueryStrings 解析eq/neq的单个值或in/not_in的值列表，normalize校验并统一每个值
+func decodeQueryStrings(operator string, value json.RawMessage, normalize func(string) (string, error)) ([]string, error) {
+	var values []string
+	if operator == "in" || operator == "not_in" {
+		if err := json.Unmarshal(value, &values); err != nil {
+			return nil, invalidUserQuery("value must be an array of strings")
+		}
+		if len(values) == 0 || len(values) > maxUserQueryValues {
+			return nil, invalidUserQuery("value must contain between 1 and %d items", maxUserQueryValues)
+		}
+	} else {
+		text, err := decodeQueryString(value)
+		if err != nil {
+			return nil, err
+		}
+		values = []string{text}
+	}
+
+	normalized := make([]string, 0, len(values))
+	for _, v := range values {
+		n, err := normalize(v)
+		if err != nil {
+			if errors.Is(err, auth.ErrInvalidUserTag) {
+				return nil, invalidUserQuery("invalid tag %q", v)
+			}
+			return nil, err
+		}
+		normalized = append(normalized, n)
+	}
+	return normalized, nil
+}
+
+// decodeQueryRange 解析between的[起始, 结束]值
+func decodeQueryRange(value json.RawMessage) ([2]string, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(value, &raw); err != nil || len(raw) != 2 {
+		return [2]string{}, invalidUserQuery("between requires an array of two values")
+	}
+
+	var bounds [2]string
+	for i, item := range raw {
+		var text string
+		if err := json.Unmarshal(item, &text); err != nil {
+			// 金额可以直接写数字
+			var number json.Number
+			if err := json.Unmarshal(item, &number); err != nil {
+				return [2]string{}, invalidUserQuery("between values must be strings or numbers")
+			}
+			text = number.String()
+		}
+		bounds[i] = strings.TrimSpace(text)
+	}
+	return bounds, nil
+}
+
+// decodeQueryAmount 解析金额值（数字或数字字符串）
+func decodeQueryAmount(value json.RawMessage) (string, error) {
+	var amount string
+	if err := json.Unmarshal(value, &amount); err != nil {
+		var number json.Number
+		if err := json.Unmarshal(value, &number); err != nil {
+			return "", invalidUserQuery("value must be an amount")
+		}
+		amount = number.String()
+	}
+	amount = strings.TrimSpace(amount)
+	if !decimalPattern.MatchString(amount) {
+		return "", invalidUserQuery("invalid amount %q", amount)
+	}
+	return amount, nil
+}
+
+// parseQueryTime 解析RFC3339时间或日期，endOfDay为true时日期取当天结束时间
+func parseQueryTime(value string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, invalidUserQuery("invalid time %q, expected YYYY-MM-DD or RFC3339", value)
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, nil
+}
+
+// combineUserQueries 用AND组合多个高级查询（忽略空查询）
+func combineUserQueries(queries ...*UserQuery) *UserQuery {
+	conditions := make([]*UserQuery, 0, len(queries))
+	for _, q := range queries {
+		if q != nil {
+			conditions = append(conditions, q)
+		}
+	}
+
+	switch len(conditions) {
+	case 0:
+		return nil
+	case 1:
+		return conditions[0]
+	}
+	return &UserQuery{Op: "and", Conditions: conditions}
+}
+
+// invalidUserQuery 生成高级查询校验错误（可用errors.Is判断为auth.ErrInvalidUserQuery）
+func invalidUserQuery(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", auth.ErrInvalidUserQuery, fmt.Sprintf(format, args...))
+}
+
+// containsString 字符串切片是否包含指定值
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,346 @@
+package user_management
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"trusioo_api_v0.0.1/internal/modules/auth"
+
+	"github.com/lib/pq"
+)
+
+// leaf 构造叶子条件
+func leaf(field, operator, value string) *UserQuery {
+	q := &UserQuery{Field: field, Operator: operator}
+	if value != "" {
+		q.Value = json.RawMessage(value)
+	}
+	return q
+}
+
+func TestCompileUserQueryOperators(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  *UserQuery
+		clause string
+		args   []interface{}
+	}{
+		{
+			name:   "text eq",
+			query:  leaf("email", "eq", `"Alice@Example.com"`),
+			clause: "LOWER(u.email) = LOWER($1)",
+			args:   []interface{}{"Alice@Example.com"},
+		},
+		{
+			name:   "text neq",
+			query:  leaf("name", "neq", `"bob"`),
+			clause: "LOWER(u.name) <> LOWER($1)",
+			args:   []interface{}{"bob"},
+		},
+		{
+			name:   "text contains escapes wildcards",
+			query:  leaf("email", "contains", `"50%_off"`),
+			clause: "u.email ILIKE $1",
+			args:   []interface{}{`%50\%\_off%`},
+		},
+		{
+			name:   "text starts_with",
+			query:  leaf("name", "starts_with", `" al "`),
+			clause: "u.name ILIKE $1",
+			args:   []interface{}{"al%"},
+		},
+		{
+			name:   "status eq",
+			query:  leaf("status", "eq", `"active"`),
+			clause: "u.status = ANY($1::text[])",
+			args:   []interface{}{pq.Array([]string{"active"})},
+		},
+		{
+			name:   "status not_in",
+			query:  leaf("status", "not_in", `["suspended","inactive"]`),
+			clause: "u.status <> ALL($1::text[])",
+			args:   []interface{}{pq.Array([]string{"suspended", "inactive"})},
+		},
+		{
+			name:   "bool eq",
+			query:  leaf("email_verified", "eq", `true`),
+			clause: "u.email_verified = $1",
+			args:   []interface{}{true},
+		},
+		{
+			name:   "time gte date starts at midnight",
+			query:  leaf("created_at", "gte", `"2024-03-01"`),
+			clause: "u.created_at >= $1",
+			args:   []interface{}{time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			name:   "time lt date starts at midnight",
+			query:  leaf("created_at", "lt", `"2024-03-01"`),
+			clause: "u.created_at < $1",
+			args:   []interface{}{time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			name:   "time lte date includes the whole day",
+			query:  leaf("created_at", "lte", `"2024-03-01"`),
+			clause: "u.created_at <= $1",
+			args:   []interface{}{time.Date(2024, 3, 1, 23, 59, 59, 999999999, time.UTC)},
+		},
+		{
+			name:   "time gt date excludes the whole day",
+			query:  leaf("last_login_at", "gt", `"2024-03-01"`),
+			clause: "stats.last_login_at > $1",
+			args:   []interface{}{time.Date(2024, 3, 1, 23, 59, 59, 999999999, time.UTC)},
+		},
+		{
+			name:   "time gt RFC3339 is exact",
+			query:  leaf("created_at", "gt", `"2024-03-01T10:00:00Z"`),
+			clause: "u.created_at > $1",
+			args:   []interface{}{time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)},
+		},
+		{
+			name:   "time between dates includes the end day",
+			query:  leaf("created_at", "between", `["2024-01-01","2024-01-31"]`),
+			clause: "u.created_at BETWEEN $1 AND $2",
+			args: []interface{}{
+				time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, 1, 31, 23, 59, 59, 999999999, time.UTC),
+			},
+		},
+		{
+			name:   "time between same day",
+			query:  leaf("created_at", "between", `["2024-01-01","2024-01-01"]`),
+			clause: "u.created_at BETWEEN $1 AND $2",
+			args: []interface{}{
+				time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, 1, 1, 23, 59, 59, 999999999, time.UTC),
+			},
+		},
+		{
+			name:   "time in_last_days",
+			query:  leaf("last_login_at", "in_last_days", `30`),
+			clause: "stats.last_login_at >= NOW() - ($1::int * INTERVAL '1 day')",
+			args:   []interface{}{30},
+		},
+		{
+			name:   "time not_in_last_days",
+			query:  leaf("created_at", "not_in_last_days", `7`),
+			clause: "u.created_at < NOW() - ($1::int * INTERVAL '1 day')",
+			args:   []interface{}{7},
+		},
+		{
+			name:   "time is_null",
+			query:  leaf("last_login_at", "is_null", ""),
+			clause: "stats.last_login_at IS NULL",
+		},
+		{
+			name:   "time not_null",
+			query:  leaf("last_login_at", "not_null", `null`),
+			clause: "stats.last_login_at IS NOT NULL",
+		},
+		{
+			name:   "wallet balance gte number",
+			query:  leaf("wallet_balance", "gte", `100.5`),
+			clause: "EXISTS (SELECT 1 FROM wallets w WHERE w.user_id = u.id AND w.balance >= $1::numeric)",
+			args:   []interface{}{"100.5"},
+		},
+		{
+			name:   "wallet balance between",
+			query:  leaf("wallet_balance", "between", `[0, "250.12345678"]`),
+			clause: "EXISTS (SELECT 1 FROM wallets w WHERE w.user_id = u.id AND w.balance BETWEEN $1::numeric AND $2::numeric)",
+			args:   []interface{}{"0", "250.12345678"},
+		},
+		{
+			name:  "login country in",
+			query: leaf("login_country", "in", `["us"," gb "]`),
+			args:  []interface{}{pq.Array([]string{"US", "GB"})},
+		},
+		{
+			name:  "tag neq",
+			query: leaf("tag", "neq", `"VIP"`),
+			args:  []interface{}{pq.Array([]string{"vip"})},
+		},
+		{
+			name: "and or nesting",
+			query: &UserQuery{Op: "and", Conditions: []*UserQuery{
+				leaf("status", "eq", `"active"`),
+				{Op: "or", Conditions: []*UserQuery{
+					leaf("email_verified", "eq", `false`),
+					leaf("has_bank_account", "eq", `true`),
+				}},
+			}},
+			clause: "(u.status = ANY($1::text[]) AND (u.email_verified = $2 OR " +
+				"(EXISTS (SELECT 1 FROM user_bank_accounts ba WHERE ba.user_id = u.id) = $3)))",
+			args: []interface{}{pq.Array([]string{"active"}), false, true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clause, args, err := compileUserQuery(tt.query, nil)
+			if err != nil {
+				t.Fatalf("compileUserQuery() error = %v", err)
+			}
+			if tt.clause != "" && clause != tt.clause {
+				t.Errorf("clause = %q, want %q", clause, tt.clause)
+			}
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("args = %#v, want %#v", args, tt.args)
+			}
+		})
+	}
+}
+
+func TestCompileUserQueryNegatedExists(t *testing.T) {
+	tests := []struct {
+		query   *UserQuery
+		negated bool
+	}{
+		{leaf("login_country", "eq", `"US"`), false},
+		{leaf("login_country", "not_in", `["US"]`), true},
+		{leaf("tag", "in", `["vip"]`), false},
+		{leaf("tag", "neq", `"vip"`), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query.Field+" "+tt.query.Operator, func(t *testing.T) {
+			clause, _, err := compileUserQuery(tt.query, nil)
+			if err != nil {
+				t.Fatalf("compileUserQuery() error = %v", err)
+			}
+			if got := strings.HasPrefix(clause, "NOT EXISTS"); got != tt.negated {
+				t.Errorf("clause = %q, negated = %v, want %v", clause, got, tt.negated)
+			}
+			if !strings.Contains(clause, "$1::text[]") {
+				t.Errorf("clause = %q, expected value to be passed as a parameter", clause)
+			}
+		})
+	}
+}
+
+func TestCompileUserQueryContinuesPlaceholders(t *testing.T) {
+	existing := []interface{}{"a", "b"}
+	clause, args, err := compileUserQuery(leaf("email", "eq", `"x@example.com"`), existing)
+	if err != nil {
+		t.Fatalf("compileUserQuery() error = %v", err)
+	}
+	if clause != "LOWER(u.email) = LOWER($3)" {
+		t.Errorf("clause = %q, want placeholder $3", clause)
+	}
+	if len(args) != 3 || args[2] != "x@example.com" {
+		t.Errorf("args = %#v", args)
+	}
+}
+
+// nested 构造嵌套depth层的查询（最内层为一个叶子条件）
+func nested(depth int) *UserQuery {
+	q := leaf("status", "eq", `"active"`)
+	for i := 1; i < depth; i++ {
+		q = &UserQuery{Op: "and", Conditions: []*UserQuery{q}}
+	}
+	return q
+}
+
+// leaves 构造包含n个叶子条件的查询
+func leaves(n int) *UserQuery {
+	conditions := make([]*UserQuery, n)
+	for i := range conditions {
+		conditions[i] = leaf("email", "contains", fmt.Sprintf(`"user%d"`, i))
+	}
+	return &UserQuery{Op: "or", Conditions: conditions}
+}
+
+func TestCompileUserQueryLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   *UserQuery
+		wantErr bool
+	}{
+		{"max depth", nested(maxUserQueryDepth), false},
+		{"too deep", nested(maxUserQueryDepth + 1), true},
+		{"max conditions", leaves(maxUserQueryConditions), false},
+		{"too many conditions", leaves(maxUserQueryConditions + 1), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateUserQuery(tt.query)
+			if tt.wantErr {
+				if !errors.Is(err, auth.ErrInvalidUserQuery) {
+					t.Errorf("validateUserQuery() error = %v, want ErrInvalidUserQuery", err)
+				}
+			} else if err != nil {
+				t.Errorf("validateUserQuery() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestCompileUserQueryRejectsInvalid(t *testing.T) {
+	tooManyValues := make([]string, maxUserQueryValues+1)
+	for i := range tooManyValues {
+		tooManyValues[i] = "active"
+	}
+	tooManyValuesJSON, _ := json.Marshal(tooManyValues)
+
+	tests := []struct {
+		name  string
+		query *UserQuery
+	}{
+		{"nil query", nil},
+		{"unknown field", leaf("password_hash", "eq", `"x"`)},
+		{"unknown field with sql", leaf("u.id; DROP TABLE users", "eq", `"x"`)},
+		{"unsupported operator", leaf("email", "gt", `"a"`)},
+		{"unknown operator", leaf("email", "like", `"a"`)},
+		{"unknown op", &UserQuery{Op: "xor", Conditions: []*UserQuery{leaf("status", "eq", `"active"`)}}},
+		{"empty branch", &UserQuery{Op: "and"}},
+		{"branch with field", &UserQuery{Op: "and", Field: "email", Conditions: []*UserQuery{leaf("status", "eq", `"active"`)}}},
+		{"leaf with conditions", &UserQuery{Field: "email", Operator: "eq", Value: json.RawMessage(`"a"`), Conditions: []*UserQuery{leaf("status", "eq", `"active"`)}}},
+		{"empty text", leaf("email", "eq", `"  "`)},
+		{"text too long", leaf("email", "contains", `"`+strings.Repeat("a", maxUserQueryText+1)+`"`)},
+		{"text not string", leaf("email", "eq", `1`)},
+		{"unknown status", leaf("status", "eq", `"deleted"`)},
+		{"empty in list", leaf("status", "in", `[]`)},
+		{"too many in values", leaf("status", "in", string(tooManyValuesJSON))},
+		{"bool not bool", leaf("email_verified", "eq", `"yes"`)},
+		{"invalid date", leaf("created_at", "gte", `"01/02/2024"`)},
+		{"between wrong arity", leaf("created_at", "between", `["2024-01-01"]`)},
+		{"between reversed", leaf("created_at", "between", `["2024-02-01","2024-01-01"]`)},
+		{"days out of range", leaf("created_at", "in_last_days", `0`)},
+		{"days too large", leaf("created_at", "in_last_days", fmt.Sprint(maxUserQueryDays+1))},
+		{"is_null with value", leaf("last_login_at", "is_null", `"2024-01-01"`)},
+		{"invalid amount", leaf("wallet_balance", "gt", `"1e9"`)},
+		{"invalid between amount", leaf("wallet_balance", "between", `["0","abc"]`)},
+		{"invalid country", leaf("login_country", "eq", `"united states"`)},
+		{"invalid tag", leaf("tag", "eq", `"not a tag!"`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := compileUserQuery(tt.query, nil)
+			if !errors.Is(err, auth.ErrInvalidUserQuery) {
+				t.Errorf("compileUserQuery() error = %v, want ErrInvalidUserQuery", err)
+			}
+		})
+	}
+}
+
+func TestCombineUserQueries(t *testing.T) {
+	a := leaf("status", "eq", `"active"`)
+	b := leaf("email_verified", "eq", `true`)
+
+	if got := combineUserQueries(nil, nil); got != nil {
+		t.Errorf("combineUserQueries(nil, nil) = %#v, want nil", got)
+	}
+	if got := combineUserQueries(nil, a); got != a {
+		t.Errorf("combineUserQueries(nil, a) = %#v, want a", got)
+	}
+	got := combineUserQueries(a, nil, b)
+	if got == nil || got.Op != "and" || len(got.Conditions) != 2 {
+		t.Errorf("combineUserQueries(a, nil, b) = %#v, want and of two conditions", got)
+	}
+}
@@ -3,6 +3,7 @@ package user_management
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
@@ -11,13 +12,15 @@ import (
 	"trusioo_api_v0.0.1/internal/modules/auth/user"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/sirupsen/logrus"
 )
 
 // Repository 用户管理仓储
 type Repository struct {
 	*database.BaseRepository
-	logger *logrus.Logger
+	auditLogKey []byte
+	logger      *logrus.Logger
 }
 
 // NewRepository 创建新的用户管理仓储
@@ -28,6 +31,11 @@ func NewRepository(db *database.Database, logger *logrus.Logger) *Repository {
 	}
 }
 
+// SetAuditLogKey 设置管理操作日志哈希链的HMAC密钥
+func (r *Repository) SetAuditLogKey(key string) {
+	r.auditLogKey = []byte(key)
+}
+
 // === 用户查询方法 ===
 
 // GetUserByID 根据ID获取用户详细信息
@@ -36,6 +44,7 @@ func (r *Repository) GetUserByID(ctx context.Context, userID string) (*UserManag
 		SELECT 
 			u.id, u.email, u.name, u.password, u.status, u.email_verified, u.email_verified_at,
 			u.created_at, u.updated_at, u.deleted_at,
+			u.suspended_at, u.suspended_reason, u.suspended_until,
 			COALESCE(stats.last_login_at, NULL) as last_login_at,
 			COALESCE(stats.login_count, 0) as login_count,
 			COALESCE(stats.failed_attempts, 0) as failed_attempts
@@ -58,6 +67,7 @@ func (r *Repository) GetUserByID(ctx context.Context, userID string) (*UserManag
 		&userModel.ID, &userModel.Email, &userModel.Name, &userModel.Password,
 		&userModel.Status, &userModel.EmailVerified, &userModel.EmailVerifiedAt,
 		&userModel.CreatedAt, &userModel.UpdatedAt, &userModel.DeletedAt,
+		&userModel.SuspendedAt, &userModel.SuspendedReason, &userModel.SuspendedUntil,
 		&userModel.LastLoginAt, &userModel.LoginCount, &userModel.FailedAttempts,
 	)
 
@@ -74,57 +84,11 @@ func (r *Repository) GetUserByID(ctx context.Context, userID string) (*UserManag
 // GetUsers 获取用户列表（支持分页和过滤）
 func (r *Repository) GetUsers(ctx context.Context, filter *SearchFilter, pagination PaginationParams) ([]*UserManagementModel, int64, error) {
 	// 构建WHERE条件
-	whereConditions := []string{"u.deleted_at IS NULL"}
-	args := []interface{}{}
-	argIndex := 1
-
-	if filter.Email != nil {
-		whereConditions = append(whereConditions, fmt.Sprintf("u.email ILIKE $%d", argIndex))
-		args = append(args, "%"+*filter.Email+"%")
-		argIndex++
-	}
-
-	if filter.Name != nil {
-		whereConditions = append(whereConditions, fmt.Sprintf("u.name ILIKE $%d", argIndex))
-		args = append(args, "%"+*filter.Name+"%")
-		argIndex++
-	}
-
-	if filter.Status != nil {
-		whereConditions = append(whereConditions, fmt.Sprintf("u.status = $%d", argIndex))
-		args = append(args, filter.Status.String())
-		argIndex++
-	}
-
-	if filter.EmailVerified != nil {
-		whereConditions = append(whereConditions, fmt.Sprintf("u.email_verified = $%d", argIndex))
-		args = append(args, *filter.EmailVerified)
-		argIndex++
-	}
-
-	if filter.CreatedFrom != nil {
-		whereConditions = append(whereConditions, fmt.Sprintf("u.created_at >= $%d", argIndex))
-		args = append(args, *filter.CreatedFrom)
-		argIndex++
-	}
-
-	if filter.CreatedTo != nil {
-		whereConditions = append(whereConditions, fmt.Sprintf("u.created_at <= $%d", argIndex))
-		args = append(args, *filter.CreatedTo)
-		argIndex++
-	}
-
-	if filter.LastLoginFrom != nil {
-		whereConditions = append(whereConditions, fmt.Sprintf("stats.last_login_at >= $%d", argIndex))
-		args = append(args, *filter.LastLoginFrom)
-		argIndex++
-	}
-
-	if filter.LastLoginTo != nil {
-		whereConditions = append(whereConditions, fmt.Sprintf("stats.last_login_at <= $%d", argIndex))
-		args = append(args, *filter.LastLoginTo)
-		argIndex++
+	whereConditions, args, err := buildUserFilterConditions(filter)
+	if err != nil {
+		return nil, 0, err
 	}
+	argIndex := len(args) + 1
 
 	whereClause := strings.Join(whereConditions, " AND ")
 
@@ -146,7 +110,7 @@ func (r *Repository) GetUsers(ctx context.Context, filter *SearchFilter, paginat
 	`, whereClause)
 
 	var total int64
-	err := r.GetDB().QueryRowContext(ctx, countQuery, args...).Scan(&total)
+	err = r.GetDB().QueryRowContext(ctx, countQuery, args...).Scan(&total)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to count users: %w", err)
 	}
@@ -208,6 +172,81 @@ func (r *Repository) GetUsers(ctx context.Context, filter *SearchFilter, paginat
 	return users, total, nil
 }
 
+// buildUserFilterConditions 根据过滤器构建用户查询的WHERE条件（last_login条件依赖stats子查询）
+func buildUserFilterConditions(filter *SearchFilter) ([]string, []interface{}, error) {
+	whereConditions := []string{"u.deleted_at IS NULL"}
+	args := []interface{}{}
+	argIndex := 1
+
+	if filter.Email != nil {
+		whereConditions = append(whereConditions, fmt.Sprintf("u.email ILIKE $%d", argIndex))
+		args = append(args, "%"+*filter.Email+"%")
+		argIndex++
+	}
+
+	if filter.Name != nil {
+		whereConditions = append(whereConditions, fmt.Sprintf("u.name ILIKE $%d", argIndex))
+		args = append(args, "%"+*filter.Name+"%")
+		argIndex++
+	}
+
+	if filter.Status != nil {
+		whereConditions = append(whereConditions, fmt.Sprintf("u.status = $%d", argIndex))
+		args = append(args, filter.Status.String())
+		argIndex++
+	}
+
+	if filter.EmailVerified != nil {
+		whereConditions = append(whereConditions, fmt.Sprintf("u.email_verified = $%d", argIndex))
+		args = append(args, *filter.EmailVerified)
+		argIndex++
+	}
+
+	if filter.CreatedFrom != nil {
+		whereConditions = append(whereConditions, fmt.Sprintf("u.created_at >= $%d", argIndex))
+		args = append(args, *filter.CreatedFrom)
+		argIndex++
+	}
+
+	if filter.CreatedTo != nil {
+		whereConditions = append(whereConditions, fmt.Sprintf("u.created_at <= $%d", argIndex))
+		args = append(args, *filter.CreatedTo)
+		argIndex++
+	}
+
+	if filter.LastLoginFrom != nil {
+		whereConditions = append(whereConditions, fmt.Sprintf("stats.last_login_at >= $%d", argIndex))
+		args = append(args, *filter.LastLoginFrom)
+		argIndex++
+	}
+
+	if filter.LastLoginTo != nil {
+		whereConditions = append(whereConditions, fmt.Sprintf("stats.last_login_at <= $%d", argIndex))
+		args = append(args, *filter.LastLoginTo)
+		argIndex++
+	}
+
+	// 需同时拥有全部标签
+	if len(filter.Tags) > 0 {
+		whereConditions = append(whereConditions, fmt.Sprintf(
+			"u.id IN (SELECT user_id FROM user_tags WHERE tag = ANY($%d::text[]) GROUP BY user_id HAVING COUNT(*) = $%d)",
+			argIndex, argIndex+1))
+		args = append(args, pq.Array(filter.Tags), len(filter.Tags))
+	}
+
+	// 高级查询（字段和运算符白名单，值全部参数化）
+	if filter.Query != nil {
+		clause, queryArgs, err := compileUserQuery(filter.Query, args)
+		if err != nil {
+			return nil, nil, err
+		}
+		whereConditions = append(whereConditions, clause)
+		args = queryArgs
+	}
+
+	return whereConditions, args, nil
+}
+
 // SearchUsers 搜索用户（支持模糊搜索）
 func (r *Repository) SearchUsers(ctx context.Context, keyword string, pagination PaginationParams) ([]*UserManagementModel, int64, error) {
 	whereCondition := "u.deleted_at IS NULL AND (u.email ILIKE $1 OR u.name ILIKE $1)"
@@ -397,10 +436,296 @@ func (r *Repository) GetUserStatistics(ctx context.Context) (*UserStatistics, er
 	return stats, nil
 }
 
+// === 统计汇总方法 ===
+
+// statisticsBucketsQuery 按时区和粒度分桶统计注册、邮箱验证和登录活跃
+// $1 时区，$2 粒度，$3 开始日期，$4 结束日期（不包含，NULL表示不限）
+const statisticsBucketsQuery = `
+	WITH bounds AS (
+		SELECT ($3::timestamp AT TIME ZONE $1) AS start_at,
+		       ($4::timestamp AT TIME ZONE $1) AS end_at
+	),
+	registrations AS (
+		SELECT date_trunc($2, u.created_at AT TIME ZONE $1)::date AS bucket_start, COUNT(*) AS registrations
+		FROM users u, bounds b
+		WHERE u.created_at >= b.start_at AND (b.end_at IS NULL OR u.created_at < b.end_at)
+		GROUP BY 1
+	),
+	verifications AS (
+		SELECT date_trunc($2, u.email_verified_at AT TIME ZONE $1)::date AS bucket_start, COUNT(*) AS verifications
+		FROM users u, bounds b
+		WHERE u.email_verified_at >= b.start_at AND (b.end_at IS NULL OR u.email_verified_at < b.end_at)
+		GROUP BY 1
+	),
+	activity AS (
+		SELECT date_trunc($2, l.created_at AT TIME ZONE $1)::date AS bucket_start,
+		       COUNT(DISTINCT l.user_id) AS active_users, COUNT(*) AS logins
+		FROM login_logs l, bounds b
+		WHERE l.user_type = 'user' AND l.login_status = 'success' AND l.user_id IS NOT NULL
+		  AND l.created_at >= b.start_at AND (b.end_at IS NULL OR l.created_at < b.end_at)
+		GROUP BY 1
+	)
+	SELECT bucket_start,
+	       COALESCE(r.registrations, 0), COALESCE(v.verifications, 0),
+	       COALESCE(a.active_users, 0), COALESCE(a.logins, 0)
+	FROM registrations r
+	FULL JOIN verifications v USING (bucket_start)
+	FULL JOIN activity a USING (bucket_start)
+`
+
+// retentionQuery 按注册周统计cohort在注册后每周的活跃用户数（最多跟踪到当前周）
+// $1 时区，$2 开始日期，$3 结束日期（不包含，NULL表示不限），$4 最多跟踪的周数
+const retentionQuery = `
+	WITH bounds AS (
+		SELECT ($2::timestamp AT TIME ZONE $1) AS start_at,
+		       ($3::timestamp AT TIME ZONE $1) AS end_at,
+		       date_trunc('week', NOW() AT TIME ZONE $1)::date AS current_week
+	),
+	cohorts AS (
+		SELECT u.id, date_trunc('week', u.created_at AT TIME ZONE $1)::date AS cohort_week
+		FROM users u, bounds b
+		WHERE u.created_at >= b.start_at AND (b.end_at IS NULL OR u.created_at < b.end_at)
+	),
+	sizes AS (
+		SELECT cohort_week, COUNT(*) AS cohort_size FROM cohorts GROUP BY cohort_week
+	),
+	cells AS (
+		SELECT s.cohort_week, s.cohort_size, o.week_offset
+		FROM sizes s, bounds b,
+		     generate_series(0, LEAST($4::int, (b.current_week - s.cohort_week) / 7)) AS o(week_offset)
+	),
+	activity AS (
+		SELECT c.cohort_week,
+		       (date_trunc('week', l.created_at AT TIME ZONE $1)::date - c.cohort_week) / 7 AS week_offset,
+		       COUNT(DISTINCT c.id) AS active_users
+		FROM cohorts c
+		JOIN login_logs l ON l.user_id = c.id
+		WHERE l.user_type = 'user' AND l.login_status = 'success'
+		  AND l.created_at >= (SELECT start_at FROM bounds)
+		GROUP BY 1, 2
+	)
+	SELECT cl.cohort_week, cl.week_offset, cl.cohort_size, COALESCE(a.active_users, 0)
+	FROM cells cl
+	LEFT JOIN activity a ON a.cohort_week = cl.cohort_week AND a.week_offset = cl.week_offset
+`
+
+// rollupHistoryStart 汇总表为空时从该日期开始汇总全部历史数据
+const rollupHistoryStart = "1970-01-01"
+
+// RefreshStatisticsRollups 刷新指定时区的统计汇总表
+// 每种粒度从已汇总的最后一个时间桶（可能尚未结束）开始重新计算，留存从仍在跟踪期内的注册周开始重新计算
+// 多个实例同时刷新同一时区时只有一个会执行，返回false表示被跳过
+func (r *Repository) RefreshStatisticsRollups(ctx context.Context, timezone string, retentionWeeks int) (bool, error) {
+	refreshed := false
+	err := r.GetDB().Transaction(func(tx *sql.Tx) error {
+		var locked bool
+		err := tx.QueryRowContext(ctx, `SELECT pg_try_advisory_xact_lock(hashtext('user_statistics_rollups:' || $1::text))`, timezone).Scan(&locked)
+		if err != nil {
+			return fmt.Errorf("failed to lock statistics rollups: %w", err)
+		}
+		if !locked {
+			return nil
+		}
+
+		for _, period := range []string{StatisticsPeriodDay, StatisticsPeriodWeek, StatisticsPeriodMonth} {
+			var lastBucket sql.NullTime
+			err := tx.QueryRowContext(ctx, `
+				SELECT MAX(bucket_start) FROM user_statistics_rollups WHERE timezone = $1 AND period = $2
+			`, timezone, period).Scan(&lastBucket)
+			if err != nil {
+				return fmt.Errorf("failed to get last statistics bucket: %w", err)
+			}
+
+			since := rollupHistoryStart
+			if lastBucket.Valid {
+				since = lastBucket.Time.Format(statisticsDateLayout)
+			}
+
+			_, err = tx.ExecContext(ctx, `
+				INSERT INTO user_statistics_rollups (
+					timezone, period, bucket_start, registrations, verifications, active_users, logins, computed_at
+				)
+				SELECT $1::text, $2::text, s.bucket_start, s.registrations, s.verifications, s.active_users, s.logins, NOW()
+				FROM (`+statisticsBucketsQuery+`) AS s (bucket_start, registrations, verifications, active_users, logins)
+				ON CONFLICT (timezone, period, bucket_start) DO UPDATE SET
+					registrations = EXCLUDED.registrations,
+					verifications = EXCLUDED.verifications,
+					active_users = EXCLUDED.active_users,
+					logins = EXCLUDED.logins,
+					computed_at = EXCLUDED.computed_at
+			`, timezone, period, since, nil)
+			if err != nil {
+				return fmt.Errorf("failed to refresh %s statistics rollups: %w", period, err)
+			}
+		}
+
+		var lastCohort sql.NullTime
+		err = tx.QueryRowContext(ctx, `
+			SELECT MAX(cohort_week) FROM user_retention_rollups WHERE timezone = $1
+		`, timezone).Scan(&lastCohort)
+		if err != nil {
+			return fmt.Errorf("failed to get last retention cohort: %w", err)
+		}
+
+		since := rollupHistoryStart
+		if lastCohort.Valid {
+			since = lastCohort.Time.AddDate(0, 0, -7*retentionWeeks).Format(statisticsDateLayout)
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO user_retention_rollups (timezone, cohort_week, week_offset, cohort_size, active_users, computed_at)
+			SELECT $1::text, s.cohort_week, s.week_offset, s.cohort_size, s.active_users, NOW()
+			FROM (`+retentionQuery+`) AS s (cohort_week, week_offset, cohort_size, active_users)
+			ON CONFLICT (timezone, cohort_week, week_offset) DO UPDATE SET
+				cohort_size = EXCLUDED.cohort_size,
+				active_users = EXCLUDED.active_users,
+				computed_at = EXCLUDED.computed_at
+		`, timezone, since, nil, retentionWeeks)
+		if err != nil {
+			return fmt.Errorf("failed to refresh retention rollups: %w", err)
+		}
+
+		refreshed = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return refreshed, nil
+}
+
+// HasStatisticsRollups 指定时区是否已有统计汇总数据
+func (r *Repository) HasStatisticsRollups(ctx context.Context, timezone string) (bool, error) {
+	var exists bool
+	err := r.GetDB().QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM user_statistics_rollups WHERE timezone = $1)
+	`, timezone).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check statistics rollups: %w", err)
+	}
+	return exists, nil
+}
+
+// GetStatisticsRollups 从汇总表读取[start, end)内的时间桶，没有数据的时间桶不返回
+func (r *Repository) GetStatisticsRollups(ctx context.Context, timezone, period string, start, end time.Time) ([]*StatisticsBucket, error) {
+	rows, err := r.GetDB().QueryContext(ctx, `
+		SELECT bucket_start, registrations, verifications, active_users, logins, computed_at
+		FROM user_statistics_rollups
+		WHERE timezone = $1 AND period = $2 AND bucket_start >= $3::date AND bucket_start < $4::date
+		ORDER BY bucket_start
+	`, timezone, period, start.Format(statisticsDateLayout), end.Format(statisticsDateLayout))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get statistics rollups: %w", err)
+	}
+	defer rows.Close()
+
+	var buckets []*StatisticsBucket
+	for rows.Next() {
+		var bucket StatisticsBucket
+		err := rows.Scan(&bucket.BucketStart, &bucket.Registrations, &bucket.Verifications,
+			&bucket.ActiveUsers, &bucket.Logins, &bucket.ComputedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan statistics rollup: %w", err)
+		}
+		buckets = append(buckets, &bucket)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate statistics rollups: %w", err)
+	}
+
+	return buckets, nil
+}
+
+// QueryStatisticsBuckets 实时计算[start, end)内的时间桶（用于没有汇总的时区），没有数据的时间桶不返回
+func (r *Repository) QueryStatisticsBuckets(ctx context.Context, timezone, period string, start, end time.Time) ([]*StatisticsBucket, error) {
+	rows, err := r.GetDB().QueryContext(ctx, statisticsBucketsQuery+` ORDER BY bucket_start`,
+		timezone, period, start.Format(statisticsDateLayout), end.Format(statisticsDateLayout))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query statistics buckets: %w", err)
+	}
+	defer rows.Close()
+
+	var buckets []*StatisticsBucket
+	for rows.Next() {
+		var bucket StatisticsBucket
+		err := rows.Scan(&bucket.BucketStart, &bucket.Registrations, &bucket.Verifications,
+			&bucket.ActiveUsers, &bucket.Logins)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan statistics bucket: %w", err)
+		}
+		buckets = append(buckets, &bucket)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate statistics buckets: %w", err)
+	}
+
+	return buckets, nil
+}
+
+// GetRetentionRollups 从汇总表读取注册周在[start, end)内的留存数据
+func (r *Repository) GetRetentionRollups(ctx context.Context, timezone string, start, end time.Time, weeks int) ([]*RetentionCell, error) {
+	rows, err := r.GetDB().QueryContext(ctx, `
+		SELECT cohort_week, week_offset, cohort_size, active_users, computed_at
+		FROM user_retention_rollups
+		WHERE timezone = $1 AND cohort_week >= $2::date AND cohort_week < $3::date AND week_offset <= $4
+		ORDER BY cohort_week, week_offset
+	`, timezone, start.Format(statisticsDateLayout), end.Format(statisticsDateLayout), weeks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get retention rollups: %w", err)
+	}
+	defer rows.Close()
+
+	var cells []*RetentionCell
+	for rows.Next() {
+		var cell RetentionCell
+		err := rows.Scan(&cell.CohortWeek, &cell.WeekOffset, &cell.CohortSize, &cell.ActiveUsers, &cell.ComputedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan retention rollup: %w", err)
+		}
+		cells = append(cells, &cell)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate retention rollups: %w", err)
+	}
+
+	return cells, nil
+}
+
+// QueryRetention 实时计算注册周在[start, end)内的留存数据（用于没有汇总的时区）
+func (r *Repository) QueryRetention(ctx context.Context, timezone string, start, end time.Time, weeks int) ([]*RetentionCell, error) {
+	rows, err := r.GetDB().QueryContext(ctx, retentionQuery+` ORDER BY cl.cohort_week, cl.week_offset`,
+		timezone, start.Format(statisticsDateLayout), end.Format(statisticsDateLayout), weeks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query retention: %w", err)
+	}
+	defer rows.Close()
+
+	var cells []*RetentionCell
+	for rows.Next() {
+		var cell RetentionCell
+		err := rows.Scan(&cell.CohortWeek, &cell.WeekOffset, &cell.CohortSize, &cell.ActiveUsers)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan retention: %w", err)
+		}
+		cells = append(cells, &cell)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate retention: %w", err)
+	}
+
+	return cells, nil
+}
+
 // === 用户管理操作方法 ===
 
-// UpdateUserStatus 更新用户状态
-func (r *Repository) UpdateUserStatus(ctx context.Context, userID string, status user.UserStatus, reason *string) error {
+// UpdateUserStatus 更新用户状态（暂停请使用SuspendUser）
+// 改为非暂停状态时同时解除当前生效的暂停，liftedBy为执行操作的管理员
+func (r *Repository) UpdateUserStatus(ctx context.Context, userID string, status user.UserStatus, liftedBy *string) error {
 	tx, err := r.GetDB().BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
@@ -425,25 +750,176 @@ func (r *Repository) UpdateUserStatus(ctx context.Context, userID string, status
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("user not found or no changes made")
+		return fmt.Errorf("user not found")
 	}
 
-	// 如果是暂停状态，记录暂停信息
-	if status == user.UserStatusSuspended && reason != nil {
-		suspendQuery := `
-			UPDATE users 
-			SET suspended_at = NOW(), suspended_reason = $1
-			WHERE id = $2
-		`
-		_, err = tx.ExecContext(ctx, suspendQuery, reason, userID)
-		if err != nil {
-			return fmt.Errorf("failed to update suspension info: %w", err)
+	// 非暂停状态时清除暂停信息
+	if status != user.UserStatusSuspended {
+		if err := liftActiveSuspensions(ctx, tx, userID, liftedBy, SuspensionLiftAdmin); err != nil {
+			return err
 		}
 	}
 
 	return tx.Commit()
 }
 
+// SuspendUser 暂停用户：写入暂停记录并更新用户的当前暂停信息，之前生效的暂停标记为被替代
+func (r *Repository) SuspendUser(ctx context.Context, suspension *UserSuspension) error {
+	suspension.ID = uuid.New().String()
+
+	return r.GetDB().Transaction(func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE user_suspensions
+			SET lifted_at = NOW(), lifted_by = $2, lift_reason = $3
+			WHERE user_id = $1 AND lifted_at IS NULL
+		`, suspension.UserID, suspension.AdminID, SuspensionLiftSuperseded); err != nil {
+			return fmt.Errorf("failed to supersede suspensions: %w", err)
+		}
+
+		result, err := tx.ExecContext(ctx, `
+			UPDATE users
+			SET status = $2, suspended_at = NOW(), suspended_reason = $3, suspended_until = $4, updated_at = NOW()
+			WHERE id = $1 AND deleted_at IS NULL
+		`, suspension.UserID, user.UserStatusSuspended.String(), suspension.Reason, suspension.EndsAt)
+		if err != nil {
+			return fmt.Errorf("failed to suspend user: %w", err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rowsAffected == 0 {
+			return fmt.Errorf("user not found")
+		}
+
+		err = tx.QueryRowContext(ctx, `
+			INSERT INTO user_suspensions (id, user_id, admin_id, reason, starts_at, ends_at, created_at)
+			VALUES ($1, $2, $3, $4, NOW(), $5, NOW())
+			RETURNING starts_at, created_at
+		`, suspension.ID, suspension.UserID, suspension.AdminID, suspension.Reason, suspension.EndsAt).Scan(
+			&suspension.StartsAt, &suspension.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to create suspension: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// LiftExpiredSuspension 解除已到期的暂停并恢复用户为激活状态
+// 暂停已被解除或替代时返回"suspension not found"
+func (r *Repository) LiftExpiredSuspension(ctx context.Context, suspension *UserSuspension) error {
+	return r.GetDB().Transaction(func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, `
+			UPDATE user_suspensions
+			SET lifted_at = NOW(), lift_reason = $2
+			WHERE id = $1 AND lifted_at IS NULL AND ends_at <= NOW()
+		`, suspension.ID, SuspensionLiftExpired)
+		if err != nil {
+			return fmt.Errorf("failed to lift suspension: %w", err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rowsAffected == 0 {
+			return fmt.Errorf("suspension not found")
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE users
+			SET status = $2, suspended_at = NULL, suspended_reason = NULL, suspended_until = NULL, updated_at = NOW()
+			WHERE id = $1 AND status = $3
+		`, suspension.UserID, user.UserStatusActive.String(), user.UserStatusSuspended.String()); err != nil {
+			return fmt.Errorf("failed to reactivate user: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// liftActiveSuspensions 解除用户当前生效的暂停并清除用户表中的暂停信息
+func liftActiveSuspensions(ctx context.Context, tx *sql.Tx, userID string, liftedBy *string, liftReason string) error {
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE user_suspensions
+		SET lifted_at = NOW(), lifted_by = $2, lift_reason = $3
+		WHERE user_id = $1 AND lifted_at IS NULL
+	`, userID, liftedBy, liftReason); err != nil {
+		return fmt.Errorf("failed to lift suspensions: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE users
+		SET suspended_at = NULL, suspended_reason = NULL, suspended_until = NULL
+		WHERE id = $1
+	`, userID); err != nil {
+		return fmt.Errorf("failed to clear suspension info: %w", err)
+	}
+
+	return nil
+}
+
+const userSuspensionColumns = `
+	id, user_id, admin_id, reason, starts_at, ends_at, lifted_at, lifted_by, lift_reason, created_at
+`
+
+// scanUserSuspension 扫描用户暂停记录
+func scanUserSuspension(scanner interface{ Scan(...interface{}) error }) (*UserSuspension, error) {
+	suspension := &UserSuspension{}
+	err := scanner.Scan(
+		&suspension.ID, &suspension.UserID, &suspension.AdminID, &suspension.Reason, &suspension.StartsAt,
+		&suspension.EndsAt, &suspension.LiftedAt, &suspension.LiftedBy, &suspension.LiftReason, &suspension.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return suspension, nil
+}
+
+// ListDueSuspensions 获取已到期但尚未解除的暂停
+func (r *Repository) ListDueSuspensions(ctx context.Context, limit int) ([]*UserSuspension, error) {
+	query := `SELECT ` + userSuspensionColumns + `
+		FROM user_suspensions
+		WHERE lifted_at IS NULL AND ends_at IS NOT NULL AND ends_at <= NOW()
+		ORDER BY ends_at
+		LIMIT $1
+	`
+
+	return r.querySuspensions(ctx, query, limit)
+}
+
+// GetUserSuspensions 获取用户的暂停历史（按时间倒序）
+func (r *Repository) GetUserSuspensions(ctx context.Context, userID string) ([]*UserSuspension, error) {
+	query := `SELECT ` + userSuspensionColumns + `
+		FROM user_suspensions
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`
+
+	return r.querySuspensions(ctx, query, userID)
+}
+
+// querySuspensions 查询暂停记录列表
+func (r *Repository) querySuspensions(ctx context.Context, query string, args ...interface{}) ([]*UserSuspension, error) {
+	rows, err := r.GetDB().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get suspensions: %w", err)
+	}
+	defer rows.Close()
+
+	suspensions := make([]*UserSuspension, 0)
+	for rows.Next() {
+		suspension, err := scanUserSuspension(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan suspension: %w", err)
+		}
+		suspensions = append(suspensions, suspension)
+	}
+
+	return suspensions, rows.Err()
+}
+
 // GetUserSessions 获取用户会话
 func (r *Repository) GetUserSessions(ctx context.Context, userID string) ([]SessionSummary, error) {
 	query := `
@@ -515,26 +991,94 @@ func (r *Repository) DeactivateUserSessions(ctx context.Context, userID string,
 	return nil
 }
 
-// CreateManagementLog 创建管理操作日志
+// CreateManagementLog 创建管理操作日志，写入时计算哈希链并在同一事务中更新检查点
+// 通过事务级advisory lock串行化写入，保证每条记录的prev_hash指向前一条记录
 func (r *Repository) CreateManagementLog(ctx context.Context, log *UserManagementLog) error {
-	log.ID = uuid.New().String()
+	if len(r.auditLogKey) == 0 {
+		return fmt.Errorf("failed to create management log: audit log key is not configured")
+	}
+	if log.ID == "" {
+		log.ID = uuid.New().String()
+	}
+	if log.CreatedAt.IsZero() {
+		log.CreatedAt = time.Now()
+	}
+	// 数据库只保存到微秒，哈希按保存后的值计算，校验时才能得到相同结果
+	log.CreatedAt = log.CreatedAt.UTC().Truncate(time.Microsecond)
 
-	query := `
-		INSERT INTO login_logs (id, user_id, email, user_type, login_status, failure_reason, ip_address, user_agent, session_id, created_at)
-		VALUES ($1, $2, $3, 'admin_operation', $4, $5, $6, $7, $8, NOW())
-	`
+	log.TargetEmailHash = hashTargetEmail(r.auditLogKey, log.TargetEmail)
 
-	// 将管理操作转换为日志格式
-	reason := fmt.Sprintf("Admin operation: %s", log.Action.String())
-	if log.Reason != nil {
-		reason += " - " + *log.Reason
+	var detailsValue interface{}
+	if log.Details != nil {
+		raw, err := json.Marshal(log.Details)
+		if err != nil {
+			return fmt.Errorf("failed to marshal management log details: %w", err)
+		}
+		detailsValue = raw
 	}
 
-	_, err := r.GetDB().ExecContext(ctx, query,
-		log.ID, log.TargetUserID, log.TargetEmail, "admin_operation",
-		reason, log.IPAddress, log.UserAgent, log.AdminID,
-	)
+	var targetUserID interface{}
+	if log.TargetUserID != "" {
+		targetUserID = log.TargetUserID
+	}
+
+	err := r.GetDB().Transaction(func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('user_management_logs'))`); err != nil {
+			return fmt.Errorf("failed to lock management log chain: %w", err)
+		}
+
+		// 按JSONB读出后的内容计算hash（JSONB会改写数字的写法），校验时才能得到相同结果
+		var details json.RawMessage
+		if detailsValue != nil {
+			var stored []byte
+			if err := tx.QueryRowContext(ctx, `SELECT $1::jsonb::text`, detailsValue).Scan(&stored); err != nil {
+				return fmt.Errorf("failed to normalize management log details: %w", err)
+			}
+			var err error
+			if details, err = canonicalJSON(stored); err != nil {
+				return err
+			}
+		}
+
+		var prevHash string
+		err := tx.QueryRowContext(ctx, `SELECT hash FROM user_management_logs ORDER BY seq DESC LIMIT 1`).Scan(&prevHash)
+		if err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("failed to get last management log hash: %w", err)
+		}
+
+		hash, err := computeLogHash(r.auditLogKey, prevHash, log, details)
+		if err != nil {
+			return err
+		}
+
+		err = tx.QueryRowContext(ctx, `
+			INSERT INTO user_management_logs (
+				id, admin_id, admin_email, target_user_id, target_email, target_email_hash, action, reason,
+				details, ip_address, user_agent, prev_hash, hash, created_at
+			)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+			RETURNING seq
+		`, log.ID, log.AdminID, log.AdminEmail, targetUserID, log.TargetEmail, log.TargetEmailHash, log.Action, log.Reason,
+			detailsValue, log.IPAddress, log.UserAgent, prevHash, hash, log.CreatedAt,
+		).Scan(&log.Seq)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO user_management_log_checkpoint (id, seq, hash, signature, updated_at)
+			VALUES (true, $1, $2, $3, NOW())
+			ON CONFLICT (id) DO UPDATE SET
+				seq = EXCLUDED.seq, hash = EXCLUDED.hash, signature = EXCLUDED.signature, updated_at = EXCLUDED.updated_at
+		`, log.Seq, hash, signLogCheckpoint(r.auditLogKey, log.Seq, hash))
+		if err != nil {
+			return fmt.Errorf("failed to update management log checkpoint: %w", err)
+		}
 
+		log.PrevHash = prevHash
+		log.Hash = hash
+		return nil
+	})
 	if err != nil {
 		return fmt.Errorf("failed to create management log: %w", err)
 	}
@@ -542,6 +1086,171 @@ func (r *Repository) CreateManagementLog(ctx context.Context, log *UserManagemen
 	return nil
 }
 
+// buildManagementLogConditions 根据过滤条件构建管理日志查询的WHERE条件
+func buildManagementLogConditions(filter *ManagementLogFilter) ([]string, []interface{}) {
+	whereConditions := []string{"1 = 1"}
+	var args []interface{}
+	if filter == nil {
+		return whereConditions, args
+	}
+
+	if filter.AdminID != nil {
+		args = append(args, *filter.AdminID)
+		whereConditions = append(whereConditions, fmt.Sprintf("admin_id = $%d", len(args)))
+	}
+	if filter.Action != nil {
+		args = append(args, filter.Action.String())
+		whereConditions = append(whereConditions, fmt.Sprintf("action = $%d", len(args)))
+	}
+	if filter.TargetUserID != nil {
+		args = append(args, *filter.TargetUserID)
+		whereConditions = append(whereConditions, fmt.Sprintf("target_user_id = $%d", len(args)))
+	}
+	if filter.DateFrom != nil {
+		args = append(args, *filter.DateFrom)
+		whereConditions = append(whereConditions, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+	if filter.DateTo != nil {
+		args = append(args, *filter.DateTo)
+		whereConditions = append(whereConditions, fmt.Sprintf("created_at < $%d", len(args)))
+	}
+	if filter.Query != nil {
+		args = append(args, "%"+*filter.Query+"%")
+		whereConditions = append(whereConditions, fmt.Sprintf("reason ILIKE $%d", len(args)))
+	}
+
+	return whereConditions, args
+}
+
+// managementLogColumns 管理日志查询字段
+const managementLogColumns = `
+	id, seq, admin_id, admin_email, target_user_id, target_email, target_email_hash, action, reason,
+	details, ip_address, user_agent, prev_hash, hash, created_at
+`
+
+// scanManagementLog 扫描管理日志，同时返回details的原始JSON（用于校验哈希）
+func scanManagementLog(rows *sql.Rows) (*UserManagementLog, []byte, error) {
+	var log UserManagementLog
+	var targetUserID sql.NullString
+	var details []byte
+	err := rows.Scan(
+		&log.ID, &log.Seq, &log.AdminID, &log.AdminEmail, &targetUserID, &log.TargetEmail, &log.TargetEmailHash,
+		&log.Action, &log.Reason, &details, &log.IPAddress, &log.UserAgent,
+		&log.PrevHash, &log.Hash, &log.CreatedAt,
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to scan management log: %w", err)
+	}
+
+	log.TargetUserID = targetUserID.String
+	if len(details) > 0 {
+		var parsed map[string]interface{}
+		if err := json.Unmarshal(details, &parsed); err == nil {
+			log.Details = &parsed
+		}
+	}
+
+	return &log, details, nil
+}
+
+// ListManagementLogs 分页查询管理操作日志（按写入顺序倒序）
+func (r *Repository) ListManagementLogs(ctx context.Context, filter *ManagementLogFilter, pagination PaginationParams) ([]*UserManagementLog, int64, error) {
+	whereConditions, args := buildManagementLogConditions(filter)
+	whereClause := strings.Join(whereConditions, " AND ")
+
+	var total int64
+	countQuery := "SELECT COUNT(*) FROM user_management_logs WHERE " + whereClause
+	if err := r.GetDB().QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count management logs: %w", err)
+	}
+
+	args = append(args, pagination.GetLimit(), pagination.GetOffset())
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM user_management_logs
+		WHERE %s
+		ORDER BY seq DESC
+		LIMIT $%d OFFSET $%d
+	`, managementLogColumns, whereClause, len(args)-1, len(args))
+
+	logs := []*UserManagementLog{}
+	err := r.streamManagementLogs(ctx, query, args, func(log *UserManagementLog, _ []byte) error {
+		logs = append(logs, log)
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return logs, total, nil
+}
+
+// StreamManagementLogs 按过滤条件逐行读取管理操作日志（按写入顺序倒序），用于导出
+func (r *Repository) StreamManagementLogs(ctx context.Context, filter *ManagementLogFilter, fn func(*UserManagementLog) error) error {
+	whereConditions, args := buildManagementLogConditions(filter)
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM user_management_logs
+		WHERE %s
+		ORDER BY seq DESC
+	`, managementLogColumns, strings.Join(whereConditions, " AND "))
+
+	return r.streamManagementLogs(ctx, query, args, func(log *UserManagementLog, _ []byte) error {
+		return fn(log)
+	})
+}
+
+// GetLogCheckpoint 获取管理操作日志哈希链检查点，不存在时返回nil
+func (r *Repository) GetLogCheckpoint(ctx context.Context) (*LogCheckpoint, error) {
+	var checkpoint LogCheckpoint
+	err := r.GetDB().QueryRowContext(ctx, `
+		SELECT seq, hash, signature, updated_at FROM user_management_log_checkpoint WHERE id
+	`).Scan(&checkpoint.Seq, &checkpoint.Hash, &checkpoint.Signature, &checkpoint.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get management log checkpoint: %w", err)
+	}
+	return &checkpoint, nil
+}
+
+// StreamLogChain 按写入顺序逐行读取全部管理操作日志，用于校验哈希链
+func (r *Repository) StreamLogChain(ctx context.Context, fn func(*UserManagementLog, []byte) error) error {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM user_management_logs
+		ORDER BY seq ASC
+	`, managementLogColumns)
+
+	return r.streamManagementLogs(ctx, query, nil, fn)
+}
+
+// streamManagementLogs 执行管理日志查询并逐行回调
+func (r *Repository) streamManagementLogs(ctx context.Context, query string, args []interface{}, fn func(*UserManagementLog, []byte) error) error {
+	rows, err := r.GetDB().QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query management logs: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		log, details, err := scanManagementLog(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(log, details); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to iterate management logs: %w", err)
+	}
+
+	return nil
+}
+
 // GetUserActivityLogs 获取用户活动日志
 func (r *Repository) GetUserActivityLogs(ctx context.Context, userID string, limit int) ([]UserActivityLogSummary, error) {
 	query := `
@@ -571,3 +1280,1294 @@ func (r *Repository) GetUserActivityLogs(ctx context.Context, userID string, lim
 
 	return logs, nil
 }
+
+// exportSortColumns 导出支持的排序字段
+var exportSortColumns = map[string]string{
+	"created_at":    "u.created_at",
+	"updated_at":    "u.updated_at",
+	"email":         "u.email",
+	"name":          "u.name",
+	"last_login_at": "stats.last_login_at",
+	"login_count":   "COALESCE(stats.login_count, 0)",
+}
+
+// StreamUsersForExport 按与用户列表相同的过滤条件逐行读取用户，每行调用一次fn，返回读取的行数
+// 有搜索关键词时与SearchUsers一致只按关键词过滤
+func (r *Repository) StreamUsersForExport(ctx context.Context, filter *SearchFilter, search string,
+	pagination PaginationParams, fn func(*UserManagementModel) error) (int64, error) {
+
+	var whereConditions []string
+	var args []interface{}
+	if search != "" {
+		whereConditions = []string{"u.deleted_at IS NULL AND (u.email ILIKE $1 OR u.name ILIKE $1)"}
+		args = []interface{}{"%" + search + "%"}
+	} else {
+		var err error
+		if whereConditions, args, err = buildUserFilterConditions(filter); err != nil {
+			return 0, err
+		}
+	}
+
+	sortColumn, ok := exportSortColumns[pagination.SortBy]
+	if !ok {
+		sortColumn = "u.created_at"
+	}
+	sortDir := "DESC"
+	if pagination.SortDir == "asc" {
+		sortDir = "ASC"
+	}
+
+	query := fmt.Sprintf(`
+		SELECT 
+			u.id, u.email, u.name, u.status, u.email_verified, u.email_verified_at,
+			u.created_at, u.updated_at, u.deleted_at,
+			stats.last_login_at,
+			COALESCE(stats.login_count, 0) as login_count,
+			COALESCE(stats.failed_attempts, 0) as failed_attempts
+		FROM users u
+		LEFT JOIN (
+			SELECT 
+				user_id,
+				MAX(created_at) as last_login_at,
+				COUNT(*) as login_count,
+				SUM(CASE WHEN login_status = 'failed' THEN 1 ELSE 0 END) as failed_attempts
+			FROM login_logs 
+			WHERE user_type = 'user' AND user_id IS NOT NULL
+			GROUP BY user_id
+		) stats ON u.id = stats.user_id
+		WHERE %s
+		ORDER BY %s %s NULLS LAST, u.id
+	`, strings.Join(whereConditions, " AND "), sortColumn, sortDir)
+
+	rows, err := r.GetDB().QueryContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query users for export: %w", err)
+	}
+	defer rows.Close()
+
+	var count int64
+	userModel := &UserManagementModel{User: &user.User{}}
+	for rows.Next() {
+		err := rows.Scan(
+			&userModel.ID, &userModel.Email, &userModel.Name, &userModel.Status,
+			&userModel.EmailVerified, &userModel.EmailVerifiedAt,
+			&userModel.CreatedAt, &userModel.UpdatedAt, &userModel.DeletedAt,
+			&userModel.LastLoginAt, &userModel.LoginCount, &userModel.FailedAttempts,
+		)
+		if err != nil {
+			return count, fmt.Errorf("failed to scan user: %w", err)
+		}
+		if err := fn(userModel); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	if err := rows.Err(); err != nil {
+		return count, fmt.Errorf("failed to iterate users: %w", err)
+	}
+
+	return count, nil
+}
+
+// === 批量操作方法 ===
+
+// ListBatchTargets 按筛选条件获取批量操作的目标用户（最多limit个）
+func (r *Repository) ListBatchTargets(ctx context.Context, filter *SearchFilter, search string, limit int) ([]*BatchTarget, error) {
+	whereConditions, args, err := buildUserFilterConditions(filter)
+	if err != nil {
+		return nil, err
+	}
+	if search != "" {
+		args = append(args, "%"+search+"%")
+		whereConditions = append(whereConditions, fmt.Sprintf("(u.email ILIKE $%d OR u.name ILIKE $%d)", len(args), len(args)))
+	}
+	args = append(args, limit)
+
+	query := fmt.Sprintf(`
+		SELECT u.id, u.email, u.status
+		FROM users u
+		LEFT JOIN (
+			SELECT user_id, MAX(created_at) as last_login_at
+			FROM login_logs
+			WHERE user_type = 'user' AND user_id IS NOT NULL
+			GROUP BY user_id
+		) stats ON u.id = stats.user_id
+		WHERE %s
+		ORDER BY u.created_at, u.id
+		LIMIT $%d
+	`, strings.Join(whereConditions, " AND "), len(args))
+
+	return r.queryBatchTargets(ctx, query, args...)
+}
+
+// GetBatchTargetsByIDs 按ID获取批量操作的目标用户（不存在或已注销的用户不返回）
+func (r *Repository) GetBatchTargetsByIDs(ctx context.Context, userIDs []string) ([]*BatchTarget, error) {
+	query := `
+		SELECT id, email, status
+		FROM users
+		WHERE id = ANY($1::uuid[]) AND deleted_at IS NULL
+		ORDER BY created_at, id
+	`
+
+	return r.queryBatchTargets(ctx, query, pq.Array(userIDs))
+}
+
+// queryBatchTargets 查询批量操作的目标用户
+func (r *Repository) queryBatchTargets(ctx context.Context, query string, args ...interface{}) ([]*BatchTarget, error) {
+	rows, err := r.GetDB().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get batch targets: %w", err)
+	}
+	defer rows.Close()
+
+	targets := make([]*BatchTarget, 0)
+	for rows.Next() {
+		target := &BatchTarget{}
+		if err := rows.Scan(&target.UserID, &target.Email, &target.Status); err != nil {
+			return nil, fmt.Errorf("failed to scan batch target: %w", err)
+		}
+		targets = append(targets, target)
+	}
+
+	return targets, rows.Err()
+}
+
+const batchJobColumns = `
+	id, action, params, target_type, target_filter, status,
+	total_count, processed_count, succeeded_count, failed_count, skipped_count,
+	created_by, created_by_email, host(ip_address), approved_by, approved_at, cancelled_by, cancelled_at,
+	started_at, completed_at, created_at, updated_at
+`
+
+// scanBatchJob 扫描批量操作任务
+func scanBatchJob(scanner interface{ Scan(...interface{}) error }) (*BatchJob, error) {
+	job := &BatchJob{}
+	var params, targetFilter []byte
+	var ipAddress sql.NullString
+	err := scanner.Scan(
+		&job.ID, &job.Action, &params, &job.TargetType, &targetFilter, &job.Status,
+		&job.TotalCount, &job.ProcessedCount, &job.SucceededCount, &job.FailedCount, &job.SkippedCount,
+		&job.CreatedBy, &job.CreatedByEmail, &ipAddress, &job.ApprovedBy, &job.ApprovedAt, &job.CancelledBy, &job.CancelledAt,
+		&job.StartedAt, &job.CompletedAt, &job.CreatedAt, &job.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	job.Params = params
+	job.TargetFilter = targetFilter
+	if ipAddress.Valid {
+		job.IPAddress = &ipAddress.String
+	}
+	return job, nil
+}
+
+// CreateBatchJob 创建批量操作任务及每个用户的执行记录，不存在的用户直接记为失败
+func (r *Repository) CreateBatchJob(ctx context.Context, job *BatchJob, targets []*BatchTarget, missingIDs []string) error {
+	job.ID = uuid.New().String()
+
+	userIDs := make([]string, 0, len(targets))
+	emails := make([]string, 0, len(targets))
+	for _, target := range targets {
+		userIDs = append(userIDs, target.UserID)
+		emails = append(emails, target.Email)
+	}
+
+	return r.GetDB().Transaction(func(tx *sql.Tx) error {
+		var targetFilter interface{}
+		if len(job.TargetFilter) > 0 {
+			targetFilter = []byte(job.TargetFilter)
+		}
+
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO user_batch_jobs (
+				id, action, params, target_type, target_filter, status,
+				total_count, processed_count, failed_count,
+				created_by, created_by_email, ip_address, approved_by, approved_at, created_at, updated_at
+			)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, NOW(), NOW())
+			RETURNING created_at, updated_at
+		`, job.ID, job.Action, []byte(job.Params), job.TargetType, targetFilter, job.Status,
+			job.TotalCount, job.ProcessedCount, job.FailedCount,
+			job.CreatedBy, job.CreatedByEmail, job.IPAddress, job.ApprovedBy, job.ApprovedAt).Scan(&job.CreatedAt, &job.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to create batch job: %w", err)
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO user_batch_job_items (job_id, user_id, email, status, created_at)
+			SELECT $1, t.user_id, t.email, 'pending', NOW()
+			FROM unnest($2::uuid[], $3::text[]) AS t(user_id, email)
+		`, job.ID, pq.Array(userIDs), pq.Array(emails)); err != nil {
+			return fmt.Errorf("failed to create batch job items: %w", err)
+		}
+
+		if len(missingIDs) > 0 {
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO user_batch_job_items (job_id, user_id, status, message, processed_at, created_at)
+				SELECT $1, t.user_id, 'failed', 'User not found', NOW(), NOW()
+				FROM unnest($2::uuid[]) AS t(user_id)
+			`, job.ID, pq.Array(missingIDs)); err != nil {
+				return fmt.Errorf("failed to create batch job items: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// GetBatchJob 获取批量操作任务
+func (r *Repository) GetBatchJob(ctx context.Context, jobID string) (*BatchJob, error) {
+	query := `SELECT ` + batchJobColumns + ` FROM user_batch_jobs WHERE id = $1`
+
+	job, err := scanBatchJob(r.GetDB().QueryRowContext(ctx, query, jobID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("batch job not found")
+		}
+		return nil, fmt.Errorf("failed to get batch job: %w", err)
+	}
+
+	return job, nil
+}
+
+// ListBatchJobs 获取批量操作任务列表（按创建时间倒序）
+func (r *Repository) ListBatchJobs(ctx context.Context, status string, pagination PaginationParams) ([]*BatchJob, int64, error) {
+	var total int64
+	err := r.GetDB().QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM user_batch_jobs WHERE $1 = '' OR status = $1
+	`, status).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count batch jobs: %w", err)
+	}
+
+	query := `SELECT ` + batchJobColumns + `
+		FROM user_batch_jobs
+		WHERE $1 = '' OR status = $1
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.GetDB().QueryContext(ctx, query, status, pagination.GetLimit(), pagination.GetOffset())
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get batch jobs: %w", err)
+	}
+	defer rows.Close()
+
+	jobs := make([]*BatchJob, 0)
+	for rows.Next() {
+		job, err := scanBatchJob(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan batch job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, total, rows.Err()
+}
+
+const batchJobItemColumns = `
+	id, job_id, user_id, email, status, message, processed_at, created_at
+`
+
+// ListBatchJobItems 获取批量操作中每个用户的执行结果
+func (r *Repository) ListBatchJobItems(ctx context.Context, jobID, status string, pagination PaginationParams) ([]*BatchJobItem, int64, error) {
+	var total int64
+	err := r.GetDB().QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM user_batch_job_items WHERE job_id = $1 AND ($2 = '' OR status = $2)
+	`, jobID, status).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count batch job items: %w", err)
+	}
+
+	query := `SELECT ` + batchJobItemColumns + `
+		FROM user_batch_job_items
+		WHERE job_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY created_at, id
+		LIMIT $3 OFFSET $4
+	`
+
+	items, err := r.queryBatchJobItems(ctx, query, jobID, status, pagination.GetLimit(), pagination.GetOffset())
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return items, total, nil
+}
+
+// ListPendingBatchJobItems 获取批量操作中尚未执行的用户
+func (r *Repository) ListPendingBatchJobItems(ctx context.Context, jobID string, limit int) ([]*BatchJobItem, error) {
+	query := `SELECT ` + batchJobItemColumns + `
+		FROM user_batch_job_items
+		WHERE job_id = $1 AND status = 'pending'
+		ORDER BY created_at, id
+		LIMIT $2
+	`
+
+	return r.queryBatchJobItems(ctx, query, jobID, limit)
+}
+
+// queryBatchJobItems 查询批量操作执行结果
+func (r *Repository) queryBatchJobItems(ctx context.Context, query string, args ...interface{}) ([]*BatchJobItem, error) {
+	rows, err := r.GetDB().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get batch job items: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]*BatchJobItem, 0)
+	for rows.Next() {
+		item := &BatchJobItem{}
+		err := rows.Scan(&item.ID, &item.JobID, &item.UserID, &item.Email, &item.Status,
+			&item.Message, &item.ProcessedAt, &item.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan batch job item: %w", err)
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// ApproveBatchJob 审批等待中的批量操作任务，任务不在等待审批状态时返回"batch job not awaiting approval"
+func (r *Repository) ApproveBatchJob(ctx context.Context, jobID, adminID string) error {
+	result, err := r.GetDB().ExecContext(ctx, `
+		UPDATE user_batch_jobs
+		SET status = $2, approved_by = $3, approved_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status = $4
+	`, jobID, BatchJobQueued, adminID, BatchJobAwaitingApproval)
+	if err != nil {
+		return fmt.Errorf("failed to approve batch job: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("batch job not awaiting approval")
+	}
+
+	return nil
+}
+
+// CancelBatchJob 取消批量操作任务，任务已结束时返回"batch job already finished"
+// 执行中的任务由后台任务在处理完当前用户后停止，并将剩余用户标记为已取消
+func (r *Repository) CancelBatchJob(ctx context.Context, jobID, adminID string) error {
+	return r.GetDB().Transaction(func(tx *sql.Tx) error {
+		var status BatchJobStatus
+		err := tx.QueryRowContext(ctx, `SELECT status FROM user_batch_jobs WHERE id = $1 FOR UPDATE`, jobID).Scan(&status)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("batch job not found")
+			}
+			return fmt.Errorf("failed to get batch job: %w", err)
+		}
+
+		switch status {
+		case BatchJobAwaitingApproval, BatchJobQueued:
+			if _, err := tx.ExecContext(ctx, `
+				UPDATE user_batch_job_items SET status = 'cancelled' WHERE job_id = $1 AND status = 'pending'
+			`, jobID); err != nil {
+				return fmt.Errorf("failed to cancel batch job items: %w", err)
+			}
+			_, err = tx.ExecContext(ctx, `
+				UPDATE user_batch_jobs
+				SET status = $2, cancelled_by = $3, cancelled_at = NOW(), completed_at = NOW(), updated_at = NOW()
+				WHERE id = $1
+			`, jobID, BatchJobCancelled, adminID)
+		case BatchJobRunning:
+			_, err = tx.ExecContext(ctx, `
+				UPDATE user_batch_jobs
+				SET status = $2, cancelled_by = $3, cancelled_at = NOW(), updated_at = NOW()
+				WHERE id = $1
+			`, jobID, BatchJobCancelled, adminID)
+		default:
+			return fmt.Errorf("batch job already finished")
+		}
+		if err != nil {
+			return fmt.Errorf("failed to cancel batch job: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// ClaimBatchJob 领取一个待执行的批量操作任务（长时间没有进展的执行中任务会被重新领取），没有时返回nil
+func (r *Repository) ClaimBatchJob(ctx context.Context, staleAfter time.Duration) (*BatchJob, error) {
+	query := `
+		UPDATE user_batch_jobs
+		SET status = 'running', started_at = COALESCE(started_at, NOW()), updated_at = NOW()
+		WHERE id = (
+			SELECT id FROM user_batch_jobs
+			WHERE status = 'queued' OR (status = 'running' AND updated_at < $1)
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + batchJobColumns
+
+	job, err := scanBatchJob(r.GetDB().QueryRowContext(ctx, query, time.Now().Add(-staleAfter)))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to claim batch job: %w", err)
+	}
+
+	return job, nil
+}
+
+// GetBatchJobStatus 获取批量操作任务的当前状态
+func (r *Repository) GetBatchJobStatus(ctx context.Context, jobID string) (BatchJobStatus, error) {
+	var status BatchJobStatus
+	err := r.GetDB().QueryRowContext(ctx, `SELECT status FROM user_batch_jobs WHERE id = $1`, jobID).Scan(&status)
+	if err != nil {
+		return "", fmt.Errorf("failed to get batch job status: %w", err)
+	}
+
+	return status, nil
+}
+
+// CompleteBatchJobItem 记录单个用户的执行结果并更新任务进度
+func (r *Repository) CompleteBatchJobItem(ctx context.Context, item *BatchJobItem, status BatchItemStatus, message *string) error {
+	return r.GetDB().Transaction(func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, `
+			UPDATE user_batch_job_items
+			SET status = $2, message = $3, processed_at = NOW()
+			WHERE id = $1 AND status = 'pending'
+		`, item.ID, status, message)
+		if err != nil {
+			return fmt.Errorf("failed to update batch job item: %w", err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rowsAffected == 0 {
+			return nil
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE user_batch_jobs
+			SET processed_count = processed_count + 1,
+			    succeeded_count = succeeded_count + CASE WHEN $2 = 'succeeded' THEN 1 ELSE 0 END,
+			    failed_count = failed_count + CASE WHEN $2 = 'failed' THEN 1 ELSE 0 END,
+			    skipped_count = skipped_count + CASE WHEN $2 = 'skipped' THEN 1 ELSE 0 END,
+			    updated_at = NOW()
+			WHERE id = $1
+		`, item.JobID, status)
+		if err != nil {
+			return fmt.Errorf("failed to update batch job progress: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// FinishBatchJob 结束批量操作任务：执行中的任务标记为完成，已取消的任务将剩余用户标记为已取消
+func (r *Repository) FinishBatchJob(ctx context.Context, jobID string) error {
+	return r.GetDB().Transaction(func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE user_batch_job_items SET status = 'cancelled' WHERE job_id = $1 AND status = 'pending'
+		`, jobID); err != nil {
+			return fmt.Errorf("failed to cancel batch job items: %w", err)
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE user_batch_jobs
+			SET status = CASE WHEN status = $2 THEN $3 ELSE status END,
+			    completed_at = NOW(), updated_at = NOW()
+			WHERE id = $1 AND completed_at IS NULL
+		`, jobID, BatchJobRunning, BatchJobCompleted); err != nil {
+			return fmt.Errorf("failed to finish batch job: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// FinishAbandonedBatchJobs 结束执行中被取消、但执行进程已退出的任务
+func (r *Repository) FinishAbandonedBatchJobs(ctx context.Context, staleAfter time.Duration) error {
+	return r.GetDB().Transaction(func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, `
+			UPDATE user_batch_jobs
+			SET completed_at = NOW(), updated_at = NOW()
+			WHERE status = $1 AND completed_at IS NULL AND updated_at < $2
+			RETURNING id
+		`, BatchJobCancelled, time.Now().Add(-staleAfter))
+		if err != nil {
+			return fmt.Errorf("failed to finish abandoned batch jobs: %w", err)
+		}
+
+		jobIDs := make([]string, 0)
+		for rows.Next() {
+			var jobID string
+			if err := rows.Scan(&jobID); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan batch job id: %w", err)
+			}
+			jobIDs = append(jobIDs, jobID)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("failed to iterate batch jobs: %w", err)
+		}
+
+		if len(jobIDs) == 0 {
+			return nil
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE user_batch_job_items SET status = 'cancelled' WHERE job_id = ANY($1::uuid[]) AND status = 'pending'
+		`, pq.Array(jobIDs)); err != nil {
+			return fmt.Errorf("failed to cancel batch job items: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// === 备注、标签和关注方法 ===
+
+// CreateUserNote 添加用户备注
+func (r *Repository) CreateUserNote(ctx context.Context, note *UserNote) error {
+	if note.ID == "" {
+		note.ID = uuid.New().String()
+	}
+
+	err := r.GetDB().QueryRowContext(ctx, `
+		INSERT INTO user_notes (id, user_id, author_id, author_email, content)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at
+	`, note.ID, note.UserID, note.AuthorID, note.AuthorEmail, note.Content).Scan(&note.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create user note: %w", err)
+	}
+
+	return nil
+}
+
+// GetUserNote 获取用户的某条备注
+func (r *Repository) GetUserNote(ctx context.Context, userID, noteID string) (*UserNote, error) {
+	note := &UserNote{}
+	err := r.GetDB().QueryRowContext(ctx, `
+		SELECT id, user_id, author_id, author_email, content, created_at
+		FROM user_notes
+		WHERE id = $1 AND user_id = $2
+	`, noteID, userID).Scan(&note.ID, &note.UserID, &note.AuthorID, &note.AuthorEmail, &note.Content, &note.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("user note not found")
+		}
+		return nil, fmt.Errorf("failed to get user note: %w", err)
+	}
+
+	return note, nil
+}
+
+// ListUserNotes 获取用户备注列表（按时间倒序）
+func (r *Repository) ListUserNotes(ctx context.Context, userID string, pagination PaginationParams) ([]*UserNote, int64, error) {
+	var total int64
+	err := r.GetDB().QueryRowContext(ctx, `SELECT COUNT(*) FROM user_notes WHERE user_id = $1`, userID).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count user notes: %w", err)
+	}
+
+	rows, err := r.GetDB().QueryContext(ctx, `
+		SELECT id, user_id, author_id, author_email, content, created_at
+		FROM user_notes
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id
+		LIMIT $2 OFFSET $3
+	`, userID, pagination.GetLimit(), pagination.GetOffset())
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get user notes: %w", err)
+	}
+	defer rows.Close()
+
+	notes := make([]*UserNote, 0)
+	for rows.Next() {
+		note := &UserNote{}
+		if err := rows.Scan(&note.ID, &note.UserID, &note.AuthorID, &note.AuthorEmail, &note.Content, &note.CreatedAt); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan user note: %w", err)
+		}
+		notes = append(notes, note)
+	}
+
+	return notes, total, rows.Err()
+}
+
+// DeleteUserNote 删除用户备注
+func (r *Repository) DeleteUserNote(ctx context.Context, userID, noteID string) error {
+	result, err := r.GetDB().ExecContext(ctx, `DELETE FROM user_notes WHERE id = $1 AND user_id = $2`, noteID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete user note: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("user note not found")
+	}
+
+	return nil
+}
+
+// GetUserTags 获取用户的标签
+func (r *Repository) GetUserTags(ctx context.Context, userID string) ([]string, error) {
+	tags, err := r.GetTagsForUsers(ctx, []string{userID})
+	if err != nil {
+		return nil, err
+	}
+	return tags[userID], nil
+}
+
+// GetTagsForUsers 批量获取用户的标签（没有标签的用户不在结果中）
+func (r *Repository) GetTagsForUsers(ctx context.Context, userIDs []string) (map[string][]string, error) {
+	result := make(map[string][]string, len(userIDs))
+	if len(userIDs) == 0 {
+		return result, nil
+	}
+
+	rows, err := r.GetDB().QueryContext(ctx, `
+		SELECT user_id, tag
+		FROM user_tags
+		WHERE user_id = ANY($1::uuid[])
+		ORDER BY user_id, tag
+	`, pq.Array(userIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user tags: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userID, tag string
+		if err := rows.Scan(&userID, &tag); err != nil {
+			return nil, fmt.Errorf("failed to scan user tag: %w", err)
+		}
+		result[userID] = append(result[userID], tag)
+	}
+
+	return result, rows.Err()
+}
+
+// AddUserTags 为用户添加标签（已有的标签忽略），返回实际新增的标签
+func (r *Repository) AddUserTags(ctx context.Context, userID string, tags []string, createdBy string) ([]string, error) {
+	rows, err := r.GetDB().QueryContext(ctx, `
+		INSERT INTO user_tags (user_id, tag, created_by)
+		SELECT $1, tag, $3 FROM UNNEST($2::text[]) AS tag
+		ON CONFLICT (user_id, tag) DO NOTHING
+		RETURNING tag
+	`, userID, pq.Array(tags), createdBy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add user tags: %w", err)
+	}
+	defer rows.Close()
+
+	added := make([]string, 0, len(tags))
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, fmt.Errorf("failed to scan user tag: %w", err)
+		}
+		added = append(added, tag)
+	}
+
+	return added, rows.Err()
+}
+
+// RemoveUserTag 移除用户标签，用户没有该标签时返回false
+func (r *Repository) RemoveUserTag(ctx context.Context, userID, tag string) (bool, error) {
+	result, err := r.GetDB().ExecContext(ctx, `DELETE FROM user_tags WHERE user_id = $1 AND tag = $2`, userID, tag)
+	if err != nil {
+		return false, fmt.Errorf("failed to remove user tag: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// ListTags 获取全部标签及使用人数（不含已注销用户）
+func (r *Repository) ListTags(ctx context.Context) ([]*TagCount, error) {
+	rows, err := r.GetDB().QueryContext(ctx, `
+		SELECT t.tag, COUNT(*)
+		FROM user_tags t
+		JOIN users u ON u.id = t.user_id AND u.deleted_at IS NULL
+		GROUP BY t.tag
+		ORDER BY COUNT(*) DESC, t.tag
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := make([]*TagCount, 0)
+	for rows.Next() {
+		tag := &TagCount{}
+		if err := rows.Scan(&tag.Tag, &tag.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan tag: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, rows.Err()
+}
+
+const userWatchColumns = `
+	w.id, w.admin_id, w.admin_email, w.user_id, u.email, w.events, w.reason, w.created_at, w.updated_at
+`
+
+// scanUserWatch 扫描用户关注
+func scanUserWatch(scanner interface{ Scan(...interface{}) error }) (*UserWatch, error) {
+	watch := &UserWatch{}
+	err := scanner.Scan(&watch.ID, &watch.AdminID, &watch.AdminEmail, &watch.UserID, &watch.UserEmail,
+		pq.Array(&watch.Events), &watch.Reason, &watch.CreatedAt, &watch.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return watch, nil
+}
+
+// UpsertUserWatch 关注用户，已关注时更新关注的事件和原因
+func (r *Repository) UpsertUserWatch(ctx context.Context, watch *UserWatch) error {
+	err := r.GetDB().QueryRowContext(ctx, `
+		INSERT INTO user_watches (id, admin_id, admin_email, user_id, events, reason)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (admin_id, user_id) DO UPDATE
+		SET admin_email = EXCLUDED.admin_email, events = EXCLUDED.events, reason = EXCLUDED.reason, updated_at = NOW()
+		RETURNING id, created_at, updated_at
+	`, uuid.New().String(), watch.AdminID, watch.AdminEmail, watch.UserID, pq.Array(watch.Events), watch.Reason).
+		Scan(&watch.ID, &watch.CreatedAt, &watch.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save user watch: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteUserWatch 取消关注用户
+func (r *Repository) DeleteUserWatch(ctx context.Context, adminID, userID string) error {
+	result, err := r.GetDB().ExecContext(ctx, `DELETE FROM user_watches WHERE admin_id = $1 AND user_id = $2`, adminID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete user watch: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("user watch not found")
+	}
+
+	return nil
+}
+
+// ListUserWatches 获取管理员的关注列表（按关注时间倒序）
+func (r *Repository) ListUserWatches(ctx context.Context, adminID string, pagination PaginationParams) ([]*UserWatch, int64, error) {
+	var total int64
+	err := r.GetDB().QueryRowContext(ctx, `SELECT COUNT(*) FROM user_watches WHERE admin_id = $1`, adminID).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count user watches: %w", err)
+	}
+
+	query := `SELECT ` + userWatchColumns + `
+		FROM user_watches w
+		JOIN users u ON u.id = w.user_id
+		WHERE w.admin_id = $1
+		ORDER BY w.created_at DESC, w.id
+		LIMIT $2 OFFSET $3
+	`
+
+	watches, err := r.queryUserWatches(ctx, query, adminID, pagination.GetLimit(), pagination.GetOffset())
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return watches, total, nil
+}
+
+// GetWatchesForEvent 获取关注了用户某项活动的全部关注
+func (r *Repository) GetWatchesForEvent(ctx context.Context, userID, event string) ([]*UserWatch, error) {
+	query := `SELECT ` + userWatchColumns + `
+		FROM user_watches w
+		JOIN users u ON u.id = w.user_id
+		WHERE w.user_id = $1 AND $2 = ANY(w.events)
+	`
+
+	return r.queryUserWatches(ctx, query, userID, event)
+}
+
+// queryUserWatches 查询用户关注
+func (r *Repository) queryUserWatches(ctx context.Context, query string, args ...interface{}) ([]*UserWatch, error) {
+	rows, err := r.GetDB().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user watches: %w", err)
+	}
+	defer rows.Close()
+
+	watches := make([]*UserWatch, 0)
+	for rows.Next() {
+		watch, err := scanUserWatch(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user watch: %w", err)
+		}
+		watches = append(watches, watch)
+	}
+
+	return watches, rows.Err()
+}
+
+// CreateWatchNotification 写入关注通知
+func (r *Repository) CreateWatchNotification(ctx context.Context, notification *WatchNotification) error {
+	if notification.ID == "" {
+		notification.ID = uuid.New().String()
+	}
+
+	var details []byte
+	if notification.Details != nil {
+		var err error
+		details, err = json.Marshal(notification.Details)
+		if err != nil {
+			return fmt.Errorf("failed to marshal notification details: %w", err)
+		}
+	}
+
+	err := r.GetDB().QueryRowContext(ctx, `
+		INSERT INTO user_watch_notifications (id, admin_id, user_id, user_email, event, details, ip_address, occurred_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING created_at
+	`, notification.ID, notification.AdminID, notification.UserID, notification.UserEmail, notification.Event,
+		details, notification.IPAddress, notification.OccurredAt).Scan(&notification.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create watch notification: %w", err)
+	}
+
+	return nil
+}
+
+// ListWatchNotifications 获取管理员的关注通知（按时间倒序），同时返回未读数
+func (r *Repository) ListWatchNotifications(ctx context.Context, adminID string, unreadOnly bool, pagination PaginationParams) ([]*WatchNotification, int64, int64, error) {
+	var total, unread int64
+	err := r.GetDB().QueryRowContext(ctx, `
+		SELECT
+			COUNT(*) FILTER (WHERE NOT $2 OR read_at IS NULL),
+			COUNT(*) FILTER (WHERE read_at IS NULL)
+		FROM user_watch_notifications
+		WHERE admin_id = $1
+	`, adminID, unreadOnly).Scan(&total, &unread)
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("failed to count watch notifications: %w", err)
+	}
+
+	rows, err := r.GetDB().QueryContext(ctx, `
+		SELECT id, admin_id, user_id, user_email, event, details, ip_address, occurred_at, read_at, created_at
+		FROM user_watch_notifications
+		WHERE admin_id = $1 AND (NOT $2 OR read_at IS NULL)
+		ORDER BY created_at DESC, id
+		LIMIT $3 OFFSET $4
+	`, adminID, unreadOnly, pagination.GetLimit(), pagination.GetOffset())
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("failed to get watch notifications: %w", err)
+	}
+	defer rows.Close()
+
+	notifications := make([]*WatchNotification, 0)
+	for rows.Next() {
+		notification := &WatchNotification{}
+		var details []byte
+		err := rows.Scan(&notification.ID, &notification.AdminID, &notification.UserID, &notification.UserEmail,
+			&notification.Event, &details, &notification.IPAddress, &notification.OccurredAt,
+			&notification.ReadAt, &notification.CreatedAt)
+		if err != nil {
+			return nil, 0, 0, fmt.Errorf("failed to scan watch notification: %w", err)
+		}
+		if len(details) > 0 {
+			if err := json.Unmarshal(details, &notification.Details); err != nil {
+				return nil, 0, 0, fmt.Errorf("failed to unmarshal notification details: %w", err)
+			}
+		}
+		notifications = append(notifications, notification)
+	}
+
+	return notifications, total, unread, rows.Err()
+}
+
+// MarkWatchNotificationsRead 标记管理员的关注通知为已读，ids为空时标记全部
+func (r *Repository) MarkWatchNotificationsRead(ctx context.Context, adminID string, ids []string) (int64, error) {
+	if ids == nil {
+		ids = []string{} // nil会作为NULL传入
+	}
+
+	result, err := r.GetDB().ExecContext(ctx, `
+		UPDATE user_watch_notifications
+		SET read_at = NOW()
+		WHERE admin_id = $1 AND read_at IS NULL AND (cardinality($2::uuid[]) = 0 OR id = ANY($2::uuid[]))
+	`, adminID, pq.Array(ids))
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark watch notifications read: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected, nil
+}
+
+// === 模拟登录方法 ===
+
+const impersonationColumns = `
+	i.id, i.admin_id, i.admin_email, i.user_id, i.user_email, i.reason, i.token_id,
+	host(i.ip_address), i.user_agent, i.user_notified, i.expires_at, i.ended_at, i.ended_by, i.created_at,
+	(SELECT COUNT(*) FROM user_impersonation_requests r WHERE r.impersonation_id = i.id)
+`
+
+// scanImpersonation 扫描模拟登录记录
+func scanImpersonation(scanner interface{ Scan(...interface{}) error }) (*UserImpersonation, error) {
+	imp := &UserImpersonation{}
+	err := scanner.Scan(&imp.ID, &imp.AdminID, &imp.AdminEmail, &imp.UserID, &imp.UserEmail, &imp.Reason, &imp.TokenID,
+		&imp.IPAddress, &imp.UserAgent, &imp.UserNotified, &imp.ExpiresAt, &imp.EndedAt, &imp.EndedBy, &imp.CreatedAt,
+		&imp.RequestCount)
+	if err != nil {
+		return nil, err
+	}
+	return imp, nil
+}
+
+// CreateImpersonation 写入模拟登录记录（ID和令牌jti由调用方生成）
+func (r *Repository) CreateImpersonation(ctx context.Context, imp *UserImpersonation) error {
+	err := r.GetDB().QueryRowContext(ctx, `
+		INSERT INTO user_impersonations (
+			id, admin_id, admin_email, user_id, user_email, reason, token_id,
+			ip_address, user_agent, user_notified, expires_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, '')::inet, $9, $10, $11)
+		RETURNING created_at
+	`, imp.ID, imp.AdminID, imp.AdminEmail, imp.UserID, imp.UserEmail, imp.Reason, imp.TokenID,
+		stringValue(imp.IPAddress), imp.UserAgent, imp.UserNotified, imp.ExpiresAt).Scan(&imp.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create impersonation: %w", err)
+	}
+
+	return nil
+}
+
+// SetImpersonationUserNotified 标记已通知被模拟的用户
+func (r *Repository) SetImpersonationUserNotified(ctx context.Context, impersonationID string) error {
+	_, err := r.GetDB().ExecContext(ctx, `UPDATE user_impersonations SET user_notified = TRUE WHERE id = $1`, impersonationID)
+	if err != nil {
+		return fmt.Errorf("failed to update impersonation: %w", err)
+	}
+	return nil
+}
+
+// GetImpersonation 获取模拟登录记录
+func (r *Repository) GetImpersonation(ctx context.Context, impersonationID string) (*UserImpersonation, error) {
+	query := `SELECT ` + impersonationColumns + ` FROM user_impersonations i WHERE i.id = $1`
+
+	imp, err := scanImpersonation(r.GetDB().QueryRowContext(ctx, query, impersonationID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("impersonation not found")
+		}
+		return nil, fmt.Errorf("failed to get impersonation: %w", err)
+	}
+
+	return imp, nil
+}
+
+// IsImpersonationActive 检查模拟登录是否仍然有效（令牌jti需与记录一致）
+func (r *Repository) IsImpersonationActive(ctx context.Context, impersonationID, tokenID string) (bool, error) {
+	var active bool
+	err := r.GetDB().QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM user_impersonations
+			WHERE id = $1 AND token_id = $2 AND ended_at IS NULL AND expires_at > NOW()
+		)
+	`, impersonationID, tokenID).Scan(&active)
+	if err != nil {
+		return false, fmt.Errorf("failed to check impersonation: %w", err)
+	}
+
+	return active, nil
+}
+
+// ListImpersonations 获取模拟登录记录（按时间倒序）
+func (r *Repository) ListImpersonations(ctx context.Context, filter *ImpersonationFilter, pagination PaginationParams) ([]*UserImpersonation, int64, error) {
+	conditions := []string{"TRUE"}
+	args := []interface{}{}
+	argIndex := 1
+
+	if filter.AdminID != nil {
+		conditions = append(conditions, fmt.Sprintf("i.admin_id = $%d", argIndex))
+		args = append(args, *filter.AdminID)
+		argIndex++
+	}
+	if filter.UserID != nil {
+		conditions = append(conditions, fmt.Sprintf("i.user_id = $%d", argIndex))
+		args = append(args, *filter.UserID)
+		argIndex++
+	}
+	if filter.Active != nil {
+		if *filter.Active {
+			conditions = append(conditions, "i.ended_at IS NULL AND i.expires_at > NOW()")
+		} else {
+			conditions = append(conditions, "(i.ended_at IS NOT NULL OR i.expires_at <= NOW())")
+		}
+	}
+
+	whereClause := strings.Join(conditions, " AND ")
+
+	var total int64
+	countQuery := `SELECT COUNT(*) FROM user_impersonations i WHERE ` + whereClause
+	if err := r.GetDB().QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count impersonations: %w", err)
+	}
+
+	query := fmt.Sprintf(`SELECT %s
+		FROM user_impersonations i
+		WHERE %s
+		ORDER BY i.created_at DESC, i.id
+		LIMIT $%d OFFSET $%d
+	`, impersonationColumns, whereClause, argIndex, argIndex+1)
+	args = append(args, pagination.GetLimit(), pagination.GetOffset())
+
+	rows, err := r.GetDB().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get impersonations: %w", err)
+	}
+	defer rows.Close()
+
+	impersonations := make([]*UserImpersonation, 0)
+	for rows.Next() {
+		imp, err := scanImpersonation(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan impersonation: %w", err)
+		}
+		impersonations = append(impersonations, imp)
+	}
+
+	return impersonations, total, rows.Err()
+}
+
+// EndImpersonation 提前结束模拟登录，已结束或已过期时返回"impersonation already ended"
+func (r *Repository) EndImpersonation(ctx context.Context, impersonationID, adminID string) error {
+	result, err := r.GetDB().ExecContext(ctx, `
+		UPDATE user_impersonations
+		SET ended_at = NOW(), ended_by = $2
+		WHERE id = $1 AND ended_at IS NULL AND expires_at > NOW()
+	`, impersonationID, adminID)
+	if err != nil {
+		return fmt.Errorf("failed to end impersonation: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("impersonation already ended")
+	}
+
+	return nil
+}
+
+// CreateImpersonationRequest 记录模拟登录令牌发起的请求
+func (r *Repository) CreateImpersonationRequest(ctx context.Context, req *ImpersonationRequestLog) error {
+	err := r.GetDB().QueryRowContext(ctx, `
+		INSERT INTO user_impersonation_requests (
+			impersonation_id, method, path, route, status_code, blocked, ip_address, user_agent, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, '')::inet, $8, $9)
+		RETURNING id
+	`, req.ImpersonationID, req.Method, req.Path, req.Route, req.StatusCode, req.Blocked,
+		stringValue(req.IPAddress), req.UserAgent, req.CreatedAt).Scan(&req.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create impersonation request: %w", err)
+	}
+
+	return nil
+}
+
+// ListImpersonationRequests 获取模拟登录令牌发起的请求（按请求顺序）
+func (r *Repository) ListImpersonationRequests(ctx context.Context, impersonationID string, pagination PaginationParams) ([]*ImpersonationRequestLog, int64, error) {
+	var total int64
+	err := r.GetDB().QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM user_impersonation_requests WHERE impersonation_id = $1
+	`, impersonationID).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count impersonation requests: %w", err)
+	}
+
+	rows, err := r.GetDB().QueryContext(ctx, `
+		SELECT id, impersonation_id, method, path, route, status_code, blocked, host(ip_address), user_agent, created_at
+		FROM user_impersonation_requests
+		WHERE impersonation_id = $1
+		ORDER BY id
+		LIMIT $2 OFFSET $3
+	`, impersonationID, pagination.GetLimit(), pagination.GetOffset())
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get impersonation requests: %w", err)
+	}
+	defer rows.Close()
+
+	requests := make([]*ImpersonationRequestLog, 0)
+	for rows.Next() {
+		req := &ImpersonationRequestLog{}
+		err := rows.Scan(&req.ID, &req.ImpersonationID, &req.Method, &req.Path, &req.Route, &req.StatusCode,
+			&req.Blocked, &req.IPAddress, &req.UserAgent, &req.CreatedAt)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan impersonation request: %w", err)
+		}
+		requests = append(requests, req)
+	}
+
+	return requests, total, rows.Err()
+}
+
+// === 保存的搜索方法 ===
+
+// scanSavedSearch 扫描保存的搜索
+func scanSavedSearch(scanner interface{ Scan(...interface{}) error }) (*SavedSearch, error) {
+	search := &SavedSearch{}
+	var query []byte
+	err := scanner.Scan(&search.ID, &search.AdminID, &search.Name, &search.Description, &query, &search.CreatedAt, &search.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(query, &search.Query); err != nil {
+		return nil, fmt.Errorf("failed to decode saved search query: %w", err)
+	}
+	return search, nil
+}
+
+// CreateSavedSearch 保存搜索，名称重复时返回"saved search name already exists"
+func (r *Repository) CreateSavedSearch(ctx context.Context, search *SavedSearch) error {
+	query, err := json.Marshal(search.Query)
+	if err != nil {
+		return fmt.Errorf("failed to encode saved search query: %w", err)
+	}
+
+	err = r.GetDB().QueryRowContext(ctx, `
+		INSERT INTO user_saved_searches (admin_id, name, description, query)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, updated_at
+	`, search.AdminID, search.Name, search.Description, query).Scan(&search.ID, &search.CreatedAt, &search.UpdatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("saved search name already exists")
+		}
+		return fmt.Errorf("failed to create saved search: %w", err)
+	}
+
+	return nil
+}
+
+// CountSavedSearches 统计管理员保存的搜索数
+func (r *Repository) CountSavedSearches(ctx context.Context, adminID string) (int, error) {
+	var count int
+	err := r.GetDB().QueryRowContext(ctx, `SELECT COUNT(*) FROM user_saved_searches WHERE admin_id = $1`, adminID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count saved searches: %w", err)
+	}
+	return count, nil
+}
+
+// GetSavedSearch 获取管理员保存的搜索
+func (r *Repository) GetSavedSearch(ctx context.Context, adminID, searchID string) (*SavedSearch, error) {
+	search, err := scanSavedSearch(r.GetDB().QueryRowContext(ctx, `
+		SELECT id, admin_id, name, description, query, created_at, updated_at
+		FROM user_saved_searches
+		WHERE id = $1 AND admin_id = $2
+	`, searchID, adminID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("saved search not found")
+		}
+		return nil, fmt.Errorf("failed to get saved search: %w", err)
+	}
+
+	return search, nil
+}
+
+// ListSavedSearches 获取管理员保存的全部搜索（按名称排序）
+func (r *Repository) ListSavedSearches(ctx context.Context, adminID string) ([]*SavedSearch, error) {
+	rows, err := r.GetDB().QueryContext(ctx, `
+		SELECT id, admin_id, name, description, query, created_at, updated_at
+		FROM user_saved_searches
+		WHERE admin_id = $1
+		ORDER BY name
+	`, adminID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get saved searches: %w", err)
+	}
+	defer rows.Close()
+
+	searches := make([]*SavedSearch, 0)
+	for rows.Next() {
+		search, err := scanSavedSearch(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan saved search: %w", err)
+		}
+		searches = append(searches, search)
+	}
+
+	return searches, rows.Err()
+}
+
+// UpdateSavedSearch 更新保存的搜索
+func (r *Repository) UpdateSavedSearch(ctx context.Context, search *SavedSearch) error {
+	query, err := json.Marshal(search.Query)
+	if err != nil {
+		return fmt.Errorf("failed to encode saved search query: %w", err)
+	}
+
+	err = r.GetDB().QueryRowContext(ctx, `
+		UPDATE user_saved_searches
+		SET name = $3, description = $4, query = $5, updated_at = NOW()
+		WHERE id = $1 AND admin_id = $2
+		RETURNING updated_at
+	`, search.ID, search.AdminID, search.Name, search.Description, query).Scan(&search.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("saved search not found")
+		}
+		if isUniqueViolation(err) {
+			return fmt.Errorf("saved search name already exists")
+		}
+		return fmt.Errorf("failed to update saved search: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteSavedSearch 删除保存的搜索
+func (r *Repository) DeleteSavedSearch(ctx context.Context, adminID, searchID string) error {
+	result, err := r.GetDB().ExecContext(ctx, `DELETE FROM user_saved_searches WHERE id = $1 AND admin_id = $2`, searchID, adminID)
+	if err != nil {
+		return fmt.Errorf("failed to delete saved search: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("saved search not found")
+	}
+
+	return nil
+}
+
+// isUniqueViolation 判断是否为唯一约束冲突
+func isUniqueViolation(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code == "23505"
+}
@@ -22,7 +22,10 @@ func NewRoutes(handler *Handler, authMiddle *auth.AuthMiddleware) *Routes {
 
 // RegisterRoutes 注册用户管理路由
 func (r *Routes) RegisterRoutes(router *gin.RouterGroup) {
-	// 用户管理路由组 - 需要管理员认证
+	// 下载导出文件 - 使用签名的限时链接，无需认证
+	router.GET("/admin/user-management/downloads/:file_name", r.handler.DownloadExportFile)
+
+	// 用户管理路由组 - 需要管理员认证，各接口按权限控制
 	userMgmt := router.Group("/admin/user-management")
 	userMgmt.Use(r.authMiddle.RequireAuth())
 	userMgmt.Use(r.authMiddle.RequireUserType("admin"))
@@ -30,73 +33,174 @@ func (r *Routes) RegisterRoutes(router *gin.RouterGroup) {
 		// === 用户查询接口 ===
 
 		// 获取用户列表（支持分页、排序、筛选、搜索）
-		userMgmt.GET("/users", r.handler.GetUsers)
+		userMgmt.GET("/users", r.authMiddle.RequirePermission(auth.PermUserView), r.handler.GetUsers)
+
+		// 高级查询用户（请求体中传入and/or组合的查询条件）
+		userMgmt.POST("/users/search", r.authMiddle.RequirePermission(auth.PermUserView), r.handler.SearchUsersAdvanced)
 
 		// 获取用户详细信息
-		userMgmt.GET("/users/:user_id", r.handler.GetUserDetail)
+		userMgmt.GET("/users/:user_id", r.authMiddle.RequirePermission(auth.PermUserView), r.handler.GetUserDetail)
 
 		// 获取用户活动信息
-		userMgmt.GET("/users/:user_id/activity", r.handler.GetUserActivity)
+		userMgmt.GET("/users/:user_id/activity", r.authMiddle.RequirePermission(auth.PermUserView), r.handler.GetUserActivity)
+
+		// 获取用户暂停历史
+		userMgmt.GET("/users/:user_id/suspensions", r.authMiddle.RequirePermission(auth.PermUserView), r.handler.GetUserSuspensions)
+
+		// === 备注、标签和关注接口 ===
+
+		// 用户备注（删除仅限作者或超级管理员）
+		userMgmt.GET("/users/:user_id/notes", r.authMiddle.RequirePermission(auth.PermUserView), r.handler.ListUserNotes)
+		userMgmt.POST("/users/:user_id/notes", r.authMiddle.RequirePermission(auth.PermUserNotes), r.handler.CreateUserNote)
+		userMgmt.DELETE("/users/:user_id/notes/:note_id", r.authMiddle.RequirePermission(auth.PermUserNotes), r.handler.DeleteUserNote)
+
+		// 用户标签
+		userMgmt.GET("/tags", r.authMiddle.RequirePermission(auth.PermUserView), r.handler.ListTags)
+		userMgmt.POST("/users/:user_id/tags", r.authMiddle.RequirePermission(auth.PermUserTags), r.handler.AddUserTags)
+		userMgmt.DELETE("/users/:user_id/tags/:tag", r.authMiddle.RequirePermission(auth.PermUserTags), r.handler.RemoveUserTag)
+
+		// 关注用户（用户登录、添加银行账户时通知当前管理员）
+		userMgmt.PUT("/users/:user_id/watch", r.authMiddle.RequirePermission(auth.PermUserWatch), r.handler.WatchUser)
+		userMgmt.DELETE("/users/:user_id/watch", r.authMiddle.RequirePermission(auth.PermUserWatch), r.handler.UnwatchUser)
+		userMgmt.GET("/watchlist", r.authMiddle.RequirePermission(auth.PermUserWatch), r.handler.ListWatches)
+		userMgmt.GET("/watchlist/notifications", r.authMiddle.RequirePermission(auth.PermUserWatch), r.handler.ListWatchNotifications)
+		userMgmt.POST("/watchlist/notifications/read", r.authMiddle.RequirePermission(auth.PermUserWatch), r.handler.MarkWatchNotificationsRead)
 
 		// === 统计接口 ===
 
 		// 获取用户统计信息
-		userMgmt.GET("/statistics", r.handler.GetStatistics)
+		userMgmt.GET("/statistics", r.authMiddle.RequirePermission(auth.PermStatisticsView), r.handler.GetStatistics)
+
+		// 注册、邮箱验证、活跃用户时间序列（按天/周/月，支持时区）
+		userMgmt.GET("/statistics/registration", r.authMiddle.RequirePermission(auth.PermStatisticsView), r.handler.GetRegistrationStatistics)
+		userMgmt.GET("/statistics/verification", r.authMiddle.RequirePermission(auth.PermStatisticsView), r.handler.GetVerificationStatistics)
+		userMgmt.GET("/statistics/activity", r.authMiddle.RequirePermission(auth.PermStatisticsView), r.handler.GetActivityStatistics)
+
+		// 注册周留存
+		userMgmt.GET("/statistics/retention", r.authMiddle.RequirePermission(auth.PermStatisticsView), r.handler.GetRetentionStatistics)
 
 		// === 用户管理操作接口 ===
 
 		// 更新用户状态
-		userMgmt.PUT("/users/:user_id/status", r.handler.UpdateUserStatus)
+		userMgmt.PUT("/users/:user_id/status", r.authMiddle.RequirePermission(auth.PermUserUpdateStatus), r.handler.UpdateUserStatus)
 
-		// 暂停用户
-		userMgmt.POST("/users/:user_id/suspend", r.handler.SuspendUser)
+		// 暂停用户（可指定结束时间，到期自动解除）
+		userMgmt.POST("/users/:user_id/suspend", r.authMiddle.RequirePermission(auth.PermUserSuspend), r.handler.SuspendUser)
 
 		// 重新激活用户
-		userMgmt.POST("/users/:user_id/reactivate", r.handler.ReactivateUser)
+		userMgmt.POST("/users/:user_id/reactivate", r.authMiddle.RequirePermission(auth.PermUserSuspend), r.handler.ReactivateUser)
 
 		// 重置用户密码
-		userMgmt.POST("/users/:user_id/reset-password", r.handler.ResetUserPassword)
+		userMgmt.POST("/users/:user_id/reset-password", r.authMiddle.RequirePermission(auth.PermUserResetPassword), r.handler.ResetUserPassword)
 
 		// 强制用户登出
-		userMgmt.POST("/users/:user_id/force-logout", r.handler.ForceLogoutUser)
+		userMgmt.POST("/users/:user_id/force-logout", r.authMiddle.RequirePermission(auth.PermUserForceLogout), r.handler.ForceLogoutUser)
 
 		// 验证用户邮箱
-		userMgmt.POST("/users/:user_id/verify-email", r.handler.VerifyUserEmail)
+		userMgmt.POST("/users/:user_id/verify-email", r.authMiddle.RequirePermission(auth.PermUserVerifyEmail), r.handler.VerifyUserEmail)
 
-		// === 未来扩展接口占位 ===
-		// 注意：这些接口在第一阶段不实现，仅作为路由占位
+		// 查看用户登录锁定情况
+		userMgmt.GET("/users/:user_id/lockout", r.authMiddle.RequirePermission(auth.PermUserView), r.handler.GetUserLockout)
 
-		// 用户会话管理
-		// userMgmt.GET("/users/:user_id/sessions", r.handler.GetUserSessions)
-		// userMgmt.DELETE("/users/:user_id/sessions/:session_id", r.handler.DeleteUserSession)
+		// 解除用户登录锁定
+		userMgmt.POST("/users/:user_id/unlock", r.authMiddle.RequirePermission(auth.PermUserUpdateStatus), r.handler.UnlockUser)
+
+		// 修改用户邮箱（原邮箱会收到撤销链接）
+		userMgmt.PUT("/users/:user_id/email", r.authMiddle.RequirePermission(auth.PermUserUpdateEmail), r.handler.UpdateUserEmail)
+
+		// 注销用户（冷静期内可恢复，期满后匿名化个人数据）
+		userMgmt.DELETE("/users/:user_id", r.authMiddle.RequirePermission(auth.PermUserDelete), r.handler.DeleteUser)
+
+		// 恢复已注销用户
+		userMgmt.POST("/users/:user_id/restore", r.authMiddle.RequirePermission(auth.PermUserDelete), r.handler.RestoreUser)
+
+		// === 保存的搜索接口（每个管理员只能访问自己保存的搜索） ===
+
+		// 获取保存的搜索
+		userMgmt.GET("/saved-searches", r.authMiddle.RequirePermission(auth.PermUserView), r.handler.ListSavedSearches)
+
+		// 保存搜索
+		userMgmt.POST("/saved-searches", r.authMiddle.RequirePermission(auth.PermUserView), r.handler.CreateSavedSearch)
+
+		// 获取保存的搜索详情
+		userMgmt.GET("/saved-searches/:search_id", r.authMiddle.RequirePermission(auth.PermUserView), r.handler.GetSavedSearch)
+
+		// 更新保存的搜索
+		userMgmt.PUT("/saved-searches/:search_id", r.authMiddle.RequirePermission(auth.PermUserView), r.handler.UpdateSavedSearch)
+
+		// 删除保存的搜索
+		userMgmt.DELETE("/saved-searches/:search_id", r.authMiddle.RequirePermission(auth.PermUserView), r.handler.DeleteSavedSearch)
 
-		// 更新用户邮箱
-		// userMgmt.PUT("/users/:user_id/email", r.handler.UpdateUserEmail)
+		// === 导出接口 ===
 
-		// 软删除用户
-		// userMgmt.DELETE("/users/:user_id", r.handler.DeleteUser)
+		// 导出用户（CSV/Excel），返回限时下载链接
+		userMgmt.GET("/export/users", r.authMiddle.RequirePermission(auth.PermUserExport), r.handler.ExportUsers)
 
-		// 恢复已删除用户
-		// userMgmt.POST("/users/:user_id/restore", r.handler.RestoreUser)
+		// === 审计日志接口 ===
 
-		// 获取操作日志
-		// userMgmt.GET("/users/:user_id/logs", r.handler.GetUserManagementLogs)
-		// userMgmt.GET("/logs", r.handler.GetAllManagementLogs)
+		// 查询管理操作日志（按管理员、操作类型、目标用户、日期范围、原因关键词过滤）
+		userMgmt.GET("/logs", r.authMiddle.RequirePermission(auth.PermAuditLogView), r.handler.GetAllManagementLogs)
 
-		// 数据导出
-		// userMgmt.GET("/export/users", r.handler.ExportUsers)
-		// userMgmt.GET("/downloads/:file_name", r.handler.DownloadExportFile)
+		// 导出管理操作日志（CSV）
+		userMgmt.GET("/logs/export", r.authMiddle.RequirePermission(auth.PermAuditLogView), r.handler.ExportManagementLogs)
 
-		// 批量操作
-		// userMgmt.POST("/users/batch/status", r.handler.BatchUpdateUserStatus)
-		// userMgmt.POST("/users/batch/suspend", r.handler.BatchSuspendUsers)
-		// userMgmt.POST("/users/batch/reactivate", r.handler.BatchReactivateUsers)
-		// userMgmt.POST("/users/batch/force-logout", r.handler.BatchForceLogoutUsers)
+		// 校验管理操作日志哈希链
+		userMgmt.GET("/logs/verify", r.authMiddle.RequirePermission(auth.PermAuditLogView), r.handler.VerifyManagementLogs)
+
+		// 查询针对某个用户的管理操作日志
+		userMgmt.GET("/users/:user_id/logs", r.authMiddle.RequirePermission(auth.PermAuditLogView), r.handler.GetUserManagementLogs)
+
+		// === 模拟登录接口（只读令牌，每个请求都记录审计） ===
+
+		// 以用户身份登录
+		userMgmt.POST("/users/:user_id/impersonate", r.authMiddle.RequirePermission(auth.PermUserImpersonate), r.handler.ImpersonateUser)
+
+		// 查询模拟登录记录
+		userMgmt.GET("/impersonations", r.authMiddle.RequirePermission(auth.PermAuditLogView), r.handler.ListImpersonations)
+
+		// 查询模拟登录令牌发起的请求
+		userMgmt.GET("/impersonations/:impersonation_id/requests", r.authMiddle.RequirePermission(auth.PermAuditLogView), r.handler.GetImpersonationRequests)
+
+		// 提前结束模拟登录
+		userMgmt.POST("/impersonations/:impersonation_id/end", r.authMiddle.RequirePermission(auth.PermUserImpersonate), r.handler.EndImpersonation)
+
+		// === 批量操作接口（异步执行，超过阈值需超级管理员审批） ===
+
+		// 批量更新用户状态
+		userMgmt.POST("/users/batch/status", r.authMiddle.RequirePermission(auth.PermUserUpdateStatus), r.handler.BatchUpdateUserStatus)
+
+		// 批量暂停用户
+		userMgmt.POST("/users/batch/suspend", r.authMiddle.RequirePermission(auth.PermUserSuspend), r.handler.BatchSuspendUsers)
+
+		// 批量重新激活用户
+		userMgmt.POST("/users/batch/reactivate", r.authMiddle.RequirePermission(auth.PermUserSuspend), r.handler.BatchReactivateUsers)
+
+		// 批量强制用户登出
+		userMgmt.POST("/users/batch/force-logout", r.authMiddle.RequirePermission(auth.PermUserForceLogout), r.handler.BatchForceLogoutUsers)
+
+		// 获取批量操作任务列表
+		userMgmt.GET("/batch-jobs", r.authMiddle.RequirePermission(auth.PermUserView), r.handler.ListBatchJobs)
+
+		// 获取批量操作任务进度
+		userMgmt.GET("/batch-jobs/:job_id", r.authMiddle.RequirePermission(auth.PermUserView), r.handler.GetBatchJob)
+
+		// 获取批量操作中每个用户的执行结果
+		userMgmt.GET("/batch-jobs/:job_id/items", r.authMiddle.RequirePermission(auth.PermUserView), r.handler.GetBatchJobItems)
+
+		// 取消批量操作任务（提交人或超级管理员）
+		userMgmt.POST("/batch-jobs/:job_id/cancel", r.authMiddle.RequirePermission(auth.PermUserView), r.handler.CancelBatchJob)
+
+		// 审批批量操作任务（仅超级管理员）
+		userMgmt.POST("/batch-jobs/:job_id/approve", r.authMiddle.RequireRole(auth.RoleSuperAdmin), r.handler.ApproveBatchJob)
+
+		// === 未来扩展接口占位 ===
+		// 注意：这些接口在第一阶段不实现，仅作为路由占位
+
+		// 用户会话管理
+		// userMgmt.GET("/users/:user_id/sessions", r.handler.GetUserSessions)
+		// userMgmt.DELETE("/users/:user_id/sessions/:session_id", r.handler.DeleteUserSession)
 
-		// 高级统计
-		// userMgmt.GET("/statistics/registration", r.handler.GetRegistrationStatistics)
-		// userMgmt.GET("/statistics/verification", r.handler.GetVerificationStatistics)
-		// userMgmt.GET("/statistics/activity", r.handler.GetActivityStatistics)
+		// 统计导出
 		// userMgmt.GET("/statistics/export", r.handler.ExportStatistics)
 	}
 }
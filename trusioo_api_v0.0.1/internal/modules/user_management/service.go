@@ -2,34 +2,105 @@ package user_management
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"trusioo_api_v0.0.1/internal/config"
+	"trusioo_api_v0.0.1/internal/infrastructure/mailer"
 	"trusioo_api_v0.0.1/internal/modules/auth"
 	"trusioo_api_v0.0.1/internal/modules/auth/user"
+	"trusioo_api_v0.0.1/internal/modules/privacy"
 	"trusioo_api_v0.0.1/pkg/cryptoutil"
 
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 )
 
+const (
+	// suspensionCheckInterval 检查到期暂停的间隔
+	suspensionCheckInterval = time.Minute
+	// suspensionBatchSize 每轮最多解除的暂停数
+	suspensionBatchSize = 100
+	// systemActor 后台任务写入管理操作日志时使用的操作人
+	systemActor = "system"
+
+	// batchCheckInterval 检查待执行批量操作任务的间隔
+	batchCheckInterval = 30 * time.Second
+	// batchItemChunkSize 每次读取的待执行用户数
+	batchItemChunkSize = 50
+	// batchItemTimeout 单个用户操作的超时时间
+	batchItemTimeout = 30 * time.Second
+	// batchJobStaleAfter 执行中的任务超过该时间没有进展，视为执行进程已退出
+	batchJobStaleAfter = 10 * time.Minute
+	// batchPreviewSampleSize 预览时返回的目标用户数
+	batchPreviewSampleSize = 20
+
+	// statisticsRollupTimeout 单次刷新统计汇总的超时时间（首次运行需汇总全部历史数据）
+	statisticsRollupTimeout = 30 * time.Minute
+	// defaultStatisticsRollupInterval 未配置有效刷新间隔时使用的间隔
+	defaultStatisticsRollupInterval = 15 * time.Minute
+
+	// exportDownloadPath 导出文件下载地址前缀
+	exportDownloadPath = "/api/v1/admin/user-management/downloads/"
+)
+
+// exportFileNamePattern 导出文件名格式，下载时用于防止路径穿越
+var exportFileNamePattern = regexp.MustCompile(`^users_export_[0-9]{14}_[0-9a-f]{16}\.(csv|xlsx)$`)
+
 // Service 用户管理服务
 type Service struct {
-	repo      *Repository
-	userRepo  *user.Repository // 复用用户仓储
-	encryptor *cryptoutil.PasswordEncryptor
-	logger    *logrus.Logger
+	repo           *Repository
+	userRepo       *user.Repository // 复用用户仓储
+	userService    *user.Service    // 复用用户认证服务（邮箱变更）
+	privacyService *privacy.Service // 复用账户注销服务
+	encryptor      *cryptoutil.PasswordEncryptor
+	passwordPolicy *auth.PasswordPolicy
+	jwtManager     *auth.JWTManager
+	lockout        *auth.LoginLockout
+	config         *config.UserManagementConfig
+	logger         *logrus.Logger
+	batchWake      chan struct{} // 有新的批量操作任务时唤醒后台任务
+	rollupReady    sync.Map      // 已完成首次统计汇总的时区
+	mailer         mailer.Mailer // 发送关注通知邮件（未设置时只写入站内通知）
 }
 
 // NewService 创建新的用户管理服务
-func NewService(repo *Repository, userRepo *user.Repository, encryptor *cryptoutil.PasswordEncryptor, logger *logrus.Logger) *Service {
+func NewService(repo *Repository, userRepo *user.Repository, userService *user.Service, privacyService *privacy.Service, encryptor *cryptoutil.PasswordEncryptor, passwordPolicy *auth.PasswordPolicy, jwtManager *auth.JWTManager, lockout *auth.LoginLockout, cfg *config.UserManagementConfig, logger *logrus.Logger) *Service {
 	return &Service{
-		repo:      repo,
-		userRepo:  userRepo,
-		encryptor: encryptor,
-		logger:    logger,
+		repo:           repo,
+		userRepo:       userRepo,
+		userService:    userService,
+		privacyService: privacyService,
+		encryptor:      encryptor,
+		passwordPolicy: passwordPolicy,
+		jwtManager:     jwtManager,
+		lockout:        lockout,
+		config:         cfg,
+		logger:         logger,
+		batchWake:      make(chan struct{}, 1),
 	}
 }
 
+// SetMailer 设置发送关注通知邮件的邮件发送器
+func (s *Service) SetMailer(m mailer.Mailer) {
+	s.mailer = m
+}
+
 // === 用户查询服务 ===
 
 // GetUserByID 根据ID获取用户详细信息
@@ -43,19 +114,21 @@ func (s *Service) GetUserByID(ctx context.Context, userID string) (*UserManageme
 	return userModel, nil
 }
 
-// GetUsers 获取用户列表（支持分页和过滤）
-func (s *Service) GetUsers(ctx context.Context, req *GetUsersRequest) (*UserListResponse, error) {
+// GetUsers 获取用户列表（支持分页、过滤和高级查询）
+func (s *Service) GetUsers(ctx context.Context, req *GetUsersRequest, admin *AdminInfo) (*UserListResponse, error) {
 	// 转换请求参数
-	filter := req.ToSearchFilter()
+	filter, search, err := s.resolveUserFilter(ctx, req, admin)
+	if err != nil {
+		return nil, err
+	}
 	pagination := req.ToPaginationParams()
 
 	var users []*UserManagementModel
 	var total int64
-	var err error
 
 	// 如果有搜索关键词，使用搜索方法
-	if req.Search != "" {
-		users, total, err = s.repo.SearchUsers(ctx, req.Search, pagination)
+	if search != "" {
+		users, total, err = s.repo.SearchUsers(ctx, search, pagination)
 	} else {
 		users, total, err = s.repo.GetUsers(ctx, filter, pagination)
 	}
@@ -65,9 +138,21 @@ func (s *Service) GetUsers(ctx context.Context, req *GetUsersRequest) (*UserList
 		return nil, fmt.Errorf("failed to get users: %w", err)
 	}
 
+	// 批量获取用户标签
+	userIDs := make([]string, 0, len(users))
+	for _, userModel := range users {
+		userIDs = append(userIDs, userModel.ID)
+	}
+	tags, err := s.repo.GetTagsForUsers(ctx, userIDs)
+	if err != nil {
+		s.logger.WithError(err).Warn("Failed to get user tags")
+	}
+
 	// 转换为响应格式
 	userSummaries := make([]UserSummaryResponse, 0, len(users))
 	for _, userModel := range users {
+		userModel.Tags = tags[userModel.ID]
+
 		// 获取活跃会话数
 		sessions, err := s.repo.GetUserSessions(ctx, userModel.ID)
 		if err != nil {
@@ -107,9 +192,22 @@ func (s *Service) GetUserDetail(ctx context.Context, userID string) (*UserDetail
 		s.logger.WithError(err).WithField("user_id", userID).Warn("Failed to get user sessions")
 	}
 
+	// 获取标签和最近备注
+	userModel.Tags, err = s.repo.GetUserTags(ctx, userID)
+	if err != nil {
+		s.logger.WithError(err).WithField("user_id", userID).Warn("Failed to get user tags")
+	}
+
 	activeSessions := len(sessions)
 	response := userModel.ToUserDetailResponse(activeSessions)
 
+	notes, _, err := s.repo.ListUserNotes(ctx, userID, batchPaginationParams(1, recentNotesLimit))
+	if err != nil {
+		s.logger.WithError(err).WithField("user_id", userID).Warn("Failed to get user notes")
+	} else {
+		response.RecentNotes = notes
+	}
+
 	return response, nil
 }
 
@@ -210,6 +308,172 @@ func (s *Service) GetStatistics(ctx context.Context) (*StatisticsResponse, error
 	return response, nil
 }
 
+// GetTimeSeries 获取按天、周或月分桶的统计时间序列
+// 配置了汇总的时区读取汇总表，其他时区实时计算
+func (s *Service) GetTimeSeries(ctx context.Context, metric string, req *GetStatisticsRequest) (*TimeSeriesResponse, error) {
+	period := req.GroupBy
+	if period == "" {
+		period = StatisticsPeriodDay
+	}
+
+	timezone, loc, err := resolveStatisticsTimezone(req.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end, err := statisticsRange(req.DateFrom, req.DateTo, period, loc, maxStatisticsBuckets)
+	if err != nil {
+		return nil, err
+	}
+
+	source := statisticsSourceLive
+	var buckets []*StatisticsBucket
+	if s.hasStatisticsRollups(ctx, timezone) {
+		source = statisticsSourceRollup
+		buckets, err = s.repo.GetStatisticsRollups(ctx, timezone, period, start, end)
+	} else {
+		buckets, err = s.repo.QueryStatisticsBuckets(ctx, timezone, period, start, end)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	response := &TimeSeriesResponse{
+		Metric:   metric,
+		GroupBy:  period,
+		Timezone: timezone,
+		DateFrom: start.Format(statisticsDateLayout),
+		DateTo:   end.AddDate(0, 0, -1).Format(statisticsDateLayout),
+		Source:   source,
+		Points:   make([]TimeSeriesPoint, 0, countBuckets(start, end, period)),
+	}
+
+	var total int64
+	for _, bucket := range fillStatisticsBuckets(buckets, start, end, period) {
+		if bucket.ComputedAt != nil && (response.ComputedAt == nil || bucket.ComputedAt.Before(*response.ComputedAt)) {
+			response.ComputedAt = bucket.ComputedAt
+		}
+
+		point := TimeSeriesPoint{Bucket: bucket.BucketStart.Format(statisticsDateLayout)}
+		switch metric {
+		case StatisticsMetricRegistration:
+			count := bucket.Registrations
+			point.Count = &count
+			total += count
+		case StatisticsMetricVerification:
+			count := bucket.Verifications
+			point.Count = &count
+			total += count
+		case StatisticsMetricActivity:
+			activeUsers, logins := bucket.ActiveUsers, bucket.Logins
+			point.ActiveUsers = &activeUsers
+			point.Logins = &logins
+		}
+		response.Points = append(response.Points, point)
+	}
+
+	if metric != StatisticsMetricActivity {
+		response.Total = &total
+	}
+
+	return response, nil
+}
+
+// GetRetention 获取按注册周划分的留存（注册后每周有成功登录的用户占比）
+func (s *Service) GetRetention(ctx context.Context, req *GetRetentionRequest) (*RetentionResponse, error) {
+	weeks := req.Weeks
+	if weeks <= 0 {
+		weeks = 8
+	}
+
+	timezone, loc, err := resolveStatisticsTimezone(req.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end, err := statisticsRange(req.DateFrom, req.DateTo, StatisticsPeriodWeek, loc, maxRetentionCohorts)
+	if err != nil {
+		return nil, err
+	}
+
+	source := statisticsSourceLive
+	var cells []*RetentionCell
+	if s.hasStatisticsRollups(ctx, timezone) {
+		source = statisticsSourceRollup
+		cells, err = s.repo.GetRetentionRollups(ctx, timezone, start, end, weeks)
+	} else {
+		cells, err = s.repo.QueryRetention(ctx, timezone, start, end, weeks)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	response := &RetentionResponse{
+		Timezone: timezone,
+		Weeks:    weeks,
+		Source:   source,
+		Cohorts:  []CohortRetentionResponse{},
+	}
+
+	// 数据按注册周、周序号排序，依次归入各注册周
+	for _, cell := range cells {
+		if cell.ComputedAt != nil && (response.ComputedAt == nil || cell.ComputedAt.Before(*response.ComputedAt)) {
+			response.ComputedAt = cell.ComputedAt
+		}
+
+		cohortWeek := cell.CohortWeek.Format(statisticsDateLayout)
+		last := len(response.Cohorts) - 1
+		if last < 0 || response.Cohorts[last].CohortWeek != cohortWeek {
+			response.Cohorts = append(response.Cohorts, CohortRetentionResponse{
+				CohortWeek: cohortWeek,
+				CohortSize: cell.CohortSize,
+				Retention:  []RetentionWeekResponse{},
+			})
+			last++
+		}
+
+		var rate float64
+		if cell.CohortSize > 0 {
+			rate = math.Round(float64(cell.ActiveUsers)/float64(cell.CohortSize)*10000) / 100
+		}
+		response.Cohorts[last].Retention = append(response.Cohorts[last].Retention, RetentionWeekResponse{
+			Week:        cell.WeekOffset,
+			ActiveUsers: cell.ActiveUsers,
+			Rate:        rate,
+		})
+	}
+
+	return response, nil
+}
+
+// hasStatisticsRollups 时区是否已配置汇总且已完成首次汇总（首次汇总完成前实时计算）
+func (s *Service) hasStatisticsRollups(ctx context.Context, timezone string) bool {
+	configured := false
+	for _, tz := range s.config.StatsTimezones {
+		if tz == timezone {
+			configured = true
+			break
+		}
+	}
+	if !configured {
+		return false
+	}
+
+	if _, ok := s.rollupReady.Load(timezone); ok {
+		return true
+	}
+
+	ready, err := s.repo.HasStatisticsRollups(ctx, timezone)
+	if err != nil {
+		s.logger.WithError(err).WithField("timezone", timezone).Warn("Failed to check statistics rollups")
+		return false
+	}
+	if ready {
+		s.rollupReady.Store(timezone, true)
+	}
+	return ready
+}
+
 // === 用户管理操作服务 ===
 
 // UpdateUserStatus 更新用户状态
@@ -228,8 +492,16 @@ func (s *Service) UpdateUserStatus(ctx context.Context, userID, adminID, adminEm
 		return nil, fmt.Errorf("failed to get target user: %w", err)
 	}
 
-	// 更新用户状态
-	err = s.repo.UpdateUserStatus(ctx, userID, status, req.Reason)
+	// 更新用户状态（改为暂停时写入永久暂停记录）
+	if status == user.UserStatusSuspended {
+		reason := "Status changed to suspended"
+		if req.Reason != nil && *req.Reason != "" {
+			reason = *req.Reason
+		}
+		err = s.repo.SuspendUser(ctx, &UserSuspension{UserID: userID, AdminID: &adminID, Reason: reason})
+	} else {
+		err = s.repo.UpdateUserStatus(ctx, userID, status, &adminID)
+	}
 	if err != nil {
 		s.logger.WithError(err).WithFields(logrus.Fields{
 			"user_id":    userID,
@@ -239,6 +511,11 @@ func (s *Service) UpdateUserStatus(ctx context.Context, userID, adminID, adminEm
 		return nil, fmt.Errorf("failed to update user status: %w", err)
 	}
 
+	// 非激活状态的用户立即失去所有令牌
+	if status != user.UserStatusActive {
+		s.revokeUserTokens(ctx, userID)
+	}
+
 	// 记录管理操作日志
 	action := UserManagementAction("update_status")
 	switch status {
@@ -280,19 +557,35 @@ func (s *Service) UpdateUserStatus(ctx context.Context, userID, adminID, adminEm
 	}, nil
 }
 
-// SuspendUser 暂停用户
+// SuspendUser 暂停用户（指定Until或Duration时到期由后台任务自动解除，否则永久暂停）
 func (s *Service) SuspendUser(ctx context.Context, userID, adminID, adminEmail, ipAddress string,
 	req *SuspendUserRequest) (*OperationResponse, error) {
 
+	endsAt, err := suspensionEnd(req, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
 	// 获取目标用户信息
 	targetUser, err := s.repo.GetUserByID(ctx, userID)
 	if err != nil {
+		if err.Error() == "user not found" {
+			return nil, auth.ErrUserNotFound
+		}
 		return nil, fmt.Errorf("failed to get target user: %w", err)
 	}
 
 	// 暂停用户
-	err = s.repo.UpdateUserStatus(ctx, userID, user.UserStatusSuspended, &req.Reason)
-	if err != nil {
+	suspension := &UserSuspension{
+		UserID:  userID,
+		AdminID: &adminID,
+		Reason:  req.Reason,
+		EndsAt:  endsAt,
+	}
+	if err := s.repo.SuspendUser(ctx, suspension); err != nil {
+		if err.Error() == "user not found" {
+			return nil, auth.ErrUserNotFound
+		}
 		return nil, fmt.Errorf("failed to suspend user: %w", err)
 	}
 
@@ -301,8 +594,13 @@ func (s *Service) SuspendUser(ctx context.Context, userID, adminID, adminEmail,
 	if err != nil {
 		s.logger.WithError(err).WithField("user_id", userID).Warn("Failed to deactivate user sessions")
 	}
+	s.revokeUserTokens(ctx, userID)
 
 	// 记录管理操作日志
+	details := map[string]interface{}{
+		"suspension_id": suspension.ID,
+		"ends_at":       suspension.EndsAt,
+	}
 	logEntry := &UserManagementLog{
 		AdminID:      adminID,
 		AdminEmail:   adminEmail,
@@ -310,6 +608,7 @@ func (s *Service) SuspendUser(ctx context.Context, userID, adminID, adminEmail,
 		TargetEmail:  targetUser.Email,
 		Action:       ActionSuspend,
 		Reason:       &req.Reason,
+		Details:      &details,
 		IPAddress:    ipAddress,
 		CreatedAt:    time.Now(),
 	}
@@ -322,16 +621,64 @@ func (s *Service) SuspendUser(ctx context.Context, userID, adminID, adminEmail,
 		"user_id":  userID,
 		"admin_id": adminID,
 		"reason":   req.Reason,
-		"duration": req.Duration,
+		"ends_at":  suspension.EndsAt,
 	}).Info("User suspended by admin")
 
 	return &OperationResponse{
 		Success:   true,
 		Message:   "User suspended successfully",
+		Data:      suspension,
 		Timestamp: time.Now(),
 	}, nil
 }
 
+// suspensionEnd 计算暂停结束时间：优先使用Until，其次按Duration天数计算，都为空表示永久暂停
+func suspensionEnd(req *SuspendUserRequest, now time.Time) (*time.Time, error) {
+	if req.Until != nil {
+		if !req.Until.After(now) {
+			return nil, auth.ErrInvalidSuspensionPeriod
+		}
+		until := *req.Until
+		return &until, nil
+	}
+
+	if req.Duration != nil {
+		until := now.AddDate(0, 0, *req.Duration)
+		return &until, nil
+	}
+
+	return nil, nil
+}
+
+// GetUserSuspensions 获取用户的暂停历史
+func (s *Service) GetUserSuspensions(ctx context.Context, userID string) (*UserSuspensionHistoryResponse, error) {
+	if _, err := s.repo.GetUserByID(ctx, userID); err != nil {
+		if err.Error() == "user not found" {
+			return nil, auth.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get target user: %w", err)
+	}
+
+	suspensions, err := s.repo.GetUserSuspensions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &UserSuspensionHistoryResponse{
+		UserID:           userID,
+		TotalSuspensions: len(suspensions),
+		Suspensions:      suspensions,
+	}
+	for _, suspension := range suspensions {
+		if suspension.IsActive() {
+			response.Active = suspension
+			break
+		}
+	}
+
+	return response, nil
+}
+
 // ReactivateUser 重新激活用户
 func (s *Service) ReactivateUser(ctx context.Context, userID, adminID, adminEmail, ipAddress string) (*OperationResponse, error) {
 	// 获取目标用户信息
@@ -340,8 +687,8 @@ func (s *Service) ReactivateUser(ctx context.Context, userID, adminID, adminEmai
 		return nil, fmt.Errorf("failed to get target user: %w", err)
 	}
 
-	// 激活用户
-	err = s.repo.UpdateUserStatus(ctx, userID, user.UserStatusActive, nil)
+	// 激活用户（同时解除生效中的暂停）
+	err = s.repo.UpdateUserStatus(ctx, userID, user.UserStatusActive, &adminID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to reactivate user: %w", err)
 	}
@@ -383,6 +730,17 @@ func (s *Service) ResetUserPassword(ctx context.Context, userID, adminID, adminE
 		return nil, fmt.Errorf("failed to get target user: %w", err)
 	}
 
+	// 检查密码策略
+	if err := s.passwordPolicy.Validate(ctx, &auth.PasswordSubject{
+		UserID:              targetUser.ID,
+		UserType:            "user",
+		Email:               targetUser.Email,
+		Name:                targetUser.Name,
+		CurrentPasswordHash: targetUser.Password,
+	}, req.NewPassword); err != nil {
+		return nil, err
+	}
+
 	// 加密新密码
 	hashedPassword, err := s.encryptor.HashPassword(req.NewPassword)
 	if err != nil {
@@ -394,12 +752,16 @@ func (s *Service) ResetUserPassword(ctx context.Context, userID, adminID, adminE
 	if err != nil {
 		return nil, fmt.Errorf("failed to update password: %w", err)
 	}
+	if err := s.passwordPolicy.RecordPassword(ctx, userID, "user", hashedPassword); err != nil {
+		s.logger.WithError(err).WithField("user_id", userID).Warn("Failed to record password history")
+	}
 
 	// 强制登出所有会话
 	err = s.repo.DeactivateUserSessions(ctx, userID, nil)
 	if err != nil {
 		s.logger.WithError(err).WithField("user_id", userID).Warn("Failed to deactivate user sessions")
 	}
+	s.revokeUserTokens(ctx, userID)
 
 	// 记录管理操作日志
 	logEntry := &UserManagementLog{
@@ -455,6 +817,13 @@ func (s *Service) ForceLogoutUser(ctx context.Context, userID, adminID, adminEma
 		return nil, fmt.Errorf("failed to force logout user: %w", err)
 	}
 
+	// 撤销刷新令牌并使已签发的访问令牌立即失效
+	if sessionID == nil {
+		if err := s.jwtManager.RevokeAllUserTokens(ctx, userID, "user"); err != nil {
+			return nil, fmt.Errorf("failed to revoke user tokens: %w", err)
+		}
+	}
+
 	// 记录管理操作日志
 	logEntry := &UserManagementLog{
 		AdminID:      adminID,
@@ -535,11 +904,1944 @@ func (s *Service) VerifyUserEmail(ctx context.Context, userID, adminID, adminEma
 	}, nil
 }
 
-// ValidateUserExists 验证用户是否存在
-func (s *Service) ValidateUserExists(ctx context.Context, userID string) error {
-	_, err := s.repo.GetUserByID(ctx, userID)
+// emailChangeLogDetails 修改邮箱的审计日志详情
+// details参与哈希链且注销时不会匿名化，新旧邮箱只记录在email_changes中（注销时删除）
+func emailChangeLogDetails(change *user.EmailChange, sendVerification bool) map[string]interface{} {
+	return map[string]interface{}{
+		"send_verification": sendVerification,
+		"email_change_id":   change.ID,
+	}
+}
+
+// UpdateUserEmail 管理员修改用户邮箱（原邮箱会收到撤销链接）
+func (s *Service) UpdateUserEmail(ctx context.Context, userID, adminID, adminEmail, ipAddress string,
+	req *UpdateUserEmailRequest) (*OperationResponse, error) {
+
+	change, err := s.userService.ChangeEmailByAdmin(ctx, userID, req.NewEmail, adminID, ipAddress, req.SendVerification)
 	if err != nil {
-		return auth.ErrUserNotFound
+		return nil, err
+	}
+
+	// 记录管理操作日志
+	details := emailChangeLogDetails(change, req.SendVerification)
+	logEntry := &UserManagementLog{
+		AdminID:      adminID,
+		AdminEmail:   adminEmail,
+		TargetUserID: userID,
+		TargetEmail:  change.NewEmail,
+		Action:       ActionUpdateEmail,
+		Details:      &details,
+		IPAddress:    ipAddress,
+		CreatedAt:    time.Now(),
+	}
+
+	if err := s.repo.CreateManagementLog(ctx, logEntry); err != nil {
+		s.logger.WithError(err).Error("Failed to create management log")
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":  userID,
+		"admin_id": adminID,
+	}).Info("User email changed by admin")
+
+	return &OperationResponse{
+		Success:   true,
+		Message:   "User email updated successfully",
+		Timestamp: time.Now(),
+	}, nil
+}
+
+// DeleteUser 管理员注销用户（进入冷静期，期间可恢复，期满后匿名化个人数据）
+func (s *Service) DeleteUser(ctx context.Context, userID, adminID, adminEmail, ipAddress string,
+	req *DeleteUserRequest) (*OperationResponse, error) {
+
+	// 获取目标用户信息
+	targetUser, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		if err.Error() == "user not found" {
+			return nil, auth.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get target user: %w", err)
+	}
+
+	deletion, err := s.privacyService.DeleteUserByAdmin(ctx, userID, adminID, &req.Reason, ipAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	// 记录管理操作日志
+	details := map[string]interface{}{
+		"deletion_id":   deletion.ID,
+		"scheduled_for": deletion.ScheduledFor,
+	}
+	logEntry := &UserManagementLog{
+		AdminID:      adminID,
+		AdminEmail:   adminEmail,
+		TargetUserID: userID,
+		TargetEmail:  targetUser.Email,
+		Action:       ActionDelete,
+		Reason:       &req.Reason,
+		Details:      &details,
+		IPAddress:    ipAddress,
+		CreatedAt:    time.Now(),
+	}
+
+	if err := s.repo.CreateManagementLog(ctx, logEntry); err != nil {
+		s.logger.WithError(err).Error("Failed to create management log")
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":  userID,
+		"admin_id": adminID,
+		"reason":   req.Reason,
+	}).Info("User deleted by admin")
+
+	return &OperationResponse{
+		Success:   true,
+		Message:   "User deleted successfully",
+		Data:      map[string]interface{}{"scheduled_for": deletion.ScheduledFor},
+		Timestamp: time.Now(),
+	}, nil
+}
+
+// RestoreUser 管理员恢复冷静期内（尚未匿名化）的已注销用户
+func (s *Service) RestoreUser(ctx context.Context, userID, adminID, adminEmail, ipAddress string) (*OperationResponse, error) {
+	// 获取目标用户信息
+	targetUser, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		if err.Error() == "user not found" {
+			return nil, auth.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get target user: %w", err)
+	}
+
+	deletion, err := s.privacyService.RestoreUserByAdmin(ctx, userID, adminID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 记录管理操作日志
+	details := map[string]interface{}{
+		"deletion_id": deletion.ID,
+	}
+	logEntry := &UserManagementLog{
+		AdminID:      adminID,
+		AdminEmail:   adminEmail,
+		TargetUserID: userID,
+		TargetEmail:  targetUser.Email,
+		Action:       ActionRestore,
+		Details:      &details,
+		IPAddress:    ipAddress,
+		CreatedAt:    time.Now(),
+	}
+
+	if err := s.repo.CreateManagementLog(ctx, logEntry); err != nil {
+		s.logger.WithError(err).Error("Failed to create management log")
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":  userID,
+		"admin_id": adminID,
+	}).Info("User restored by admin")
+
+	return &OperationResponse{
+		Success:   true,
+		Message:   "User restored successfully",
+		Timestamp: time.Now(),
+	}, nil
+}
+
+// GetUserLockout 获取用户的登录锁定情况
+func (s *Service) GetUserLockout(ctx context.Context, userID string) (*UserLockoutResponse, error) {
+	targetUser, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	lockout, err := s.lockout.GetAccountLockout(ctx, "user", targetUser.Email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user lockout: %w", err)
+	}
+
+	return &UserLockoutResponse{
+		UserID:  userID,
+		Lockout: lockout,
+	}, nil
+}
+
+// UnlockUser 解除用户的登录锁定
+func (s *Service) UnlockUser(ctx context.Context, userID, adminID, adminEmail, ipAddress string,
+	req *UnlockUserRequest) (*OperationResponse, error) {
+
+	// 获取目标用户信息
+	targetUser, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.lockout.UnlockAccount(ctx, "user", targetUser.Email); err != nil {
+		return nil, fmt.Errorf("failed to unlock user: %w", err)
+	}
+
+	// 记录管理操作日志
+	logEntry := &UserManagementLog{
+		AdminID:      adminID,
+		AdminEmail:   adminEmail,
+		TargetUserID: userID,
+		TargetEmail:  targetUser.Email,
+		Action:       ActionUnlockLogin,
+		Reason:       &req.Reason,
+		IPAddress:    ipAddress,
+		CreatedAt:    time.Now(),
+	}
+
+	if err := s.repo.CreateManagementLog(ctx, logEntry); err != nil {
+		s.logger.WithError(err).Error("Failed to create management log")
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":  userID,
+		"admin_id": adminID,
+		"reason":   req.Reason,
+	}).Info("User login lockout cleared by admin")
+
+	return &OperationResponse{
+		Success:   true,
+		Message:   "User login lockout cleared successfully",
+		Timestamp: time.Now(),
+	}, nil
+}
+
+// === 备注、标签和关注服务 ===
+
+// getManagedUser 获取备注、标签和关注操作的目标用户
+func (s *Service) getManagedUser(ctx context.Context, userID string) (*UserManagementModel, error) {
+	userModel, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		if err.Error() == "user not found" {
+			return nil, auth.ErrUserNotFound
+		}
+		return nil, err
+	}
+	return userModel, nil
+}
+
+// ListUserNotes 获取用户备注列表
+func (s *Service) ListUserNotes(ctx context.Context, userID string, req *ListUserNotesRequest) (*UserNoteListResponse, error) {
+	if _, err := s.getManagedUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	pagination := req.ToPaginationParams()
+	notes, total, err := s.repo.ListUserNotes(ctx, userID, pagination)
+	if err != nil {
+		return nil, err
+	}
+
+	paginatedResult := NewPaginatedResult(notes, total, pagination)
+
+	return &UserNoteListResponse{
+		UserID:     userID,
+		Notes:      notes,
+		Total:      paginatedResult.Total,
+		Page:       paginatedResult.Page,
+		PageSize:   paginatedResult.PageSize,
+		TotalPages: paginatedResult.TotalPages,
+		HasNext:    paginatedResult.HasNext,
+		HasPrev:    paginatedResult.HasPrev,
+	}, nil
+}
+
+// CreateUserNote 添加用户备注
+func (s *Service) CreateUserNote(ctx context.Context, userID string, admin *AdminInfo, ipAddress string,
+	req *CreateUserNoteRequest) (*UserNote, error) {
+
+	targetUser, err := s.getManagedUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	note := &UserNote{
+		UserID:      userID,
+		AuthorID:    admin.ID,
+		AuthorEmail: admin.Email,
+		Content:     strings.TrimSpace(req.Content),
+	}
+	if note.Content == "" {
+		return nil, auth.ErrEmptyUserNote
+	}
+
+	if err := s.repo.CreateUserNote(ctx, note); err != nil {
+		return nil, err
+	}
+
+	// 记录管理操作日志
+	details := map[string]interface{}{
+		"note_id": note.ID,
+		"content": note.Content,
+	}
+	logEntry := &UserManagementLog{
+		AdminID:      admin.ID,
+		AdminEmail:   admin.Email,
+		TargetUserID: userID,
+		TargetEmail:  targetUser.Email,
+		Action:       ActionAddNote,
+		Details:      &details,
+		IPAddress:    ipAddress,
+		CreatedAt:    time.Now(),
+	}
+
+	if err := s.repo.CreateManagementLog(ctx, logEntry); err != nil {
+		s.logger.WithError(err).Error("Failed to create management log")
+	}
+
+	return note, nil
+}
+
+// DeleteUserNote 删除用户备注（仅备注作者或超级管理员）
+func (s *Service) DeleteUserNote(ctx context.Context, userID, noteID string, admin *AdminInfo, ipAddress string) (*OperationResponse, error) {
+	targetUser, err := s.getManagedUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	note, err := s.repo.GetUserNote(ctx, userID, noteID)
+	if err != nil {
+		if err.Error() == "user note not found" {
+			return nil, auth.ErrUserNoteNotFound
+		}
+		return nil, err
+	}
+
+	if note.AuthorID != admin.ID && admin.Role != auth.RoleSuperAdmin {
+		return nil, auth.ErrPermissionDenied
+	}
+
+	if err := s.repo.DeleteUserNote(ctx, userID, noteID); err != nil {
+		if err.Error() == "user note not found" {
+			return nil, auth.ErrUserNoteNotFound
+		}
+		return nil, err
+	}
+
+	// 记录管理操作日志（保留被删除的备注内容）
+	details := map[string]interface{}{
+		"note_id":      note.ID,
+		"content":      note.Content,
+		"author_email": note.AuthorEmail,
+		"created_at":   note.CreatedAt,
+	}
+	logEntry := &UserManagementLog{
+		AdminID:      admin.ID,
+		AdminEmail:   admin.Email,
+		TargetUserID: userID,
+		TargetEmail:  targetUser.Email,
+		Action:       ActionDeleteNote,
+		Details:      &details,
+		IPAddress:    ipAddress,
+		CreatedAt:    time.Now(),
+	}
+
+	if err := s.repo.CreateManagementLog(ctx, logEntry); err != nil {
+		s.logger.WithError(err).Error("Failed to create management log")
+	}
+
+	return &OperationResponse{
+		Success:   true,
+		Message:   "User note deleted successfully",
+		Timestamp: time.Now(),
+	}, nil
+}
+
+// ListTags 获取全部标签及使用人数
+func (s *Service) ListTags(ctx context.Context) (*TagListResponse, error) {
+	tags, err := s.repo.ListTags(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &TagListResponse{Tags: tags}, nil
+}
+
+// AddUserTags 为用户添加标签
+func (s *Service) AddUserTags(ctx context.Context, userID string, admin *AdminInfo, ipAddress string,
+	req *AddUserTagsRequest) (*UserTagsResponse, error) {
+
+	tags, err := normalizeUserTags(req.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	targetUser, err := s.getManagedUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := s.repo.GetUserTags(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 检查添加后是否超过标签数上限
+	existing := make(map[string]bool, len(current))
+	for _, tag := range current {
+		existing[tag] = true
+	}
+	newCount := 0
+	for _, tag := range tags {
+		if !existing[tag] {
+			newCount++
+		}
+	}
+	if len(current)+newCount > maxUserTags {
+		return nil, auth.ErrTooManyUserTags
+	}
+
+	added, err := s.repo.AddUserTags(ctx, userID, tags, admin.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 记录管理操作日志
+	if len(added) > 0 {
+		details := map[string]interface{}{"tags": added}
+		logEntry := &UserManagementLog{
+			AdminID:      admin.ID,
+			AdminEmail:   admin.Email,
+			TargetUserID: userID,
+			TargetEmail:  targetUser.Email,
+			Action:       ActionAddTag,
+			Details:      &details,
+			IPAddress:    ipAddress,
+			CreatedAt:    time.Now(),
+		}
+
+		if err := s.repo.CreateManagementLog(ctx, logEntry); err != nil {
+			s.logger.WithError(err).Error("Failed to create management log")
+		}
+	}
+
+	return s.getUserTagsResponse(ctx, userID)
+}
+
+// RemoveUserTag 移除用户标签（用户没有该标签时直接返回当前标签）
+func (s *Service) RemoveUserTag(ctx context.Context, userID, tag string, admin *AdminInfo, ipAddress string) (*UserTagsResponse, error) {
+	tag, err := normalizeUserTag(tag)
+	if err != nil {
+		return nil, err
+	}
+
+	targetUser, err := s.getManagedUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	removed, err := s.repo.RemoveUserTag(ctx, userID, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	// 记录管理操作日志
+	if removed {
+		details := map[string]interface{}{"tags": []string{tag}}
+		logEntry := &UserManagementLog{
+			AdminID:      admin.ID,
+			AdminEmail:   admin.Email,
+			TargetUserID: userID,
+			TargetEmail:  targetUser.Email,
+			Action:       ActionRemoveTag,
+			Details:      &details,
+			IPAddress:    ipAddress,
+			CreatedAt:    time.Now(),
+		}
+
+		if err := s.repo.CreateManagementLog(ctx, logEntry); err != nil {
+			s.logger.WithError(err).Error("Failed to create management log")
+		}
+	}
+
+	return s.getUserTagsResponse(ctx, userID)
+}
+
+// getUserTagsResponse 获取用户当前标签
+func (s *Service) getUserTagsResponse(ctx context.Context, userID string) (*UserTagsResponse, error) {
+	tags, err := s.repo.GetUserTags(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &UserTagsResponse{UserID: userID, Tags: nonNilTags(tags)}, nil
+}
+
+// WatchUser 关注用户（已关注时更新关注的事件和原因）
+func (s *Service) WatchUser(ctx context.Context, userID string, admin *AdminInfo, req *WatchUserRequest) (*UserWatch, error) {
+	events, err := normalizeWatchEvents(req.Events)
+	if err != nil {
+		return nil, err
+	}
+
+	targetUser, err := s.getManagedUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if targetUser.DeletedAt != nil {
+		return nil, auth.ErrUserNotFound
+	}
+
+	watch := &UserWatch{
+		AdminID:    admin.ID,
+		AdminEmail: admin.Email,
+		UserID:     userID,
+		UserEmail:  targetUser.Email,
+		Events:     events,
+		Reason:     req.Reason,
+	}
+	if err := s.repo.UpsertUserWatch(ctx, watch); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":  userID,
+		"admin_id": admin.ID,
+		"events":   events,
+	}).Info("Admin is watching user")
+
+	return watch, nil
+}
+
+// UnwatchUser 取消关注用户
+func (s *Service) UnwatchUser(ctx context.Context, userID string, admin *AdminInfo) (*OperationResponse, error) {
+	if err := s.repo.DeleteUserWatch(ctx, admin.ID, userID); err != nil {
+		if err.Error() == "user watch not found" {
+			return nil, auth.ErrUserWatchNotFound
+		}
+		return nil, err
+	}
+
+	return &OperationResponse{
+		Success:   true,
+		Message:   "User removed from watchlist",
+		Timestamp: time.Now(),
+	}, nil
+}
+
+// ListWatches 获取当前管理员的关注列表
+func (s *Service) ListWatches(ctx context.Context, admin *AdminInfo, req *ListWatchesRequest) (*WatchListResponse, error) {
+	pagination := req.ToPaginationParams()
+	watches, total, err := s.repo.ListUserWatches(ctx, admin.ID, pagination)
+	if err != nil {
+		return nil, err
+	}
+
+	paginatedResult := NewPaginatedResult(watches, total, pagination)
+
+	return &WatchListResponse{
+		Watches:    watches,
+		Total:      paginatedResult.Total,
+		Page:       paginatedResult.Page,
+		PageSize:   paginatedResult.PageSize,
+		TotalPages: paginatedResult.TotalPages,
+		HasNext:    paginatedResult.HasNext,
+		HasPrev:    paginatedResult.HasPrev,
+	}, nil
+}
+
+// ListWatchNotifications 获取当前管理员的关注通知
+func (s *Service) ListWatchNotifications(ctx context.Context, admin *AdminInfo, req *ListWatchNotificationsRequest) (*WatchNotificationListResponse, error) {
+	pagination := req.ToPaginationParams()
+	notifications, total, unread, err := s.repo.ListWatchNotifications(ctx, admin.ID, req.Unread, pagination)
+	if err != nil {
+		return nil, err
+	}
+
+	paginatedResult := NewPaginatedResult(notifications, total, pagination)
+
+	return &WatchNotificationListResponse{
+		Notifications: notifications,
+		UnreadCount:   unread,
+		Total:         paginatedResult.Total,
+		Page:          paginatedResult.Page,
+		PageSize:      paginatedResult.PageSize,
+		TotalPages:    paginatedResult.TotalPages,
+		HasNext:       paginatedResult.HasNext,
+		HasPrev:       paginatedResult.HasPrev,
+	}, nil
+}
+
+// MarkWatchNotificationsRead 标记当前管理员的关注通知为已读
+func (s *Service) MarkWatchNotificationsRead(ctx context.Context, admin *AdminInfo, req *MarkWatchNotificationsReadRequest) (*OperationResponse, error) {
+	updated, err := s.repo.MarkWatchNotificationsRead(ctx, admin.ID, req.IDs)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OperationResponse{
+		Success:   true,
+		Message:   "Notifications marked as read",
+		Data:      map[string]int64{"updated": updated},
+		Timestamp: time.Now(),
+	}, nil
+}
+
+// OnUserActivity 实现auth.UserActivityObserver，异步通知关注了该用户活动的管理员
+func (s *Service) OnUserActivity(_ context.Context, event *auth.UserActivityEvent) {
+	go s.notifyWatchers(event)
+}
+
+// notifyWatchers 为关注了该活动的管理员写入站内通知并发送邮件
+func (s *Service) notifyWatchers(event *auth.UserActivityEvent) {
+	// 请求可能已经结束，使用独立的上下文
+	ctx, cancel := context.WithTimeout(context.Background(), watchNotifyTimeout)
+	defer cancel()
+
+	watches, err := s.repo.GetWatchesForEvent(ctx, event.UserID, event.Type)
+	if err != nil {
+		s.logger.WithError(err).WithField("user_id", event.UserID).Warn("Failed to get user watches")
+		return
+	}
+
+	for _, watch := range watches {
+		notification := &WatchNotification{
+			AdminID:    watch.AdminID,
+			UserID:     event.UserID,
+			UserEmail:  event.Email,
+			Event:      event.Type,
+			Details:    event.Details,
+			OccurredAt: event.OccurredAt,
+		}
+		if notification.UserEmail == "" {
+			notification.UserEmail = watch.UserEmail
+		}
+		if event.IPAddress != "" {
+			notification.IPAddress = &event.IPAddress
+		}
+
+		if err := s.repo.CreateWatchNotification(ctx, notification); err != nil {
+			s.logger.WithError(err).WithFields(logrus.Fields{
+				"user_id":  event.UserID,
+				"admin_id": watch.AdminID,
+			}).Error("Failed to create watch notification")
+			continue
+		}
+
+		if s.mailer == nil {
+			continue
+		}
+		subject, body := watchNotificationMessage(notification, watch)
+		if err := s.mailer.Send(ctx, &mailer.Message{
+			To:      []string{watch.AdminEmail},
+			Subject: subject,
+			Body:    body,
+		}); err != nil {
+			s.logger.WithError(err).WithFields(logrus.Fields{
+				"user_id":  event.UserID,
+				"admin_id": watch.AdminID,
+			}).Warn("Failed to send watch notification email")
+		}
+	}
+}
+
+// === 高级查询和保存的搜索服务 ===
+
+// resolveUserFilter 将用户列表请求转换为搜索过滤器，合并保存的搜索和请求中的高级查询
+// 使用高级查询时搜索关键词作为查询条件合并，返回的search为空；否则返回原搜索关键词
+func (s *Service) resolveUserFilter(ctx context.Context, req *GetUsersRequest, admin *AdminInfo) (*SearchFilter, string, error) {
+	filter := req.ToSearchFilter()
+
+	var savedQuery *UserQuery
+	if req.SavedSearchID != "" {
+		search, err := s.GetSavedSearch(ctx, req.SavedSearchID, admin)
+		if err != nil {
+			return nil, "", err
+		}
+		savedQuery = search.Query
+	}
+
+	filter.Query = combineUserQueries(savedQuery, req.Query)
+	if filter.Query == nil {
+		return filter, req.Search, nil
+	}
+
+	if req.Search != "" {
+		value, err := json.Marshal(req.Search)
+		if err != nil {
+			return nil, "", err
+		}
+		filter.Query = combineUserQueries(filter.Query, &UserQuery{
+			Op: "or",
+			Conditions: []*UserQuery{
+				{Field: "email", Operator: "contains", Value: value},
+				{Field: "name", Operator: "contains", Value: value},
+			},
+		})
+	}
+
+	if err := validateUserQuery(filter.Query); err != nil {
+		return nil, "", err
+	}
+
+	return filter, "", nil
+}
+
+// ListSavedSearches 获取管理员保存的搜索
+func (s *Service) ListSavedSearches(ctx context.Context, admin *AdminInfo) (*SavedSearchListResponse, error) {
+	searches, err := s.repo.ListSavedSearches(ctx, admin.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SavedSearchListResponse{
+		SavedSearches: searches,
+		Total:         len(searches),
+		Limit:         maxSavedSearches,
+	}, nil
+}
+
+// GetSavedSearch 获取管理员保存的搜索（只能访问自己保存的搜索）
+func (s *Service) GetSavedSearch(ctx context.Context, searchID string, admin *AdminInfo) (*SavedSearch, error) {
+	search, err := s.repo.GetSavedSearch(ctx, admin.ID, searchID)
+	if err != nil {
+		if err.Error() == "saved search not found" {
+			return nil, auth.ErrSavedSearchNotFound
+		}
+		return nil, err
+	}
+	return search, nil
+}
+
+// CreateSavedSearch 保存搜索
+func (s *Service) CreateSavedSearch(ctx context.Context, admin *AdminInfo, req *CreateSavedSearchRequest) (*SavedSearch, error) {
+	if err := validateUserQuery(req.Query); err != nil {
+		return nil, err
+	}
+
+	count, err := s.repo.CountSavedSearches(ctx, admin.ID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxSavedSearches {
+		return nil, auth.ErrTooManySavedSearches
+	}
+
+	search := &SavedSearch{
+		AdminID:     admin.ID,
+		Name:        strings.TrimSpace(req.Name),
+		Description: req.Description,
+		Query:       req.Query,
+	}
+	if err := s.repo.CreateSavedSearch(ctx, search); err != nil {
+		if err.Error() == "saved search name already exists" {
+			return nil, auth.ErrSavedSearchNameTaken
+		}
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"saved_search_id": search.ID,
+		"admin_id":        admin.ID,
+	}).Info("User search saved")
+
+	return search, nil
+}
+
+// UpdateSavedSearch 更新保存的搜索
+func (s *Service) UpdateSavedSearch(ctx context.Context, searchID string, admin *AdminInfo, req *UpdateSavedSearchRequest) (*SavedSearch, error) {
+	search, err := s.GetSavedSearch(ctx, searchID, admin)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		search.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Description != nil {
+		search.Description = req.Description
+	}
+	if req.Query != nil {
+		if err := validateUserQuery(req.Query); err != nil {
+			return nil, err
+		}
+		search.Query = req.Query
+	}
+
+	if err := s.repo.UpdateSavedSearch(ctx, search); err != nil {
+		switch err.Error() {
+		case "saved search not found":
+			return nil, auth.ErrSavedSearchNotFound
+		case "saved search name already exists":
+			return nil, auth.ErrSavedSearchNameTaken
+		}
+		return nil, err
+	}
+
+	return search, nil
+}
+
+// DeleteSavedSearch 删除保存的搜索
+func (s *Service) DeleteSavedSearch(ctx context.Context, searchID string, admin *AdminInfo) (*OperationResponse, error) {
+	if err := s.repo.DeleteSavedSearch(ctx, admin.ID, searchID); err != nil {
+		if err.Error() == "saved search not found" {
+			return nil, auth.ErrSavedSearchNotFound
+		}
+		return nil, err
+	}
+
+	return &OperationResponse{
+		Success:   true,
+		Message:   "Saved search deleted successfully",
+		Timestamp: time.Now(),
+	}, nil
+}
+
+// === 模拟登录服务 ===
+
+// ImpersonateUser 管理员以用户身份登录，签发短期只读访问令牌
+func (s *Service) ImpersonateUser(ctx context.Context, userID string, admin *AdminInfo, ipAddress, userAgent string,
+	req *ImpersonateUserRequest) (*ImpersonationTokenResponse, error) {
+
+	targetUser, err := s.getManagedUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if targetUser.DeletedAt != nil {
+		return nil, auth.ErrImpersonationNotAllowed
+	}
+
+	ttl := s.config.ImpersonationTTL
+	if ttl <= 0 {
+		ttl = defaultImpersonationTTL
+	}
+
+	impersonationID := uuid.New().String()
+	accessToken, claims, err := s.jwtManager.GenerateImpersonationToken(userID, targetUser.Email, admin.ID, impersonationID, ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	imp := &UserImpersonation{
+		ID:         impersonationID,
+		AdminID:    admin.ID,
+		AdminEmail: admin.Email,
+		UserID:     userID,
+		UserEmail:  targetUser.Email,
+		Reason:     strings.TrimSpace(req.Reason),
+		TokenID:    claims.ID,
+		ExpiresAt:  claims.ExpiresAt.Time,
+	}
+	if ipAddress != "" {
+		imp.IPAddress = &ipAddress
+	}
+	if userAgent != "" {
+		imp.UserAgent = &userAgent
+	}
+
+	if err := s.repo.CreateImpersonation(ctx, imp); err != nil {
+		return nil, err
+	}
+
+	notify := s.config.ImpersonationNotify
+	if req.NotifyUser != nil {
+		notify = *req.NotifyUser
+	}
+	if notify {
+		s.notifyImpersonatedUser(ctx, imp)
+	}
+
+	// 记录管理操作日志
+	details := map[string]interface{}{
+		"impersonation_id": imp.ID,
+		"expires_at":       imp.ExpiresAt,
+		"notify_user":      notify,
+		"user_notified":    imp.UserNotified,
+	}
+	logEntry := &UserManagementLog{
+		AdminID:      admin.ID,
+		AdminEmail:   admin.Email,
+		TargetUserID: userID,
+		TargetEmail:  targetUser.Email,
+		Action:       ActionImpersonate,
+		Reason:       &imp.Reason,
+		Details:      &details,
+		IPAddress:    ipAddress,
+		CreatedAt:    time.Now(),
+	}
+
+	if err := s.repo.CreateManagementLog(ctx, logEntry); err != nil {
+		s.logger.WithError(err).Error("Failed to create management log")
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"impersonation_id": imp.ID,
+		"user_id":          userID,
+		"admin_id":         admin.ID,
+		"expires_at":       imp.ExpiresAt,
+	}).Warn("Admin started impersonating user")
+
+	return &ImpersonationTokenResponse{
+		Impersonation: imp,
+		AccessToken:   accessToken,
+		TokenType:     "Bearer",
+		ExpiresIn:     int64(ttl.Seconds()),
+		ReadOnly:      true,
+	}, nil
+}
+
+// notifyImpersonatedUser 邮件通知被模拟的用户（未配置邮件或发送失败时只记录日志）
+func (s *Service) notifyImpersonatedUser(ctx context.Context, imp *UserImpersonation) {
+	if s.mailer == nil {
+		s.logger.WithField("impersonation_id", imp.ID).Warn("Mailer not configured, impersonated user not notified")
+		return
+	}
+
+	subject, body := impersonationNotificationMessage(imp)
+	if err := s.mailer.Send(ctx, &mailer.Message{
+		To:      []string{imp.UserEmail},
+		Subject: subject,
+		Body:    body,
+	}); err != nil {
+		s.logger.WithError(err).WithField("impersonation_id", imp.ID).Warn("Failed to send impersonation notification email")
+		return
+	}
+
+	if err := s.repo.SetImpersonationUserNotified(ctx, imp.ID); err != nil {
+		s.logger.WithError(err).WithField("impersonation_id", imp.ID).Error("Failed to mark impersonated user notified")
+		return
+	}
+	imp.UserNotified = true
+}
+
+// GetImpersonation 获取模拟登录记录
+func (s *Service) GetImpersonation(ctx context.Context, impersonationID string) (*UserImpersonation, error) {
+	imp, err := s.repo.GetImpersonation(ctx, impersonationID)
+	if err != nil {
+		if err.Error() == "impersonation not found" {
+			return nil, auth.ErrImpersonationNotFound
+		}
+		return nil, err
+	}
+	return imp, nil
+}
+
+// ListImpersonations 获取模拟登录记录列表
+func (s *Service) ListImpersonations(ctx context.Context, req *ListImpersonationsRequest) (*ImpersonationListResponse, error) {
+	pagination := req.ToPaginationParams()
+
+	impersonations, total, err := s.repo.ListImpersonations(ctx, req.ToFilter(), pagination)
+	if err != nil {
+		return nil, err
+	}
+
+	paginatedResult := NewPaginatedResult(impersonations, total, pagination)
+
+	return &ImpersonationListResponse{
+		Impersonations: impersonations,
+		Total:          paginatedResult.Total,
+		Page:           paginatedResult.Page,
+		PageSize:       paginatedResult.PageSize,
+		TotalPages:     paginatedResult.TotalPages,
+		HasNext:        paginatedResult.HasNext,
+		HasPrev:        paginatedResult.HasPrev,
+	}, nil
+}
+
+// ListImpersonationRequests 获取模拟登录令牌发起的请求
+func (s *Service) ListImpersonationRequests(ctx context.Context, impersonationID string,
+	req *ListImpersonationRequestsRequest) (*ImpersonationRequestListResponse, error) {
+
+	imp, err := s.GetImpersonation(ctx, impersonationID)
+	if err != nil {
+		return nil, err
+	}
+
+	pagination := req.ToPaginationParams()
+	requests, total, err := s.repo.ListImpersonationRequests(ctx, impersonationID, pagination)
+	if err != nil {
+		return nil, err
+	}
+
+	paginatedResult := NewPaginatedResult(requests, total, pagination)
+
+	return &ImpersonationRequestListResponse{
+		Impersonation: imp,
+		Requests:      requests,
+		Total:         paginatedResult.Total,
+		Page:          paginatedResult.Page,
+		PageSize:      paginatedResult.PageSize,
+		TotalPages:    paginatedResult.TotalPages,
+		HasNext:       paginatedResult.HasNext,
+		HasPrev:       paginatedResult.HasPrev,
+	}, nil
+}
+
+// EndImpersonation 提前结束模拟登录并撤销令牌（发起人或超级管理员）
+func (s *Service) EndImpersonation(ctx context.Context, impersonationID string, admin *AdminInfo, ipAddress string) (*UserImpersonation, error) {
+	imp, err := s.GetImpersonation(ctx, impersonationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if imp.AdminID != admin.ID && admin.Role != auth.RoleSuperAdmin {
+		return nil, auth.ErrPermissionDenied
+	}
+
+	if err := s.repo.EndImpersonation(ctx, impersonationID, admin.ID); err != nil {
+		if err.Error() == "impersonation already ended" {
+			return nil, auth.ErrImpersonationEnded
+		}
+		return nil, err
+	}
+
+	// 令牌撤销依赖Redis，未配置时由CheckImpersonation按结束时间拒绝
+	if err := s.jwtManager.RevokeAccessToken(ctx, &auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        imp.TokenID,
+			ExpiresAt: jwt.NewNumericDate(imp.ExpiresAt),
+		},
+	}); err != nil {
+		s.logger.WithError(err).WithField("impersonation_id", impersonationID).Warn("Failed to revoke impersonation token")
+	}
+
+	// 记录管理操作日志
+	details := map[string]interface{}{
+		"impersonation_id": impersonationID,
+		"started_by":       imp.AdminID,
+	}
+	logEntry := &UserManagementLog{
+		AdminID:      admin.ID,
+		AdminEmail:   admin.Email,
+		TargetUserID: imp.UserID,
+		TargetEmail:  imp.UserEmail,
+		Action:       ActionEndImpersonation,
+		Details:      &details,
+		IPAddress:    ipAddress,
+		CreatedAt:    time.Now(),
+	}
+
+	if err := s.repo.CreateManagementLog(ctx, logEntry); err != nil {
+		s.logger.WithError(err).Error("Failed to create management log")
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"impersonation_id": impersonationID,
+		"user_id":          imp.UserID,
+		"admin_id":         admin.ID,
+	}).Info("Impersonation ended")
+
+	return s.GetImpersonation(ctx, impersonationID)
+}
+
+// CheckImpersonation 校验模拟登录会话仍然有效（实现auth.ImpersonationAuditor）
+func (s *Service) CheckImpersonation(ctx context.Context, claims *auth.Claims) error {
+	if claims.ImpersonationID == "" {
+		return auth.ErrImpersonationEnded
+	}
+
+	active, err := s.repo.IsImpersonationActive(ctx, claims.ImpersonationID, claims.ID)
+	if err != nil {
+		return err
+	}
+	if !active {
+		return auth.ErrImpersonationEnded
+	}
+	return nil
+}
+
+// RecordImpersonatedRequest 记录模拟登录令牌发起的请求（实现auth.ImpersonationAuditor）
+func (s *Service) RecordImpersonatedRequest(_ context.Context, req *auth.ImpersonatedRequest) {
+	if req.ImpersonationID == "" {
+		return
+	}
+
+	// 请求结束后上下文可能已取消，使用独立的上下文写入
+	ctx, cancel := context.WithTimeout(context.Background(), impersonationAuditTimeout)
+	defer cancel()
+
+	entry := &ImpersonationRequestLog{
+		ImpersonationID: req.ImpersonationID,
+		Method:          req.Method,
+		Path:            req.Path,
+		StatusCode:      req.StatusCode,
+		Blocked:         req.Blocked,
+		CreatedAt:       req.OccurredAt,
+	}
+	if req.Route != "" {
+		entry.Route = &req.Route
+	}
+	if req.IPAddress != "" {
+		entry.IPAddress = &req.IPAddress
+	}
+	if req.UserAgent != "" {
+		entry.UserAgent = &req.UserAgent
+	}
+
+	if err := s.repo.CreateImpersonationRequest(ctx, entry); err != nil {
+		s.logger.WithError(err).WithFields(logrus.Fields{
+			"impersonation_id": req.ImpersonationID,
+			"admin_id":         req.AdminID,
+			"method":           req.Method,
+			"path":             req.Path,
+		}).Error("Failed to record impersonated request")
+	}
+}
+
+// === 用户导出服务 ===
+
+// ExportUsers 按用户列表的过滤条件导出用户，返回带签名的限时下载链接
+func (s *Service) ExportUsers(ctx context.Context, req *ExportUsersRequest, admin *AdminInfo) (*ExportResponse, error) {
+	columns, err := parseExportFields(req.Fields)
+	if err != nil {
+		return nil, err
+	}
+
+	timezone := req.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, auth.ErrInvalidTimezone
+	}
+
+	format, ext := "csv", "csv"
+	if req.Format == "excel" {
+		format, ext = "excel", "xlsx"
+	}
+
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, fmt.Errorf("failed to generate export file name: %w", err)
+	}
+	generatedAt := time.Now()
+	fileName := fmt.Sprintf("users_export_%s_%s.%s", generatedAt.UTC().Format("20060102150405"), hex.EncodeToString(suffix), ext)
+
+	filter, search, err := s.resolveUserFilter(ctx, &req.GetUsersRequest, admin)
+	if err != nil {
+		return nil, err
+	}
+	pagination := req.ToPaginationParams()
+	count, size, err := writeUserExport(filepath.Join(s.config.ExportDir, fileName), format, columns, loc,
+		func(fn func(*UserManagementModel) error) (int64, error) {
+			return s.repo.StreamUsersForExport(ctx, filter, search, pagination, fn)
+		})
+	if err != nil {
+		return nil, fmt.Errorf("failed to export users: %w", err)
+	}
+
+	expiresAt := generatedAt.Add(s.config.ExportTTL)
+
+	s.logger.WithFields(logrus.Fields{
+		"admin_id":  admin.ID,
+		"file_name": fileName,
+		"records":   count,
+		"format":    format,
+	}).Info("Users exported by admin")
+
+	return &ExportResponse{
+		FileName:    fileName,
+		FileSize:    size,
+		RecordCount: count,
+		DownloadURL: s.exportDownloadURL(fileName, expiresAt),
+		ExpiresAt:   expiresAt,
+		GeneratedAt: generatedAt,
+	}, nil
+}
+
+// exportDownloadURL 生成带签名的限时下载链接
+func (s *Service) exportDownloadURL(fileName string, expiresAt time.Time) string {
+	expires := strconv.FormatInt(expiresAt.Unix(), 10)
+	return exportDownloadPath + fileName + "?expires=" + expires + "&signature=" + s.signExportDownload(fileName, expires)
+}
+
+// signExportDownload 计算下载链接签名
+func (s *Service) signExportDownload(fileName, expires string) string {
+	mac := hmac.New(sha256.New, []byte(s.config.ExportSigningKey))
+	mac.Write([]byte(fileName + "." + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ResolveExportDownload 校验下载链接的签名和有效期，返回导出文件路径
+func (s *Service) ResolveExportDownload(fileName, expires, signature string) (string, error) {
+	if !exportFileNamePattern.MatchString(fileName) {
+		return "", auth.ErrExportLinkInvalid
+	}
+
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return "", auth.ErrExportLinkInvalid
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(s.signExportDownload(fileName, expires))) {
+		return "", auth.ErrExportLinkInvalid
+	}
+
+	path := filepath.Join(s.config.ExportDir, fileName)
+	if _, err := os.Stat(path); err != nil {
+		return "", auth.ErrExportLinkInvalid
+	}
+
+	return path, nil
+}
+
+// CleanupExportFiles 删除下载链接已过期的导出文件
+func (s *Service) CleanupExportFiles() {
+	entries, err := os.ReadDir(s.config.ExportDir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			s.logger.WithError(err).Error("Failed to read user export directory")
+		}
+		return
+	}
+
+	cutoff := time.Now().Add(-s.config.ExportTTL)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasPrefix(entry.Name(), "users_export_") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.config.ExportDir, entry.Name())); err != nil {
+			s.logger.WithError(err).WithField("file_name", entry.Name()).Warn("Failed to remove expired user export")
+		}
+	}
+}
+
+// === 审计日志服务 ===
+
+// managementLogExportHeaders 管理操作日志导出的表头
+var managementLogExportHeaders = []string{
+	"序号", "日志ID", "操作时间", "管理员ID", "管理员邮箱", "目标用户ID", "目标用户邮箱",
+	"操作", "原因", "详细信息", "IP地址", "User-Agent", "上一条Hash", "Hash",
+}
+
+// GetManagementLogs 查询管理操作日志
+func (s *Service) GetManagementLogs(ctx context.Context, req *GetManagementLogsRequest) (*ManagementLogListResponse, error) {
+	pagination := req.ToPaginationParams()
+
+	logs, total, err := s.repo.ListManagementLogs(ctx, req.ToLogFilter(), pagination)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]UserManagementLogResponse, 0, len(logs))
+	for _, log := range logs {
+		responses = append(responses, log.ToResponse())
+	}
+
+	paginatedResult := NewPaginatedResult(responses, total, pagination)
+
+	return &ManagementLogListResponse{
+		Logs:       responses,
+		Total:      paginatedResult.Total,
+		Page:       paginatedResult.Page,
+		PageSize:   paginatedResult.PageSize,
+		TotalPages: paginatedResult.TotalPages,
+		HasNext:    paginatedResult.HasNext,
+		HasPrev:    paginatedResult.HasPrev,
+	}, nil
+}
+
+// GetUserManagementLogs 查询针对某个用户的管理操作日志
+func (s *Service) GetUserManagementLogs(ctx context.Context, userID string, req *GetManagementLogsRequest) (*ManagementLogListResponse, error) {
+	req.TargetUserID = userID
+	return s.GetManagementLogs(ctx, req)
+}
+
+// ExportManagementLogs 按过滤条件将管理操作日志以CSV格式流式写入w，返回导出的行数
+func (s *Service) ExportManagementLogs(ctx context.Context, req *ExportManagementLogsRequest, w io.Writer) (int64, error) {
+	timezone := req.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return 0, auth.ErrInvalidTimezone
+	}
+
+	writer, err := newCSVExportWriter(w)
+	if err != nil {
+		return 0, err
+	}
+	if err := writer.WriteRow(managementLogExportHeaders); err != nil {
+		return 0, err
+	}
+
+	var count int64
+	err = s.repo.StreamManagementLogs(ctx, req.ToLogFilter(), func(log *UserManagementLog) error {
+		var details string
+		if log.Details != nil {
+			if data, err := json.Marshal(log.Details); err == nil {
+				details = string(data)
+			}
+		}
+
+		count++
+		return writer.WriteRow([]string{
+			strconv.FormatInt(log.Seq, 10),
+			log.ID,
+			formatExportTime(&log.CreatedAt, loc),
+			log.AdminID,
+			log.AdminEmail,
+			log.TargetUserID,
+			log.TargetEmail,
+			log.Action.String(),
+			stringValue(log.Reason),
+			details,
+			log.IPAddress,
+			stringValue(log.UserAgent),
+			log.PrevHash,
+			log.Hash,
+		})
+	})
+	if err != nil {
+		return count, err
+	}
+
+	return count, writer.Close()
+}
+
+// VerifyManagementLogChain 按写入顺序校验全部管理操作日志的哈希链，并与检查点对比
+func (s *Service) VerifyManagementLogChain(ctx context.Context) (*LogChainVerificationResponse, error) {
+	// 先读取检查点：校验期间新写入的日志都在检查点之后
+	checkpoint, err := s.repo.GetLogCheckpoint(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	verifier := newLogChainVerifier([]byte(s.config.AuditLogKey), checkpoint)
+	if err := s.repo.StreamLogChain(ctx, verifier.check); err != nil {
+		return nil, err
+	}
+	verifier.finish()
+
+	issues := verifier.issues
+	if issues == nil {
+		issues = []LogChainIssue{}
+	}
+
+	result := &LogChainVerificationResponse{
+		Valid:          verifier.total == 0,
+		CheckedEntries: verifier.checked,
+		FirstSeq:       verifier.firstSeq,
+		LastSeq:        verifier.lastSeq,
+		LastHash:       verifier.lastHash,
+		Checkpoint:     checkpoint,
+		IssueCount:     verifier.total,
+		Issues:         issues,
+		VerifiedAt:     time.Now(),
+	}
+
+	if !result.Valid {
+		s.logger.WithFields(logrus.Fields{
+			"checked_entries": result.CheckedEntries,
+			"issue_count":     result.IssueCount,
+		}).Error("Management log hash chain verification failed")
+	}
+
+	return result, nil
+}
+
+// stringValue 返回字符串指针的值，nil时返回空字符串
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// === 批量操作服务 ===
+
+// PreviewBatchJob 预览批量操作影响的用户（dry_run，不创建任务）
+func (s *Service) PreviewBatchJob(ctx context.Context, action BatchAction, target *BatchTargetRequest,
+	params interface{}, admin *AdminInfo) (*BatchPreviewResponse, error) {
+
+	if err := validateBatchParams(params); err != nil {
+		return nil, err
+	}
+
+	targets, missingIDs, err := s.resolveBatchTargets(ctx, target, admin)
+	if err != nil {
+		return nil, err
+	}
+
+	sample := targets
+	if len(sample) > batchPreviewSampleSize {
+		sample = sample[:batchPreviewSampleSize]
+	}
+
+	return &BatchPreviewResponse{
+		Action:            action,
+		TotalUsers:        len(targets),
+		NotFoundIDs:       missingIDs,
+		RequiresApproval:  s.batchRequiresApproval(len(targets), admin.Role),
+		ApprovalThreshold: s.config.BatchApprovalThreshold,
+		MaxUsers:          s.config.BatchMaxUsers,
+		Sample:            sample,
+	}, nil
+}
+
+// CreateBatchJob 创建批量操作任务，params为单个用户操作的请求参数
+// 目标用户在创建时确定；超过审批阈值且提交人不是超级管理员时，需超级管理员审批后才会执行
+func (s *Service) CreateBatchJob(ctx context.Context, action BatchAction, target *BatchTargetRequest,
+	params interface{}, admin *AdminInfo, ipAddress string) (*BatchJobResponse, error) {
+
+	if err := validateBatchParams(params); err != nil {
+		return nil, err
+	}
+
+	targets, missingIDs, err := s.resolveBatchTargets(ctx, target, admin)
+	if err != nil {
+		return nil, err
+	}
+	if len(targets) == 0 {
+		return nil, auth.ErrBatchNoTargets
+	}
+
+	paramsJSON, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal batch params: %w", err)
+	}
+
+	job := &BatchJob{
+		Action:         action,
+		Params:         paramsJSON,
+		TargetType:     BatchTargetIDs,
+		Status:         BatchJobQueued,
+		TotalCount:     len(targets) + len(missingIDs),
+		ProcessedCount: len(missingIDs),
+		FailedCount:    len(missingIDs),
+		CreatedBy:      admin.ID,
+		CreatedByEmail: admin.Email,
+		IPAddress:      &ipAddress,
+	}
+	if target.Filter != nil {
+		job.TargetType = BatchTargetFilter
+		if job.TargetFilter, err = json.Marshal(target.Filter); err != nil {
+			return nil, fmt.Errorf("failed to marshal batch filter: %w", err)
+		}
+	}
+	if s.batchRequiresApproval(len(targets), admin.Role) {
+		job.Status = BatchJobAwaitingApproval
+	}
+
+	if err := s.repo.CreateBatchJob(ctx, job, targets, missingIDs); err != nil {
+		return nil, err
+	}
+
+	if job.Status == BatchJobQueued {
+		s.wakeBatchWorker()
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"job_id":      job.ID,
+		"action":      action,
+		"admin_id":    admin.ID,
+		"total_users": job.TotalCount,
+		"status":      job.Status,
+	}).Info("User batch job created")
+
+	response := job.ToBatchJobResponse()
+	return &response, nil
+}
+
+// resolveBatchTargets 确定批量操作的目标用户，按ID提交时同时返回不存在或已注销的ID
+func (s *Service) resolveBatchTargets(ctx context.Context, target *BatchTargetRequest, admin *AdminInfo) ([]*BatchTarget, []string, error) {
+	hasIDs := len(target.UserIDs) > 0
+	if hasIDs == (target.Filter != nil) {
+		return nil, nil, auth.ErrInvalidBatchTarget
+	}
+
+	if target.Filter != nil {
+		filter, search, err := s.resolveUserFilter(ctx, target.Filter, admin)
+		if err != nil {
+			return nil, nil, err
+		}
+		// 任务记录实际使用的查询条件（保存的搜索之后可能被修改）
+		target.Filter.Query = filter.Query
+
+		targets, err := s.repo.ListBatchTargets(ctx, filter, search, s.config.BatchMaxUsers+1)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(targets) > s.config.BatchMaxUsers {
+			return nil, nil, auth.ErrBatchTooManyUsers
+		}
+		return targets, nil, nil
+	}
+
+	// 去重（UUID不区分大小写）
+	seen := make(map[string]bool, len(target.UserIDs))
+	userIDs := make([]string, 0, len(target.UserIDs))
+	for _, userID := range target.UserIDs {
+		userID = strings.ToLower(userID)
+		if !seen[userID] {
+			seen[userID] = true
+			userIDs = append(userIDs, userID)
+		}
+	}
+	if len(userIDs) > s.config.BatchMaxUsers {
+		return nil, nil, auth.ErrBatchTooManyUsers
+	}
+
+	targets, err := s.repo.GetBatchTargetsByIDs(ctx, userIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	found := make(map[string]bool, len(targets))
+	for _, t := range targets {
+		found[t.UserID] = true
+	}
+	missingIDs := make([]string, 0)
+	for _, userID := range userIDs {
+		if !found[userID] {
+			missingIDs = append(missingIDs, userID)
+		}
+	}
+
+	return targets, missingIDs, nil
+}
+
+// batchRequiresApproval 批量操作是否需要超级管理员审批
+func (s *Service) batchRequiresApproval(userCount int, role string) bool {
+	return role != auth.RoleSuperAdmin && userCount > s.config.BatchApprovalThreshold
+}
+
+// validateBatchParams 在创建任务前校验单个用户操作的参数
+func validateBatchParams(params interface{}) error {
+	if req, ok := params.(*SuspendUserRequest); ok {
+		if _, err := suspensionEnd(req, time.Now()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetBatchJob 获取批量操作任务（含进度）
+func (s *Service) GetBatchJob(ctx context.Context, jobID string) (*BatchJobResponse, error) {
+	job, err := s.repo.GetBatchJob(ctx, jobID)
+	if err != nil {
+		if err.Error() == "batch job not found" {
+			return nil, auth.ErrBatchJobNotFound
+		}
+		return nil, err
+	}
+
+	response := job.ToBatchJobResponse()
+	return &response, nil
+}
+
+// ListBatchJobs 获取批量操作任务列表
+func (s *Service) ListBatchJobs(ctx context.Context, req *ListBatchJobsRequest) (*BatchJobListResponse, error) {
+	pagination := req.ToPaginationParams()
+
+	jobs, total, err := s.repo.ListBatchJobs(ctx, req.Status, pagination)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]BatchJobResponse, 0, len(jobs))
+	for _, job := range jobs {
+		responses = append(responses, job.ToBatchJobResponse())
+	}
+
+	paginatedResult := NewPaginatedResult(responses, total, pagination)
+
+	return &BatchJobListResponse{
+		Jobs:       responses,
+		Total:      paginatedResult.Total,
+		Page:       paginatedResult.Page,
+		PageSize:   paginatedResult.PageSize,
+		TotalPages: paginatedResult.TotalPages,
+		HasNext:    paginatedResult.HasNext,
+		HasPrev:    paginatedResult.HasPrev,
+	}, nil
+}
+
+// ListBatchJobItems 获取批量操作中每个用户的执行结果
+func (s *Service) ListBatchJobItems(ctx context.Context, jobID string, req *ListBatchJobItemsRequest) (*BatchJobItemListResponse, error) {
+	if _, err := s.GetBatchJob(ctx, jobID); err != nil {
+		return nil, err
+	}
+
+	pagination := req.ToPaginationParams()
+
+	items, total, err := s.repo.ListBatchJobItems(ctx, jobID, req.Status, pagination)
+	if err != nil {
+		return nil, err
+	}
+
+	paginatedResult := NewPaginatedResult(items, total, pagination)
+
+	return &BatchJobItemListResponse{
+		JobID:      jobID,
+		Items:      items,
+		Total:      paginatedResult.Total,
+		Page:       paginatedResult.Page,
+		PageSize:   paginatedResult.PageSize,
+		TotalPages: paginatedResult.TotalPages,
+		HasNext:    paginatedResult.HasNext,
+		HasPrev:    paginatedResult.HasPrev,
+	}, nil
+}
+
+// ApproveBatchJob 超级管理员审批批量操作任务
+func (s *Service) ApproveBatchJob(ctx context.Context, jobID string, admin *AdminInfo) (*BatchJobResponse, error) {
+	if _, err := s.GetBatchJob(ctx, jobID); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.ApproveBatchJob(ctx, jobID, admin.ID); err != nil {
+		if err.Error() == "batch job not awaiting approval" {
+			return nil, auth.ErrBatchJobNotAwaitingApproval
+		}
+		return nil, err
+	}
+
+	s.wakeBatchWorker()
+
+	s.logger.WithFields(logrus.Fields{
+		"job_id":   jobID,
+		"admin_id": admin.ID,
+	}).Info("User batch job approved")
+
+	return s.GetBatchJob(ctx, jobID)
+}
+
+// CancelBatchJob 取消批量操作任务（提交人或超级管理员），已执行的用户不会回滚
+func (s *Service) CancelBatchJob(ctx context.Context, jobID string, admin *AdminInfo) (*BatchJobResponse, error) {
+	job, err := s.GetBatchJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	if job.CreatedBy != admin.ID && admin.Role != auth.RoleSuperAdmin {
+		return nil, auth.ErrPermissionDenied
+	}
+
+	if err := s.repo.CancelBatchJob(ctx, jobID, admin.ID); err != nil {
+		switch err.Error() {
+		case "batch job not found":
+			return nil, auth.ErrBatchJobNotFound
+		case "batch job already finished":
+			return nil, auth.ErrBatchJobNotCancellable
+		}
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"job_id":   jobID,
+		"admin_id": admin.ID,
+	}).Info("User batch job cancelled")
+
+	return s.GetBatchJob(ctx, jobID)
+}
+
+// wakeBatchWorker 唤醒后台任务立即执行批量操作
+func (s *Service) wakeBatchWorker() {
+	select {
+	case s.batchWake <- struct{}{}:
+	default:
+	}
+}
+
+// === 后台任务 ===
+
+// Start 启动后台任务：定期解除已到期的暂停、清理过期导出文件、执行批量操作任务
+func (s *Service) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(suspensionCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				runCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+				s.LiftExpiredSuspensions(runCtx)
+				cancel()
+				s.CleanupExportFiles()
+			}
+		}
+	}()
+
+	// 批量操作任务可能运行较长时间，使用单独的协程避免阻塞到期暂停的解除
+	go func() {
+		ticker := time.NewTicker(batchCheckInterval)
+		defer ticker.Stop()
+
+		s.ProcessBatchJobs(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.ProcessBatchJobs(ctx)
+			case <-s.batchWake:
+				s.ProcessBatchJobs(ctx)
+			}
+		}
+	}()
+
+	// 统计汇总首次运行会汇总全部历史数据，同样使用单独的协程
+	go func() {
+		interval := s.config.StatsRollupInterval
+		if interval <= 0 {
+			interval = defaultStatisticsRollupInterval
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			runCtx, cancel := context.WithTimeout(ctx, statisticsRollupTimeout)
+			s.RefreshStatisticsRollups(runCtx)
+			cancel()
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// RefreshStatisticsRollups 刷新所有配置时区的统计汇总表
+func (s *Service) RefreshStatisticsRollups(ctx context.Context) {
+	for _, timezone := range s.config.StatsTimezones {
+		if _, _, err := resolveStatisticsTimezone(timezone); err != nil {
+			s.logger.WithField("timezone", timezone).Warn("Skipping statistics rollup for unknown timezone")
+			continue
+		}
+
+		started := time.Now()
+		refreshed, err := s.repo.RefreshStatisticsRollups(ctx, timezone, maxRetentionWeeks)
+		if err != nil {
+			s.logger.WithError(err).WithField("timezone", timezone).Error("Failed to refresh statistics rollups")
+			continue
+		}
+		if !refreshed {
+			continue
+		}
+
+		s.logger.WithFields(logrus.Fields{
+			"timezone": timezone,
+			"duration": time.Since(started).String(),
+		}).Debug("Statistics rollups refreshed")
+	}
+}
+
+// LiftExpiredSuspensions 解除已到期的暂停并记录管理操作日志
+func (s *Service) LiftExpiredSuspensions(ctx context.Context) {
+	suspensions, err := s.repo.ListDueSuspensions(ctx, suspensionBatchSize)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to list expired suspensions")
+		return
+	}
+
+	for _, suspension := range suspensions {
+		if err := s.repo.LiftExpiredSuspension(ctx, suspension); err != nil {
+			if err.Error() != "suspension not found" {
+				s.logger.WithError(err).WithField("user_id", suspension.UserID).Error("Failed to lift expired suspension")
+			}
+			continue
+		}
+
+		targetEmail := ""
+		if targetUser, err := s.repo.GetUserByID(ctx, suspension.UserID); err == nil {
+			targetEmail = targetUser.Email
+		}
+
+		reason := "Suspension expired"
+		details := map[string]interface{}{
+			"suspension_id": suspension.ID,
+			"ends_at":       suspension.EndsAt,
+		}
+		logEntry := &UserManagementLog{
+			AdminID:      systemActor,
+			AdminEmail:   systemActor,
+			TargetUserID: suspension.UserID,
+			TargetEmail:  targetEmail,
+			Action:       ActionUnsuspend,
+			Reason:       &reason,
+			Details:      &details,
+			IPAddress:    "127.0.0.1",
+			CreatedAt:    time.Now(),
+		}
+		if err := s.repo.CreateManagementLog(ctx, logEntry); err != nil {
+			s.logger.WithError(err).Error("Failed to create management log")
+		}
+
+		s.logger.WithFields(logrus.Fields{
+			"user_id":       suspension.UserID,
+			"suspension_id": suspension.ID,
+		}).Info("Expired user suspension lifted")
+	}
+}
+
+// ProcessBatchJobs 依次执行待执行的批量操作任务
+func (s *Service) ProcessBatchJobs(ctx context.Context) {
+	if err := s.repo.FinishAbandonedBatchJobs(ctx, batchJobStaleAfter); err != nil {
+		s.logger.WithError(err).Error("Failed to finish abandoned batch jobs")
+	}
+
+	for ctx.Err() == nil {
+		job, err := s.repo.ClaimBatchJob(ctx, batchJobStaleAfter)
+		if err != nil {
+			s.logger.WithError(err).Error("Failed to claim batch job")
+			return
+		}
+		if job == nil {
+			return
+		}
+
+		s.runBatchJob(ctx, job)
+	}
+}
+
+// runBatchJob 逐个用户执行批量操作，每个用户执行前检查任务是否已被取消
+// 中途退出时任务保持执行中状态，超时后会被重新领取并继续处理剩余用户
+func (s *Service) runBatchJob(ctx context.Context, job *BatchJob) {
+	logger := s.logger.WithFields(logrus.Fields{
+		"job_id": job.ID,
+		"action": job.Action,
+	})
+	logger.Info("User batch job started")
+
+processing:
+	for ctx.Err() == nil {
+		items, err := s.repo.ListPendingBatchJobItems(ctx, job.ID, batchItemChunkSize)
+		if err != nil {
+			logger.WithError(err).Error("Failed to get pending batch job items")
+			return
+		}
+		if len(items) == 0 {
+			break
+		}
+
+		for _, item := range items {
+			status, err := s.repo.GetBatchJobStatus(ctx, job.ID)
+			if err != nil {
+				logger.WithError(err).Error("Failed to get batch job status")
+				return
+			}
+			if status != BatchJobRunning {
+				break processing
+			}
+
+			itemCtx, cancel := context.WithTimeout(ctx, batchItemTimeout)
+			itemStatus, message := s.executeBatchItem(itemCtx, job, item)
+			cancel()
+
+			if err := s.repo.CompleteBatchJobItem(ctx, item, itemStatus, message); err != nil {
+				logger.WithError(err).WithField("user_id", item.UserID).Error("Failed to record batch job item result")
+				return
+			}
+		}
+	}
+	if ctx.Err() != nil {
+		return
+	}
+
+	if err := s.repo.FinishBatchJob(ctx, job.ID); err != nil {
+		logger.WithError(err).Error("Failed to finish batch job")
+		return
+	}
+
+	logger.Info("User batch job finished")
+}
+
+// executeBatchItem 对单个用户执行批量操作，复用单用户操作以便每个用户都有独立的管理操作日志
+func (s *Service) executeBatchItem(ctx context.Context, job *BatchJob, item *BatchJobItem) (BatchItemStatus, *string) {
+	targetUser, err := s.repo.GetUserByID(ctx, item.UserID)
+	if err != nil {
+		if err.Error() != "user not found" {
+			s.logger.WithError(err).WithField("user_id", item.UserID).Error("Failed to get batch target user")
+		}
+		return BatchItemFailed, batchItemMessage(err)
+	}
+
+	ipAddress := "127.0.0.1"
+	if job.IPAddress != nil {
+		ipAddress = *job.IPAddress
+	}
+
+	switch job.Action {
+	case BatchActionUpdateStatus:
+		var req UpdateUserStatusRequest
+		if err := json.Unmarshal(job.Params, &req); err != nil {
+			return BatchItemFailed, batchItemMessage(err)
+		}
+		if targetUser.Status == req.Status && req.Status != user.UserStatusSuspended.String() {
+			message := fmt.Sprintf("User status is already %s", req.Status)
+			return BatchItemSkipped, &message
+		}
+		_, err = s.UpdateUserStatus(ctx, item.UserID, job.CreatedBy, job.CreatedByEmail, ipAddress, &req)
+
+	case BatchActionSuspend:
+		var req SuspendUserRequest
+		if err := json.Unmarshal(job.Params, &req); err != nil {
+			return BatchItemFailed, batchItemMessage(err)
+		}
+		_, err = s.SuspendUser(ctx, item.UserID, job.CreatedBy, job.CreatedByEmail, ipAddress, &req)
+
+	case BatchActionReactivate:
+		if targetUser.Status == user.UserStatusActive.String() {
+			message := "User is already active"
+			return BatchItemSkipped, &message
+		}
+		_, err = s.ReactivateUser(ctx, item.UserID, job.CreatedBy, job.CreatedByEmail, ipAddress)
+
+	case BatchActionForceLogout:
+		var req ForceLogoutRequest
+		if err := json.Unmarshal(job.Params, &req); err != nil {
+			return BatchItemFailed, batchItemMessage(err)
+		}
+		if sessions, err := s.repo.GetUserSessions(ctx, item.UserID); err == nil && len(sessions) == 0 {
+			message := "User has no active sessions"
+			return BatchItemSkipped, &message
+		}
+		_, err = s.ForceLogoutUser(ctx, item.UserID, job.CreatedBy, job.CreatedByEmail, ipAddress, &req)
+
+	default:
+		err = fmt.Errorf("unsupported batch action: %s", job.Action)
+	}
+
+	if err != nil {
+		s.logger.WithError(err).WithFields(logrus.Fields{
+			"job_id":  job.ID,
+			"user_id": item.UserID,
+		}).Warn("Batch operation failed for user")
+		return BatchItemFailed, batchItemMessage(err)
+	}
+
+	return BatchItemSucceeded, nil
+}
+
+// batchItemMessage 将单个用户的执行错误转换为可展示的原因
+func batchItemMessage(err error) *string {
+	message := "Operation failed"
+	switch {
+	case errors.Is(err, auth.ErrUserNotFound), strings.Contains(err.Error(), "user not found"):
+		message = "User not found"
+	case errors.Is(err, auth.ErrInvalidSuspensionPeriod):
+		message = "The suspension end time must be in the future"
+	}
+	return &message
+}
+
+// ValidateUserExists 验证用户是否存在
+func (s *Service) ValidateUserExists(ctx context.Context, userID string) error {
+	_, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return auth.ErrUserNotFound
+	}
+	return nil
+}
+
+// revokeUserTokens 撤销用户所有令牌（失败只记录日志）
+func (s *Service) revokeUserTokens(ctx context.Context, userID string) {
+	if err := s.jwtManager.RevokeAllUserTokens(ctx, userID, "user"); err != nil {
+		s.logger.WithError(err).WithField("user_id", userID).Warn("Failed to revoke user tokens")
 	}
-	return nil
 }
@@ -0,0 +1,156 @@
+package user_management
+
+import (
+	"time"
+
+	"trusioo_api_v0.0.1/internal/modules/auth"
+)
+
+const (
+	// statisticsDateLayout 统计接口的日期格式
+	statisticsDateLayout = "2006-01-02"
+	// maxStatisticsBuckets 单次查询最多返回的时间桶数
+	maxStatisticsBuckets = 366
+	// maxRetentionCohorts 单次查询最多返回的注册周数
+	maxRetentionCohorts = 53
+	// maxRetentionWeeks 留存统计最多跟踪的周数（汇总表按此保存）
+	maxRetentionWeeks = 12
+)
+
+// 统计指标
+const (
+	StatisticsMetricRegistration = "registration"
+	StatisticsMetricVerification = "verification"
+	StatisticsMetricActivity     = "activity"
+)
+
+// 统计数据来源
+const (
+	statisticsSourceRollup = "rollup"
+	statisticsSourceLive   = "live"
+)
+
+// defaultStatisticsBuckets 未指定开始日期时各粒度默认返回的时间桶数
+var defaultStatisticsBuckets = map[string]int{
+	StatisticsPeriodDay:   30,
+	StatisticsPeriodWeek:  12,
+	StatisticsPeriodMonth: 12,
+}
+
+// truncateToBucket 将日期截断到所在时间桶的开始（周以周一开始，与PostgreSQL的date_trunc一致）
+// 日期统一用UTC的零点表示某个时区下的自然日
+func truncateToBucket(date time.Time, period string) time.Time {
+	date = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	switch period {
+	case StatisticsPeriodWeek:
+		return date.AddDate(0, 0, -((int(date.Weekday()) + 6) % 7))
+	case StatisticsPeriodMonth:
+		return date.AddDate(0, 0, 1-date.Day())
+	default:
+		return date
+	}
+}
+
+// nextBucket 下一个时间桶的开始
+func nextBucket(bucket time.Time, period string) time.Time {
+	switch period {
+	case StatisticsPeriodWeek:
+		return bucket.AddDate(0, 0, 7)
+	case StatisticsPeriodMonth:
+		return bucket.AddDate(0, 1, 0)
+	default:
+		return bucket.AddDate(0, 0, 1)
+	}
+}
+
+// resolveStatisticsTimezone 解析统计使用的时区，默认UTC
+func resolveStatisticsTimezone(name string) (string, *time.Location, error) {
+	if name == "" {
+		name = "UTC"
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil || name == "Local" {
+		return "", nil, auth.ErrInvalidTimezone
+	}
+	return name, loc, nil
+}
+
+// localToday 指定时区的当天日期
+func localToday(loc *time.Location) time.Time {
+	now := time.Now().In(loc)
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// statisticsRange 解析统计的日期范围，返回第一个时间桶的开始和最后一个时间桶的结束（不包含）
+// 未指定结束日期时为当天，未指定开始日期时按粒度取默认的时间桶数
+func statisticsRange(dateFrom, dateTo, period string, loc *time.Location, maxBuckets int) (time.Time, time.Time, error) {
+	to := localToday(loc)
+	if dateTo != "" {
+		parsed, err := time.Parse(statisticsDateLayout, dateTo)
+		if err != nil {
+			return time.Time{}, time.Time{}, auth.ErrInvalidStatisticsRange
+		}
+		to = parsed
+	}
+	end := nextBucket(truncateToBucket(to, period), period)
+
+	var start time.Time
+	if dateFrom != "" {
+		parsed, err := time.Parse(statisticsDateLayout, dateFrom)
+		if err != nil {
+			return time.Time{}, time.Time{}, auth.ErrInvalidStatisticsRange
+		}
+		start = truncateToBucket(parsed, period)
+	} else {
+		start = end
+		for i := 0; i < defaultStatisticsBuckets[period]; i++ {
+			start = previousBucket(start, period)
+		}
+	}
+
+	if !start.Before(end) || countBuckets(start, end, period) > maxBuckets {
+		return time.Time{}, time.Time{}, auth.ErrInvalidStatisticsRange
+	}
+
+	return start, end, nil
+}
+
+// previousBucket 上一个时间桶的开始
+func previousBucket(bucket time.Time, period string) time.Time {
+	switch period {
+	case StatisticsPeriodWeek:
+		return bucket.AddDate(0, 0, -7)
+	case StatisticsPeriodMonth:
+		return bucket.AddDate(0, -1, 0)
+	default:
+		return bucket.AddDate(0, 0, -1)
+	}
+}
+
+// countBuckets 计算[start, end)内的时间桶数
+func countBuckets(start, end time.Time, period string) int {
+	count := 0
+	for bucket := start; bucket.Before(end); bucket = nextBucket(bucket, period) {
+		count++
+	}
+	return count
+}
+
+// fillStatisticsBuckets 按时间顺序补齐没有数据的时间桶
+func fillStatisticsBuckets(buckets []*StatisticsBucket, start, end time.Time, period string) []*StatisticsBucket {
+	byStart := make(map[string]*StatisticsBucket, len(buckets))
+	for _, bucket := range buckets {
+		byStart[bucket.BucketStart.Format(statisticsDateLayout)] = bucket
+	}
+
+	filled := make([]*StatisticsBucket, 0, countBuckets(start, end, period))
+	for bucketStart := start; bucketStart.Before(end); bucketStart = nextBucket(bucketStart, period) {
+		if bucket, ok := byStart[bucketStart.Format(statisticsDateLayout)]; ok {
+			filled = append(filled, bucket)
+			continue
+		}
+		filled = append(filled, &StatisticsBucket{BucketStart: bucketStart})
+	}
+
+	return filled
+}
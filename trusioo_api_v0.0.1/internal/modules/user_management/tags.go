@@ -0,0 +1,64 @@
+package user_management
+
+import (
+	"regexp"
+	"strings"
+
+	"trusioo_api_v0.0.1/internal/modules/auth"
+)
+
+const (
+	// maxUserTags 单个用户最多的标签数
+	maxUserTags = 20
+	// maxFilterTags 用户列表最多按多少个标签过滤
+	maxFilterTags = 10
+	// recentNotesLimit 用户详情中返回的最近备注数
+	recentNotesLimit = 5
+)
+
+// userTagPattern 标签格式：小写字母、数字开头，可包含-和_，最长32个字符
+var userTagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// normalizeUserTag 统一标签格式（去空格、转小写）并校验
+func normalizeUserTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if !userTagPattern.MatchString(tag) {
+		return "", auth.ErrInvalidUserTag
+	}
+	return tag, nil
+}
+
+// normalizeUserTags 统一并去重一组标签，有任一无效时返回错误
+func normalizeUserTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		t, err := normalizeUserTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[t] {
+			seen[t] = true
+			normalized = append(normalized, t)
+		}
+	}
+	return normalized, nil
+}
+
+// parseTagList 解析逗号分隔的过滤标签（格式无效的标签不可能匹配任何用户，原样保留）
+func parseTagList(value string) []string {
+	seen := make(map[string]bool)
+	tags := make([]string, 0)
+	for _, part := range strings.Split(value, ",") {
+		tag := strings.ToLower(strings.TrimSpace(part))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+		if len(tags) == maxFilterTags {
+			break
+		}
+	}
+	return tags
+}
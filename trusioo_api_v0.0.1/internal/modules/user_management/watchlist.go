@@ -0,0 +1,73 @@
+package user_management
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"trusioo_api_v0.0.1/internal/modules/auth"
+)
+
+// watchNotifyTimeout 处理一次用户活动通知（查询关注、写入通知、发送邮件）的超时时间
+const watchNotifyTimeout = 30 * time.Second
+
+// watchEventNames 通知邮件中的事件名称
+var watchEventNames = map[string]string{
+	auth.UserActivityLogin:            "logged in",
+	auth.UserActivityBankAccountAdded: "added a bank account",
+}
+
+// normalizeWatchEvents 校验并去重关注的事件，为空时关注全部事件
+func normalizeWatchEvents(events []string) ([]string, error) {
+	if len(events) == 0 {
+		return append([]string{}, auth.UserActivityEvents...), nil
+	}
+
+	seen := make(map[string]bool, len(events))
+	normalized := make([]string, 0, len(events))
+	for _, event := range events {
+		if _, ok := watchEventNames[event]; !ok {
+			return nil, auth.ErrInvalidWatchEvent
+		}
+		if !seen[event] {
+			seen[event] = true
+			normalized = append(normalized, event)
+		}
+	}
+	return normalized, nil
+}
+
+// watchNotificationMessage 生成关注通知邮件的标题和正文
+func watchNotificationMessage(notification *WatchNotification, watch *UserWatch) (string, string) {
+	eventName := watchEventNames[notification.Event]
+	if eventName == "" {
+		eventName = notification.Event
+	}
+
+	subject := fmt.Sprintf("Watched user %s %s", notification.UserEmail, eventName)
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "A user on your watchlist %s.\n\n", eventName)
+	fmt.Fprintf(&body, "User: %s (%s)\n", notification.UserEmail, notification.UserID)
+	fmt.Fprintf(&body, "Time: %s\n", notification.OccurredAt.UTC().Format(time.RFC3339))
+	if notification.IPAddress != nil {
+		fmt.Fprintf(&body, "IP address: %s\n", *notification.IPAddress)
+	}
+
+	keys := make([]string, 0, len(notification.Details))
+	for key := range notification.Details {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(&body, "%s: %v\n", key, notification.Details[key])
+	}
+
+	if watch.Reason != nil && *watch.Reason != "" {
+		fmt.Fprintf(&body, "\nWatch reason: %s\n", *watch.Reason)
+	}
+	body.WriteString("\nYou are receiving this email because you are watching this user in the admin console.")
+
+	return subject, body.String()
+}
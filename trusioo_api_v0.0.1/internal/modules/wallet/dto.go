@@ -346,8 +346,8 @@ func (w *Wallet) ToWalletResponse() *WalletResponse {
 		IsWithdrawalEnabled:  w.IsWithdrawalEnabled,
 		HasTransactionPin:    w.TransactionPinHash != nil,
 		DailyWithdrawalLimit: w.DailyWithdrawalLimit,
-		DailyWithdrawnAmount: w.DailyWithdrawnAmount,
-		RemainingDailyLimit:  w.DailyWithdrawalLimit - w.DailyWithdrawnAmount,
+		DailyWithdrawnAmount: w.WithdrawnToday(),
+		RemainingDailyLimit:  w.DailyWithdrawalLimit - w.WithdrawnToday(),
 		WithdrawalCount:      w.WithdrawalCount,
 		TotalDeposited:       w.TotalDeposited,
 		TotalWithdrawn:       w.TotalWithdrawn,
@@ -2,9 +2,12 @@ package wallet
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"time"
 
+	"trusioo_api_v0.0.1/internal/modules/auth"
+
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 )
@@ -441,6 +444,9 @@ func (h *Handler) CalculateWithdrawal(c *gin.Context) {
 	defer cancel()
 
 	calculation, err := h.service.CalculateWithdrawal(ctx, userID, &req)
+	if h.respondWithdrawalLimitError(c, err) {
+		return
+	}
 	if err != nil {
 		h.logger.WithError(err).WithField("user_id", userID).Error("Failed to calculate withdrawal")
 		h.respondError(c, http.StatusInternalServerError, "Internal server error", "Failed to calculate withdrawal")
@@ -469,6 +475,9 @@ func (h *Handler) CreateWithdrawal(c *gin.Context) {
 	defer cancel()
 
 	withdrawal, err := h.service.CreateWithdrawalRequest(ctx, userID, &req)
+	if h.respondWithdrawalLimitError(c, err) {
+		return
+	}
 	if err != nil {
 		h.logger.WithError(err).WithField("user_id", userID).Error("Failed to create withdrawal")
 		h.respondError(c, http.StatusInternalServerError, "Internal server error", "Failed to create withdrawal")
@@ -625,6 +634,19 @@ func (h *Handler) respondError(c *gin.Context, statusCode int, error, message st
 	c.JSON(statusCode, response)
 }
 
+// respondWithdrawalLimitError 提现开关和每日限额错误的响应，已响应时返回true
+func (h *Handler) respondWithdrawalLimitError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, auth.ErrWithdrawalNotEnabled):
+		h.respondError(c, http.StatusForbidden, "Withdrawal not enabled", "Please complete identity verification before withdrawing")
+	case errors.Is(err, auth.ErrDailyWithdrawalLimitExceeded):
+		h.respondError(c, http.StatusForbidden, "Daily limit exceeded", "This withdrawal exceeds your daily withdrawal limit, verify your identity at a higher level to increase it")
+	default:
+		return false
+	}
+	return true
+}
+
 // respondSuccess 响应成功
 func (h *Handler) respondSuccess(c *gin.Context, message string, data interface{}) {
 	response := OperationResponse{
@@ -418,13 +418,23 @@ func (w *Wallet) CanWithdrawAmount(amount float64) bool {
 	}
 
 	// 检查每日限额
-	if w.DailyWithdrawnAmount+amount > w.DailyWithdrawalLimit {
+	if w.WithdrawnToday()+amount > w.DailyWithdrawalLimit {
 		return false
 	}
 
 	return true
 }
 
+// WithdrawnToday 获取今日（UTC）已提现金额，上次重置不在今天时视为0
+func (w *Wallet) WithdrawnToday() float64 {
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if w.LastWithdrawalReset.Before(today) {
+		return 0
+	}
+	return w.DailyWithdrawnAmount
+}
+
 // IsExpired 检查提现申请是否已过期
 func (wr *WithdrawalRequest) IsExpired() bool {
 	return time.Now().After(wr.ExpiresAt)
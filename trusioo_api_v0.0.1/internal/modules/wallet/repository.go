@@ -21,6 +21,7 @@ type Repository interface {
 	UpdateWallet(ctx context.Context, wallet *Wallet) error
 	SetTransactionPin(ctx context.Context, userID, pinHash string) error
 	VerifyTransactionPin(ctx context.Context, userID, pinHash string) error
+	UpdateWithdrawalLimits(ctx context.Context, userID string, enabled bool, dailyLimit float64) error
 
 	// 货币相关
 	GetCurrencies(ctx context.Context, isActive bool) ([]*Currency, error)
@@ -198,7 +199,7 @@ func (r *repository) UpdateWallet(ctx context.Context, wallet *Wallet) error {
 func (r *repository) SetTransactionPin(ctx context.Context, userID, pinHash string) error {
 	query := `
 		UPDATE wallets SET
-			transaction_pin_hash = $2, is_withdrawal_enabled = true,
+			transaction_pin_hash = $2,
 			pin_attempts = 0, pin_locked_until = NULL, updated_at = NOW()
 		WHERE user_id = $1`
 
@@ -220,6 +221,31 @@ func (r *repository) SetTransactionPin(ctx context.Context, userID, pinHash stri
 	return nil
 }
 
+// UpdateWithdrawalLimits 更新提现开关和每日提现限额（由KYC等级决定）
+func (r *repository) UpdateWithdrawalLimits(ctx context.Context, userID string, enabled bool, dailyLimit float64) error {
+	query := `
+		UPDATE wallets SET
+			is_withdrawal_enabled = $2, daily_withdrawal_limit = $3, updated_at = NOW()
+		WHERE user_id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, userID, enabled, dailyLimit)
+	if err != nil {
+		r.logger.WithError(err).WithField("user_id", userID).Error("Failed to update withdrawal limits")
+		return fmt.Errorf("failed to update withdrawal limits: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("wallet not found for user %s", userID)
+	}
+
+	return nil
+}
+
 // VerifyTransactionPin 验证交易密码
 func (r *repository) VerifyTransactionPin(ctx context.Context, userID, pin string) error {
 	query := `
@@ -62,28 +62,28 @@ func (r *Routes) registerUserRoutes(group *gin.RouterGroup) {
 		// 获取钱包信息
 		user.GET("", r.handler.GetWallet)
 
-		// 交易密码管理
-		user.POST("/transaction-pin", r.handler.SetTransactionPin)
-		user.PUT("/transaction-pin", r.handler.ChangeTransactionPin)
+		// 交易密码管理（管理员模拟登录时禁止）
+		user.POST("/transaction-pin", r.authMiddle.DenyImpersonation(), r.handler.SetTransactionPin)
+		user.PUT("/transaction-pin", r.authMiddle.DenyImpersonation(), r.handler.ChangeTransactionPin)
 
 		// === 银行账户管理 ===
 
-		// 银行账户CRUD
+		// 银行账户CRUD（修改操作在管理员模拟登录时禁止）
 		user.GET("/bank-accounts", r.handler.GetBankAccounts)
-		user.POST("/bank-accounts", r.handler.AddBankAccount)
-		user.PUT("/bank-accounts/:account_id", r.handler.UpdateBankAccount)
-		user.DELETE("/bank-accounts/:account_id", r.handler.DeleteBankAccount)
+		user.POST("/bank-accounts", r.authMiddle.DenyImpersonation(), r.handler.AddBankAccount)
+		user.PUT("/bank-accounts/:account_id", r.authMiddle.DenyImpersonation(), r.handler.UpdateBankAccount)
+		user.DELETE("/bank-accounts/:account_id", r.authMiddle.DenyImpersonation(), r.handler.DeleteBankAccount)
 
 		// === 提现相关 ===
 
 		// 提现费用计算
 		user.POST("/withdrawal/calculate", r.handler.CalculateWithdrawal)
 
-		// 提现申请
-		user.POST("/withdrawals", r.handler.CreateWithdrawal)
+		// 提现申请（资金操作在管理员模拟登录时禁止）
+		user.POST("/withdrawals", r.authMiddle.DenyImpersonation(), r.handler.CreateWithdrawal)
 		user.GET("/withdrawals", r.handler.GetUserWithdrawals)
 		user.GET("/withdrawals/:withdrawal_id", r.handler.GetWithdrawal)
-		user.POST("/withdrawals/:withdrawal_id/cancel", r.handler.CancelWithdrawal)
+		user.POST("/withdrawals/:withdrawal_id/cancel", r.authMiddle.DenyImpersonation(), r.handler.CancelWithdrawal)
 
 		// === 交易记录 ===
 
@@ -95,7 +95,7 @@ func (r *Routes) registerUserRoutes(group *gin.RouterGroup) {
 
 // registerAdminRoutes 注册管理员路由
 func (r *Routes) registerAdminRoutes(group *gin.RouterGroup) {
-	// 管理员接口组 - 需要管理员认证
+	// 管理员接口组 - 需要管理员认证，各接口按权限控制
 	admin := group.Group("/admin")
 	admin.Use(r.authMiddle.RequireAuth())
 	admin.Use(r.authMiddle.RequireUserType("admin"))
@@ -103,32 +103,32 @@ func (r *Routes) registerAdminRoutes(group *gin.RouterGroup) {
 		// === 提现管理 ===
 
 		// 提现申请管理
-		admin.GET("/withdrawals", r.handler.GetPendingWithdrawals)
-		admin.GET("/withdrawals/:withdrawal_id", r.handler.GetWithdrawalDetail)
-		admin.POST("/withdrawals/:withdrawal_id/review", r.handler.ReviewWithdrawal)
-		admin.POST("/withdrawals/:withdrawal_id/process", r.handler.ProcessWithdrawal)
+		admin.GET("/withdrawals", r.authMiddle.RequirePermission(auth.PermWithdrawalView), r.handler.GetPendingWithdrawals)
+		admin.GET("/withdrawals/:withdrawal_id", r.authMiddle.RequirePermission(auth.PermWithdrawalView), r.handler.GetWithdrawalDetail)
+		admin.POST("/withdrawals/:withdrawal_id/review", r.authMiddle.RequirePermission(auth.PermWithdrawalReview), r.handler.ReviewWithdrawal)
+		admin.POST("/withdrawals/:withdrawal_id/process", r.authMiddle.RequirePermission(auth.PermWithdrawalProcess), r.handler.ProcessWithdrawal)
 
 		// === 汇率管理 ===
 
 		// 汇率设置
-		admin.POST("/exchange-rates", r.handler.CreateExchangeRate)
-		admin.PUT("/exchange-rates/:rate_id", r.handler.UpdateExchangeRate)
-		admin.GET("/exchange-rates", r.handler.GetExchangeRates)
+		admin.POST("/exchange-rates", r.authMiddle.RequirePermission(auth.PermExchangeRateManage), r.handler.CreateExchangeRate)
+		admin.PUT("/exchange-rates/:rate_id", r.authMiddle.RequirePermission(auth.PermExchangeRateManage), r.handler.UpdateExchangeRate)
+		admin.GET("/exchange-rates", r.authMiddle.RequirePermission(auth.PermExchangeRateView), r.handler.GetExchangeRates)
 
 		// === 钱包管理 ===
 
 		// 钱包调整
-		admin.POST("/wallets/adjust", r.handler.AdjustWallet)
-		admin.GET("/wallets/:user_id", r.handler.GetUserWallet)
-		admin.POST("/wallets/:user_id/freeze", r.handler.FreezeWallet)
-		admin.POST("/wallets/:user_id/unfreeze", r.handler.UnfreezeWallet)
+		admin.POST("/wallets/adjust", r.authMiddle.RequirePermission(auth.PermWalletAdjust), r.handler.AdjustWallet)
+		admin.GET("/wallets/:user_id", r.authMiddle.RequirePermission(auth.PermWalletView), r.handler.GetUserWallet)
+		admin.POST("/wallets/:user_id/freeze", r.authMiddle.RequirePermission(auth.PermWalletFreeze), r.handler.FreezeWallet)
+		admin.POST("/wallets/:user_id/unfreeze", r.authMiddle.RequirePermission(auth.PermWalletFreeze), r.handler.UnfreezeWallet)
 
 		// === 统计报告 ===
 
 		// 统计信息
-		admin.GET("/statistics/wallets", r.handler.GetWalletStatistics)
-		admin.GET("/statistics/transactions", r.handler.GetTransactionStatistics)
-		admin.GET("/statistics/withdrawals", r.handler.GetWithdrawalStatistics)
+		admin.GET("/statistics/wallets", r.authMiddle.RequirePermission(auth.PermStatisticsView), r.handler.GetWalletStatistics)
+		admin.GET("/statistics/transactions", r.authMiddle.RequirePermission(auth.PermStatisticsView), r.handler.GetTransactionStatistics)
+		admin.GET("/statistics/withdrawals", r.authMiddle.RequirePermission(auth.PermStatisticsView), r.handler.GetWithdrawalStatistics)
 	}
 }
 
@@ -3,7 +3,10 @@ package wallet
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
+	"trusioo_api_v0.0.1/internal/modules/auth"
 	"trusioo_api_v0.0.1/pkg/cryptoutil"
 
 	"github.com/sirupsen/logrus"
@@ -45,13 +48,31 @@ type Service interface {
 	UpdateExchangeRate(ctx context.Context, adminID string, req *AdminUpdateExchangeRateRequest) error
 	AdjustWallet(ctx context.Context, adminID string, req *AdminWalletAdjustmentRequest) error
 	GetWalletStatistics(ctx context.Context) (*WalletStatisticsResponse, error)
+
+	// 通知
+	SetActivityObserver(observer auth.UserActivityObserver)
+
+	// KYC等级限制（提现时按用户当前等级检查）
+	SetKYCLimitsProvider(provider auth.KYCLimitsProvider)
+
+	// KYC等级变更（更新提现开关和每日限额）
+	OnKYCTierChanged(ctx context.Context, userID string, limits *auth.KYCLimits) error
 }
 
+// baseCurrencyCode 钱包余额和提现限额使用的货币
+const baseCurrencyCode = "TRU"
+
 // service 钱包服务实现
 type service struct {
 	repo      Repository
 	encryptor *cryptoutil.PasswordEncryptor
 	logger    *logrus.Logger
+
+	// 用户活动观察者（管理员关注列表，未设置时不通知）
+	activityObserver auth.UserActivityObserver
+
+	// KYC等级限制（未设置时使用钱包上同步的提现开关和限额）
+	kycLimits auth.KYCLimitsProvider
 }
 
 // NewService 创建新的钱包服务
@@ -63,6 +84,90 @@ func NewService(repo Repository, encryptor *cryptoutil.PasswordEncryptor, logger
 	}
 }
 
+// SetActivityObserver 设置用户活动观察者
+func (s *service) SetActivityObserver(observer auth.UserActivityObserver) {
+	s.activityObserver = observer
+}
+
+// SetKYCLimitsProvider 设置KYC等级限制来源
+func (s *service) SetKYCLimitsProvider(provider auth.KYCLimitsProvider) {
+	s.kycLimits = provider
+}
+
+// applyKYCLimits 按用户当前KYC等级覆盖钱包上的提现开关和每日限额
+// 等级变更后同步钱包是尽力而为的，失败时钱包上的值可能已过期
+func (s *service) applyKYCLimits(ctx context.Context, wallet *Wallet) error {
+	if s.kycLimits == nil {
+		return nil
+	}
+	limits, err := s.kycLimits.GetKYCLimits(ctx, wallet.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get kyc limits: %w", err)
+	}
+	wallet.IsWithdrawalEnabled = limits.WithdrawalEnabled
+	wallet.DailyWithdrawalLimit = limits.DailyWithdrawalLimit
+	return nil
+}
+
+// notifyActivity 通知用户活动观察者
+func (s *service) notifyActivity(ctx context.Context, eventType, userID string, details map[string]interface{}) {
+	if s.activityObserver == nil {
+		return
+	}
+	s.activityObserver.OnUserActivity(ctx, &auth.UserActivityEvent{
+		Type:       eventType,
+		UserID:     userID,
+		Details:    details,
+		OccurredAt: time.Now(),
+	})
+}
+
+// OnKYCTierChanged KYC等级变更后更新提现开关和每日提现限额
+func (s *service) OnKYCTierChanged(ctx context.Context, userID string, limits *auth.KYCLimits) error {
+	if err := s.repo.UpdateWithdrawalLimits(ctx, userID, limits.WithdrawalEnabled, limits.DailyWithdrawalLimit); err != nil {
+		return err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":                userID,
+		"kyc_tier":               limits.Tier,
+		"withdrawal_enabled":     limits.WithdrawalEnabled,
+		"daily_withdrawal_limit": limits.DailyWithdrawalLimit,
+	}).Info("Wallet withdrawal limits updated for kyc tier")
+
+	return nil
+}
+
+// checkWithdrawalLimits 检查提现开关（由KYC等级决定）和每日限额，返回按当前汇率折算的TRU金额
+func (s *service) checkWithdrawalLimits(ctx context.Context, userID, currencyCode string, amountLocal float64) (float64, error) {
+	wallet, err := s.repo.GetWalletByUserID(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get wallet: %w", err)
+	}
+	if err := s.applyKYCLimits(ctx, wallet); err != nil {
+		return 0, err
+	}
+
+	if !wallet.IsWithdrawalEnabled {
+		return 0, auth.ErrWithdrawalNotEnabled
+	}
+
+	rate, err := s.repo.GetExchangeRateByCode(ctx, baseCurrencyCode, currencyCode)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get exchange rate: %w", err)
+	}
+	if rate.Rate <= 0 {
+		return 0, fmt.Errorf("invalid exchange rate for %s", currencyCode)
+	}
+
+	amountTRU := amountLocal / rate.Rate
+	if wallet.WithdrawnToday()+amountTRU > wallet.DailyWithdrawalLimit {
+		return 0, auth.ErrDailyWithdrawalLimitExceeded
+	}
+
+	return amountTRU, nil
+}
+
 // === 钱包相关实现 ===
 
 // GetWallet 获取用户钱包信息
@@ -72,6 +177,10 @@ func (s *service) GetWallet(ctx context.Context, userID string) (*WalletResponse
 		s.logger.WithError(err).WithField("user_id", userID).Error("Failed to get wallet")
 		return nil, fmt.Errorf("failed to get wallet: %w", err)
 	}
+	if err := s.applyKYCLimits(ctx, wallet); err != nil {
+		s.logger.WithError(err).WithField("user_id", userID).Error("Failed to get kyc limits")
+		return nil, err
+	}
 
 	return wallet.ToWalletResponse(), nil
 }
@@ -272,6 +381,13 @@ func (s *service) AddBankAccount(ctx context.Context, userID string, req *AddBan
 		"bank_name":  bank.Name,
 	}).Info("Bank account created successfully")
 
+	s.notifyActivity(ctx, auth.UserActivityBankAccountAdded, userID, map[string]interface{}{
+		"account_id":     account.ID,
+		"bank_name":      bank.Name,
+		"account_name":   account.AccountName,
+		"account_number": maskAccountNumber(account.AccountNumber),
+	})
+
 	return account.ToBankAccountResponse(), nil
 }
 
@@ -346,12 +462,20 @@ func (s *service) DeleteBankAccount(ctx context.Context, userID, accountID strin
 // === 简化实现其他方法 ===
 
 func (s *service) CalculateWithdrawal(ctx context.Context, userID string, req *CalculateWithdrawalRequest) (*WithdrawalCalculationResponse, error) {
+	if _, err := s.checkWithdrawalLimits(ctx, userID, req.CurrencyCode, req.AmountLocal); err != nil {
+		return nil, err
+	}
+
 	// 简化实现
 	return nil, fmt.Errorf("not implemented")
 }
 
 func (s *service) CreateWithdrawalRequest(ctx context.Context, userID string, req *CreateWithdrawalRequest) (*WithdrawalResponse, error) {
-	// 简化实现
+	if _, err := s.checkWithdrawalLimits(ctx, userID, req.CurrencyCode, req.AmountLocal); err != nil {
+		return nil, err
+	}
+
+	// 简化实现（实现后调用s.notifyActivity(ctx, auth.UserActivityWithdrawalRequested, ...)，并将该事件加入auth.UserActivityEvents开放关注）
 	return nil, fmt.Errorf("not implemented")
 }
 
@@ -399,3 +523,11 @@ func (s *service) GetWalletStatistics(ctx context.Context) (*WalletStatisticsRes
 	// 简化实现
 	return nil, fmt.Errorf("not implemented")
 }
+
+// maskAccountNumber 脱敏银行账号，仅保留后4位
+func maskAccountNumber(accountNumber string) string {
+	if len(accountNumber) <= 4 {
+		return accountNumber
+	}
+	return strings.Repeat("*", len(accountNumber)-4) + accountNumber[len(accountNumber)-4:]
+}
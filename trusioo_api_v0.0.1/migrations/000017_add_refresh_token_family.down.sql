@@ -0,0 +1,8 @@
+-- 删除刷新令牌族相关字段
+DROP INDEX IF EXISTS idx_refresh_tokens_parent_token_id;
+DROP INDEX IF EXISTS idx_refresh_tokens_family_id;
+
+ALTER TABLE refresh_tokens
+    DROP COLUMN IF EXISTS revoked_reason,
+    DROP COLUMN IF EXISTS parent_token_id,
+    DROP COLUMN IF EXISTS family_id;
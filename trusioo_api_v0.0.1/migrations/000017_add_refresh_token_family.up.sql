@@ -0,0 +1,14 @@
+-- 为刷新令牌添加令牌族（family）支持，用于检测刷新令牌重放
+ALTER TABLE refresh_tokens
+    ADD COLUMN IF NOT EXISTS family_id VARCHAR(255), -- 令牌族ID（首个令牌的jti）
+    ADD COLUMN IF NOT EXISTS parent_token_id VARCHAR(255), -- 轮换前的父令牌jti
+    ADD COLUMN IF NOT EXISTS revoked_reason VARCHAR(50); -- 撤销原因: rotated, logout, reuse_detected, revoke_all
+
+-- 已有令牌各自成为独立的令牌族
+UPDATE refresh_tokens SET family_id = token_id WHERE family_id IS NULL;
+
+ALTER TABLE refresh_tokens ALTER COLUMN family_id SET NOT NULL;
+
+-- 创建索引
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens(family_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_parent_token_id ON refresh_tokens(parent_token_id);
@@ -0,0 +1,2 @@
+-- 删除JWT签名密钥表
+DROP TABLE IF EXISTS jwt_signing_keys;
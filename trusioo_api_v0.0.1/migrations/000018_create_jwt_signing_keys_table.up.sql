@@ -0,0 +1,17 @@
+-- 创建JWT签名密钥表（非对称算法，支持密钥轮换）
+CREATE TABLE IF NOT EXISTS jwt_signing_keys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    kid VARCHAR(64) UNIQUE NOT NULL, -- JWT头部中的kid
+    algorithm VARCHAR(20) NOT NULL, -- RS256, ES256, EdDSA
+    private_key TEXT NOT NULL, -- 加密后的PKCS8私钥
+    public_key TEXT NOT NULL, -- PKIX公钥(PEM)
+    status VARCHAR(20) NOT NULL DEFAULT 'active', -- active, retired
+    retired_at TIMESTAMP WITH TIME ZONE, -- 停止用于签名的时间
+    expires_at TIMESTAMP WITH TIME ZONE, -- 宽限期结束，停止用于验证的时间
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- 创建索引
+CREATE INDEX IF NOT EXISTS idx_jwt_signing_keys_status ON jwt_signing_keys(status);
+CREATE INDEX IF NOT EXISTS idx_jwt_signing_keys_expires_at ON jwt_signing_keys(expires_at);
+CREATE INDEX IF NOT EXISTS idx_jwt_signing_keys_created_at ON jwt_signing_keys(created_at);
@@ -0,0 +1,5 @@
+-- 删除角色权限表
+DROP TABLE IF EXISTS admin_role_permissions;
+
+-- 删除管理员角色表
+DROP TABLE IF EXISTS admin_roles;
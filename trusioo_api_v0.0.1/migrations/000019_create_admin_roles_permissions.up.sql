@@ -0,0 +1,48 @@
+-- 创建管理员角色表
+CREATE TABLE IF NOT EXISTS admin_roles (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(50) UNIQUE NOT NULL, -- 角色标识，对应admins.role
+    display_name VARCHAR(100) NOT NULL,
+    description TEXT,
+    is_system BOOLEAN NOT NULL DEFAULT false, -- 系统内置角色不可删除
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- 创建角色权限表
+CREATE TABLE IF NOT EXISTS admin_role_permissions (
+    role_name VARCHAR(50) NOT NULL REFERENCES admin_roles(name) ON UPDATE CASCADE ON DELETE CASCADE,
+    permission VARCHAR(100) NOT NULL, -- 如 wallet.adjust, withdrawal.review
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (role_name, permission)
+);
+
+-- 创建索引
+CREATE INDEX IF NOT EXISTS idx_admin_role_permissions_permission ON admin_role_permissions(permission);
+
+-- 内置角色（super_admin 隐式拥有全部权限，无需写入权限表）
+INSERT INTO admin_roles (name, display_name, description, is_system)
+VALUES
+    ('super_admin', 'Super Admin', 'Full access to all administrative features', true),
+    ('admin', 'Admin', 'Day-to-day operations on users, wallets and withdrawals', true)
+ON CONFLICT (name) DO NOTHING;
+
+-- 普通管理员默认权限（保持与原有行为一致，但不包含角色和管理员管理）
+INSERT INTO admin_role_permissions (role_name, permission)
+VALUES
+    ('admin', 'user.view'),
+    ('admin', 'user.update_status'),
+    ('admin', 'user.suspend'),
+    ('admin', 'user.reset_password'),
+    ('admin', 'user.force_logout'),
+    ('admin', 'user.verify_email'),
+    ('admin', 'wallet.view'),
+    ('admin', 'wallet.adjust'),
+    ('admin', 'wallet.freeze'),
+    ('admin', 'withdrawal.view'),
+    ('admin', 'withdrawal.review'),
+    ('admin', 'withdrawal.process'),
+    ('admin', 'exchange_rate.view'),
+    ('admin', 'exchange_rate.manage'),
+    ('admin', 'statistics.view')
+ON CONFLICT (role_name, permission) DO NOTHING;
@@ -0,0 +1,2 @@
+-- 删除管理员邀请表
+DROP TABLE IF EXISTS admin_invitations;
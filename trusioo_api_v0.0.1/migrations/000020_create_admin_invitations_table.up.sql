@@ -0,0 +1,18 @@
+-- 创建管理员邀请表
+CREATE TABLE IF NOT EXISTS admin_invitations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    email VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    role VARCHAR(50) NOT NULL REFERENCES admin_roles(name) ON UPDATE CASCADE,
+    token_hash VARCHAR(64) UNIQUE NOT NULL, -- 邀请令牌的带密钥哈希，明文只出现在邮件中
+    invited_by UUID REFERENCES admins(id) ON DELETE SET NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    accepted_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- 创建索引
+CREATE INDEX IF NOT EXISTS idx_admin_invitations_email ON admin_invitations(email);
+CREATE INDEX IF NOT EXISTS idx_admin_invitations_pending ON admin_invitations(expires_at) WHERE accepted_at IS NULL AND revoked_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_admin_invitations_created_at ON admin_invitations(created_at);
@@ -0,0 +1,4 @@
+-- 删除登录提醒表
+DROP TABLE IF EXISTS login_alerts;
+
+ALTER TABLE users DROP COLUMN IF EXISTS password_reset_required;
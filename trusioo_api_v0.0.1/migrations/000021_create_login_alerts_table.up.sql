@@ -0,0 +1,22 @@
+-- 用户被要求重置密码后才能再次登录（举报陌生登录后设置）
+ALTER TABLE users ADD COLUMN IF NOT EXISTS password_reset_required BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- 创建新设备/新位置登录提醒表
+CREATE TABLE IF NOT EXISTS login_alerts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    session_id VARCHAR(255),
+    token_hash VARCHAR(64) UNIQUE NOT NULL, -- “不是我本人”链接令牌的带密钥哈希，明文只出现在邮件中
+    ip_address INET,
+    device_info JSONB,
+    location_info JSONB,
+    new_device BOOLEAN NOT NULL DEFAULT FALSE,
+    new_location BOOLEAN NOT NULL DEFAULT FALSE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    reported_at TIMESTAMP WITH TIME ZONE, -- 用户确认不是本人登录的时间
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- 创建索引
+CREATE INDEX IF NOT EXISTS idx_login_alerts_user_id ON login_alerts(user_id);
+CREATE INDEX IF NOT EXISTS idx_login_alerts_created_at ON login_alerts(created_at);
@@ -0,0 +1,4 @@
+-- 删除密码历史表
+DROP TABLE IF EXISTS password_history;
+
+ALTER TABLE admins DROP COLUMN IF EXISTS password_changed_at;
@@ -0,0 +1,14 @@
+-- 管理员密码最近一次修改时间（用于密码有效期检查）
+ALTER TABLE admins ADD COLUMN IF NOT EXISTS password_changed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW();
+
+-- 创建密码历史表（只保存密码哈希，用于禁止重复使用最近的密码）
+CREATE TABLE IF NOT EXISTS password_history (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    user_type VARCHAR(50) NOT NULL, -- admin, user
+    password_hash VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- 创建索引
+CREATE INDEX IF NOT EXISTS idx_password_history_user ON password_history(user_id, user_type, created_at DESC);
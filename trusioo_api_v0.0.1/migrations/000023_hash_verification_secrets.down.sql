@@ -0,0 +1,15 @@
+-- 带密钥哈希无法还原为摘要，回滚时作废尚未使用的登录提醒和邀请令牌
+UPDATE login_alerts SET token_hash = substr(token_hash, 8) WHERE token_hash LIKE 'sha256:%';
+UPDATE login_alerts SET expires_at = NOW() WHERE reported_at IS NULL AND expires_at > NOW();
+ALTER TABLE login_alerts ALTER COLUMN token_hash TYPE VARCHAR(64);
+
+UPDATE admin_invitations SET token_hash = substr(token_hash, 8) WHERE token_hash LIKE 'sha256:%';
+UPDATE admin_invitations SET expires_at = NOW() WHERE accepted_at IS NULL AND revoked_at IS NULL AND expires_at > NOW();
+ALTER TABLE admin_invitations ALTER COLUMN token_hash TYPE VARCHAR(64);
+
+-- 哈希无法还原为明文，回滚时删除已哈希的验证记录
+DELETE FROM email_verifications WHERE length(verification_code) > 10;
+
+CREATE INDEX IF NOT EXISTS idx_email_verifications_code ON email_verifications(verification_code);
+
+ALTER TABLE email_verifications ALTER COLUMN verification_code TYPE VARCHAR(10);
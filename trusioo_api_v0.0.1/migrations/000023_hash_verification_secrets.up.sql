@@ -0,0 +1,17 @@
+-- 验证码改为保存十六进制HMAC-SHA256哈希（64位），明文记录在服务启动时改写
+ALTER TABLE email_verifications ALTER COLUMN verification_code TYPE VARCHAR(64);
+
+-- 按哈希查找验证码没有意义，删除验证码索引
+DROP INDEX IF EXISTS idx_email_verifications_code;
+
+-- 未使用的登录链接以不带密钥的摘要保存，无法转换为新格式，直接作废
+UPDATE email_verifications
+SET expires_at = NOW()
+WHERE type = 'magic_link' AND verified = false AND expires_at > NOW();
+
+-- 登录提醒和管理员邀请令牌改为保存对SHA-256摘要的HMAC：旧摘要加上标记，服务启动时改写
+ALTER TABLE login_alerts ALTER COLUMN token_hash TYPE VARCHAR(72);
+UPDATE login_alerts SET token_hash = 'sha256:' || token_hash WHERE length(token_hash) = 64;
+
+ALTER TABLE admin_invitations ALTER COLUMN token_hash TYPE VARCHAR(72);
+UPDATE admin_invitations SET token_hash = 'sha256:' || token_hash WHERE length(token_hash) = 64;
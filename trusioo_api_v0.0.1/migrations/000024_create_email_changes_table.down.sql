@@ -0,0 +1,2 @@
+-- 删除邮箱变更记录表
+DROP TABLE IF EXISTS email_changes;
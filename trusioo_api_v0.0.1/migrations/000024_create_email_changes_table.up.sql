@@ -0,0 +1,17 @@
+-- 创建邮箱变更记录表（原邮箱可在有效期内通过撤销链接恢复）
+CREATE TABLE IF NOT EXISTS email_changes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    old_email VARCHAR(255) NOT NULL,
+    new_email VARCHAR(255) NOT NULL,
+    changed_by VARCHAR(50) NOT NULL, -- user, admin
+    admin_id UUID, -- 管理员代为修改时的操作人
+    revert_token_hash VARCHAR(64) UNIQUE NOT NULL, -- 撤销链接令牌的HMAC哈希，明文只出现在发往原邮箱的邮件中
+    revert_expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    reverted_at TIMESTAMP WITH TIME ZONE,
+    ip_address INET,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- 创建索引
+CREATE INDEX IF NOT EXISTS idx_email_changes_user_id ON email_changes(user_id, created_at DESC);
@@ -0,0 +1,3 @@
+-- 删除个人数据导出任务表和账户注销记录表
+DROP TABLE IF EXISTS data_export_jobs;
+DROP TABLE IF EXISTS account_deletions;
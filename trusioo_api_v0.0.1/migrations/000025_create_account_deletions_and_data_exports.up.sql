@@ -0,0 +1,38 @@
+-- 创建账户注销记录表（冷静期内可通过恢复链接或管理员恢复，期满后匿名化个人数据）
+CREATE TABLE IF NOT EXISTS account_deletions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id),
+    requested_by VARCHAR(50) NOT NULL, -- user, admin
+    admin_id UUID, -- 管理员代为注销时的操作人
+    reason TEXT,
+    restore_token_hash VARCHAR(64) UNIQUE NOT NULL, -- 恢复链接令牌的HMAC哈希，明文只出现在邮件中
+    scheduled_for TIMESTAMP WITH TIME ZONE NOT NULL, -- 冷静期结束、开始匿名化的时间
+    restored_at TIMESTAMP WITH TIME ZONE,
+    anonymized_at TIMESTAMP WITH TIME ZONE,
+    ip_address INET,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- 创建索引
+CREATE INDEX IF NOT EXISTS idx_account_deletions_user_id ON account_deletions(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_account_deletions_due
+ON account_deletions(scheduled_for) WHERE restored_at IS NULL AND anonymized_at IS NULL;
+
+-- 创建个人数据导出任务表
+CREATE TABLE IF NOT EXISTS data_export_jobs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(50) NOT NULL DEFAULT 'pending', -- pending, processing, completed, failed, expired
+    file_path TEXT, -- 导出文件路径（只在服务端使用）
+    file_size BIGINT,
+    error_message TEXT,
+    ip_address INET,
+    started_at TIMESTAMP WITH TIME ZONE,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    expires_at TIMESTAMP WITH TIME ZONE, -- 导出文件过期时间
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- 创建索引
+CREATE INDEX IF NOT EXISTS idx_data_export_jobs_user_id ON data_export_jobs(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_data_export_jobs_status ON data_export_jobs(status);
@@ -0,0 +1,6 @@
+-- 删除用户暂停历史表
+DROP TABLE IF EXISTS user_suspensions;
+
+ALTER TABLE users DROP COLUMN IF EXISTS suspended_until;
+ALTER TABLE users DROP COLUMN IF EXISTS suspended_reason;
+ALTER TABLE users DROP COLUMN IF EXISTS suspended_at;
@@ -0,0 +1,23 @@
+-- 用户表增加当前暂停信息（suspended_until为空表示永久暂停）
+ALTER TABLE users ADD COLUMN IF NOT EXISTS suspended_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS suspended_reason TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS suspended_until TIMESTAMP WITH TIME ZONE;
+
+-- 创建用户暂停历史表（到期后由后台任务自动解除）
+CREATE TABLE IF NOT EXISTS user_suspensions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    admin_id UUID, -- 执行暂停的管理员
+    reason TEXT NOT NULL,
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    ends_at TIMESTAMP WITH TIME ZONE, -- 为空表示永久暂停
+    lifted_at TIMESTAMP WITH TIME ZONE, -- 实际解除时间
+    lifted_by UUID, -- 手动解除的管理员，到期自动解除时为空
+    lift_reason VARCHAR(50), -- expired, admin, superseded
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- 创建索引
+CREATE INDEX IF NOT EXISTS idx_user_suspensions_user_id ON user_suspensions(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_user_suspensions_due
+ON user_suspensions(ends_at) WHERE lifted_at IS NULL AND ends_at IS NOT NULL;
@@ -0,0 +1,3 @@
+-- 删除用户批量操作任务表
+DROP TABLE IF EXISTS user_batch_job_items;
+DROP TABLE IF EXISTS user_batch_jobs;
@@ -0,0 +1,43 @@
+-- 创建用户批量操作任务表（异步执行，超过阈值需超级管理员审批）
+CREATE TABLE IF NOT EXISTS user_batch_jobs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    action VARCHAR(30) NOT NULL, -- update_status, suspend, reactivate, force_logout
+    params JSONB NOT NULL DEFAULT '{}', -- 单个用户操作的请求参数
+    target_type VARCHAR(20) NOT NULL, -- ids, filter
+    target_filter JSONB, -- 按筛选条件提交时的条件（仅用于审计，目标用户在创建时已确定）
+    status VARCHAR(30) NOT NULL DEFAULT 'queued', -- awaiting_approval, queued, running, completed, cancelled
+    total_count INTEGER NOT NULL DEFAULT 0,
+    processed_count INTEGER NOT NULL DEFAULT 0,
+    succeeded_count INTEGER NOT NULL DEFAULT 0,
+    failed_count INTEGER NOT NULL DEFAULT 0,
+    skipped_count INTEGER NOT NULL DEFAULT 0,
+    created_by UUID NOT NULL, -- 提交任务的管理员
+    created_by_email VARCHAR(255) NOT NULL,
+    ip_address INET,
+    approved_by UUID,
+    approved_at TIMESTAMP WITH TIME ZONE,
+    cancelled_by UUID,
+    cancelled_at TIMESTAMP WITH TIME ZONE,
+    started_at TIMESTAMP WITH TIME ZONE,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- 创建批量操作明细表（每个用户一条执行结果）
+CREATE TABLE IF NOT EXISTS user_batch_job_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    job_id UUID NOT NULL REFERENCES user_batch_jobs(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL,
+    email VARCHAR(255),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, succeeded, failed, skipped, cancelled
+    message TEXT, -- 失败或跳过的原因
+    processed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (job_id, user_id)
+);
+
+-- 创建索引
+CREATE INDEX IF NOT EXISTS idx_user_batch_jobs_status ON user_batch_jobs(status, created_at);
+CREATE INDEX IF NOT EXISTS idx_user_batch_jobs_created_at ON user_batch_jobs(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_user_batch_job_items_job_status ON user_batch_job_items(job_id, status);
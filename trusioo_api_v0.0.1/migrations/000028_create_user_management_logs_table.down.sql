@@ -0,0 +1,3 @@
+-- 删除用户管理操作审计日志表和哈希链检查点表
+DROP TABLE IF EXISTS user_management_log_checkpoint;
+DROP TABLE IF EXISTS user_management_logs;
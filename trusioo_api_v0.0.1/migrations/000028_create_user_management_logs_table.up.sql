@@ -0,0 +1,35 @@
+-- 创建用户管理操作审计日志表（之前的操作记录仍保留在login_logs中，user_type = 'admin_operation'）
+-- 每条记录的hash是包含上一条记录hash的HMAC，组成哈希链，删除或修改记录都可以被检测到
+CREATE TABLE IF NOT EXISTS user_management_logs (
+    id UUID PRIMARY KEY,
+    seq BIGSERIAL NOT NULL UNIQUE, -- 写入顺序，哈希链按此顺序校验
+    admin_id VARCHAR(100) NOT NULL, -- 管理员ID，后台任务为system
+    admin_email VARCHAR(255) NOT NULL,
+    target_user_id UUID,
+    target_email VARCHAR(255) NOT NULL DEFAULT '', -- 用户注销匿名化时会被替换，不参与hash计算
+    target_email_hash VARCHAR(64) NOT NULL DEFAULT '', -- 操作时目标邮箱的HMAC，代替明文邮箱参与hash计算
+    action VARCHAR(50) NOT NULL,
+    reason TEXT,
+    details JSONB,
+    ip_address VARCHAR(45) NOT NULL,
+    user_agent TEXT,
+    prev_hash VARCHAR(64) NOT NULL DEFAULT '', -- 上一条记录的hash，第一条为空
+    hash VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- 创建索引
+CREATE INDEX IF NOT EXISTS idx_user_management_logs_admin_id ON user_management_logs(admin_id, seq DESC);
+CREATE INDEX IF NOT EXISTS idx_user_management_logs_target_user_id ON user_management_logs(target_user_id, seq DESC);
+CREATE INDEX IF NOT EXISTS idx_user_management_logs_action ON user_management_logs(action, seq DESC);
+CREATE INDEX IF NOT EXISTS idx_user_management_logs_created_at ON user_management_logs(created_at);
+
+-- 创建哈希链检查点表（只有一行）：每次写入日志时在同一事务中更新最新的seq和hash并签名，
+-- 用于发现末尾记录被删除
+CREATE TABLE IF NOT EXISTS user_management_log_checkpoint (
+    id BOOLEAN PRIMARY KEY DEFAULT true CHECK (id),
+    seq BIGINT NOT NULL,
+    hash VARCHAR(64) NOT NULL,
+    signature VARCHAR(64) NOT NULL, -- seq和hash的HMAC
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,3 @@
+-- 删除用户统计汇总表
+DROP TABLE IF EXISTS user_retention_rollups;
+DROP TABLE IF EXISTS user_statistics_rollups;
@@ -0,0 +1,24 @@
+-- 创建用户统计汇总表，由后台任务定期刷新，统计接口直接读取，无需每次扫描users和login_logs
+-- 按时区分别汇总（自然日/周/月的边界取决于时区），只汇总USER_STATS_TIMEZONES中配置的时区
+CREATE TABLE IF NOT EXISTS user_statistics_rollups (
+    timezone VARCHAR(64) NOT NULL,
+    period VARCHAR(10) NOT NULL, -- day, week, month
+    bucket_start DATE NOT NULL, -- 该时区下的自然日、周一或月初
+    registrations BIGINT NOT NULL DEFAULT 0, -- 注册用户数（包含之后已注销的用户）
+    verifications BIGINT NOT NULL DEFAULT 0, -- 完成邮箱验证的用户数
+    active_users BIGINT NOT NULL DEFAULT 0, -- 成功登录的去重用户数
+    logins BIGINT NOT NULL DEFAULT 0, -- 成功登录次数
+    computed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (timezone, period, bucket_start)
+);
+
+-- 创建按注册周划分的留存汇总表：week_offset为注册后第N周，active_users为该周有成功登录的用户数
+CREATE TABLE IF NOT EXISTS user_retention_rollups (
+    timezone VARCHAR(64) NOT NULL,
+    cohort_week DATE NOT NULL, -- 注册周的周一
+    week_offset INTEGER NOT NULL,
+    cohort_size BIGINT NOT NULL DEFAULT 0,
+    active_users BIGINT NOT NULL DEFAULT 0,
+    computed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (timezone, cohort_week, week_offset)
+);
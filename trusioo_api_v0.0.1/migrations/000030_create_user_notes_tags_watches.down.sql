@@ -0,0 +1,8 @@
+-- 撤销普通管理员的备注、标签和关注权限
+DELETE FROM admin_role_permissions WHERE permission IN ('user.notes', 'user.tags', 'user.watch');
+
+-- 删除用户备注、标签和关注相关表
+DROP TABLE IF EXISTS user_watch_notifications;
+DROP TABLE IF EXISTS user_watches;
+DROP TABLE IF EXISTS user_tags;
+DROP TABLE IF EXISTS user_notes;
@@ -0,0 +1,62 @@
+-- 创建用户备注表（管理员对用户的内部备注，可有多条）
+CREATE TABLE IF NOT EXISTS user_notes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    author_id UUID NOT NULL, -- 撰写备注的管理员
+    author_email VARCHAR(255) NOT NULL,
+    content TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_notes_user_id ON user_notes(user_id, created_at DESC);
+
+-- 创建用户标签表（如vip、fraud-suspect），用户列表可按标签过滤
+CREATE TABLE IF NOT EXISTS user_tags (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    tag VARCHAR(32) NOT NULL,
+    created_by UUID NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (user_id, tag)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_tags_tag ON user_tags(tag);
+
+-- 创建用户关注表：管理员关注某个用户后，该用户登录或添加银行账户时通知管理员
+CREATE TABLE IF NOT EXISTS user_watches (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    admin_id UUID NOT NULL REFERENCES admins(id) ON DELETE CASCADE,
+    admin_email VARCHAR(255) NOT NULL, -- 通知邮件的收件人
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    events TEXT[] NOT NULL, -- 需要通知的事件：login, bank_account_added
+    reason TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (admin_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_watches_user_id ON user_watches(user_id);
+
+-- 创建关注通知表（管理员的站内通知，同时会发送邮件）
+CREATE TABLE IF NOT EXISTS user_watch_notifications (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    admin_id UUID NOT NULL REFERENCES admins(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    user_email VARCHAR(255) NOT NULL,
+    event VARCHAR(50) NOT NULL,
+    details JSONB,
+    ip_address VARCHAR(45),
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    read_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_watch_notifications_admin_id ON user_watch_notifications(admin_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_user_watch_notifications_unread ON user_watch_notifications(admin_id) WHERE read_at IS NULL;
+
+-- 普通管理员可以维护用户备注、标签和关注列表
+INSERT INTO admin_role_permissions (role_name, permission)
+VALUES
+    ('admin', 'user.notes'),
+    ('admin', 'user.tags'),
+    ('admin', 'user.watch')
+ON CONFLICT (role_name, permission) DO NOTHING;
@@ -0,0 +1,3 @@
+-- 删除管理员模拟用户登录相关表
+DROP TABLE IF EXISTS user_impersonation_requests;
+DROP TABLE IF EXISTS user_impersonations;
@@ -0,0 +1,36 @@
+-- 创建管理员模拟用户登录记录表（只读令牌，不签发刷新令牌）
+CREATE TABLE IF NOT EXISTS user_impersonations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    admin_id UUID NOT NULL, -- 发起模拟登录的管理员
+    admin_email VARCHAR(255) NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    user_email VARCHAR(255) NOT NULL,
+    reason TEXT NOT NULL,
+    token_id VARCHAR(64) NOT NULL, -- 访问令牌jti，结束时加入撤销列表
+    ip_address INET,
+    user_agent TEXT,
+    user_notified BOOLEAN NOT NULL DEFAULT FALSE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ended_at TIMESTAMP WITH TIME ZONE, -- 管理员提前结束的时间
+    ended_by UUID,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_impersonations_admin_id ON user_impersonations(admin_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_user_impersonations_user_id ON user_impersonations(user_id, created_at DESC);
+
+-- 创建模拟登录请求日志表（模拟令牌发起的每个请求，包括被拦截的请求）
+CREATE TABLE IF NOT EXISTS user_impersonation_requests (
+    id BIGSERIAL PRIMARY KEY,
+    impersonation_id UUID NOT NULL REFERENCES user_impersonations(id) ON DELETE CASCADE,
+    method VARCHAR(10) NOT NULL,
+    path TEXT NOT NULL,
+    route TEXT, -- 匹配的路由模板
+    status_code INTEGER NOT NULL,
+    blocked BOOLEAN NOT NULL DEFAULT FALSE, -- 被只读限制或禁止模拟的路由拦截
+    ip_address INET,
+    user_agent TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_impersonation_requests_impersonation_id ON user_impersonation_requests(impersonation_id, id);
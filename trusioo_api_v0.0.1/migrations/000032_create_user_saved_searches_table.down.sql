@@ -0,0 +1,3 @@
+-- 删除保存的用户搜索表
+DROP INDEX IF EXISTS idx_login_logs_user_country;
+DROP TABLE IF EXISTS user_saved_searches;
@@ -0,0 +1,18 @@
+-- 创建管理员保存的用户搜索表（query为用户高级查询条件树，可用于用户列表、导出和批量操作）
+CREATE TABLE IF NOT EXISTS user_saved_searches (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    admin_id UUID NOT NULL REFERENCES admins(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    query JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    -- 同一管理员的搜索名称不能重复
+    CONSTRAINT unique_admin_saved_search_name UNIQUE (admin_id, name)
+);
+
+-- 高级查询中登录国家条件按用户查询成功登录记录
+CREATE INDEX IF NOT EXISTS idx_login_logs_user_country
+ON login_logs(user_id, (UPPER(location_info->>'country_code')))
+WHERE user_type = 'user' AND login_status = 'success';
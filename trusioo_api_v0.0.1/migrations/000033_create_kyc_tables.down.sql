@@ -0,0 +1,7 @@
+-- 撤销普通管理员的KYC权限
+DELETE FROM admin_role_permissions WHERE permission IN ('kyc.view', 'kyc.review');
+
+-- 删除KYC相关表（证件文件需手动从KYC_DOCUMENT_DIR清理）
+DROP TABLE IF EXISTS user_kyc;
+DROP TABLE IF EXISTS kyc_documents;
+DROP TABLE IF EXISTS kyc_submissions;
@@ -0,0 +1,79 @@
+-- 创建KYC认证申请表（每个用户同一时间只能有一个待审核的申请）
+CREATE TABLE IF NOT EXISTS kyc_submissions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    requested_tier SMALLINT NOT NULL CHECK (requested_tier IN (1, 2)),
+    approved_tier SMALLINT CHECK (approved_tier IN (1, 2)), -- 审核通过的等级，可低于申请等级
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+
+    -- 申请人信息（账户匿名化时清除）
+    first_name VARCHAR(100) NOT NULL,
+    last_name VARCHAR(100) NOT NULL,
+    date_of_birth DATE,
+    nationality CHAR(2) NOT NULL, -- ISO 3166-1 alpha-2
+    document_type VARCHAR(30) NOT NULL CHECK (document_type IN ('passport', 'national_id', 'driving_licence')),
+    document_number VARCHAR(64) NOT NULL,
+    address_line1 VARCHAR(255),
+    address_line2 VARCHAR(255),
+    city VARCHAR(100),
+    postal_code VARCHAR(20),
+    country CHAR(2),
+
+    -- 审核信息
+    provider VARCHAR(50) NOT NULL DEFAULT 'manual', -- 审核方：manual为管理员人工审核，其他为外部KYC服务商
+    provider_reference VARCHAR(255), -- 外部服务商的申请编号
+    reviewed_by UUID, -- 审核管理员，服务商自动审核时为空
+    reviewed_at TIMESTAMP WITH TIME ZONE,
+    rejection_reason TEXT, -- 拒绝原因（会发送给用户）
+    review_notes TEXT, -- 内部审核备注
+    ip_address INET,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_kyc_submissions_user_id ON kyc_submissions(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_kyc_submissions_status ON kyc_submissions(status, created_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_kyc_submissions_one_pending ON kyc_submissions(user_id) WHERE status = 'pending';
+
+-- 创建KYC证件文件表（上传后在提交申请时关联到申请）
+CREATE TABLE IF NOT EXISTS kyc_documents (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    submission_id UUID REFERENCES kyc_submissions(id) ON DELETE CASCADE,
+    kind VARCHAR(30) NOT NULL CHECK (kind IN ('id_front', 'id_back', 'selfie', 'proof_of_address')),
+    file_path TEXT NOT NULL, -- 服务端文件路径
+    content_type VARCHAR(100) NOT NULL,
+    file_size BIGINT NOT NULL,
+    sha256 CHAR(64) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_kyc_documents_user_id ON kyc_documents(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_kyc_documents_submission_id ON kyc_documents(submission_id);
+
+-- 创建用户KYC等级表（没有记录的用户为0级）
+CREATE TABLE IF NOT EXISTS user_kyc (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    tier SMALLINT NOT NULL DEFAULT 0 CHECK (tier BETWEEN 0 AND 2),
+    submission_id UUID REFERENCES kyc_submissions(id) ON DELETE SET NULL, -- 最近一次通过的申请
+    verified_at TIMESTAMP WITH TIME ZONE,
+    updated_by UUID, -- 最近一次调整等级的管理员
+    reason TEXT, -- 管理员直接调整等级的原因
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_kyc_tier ON user_kyc(tier);
+
+-- 提现开关改为由KYC等级决定：已开启提现的现有钱包视为1级（保留原有提现开关和限额），其余用户为0级
+INSERT INTO user_kyc (user_id, tier, reason)
+SELECT user_id, 1, 'Withdrawals were enabled before KYC was introduced'
+FROM wallets
+WHERE is_withdrawal_enabled = true
+ON CONFLICT (user_id) DO NOTHING;
+
+-- 普通管理员可以查看和审核KYC申请（与提现审核权限一致）
+INSERT INTO admin_role_permissions (role_name, permission)
+VALUES
+    ('admin', 'kyc.view'),
+    ('admin', 'kyc.review')
+ON CONFLICT (role_name, permission) DO NOTHING;
@@ -0,0 +1,10 @@
+-- 删除用户个人资料字段（头像文件需手动从PROFILE_AVATAR_DIR清理）
+DROP INDEX IF EXISTS idx_users_phone_number;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS phone_verified_at,
+    DROP COLUMN IF EXISTS phone_number,
+    DROP COLUMN IF EXISTS timezone,
+    DROP COLUMN IF EXISTS language,
+    DROP COLUMN IF EXISTS avatar_updated_at,
+    DROP COLUMN IF EXISTS avatar_path;
@@ -0,0 +1,11 @@
+-- 为用户添加可编辑的个人资料：头像、语言、时区和已验证的手机号
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS avatar_path VARCHAR(500), -- 头像文件路径（服务端本地存储，不对外暴露）
+    ADD COLUMN IF NOT EXISTS avatar_updated_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS language VARCHAR(10) NOT NULL DEFAULT 'en', -- 首选语言（BCP 47，如 en、zh-CN）
+    ADD COLUMN IF NOT EXISTS timezone VARCHAR(64) NOT NULL DEFAULT 'UTC', -- IANA时区（如 Africa/Lagos）
+    ADD COLUMN IF NOT EXISTS phone_number VARCHAR(20), -- E.164格式，只保存通过短信验证的号码
+    ADD COLUMN IF NOT EXISTS phone_verified_at TIMESTAMP WITH TIME ZONE;
+
+-- 一个手机号只能绑定一个账户
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_phone_number ON users(phone_number) WHERE phone_number IS NOT NULL AND deleted_at IS NULL;
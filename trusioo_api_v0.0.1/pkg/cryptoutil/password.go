@@ -7,24 +7,51 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"strings"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
+// 支持的密码哈希方法
+const (
+	MethodBcrypt       = "bcrypt"
+	MethodHMACBcrypt   = "hmac-bcrypt"
+	MethodArgon2id     = "argon2id"
+	MethodHMACArgon2id = "hmac-argon2id"
+)
+
+// hmacBcryptPrefix HMAC+bcrypt哈希的前缀，后接标准bcrypt哈希（$2a$...）
+// 早期版本的HMAC+bcrypt哈希没有前缀，与纯bcrypt哈希无法区分，验证时两种都会尝试
+const hmacBcryptPrefix = "$" + MethodHMACBcrypt
+
 // PasswordEncryptor 密码加密器
+//
+// 生成的哈希自带算法和参数，例如：
+//
+//	$2a$10$...                                    bcrypt
+//	$hmac-bcrypt$2a$10$...                        HMAC-SHA256 + bcrypt
+//	$argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>  Argon2id（PHC格式）
+//	$hmac-argon2id$v=19$m=65536,t=3,p=2$...       HMAC-SHA256 + Argon2id
+//
+// 因此更换方法或提高强度后旧哈希仍可验证，登录时可按 VerifyAndCheckRehash 的结果升级。
 type PasswordEncryptor struct {
 	encryptionKey []byte
 	method        string
 	saltLength    int
+	bcryptCost    int
+	argon2        Argon2Params
 }
 
 // PasswordConfig 密码配置
 type PasswordConfig struct {
-	EncryptionKey string `json:"encryption_key"`
-	Method        string `json:"method"`
-	SaltLength    int    `json:"salt_length"`
-	BcryptCost    int    `json:"bcrypt_cost"`
+	EncryptionKey     string `json:"encryption_key"`
+	Method            string `json:"method"`
+	SaltLength        int    `json:"salt_length"`
+	BcryptCost        int    `json:"bcrypt_cost"`
+	Argon2Memory      uint32 `json:"argon2_memory"` // KiB
+	Argon2Iterations  uint32 `json:"argon2_iterations"`
+	Argon2Parallelism uint8  `json:"argon2_parallelism"`
 }
 
 // NewPasswordEncryptor 创建新的密码加密器
@@ -33,6 +60,8 @@ func NewPasswordEncryptor(key, method string) *PasswordEncryptor {
 		encryptionKey: []byte(key),
 		method:        method,
 		saltLength:    32, // 默认盐长度
+		bcryptCost:    bcrypt.DefaultCost,
+		argon2:        DefaultArgon2Params,
 	}
 }
 
@@ -43,53 +72,147 @@ func NewPasswordEncryptorWithConfig(config *PasswordConfig) *PasswordEncryptor {
 		saltLength = 32
 	}
 
+	bcryptCost := config.BcryptCost
+	if bcryptCost < bcrypt.MinCost || bcryptCost > bcrypt.MaxCost {
+		bcryptCost = bcrypt.DefaultCost
+	}
+
+	argon2Params := DefaultArgon2Params
+	if config.Argon2Memory > 0 {
+		argon2Params.Memory = config.Argon2Memory
+	}
+	if config.Argon2Iterations > 0 {
+		argon2Params.Iterations = config.Argon2Iterations
+	}
+	if config.Argon2Parallelism > 0 {
+		argon2Params.Parallelism = config.Argon2Parallelism
+	}
+
 	return &PasswordEncryptor{
 		encryptionKey: []byte(config.EncryptionKey),
 		method:        config.Method,
 		saltLength:    saltLength,
+		bcryptCost:    bcryptCost,
+		argon2:        argon2Params,
 	}
 }
 
-// HashPassword 加密密码
-// 使用HMAC-SHA256对密码进行签名，然后再用bcrypt加密
+// HashPassword 使用配置的方法加密密码
 func (pe *PasswordEncryptor) HashPassword(password string) (string, error) {
 	switch pe.method {
-	case "hmac-bcrypt":
+	case MethodHMACBcrypt:
 		return pe.hashWithHMACBcrypt(password)
-	case "bcrypt":
+	case MethodBcrypt:
 		return pe.hashWithBcrypt(password)
+	case MethodArgon2id:
+		return hashWithArgon2id(MethodArgon2id, []byte(password), pe.argon2)
+	case MethodHMACArgon2id:
+		return hashWithArgon2id(MethodHMACArgon2id, []byte(pe.signWithHMAC(password)), pe.argon2)
 	default:
 		return "", fmt.Errorf("unsupported encryption method: %s", pe.method)
 	}
 }
 
-// VerifyPassword 验证密码
+// VerifyPassword 验证密码（根据哈希自身的格式选择算法，与当前配置的方法无关）
 func (pe *PasswordEncryptor) VerifyPassword(password, hashedPassword string) error {
-	switch pe.method {
-	case "hmac-bcrypt":
-		return pe.verifyHMACBcrypt(password, hashedPassword)
-	case "bcrypt":
-		return pe.verifyBcrypt(password, hashedPassword)
+	_, err := pe.VerifyAndCheckRehash(password, hashedPassword)
+	return err
+}
+
+// VerifyAndCheckRehash 验证密码，并返回该哈希是否应按当前配置重新生成
+// （方法不同、强度低于当前配置或是没有前缀的旧格式）
+func (pe *PasswordEncryptor) VerifyAndCheckRehash(password, hashedPassword string) (bool, error) {
+	switch {
+	case strings.HasPrefix(hashedPassword, hmacBcryptPrefix+"$"):
+		bcryptHash := strings.TrimPrefix(hashedPassword, hmacBcryptPrefix)
+		if err := pe.verifyHMACBcrypt(password, bcryptHash); err != nil {
+			return false, err
+		}
+		return pe.method != MethodHMACBcrypt || pe.bcryptCostTooLow(bcryptHash), nil
+
+	case strings.HasPrefix(hashedPassword, "$"+MethodArgon2id+"$"):
+		if err := verifyArgon2id([]byte(password), hashedPassword); err != nil {
+			return false, err
+		}
+		return pe.method != MethodArgon2id || pe.argon2ParamsTooWeak(hashedPassword), nil
+
+	case strings.HasPrefix(hashedPassword, "$"+MethodHMACArgon2id+"$"):
+		if err := verifyArgon2id([]byte(pe.signWithHMAC(password)), hashedPassword); err != nil {
+			return false, err
+		}
+		return pe.method != MethodHMACArgon2id || pe.argon2ParamsTooWeak(hashedPassword), nil
+
+	case strings.HasPrefix(hashedPassword, "$2"):
+		return pe.verifyUnprefixedBcrypt(password, hashedPassword)
+
 	default:
-		return fmt.Errorf("unsupported encryption method: %s", pe.method)
+		return false, fmt.Errorf("unrecognized password hash format")
 	}
 }
 
+// verifyUnprefixedBcrypt 验证没有前缀的bcrypt哈希（纯bcrypt或早期的HMAC+bcrypt）
+// 优先尝试当前配置对应的方式，减少一次bcrypt计算
+func (pe *PasswordEncryptor) verifyUnprefixedBcrypt(password, hashedPassword string) (bool, error) {
+	verifiers := []struct {
+		method string
+		verify func(password, hashedPassword string) error
+	}{
+		{MethodHMACBcrypt, pe.verifyHMACBcrypt},
+		{MethodBcrypt, pe.verifyBcrypt},
+	}
+	if pe.method == MethodBcrypt {
+		verifiers[0], verifiers[1] = verifiers[1], verifiers[0]
+	}
+
+	var lastErr error
+	for _, v := range verifiers {
+		if err := v.verify(password, hashedPassword); err != nil {
+			lastErr = err
+			continue
+		}
+		// 纯bcrypt本身就是自描述格式；早期HMAC+bcrypt需要升级为带前缀的格式
+		if v.method == MethodBcrypt && pe.method == MethodBcrypt {
+			return pe.bcryptCostTooLow(hashedPassword), nil
+		}
+		return true, nil
+	}
+
+	return false, lastErr
+}
+
+// bcryptCostTooLow bcrypt哈希的cost是否低于当前配置
+func (pe *PasswordEncryptor) bcryptCostTooLow(hashedPassword string) bool {
+	cost, err := bcrypt.Cost([]byte(hashedPassword))
+	return err != nil || cost < pe.bcryptCost
+}
+
+// argon2ParamsTooWeak Argon2id哈希的参数是否弱于当前配置
+func (pe *PasswordEncryptor) argon2ParamsTooWeak(hashedPassword string) bool {
+	params, _, _, err := decodeArgon2id(hashedPassword)
+	if err != nil {
+		return true
+	}
+	return params.Memory < pe.argon2.Memory ||
+		params.Iterations < pe.argon2.Iterations ||
+		params.Parallelism < pe.argon2.Parallelism ||
+		params.KeyLength < pe.argon2.KeyLength
+}
+
 // hashWithHMACBcrypt 使用HMAC+bcrypt双重加密
 func (pe *PasswordEncryptor) hashWithHMACBcrypt(password string) (string, error) {
 	// 第一步：使用HMAC-SHA256对密码进行签名
 	hmacSigned := pe.signWithHMAC(password)
 
 	// 第二步：对HMAC签名结果使用bcrypt加密
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(hmacSigned), bcrypt.DefaultCost)
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(hmacSigned), pe.bcryptCost)
 	if err != nil {
 		return "", fmt.Errorf("failed to bcrypt hash password: %w", err)
 	}
 
-	return string(hashedPassword), nil
+	return hmacBcryptPrefix + string(hashedPassword), nil
 }
 
-// verifyHMACBcrypt 验证HMAC+bcrypt双重加密的密码
+// verifyHMACBcrypt 验证HMAC+bcrypt双重加密的密码（hashedPassword为不带前缀的bcrypt哈希）
 func (pe *PasswordEncryptor) verifyHMACBcrypt(password, hashedPassword string) error {
 	// 第一步：使用HMAC-SHA256对输入密码进行签名
 	hmacSigned := pe.signWithHMAC(password)
@@ -105,7 +228,7 @@ func (pe *PasswordEncryptor) verifyHMACBcrypt(password, hashedPassword string) e
 
 // hashWithBcrypt 仅使用bcrypt加密（向后兼容）
 func (pe *PasswordEncryptor) hashWithBcrypt(password string) (string, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), pe.bcryptCost)
 	if err != nil {
 		return "", fmt.Errorf("failed to bcrypt hash password: %w", err)
 	}
@@ -139,6 +262,8 @@ func (pe *PasswordEncryptor) GenerateSalt() (string, error) {
 }
 
 // ValidatePasswordStrength 验证密码强度
+//
+// Deprecated: 规则固定且不检查历史和泄露密码，请使用可配置的 auth.PasswordPolicy。
 func ValidatePasswordStrength(password string) error {
 	if len(password) < 8 {
 		return fmt.Errorf("password must be at least 8 characters long")
@@ -220,6 +345,9 @@ func NewPasswordManager(encryptor *PasswordEncryptor) *PasswordManager {
 }
 
 // VerifyPasswordWithLockout 验证密码（包含锁定机制）
+//
+// Deprecated: 计数仅保存在当前进程内存中，多实例部署时无效。
+// 登录失败锁定请使用 auth.LoginLockout（基于Redis）。
 func (pm *PasswordManager) VerifyPasswordWithLockout(identifier, password, hashedPassword string) error {
 	// 检查是否被锁定
 	if lockTime, exists := pm.lockouts[identifier]; exists {
@@ -251,23 +379,27 @@ func (pm *PasswordManager) VerifyPasswordWithLockout(identifier, password, hashe
 // IsValidMethod 检查加密方法是否有效
 func IsValidMethod(method string) bool {
 	validMethods := map[string]bool{
-		"hmac-bcrypt": true,
-		"bcrypt":      true,
-		// 可以在这里添加更多支持的方法
+		MethodHMACBcrypt:   true,
+		MethodBcrypt:       true,
+		MethodArgon2id:     true,
+		MethodHMACArgon2id: true,
 	}
 	return validMethods[method]
 }
 
 // GetSupportedMethods 获取支持的加密方法列表
 func GetSupportedMethods() []string {
-	return []string{"hmac-bcrypt", "bcrypt"}
+	return []string{MethodHMACBcrypt, MethodBcrypt, MethodArgon2id, MethodHMACArgon2id}
 }
 
 // DefaultPasswordConfig 返回默认密码配置
 func DefaultPasswordConfig() *PasswordConfig {
 	return &PasswordConfig{
-		Method:     "hmac-bcrypt",
-		SaltLength: 32,
-		BcryptCost: bcrypt.DefaultCost,
+		Method:            MethodHMACBcrypt,
+		SaltLength:        32,
+		BcryptCost:        bcrypt.DefaultCost,
+		Argon2Memory:      DefaultArgon2Params.Memory,
+		Argon2Iterations:  DefaultArgon2Params.Iterations,
+		Argon2Parallelism: DefaultArgon2Params.Parallelism,
 	}
 }
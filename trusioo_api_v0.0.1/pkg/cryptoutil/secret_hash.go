@@ -0,0 +1,37 @@
+package cryptoutil
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// SecretHasher 验证码、一次性令牌等短期凭据的带密钥哈希（HMAC-SHA256）
+// 数据库中只保存哈希，没有服务端密钥时无法通过枚举6位验证码还原
+type SecretHasher struct {
+	key []byte
+}
+
+// NewSecretHasher 创建凭据哈希器
+func NewSecretHasher(key string) *SecretHasher {
+	return &SecretHasher{key: []byte(key)}
+}
+
+// Hash 计算凭据的十六进制HMAC-SHA256
+func (h *SecretHasher) Hash(value string) string {
+	mac := hmac.New(sha256.New, h.key)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Equal 以常量时间比较凭据与已保存的哈希
+func (h *SecretHasher) Equal(value, hashed string) bool {
+	expected, err := hex.DecodeString(hashed)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, h.key)
+	mac.Write([]byte(value))
+	return hmac.Equal(mac.Sum(nil), expected)
+}